@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/match/auto_ban"
+)
+
+var (
+	bansConfigFile string
+	bansController string
+	bansKey        string
+)
+
+// init registers the bans subcommands and their flags.
+func init() {
+	rootCmd.AddCommand(bansCmd)
+	bansCmd.AddCommand(bansListCmd, bansLiftCmd)
+	bansCmd.PersistentFlags().StringVar(&bansConfigFile, "config", "config.yaml", "Path to the configuration file")
+	bansCmd.PersistentFlags().StringVar(&bansController, "controller", "", "Name of the auto-ban controller")
+	bansLiftCmd.Flags().StringVar(&bansKey, "key", "", "Banned IP address, IPv6 network or ASN")
+}
+
+var bansCmd = &cobra.Command{
+	Use:   "bans",
+	Short: "Manage the bans of an auto-ban controller",
+	Long: `Manage the bans of an auto-ban controller keeping them in a Redis store.
+
+The controller and its Redis datasource are read from the configuration file.
+Bans of controllers with a memory store live in the service process: manage
+them with the bans endpoints of the admin server, enabled by admin.address.`,
+}
+
+var bansListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the active bans",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return withBans(func(ctx context.Context, store auto_ban.Store) error {
+			bans, err := store.List(ctx)
+			if err != nil {
+				return fmt.Errorf("could not list bans: %w", err)
+			}
+			now := time.Now()
+			for _, ban := range bans {
+				fmt.Printf("%s\t%s\t%s\n", ban.Key, ban.Until.UTC().Format(time.RFC3339), ban.Until.Sub(now).Round(time.Second))
+			}
+			return nil
+		})
+	},
+}
+
+var bansLiftCmd = &cobra.Command{
+	Use:   "lift",
+	Short: "Lift the ban of a key, clearing its offenses",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if bansKey == "" {
+			return fmt.Errorf("flag \"key\" is required")
+		}
+		return withBans(func(ctx context.Context, store auto_ban.Store) error {
+			lifted, err := store.Lift(ctx, bansKey)
+			if err != nil {
+				return fmt.Errorf("could not lift ban: %w", err)
+			}
+			if !lifted {
+				fmt.Printf("%s was not banned\n", bansKey)
+				return nil
+			}
+			fmt.Printf("lifted ban of %s\n", bansKey)
+			return nil
+		})
+	},
+}
+
+// withBans opens the bans store of the controller selected by the flags and runs fn on it
+func withBans(fn func(ctx context.Context, store auto_ban.Store) error) error {
+	if bansController == "" {
+		return fmt.Errorf("flag \"controller\" is required")
+	}
+
+	path, err := filepath.Abs(bansConfigFile)
+	if err != nil {
+		return fmt.Errorf("resolve config path: %w", err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	var controllerConfig *config.ControllerConfig
+	for i := range cfg.MatchControllers {
+		if cfg.MatchControllers[i].Name == bansController {
+			controllerConfig = &cfg.MatchControllers[i]
+			break
+		}
+	}
+	if controllerConfig == nil {
+		return fmt.Errorf("match controller '%s' not found in %s", bansController, path)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dataSources, err := controller.BuildDataSources(ctx, zap.NewNop(), cfg.DataSources)
+	if err != nil {
+		return fmt.Errorf("could not build datasources: %w", err)
+	}
+	defer func() { _ = dataSources.Close() }()
+
+	store, err := auto_ban.OpenBans(controller.WithDataSources(ctx, dataSources), *controllerConfig)
+	if err != nil {
+		return err
+	}
+	return fn(ctx, store)
+}
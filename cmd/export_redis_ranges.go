@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/cidrlist"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/match/attribute_match_database"
+)
+
+var (
+	redisRangesFile string
+	redisRangesKey  string
+)
+
+// init registers the export-redis-ranges subcommand and its flags.
+func init() {
+	rootCmd.AddCommand(exportRedisRangesCmd)
+	exportRedisRangesCmd.Flags().StringVar(&redisRangesFile, "file", "", "Path to the CIDR list file")
+	exportRedisRangesCmd.Flags().StringVar(&redisRangesKey, "key", "", "Redis sorted set key the ranges are added to")
+}
+
+var exportRedisRangesCmd = &cobra.Command{
+	Use:   "export-redis-ranges",
+	Short: "Print redis-cli commands loading a CIDR list into a Redis range set",
+	Long: `Print redis-cli commands loading a CIDR list into the sorted set read by the
+ip-match-database controller with the Redis range lookup.
+
+Redundant CIDRs are removed first, since ranges in the set must not overlap.
+The output can be piped into redis-cli:
+
+  envoy-authorization-service export-redis-ranges --file blocked.txt --key blocked-ranges | redis-cli`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if redisRangesFile == "" {
+			return fmt.Errorf("flag \"file\" is required")
+		}
+		if redisRangesKey == "" {
+			return fmt.Errorf("flag \"key\" is required")
+		}
+
+		data, err := os.ReadFile(redisRangesFile)
+		if err != nil {
+			return fmt.Errorf("could not read file %s: %w", redisRangesFile, err)
+		}
+
+		for _, entry := range cidrlist.Synthesize(cidrlist.Parse(string(data))).NewList {
+			member := attribute_match_database.RedisRangeMember(entry.Value, entry.Comment)
+			fmt.Printf("ZADD %s 0 %s\n", strconv.Quote(redisRangesKey), strconv.Quote(member))
+		}
+
+		return nil
+	},
+}
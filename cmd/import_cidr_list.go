@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/cidrlist"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/listsource"
+)
+
+var (
+	importProvider string
+	importSource   string
+	importServices []string
+	importRegions  []string
+	importOutput   string
+)
+
+// init registers the import-cidr-list subcommand and its flags.
+func init() {
+	rootCmd.AddCommand(importCIDRListCmd)
+	importCIDRListCmd.Flags().StringVar(&importProvider, "provider", "", "Provider feed format: "+strings.Join(cidrlist.Providers(), ", "))
+	importCIDRListCmd.Flags().StringVar(&importSource, "source", "", "Path or http(s) URL of the provider feed, defaults to the provider's published feed")
+	importCIDRListCmd.Flags().StringSliceVar(&importServices, "service", nil, "Only import ranges of these services (repeatable)")
+	importCIDRListCmd.Flags().StringSliceVar(&importRegions, "region", nil, "Only import ranges of these regions (repeatable)")
+	importCIDRListCmd.Flags().StringVar(&importOutput, "output", "", "Path of the CIDR list file to write, otherwise prints to stdout")
+}
+
+var importCIDRListCmd = &cobra.Command{
+	Use:   "import-cidr-list",
+	Short: "Build a CIDR list file from a cloud provider IP range feed",
+	Long: `Build a CIDR list file from a cloud provider IP range feed.
+
+Supported providers:
+- aws:        ip-ranges.json (filter by service and region)
+- gcp:        cloud.json (filter by service and scope)
+- azure:      ServiceTags JSON download (filter by tag, system service and region)
+- cloudflare: API response or plain-text ips-v4 list
+- fastly:     public-ip-list
+- googlebot:  googlebot.json and the other Google crawler feeds
+
+Each range is annotated with a comment describing its origin, e.g. "AWS EC2 eu-west-1".
+Only IPv4 ranges are imported.`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if importProvider == "" {
+			return fmt.Errorf("flag \"provider\" is required")
+		}
+
+		source := importSource
+		if source == "" {
+			source = cidrlist.ProviderDefaultURL(importProvider)
+		}
+		if source == "" {
+			return fmt.Errorf("flag \"source\" is required for provider %s", importProvider)
+		}
+
+		data, err := readFeed(source)
+		if err != nil {
+			return err
+		}
+
+		list, err := cidrlist.ImportProvider(importProvider, data, cidrlist.ProviderFilter{
+			Services: importServices,
+			Regions:  importRegions,
+		})
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return fmt.Errorf("no IPv4 ranges matched the provided filters")
+		}
+
+		output := cidrlist.Format(list)
+
+		if importOutput != "" {
+			if err := os.WriteFile(importOutput, []byte(output+"\n"), 0o644); err != nil {
+				return fmt.Errorf("write file %s: %w", importOutput, err)
+			}
+			return nil
+		}
+
+		fmt.Println(output)
+
+		return nil
+	},
+}
+
+// readFeed loads a provider feed from a local file or an http(s) URL.
+func readFeed(source string) ([]byte, error) {
+	if !listsource.IsRemote(source) {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("could not read file %s: %w", source, err)
+		}
+		return data, nil
+	}
+
+	client := &http.Client{Timeout: time.Minute}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch %s: %w", source, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch %s: unexpected status %d", source, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read response from %s: %w", source, err)
+	}
+	return data, nil
+}
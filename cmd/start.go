@@ -9,15 +9,18 @@ import (
 	"syscall"
 	"time"
 
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/gtriggiano/envoy-authorization-service/pkg/admin"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/logging"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/metrics"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/policy"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/ratelimit"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/service"
 
 	// Register analysis controllers
@@ -28,8 +31,18 @@ import (
 	// Register match controllers
 	_ "github.com/gtriggiano/envoy-authorization-service/pkg/match/asn_match"
 	_ "github.com/gtriggiano/envoy-authorization-service/pkg/match/asn_match_database"
+	_ "github.com/gtriggiano/envoy-authorization-service/pkg/match/attribute_match_database"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/match/auto_ban"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/match/challenge_pass"
+	_ "github.com/gtriggiano/envoy-authorization-service/pkg/match/credential_match"
+	_ "github.com/gtriggiano/envoy-authorization-service/pkg/match/geo_match"
 	_ "github.com/gtriggiano/envoy-authorization-service/pkg/match/ip_match"
 	_ "github.com/gtriggiano/envoy-authorization-service/pkg/match/ip_match_database"
+	_ "github.com/gtriggiano/envoy-authorization-service/pkg/match/jwt_match"
+	_ "github.com/gtriggiano/envoy-authorization-service/pkg/match/peer_identity_match"
+	_ "github.com/gtriggiano/envoy-authorization-service/pkg/match/rate_limit"
+	_ "github.com/gtriggiano/envoy-authorization-service/pkg/match/request_match"
+	_ "github.com/gtriggiano/envoy-authorization-service/pkg/match/signature_match"
 )
 
 var (
@@ -66,13 +79,23 @@ var startCmd = &cobra.Command{
 		runCtx, cancelRunCtx := context.WithCancel(context.Background())
 		defer cancelRunCtx()
 
-		analysisControllers, err := controller.BuildAnalysisControllers(runCtx, baseLogger.With(zap.String("component", "analysis-controller")), cfg.AnalysisControllers)
+		dataSources, err := controller.BuildDataSources(runCtx, baseLogger.With(zap.String("component", "datasource")), cfg.DataSources)
+		if err != nil {
+			logger.Error("could not build datasources", zap.Error(err))
+			return err
+		}
+		defer func() { _ = dataSources.Close() }()
+
+		// Controllers acquire the shared datasources they reference while being built
+		controllersCtx := controller.WithDataSources(runCtx, dataSources)
+
+		analysisControllers, err := controller.BuildAnalysisControllers(controllersCtx, baseLogger.With(zap.String("component", "analysis-controller")), cfg.AnalysisControllers)
 		if err != nil {
 			logger.Error("could not build analysis controllers", zap.Error(err))
 			return err
 		}
 
-		matchControllers, err := controller.BuildMatchControllers(runCtx, baseLogger.With(zap.String("component", "match-controller")), cfg.MatchControllers)
+		matchControllers, err := controller.BuildMatchControllers(controllersCtx, baseLogger.With(zap.String("component", "match-controller")), cfg.MatchControllers)
 		if err != nil {
 			logger.Error("could not build match controllers", zap.Error(err))
 			return err
@@ -84,19 +107,51 @@ var startCmd = &cobra.Command{
 			return err
 		}
 
+		// Challenges are only sent when the policy denies requests because of
+		// the challenge-pass controller
+		for _, matchController := range matchControllers {
+			if matchController.Kind() == challenge_pass.ControllerKind && authorizationPolicy != nil && !authorizationPolicy.Blames(matchController.Name()) {
+				err := fmt.Errorf("authorization policy never denies requests because of %s controller '%s', so its challenges are never sent: make it the right operand of '||', such as '!suspicious || %s'", challenge_pass.ControllerKind, matchController.Name(), matchController.Name())
+				logger.Error("invalid authorization policy", zap.Error(err))
+				return err
+			}
+		}
+
 		metricsServer := metrics.NewServer(cfg.Metrics, baseLogger.With(zap.String("component", "metrics-server")), analysisControllers, matchControllers)
 		metricsServer.SetReady(false)
 
+		for _, dataSource := range dataSources.List() {
+			if instrumented, ok := dataSource.(interface {
+				SetInstrumentation(*metrics.Instrumentation)
+			}); ok {
+				instrumented.SetInstrumentation(metricsServer.Instrumentation())
+			}
+		}
+
+		manager := service.NewManager(
+			analysisControllers,
+			matchControllers,
+			metricsServer.Instrumentation(),
+			authorizationPolicy,
+			cfg.AuthorizationPolicyBypass,
+			baseLogger.With(zap.String("component", "service-manager")),
+		)
+
+		var rateLimitService rlsv3.RateLimitServiceServer
+		if cfg.RateLimitService != nil {
+			rls, err := ratelimit.NewService(controllersCtx, *cfg.RateLimitService, manager, baseLogger.With(zap.String("component", "rate-limit-service")))
+			if err != nil {
+				logger.Error("could not build rate limit service", zap.Error(err))
+				return err
+			}
+			rls.SetInstrumentation(metricsServer.Instrumentation())
+			rateLimitService = rls
+		}
+
 		serviceServer, err := service.NewServer(
 			cfg.Server,
-			service.NewManager(
-				analysisControllers,
-				matchControllers,
-				metricsServer.Instrumentation(),
-				authorizationPolicy,
-				cfg.AuthorizationPolicyBypass,
-				baseLogger.With(zap.String("component", "service-manager")),
-			),
+			manager,
+			rateLimitService,
 			baseLogger.With(zap.String("component", "service-server")),
 		)
 		if err != nil {
@@ -114,6 +169,14 @@ var startCmd = &cobra.Command{
 			return serviceServer.Start(serversCtx, func() { metricsServer.SetReady(true) })
 		})
 
+		if cfg.Admin.Address != "" {
+			adminServer := admin.NewServer(cfg.Admin, baseLogger.With(zap.String("component", "admin-server")))
+			adminServer.Handle("/bans/", auto_ban.NewBansHandler("/bans", matchControllers))
+			serversGroup.Go(func() error {
+				return adminServer.Start(serversCtx)
+			})
+		}
+
 		sigCh := make(chan os.Signal, 1)
 		signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
 		defer signal.Stop(sigCh)
@@ -140,7 +203,9 @@ var startCmd = &cobra.Command{
 			}
 		}()
 
-		if err := serversGroup.Wait(); err != nil && serversCtx.Err() == nil {
+		err = serversGroup.Wait()
+		manager.Close()
+		if err != nil && serversCtx.Err() == nil {
 			logger.Error("server exited with error", zap.Error(err))
 			return err
 		}
@@ -23,6 +23,7 @@ const defaultConfiguration = defineConfig({
           { text: "Docker Deployment", link: "/guides/docker" },
           { text: "Kubernetes Deployment", link: "/guides/kubernetes" },
           { text: "Observability", link: "/guides/observability" },
+          { text: "Rate Limit Service", link: "/guides/rate-limit-service" },
         ],
       },
       { text: "Use Cases", link: "/examples/" },
@@ -77,6 +78,8 @@ const defaultConfiguration = defineConfig({
             { text: "Docker Deployment", link: "/guides/docker" },
             { text: "Kubernetes Deployment", link: "/guides/kubernetes" },
             { text: "Observability", link: "/guides/observability" },
+            { text: "Rate Limit Service", link: "/guides/rate-limit-service" },
+          { text: "Rate Limit Service", link: "/guides/rate-limit-service" },
           ],
         },
         {
@@ -129,6 +132,20 @@ const defaultConfiguration = defineConfig({
               text: "ASN Match Database",
               link: "/match-controllers/asn-match-database",
             },
+            {
+              text: "Attribute Match Database",
+              link: "/match-controllers/attribute-match-database",
+            },
+            { text: "Auto Ban", link: "/match-controllers/auto-ban" },
+            {
+              text: "Challenge Pass",
+              link: "/match-controllers/challenge-pass",
+            },
+            {
+              text: "Credential Match",
+              link: "/match-controllers/credential-match",
+            },
+            { text: "Geo Match", link: "/match-controllers/geo-match" },
             {
               text: "Geofence Match",
               link: "/match-controllers/geofence-match",
@@ -138,6 +155,17 @@ const defaultConfiguration = defineConfig({
               text: "IP Match Database",
               link: "/match-controllers/ip-match-database",
             },
+            { text: "JWT Match", link: "/match-controllers/jwt-match" },
+            {
+              text: "Peer Identity Match",
+              link: "/match-controllers/peer-identity-match",
+            },
+            { text: "Rate Limit", link: "/match-controllers/rate-limit" },
+            { text: "Request Match", link: "/match-controllers/request-match" },
+            {
+              text: "Signature Match",
+              link: "/match-controllers/signature-match",
+            },
           ],
         },
         {
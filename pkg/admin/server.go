@@ -0,0 +1,86 @@
+// Package admin provides the HTTP server of the administration endpoints,
+// such as the management of auto-ban bans. It listens separately from the
+// metrics server and requires a bearer token on every request.
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+)
+
+const (
+	// Server timeouts
+	defaultGracefulShutdownTimeout = 5 * time.Second
+	defaultReadHeaderTimeout       = 5 * time.Second
+)
+
+// Server exposes the administration endpoints.
+type Server struct {
+	cfg    config.AdminConfig
+	logger *zap.Logger
+	token  []byte
+	mux    *http.ServeMux
+}
+
+// NewServer builds an admin server instance. The bearer token is read from
+// the environment variable configured in admin.bearerTokenEnv.
+func NewServer(cfg config.AdminConfig, logger *zap.Logger) *Server {
+	return &Server{
+		cfg:    cfg,
+		logger: logger,
+		token:  []byte(os.Getenv(cfg.BearerTokenEnv)),
+		mux:    http.NewServeMux(),
+	}
+}
+
+// Handle registers an endpoint. It must be called before Start.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Handler returns the endpoints, refusing requests without the bearer token.
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), s.token) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		s.mux.ServeHTTP(w, r)
+	})
+}
+
+// Start launches the HTTP endpoints and blocks until context cancellation.
+func (s *Server) Start(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.cfg.Address,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: defaultReadHeaderTimeout,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), defaultGracefulShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			s.logger.Error("admin server shutdown", zap.Error(err))
+		}
+	}()
+
+	s.logger.Info("admin server listening", zap.String("addr", s.cfg.Address))
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
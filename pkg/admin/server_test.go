@@ -0,0 +1,43 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+)
+
+func TestHandlerRequiresBearerToken(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "s3cret")
+	server := NewServer(config.AdminConfig{Address: "127.0.0.1:0", BearerTokenEnv: "ADMIN_TOKEN"}, zap.NewNop())
+	server.Handle("GET /bans/{controller}", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{name: "valid token", authorization: "Bearer s3cret", want: http.StatusNoContent},
+		{name: "missing token", want: http.StatusUnauthorized},
+		{name: "wrong token", authorization: "Bearer s3cre", want: http.StatusUnauthorized},
+		{name: "other scheme", authorization: "Basic s3cret", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/bans/repeat-offenders", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			server.Handler().ServeHTTP(recorder, req)
+			if recorder.Code != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, recorder.Code)
+			}
+		})
+	}
+}
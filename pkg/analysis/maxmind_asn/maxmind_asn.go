@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/netip"
+	"os"
 	"path/filepath"
 	"sync"
 
@@ -12,6 +13,7 @@ import (
 
 	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/maxmind"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
 )
 
@@ -25,7 +27,8 @@ func init() {
 }
 
 type MaxMindAsnAnalysisConfig struct {
-	DatabasePath string `yaml:"databasePath"`
+	DatabasePath string                `yaml:"databasePath"`
+	AutoUpdate   *maxmind.UpdateConfig `yaml:"autoUpdate"`
 }
 
 type IpLookupResult struct {
@@ -53,11 +56,14 @@ func GetIpLookupResultFromReport(report *controller.AnalysisReport) *IpLookupRes
 }
 
 type maxMindAsnAnalysisController struct {
-	name    string
-	asnDb   *geoip2.Reader
-	cache   map[string]*IpLookupResult
-	cacheMu sync.RWMutex
-	logger  *zap.Logger
+	name         string
+	databasePath string
+	asnDb        *geoip2.Reader
+	asnDbClosed  bool
+	asnDbMu      sync.RWMutex
+	cache        map[string]*IpLookupResult
+	cacheMu      sync.RWMutex
+	logger       *zap.Logger
 }
 
 // Analyze implements controller.AnalysisController.
@@ -108,6 +114,11 @@ func (c *maxMindAsnAnalysisController) ipLookup(ipAddress netip.Addr) *IpLookupR
 	}
 	c.cacheMu.RUnlock()
 
+	// Hold the database read lock so a concurrent reload cannot swap the
+	// reader between the lookup and the cache update
+	c.asnDbMu.RLock()
+	defer c.asnDbMu.RUnlock()
+
 	// Cache miss - perform database lookup
 	ipLookupResult := c.databaseLookup(ipAddress)
 
@@ -121,7 +132,12 @@ func (c *maxMindAsnAnalysisController) ipLookup(ipAddress netip.Addr) *IpLookupR
 }
 
 // databaseLookup queries the MaxMind database for ASN information.
+// Callers must hold the database read lock.
 func (c *maxMindAsnAnalysisController) databaseLookup(ip netip.Addr) *IpLookupResult {
+	if c.asnDbClosed {
+		return nil
+	}
+
 	asnRecord, err := c.asnDb.ASN(ip)
 	if err != nil {
 		c.logger.Error("could not get ASN data from MaxMind database", zap.String("ip", ip.String()), zap.Error(err))
@@ -141,8 +157,50 @@ func (c *maxMindAsnAnalysisController) databaseLookup(ip netip.Addr) *IpLookupRe
 	return result
 }
 
+// reloadDatabase opens the database file written by the updater, swaps it in
+// place of the current reader and drops all cached lookups.
+func (c *maxMindAsnAnalysisController) reloadDatabase() {
+	asnDb, err := geoip2.Open(c.databasePath)
+	if err != nil {
+		c.logger.Error("could not open updated ASN database", zap.String("path", c.databasePath), zap.Error(err))
+		return
+	}
+
+	c.asnDbMu.Lock()
+	if c.asnDbClosed {
+		c.asnDbMu.Unlock()
+		_ = asnDb.Close()
+		return
+	}
+	previousDb := c.asnDb
+	c.asnDb = asnDb
+	c.cacheMu.Lock()
+	c.cache = make(map[string]*IpLookupResult)
+	c.cacheMu.Unlock()
+	c.asnDbMu.Unlock()
+
+	if err := previousDb.Close(); err != nil {
+		c.logger.Error("failed to close previous ASN database", zap.Error(err))
+	}
+
+	c.logger.Info("ASN database reloaded", zap.String("path", c.databasePath))
+}
+
+// closeDatabase releases the current reader and prevents further reloads.
+func (c *maxMindAsnAnalysisController) closeDatabase() error {
+	c.asnDbMu.Lock()
+	defer c.asnDbMu.Unlock()
+
+	if c.asnDbClosed {
+		return nil
+	}
+	c.asnDbClosed = true
+	return c.asnDb.Close()
+}
+
 // newMaxMindAsnAnalysisController loads the MaxMind ASN database file and
-// returns an analysis controller that caches lookup results.
+// returns an analysis controller that caches lookup results. When autoUpdate
+// is configured the database is refreshed from the MaxMind update service.
 func newMaxMindAsnAnalysisController(ctx context.Context, logger *zap.Logger, cfg config.ControllerConfig) (controller.AnalysisController, error) {
 	var config MaxMindAsnAnalysisConfig
 	if err := controller.DecodeControllerSettings(cfg.Settings, &config); err != nil {
@@ -158,25 +216,48 @@ func newMaxMindAsnAnalysisController(ctx context.Context, logger *zap.Logger, cf
 		return nil, fmt.Errorf("databasePath '%s' is not valid: %w", config.DatabasePath, err)
 	}
 
+	var updater *maxmind.Updater
+	if config.AutoUpdate != nil {
+		updater, err = maxmind.NewUpdater(config.AutoUpdate, databaseFilePath, logger)
+		if err != nil {
+			return nil, fmt.Errorf("invalid autoUpdate configuration: %w", err)
+		}
+
+		if _, err := updater.Update(ctx); err != nil {
+			if _, statErr := os.Stat(databaseFilePath); statErr != nil {
+				return nil, fmt.Errorf("could not download ASN database to %s: %w", databaseFilePath, err)
+			}
+			logger.Warn("could not update ASN database, using local copy", zap.String("path", databaseFilePath), zap.Error(err))
+		}
+	}
+
 	asnDb, err := geoip2.Open(databaseFilePath)
 	if err != nil {
 		return nil, fmt.Errorf("could not open ASN database at %s: %w", databaseFilePath, err)
 	}
 
+	c := &maxMindAsnAnalysisController{
+		name:         cfg.Name,
+		databasePath: databaseFilePath,
+		asnDb:        asnDb,
+		cache:        make(map[string]*IpLookupResult),
+		logger:       logger,
+	}
+
+	if updater != nil {
+		logger.Info("automatic database updates enabled", zap.String("edition", config.AutoUpdate.EditionID), zap.Duration("interval", updater.Interval()))
+		go updater.Run(ctx, c.reloadDatabase)
+	}
+
 	// Setup cleanup when context is canceled
 	go func() {
 		<-ctx.Done()
-		if err := asnDb.Close(); err != nil {
+		if err := c.closeDatabase(); err != nil {
 			logger.Error("failed to close ASN database", zap.Error(err))
 		}
 	}()
 
-	return &maxMindAsnAnalysisController{
-		name:   cfg.Name,
-		asnDb:  asnDb,
-		cache:  make(map[string]*IpLookupResult),
-		logger: logger,
-	}, nil
+	return c, nil
 }
 
 // makeUpstreamHeaders converts lookup results into headers forwarded upstream.
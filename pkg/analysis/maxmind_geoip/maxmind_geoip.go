@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/netip"
+	"os"
 	"path/filepath"
 	"sync"
 
@@ -12,6 +13,7 @@ import (
 
 	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/maxmind"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
 )
 
@@ -25,16 +27,19 @@ func init() {
 }
 
 type MaxMindCityAnalysisConfig struct {
-	DatabasePath string `yaml:"databasePath"`
+	DatabasePath string                `yaml:"databasePath"`
+	AutoUpdate   *maxmind.UpdateConfig `yaml:"autoUpdate"`
 }
 
 type IpLookupResult struct {
 	City          string
 	PostalCode    string
 	Region        string
+	RegionISO     string
 	CountryName   string
 	CountryISO    string
 	ContinentName string
+	ContinentCode string
 	TimeZone      string
 	Latitude      float64
 	Longitude     float64
@@ -60,11 +65,14 @@ func GetIpLookupResultFromReport(report *controller.AnalysisReport) *IpLookupRes
 }
 
 type maxMindCityAnalysisController struct {
-	name    string
-	cityDb  *geoip2.Reader
-	cache   map[string]*IpLookupResult
-	cacheMu sync.RWMutex
-	logger  *zap.Logger
+	name         string
+	databasePath string
+	cityDb       *geoip2.Reader
+	cityDbClosed bool
+	cityDbMu     sync.RWMutex
+	cache        map[string]*IpLookupResult
+	cacheMu      sync.RWMutex
+	logger       *zap.Logger
 }
 
 // Analyze implements controller.AnalysisController.
@@ -114,6 +122,11 @@ func (c *maxMindCityAnalysisController) ipLookup(ipAddress netip.Addr) *IpLookup
 	}
 	c.cacheMu.RUnlock()
 
+	// Hold the database read lock so a concurrent reload cannot swap the
+	// reader between the lookup and the cache update
+	c.cityDbMu.RLock()
+	defer c.cityDbMu.RUnlock()
+
 	// Cache miss - perform database lookup
 	ipLookupResult := c.databaseLookup(ipAddress)
 
@@ -127,7 +140,12 @@ func (c *maxMindCityAnalysisController) ipLookup(ipAddress netip.Addr) *IpLookup
 }
 
 // databaseLookup queries the MaxMind City database for geographic metadata.
+// Callers must hold the database read lock.
 func (c *maxMindCityAnalysisController) databaseLookup(ip netip.Addr) *IpLookupResult {
+	if c.cityDbClosed {
+		return nil
+	}
+
 	cityRecord, err := c.cityDb.City(ip)
 	if err != nil {
 		c.logger.Error("could not get GeoIP data from MaxMind database", zap.String("ip", ip.String()), zap.Error(err))
@@ -139,8 +157,10 @@ func (c *maxMindCityAnalysisController) databaseLookup(ip netip.Addr) *IpLookupR
 	}
 
 	region := ""
+	regionISO := ""
 	if len(cityRecord.Subdivisions) > 0 {
 		region = cityRecord.Subdivisions[0].Names.English
+		regionISO = cityRecord.Subdivisions[0].ISOCode
 	}
 
 	var latitude float64
@@ -154,9 +174,11 @@ func (c *maxMindCityAnalysisController) databaseLookup(ip netip.Addr) *IpLookupR
 		City:          cityRecord.City.Names.English,
 		PostalCode:    cityRecord.Postal.Code,
 		Region:        region,
+		RegionISO:     regionISO,
 		CountryName:   cityRecord.Country.Names.English,
 		CountryISO:    cityRecord.Country.ISOCode,
 		ContinentName: cityRecord.Continent.Names.English,
+		ContinentCode: cityRecord.Continent.Code,
 		TimeZone:      cityRecord.Location.TimeZone,
 		Latitude:      latitude,
 		Longitude:     longitude,
@@ -165,8 +187,50 @@ func (c *maxMindCityAnalysisController) databaseLookup(ip netip.Addr) *IpLookupR
 	return result
 }
 
+// reloadDatabase opens the database file written by the updater, swaps it in
+// place of the current reader and drops all cached lookups.
+func (c *maxMindCityAnalysisController) reloadDatabase() {
+	cityDb, err := geoip2.Open(c.databasePath)
+	if err != nil {
+		c.logger.Error("could not open updated City database", zap.String("path", c.databasePath), zap.Error(err))
+		return
+	}
+
+	c.cityDbMu.Lock()
+	if c.cityDbClosed {
+		c.cityDbMu.Unlock()
+		_ = cityDb.Close()
+		return
+	}
+	previousDb := c.cityDb
+	c.cityDb = cityDb
+	c.cacheMu.Lock()
+	c.cache = make(map[string]*IpLookupResult)
+	c.cacheMu.Unlock()
+	c.cityDbMu.Unlock()
+
+	if err := previousDb.Close(); err != nil {
+		c.logger.Error("failed to close previous City database", zap.Error(err))
+	}
+
+	c.logger.Info("City database reloaded", zap.String("path", c.databasePath))
+}
+
+// closeDatabase releases the current reader and prevents further reloads.
+func (c *maxMindCityAnalysisController) closeDatabase() error {
+	c.cityDbMu.Lock()
+	defer c.cityDbMu.Unlock()
+
+	if c.cityDbClosed {
+		return nil
+	}
+	c.cityDbClosed = true
+	return c.cityDb.Close()
+}
+
 // newMaxMindCityAnalysisController loads the MaxMind City database and returns
-// an analysis controller that caches GeoIP lookups.
+// an analysis controller that caches GeoIP lookups. When autoUpdate is
+// configured the database is refreshed from the MaxMind update service.
 func newMaxMindCityAnalysisController(ctx context.Context, logger *zap.Logger, cfg config.ControllerConfig) (controller.AnalysisController, error) {
 	var config MaxMindCityAnalysisConfig
 	if err := controller.DecodeControllerSettings(cfg.Settings, &config); err != nil {
@@ -182,25 +246,48 @@ func newMaxMindCityAnalysisController(ctx context.Context, logger *zap.Logger, c
 		return nil, fmt.Errorf("databasePath '%s' is not valid: %w", config.DatabasePath, err)
 	}
 
+	var updater *maxmind.Updater
+	if config.AutoUpdate != nil {
+		updater, err = maxmind.NewUpdater(config.AutoUpdate, databaseFilePath, logger)
+		if err != nil {
+			return nil, fmt.Errorf("invalid autoUpdate configuration: %w", err)
+		}
+
+		if _, err := updater.Update(ctx); err != nil {
+			if _, statErr := os.Stat(databaseFilePath); statErr != nil {
+				return nil, fmt.Errorf("could not download City database to %s: %w", databaseFilePath, err)
+			}
+			logger.Warn("could not update City database, using local copy", zap.String("path", databaseFilePath), zap.Error(err))
+		}
+	}
+
 	cityDb, err := geoip2.Open(databaseFilePath)
 	if err != nil {
 		return nil, fmt.Errorf("could not open City database at %s: %w", databaseFilePath, err)
 	}
 
+	c := &maxMindCityAnalysisController{
+		name:         cfg.Name,
+		databasePath: databaseFilePath,
+		cityDb:       cityDb,
+		cache:        make(map[string]*IpLookupResult),
+		logger:       logger,
+	}
+
+	if updater != nil {
+		logger.Info("automatic database updates enabled", zap.String("edition", config.AutoUpdate.EditionID), zap.Duration("interval", updater.Interval()))
+		go updater.Run(ctx, c.reloadDatabase)
+	}
+
 	// Setup cleanup when context is canceled
 	go func() {
 		<-ctx.Done()
-		if err := cityDb.Close(); err != nil {
+		if err := c.closeDatabase(); err != nil {
 			logger.Error("failed to close City database", zap.Error(err))
 		}
 	}()
 
-	return &maxMindCityAnalysisController{
-		name:   cfg.Name,
-		cityDb: cityDb,
-		cache:  make(map[string]*IpLookupResult),
-		logger: logger,
-	}, nil
+	return c, nil
 }
 
 // makeUpstreamHeaders serializes lookup results into HTTP headers to forward upstream.
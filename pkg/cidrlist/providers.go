@@ -0,0 +1,291 @@
+package cidrlist
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Supported cloud provider feed formats.
+const (
+	ProviderAWS        = "aws"
+	ProviderGCP        = "gcp"
+	ProviderAzure      = "azure"
+	ProviderCloudflare = "cloudflare"
+	ProviderFastly     = "fastly"
+	ProviderGooglebot  = "googlebot"
+)
+
+// ProviderFilter restricts which ranges of a provider feed are imported.
+// Empty slices match everything; comparisons are case-insensitive.
+type ProviderFilter struct {
+	Services []string
+	Regions  []string
+}
+
+type providerImporter struct {
+	defaultURL string
+	parse      func(data []byte, filter ProviderFilter) ([]CIDR, error)
+}
+
+var providerImporters = map[string]providerImporter{
+	ProviderAWS:        {defaultURL: "https://ip-ranges.amazonaws.com/ip-ranges.json", parse: importAWS},
+	ProviderGCP:        {defaultURL: "https://www.gstatic.com/ipranges/cloud.json", parse: importGCP},
+	ProviderAzure:      {parse: importAzure},
+	ProviderCloudflare: {defaultURL: "https://api.cloudflare.com/client/v4/ips", parse: importCloudflare},
+	ProviderFastly:     {defaultURL: "https://api.fastly.com/public-ip-list", parse: importFastly},
+	ProviderGooglebot:  {defaultURL: "https://developers.google.com/static/search/apis/ipranges/googlebot.json", parse: importGooglebot},
+}
+
+// Providers returns the names of the supported provider feed formats.
+func Providers() []string {
+	names := make([]string, 0, len(providerImporters))
+	for name := range providerImporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProviderDefaultURL returns the well-known location of a provider feed, or an
+// empty string when the provider does not publish one at a stable URL (Azure).
+func ProviderDefaultURL(provider string) string {
+	return providerImporters[provider].defaultURL
+}
+
+// ImportProvider converts a provider feed into CIDR entries annotated with a
+// comment describing their origin (for example "AWS EC2 eu-west-1"). Only IPv4
+// ranges are imported; exact duplicates keep their first occurrence.
+func ImportProvider(provider string, data []byte, filter ProviderFilter) ([]CIDR, error) {
+	importer, ok := providerImporters[provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider '%s', supported providers are %s", provider, strings.Join(Providers(), ", "))
+	}
+
+	list, err := importer.parse(data, filter)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s feed: %w", provider, err)
+	}
+
+	return dedupe(list), nil
+}
+
+// importAWS parses the AWS ip-ranges.json feed.
+func importAWS(data []byte, filter ProviderFilter) ([]CIDR, error) {
+	var feed struct {
+		Prefixes []struct {
+			IPPrefix string `json:"ip_prefix"`
+			Region   string `json:"region"`
+			Service  string `json:"service"`
+		} `json:"prefixes"`
+	}
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, err
+	}
+
+	var list []CIDR
+	for _, entry := range feed.Prefixes {
+		if !filter.matchService(entry.Service) || !filter.matchRegion(entry.Region) {
+			continue
+		}
+		list = appendPrefix(list, entry.IPPrefix, joinComment("AWS", entry.Service, entry.Region))
+	}
+	return list, nil
+}
+
+// importGCP parses the Google Cloud cloud.json feed.
+func importGCP(data []byte, filter ProviderFilter) ([]CIDR, error) {
+	var feed googleFeed
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, err
+	}
+
+	var list []CIDR
+	for _, entry := range feed.Prefixes {
+		if !filter.matchService(entry.Service) || !filter.matchRegion(entry.Scope) {
+			continue
+		}
+		list = appendPrefix(list, entry.IPv4Prefix, joinComment("GCP", entry.Service, entry.Scope))
+	}
+	return list, nil
+}
+
+// importGooglebot parses the Google crawler feeds (googlebot.json and siblings),
+// which carry neither services nor regions.
+func importGooglebot(data []byte, filter ProviderFilter) ([]CIDR, error) {
+	if err := filter.requireUnfiltered(ProviderGooglebot); err != nil {
+		return nil, err
+	}
+
+	var feed googleFeed
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, err
+	}
+
+	var list []CIDR
+	for _, entry := range feed.Prefixes {
+		list = appendPrefix(list, entry.IPv4Prefix, "Googlebot")
+	}
+	return list, nil
+}
+
+type googleFeed struct {
+	Prefixes []struct {
+		IPv4Prefix string `json:"ipv4Prefix"`
+		Service    string `json:"service"`
+		Scope      string `json:"scope"`
+	} `json:"prefixes"`
+}
+
+// importAzure parses the Azure ServiceTags JSON download. Services match the
+// tag name (e.g. "Storage.WestEurope"), its base name (e.g. "Storage") or the
+// tag's system service (e.g. "AzureStorage"); regions match the tag region.
+func importAzure(data []byte, filter ProviderFilter) ([]CIDR, error) {
+	var feed struct {
+		Values []struct {
+			Name       string `json:"name"`
+			Properties struct {
+				Region          string   `json:"region"`
+				SystemService   string   `json:"systemService"`
+				AddressPrefixes []string `json:"addressPrefixes"`
+			} `json:"properties"`
+		} `json:"values"`
+	}
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, err
+	}
+
+	var list []CIDR
+	for _, tag := range feed.Values {
+		baseName, _, _ := strings.Cut(tag.Name, ".")
+		if !filter.matchService(tag.Name, baseName, tag.Properties.SystemService) || !filter.matchRegion(tag.Properties.Region) {
+			continue
+		}
+		comment := joinComment("Azure", baseName, tag.Properties.Region)
+		for _, prefix := range tag.Properties.AddressPrefixes {
+			list = appendPrefix(list, prefix, comment)
+		}
+	}
+	return list, nil
+}
+
+// importCloudflare parses either the Cloudflare API response
+// (api.cloudflare.com/client/v4/ips) or the plain-text ips-v4 list.
+func importCloudflare(data []byte, filter ProviderFilter) ([]CIDR, error) {
+	if err := filter.requireUnfiltered(ProviderCloudflare); err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		var list []CIDR
+		for _, entry := range Parse(string(data)) {
+			list = append(list, CIDR{Value: entry.Value, Comment: "Cloudflare"})
+		}
+		return list, nil
+	}
+
+	var feed struct {
+		Success bool `json:"success"`
+		Result  struct {
+			IPv4CIDRs []string `json:"ipv4_cidrs"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, err
+	}
+	if !feed.Success {
+		return nil, fmt.Errorf("API response reports failure")
+	}
+
+	var list []CIDR
+	for _, prefix := range feed.Result.IPv4CIDRs {
+		list = appendPrefix(list, prefix, "Cloudflare")
+	}
+	return list, nil
+}
+
+// importFastly parses the Fastly public-ip-list feed.
+func importFastly(data []byte, filter ProviderFilter) ([]CIDR, error) {
+	if err := filter.requireUnfiltered(ProviderFastly); err != nil {
+		return nil, err
+	}
+
+	var feed struct {
+		Addresses []string `json:"addresses"`
+	}
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, err
+	}
+
+	var list []CIDR
+	for _, prefix := range feed.Addresses {
+		list = appendPrefix(list, prefix, "Fastly")
+	}
+	return list, nil
+}
+
+// matchService reports whether any of the candidate names passes the service filter.
+func (f ProviderFilter) matchService(candidates ...string) bool {
+	return matchAny(f.Services, candidates)
+}
+
+// matchRegion reports whether the region passes the region filter.
+func (f ProviderFilter) matchRegion(region string) bool {
+	return matchAny(f.Regions, []string{region})
+}
+
+// requireUnfiltered rejects service and region filters for feeds that carry neither.
+func (f ProviderFilter) requireUnfiltered(provider string) error {
+	if len(f.Services) > 0 || len(f.Regions) > 0 {
+		return fmt.Errorf("%s feed does not support service or region filters", provider)
+	}
+	return nil
+}
+
+func matchAny(allowed []string, candidates []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(allowed, func(value string) bool {
+		return slices.ContainsFunc(candidates, func(candidate string) bool {
+			return candidate != "" && strings.EqualFold(value, candidate)
+		})
+	})
+}
+
+// appendPrefix adds an IPv4 prefix to the list, skipping IPv6 and malformed entries.
+func appendPrefix(list []CIDR, value, comment string) []CIDR {
+	prefix, ok := parsePrefix(strings.TrimSpace(value))
+	if !ok {
+		return list
+	}
+	return append(list, CIDR{Value: prefix, Comment: comment})
+}
+
+// joinComment builds a comment from the non-empty parts, e.g. "AWS EC2 eu-west-1".
+func joinComment(parts ...string) string {
+	nonEmpty := parts[:0:0]
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
+
+// dedupe drops entries whose prefix already appeared earlier in the list.
+func dedupe(list []CIDR) []CIDR {
+	seen := make(map[string]struct{}, len(list))
+	result := make([]CIDR, 0, len(list))
+	for _, entry := range list {
+		key := entry.Value.Masked().String()
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, entry)
+	}
+	return result
+}
@@ -0,0 +1,179 @@
+package cidrlist
+
+import (
+	"strings"
+	"testing"
+)
+
+const awsFeed = `{
+  "syncToken": "1700000000",
+  "prefixes": [
+    {"ip_prefix": "3.5.140.0/22", "region": "ap-northeast-2", "service": "AMAZON", "network_border_group": "ap-northeast-2"},
+    {"ip_prefix": "52.95.245.0/24", "region": "eu-west-1", "service": "EC2", "network_border_group": "eu-west-1"},
+    {"ip_prefix": "52.94.76.0/22", "region": "us-west-2", "service": "EC2", "network_border_group": "us-west-2"},
+    {"ip_prefix": "52.95.245.0/24", "region": "eu-west-1", "service": "EC2", "network_border_group": "eu-west-1"}
+  ],
+  "ipv6_prefixes": [
+    {"ipv6_prefix": "2a05:d07a:a000::/40", "region": "eu-south-1", "service": "EC2", "network_border_group": "eu-south-1"}
+  ]
+}`
+
+const gcpFeed = `{
+  "syncToken": "1700000000",
+  "prefixes": [
+    {"ipv4Prefix": "34.1.208.0/20", "service": "Google Cloud", "scope": "africa-south1"},
+    {"ipv4Prefix": "34.35.0.0/16", "service": "Google Cloud", "scope": "europe-west1"},
+    {"ipv6Prefix": "2600:1900:8000::/44", "service": "Google Cloud", "scope": "europe-west1"}
+  ]
+}`
+
+const azureFeed = `{
+  "changeNumber": 300,
+  "cloud": "Public",
+  "values": [
+    {"name": "Storage.WestEurope", "id": "Storage.WestEurope", "properties": {"region": "westeurope", "systemService": "AzureStorage", "addressPrefixes": ["13.69.40.0/24", "2603:1020:206::/48"]}},
+    {"name": "AzureFrontDoor.Frontend", "id": "AzureFrontDoor.Frontend", "properties": {"region": "", "systemService": "AzureFrontDoor", "addressPrefixes": ["13.107.208.0/24"]}},
+    {"name": "Storage.NorthEurope", "id": "Storage.NorthEurope", "properties": {"region": "northeurope", "systemService": "AzureStorage", "addressPrefixes": ["13.70.99.0/24"]}}
+  ]
+}`
+
+const googlebotFeed = `{
+  "creationTime": "2024-01-01T00:00:00.000000",
+  "prefixes": [
+    {"ipv6Prefix": "2001:4860:4801:10::/64"},
+    {"ipv4Prefix": "66.249.64.0/27"},
+    {"ipv4Prefix": "66.249.64.32/27"}
+  ]
+}`
+
+func TestImportProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		data     string
+		filter   ProviderFilter
+		want     []string
+	}{
+		{
+			name:     "aws all",
+			provider: ProviderAWS,
+			data:     awsFeed,
+			want:     []string{"3.5.140.0/22 AWS AMAZON ap-northeast-2", "52.95.245.0/24 AWS EC2 eu-west-1", "52.94.76.0/22 AWS EC2 us-west-2"},
+		},
+		{
+			name:     "aws service and region",
+			provider: ProviderAWS,
+			data:     awsFeed,
+			filter:   ProviderFilter{Services: []string{"ec2"}, Regions: []string{"eu-west-1"}},
+			want:     []string{"52.95.245.0/24 AWS EC2 eu-west-1"},
+		},
+		{
+			name:     "gcp region",
+			provider: ProviderGCP,
+			data:     gcpFeed,
+			filter:   ProviderFilter{Regions: []string{"europe-west1"}},
+			want:     []string{"34.35.0.0/16 GCP Google Cloud europe-west1"},
+		},
+		{
+			name:     "azure base name",
+			provider: ProviderAzure,
+			data:     azureFeed,
+			filter:   ProviderFilter{Services: []string{"Storage"}},
+			want:     []string{"13.69.40.0/24 Azure Storage westeurope", "13.70.99.0/24 Azure Storage northeurope"},
+		},
+		{
+			name:     "azure system service and region",
+			provider: ProviderAzure,
+			data:     azureFeed,
+			filter:   ProviderFilter{Services: []string{"AzureStorage"}, Regions: []string{"northeurope"}},
+			want:     []string{"13.70.99.0/24 Azure Storage northeurope"},
+		},
+		{
+			name:     "azure full tag name",
+			provider: ProviderAzure,
+			data:     azureFeed,
+			filter:   ProviderFilter{Services: []string{"AzureFrontDoor.Frontend"}},
+			want:     []string{"13.107.208.0/24 Azure AzureFrontDoor"},
+		},
+		{
+			name:     "cloudflare api",
+			provider: ProviderCloudflare,
+			data:     `{"result": {"ipv4_cidrs": ["173.245.48.0/20", "103.21.244.0/22"], "ipv6_cidrs": ["2400:cb00::/32"], "etag": "abc"}, "success": true, "errors": [], "messages": []}`,
+			want:     []string{"173.245.48.0/20 Cloudflare", "103.21.244.0/22 Cloudflare"},
+		},
+		{
+			name:     "cloudflare text",
+			provider: ProviderCloudflare,
+			data:     "173.245.48.0/20\n103.21.244.0/22\n",
+			want:     []string{"173.245.48.0/20 Cloudflare", "103.21.244.0/22 Cloudflare"},
+		},
+		{
+			name:     "fastly",
+			provider: ProviderFastly,
+			data:     `{"addresses": ["23.235.32.0/20", "43.249.72.0/22"], "ipv6_addresses": ["2a04:4e40::/32"]}`,
+			want:     []string{"23.235.32.0/20 Fastly", "43.249.72.0/22 Fastly"},
+		},
+		{
+			name:     "googlebot",
+			provider: ProviderGooglebot,
+			data:     googlebotFeed,
+			want:     []string{"66.249.64.0/27 Googlebot", "66.249.64.32/27 Googlebot"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := ImportProvider(tt.provider, []byte(tt.data), tt.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := make([]string, len(list))
+			for i, entry := range list {
+				got[i] = entry.Value.String() + " " + entry.Comment
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Fatalf("unexpected import result:\ngot  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportProvider_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		data     string
+		filter   ProviderFilter
+		wantErr  string
+	}{
+		{"unknown provider", "oracle", `{}`, ProviderFilter{}, "unknown provider"},
+		{"malformed json", ProviderAWS, `{"prefixes": [`, ProviderFilter{}, "could not parse aws feed"},
+		{"cloudflare failure", ProviderCloudflare, `{"success": false}`, ProviderFilter{}, "reports failure"},
+		{"unsupported filter", ProviderFastly, `{"addresses": []}`, ProviderFilter{Regions: []string{"eu"}}, "does not support"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ImportProvider(tt.provider, []byte(tt.data), tt.filter)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestProviderDefaultURL(t *testing.T) {
+	for _, provider := range Providers() {
+		url := ProviderDefaultURL(provider)
+		if provider == ProviderAzure {
+			if url != "" {
+				t.Fatalf("expected no default URL for azure, got %s", url)
+			}
+			continue
+		}
+		if !strings.HasPrefix(url, "https://") {
+			t.Fatalf("expected https default URL for %s, got %q", provider, url)
+		}
+	}
+}
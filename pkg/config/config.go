@@ -26,8 +26,12 @@ type Config struct {
 	Server ServerConfig `yaml:"server"`
 	// Metrics configures the HTTP server for Prometheus metrics and health endpoints.
 	Metrics MetricsConfig `yaml:"metrics"`
+	// Admin configures the optional HTTP server of the administration endpoints.
+	Admin AdminConfig `yaml:"admin"`
 	// Logging configures structured logging output and levels.
 	Logging logging.Config `yaml:"logging"`
+	// DataSources defines connection pools shared by the controllers referencing them by name.
+	DataSources []DataSourceConfig `yaml:"datasources"`
 	// AnalysisControllers defines controllers that inspect requests and emit metadata.
 	AnalysisControllers []ControllerConfig `yaml:"analysisControllers"`
 	// MatchControllers defines controllers that match requests for policy evaluation.
@@ -36,6 +40,8 @@ type Config struct {
 	AuthorizationPolicy string `yaml:"authorizationPolicy"`
 	// AuthorizationPolicyBypass allows requests even when the policy evaluates to false (for testing/metrics).
 	AuthorizationPolicyBypass bool `yaml:"authorizationPolicyBypass"`
+	// RateLimitService enables the Envoy Rate Limit Service API on the gRPC listener.
+	RateLimitService *RateLimitServiceConfig `yaml:"rateLimitService"`
 	// Shutdown controls graceful shutdown behavior.
 	Shutdown ShutdownConfig `yaml:"shutdown"`
 }
@@ -76,6 +82,14 @@ type MetricsConfig struct {
 	TrackGeofence *bool `yaml:"trackGeofence"`
 }
 
+// AdminConfig controls the administration HTTP server, disabled unless an address is set.
+type AdminConfig struct {
+	// Address is the bind address for the admin HTTP server (e.g., "127.0.0.1:9091").
+	Address string `yaml:"address"`
+	// BearerTokenEnv names the environment variable holding the token required on every request.
+	BearerTokenEnv string `yaml:"bearerTokenEnv"`
+}
+
 // ControllerConfig defines one controller instance with its type and settings.
 type ControllerConfig struct {
 	// Name is the unique identifier for this controller instance.
@@ -88,6 +102,47 @@ type ControllerConfig struct {
 	Settings map[string]any `yaml:"settings"`
 }
 
+// DataSourceConfig defines one shared data source with its type and settings.
+type DataSourceConfig struct {
+	// Name is the unique identifier controllers reference the data source by.
+	Name string `yaml:"name"`
+	// Type specifies the data source kind (e.g., "postgres", "redis").
+	Type string `yaml:"type"`
+	// Settings contains data source-specific configuration as a map.
+	Settings map[string]any `yaml:"settings"`
+}
+
+// RateLimitServiceConfig defines the descriptor-based limits enforced by the
+// Envoy Rate Limit Service API.
+type RateLimitServiceConfig struct {
+	// AddressKey is the descriptor entry carrying the client IP the analysis controllers run on (default "remote_address").
+	AddressKey string `yaml:"addressKey"`
+	// UserAgentKey is the descriptor entry carrying the User-Agent the analysis controllers run on (default "user_agent").
+	UserAgentKey string `yaml:"userAgentKey"`
+	// Limits defines the limits applied to the descriptors of a domain.
+	Limits []RateLimitConfig `yaml:"limits"`
+}
+
+// RateLimitConfig defines one limit applied to the descriptors matching its entries.
+type RateLimitConfig struct {
+	// Name is the unique identifier for this limit.
+	Name string `yaml:"name"`
+	// Domain is the rate limit domain configured in Envoy.
+	Domain string `yaml:"domain"`
+	// Descriptor lists the entries a descriptor must carry for the limit to apply.
+	Descriptor []DescriptorEntryConfig `yaml:"descriptor"`
+	// Settings contains the algorithm, limit, period and store as a map.
+	Settings map[string]any `yaml:"settings"`
+}
+
+// DescriptorEntryConfig matches one descriptor entry by key and, optionally, value.
+type DescriptorEntryConfig struct {
+	// Key is the descriptor entry key.
+	Key string `yaml:"key"`
+	// Value restricts the limit to one value; when empty every value is counted separately.
+	Value string `yaml:"value"`
+}
+
 // ShutdownConfig holds graceful shutdown parameters.
 type ShutdownConfig struct {
 	// Timeout is the maximum duration to wait for graceful shutdown (e.g., "25s").
@@ -135,6 +190,14 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if err := c.Admin.validate(); err != nil {
+		return err
+	}
+
+	if err := validateDataSources(c.DataSources); err != nil {
+		return err
+	}
+
 	if err := validateControllerSet(c.AnalysisControllers, "analysis"); err != nil {
 		return err
 	}
@@ -142,6 +205,12 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if c.RateLimitService != nil {
+		if err := c.RateLimitService.validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -164,6 +233,60 @@ func validateControllerSet(ctrls []ControllerConfig, phaseLabel string) error {
 	return nil
 }
 
+// validateDataSources ensures all data sources have unique names and required fields.
+func validateDataSources(dataSources []DataSourceConfig) error {
+	names := make(map[string]struct{})
+	for _, dataSource := range dataSources {
+		if dataSource.Name == "" {
+			return errors.New("datasource name is required")
+		}
+		if dataSource.Type == "" {
+			return fmt.Errorf("datasource %s type is required", dataSource.Name)
+		}
+		if _, exists := names[dataSource.Name]; exists {
+			return fmt.Errorf("duplicate datasource name %s", dataSource.Name)
+		}
+		names[dataSource.Name] = struct{}{}
+	}
+	return nil
+}
+
+// validate ensures all limits have unique names, a domain and descriptor entries with keys.
+func (r RateLimitServiceConfig) validate() error {
+	if len(r.Limits) == 0 {
+		return errors.New("configuration 'rateLimitService.limits' requires at least one limit")
+	}
+
+	names := make(map[string]struct{})
+	for _, limit := range r.Limits {
+		if limit.Name == "" {
+			return errors.New("rate limit name is required")
+		}
+		if _, exists := names[limit.Name]; exists {
+			return fmt.Errorf("duplicate rate limit name %s", limit.Name)
+		}
+		names[limit.Name] = struct{}{}
+
+		if limit.Domain == "" {
+			return fmt.Errorf("rate limit %s domain is required", limit.Name)
+		}
+		if len(limit.Descriptor) == 0 {
+			return fmt.Errorf("rate limit %s descriptor requires at least one entry", limit.Name)
+		}
+		keys := make(map[string]struct{})
+		for _, entry := range limit.Descriptor {
+			if entry.Key == "" {
+				return fmt.Errorf("rate limit %s descriptor entry key is required", limit.Name)
+			}
+			if _, exists := keys[entry.Key]; exists {
+				return fmt.Errorf("rate limit %s descriptor has duplicate entry key %s", limit.Name, entry.Key)
+			}
+			keys[entry.Key] = struct{}{}
+		}
+	}
+	return nil
+}
+
 // applyDefaults populates configuration fields with sensible default values when they
 // are not explicitly specified in the configuration file.
 func (c *Config) applyDefaults() {
@@ -189,6 +312,15 @@ func (c *Config) applyDefaults() {
 		c.Metrics.TrackGeofence = &val
 	}
 
+	if c.RateLimitService != nil {
+		if c.RateLimitService.AddressKey == "" {
+			c.RateLimitService.AddressKey = "remote_address"
+		}
+		if c.RateLimitService.UserAgentKey == "" {
+			c.RateLimitService.UserAgentKey = "user_agent"
+		}
+	}
+
 	if c.Shutdown.Timeout == "" {
 		c.Shutdown.Timeout = "20s"
 	}
@@ -238,6 +370,20 @@ func (m MetricsConfig) validate() error {
 	return nil
 }
 
+// validate ensures an enabled admin server requires a non-empty bearer token.
+func (a AdminConfig) validate() error {
+	if a.Address == "" {
+		return nil
+	}
+	if a.BearerTokenEnv == "" {
+		return errors.New("configuration 'admin.bearerTokenEnv' is required when 'admin.address' is set")
+	}
+	if os.Getenv(a.BearerTokenEnv) == "" {
+		return fmt.Errorf("configuration 'admin.bearerTokenEnv': environment variable '%s' is not set or empty", a.BearerTokenEnv)
+	}
+	return nil
+}
+
 // IsEnabled returns true if the controller should run. Controllers are enabled by default
 // unless explicitly set to false in the configuration.
 func (c ControllerConfig) IsEnabled() bool {
@@ -60,6 +60,21 @@ metrics:
 		if cfg.Shutdown.Timeout != "20s" {
 			t.Errorf("expected default shutdown timeout '20s', got %q", cfg.Shutdown.Timeout)
 		}
+		if cfg.RateLimitService != nil {
+			t.Errorf("expected the rate limit service to stay disabled, got %+v", cfg.RateLimitService)
+		}
+	})
+
+	t.Run("applies rate limit service descriptor keys", func(t *testing.T) {
+		cfg := &Config{RateLimitService: &RateLimitServiceConfig{}}
+		cfg.applyDefaults()
+
+		if cfg.RateLimitService.AddressKey != "remote_address" {
+			t.Errorf("expected default address key 'remote_address', got %q", cfg.RateLimitService.AddressKey)
+		}
+		if cfg.RateLimitService.UserAgentKey != "user_agent" {
+			t.Errorf("expected default user agent key 'user_agent', got %q", cfg.RateLimitService.UserAgentKey)
+		}
 	})
 
 	t.Run("full configuration with all fields", func(t *testing.T) {
@@ -154,6 +169,21 @@ func TestConfigValidate(t *testing.T) {
 		}
 	})
 
+	t.Run("admin server without bearer token returns error", func(t *testing.T) {
+		t.Setenv("EMPTY_ADMIN_TOKEN", "")
+		for _, tokenEnv := range []string{"", "EMPTY_ADMIN_TOKEN", "MISSING_ADMIN_TOKEN"} {
+			cfg := &Config{
+				Server:  ServerConfig{Address: ":9001"},
+				Metrics: MetricsConfig{Address: ":9090"},
+				Admin:   AdminConfig{Address: "127.0.0.1:9091", BearerTokenEnv: tokenEnv},
+			}
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), "admin.bearerTokenEnv") {
+				t.Fatalf("expected bearer token error for %q, got %v", tokenEnv, err)
+			}
+		}
+	})
+
 	t.Run("valid minimal config passes validation", func(t *testing.T) {
 		cfg := &Config{
 			Server:  ServerConfig{Address: ":9001"},
@@ -221,6 +251,103 @@ func TestConfigValidate(t *testing.T) {
 			t.Fatalf("expected type required error, got %v", err)
 		}
 	})
+
+	t.Run("duplicate datasource names return error", func(t *testing.T) {
+		cfg := &Config{
+			Server:  ServerConfig{Address: ":9001"},
+			Metrics: MetricsConfig{Address: ":9090"},
+			DataSources: []DataSourceConfig{
+				{Name: "security-db", Type: "postgres"},
+				{Name: "security-db", Type: "redis"},
+			},
+		}
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), "duplicate datasource name security-db") {
+			t.Fatalf("expected duplicate name error, got %v", err)
+		}
+	})
+
+	t.Run("missing datasource type returns error", func(t *testing.T) {
+		cfg := &Config{
+			Server:      ServerConfig{Address: ":9001"},
+			Metrics:     MetricsConfig{Address: ":9090"},
+			DataSources: []DataSourceConfig{{Name: "security-db"}},
+		}
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), "datasource security-db type is required") {
+			t.Fatalf("expected type required error, got %v", err)
+		}
+	})
+
+	t.Run("rate limit service validation", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			limits  []RateLimitConfig
+			wantErr string
+		}{
+			{
+				name:   "valid limits",
+				limits: []RateLimitConfig{{Name: "per-ip", Domain: "edge", Descriptor: []DescriptorEntryConfig{{Key: "remote_address"}}}},
+			},
+			{
+				name:    "no limits",
+				wantErr: "requires at least one limit",
+			},
+			{
+				name:    "missing name",
+				limits:  []RateLimitConfig{{Domain: "edge", Descriptor: []DescriptorEntryConfig{{Key: "remote_address"}}}},
+				wantErr: "rate limit name is required",
+			},
+			{
+				name: "duplicate names",
+				limits: []RateLimitConfig{
+					{Name: "per-ip", Domain: "edge", Descriptor: []DescriptorEntryConfig{{Key: "remote_address"}}},
+					{Name: "per-ip", Domain: "edge", Descriptor: []DescriptorEntryConfig{{Key: "country"}}},
+				},
+				wantErr: "duplicate rate limit name per-ip",
+			},
+			{
+				name:    "missing domain",
+				limits:  []RateLimitConfig{{Name: "per-ip", Descriptor: []DescriptorEntryConfig{{Key: "remote_address"}}}},
+				wantErr: "rate limit per-ip domain is required",
+			},
+			{
+				name:    "empty descriptor",
+				limits:  []RateLimitConfig{{Name: "per-ip", Domain: "edge"}},
+				wantErr: "rate limit per-ip descriptor requires at least one entry",
+			},
+			{
+				name:    "missing entry key",
+				limits:  []RateLimitConfig{{Name: "per-ip", Domain: "edge", Descriptor: []DescriptorEntryConfig{{Value: "CN"}}}},
+				wantErr: "rate limit per-ip descriptor entry key is required",
+			},
+			{
+				name:    "duplicate entry keys",
+				limits:  []RateLimitConfig{{Name: "per-ip", Domain: "edge", Descriptor: []DescriptorEntryConfig{{Key: "country"}, {Key: "country", Value: "CN"}}}},
+				wantErr: "rate limit per-ip descriptor has duplicate entry key country",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				cfg := &Config{
+					Server:           ServerConfig{Address: ":9001"},
+					Metrics:          MetricsConfig{Address: ":9090"},
+					RateLimitService: &RateLimitServiceConfig{Limits: tt.limits},
+				}
+				err := cfg.Validate()
+				if tt.wantErr == "" {
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					return
+				}
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+			})
+		}
+	})
 }
 
 // TestTLSConfigValidation exercises TLS-specific validation logic.
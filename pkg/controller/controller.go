@@ -33,7 +33,11 @@ type MatchVerdict struct {
 	Description           string
 	IsMatch               bool
 	DenyDownstreamHeaders map[string]string
+	DenyHTTPStatus        int    // HTTP status of the deny response, derived from DenyCode when zero
+	DenyBody              string // Body of the deny response, DenyMessage when empty
+	DenyChallenge         bool   // Deny response challenges the client to authenticate or prove itself, not an offense
 	AllowUpstreamHeaders  map[string]string
+	LogFields             []zap.Field // Details of the match added to the request logs
 }
 
 // MatchVerdicts collects verdicts indexed by controller name.
@@ -55,6 +59,20 @@ type MatchController interface {
 	HealthCheck(ctx context.Context) error
 }
 
+// Decision is the outcome of the authorization policy on a request.
+type Decision struct {
+	// Allowed reports whether the policy allowed the request, regardless of bypass
+	Allowed bool
+	// Culprit is the verdict that denied the request, nil when allowed
+	Culprit *MatchVerdict
+}
+
+// DecisionObserver is implemented by match controllers learning the policy
+// decisions, such as controllers counting the denials of a client.
+type DecisionObserver interface {
+	ObserveDecision(ctx context.Context, req *runtime.RequestContext, reports AnalysisReports, decision Decision)
+}
+
 // AnalysisControllerFactory builds an analysis controller instance from configuration.
 type AnalysisControllerFactory func(ctx context.Context, logger *zap.Logger, cfg config.ControllerConfig) (AnalysisController, error)
 
@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+)
+
+// DataSource is a connection pool shared by the controllers referencing it by name.
+type DataSource interface {
+	Name() string
+	Kind() string
+	HealthCheck(ctx context.Context) error
+	Close() error
+}
+
+// DataSourceFactory builds a shared data source from configuration.
+type DataSourceFactory func(ctx context.Context, logger *zap.Logger, cfg config.DataSourceConfig) (DataSource, error)
+
+var dataSourcesRegistry = newRegistry[DataSourceFactory]()
+
+// RegisterDataSourceFactory associates a data source type with a factory.
+func RegisterDataSourceFactory(kind string, factory DataSourceFactory) {
+	if err := register(dataSourcesRegistry, kind, factory); err != nil {
+		panic(err)
+	}
+}
+
+// DataSources holds the shared data sources. Each one is reference-counted by
+// the controllers acquiring it and closed once the set is closed and the last
+// controller released it.
+type DataSources struct {
+	mu      sync.Mutex
+	entries map[string]*sharedDataSource
+	order   []string
+	closed  bool
+	logger  *zap.Logger
+}
+
+// sharedDataSource tracks the references to a data source
+type sharedDataSource struct {
+	dataSource DataSource
+	refs       int
+	health     singleflight.Group
+}
+
+// BuildDataSources creates the shared data sources from configuration definitions.
+func BuildDataSources(ctx context.Context, logger *zap.Logger, configurations []config.DataSourceConfig) (*DataSources, error) {
+	dataSources := &DataSources{
+		entries: make(map[string]*sharedDataSource, len(configurations)),
+		logger:  logger,
+	}
+	for _, configuration := range configurations {
+		factory, ok := getFactory(dataSourcesRegistry, configuration.Type)
+		if !ok {
+			_ = dataSources.Close()
+			return nil, fmt.Errorf("datasource '%s' is of unknown type '%s'", configuration.Name, configuration.Type)
+		}
+
+		dataSource, err := factory(ctx, logger.With(zap.String("datasource_type", configuration.Type), zap.String("datasource_name", configuration.Name)), configuration)
+		if err != nil {
+			_ = dataSources.Close()
+			return nil, fmt.Errorf("could not build datasource '%s' of type '%s': %w", configuration.Name, configuration.Type, err)
+		}
+		dataSources.entries[configuration.Name] = &sharedDataSource{dataSource: dataSource}
+		dataSources.order = append(dataSources.order, configuration.Name)
+	}
+	return dataSources, nil
+}
+
+// Acquire returns a reference to the named data source, which must be of the
+// given kind. The reference must be released when the controller holding it
+// stops.
+func (d *DataSources) Acquire(name, kind string) (*DataSourceHandle, error) {
+	if d == nil {
+		return nil, fmt.Errorf("datasource '%s' is not defined", name)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, ok := d.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("datasource '%s' is not defined", name)
+	}
+	if entry.dataSource.Kind() != kind {
+		return nil, fmt.Errorf("datasource '%s' is of type '%s', not '%s'", name, entry.dataSource.Kind(), kind)
+	}
+	if d.closed {
+		return nil, fmt.Errorf("datasource '%s' is closed", name)
+	}
+	entry.refs++
+	return &DataSourceHandle{owner: d, entry: entry}, nil
+}
+
+// List returns the shared data sources in configuration order.
+func (d *DataSources) List() []DataSource {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	list := make([]DataSource, 0, len(d.order))
+	for _, name := range d.order {
+		list = append(list, d.entries[name].dataSource)
+	}
+	return list
+}
+
+// Close closes the data sources no controller holds, the others as soon as
+// they are released.
+func (d *DataSources) Close() error {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.closed = true
+	var errs []error
+	for _, name := range d.order {
+		if entry := d.entries[name]; entry.refs == 0 {
+			errs = append(errs, d.closeEntry(entry))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// release drops a reference to entry, closing it if it was the last one of a closed set
+func (d *DataSources) release(entry *sharedDataSource) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry.refs--
+	if entry.refs == 0 && d.closed {
+		_ = d.closeEntry(entry)
+	}
+}
+
+// closeEntry closes the data source of entry, logging failures
+func (d *DataSources) closeEntry(entry *sharedDataSource) error {
+	err := entry.dataSource.Close()
+	if err != nil {
+		d.logger.Error("failed to close datasource", zap.String("datasource", entry.dataSource.Name()), zap.Error(err))
+	}
+	return err
+}
+
+// DataSourceHandle is a reference to a shared data source held by a controller.
+type DataSourceHandle struct {
+	owner    *DataSources
+	entry    *sharedDataSource
+	released sync.Once
+}
+
+// DataSource returns the shared data source.
+func (h *DataSourceHandle) DataSource() DataSource {
+	return h.entry.dataSource
+}
+
+// HealthCheck checks the shared data source. Concurrent checks from the
+// controllers sharing it, such as those of one readiness probe, are coalesced
+// into one.
+func (h *DataSourceHandle) HealthCheck(ctx context.Context) error {
+	_, err, _ := h.entry.health.Do("", func() (any, error) {
+		return nil, h.entry.dataSource.HealthCheck(ctx)
+	})
+	return err
+}
+
+// Release drops the reference; later calls do nothing.
+func (h *DataSourceHandle) Release() {
+	h.released.Do(func() { h.owner.release(h.entry) })
+}
+
+type dataSourcesContextKey struct{}
+
+// WithDataSources returns a context carrying the shared data sources, made
+// available to the controller factories building with it.
+func WithDataSources(ctx context.Context, dataSources *DataSources) context.Context {
+	return context.WithValue(ctx, dataSourcesContextKey{}, dataSources)
+}
+
+// DataSourcesFromContext returns the shared data sources carried by ctx, or nil.
+func DataSourcesFromContext(ctx context.Context) *DataSources {
+	dataSources, _ := ctx.Value(dataSourcesContextKey{}).(*DataSources)
+	return dataSources
+}
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+)
+
+type mockDataSource struct {
+	name         string
+	kind         string
+	healthChecks atomic.Int32
+	healthDelay  time.Duration
+	closed       atomic.Bool
+}
+
+func (m *mockDataSource) Name() string { return m.name }
+func (m *mockDataSource) Kind() string { return m.kind }
+func (m *mockDataSource) HealthCheck(ctx context.Context) error {
+	m.healthChecks.Add(1)
+	time.Sleep(m.healthDelay)
+	return nil
+}
+func (m *mockDataSource) Close() error {
+	m.closed.Store(true)
+	return nil
+}
+
+// withDataSourceRegistry swaps the data source registry for the duration of the test
+func withDataSourceRegistry(t *testing.T) {
+	oldReg := dataSourcesRegistry
+	t.Cleanup(func() {
+		dataSourcesRegistry = oldReg
+	})
+	dataSourcesRegistry = newRegistry[DataSourceFactory]()
+}
+
+func TestBuildDataSources(t *testing.T) {
+	withDataSourceRegistry(t)
+
+	var built []*mockDataSource
+	RegisterDataSourceFactory("postgres", func(ctx context.Context, logger *zap.Logger, cfg config.DataSourceConfig) (DataSource, error) {
+		if cfg.Settings["fail"] == true {
+			return nil, errors.New("connection refused")
+		}
+		dataSource := &mockDataSource{name: cfg.Name, kind: cfg.Type}
+		built = append(built, dataSource)
+		return dataSource, nil
+	})
+
+	t.Run("builds data sources in order", func(t *testing.T) {
+		dataSources, err := BuildDataSources(context.Background(), zap.NewNop(), []config.DataSourceConfig{
+			{Name: "primary", Type: "postgres"},
+			{Name: "replica", Type: "postgres"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		list := dataSources.List()
+		if len(list) != 2 || list[0].Name() != "primary" || list[1].Name() != "replica" {
+			t.Fatalf("unexpected data sources: %v", list)
+		}
+	})
+
+	t.Run("unknown type returns error", func(t *testing.T) {
+		_, err := BuildDataSources(context.Background(), zap.NewNop(), []config.DataSourceConfig{{Name: "cache", Type: "memcached"}})
+		if err == nil || !strings.Contains(err.Error(), "datasource 'cache' is of unknown type 'memcached'") {
+			t.Fatalf("expected unknown type error, got %v", err)
+		}
+	})
+
+	t.Run("factory error closes the data sources already built", func(t *testing.T) {
+		built = nil
+		_, err := BuildDataSources(context.Background(), zap.NewNop(), []config.DataSourceConfig{
+			{Name: "primary", Type: "postgres"},
+			{Name: "broken", Type: "postgres", Settings: map[string]any{"fail": true}},
+		})
+		if err == nil || !strings.Contains(err.Error(), "could not build datasource 'broken'") {
+			t.Fatalf("expected build error, got %v", err)
+		}
+		if len(built) != 1 || !built[0].closed.Load() {
+			t.Fatal("expected the first data source to be closed")
+		}
+	})
+}
+
+func TestDataSourcesAcquire(t *testing.T) {
+	shared := &mockDataSource{name: "security-db", kind: "postgres"}
+	dataSources := &DataSources{
+		entries: map[string]*sharedDataSource{"security-db": {dataSource: shared}},
+		order:   []string{"security-db"},
+		logger:  zap.NewNop(),
+	}
+
+	if _, err := dataSources.Acquire("missing", "postgres"); err == nil || !strings.Contains(err.Error(), "datasource 'missing' is not defined") {
+		t.Fatalf("expected not defined error, got %v", err)
+	}
+	if _, err := dataSources.Acquire("security-db", "redis"); err == nil || !strings.Contains(err.Error(), "is of type 'postgres', not 'redis'") {
+		t.Fatalf("expected type mismatch error, got %v", err)
+	}
+	if _, err := (*DataSources)(nil).Acquire("security-db", "postgres"); err == nil {
+		t.Fatal("expected error without data sources")
+	}
+
+	first, err := dataSources.Acquire("security-db", "postgres")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := dataSources.Acquire("security-db", "postgres")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.DataSource() != shared {
+		t.Fatal("expected the handle to expose the shared data source")
+	}
+
+	// Closing the set waits for every controller to release the data source
+	if err := dataSources.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+	first.Release()
+	first.Release()
+	if shared.closed.Load() {
+		t.Fatal("expected the data source to stay open while referenced")
+	}
+	second.Release()
+	if !shared.closed.Load() {
+		t.Fatal("expected the data source to be closed after the last release")
+	}
+
+	if _, err := dataSources.Acquire("security-db", "postgres"); err == nil || !strings.Contains(err.Error(), "is closed") {
+		t.Fatalf("expected closed error, got %v", err)
+	}
+}
+
+func TestDataSourceHandleHealthCheck(t *testing.T) {
+	shared := &mockDataSource{name: "security-db", kind: "postgres", healthDelay: 50 * time.Millisecond}
+	dataSources := &DataSources{
+		entries: map[string]*sharedDataSource{"security-db": {dataSource: shared}},
+		order:   []string{"security-db"},
+		logger:  zap.NewNop(),
+	}
+
+	var wg sync.WaitGroup
+	for range 5 {
+		handle, err := dataSources.Acquire("security-db", "postgres")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := handle.HealthCheck(context.Background()); err != nil {
+				t.Errorf("unexpected health check error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := shared.healthChecks.Load(); got != 1 {
+		t.Fatalf("expected concurrent health checks to be coalesced, got %d checks", got)
+	}
+}
+
+func TestDataSourcesFromContext(t *testing.T) {
+	if DataSourcesFromContext(context.Background()) != nil {
+		t.Fatal("expected no data sources in an empty context")
+	}
+
+	dataSources := &DataSources{}
+	if got := DataSourcesFromContext(WithDataSources(context.Background(), dataSources)); got != dataSources {
+		t.Fatal("expected the data sources carried by the context")
+	}
+}
@@ -0,0 +1,377 @@
+// Package listsource loads text lists (CIDR or ASN lists) from remote HTTP(S)
+// locations, refreshes them periodically with conditional requests and keeps
+// the last good copy on disk for cold starts.
+package listsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/metrics"
+)
+
+const (
+	DefaultRefreshInterval = time.Hour
+	DefaultRequestTimeout  = 30 * time.Second
+	maxContentSize         = 64 << 20
+)
+
+// Config configures how a remote list is refreshed and cached.
+type Config struct {
+	RefreshInterval string `yaml:"refreshInterval"`
+	MaxAge          string `yaml:"maxAge"`
+	CacheFile       string `yaml:"cacheFile"`
+	Timeout         string `yaml:"timeout"`
+}
+
+// IsRemote reports whether a list location refers to an HTTP(S) source rather than a local file.
+func IsRemote(location string) bool {
+	return strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://")
+}
+
+// Validate checks the remote list configuration for correctness.
+func (c *Config) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	if c.RefreshInterval != "" {
+		interval, err := time.ParseDuration(c.RefreshInterval)
+		if err != nil {
+			return fmt.Errorf("invalid remote.refreshInterval: %w", err)
+		}
+		if interval <= 0 {
+			return fmt.Errorf("remote.refreshInterval must be positive")
+		}
+	}
+
+	if c.MaxAge != "" {
+		maxAge, err := time.ParseDuration(c.MaxAge)
+		if err != nil {
+			return fmt.Errorf("invalid remote.maxAge: %w", err)
+		}
+		if maxAge <= 0 {
+			return fmt.Errorf("remote.maxAge must be positive")
+		}
+		if maxAge < c.GetRefreshInterval() {
+			return fmt.Errorf("remote.maxAge must not be shorter than remote.refreshInterval")
+		}
+	}
+
+	if c.Timeout != "" {
+		timeout, err := time.ParseDuration(c.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid remote.timeout: %w", err)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("remote.timeout must be positive")
+		}
+	}
+
+	return nil
+}
+
+// GetRefreshInterval returns the parsed refresh interval, or the default if not specified.
+func (c *Config) GetRefreshInterval() time.Duration {
+	if c == nil || c.RefreshInterval == "" {
+		return DefaultRefreshInterval
+	}
+	interval, err := time.ParseDuration(c.RefreshInterval)
+	if err != nil || interval <= 0 {
+		return DefaultRefreshInterval
+	}
+	return interval
+}
+
+// GetMaxAge returns the parsed maximum age, or 0 when the list never becomes stale.
+func (c *Config) GetMaxAge() time.Duration {
+	if c == nil || c.MaxAge == "" {
+		return 0
+	}
+	maxAge, _ := time.ParseDuration(c.MaxAge)
+	return maxAge
+}
+
+// GetTimeout returns the parsed request timeout, or the default if not specified.
+func (c *Config) GetTimeout() time.Duration {
+	if c == nil || c.Timeout == "" {
+		return DefaultRequestTimeout
+	}
+	timeout, err := time.ParseDuration(c.Timeout)
+	if err != nil || timeout <= 0 {
+		return DefaultRequestTimeout
+	}
+	return timeout
+}
+
+// ApplyFunc receives freshly loaded list content. Returning an error rejects
+// the content, which is then neither used nor persisted.
+type ApplyFunc func(content []byte) error
+
+// Source fetches a remote list and keeps it up to date.
+type Source struct {
+	url             string
+	cacheFile       string
+	refreshInterval time.Duration
+	maxAge          time.Duration
+	client          *http.Client
+	controllerName  string
+	controllerKind  string
+	logger          *zap.Logger
+
+	mu              sync.RWMutex
+	etag            string
+	lastModified    string
+	lastSuccess     time.Time
+	instrumentation *metrics.Instrumentation
+}
+
+// New creates a source for the list located at rawURL.
+func New(rawURL string, config *Config, controllerName, controllerKind string, logger *zap.Logger) (*Source, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || parsedURL.Host == "" || (parsedURL.Scheme != "https" && parsedURL.Scheme != "http") {
+		return nil, fmt.Errorf("'%s' is not a valid http(s) URL", rawURL)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	var cacheFile string
+	if config != nil && config.CacheFile != "" {
+		cacheFile, err = filepath.Abs(config.CacheFile)
+		if err != nil {
+			return nil, fmt.Errorf("remote.cacheFile path is not valid: %w", err)
+		}
+	}
+
+	return &Source{
+		url:             rawURL,
+		cacheFile:       cacheFile,
+		refreshInterval: config.GetRefreshInterval(),
+		maxAge:          config.GetMaxAge(),
+		client:          &http.Client{Timeout: config.GetTimeout()},
+		controllerName:  controllerName,
+		controllerKind:  controllerKind,
+		logger:          logger.With(zap.String("list_url", rawURL)),
+	}, nil
+}
+
+// SetInstrumentation injects the shared metrics instrumentation.
+func (s *Source) SetInstrumentation(inst *metrics.Instrumentation) {
+	s.mu.Lock()
+	s.instrumentation = inst
+	lastSuccess := s.lastSuccess
+	s.mu.Unlock()
+
+	if !lastSuccess.IsZero() {
+		inst.ObserveListSourceLastSuccess(s.controllerName, s.controllerKind, lastSuccess)
+	}
+}
+
+// Start loads the list, falling back to the on-disk copy when the remote is
+// unreachable, and then refreshes it in the background until ctx is canceled.
+func (s *Source) Start(ctx context.Context, apply ApplyFunc) error {
+	if err := s.refresh(ctx, apply); err != nil {
+		if cacheErr := s.loadCacheFile(apply); cacheErr != nil {
+			return fmt.Errorf("could not fetch list (%w) and no usable cached copy is available (%w)", err, cacheErr)
+		}
+		s.logger.Warn("could not fetch list, using cached copy", zap.String("cache_file", s.cacheFile), zap.Error(err))
+	}
+
+	go s.run(ctx, apply)
+	return nil
+}
+
+// HealthCheck reports an error when the list has not been refreshed successfully within maxAge.
+func (s *Source) HealthCheck() error {
+	if s.maxAge <= 0 {
+		return nil
+	}
+
+	s.mu.RLock()
+	lastSuccess := s.lastSuccess
+	s.mu.RUnlock()
+
+	if age := time.Since(lastSuccess); age > s.maxAge {
+		return fmt.Errorf("list from %s is stale: last successful refresh %s ago exceeds maxAge %s", s.url, age.Truncate(time.Second), s.maxAge)
+	}
+	return nil
+}
+
+// run refreshes the list on every tick until the context is canceled.
+func (s *Source) run(ctx context.Context, apply ApplyFunc) {
+	ticker := time.NewTicker(s.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.refresh(ctx, apply); err != nil && ctx.Err() == nil {
+				s.logger.Warn("could not refresh list", zap.Error(err))
+			}
+		}
+	}
+}
+
+// refresh performs one conditional fetch and applies the content when it changed.
+func (s *Source) refresh(ctx context.Context, apply ApplyFunc) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return fmt.Errorf("could not build request: %w", err)
+	}
+
+	s.mu.RLock()
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	if s.lastModified != "" {
+		req.Header.Set("If-Modified-Since", s.lastModified)
+	}
+	s.mu.RUnlock()
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.observeRefresh(metrics.ERROR)
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		s.markSuccess(time.Now())
+		s.touchCacheFile()
+		s.observeRefresh(metrics.NOT_MODIFIED)
+		s.logger.Debug("list not modified")
+		return nil
+	case http.StatusOK:
+	default:
+		s.observeRefresh(metrics.ERROR)
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxContentSize+1))
+	if err != nil {
+		s.observeRefresh(metrics.ERROR)
+		return fmt.Errorf("could not read response body: %w", err)
+	}
+	if len(content) > maxContentSize {
+		s.observeRefresh(metrics.ERROR)
+		return fmt.Errorf("list exceeds the maximum size of %d bytes", maxContentSize)
+	}
+
+	if err := apply(content); err != nil {
+		s.observeRefresh(metrics.ERROR)
+		return fmt.Errorf("list rejected: %w", err)
+	}
+
+	s.mu.Lock()
+	s.etag = resp.Header.Get("ETag")
+	s.lastModified = resp.Header.Get("Last-Modified")
+	s.mu.Unlock()
+
+	s.markSuccess(time.Now())
+	s.writeCacheFile(content)
+	s.observeRefresh(metrics.UPDATED)
+	s.logger.Info("list updated", zap.Int("bytes", len(content)))
+	return nil
+}
+
+// loadCacheFile applies the on-disk copy of the list, treating its modification time as the last success.
+func (s *Source) loadCacheFile(apply ApplyFunc) error {
+	if s.cacheFile == "" {
+		return errors.New("remote.cacheFile is not configured")
+	}
+
+	info, err := os.Stat(s.cacheFile)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(s.cacheFile)
+	if err != nil {
+		return err
+	}
+
+	if err := apply(content); err != nil {
+		return fmt.Errorf("cached list rejected: %w", err)
+	}
+
+	s.markSuccess(info.ModTime())
+	return nil
+}
+
+// writeCacheFile atomically persists the last good copy of the list.
+func (s *Source) writeCacheFile(content []byte) {
+	if s.cacheFile == "" {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.cacheFile), 0o755); err != nil {
+		s.logger.Warn("could not create cache directory", zap.String("cache_file", s.cacheFile), zap.Error(err))
+		return
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(s.cacheFile), "."+filepath.Base(s.cacheFile)+"-*.tmp")
+	if err != nil {
+		s.logger.Warn("could not create temporary cache file", zap.String("cache_file", s.cacheFile), zap.Error(err))
+		return
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		s.logger.Warn("could not write cache file", zap.String("cache_file", s.cacheFile), zap.Error(err))
+		return
+	}
+	if err := tmpFile.Close(); err != nil {
+		s.logger.Warn("could not write cache file", zap.String("cache_file", s.cacheFile), zap.Error(err))
+		return
+	}
+	if err := os.Rename(tmpPath, s.cacheFile); err != nil {
+		s.logger.Warn("could not replace cache file", zap.String("cache_file", s.cacheFile), zap.Error(err))
+	}
+}
+
+// touchCacheFile bumps the cache file modification time so cold starts see an accurate age.
+func (s *Source) touchCacheFile() {
+	if s.cacheFile == "" {
+		return
+	}
+	now := time.Now()
+	if err := os.Chtimes(s.cacheFile, now, now); err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.logger.Debug("could not touch cache file", zap.String("cache_file", s.cacheFile), zap.Error(err))
+	}
+}
+
+// markSuccess records the time of a successful load.
+func (s *Source) markSuccess(at time.Time) {
+	s.mu.Lock()
+	s.lastSuccess = at
+	inst := s.instrumentation
+	s.mu.Unlock()
+
+	inst.ObserveListSourceLastSuccess(s.controllerName, s.controllerKind, at)
+}
+
+func (s *Source) observeRefresh(result string) {
+	s.mu.RLock()
+	inst := s.instrumentation
+	s.mu.RUnlock()
+
+	inst.ObserveListSourceRefresh(s.controllerName, s.controllerKind, result)
+}
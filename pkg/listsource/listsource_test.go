@@ -0,0 +1,276 @@
+package listsource
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/metrics"
+)
+
+type testListServer struct {
+	*httptest.Server
+	mu          sync.Mutex
+	content     string
+	etag        string
+	status      int
+	conditional atomic.Int32
+}
+
+// newTestListServer serves a text list honouring If-None-Match against its current ETag.
+func newTestListServer(t *testing.T, content, etag string) *testListServer {
+	t.Helper()
+
+	server := &testListServer{content: content, etag: etag}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+
+		if server.status != 0 {
+			w.WriteHeader(server.status)
+			return
+		}
+		if match := r.Header.Get("If-None-Match"); match != "" {
+			server.conditional.Add(1)
+			if match == server.etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.Header().Set("ETag", server.etag)
+		_, _ = w.Write([]byte(server.content))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (s *testListServer) set(content, etag string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.content = content
+	s.etag = etag
+	s.status = status
+}
+
+type recordedList struct {
+	mu      sync.Mutex
+	content string
+	applied int
+}
+
+func (r *recordedList) apply(content []byte) error {
+	if strings.TrimSpace(string(content)) == "" {
+		return errors.New("empty list")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.content = string(content)
+	r.applied++
+	return nil
+}
+
+func (r *recordedList) snapshot() (string, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.content, r.applied
+}
+
+func TestSource_FetchesAndRefreshesConditionally(t *testing.T) {
+	server := newTestListServer(t, "10.0.0.0/8", `"v1"`)
+	cacheFile := filepath.Join(t.TempDir(), "list.txt")
+
+	source, err := New(server.URL, &Config{CacheFile: cacheFile}, "test", "ip-match", zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	reg := prometheus.NewRegistry()
+	inst := metrics.NewInstrumentation(reg, metrics.TrackOptions{})
+	source.SetInstrumentation(inst)
+
+	list := &recordedList{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := source.Start(ctx, list.apply); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := list.snapshot(); content != "10.0.0.0/8" {
+		t.Fatalf("expected initial content, got %q", content)
+	}
+
+	cached, err := os.ReadFile(cacheFile)
+	if err != nil || string(cached) != "10.0.0.0/8" {
+		t.Fatalf("expected cache file to hold the list, got %q (%v)", cached, err)
+	}
+
+	if err := source.refresh(ctx, list.apply); err != nil {
+		t.Fatalf("unexpected refresh error: %v", err)
+	}
+	if _, applied := list.snapshot(); applied != 1 {
+		t.Fatalf("expected unchanged list not to be re-applied, got %d applications", applied)
+	}
+	if server.conditional.Load() != 1 {
+		t.Fatalf("expected a conditional request, got %d", server.conditional.Load())
+	}
+
+	server.set("192.168.0.0/16", `"v2"`, 0)
+	if err := source.refresh(ctx, list.apply); err != nil {
+		t.Fatalf("unexpected refresh error: %v", err)
+	}
+	if content, _ := list.snapshot(); content != "192.168.0.0/16" {
+		t.Fatalf("expected refreshed content, got %q", content)
+	}
+
+	if v := testutil.ToFloat64(inst.ListSourceRefreshes().WithLabelValues("test", "ip-match", metrics.UPDATED)); v != 2 {
+		t.Fatalf("expected 2 updated refreshes, got %v", v)
+	}
+	if v := testutil.ToFloat64(inst.ListSourceRefreshes().WithLabelValues("test", "ip-match", metrics.NOT_MODIFIED)); v != 1 {
+		t.Fatalf("expected 1 not-modified refresh, got %v", v)
+	}
+}
+
+func TestSource_KeepsListWhenRefreshFails(t *testing.T) {
+	server := newTestListServer(t, "10.0.0.0/8", `"v1"`)
+	source, err := New(server.URL, nil, "test", "ip-match", zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	list := &recordedList{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := source.Start(ctx, list.apply); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	server.set("", `"v2"`, http.StatusInternalServerError)
+	if err := source.refresh(ctx, list.apply); err == nil {
+		t.Fatal("expected refresh error")
+	}
+
+	server.set("   ", `"v3"`, 0)
+	if err := source.refresh(ctx, list.apply); err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Fatalf("expected rejected list error, got %v", err)
+	}
+
+	if content, _ := list.snapshot(); content != "10.0.0.0/8" {
+		t.Fatalf("expected last good list to remain active, got %q", content)
+	}
+}
+
+func TestSource_FallsBackToCacheFile(t *testing.T) {
+	server := newTestListServer(t, "", "")
+	server.set("", "", http.StatusServiceUnavailable)
+
+	cacheFile := filepath.Join(t.TempDir(), "list.txt")
+	if err := os.WriteFile(cacheFile, []byte("172.16.0.0/12"), 0o644); err != nil {
+		t.Fatalf("failed to write cache file: %v", err)
+	}
+	staleTime := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(cacheFile, staleTime, staleTime); err != nil {
+		t.Fatalf("failed to age cache file: %v", err)
+	}
+
+	source, err := New(server.URL, &Config{CacheFile: cacheFile, MaxAge: "1h", RefreshInterval: "10m"}, "test", "ip-match", zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	list := &recordedList{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := source.Start(ctx, list.apply); err != nil {
+		t.Fatalf("expected cached copy to be used, got %v", err)
+	}
+	if content, _ := list.snapshot(); content != "172.16.0.0/12" {
+		t.Fatalf("expected cached content, got %q", content)
+	}
+
+	if err := source.HealthCheck(); err == nil || !strings.Contains(err.Error(), "stale") {
+		t.Fatalf("expected stale health check error, got %v", err)
+	}
+
+	server.set("172.16.0.0/12", `"v1"`, 0)
+	if err := source.refresh(ctx, list.apply); err != nil {
+		t.Fatalf("unexpected refresh error: %v", err)
+	}
+	if err := source.HealthCheck(); err != nil {
+		t.Fatalf("expected healthy source after refresh, got %v", err)
+	}
+}
+
+func TestSource_FailsWithoutRemoteOrCache(t *testing.T) {
+	server := newTestListServer(t, "", "")
+	server.set("", "", http.StatusNotFound)
+
+	source, err := New(server.URL, nil, "test", "ip-match", zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	if err := source.Start(context.Background(), (&recordedList{}).apply); err == nil {
+		t.Fatal("expected start to fail")
+	}
+}
+
+func TestNew_InvalidURL(t *testing.T) {
+	for _, location := range []string{"ftp://example.com/list.txt", "https://", "://bad"} {
+		if _, err := New(location, nil, "test", "ip-match", zap.NewNop()); err == nil {
+			t.Fatalf("expected error for %q", location)
+		}
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *Config
+		wantErr string
+	}{
+		{"nil", nil, ""},
+		{"valid", &Config{RefreshInterval: "5m", MaxAge: "1h", Timeout: "10s"}, ""},
+		{"invalid interval", &Config{RefreshInterval: "often"}, "invalid remote.refreshInterval"},
+		{"negative interval", &Config{RefreshInterval: "-1m"}, "must be positive"},
+		{"invalid max age", &Config{MaxAge: "old"}, "invalid remote.maxAge"},
+		{"max age below interval", &Config{RefreshInterval: "1h", MaxAge: "10m"}, "must not be shorter"},
+		{"invalid timeout", &Config{Timeout: "0s"}, "remote.timeout must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestIsRemote(t *testing.T) {
+	if !IsRemote("https://example.com/list.txt") || !IsRemote("http://example.com/list.txt") {
+		t.Fatal("expected http(s) locations to be remote")
+	}
+	if IsRemote("config/list.txt") || IsRemote("/etc/list.txt") {
+		t.Fatal("expected file paths not to be remote")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
@@ -13,6 +14,8 @@ import (
 	"github.com/gtriggiano/envoy-authorization-service/pkg/asnlist"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/listsource"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/metrics"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
 )
 
@@ -27,13 +30,23 @@ func init() {
 }
 
 type ASNMatchConfig struct {
-	ASNList string `yaml:"asnList"`
+	ASNList string             `yaml:"asnList"`
+	Remote  *listsource.Config `yaml:"remote"`
 }
 
 type asnMatchController struct {
-	name   string
-	asnMap map[uint]string
-	logger *zap.Logger
+	name     string
+	asnMap   map[uint]string
+	asnMapMu sync.RWMutex
+	source   *listsource.Source // nil when the list is read from a local file
+	logger   *zap.Logger
+}
+
+// SetInstrumentation injects the shared metrics instrumentation.
+func (c *asnMatchController) SetInstrumentation(inst *metrics.Instrumentation) {
+	if c.source != nil {
+		c.source.SetInstrumentation(inst)
+	}
 }
 
 // Match implements controller.MatchController.
@@ -61,6 +74,9 @@ func (c *asnMatchController) Kind() string {
 
 // HealthCheck implements controller.MatchController.
 func (c *asnMatchController) HealthCheck(ctx context.Context) error {
+	if c.source != nil {
+		return c.source.HealthCheck()
+	}
 	// No external dependencies to check
 	return nil
 }
@@ -80,7 +96,9 @@ func (c *asnMatchController) deriveMatch(reports controller.AnalysisReports) (bo
 		return false, "no ASN information available"
 	}
 
+	c.asnMapMu.RLock()
 	asnComment, asnMatched := c.asnMap[ipLookupResult.AutonomousSystemNumber]
+	c.asnMapMu.RUnlock()
 
 	if asnMatched {
 		if asnComment != "" {
@@ -95,8 +113,33 @@ func (c *asnMatchController) deriveMatch(reports controller.AnalysisReports) (bo
 	return false, fmt.Sprintf("AS %d %s did not match list", ipLookupResult.AutonomousSystemNumber, ipLookupResult.AutonomousSystemOrganization)
 }
 
+// applyASNList parses remote list content and replaces the active ASN map.
+func (c *asnMatchController) applyASNList(content []byte) error {
+	asnMap := buildASNMap(string(content))
+	if len(asnMap) == 0 {
+		return fmt.Errorf("list contains no valid AS numbers")
+	}
+
+	c.asnMapMu.Lock()
+	c.asnMap = asnMap
+	c.asnMapMu.Unlock()
+
+	c.logger.Debug("ASN list applied", zap.Int("entries", len(asnMap)))
+	return nil
+}
+
+// buildASNMap parses and deduplicates an ASN list into a lookup map of comments.
+func buildASNMap(text string) map[uint]string {
+	asnMap := make(map[uint]string)
+	for _, entry := range asnlist.Synthesize(asnlist.Parse(text)).NewList {
+		asnMap[entry.Number] = entry.Comment
+	}
+	return asnMap
+}
+
 // newASNMatchController loads the ASN list from disk and prepares a controller.
-func newASNMatchController(_ context.Context, logger *zap.Logger, cfg config.ControllerConfig) (controller.MatchController, error) {
+// When asnList is an http(s) URL the list is fetched and refreshed in the background.
+func newASNMatchController(ctx context.Context, logger *zap.Logger, cfg config.ControllerConfig) (controller.MatchController, error) {
 	var config ASNMatchConfig
 	if err := controller.DecodeControllerSettings(cfg.Settings, &config); err != nil {
 		return nil, err
@@ -106,6 +149,28 @@ func newASNMatchController(_ context.Context, logger *zap.Logger, cfg config.Con
 		return nil, fmt.Errorf("asnList is required, check your configuration")
 	}
 
+	if listsource.IsRemote(config.ASNList) {
+		ctrl := &asnMatchController{
+			name:   cfg.Name,
+			logger: logger,
+		}
+
+		source, err := listsource.New(config.ASNList, config.Remote, cfg.Name, ControllerKind, logger)
+		if err != nil {
+			return nil, fmt.Errorf("asnList source is not valid: %w", err)
+		}
+		if err := source.Start(ctx, ctrl.applyASNList); err != nil {
+			return nil, fmt.Errorf("could not load asnList: %w", err)
+		}
+		ctrl.source = source
+
+		return ctrl, nil
+	}
+
+	if config.Remote != nil {
+		return nil, fmt.Errorf("remote settings require asnList to be an http(s) URL")
+	}
+
 	asnListFilePath, err := filepath.Abs(config.ASNList)
 	if err != nil {
 		return nil, fmt.Errorf("asnList path is not valid: %w", err)
@@ -116,14 +181,9 @@ func newASNMatchController(_ context.Context, logger *zap.Logger, cfg config.Con
 		return nil, fmt.Errorf("could not read asnList file: %w", err)
 	}
 
-	asnMap := make(map[uint]string)
-	for _, entry := range asnlist.Synthesize(asnlist.Parse(string(asnListFileContent))).NewList {
-		asnMap[entry.Number] = entry.Comment
-	}
-
 	return &asnMatchController{
 		name:   cfg.Name,
-		asnMap: asnMap,
+		asnMap: buildASNMap(string(asnListFileContent)),
 		logger: logger,
 	}, nil
 }
@@ -2,6 +2,8 @@ package asn_match
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -71,6 +73,42 @@ func TestNewASNMatchController_InvalidPath(t *testing.T) {
 	}
 }
 
+func TestNewASNMatchController_RemoteASNList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("# ExampleNet\nAS64500"))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl, err := newASNMatchController(ctx, zap.NewNop(), config.ControllerConfig{
+		Name: "remote",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"asnList": server.URL + "/asns.txt",
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create controller: %v", err)
+	}
+
+	if verdict := matchASN(t, ctrl, 64500); !verdict.IsMatch {
+		t.Fatalf("expected match from remote list, got %s", verdict.Description)
+	}
+
+	c := ctrl.(*asnMatchController)
+	if err := c.applyASNList([]byte("not a list")); err == nil {
+		t.Fatal("expected list without AS numbers to be rejected")
+	}
+	if err := c.applyASNList([]byte("AS64501")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if verdict := matchASN(t, ctrl, 64500); verdict.IsMatch {
+		t.Fatal("expected AS64500 to no longer match after list update")
+	}
+}
+
 // helpers
 func createTestController(t *testing.T, asnList string) controller.MatchController {
 	t.Helper()
@@ -15,6 +15,7 @@ import (
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
 	"github.com/jackc/pgx/v5"
+	dockercontainer "github.com/moby/moby/api/types/container"
 	"github.com/redis/go-redis/v9"
 	"github.com/testcontainers/testcontainers-go"
 	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
@@ -23,6 +24,7 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
 
+	"github.com/gtriggiano/envoy-authorization-service/pkg/analysis/maxmind_asn"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
@@ -78,6 +80,89 @@ func TestRedisAsnMatchDatabase(t *testing.T) {
 	}
 }
 
+func TestRedisSentinelAsnMatchDatabase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, sentinelAddr := startRedisSentinel(t, ctx)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	client := redis.NewFailoverClient(&redis.FailoverOptions{
+		MasterName:    "mymaster",
+		SentinelAddrs: []string{sentinelAddr},
+	})
+	defer client.Close()
+	requireNoErr(t, client.Set(ctx, "asn:block:13335", "1", 0).Err())
+	// Make sure the replica has the key before reading from it
+	requireNoErr(t, client.Do(ctx, "WAIT", 1, 5000).Err())
+
+	for _, readFromReplica := range []bool{false, true} {
+		t.Run(fmt.Sprintf("readFromReplica=%v", readFromReplica), func(t *testing.T) {
+			ctrl := buildController(t, ctx, zaptest.NewLogger(t), config.ControllerConfig{
+				Name: "asn-db-redis-sentinel",
+				Type: ControllerKind,
+				Settings: map[string]any{
+					"database": map[string]any{
+						"type":              "redis",
+						"connectionTimeout": "5s",
+						"redis": map[string]any{
+							"mode":            "sentinel",
+							"keyPrefix":       "asn:block:",
+							"readFromReplica": readFromReplica,
+							"sentinel": map[string]any{
+								"masterName": "mymaster",
+								"addresses":  []string{sentinelAddr},
+							},
+						},
+					},
+				},
+			})
+
+			requireMatch(t, ctx, ctrl, 13335, true)
+			requireMatch(t, ctx, ctrl, 15169, false)
+		})
+	}
+}
+
+func TestRedisClusterAsnMatchDatabase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, seedAddrs := startRedisCluster(t, ctx)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: seedAddrs})
+	defer client.Close()
+	requireNoErr(t, client.Set(ctx, "asn:block:13335", "1", 0).Err())
+
+	for _, readFromReplica := range []bool{false, true} {
+		t.Run(fmt.Sprintf("readFromReplica=%v", readFromReplica), func(t *testing.T) {
+			ctrl := buildController(t, ctx, zaptest.NewLogger(t), config.ControllerConfig{
+				Name: "asn-db-redis-cluster",
+				Type: ControllerKind,
+				Settings: map[string]any{
+					"database": map[string]any{
+						"type":              "redis",
+						"connectionTimeout": "5s",
+						"redis": map[string]any{
+							"mode":            "cluster",
+							"keyPrefix":       "asn:block:",
+							"readFromReplica": readFromReplica,
+							"cluster": map[string]any{
+								"addresses": seedAddrs,
+							},
+						},
+					},
+				},
+			})
+
+			// Replicas catch up asynchronously, so allow the first lookups to miss
+			requireEventualMatch(t, ctx, ctrl, 13335)
+			requireMatch(t, ctx, ctrl, 15169, false)
+		})
+	}
+}
+
 func TestPostgresAsnMatchDatabase(t *testing.T) {
 	t.Parallel()
 
@@ -149,6 +234,272 @@ func TestPostgresAsnMatchDatabase(t *testing.T) {
 
 // --- helpers ---
 
+func TestRedisKeyspaceInvalidationAsnMatchDatabase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, host, port := startRedis(t, ctx)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	client := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:%d", host, port)})
+	requireNoErr(t, client.ConfigSet(ctx, "notify-keyspace-events", "Kgx$").Err())
+	requireNoErr(t, client.Set(ctx, "asn:block:13335", "1", 0).Err())
+
+	ctrlCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctrl := buildController(t, ctrlCtx, zaptest.NewLogger(t), config.ControllerConfig{
+		Name: "asn-db-redis-keyspace",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"cache": map[string]any{
+				"ttl": "1h",
+			},
+			"database": map[string]any{
+				"type": "redis",
+				"redis": map[string]any{
+					"keyPrefix":             "asn:block:",
+					"host":                  host,
+					"port":                  port,
+					"keyspaceNotifications": true,
+				},
+			},
+		},
+	})
+
+	requireEventualMatch(t, ctx, ctrl, 13335)
+
+	// Despite the 1h TTL the deletion is picked up through the keyspace event
+	requireNoErr(t, client.Del(ctx, "asn:block:13335").Err())
+	requireEventualMiss(t, ctx, ctrl, 13335)
+}
+
+func TestPostgresNotifyInvalidationAsnMatchDatabase(t *testing.T) {
+	t.Parallel()
+
+	userEnv := "ASN_PG_USER_" + sanitizeEnvName(t.Name())
+	passEnv := "ASN_PG_PASS_" + sanitizeEnvName(t.Name())
+	setEnvForTest(t, userEnv, "postgres")
+	setEnvForTest(t, passEnv, "postgres")
+
+	ctx := context.Background()
+	container, host, port := startPostgres(t, ctx)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	dsn := fmt.Sprintf("postgres://postgres:postgres@%s:%d/security?sslmode=disable", host, port)
+	conn, err := pgx.Connect(ctx, dsn)
+	requireNoErr(t, err)
+	t.Cleanup(func() { _ = conn.Close(ctx) })
+
+	_, err = conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS trusted_asns (asn bigint PRIMARY KEY);
+		INSERT INTO trusted_asns (asn) VALUES (13335) ON CONFLICT DO NOTHING;
+
+		CREATE OR REPLACE FUNCTION notify_trusted_asns() RETURNS trigger AS $$
+		BEGIN
+			PERFORM pg_notify('trusted_asns_changed', COALESCE(NEW.asn, OLD.asn)::text);
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE TRIGGER trusted_asns_changed AFTER INSERT OR UPDATE OR DELETE ON trusted_asns
+			FOR EACH ROW EXECUTE FUNCTION notify_trusted_asns();
+	`)
+	requireNoErr(t, err)
+
+	ctrlCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctrl := buildController(t, ctrlCtx, zaptest.NewLogger(t), config.ControllerConfig{
+		Name: "asn-db-postgres-notify",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"cache": map[string]any{
+				"ttl": "1h",
+			},
+			"database": map[string]any{
+				"type":              "postgres",
+				"connectionTimeout": "1s",
+				"postgres": map[string]any{
+					"query":         "SELECT 1 FROM trusted_asns WHERE asn = $1 LIMIT 1",
+					"host":          host,
+					"port":          port,
+					"databaseName":  "security",
+					"usernameEnv":   userEnv,
+					"passwordEnv":   passEnv,
+					"notifyChannel": "trusted_asns_changed",
+				},
+			},
+		},
+	})
+
+	requireEventualMatch(t, ctx, ctrl, 13335)
+
+	// Despite the 1h TTL the deletion is picked up through the notification
+	_, err = conn.Exec(ctx, `DELETE FROM trusted_asns WHERE asn = 13335`)
+	requireNoErr(t, err)
+	requireEventualMiss(t, ctx, ctrl, 13335)
+}
+
+func TestRedisFullSyncAsnMatchDatabase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, host, port := startRedis(t, ctx)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	client := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:%d", host, port)})
+	requireNoErr(t, client.Set(ctx, "asn:block:13335", "1", 0).Err())
+	requireNoErr(t, client.Set(ctx, "asn:block:AS64500", "1", 0).Err())
+
+	ctrlCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctrl := buildController(t, ctrlCtx, zaptest.NewLogger(t), config.ControllerConfig{
+		Name: "asn-db-redis-full-sync",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"syncMode": "full",
+			"sync": map[string]any{
+				"interval": "1s",
+			},
+			"database": map[string]any{
+				"type": "redis",
+				"redis": map[string]any{
+					"keyPrefix": "asn:block:",
+					"host":      host,
+					"port":      port,
+				},
+			},
+		},
+	})
+
+	requireMatch(t, ctx, ctrl, 13335, true)
+	requireMatch(t, ctx, ctrl, 64500, true)
+	requireMatch(t, ctx, ctrl, 15169, false)
+
+	// Changes are picked up by the next sync
+	requireNoErr(t, client.Set(ctx, "asn:block:15169", "1", 0).Err())
+	requireEventualMatch(t, ctx, ctrl, 15169)
+}
+
+func TestPostgresFullSyncAsnMatchDatabase(t *testing.T) {
+	t.Parallel()
+
+	userEnv := "ASN_PG_USER_" + sanitizeEnvName(t.Name())
+	passEnv := "ASN_PG_PASS_" + sanitizeEnvName(t.Name())
+	setEnvForTest(t, userEnv, "postgres")
+	setEnvForTest(t, passEnv, "postgres")
+
+	ctx := context.Background()
+	container, host, port := startPostgres(t, ctx)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	dsn := fmt.Sprintf("postgres://postgres:postgres@%s:%d/security?sslmode=disable", host, port)
+	conn, err := pgx.Connect(ctx, dsn)
+	requireNoErr(t, err)
+	t.Cleanup(func() { _ = conn.Close(ctx) })
+
+	_, err = conn.Exec(ctx, `
+        CREATE TABLE IF NOT EXISTS blocked_asns (asn bigint PRIMARY KEY);
+        INSERT INTO blocked_asns (asn) VALUES (13335), (64500) ON CONFLICT DO NOTHING;
+    `)
+	requireNoErr(t, err)
+
+	ctrlCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctrl := buildController(t, ctrlCtx, zaptest.NewLogger(t), config.ControllerConfig{
+		Name: "asn-db-postgres-full-sync",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"syncMode": "full",
+			"sync": map[string]any{
+				"interval": "1s",
+			},
+			"database": map[string]any{
+				"type": "postgres",
+				"postgres": map[string]any{
+					"syncQuery":    "SELECT asn FROM blocked_asns",
+					"host":         host,
+					"port":         port,
+					"databaseName": "security",
+					"usernameEnv":  userEnv,
+					"passwordEnv":  passEnv,
+				},
+			},
+		},
+	})
+
+	requireMatch(t, ctx, ctrl, 13335, true)
+	requireMatch(t, ctx, ctrl, 15169, false)
+
+	// Changes are picked up by the next sync
+	_, err = conn.Exec(ctx, `DELETE FROM blocked_asns WHERE asn = 13335`)
+	requireNoErr(t, err)
+	requireEventualMiss(t, ctx, ctrl, 13335)
+}
+
+func TestPostgresMetadataAsnMatchDatabase(t *testing.T) {
+	t.Parallel()
+
+	userEnv := "ASN_PG_USER_" + sanitizeEnvName(t.Name())
+	passEnv := "ASN_PG_PASS_" + sanitizeEnvName(t.Name())
+	setEnvForTest(t, userEnv, "postgres")
+	setEnvForTest(t, passEnv, "postgres")
+
+	ctx := context.Background()
+	container, host, port := startPostgres(t, ctx)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	dsn := fmt.Sprintf("postgres://postgres:postgres@%s:%d/security?sslmode=disable", host, port)
+	conn, err := pgx.Connect(ctx, dsn)
+	requireNoErr(t, err)
+	t.Cleanup(func() { _ = conn.Close(ctx) })
+
+	_, err = conn.Exec(ctx, `
+        CREATE TABLE IF NOT EXISTS blocked_asns (asn bigint PRIMARY KEY, reason text, expires_at timestamptz);
+        INSERT INTO blocked_asns (asn, reason, expires_at) VALUES
+            (13335, 'abuse reports', NULL),
+            (64500, 'lifted', now() - interval '1 day')
+        ON CONFLICT DO NOTHING;
+    `)
+	requireNoErr(t, err)
+
+	ctrl := buildController(t, ctx, zaptest.NewLogger(t), config.ControllerConfig{
+		Name: "asn-db-postgres-metadata",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"metadata": map[string]any{
+				"description":    "{reason}",
+				"expiresAtField": "expires_at",
+				"upstreamHeaders": map[string]any{
+					"X-ASN-Reason": "{reason}",
+				},
+			},
+			"database": map[string]any{
+				"type": "postgres",
+				"postgres": map[string]any{
+					"query":        "SELECT reason, expires_at FROM blocked_asns WHERE asn = $1",
+					"host":         host,
+					"port":         port,
+					"databaseName": "security",
+					"usernameEnv":  userEnv,
+					"passwordEnv":  passEnv,
+				},
+			},
+		},
+	})
+
+	verdict, err := ctrl.Match(ctx, runtime.NewRequestContext(minimalCheckRequest("203.0.113.10")), asnReports(13335))
+	requireNoErr(t, err)
+	if !verdict.IsMatch || !strings.HasSuffix(verdict.Description, ": abuse reports") {
+		t.Fatalf("expected match with metadata, got: %s", verdict.Description)
+	}
+	if verdict.AllowUpstreamHeaders["X-ASN-Reason"] != "abuse reports" {
+		t.Fatalf("unexpected upstream headers: %v", verdict.AllowUpstreamHeaders)
+	}
+
+	// Expired entries do not match
+	requireMatch(t, ctx, ctrl, 64500, false)
+}
+
 func startRedis(t *testing.T, ctx context.Context) (testcontainers.Container, string, int) {
 	t.Helper()
 
@@ -165,6 +516,123 @@ func startRedis(t *testing.T, ctx context.Context) (testcontainers.Container, st
 	return container, host, port
 }
 
+// startRedisSentinel runs a master, a replica and a sentinel monitoring them
+// in a single container on the host network, so the addresses the sentinel
+// announces are reachable from the test process. It returns the sentinel address.
+func startRedisSentinel(t *testing.T, ctx context.Context) (testcontainers.Container, string) {
+	t.Helper()
+
+	masterPort, replicaPort, sentinelPort := freePort(t), freePort(t), freePort(t)
+	script := fmt.Sprintf(`redis-server --port %[1]d --daemonize yes &&
+redis-server --port %[2]d --replicaof 127.0.0.1 %[1]d --daemonize yes &&
+printf 'port %[3]d\nsentinel monitor mymaster 127.0.0.1 %[1]d 1\n' > /tmp/sentinel.conf &&
+exec redis-sentinel /tmp/sentinel.conf`, masterPort, replicaPort, sentinelPort)
+
+	container, err := testcontainers.Run(ctx, "redis:7-alpine",
+		testcontainers.WithEntrypoint("sh", "-c", script),
+		testcontainers.WithHostConfigModifier(func(hostConfig *dockercontainer.HostConfig) {
+			hostConfig.NetworkMode = "host"
+		}),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("+slave slave").WithStartupTimeout(time.Minute),
+		),
+	)
+	requireNoErr(t, err)
+
+	return container, fmt.Sprintf("127.0.0.1:%d", sentinelPort)
+}
+
+// startRedisCluster runs a three-master, three-replica Redis Cluster in a
+// single container on the host network and returns the seed addresses.
+func startRedisCluster(t *testing.T, ctx context.Context) (testcontainers.Container, []string) {
+	t.Helper()
+
+	var ports []string
+	var addrs []string
+	for range 6 {
+		port := freePort(t)
+		ports = append(ports, strconv.Itoa(port))
+		addrs = append(addrs, fmt.Sprintf("127.0.0.1:%d", port))
+	}
+	script := fmt.Sprintf(`for port in %s; do
+  redis-server --port $port --cluster-enabled yes --cluster-config-file nodes-$port.conf --daemonize yes || exit 1
+done &&
+redis-cli --cluster create %s --cluster-replicas 1 --cluster-yes &&
+echo cluster-ready &&
+exec tail -f /dev/null`, strings.Join(ports, " "), strings.Join(addrs, " "))
+
+	container, err := testcontainers.Run(ctx, "redis:7-alpine",
+		testcontainers.WithEntrypoint("sh", "-c", script),
+		testcontainers.WithHostConfigModifier(func(hostConfig *dockercontainer.HostConfig) {
+			hostConfig.NetworkMode = "host"
+		}),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("cluster-ready").WithStartupTimeout(2*time.Minute),
+		),
+	)
+	requireNoErr(t, err)
+
+	return container, addrs[:3]
+}
+
+// freePort returns a TCP port that is currently free on the loopback interface.
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	requireNoErr(t, err)
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// requireMatch runs a single lookup and checks its outcome.
+func requireMatch(t *testing.T, ctx context.Context, ctrl controller.MatchController, asn uint, match bool) {
+	t.Helper()
+
+	verdict, err := ctrl.Match(ctx, runtime.NewRequestContext(minimalCheckRequest("203.0.113.10")), asnReports(asn))
+	requireNoErr(t, err)
+	if verdict.IsMatch != match {
+		t.Fatalf("AS%d: expected match=%v, got: %s", asn, match, verdict.Description)
+	}
+}
+
+// requireEventualMatch retries an uncached lookup until it matches.
+func requireEventualMatch(t *testing.T, ctx context.Context, ctrl controller.MatchController, asn uint) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		verdict, err := ctrl.Match(ctx, runtime.NewRequestContext(minimalCheckRequest("203.0.113.10")), asnReports(asn))
+		requireNoErr(t, err)
+		if verdict.IsMatch {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("AS%d: expected match, got: %s", asn, verdict.Description)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// requireEventualMiss retries a lookup until it no longer matches.
+func requireEventualMiss(t *testing.T, ctx context.Context, ctrl controller.MatchController, asn uint) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		verdict, err := ctrl.Match(ctx, runtime.NewRequestContext(minimalCheckRequest("203.0.113.10")), asnReports(asn))
+		requireNoErr(t, err)
+		if !verdict.IsMatch {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("AS%d: expected miss, got: %s", asn, verdict.Description)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
 func startPostgres(t *testing.T, ctx context.Context) (testcontainers.Container, string, int) {
 	t.Helper()
 
@@ -209,6 +677,20 @@ func minimalCheckRequest(ip string) *authv3.CheckRequest {
 	}
 }
 
+func asnReports(asn uint) controller.AnalysisReports {
+	return controller.AnalysisReports{
+		"asn": {
+			ControllerKind: maxmind_asn.ControllerKind,
+			Data: map[string]any{
+				"result": &maxmind_asn.IpLookupResult{
+					AutonomousSystemNumber:       asn,
+					AutonomousSystemOrganization: "test",
+				},
+			},
+		},
+	}
+}
+
 func buildController(t *testing.T, ctx context.Context, logger *zap.Logger, cfg config.ControllerConfig) controller.MatchController {
 	t.Helper()
 
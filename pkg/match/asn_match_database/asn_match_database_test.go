@@ -2,123 +2,107 @@ package asn_match_database
 
 import (
 	"context"
-	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
-	"time"
 
 	"go.uber.org/zap"
 
-	"github.com/gtriggiano/envoy-authorization-service/pkg/analysis/maxmind_asn"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
-	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/match/attribute_match_database"
 )
 
-func TestMatchWithoutASNReportRespectsMatchesOnFailure(t *testing.T) {
-	ctrl := &asnMatchDatabaseController{
-		name:             "asn-db",
-		matchesOnFailure: false,
-		dataSource:       &stubDataSource{},
-		cache:            NewCache(time.Minute),
-		dbType:           "redis",
-		logger:           zap.NewNop(),
+// baselineConfig holds the examples of the asn-match-database documentation
+// before the controller became an attribute-match-database preset, without
+// their optional TLS settings
+const baselineConfig = `
+analysisControllers:
+  - name: asn
+    type: maxmind-asn
+    settings:
+      databasePath: config/GeoLite2-ASN.mmdb
+
+matchControllers:
+  - name: asn-blocklist
+    type: asn-match-database
+    settings:
+      matchesOnFailure: false
+      cache:
+        ttl: 5m
+      database:
+        type: redis
+        redis:
+          keyPrefix: "asn:block:"
+          host: redis.example.com
+          port: 6379
+  - name: trusted-asn
+    type: asn-match-database
+    settings:
+      matchesOnFailure: false
+      database:
+        type: postgres
+        postgres:
+          query: "SELECT 1 FROM trusted_asns WHERE asn = $1 LIMIT 1"
+          host: postgres.example.com
+          databaseName: security
+          port: 5432
+          usernameEnv: POSTGRES_USER
+          passwordEnv: POSTGRES_PASSWORD
+`
+
+func TestPresetSettings(t *testing.T) {
+	t.Setenv("POSTGRES_USER", "postgres")
+	t.Setenv("POSTGRES_PASSWORD", "postgres")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(baselineConfig), 0o600); err != nil {
+		t.Fatalf("failed to write configuration: %v", err)
 	}
-
-	req := runtime.NewRequestContext(nil)
-	verdict, err := ctrl.Match(context.Background(), req, nil)
+	cfg, err := config.Load(path)
 	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	if verdict.IsMatch {
-		t.Fatalf("expected no match when ASN data missing")
-	}
-}
-
-func TestMatchDatabaseErrorUsesMatchesOnFailure(t *testing.T) {
-	ctrl := &asnMatchDatabaseController{
-		name:             "asn-db",
-		matchesOnFailure: true,
-		dataSource:       &stubDataSource{err: errors.New("boom")},
-		cache:            nil,
-		dbType:           "postgres",
-		logger:           zap.NewNop(),
+		t.Fatalf("failed to load configuration: %v", err)
 	}
 
-	reports := controller.AnalysisReports{
-		"asn": {
-			ControllerKind: maxmind_asn.ControllerKind,
-			Data: map[string]any{
-				"result": &maxmind_asn.IpLookupResult{AutonomousSystemNumber: 64500},
-			},
-		},
+	decoded := make(map[string]attribute_match_database.AttributeMatchDatabaseConfig)
+	for _, matchController := range cfg.MatchControllers {
+		controllerConfig, err := attribute_match_database.DecodePresetSettings(ControllerKind, presetKey, matchController.Settings)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", matchController.Name, err)
+		}
+		controllerConfig.ApplyDefaults()
+		if err := controllerConfig.Validate(); err != nil {
+			t.Fatalf("%s: unexpected validation error: %v", matchController.Name, err)
+		}
+		if controllerConfig.Key.Source != attribute_match_database.KeySourceASN {
+			t.Fatalf("%s: expected the ASN to be the key, got %q", matchController.Name, controllerConfig.Key.Source)
+		}
+		decoded[matchController.Name] = controllerConfig
 	}
 
-	req := runtime.NewRequestContext(nil)
-	verdict, err := ctrl.Match(context.Background(), req, reports)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	blocklist := decoded["asn-blocklist"]
+	if redis := blocklist.Database.Redis; redis == nil || redis.KeyPrefix != "asn:block:" || redis.Lookup != attribute_match_database.RedisLookupKey || redis.Addresses()[0] != "redis.example.com:6379" {
+		t.Fatalf("unexpected redis settings %+v", blocklist.Database.Redis)
 	}
-	if !verdict.IsMatch {
-		t.Fatalf("expected match on failure, got %v", verdict.IsMatch)
+	if blocklist.MatchesOnFailure || blocklist.GetCacheOptions().TTL.String() != "5m0s" {
+		t.Fatalf("unexpected asn-blocklist settings %+v", blocklist)
 	}
-	if verdict.Description == "" {
-		t.Fatalf("expected description to mention database failure")
-	}
-}
-
-func TestCachePreventsRepeatedQueries(t *testing.T) {
-	dataSource := &stubDataSource{matches: true}
-	ctrl := &asnMatchDatabaseController{
-		name:             "asn-db",
-		matchesOnFailure: false,
-		dataSource:       dataSource,
-		cache:            NewCache(time.Minute),
-		dbType:           "redis",
-		logger:           zap.NewNop(),
-	}
-
-	reports := asnReports(64500)
 
-	req := runtime.NewRequestContext(nil)
-	if _, err := ctrl.Match(context.Background(), req, reports); err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	trusted := decoded["trusted-asn"]
+	if postgres := trusted.Database.Postgres; postgres == nil || postgres.Query != "SELECT 1 FROM trusted_asns WHERE asn = $1 LIMIT 1" || postgres.DatabaseName != "security" || postgres.UsernameEnv != "POSTGRES_USER" {
+		t.Fatalf("unexpected postgres settings %+v", trusted.Database.Postgres)
 	}
-	if dataSource.containsCalls != 1 {
-		t.Fatalf("expected single database query, got %d", dataSource.containsCalls)
+	if trusted.MatchesOnFailure || trusted.Database.GetConnectionTimeout() != attribute_match_database.DefaultDatabaseConnectionTimeout {
+		t.Fatalf("unexpected trusted-asn settings %+v", trusted)
 	}
 
-	if _, err := ctrl.Match(context.Background(), req, reports); err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	// The registered factory refuses to look up another key
+	_, err = controller.BuildMatchControllers(context.Background(), zap.NewNop(), []config.ControllerConfig{{
+		Name:     "asn-blocklist",
+		Type:     ControllerKind,
+		Settings: map[string]any{"key": map[string]any{"source": "ip"}},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "key is not configurable for asn-match-database controllers") {
+		t.Fatalf("expected the key not to be configurable, got %v", err)
 	}
-	if dataSource.containsCalls != 1 {
-		t.Fatalf("expected cached result to be used")
-	}
-}
-
-func asnReports(asn uint) controller.AnalysisReports {
-	return controller.AnalysisReports{
-		"asn": {
-			ControllerKind: maxmind_asn.ControllerKind,
-			Data: map[string]any{
-				"result": &maxmind_asn.IpLookupResult{
-					AutonomousSystemNumber:       asn,
-					AutonomousSystemOrganization: "test",
-				},
-			},
-		},
-	}
-}
-
-type stubDataSource struct {
-	matches       bool
-	err           error
-	containsCalls int
-}
-
-func (s *stubDataSource) Contains(ctx context.Context, asn uint) (bool, error) {
-	s.containsCalls++
-	return s.matches, s.err
 }
-
-func (s *stubDataSource) Close() error { return nil }
-
-func (s *stubDataSource) HealthCheck(ctx context.Context) error { return nil }
@@ -0,0 +1,790 @@
+package attribute_match_database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/metrics"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+const (
+	ControllerKind = "attribute-match-database"
+	// revalidationTimeout bounds background refreshes of stale cache entries
+	revalidationTimeout = 5 * time.Second
+	// invalidationRetryInterval is the delay before resubscribing to change
+	// notifications after the subscription failed
+	invalidationRetryInterval = 5 * time.Second
+)
+
+// init registers the attribute-match-database match controller
+func init() {
+	controller.RegisterMatchControllerFactory(ControllerKind, newAttributeMatchDatabaseController)
+}
+
+type attributeMatchDatabaseController struct {
+	name             string
+	kind             string
+	key              *keyExtractor
+	matchesOnFailure bool
+	dataSources      []namedDataSource // queried in order, the first one answering wins
+	fallbackAfter    time.Duration
+	cache            *Cache
+	inflight         singleflight.Group
+	metadata         *MetadataConfig
+	fullSync         bool
+	snapshot         atomic.Pointer[Snapshot]
+	syncTimeout      time.Duration
+	syncMaxAge       time.Duration
+	dbType           string // type of the primary data source
+	instrumentation  *metrics.Instrumentation
+	logger           *zap.Logger
+}
+
+// namedDataSource is one of the data sources of a controller, identified in
+// metrics and logs by its name and type
+type namedDataSource struct {
+	DataSource
+	name         string
+	dbType       string
+	queryTimeout time.Duration // bounds every query attempt, when set
+	retry        RetryOptions
+}
+
+// SetInstrumentation injects the shared metrics instrumentation.
+func (c *attributeMatchDatabaseController) SetInstrumentation(inst *metrics.Instrumentation) {
+	c.instrumentation = inst
+
+	// The initial snapshot is loaded before instrumentation is available
+	if snapshot := c.snapshot.Load(); snapshot != nil {
+		c.observeSnapshot(snapshot)
+	}
+}
+
+// Match implements controller.MatchController
+func (c *attributeMatchDatabaseController) Match(ctx context.Context, req *runtime.RequestContext, reports controller.AnalysisReports) (*controller.MatchVerdict, error) {
+	key, ok := c.key.extract(req, reports)
+	if !ok {
+		verdict := c.createVerdict(c.matchesOnFailure, c.key.missingDescription())
+		c.observeMatchDatabaseRequest(req.Authority, verdict.IsMatch, true)
+		return verdict, nil
+	}
+
+	var result LookupResult
+	var dbError error
+
+	if c.fullSync {
+		// Answer from the in-memory snapshot, the database is not queried
+		snapshot := c.snapshot.Load()
+		result.Matched, result.Range = snapshot.Find(key)
+		result.Source = snapshot.source
+	} else {
+		result, dbError = c.lookup(ctx, req.Authority, key)
+	}
+
+	success := true
+	var verdict *controller.MatchVerdict
+
+	// Handle database errors
+	if dbError != nil {
+		success = false
+		c.observeUnavailable(req.Authority)
+		c.logger.Warn("database query failed", c.key.logField(key), zap.Error(dbError))
+		verdict = c.createVerdict(c.matchesOnFailure, fmt.Sprintf("database unavailable: %v", dbError))
+	} else if expiresAt, ok := c.metadata.expiresAt(result.Metadata); result.Matched && ok && !expiresAt.After(time.Now()) {
+		// The entry is still stored but no longer in effect
+		verdict = c.createVerdict(false, fmt.Sprintf("%s entry in '%s' expired at %s", c.key.describe(key), result.Source, expiresAt.UTC().Format(time.RFC3339)))
+	} else {
+		verdict = c.createVerdict(result.Matched, c.getVerdictDescription(key, result))
+		if result.Matched {
+			verdict.AllowUpstreamHeaders = c.metadata.upstreamHeaders(result.Metadata)
+			verdict.DenyDownstreamHeaders = c.metadata.downstreamHeaders(result.Metadata)
+			verdict.LogFields = c.metadata.logFields(result.Metadata)
+		}
+	}
+
+	c.observeMatchDatabaseRequest(req.Authority, verdict.IsMatch, success)
+	return verdict, nil
+}
+
+// Name implements controller.MatchController
+func (c *attributeMatchDatabaseController) Name() string {
+	return c.name
+}
+
+// Kind implements controller.MatchController
+func (c *attributeMatchDatabaseController) Kind() string {
+	return c.kind
+}
+
+// HealthCheck implements controller.MatchController. Lookups keep being
+// answered as long as one of the data sources is healthy.
+func (c *attributeMatchDatabaseController) HealthCheck(ctx context.Context) error {
+	if c.fullSync {
+		// The database is off the request path, only a stale snapshot matters
+		return c.snapshotHealth()
+	}
+
+	var failures []string
+	for _, dataSource := range c.dataSources {
+		err := dataSource.HealthCheck(ctx)
+		c.observeHealth(dataSource, err == nil)
+		if err == nil {
+			return nil
+		}
+		if len(c.dataSources) == 1 {
+			return err
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", dataSource.name, err))
+	}
+	return fmt.Errorf("all data sources are unhealthy: %s", strings.Join(failures, "; "))
+}
+
+// syncSnapshot loads the whole database into a new in-memory snapshot, from
+// the first data source able to provide it. On failure the previous snapshot
+// stays in use.
+func (c *attributeMatchDatabaseController) syncSnapshot(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.syncTimeout)
+	defer cancel()
+
+	start := time.Now()
+	var entries []SnapshotEntry
+	var dataSource namedDataSource
+	var err error
+	for i, candidate := range c.dataSources {
+		dataSource = candidate
+		entries, err = candidate.DataSource.(SnapshotSource).LoadSnapshot(ctx)
+		c.observeSync(candidate, err == nil)
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+		if i < len(c.dataSources)-1 {
+			c.logger.Warn("full sync failed, falling back",
+				zap.String("datasource", candidate.name),
+				zap.String("fallback", c.dataSources[i+1].name),
+				zap.Error(err),
+			)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// Entries that cannot be keys of this controller are skipped
+	validEntries := make([]SnapshotEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry, ok := c.key.snapshotEntry(entry); ok {
+			validEntries = append(validEntries, entry)
+		}
+	}
+
+	snapshot := NewSnapshot(validEntries, time.Now())
+	snapshot.source = dataSource.dbType
+	c.snapshot.Store(snapshot)
+	c.observeSnapshot(snapshot)
+	c.logger.Debug("snapshot synced",
+		zap.String("datasource", dataSource.name),
+		zap.Int("entries", snapshot.Size()),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	return nil
+}
+
+// runSync refreshes the snapshot every interval until ctx is done
+func (c *attributeMatchDatabaseController) runSync(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.syncSnapshot(ctx); err != nil && ctx.Err() == nil {
+				c.logger.Warn("full sync failed, serving last snapshot",
+					zap.Duration("snapshot_age", time.Since(c.snapshot.Load().LoadedAt())),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+}
+
+// snapshotHealth fails when the last successful sync is older than the configured max age
+func (c *attributeMatchDatabaseController) snapshotHealth() error {
+	if c.syncMaxAge <= 0 {
+		return nil
+	}
+	if age := time.Since(c.snapshot.Load().LoadedAt()); age > c.syncMaxAge {
+		return fmt.Errorf("snapshot is stale: last successful sync %s ago", age.Round(time.Second))
+	}
+	return nil
+}
+
+// lookup resolves the key through the cache, falling back to the
+// database. Expired entries are served while they are refreshed in the
+// background (stale-while-revalidate) or when the database fails (stale-if-error).
+func (c *attributeMatchDatabaseController) lookup(ctx context.Context, authority, key string) (LookupResult, error) {
+	if c.cache == nil {
+		// No cache, query database directly
+		return c.coalescedQuery(ctx, authority, key)
+	}
+
+	cached, state := c.cache.Lookup(key)
+	switch state {
+	case CacheFresh:
+		c.observeCacheHit(authority)
+		c.logger.Debug("cache hit", c.key.logField(key), zap.Bool("matched", cached.Matched))
+		return cached, nil
+	case CacheStale:
+		c.observeCacheStale(authority, false)
+		c.logger.Debug("cache stale, revalidating", c.key.logField(key), zap.Bool("matched", cached.Matched))
+		c.revalidate(ctx, authority, key)
+		return cached, nil
+	}
+
+	c.observeCacheMiss(authority)
+	c.logger.Debug("cache miss", c.key.logField(key))
+	result, err := c.coalescedQuery(ctx, authority, key)
+	if err != nil {
+		if state == CacheExpired {
+			c.observeUnavailable(authority)
+			c.observeCacheStale(authority, true)
+			c.logger.Warn("database query failed, serving stale cache entry", c.key.logField(key), zap.Error(err))
+			return cached, nil
+		}
+		return LookupResult{}, err
+	}
+
+	// Cache the result since the query was successful
+	c.cache.Store(key, result)
+	c.logger.Debug("cache update", c.key.logField(key))
+	c.observeCacheSize(authority)
+
+	return result, nil
+}
+
+// revalidate refreshes a stale cache entry in the background, unless a
+// refresh of the same key is already running
+func (c *attributeMatchDatabaseController) revalidate(ctx context.Context, authority, key string) {
+	if !c.cache.BeginRevalidation(key) {
+		return
+	}
+
+	go func() {
+		defer c.cache.EndRevalidation(key)
+
+		// Detach from the request, which may complete before the query does
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), revalidationTimeout)
+		defer cancel()
+
+		result, err := c.coalescedQuery(ctx, authority, key)
+		if err != nil {
+			c.logger.Debug("cache revalidation failed", c.key.logField(key), zap.Error(err))
+			return
+		}
+
+		c.cache.Store(key, result)
+		c.logger.Debug("cache revalidated", c.key.logField(key))
+		c.observeCacheSize(authority)
+	}()
+}
+
+// watchInvalidations keeps a subscription to the change notifications of the
+// data source, resubscribing after failures. While the subscription is down
+// entries only expire with their TTL, and changes may have been missed, so the
+// whole cache is flushed every time the subscription is established.
+func (c *attributeMatchDatabaseController) watchInvalidations(ctx context.Context, source InvalidationSource) {
+	for {
+		err := source.WatchInvalidations(ctx, func() {
+			c.cache.Clear()
+			c.logger.Info("subscribed to cache invalidations")
+		}, c.invalidate)
+		if ctx.Err() != nil {
+			return
+		}
+		c.logger.Warn("cache invalidation subscription failed, falling back to cache TTL",
+			zap.Duration("retry_in", invalidationRetryInterval),
+			zap.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(invalidationRetryInterval):
+		}
+	}
+}
+
+// invalidate drops the cached lookup of a changed key. Values that are not a
+// single key (an empty payload, a network with IP keys) flush the whole cache.
+func (c *attributeMatchDatabaseController) invalidate(value string) {
+	key, ok := c.key.normalize(value)
+	if !ok {
+		c.cache.Clear()
+		c.logger.Debug("cache flushed on change notification", zap.String("value", value))
+		return
+	}
+
+	c.cache.Invalidate(key)
+	c.logger.Debug("cache entry invalidated on change notification", c.key.logField(key))
+}
+
+// coalescedQuery queries the database, sharing one in-flight query between
+// concurrent lookups of the same key. The shared query is detached from
+// the cancellation of the request that started it, so that one client going
+// away does not fail the others, but keeps its deadline.
+func (c *attributeMatchDatabaseController) coalescedQuery(ctx context.Context, authority, key string) (LookupResult, error) {
+	leader := false
+	result, err, _ := c.inflight.Do(key, func() (any, error) {
+		leader = true
+
+		queryCtx := context.WithoutCancel(ctx)
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			queryCtx, cancel = context.WithDeadline(queryCtx, deadline)
+			defer cancel()
+		}
+
+		return c.queryDatabase(queryCtx, authority, key)
+	})
+	if !leader {
+		c.observeCoalesced(authority)
+		c.logger.Debug("database query coalesced", c.key.logField(key))
+	}
+
+	return result.(LookupResult), err
+}
+
+// queryDatabase queries the data sources in order until one answers. Every
+// data source but the last is given fallbackAfter to answer, when configured.
+func (c *attributeMatchDatabaseController) queryDatabase(ctx context.Context, authority, key string) (LookupResult, error) {
+	var failures []string
+	for i, dataSource := range c.dataSources {
+		queryCtx, cancel := ctx, context.CancelFunc(func() {})
+		if c.fallbackAfter > 0 && i < len(c.dataSources)-1 {
+			queryCtx, cancel = context.WithTimeout(ctx, c.fallbackAfter)
+		}
+		result, err := c.queryWithRetry(queryCtx, authority, dataSource, key)
+		cancel()
+		if err == nil {
+			return result, nil
+		}
+		if len(c.dataSources) == 1 {
+			return LookupResult{}, err
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", dataSource.name, err))
+
+		// The request is gone, no time is left for the fallbacks
+		if ctx.Err() != nil {
+			break
+		}
+		if i < len(c.dataSources)-1 {
+			c.logger.Debug("database query failed, falling back",
+				c.key.logField(key),
+				zap.String("datasource", dataSource.name),
+				zap.String("fallback", c.dataSources[i+1].name),
+				zap.Error(err),
+			)
+		}
+	}
+	return LookupResult{}, fmt.Errorf("all data sources failed: %s", strings.Join(failures, "; "))
+}
+
+// queryWithRetry queries one data source, retrying transient failures with a
+// jittered exponential backoff while attempts and the deadline allow
+func (c *attributeMatchDatabaseController) queryWithRetry(ctx context.Context, authority string, dataSource namedDataSource, key string) (LookupResult, error) {
+	for attempt := 1; ; attempt++ {
+		queryCtx, cancel := ctx, context.CancelFunc(func() {})
+		if dataSource.queryTimeout > 0 {
+			queryCtx, cancel = context.WithTimeout(ctx, dataSource.queryTimeout)
+		}
+		result, err := c.queryDataSource(queryCtx, authority, dataSource, key)
+		cancel()
+
+		if err == nil || attempt >= dataSource.retry.MaxAttempts || ctx.Err() != nil || !isTransient(err) {
+			return result, err
+		}
+
+		backoff := dataSource.retry.retryBackoff(attempt)
+		c.logger.Debug("database query failed, retrying",
+			c.key.logField(key),
+			zap.String("datasource", dataSource.name),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+		if !sleep(ctx, backoff) {
+			return result, err
+		}
+	}
+}
+
+// queryDataSource queries one data source. The matched range is only
+// reported by data sources implementing RangeDataSource, the payload by data
+// sources implementing MetadataDataSource when metadata is configured.
+func (c *attributeMatchDatabaseController) queryDataSource(ctx context.Context, authority string, dataSource namedDataSource, key string) (LookupResult, error) {
+	result := LookupResult{Source: dataSource.dbType}
+	var err error
+
+	start := time.Now()
+	if snapshotDataSource, ok := dataSource.DataSource.(SnapshotDataSource); ok {
+		result.Matched, result.Range = snapshotDataSource.Snapshot().Find(key)
+	} else if rangeDataSource, ok := dataSource.DataSource.(RangeDataSource); ok {
+		result.Range, err = rangeDataSource.FindRange(ctx, key)
+		result.Matched = result.Range != nil
+	} else if metadataDataSource, ok := dataSource.DataSource.(MetadataDataSource); ok && c.metadata != nil {
+		result.Matched, result.Metadata, err = metadataDataSource.LookupMetadata(ctx, key)
+	} else {
+		result.Matched, err = dataSource.Contains(ctx, key)
+	}
+	duration := time.Since(start)
+
+	logFields := []zap.Field{
+		c.key.logField(key),
+		zap.String("db_type", dataSource.dbType),
+		zap.String("datasource", dataSource.name),
+		zap.Duration("duration", duration),
+	}
+
+	if err != nil {
+		logFields = append(logFields, zap.Error(err))
+	} else {
+		logFields = append(logFields, zap.Bool("matched", result.Matched))
+	}
+	if result.Range != nil {
+		logFields = append(logFields, zap.String("range", result.Range.Range))
+	}
+
+	c.observeQuery(authority, dataSource, result.Matched, err, duration)
+
+	c.logger.Debug("database query", logFields...)
+
+	return result, err
+}
+
+// createVerdict constructs a MatchVerdict with the given details. The
+// metadata upstream headers are set empty, Match renders them for matches.
+func (c *attributeMatchDatabaseController) createVerdict(isMatch bool, description string) *controller.MatchVerdict {
+	return &controller.MatchVerdict{
+		Controller:           c.name,
+		ControllerType:       c.kind,
+		DenyCode:             codes.PermissionDenied,
+		Description:          description,
+		IsMatch:              isMatch,
+		AllowUpstreamHeaders: c.metadata.upstreamHeaders(nil),
+	}
+}
+
+// getVerdictDescription generates a description for the verdict based on match result
+func (c *attributeMatchDatabaseController) getVerdictDescription(key string, result LookupResult) string {
+	subject := c.key.describe(key)
+	if matchedRange := result.Range; matchedRange != nil {
+		if matchedRange.Comment != "" {
+			return fmt.Sprintf("%s matched range %s [%s] in '%s'", subject, matchedRange.Range, matchedRange.Comment, result.Source)
+		}
+		return fmt.Sprintf("%s matched range %s in '%s'", subject, matchedRange.Range, result.Source)
+	}
+	if result.Matched {
+		if description := c.metadata.describe(result.Metadata); description != "" {
+			return fmt.Sprintf("%s found in '%s': %s", subject, result.Source, description)
+		}
+		return fmt.Sprintf("%s found in '%s'", subject, result.Source)
+	}
+	return fmt.Sprintf("%s not found in '%s'", subject, result.Source)
+}
+
+func (c *attributeMatchDatabaseController) observeMatchDatabaseRequest(authority string, matched bool, success bool) {
+	c.instrumentation.ObserveMatchDatabaseRequest(authority, c.name, c.kind, c.dbType, matched, success)
+}
+
+func (c *attributeMatchDatabaseController) observeQuery(authority string, dataSource namedDataSource, matched bool, err error, duration time.Duration) {
+	c.instrumentation.ObserveMatchDatabaseQuery(authority, c.name, c.kind, dataSource.dbType, dataSource.name, matched, err, duration)
+}
+
+func (c *attributeMatchDatabaseController) observeHealth(dataSource namedDataSource, healthy bool) {
+	c.instrumentation.ObserveMatchDatabaseHealth(c.name, c.kind, dataSource.dbType, dataSource.name, healthy)
+}
+
+func (c *attributeMatchDatabaseController) observeCacheHit(authority string) {
+	c.instrumentation.ObserveMatchDatabaseCacheHit(authority, c.name, c.kind, c.dbType)
+}
+
+func (c *attributeMatchDatabaseController) observeCacheMiss(authority string) {
+	c.instrumentation.ObserveMatchDatabaseCacheMiss(authority, c.name, c.kind, c.dbType)
+}
+
+func (c *attributeMatchDatabaseController) observeCacheStale(authority string, onError bool) {
+	c.instrumentation.ObserveMatchDatabaseCacheStale(authority, c.name, c.kind, c.dbType, onError)
+}
+
+func (c *attributeMatchDatabaseController) observeCoalesced(authority string) {
+	c.instrumentation.ObserveMatchDatabaseCoalesced(authority, c.name, c.kind, c.dbType)
+}
+
+func (c *attributeMatchDatabaseController) observeSync(dataSource namedDataSource, success bool) {
+	c.instrumentation.ObserveMatchDatabaseSync(c.name, c.kind, dataSource.dbType, success)
+}
+
+func (c *attributeMatchDatabaseController) observeSnapshot(snapshot *Snapshot) {
+	c.instrumentation.ObserveMatchDatabaseSnapshot(c.name, c.kind, c.dbType, snapshot.Size(), snapshot.LoadedAt())
+}
+
+func (c *attributeMatchDatabaseController) observeCacheSize(authority string) {
+	if c.cache == nil {
+		return
+	}
+	c.instrumentation.ObserveMatchDatabaseCacheSize(authority, c.name, c.kind, c.dbType, c.cache.Size())
+}
+
+func (c *attributeMatchDatabaseController) observeUnavailable(authority string) {
+	c.instrumentation.ObserveMatchDatabaseUnavailable(authority, c.name, c.kind, c.dbType)
+}
+
+// NewPresetFactory returns the factory of a controller kind whose lookup key
+// is fixed, such as ip-match-database. Its settings are those of
+// attribute-match-database without the key.
+func NewPresetFactory(kind string, key KeyConfig) controller.MatchControllerFactory {
+	return func(ctx context.Context, logger *zap.Logger, cfg config.ControllerConfig) (controller.MatchController, error) {
+		controllerConfig, err := DecodePresetSettings(kind, key, cfg.Settings)
+		if err != nil {
+			return nil, err
+		}
+
+		return newController(ctx, logger, cfg.Name, kind, controllerConfig)
+	}
+}
+
+// DecodePresetSettings decodes the settings of a controller kind whose lookup
+// key is fixed, refusing settings that configure the key.
+func DecodePresetSettings(kind string, key KeyConfig, settings map[string]any) (AttributeMatchDatabaseConfig, error) {
+	var controllerConfig AttributeMatchDatabaseConfig
+	if err := controller.DecodeControllerSettings(settings, &controllerConfig); err != nil {
+		return controllerConfig, fmt.Errorf("failed to decode settings: %w", err)
+	}
+	if controllerConfig.Key != (KeyConfig{}) {
+		return controllerConfig, fmt.Errorf("key is not configurable for %s controllers", kind)
+	}
+	controllerConfig.Key = key
+	return controllerConfig, nil
+}
+
+// newAttributeMatchDatabaseController constructs a controller from configuration
+func newAttributeMatchDatabaseController(ctx context.Context, logger *zap.Logger, cfg config.ControllerConfig) (controller.MatchController, error) {
+	// Decode configuration
+	var controllerConfig AttributeMatchDatabaseConfig
+	if err := controller.DecodeControllerSettings(cfg.Settings, &controllerConfig); err != nil {
+		return nil, fmt.Errorf("failed to decode settings: %w", err)
+	}
+
+	return newController(ctx, logger, cfg.Name, ControllerKind, controllerConfig)
+}
+
+// newController validates the decoded configuration, connects to the database
+// and constructs a controller of the given kind
+func newController(ctx context.Context, logger *zap.Logger, name, kind string, controllerConfig AttributeMatchDatabaseConfig) (controller.MatchController, error) {
+	// Apply defaults
+	controllerConfig.ApplyDefaults()
+
+	// Validate configuration
+	if err := controllerConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	// Connect to the databases, in the order they are queried
+	var dataSources []namedDataSource
+	closeDataSources := func() {
+		for _, dataSource := range dataSources {
+			if err := dataSource.Close(); err != nil {
+				logger.Error("failed to close data source", zap.String("datasource", dataSource.name), zap.Error(err))
+			}
+		}
+	}
+	for _, database := range controllerConfig.Databases() {
+		dataSource, err := newDataSource(ctx, logger, controllerConfig.Key, database)
+		if err != nil {
+			closeDataSources()
+			return nil, err
+		}
+		dataSources = append(dataSources, dataSource)
+	}
+	dbType := dataSources[0].dbType
+
+	// Create cache if configured
+	var cache *Cache
+	cacheOptions := controllerConfig.GetCacheOptions()
+	if cacheOptions.TTL > 0 {
+		cache = NewCacheWithOptions(cacheOptions)
+		logger.Info("caching enabled",
+			zap.Duration("ttl", cacheOptions.TTL),
+			zap.Duration("negativeTTL", cacheOptions.NegativeTTL),
+			zap.Duration("staleWhileRevalidate", cacheOptions.StaleWhileRevalidate),
+			zap.Duration("staleIfError", cacheOptions.StaleIfError),
+		)
+	} else {
+		logger.Info("caching disabled")
+	}
+
+	ctrl := &attributeMatchDatabaseController{
+		name:             name,
+		kind:             kind,
+		key:              newKeyExtractor(controllerConfig.Key),
+		matchesOnFailure: controllerConfig.MatchesOnFailure,
+		dataSources:      dataSources,
+		fallbackAfter:    controllerConfig.GetFallbackAfter(),
+		cache:            cache,
+		metadata:         controllerConfig.Metadata,
+		fullSync:         controllerConfig.FullSync(),
+		syncTimeout:      controllerConfig.GetSyncTimeout(),
+		syncMaxAge:       controllerConfig.GetSyncMaxAge(),
+		dbType:           dbType,
+		logger:           logger,
+	}
+
+	// Mirror the database in memory if configured
+	if ctrl.fullSync {
+		for _, dataSource := range dataSources {
+			if _, ok := dataSource.DataSource.(SnapshotSource); !ok {
+				closeDataSources()
+				return nil, fmt.Errorf("syncMode '%s' is not supported by the %s data source", SyncModeFull, dataSource.dbType)
+			}
+		}
+		if err := ctrl.syncSnapshot(ctx); err != nil {
+			closeDataSources()
+			return nil, fmt.Errorf("initial full sync failed: %w", err)
+		}
+		logger.Info("full sync enabled",
+			zap.Int("entries", ctrl.snapshot.Load().Size()),
+			zap.Duration("interval", controllerConfig.GetSyncInterval()),
+			zap.Duration("maxAge", ctrl.syncMaxAge),
+		)
+		go ctrl.runSync(ctx, controllerConfig.GetSyncInterval())
+	}
+
+	// Setup cleanup when context is canceled
+	go func() {
+		<-ctx.Done()
+		closeDataSources()
+	}()
+
+	fallbacks := make([]string, 0, len(dataSources)-1)
+	for _, dataSource := range dataSources[1:] {
+		fallbacks = append(fallbacks, dataSource.name)
+	}
+	logger.Info("controller initialized",
+		zap.String("db_type", dbType),
+		zap.Strings("fallbacks", fallbacks),
+		zap.String("key_source", controllerConfig.Key.Source),
+		zap.Bool("matchesOnFailure", controllerConfig.MatchesOnFailure),
+	)
+
+	// Subscribe to change notifications if configured
+	for i, database := range controllerConfig.Databases() {
+		databaseConfig := controllerConfig
+		databaseConfig.Database = database
+		if source, ok := dataSources[i].DataSource.(InvalidationSource); ok && cache != nil && databaseConfig.InvalidationEnabled() {
+			logger.Info("push cache invalidation enabled", zap.String("db_type", dataSources[i].dbType), zap.String("datasource", dataSources[i].name))
+			go ctrl.watchInvalidations(ctx, source)
+		}
+	}
+
+	return ctrl, nil
+}
+
+// newDataSource connects to a database, bounded by its connection timeout
+func newDataSource(ctx context.Context, logger *zap.Logger, key KeyConfig, database DatabaseConfig) (namedDataSource, error) {
+	// Create context with timeout for initialization
+	initCtx, cancel := context.WithTimeout(ctx, database.GetConnectionTimeout())
+	defer cancel()
+
+	logger = logger.With(zap.String("datasource", database.GetName()))
+
+	// Create data source based on type
+	var dataSource DataSource
+	var dbType string
+	var err error
+
+	switch {
+	case database.DataSource != "":
+		// Use the connection of a shared datasource
+		dataSource, err = newSharedDataSource(ctx, database)
+		if err != nil {
+			return namedDataSource{}, fmt.Errorf("failed to use datasource '%s': %w", database.DataSource, err)
+		}
+		dbType = metrics.REDIS
+		if database.Type == "postgres" {
+			dbType = metrics.POSTGRES
+		}
+		logger.Info("using shared datasource", zap.String("shared_datasource", database.DataSource))
+	case database.Type == "redis":
+		if database.Redis.Lookup == RedisLookupRange {
+			dataSource, err = NewRedisRangeDataSource(initCtx, database.Redis)
+		} else {
+			dataSource, err = NewRedisDataSource(initCtx, database.Redis)
+		}
+		if err != nil {
+			return namedDataSource{}, fmt.Errorf("failed to create Redis data source: %w", err)
+		}
+		dbType = metrics.REDIS
+		logger.Info("connected to Redis",
+			zap.String("mode", database.Redis.Mode),
+			zap.Strings("addresses", database.Redis.Addresses()),
+			zap.Int("db", database.Redis.DB),
+			zap.String("lookup", database.Redis.Lookup),
+		)
+	case database.Type == "postgres":
+		dataSource, err = NewPostgresDataSource(initCtx, database.Postgres)
+		if err != nil {
+			return namedDataSource{}, fmt.Errorf("failed to create PostgreSQL data source: %w", err)
+		}
+		dbType = metrics.POSTGRES
+		logger.Info("connected to PostgreSQL",
+			zap.String("host", database.Postgres.Host),
+			zap.Int("port", database.Postgres.Port),
+			zap.String("database", database.Postgres.DatabaseName),
+		)
+	case database.Type == "http":
+		dataSource, err = NewHTTPDataSource(initCtx, database.HTTP)
+		if err != nil {
+			return namedDataSource{}, fmt.Errorf("failed to create HTTP data source: %w", err)
+		}
+		dbType = metrics.HTTP
+		logger.Info("configured HTTP lookup service",
+			zap.String("method", database.HTTP.Method),
+			zap.String("url", database.HTTP.URL),
+			zap.Bool("health_check", database.HTTP.HealthURL != ""),
+		)
+	case database.Type == "file":
+		fileDataSource, err := NewFileDataSource(database.File, newKeyExtractor(key).snapshotEntry)
+		if err != nil {
+			return namedDataSource{}, fmt.Errorf("failed to create file data source: %w", err)
+		}
+		dataSource = fileDataSource
+		dbType = metrics.FILE
+		logger.Info("loaded snapshot file",
+			zap.String("path", database.File.Path),
+			zap.Int("entries", fileDataSource.Snapshot().Size()),
+			zap.Duration("reloadInterval", database.File.GetReloadInterval()),
+		)
+	default:
+		return namedDataSource{}, fmt.Errorf("unsupported database type: %s", database.Type)
+	}
+
+	return namedDataSource{
+		DataSource:   dataSource,
+		name:         database.GetName(),
+		dbType:       dbType,
+		queryTimeout: database.GetQueryTimeout(),
+		retry:        database.GetRetryOptions(),
+	}, nil
+}
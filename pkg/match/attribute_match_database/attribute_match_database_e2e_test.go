@@ -0,0 +1,310 @@
+//go:build e2e
+
+package attribute_match_database
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/testcontainers/testcontainers-go"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+	tcredis "github.com/testcontainers/testcontainers-go/modules/redis"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+func TestRedisHeaderAttributeMatchDatabase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, host, port := startRedis(t, ctx)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	client := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:%d", host, port)})
+	t.Cleanup(func() { _ = client.Close() })
+	requireNoErr(t, client.HSet(ctx, "apikey:k-123", "tenant", "acme").Err())
+
+	logger := zaptest.NewLogger(t)
+	ctrl := buildController(t, ctx, logger, config.ControllerConfig{
+		Name: "api-keys",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"key": map[string]any{
+				"source": "header",
+				"name":   "x-api-key",
+			},
+			"metadata": map[string]any{
+				"upstreamHeaders": map[string]any{"x-tenant": "{tenant}"},
+			},
+			"database": map[string]any{
+				"type": "redis",
+				"redis": map[string]any{
+					"keyPrefix": "apikey:",
+					"valueType": "hash",
+					"host":      host,
+					"port":      port,
+				},
+			},
+		},
+	})
+
+	verdict, err := ctrl.Match(ctx, runtime.NewRequestContext(headerCheckRequest("/", map[string]string{"x-api-key": "k-123"})), nil)
+	requireNoErr(t, err)
+	if !verdict.IsMatch || verdict.AllowUpstreamHeaders["x-tenant"] != "acme" {
+		t.Fatalf("expected to match API key with tenant header, got %+v", verdict)
+	}
+
+	verdict, err = ctrl.Match(ctx, runtime.NewRequestContext(headerCheckRequest("/", map[string]string{"x-api-key": "k-999"})), nil)
+	requireNoErr(t, err)
+	if verdict.IsMatch {
+		t.Fatalf("expected to miss API key, got: %s", verdict.Description)
+	}
+}
+
+func TestPostgresPathAttributeMatchDatabase(t *testing.T) {
+	t.Parallel()
+
+	userEnv := "ATTR_PG_USER_" + sanitizeEnvName(t.Name())
+	passEnv := "ATTR_PG_PASS_" + sanitizeEnvName(t.Name())
+	setEnvForTest(t, userEnv, "postgres")
+	setEnvForTest(t, passEnv, "postgres")
+
+	ctx := context.Background()
+	container, host, port := startPostgres(t, ctx)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	dsn := fmt.Sprintf("postgres://postgres:postgres@%s:%d/security?sslmode=disable", host, port)
+	conn, err := pgx.Connect(ctx, dsn)
+	requireNoErr(t, err)
+	t.Cleanup(func() { _ = conn.Close(ctx) })
+
+	_, err = conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS suspended_tenants (tenant text PRIMARY KEY);
+		INSERT INTO suspended_tenants (tenant) VALUES ('acme') ON CONFLICT DO NOTHING;
+	`)
+	requireNoErr(t, err)
+
+	logger := zaptest.NewLogger(t)
+	ctrl := buildController(t, ctx, logger, config.ControllerConfig{
+		Name: "suspended-tenants",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"key": map[string]any{
+				"source":  "path",
+				"pattern": "^/tenants/([^/]+)",
+			},
+			"database": map[string]any{
+				"type":              "postgres",
+				"connectionTimeout": "1s",
+				"postgres": map[string]any{
+					"query":        "SELECT 1 FROM suspended_tenants WHERE tenant = $1 LIMIT 1",
+					"host":         host,
+					"port":         port,
+					"databaseName": "security",
+					"usernameEnv":  userEnv,
+					"passwordEnv":  passEnv,
+				},
+			},
+		},
+	})
+
+	for path, want := range map[string]bool{
+		"/tenants/acme/orders?page=2": true,
+		"/tenants/globex/orders":      false,
+		"/health":                     false,
+	} {
+		verdict, err := ctrl.Match(ctx, runtime.NewRequestContext(headerCheckRequest(path, nil)), nil)
+		requireNoErr(t, err)
+		if verdict.IsMatch != want {
+			t.Fatalf("expected match %v for %s, got: %s", want, path, verdict.Description)
+		}
+	}
+}
+
+func startRedis(t *testing.T, ctx context.Context) (testcontainers.Container, string, int) {
+	t.Helper()
+
+	container, err := tcredis.Run(ctx, "redis:7-alpine")
+	requireNoErr(t, err)
+
+	endpoint, err := container.Endpoint(ctx, "")
+	requireNoErr(t, err)
+	host, portStr, err := net.SplitHostPort(endpoint)
+	requireNoErr(t, err)
+	port, err := strconv.Atoi(portStr)
+	requireNoErr(t, err)
+
+	return container, host, port
+}
+
+func startPostgres(t *testing.T, ctx context.Context) (testcontainers.Container, string, int) {
+	t.Helper()
+
+	container, err := tcpostgres.Run(ctx,
+		"postgres:16-alpine",
+		tcpostgres.WithDatabase("security"),
+		tcpostgres.WithUsername("postgres"),
+		tcpostgres.WithPassword("postgres"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(2*time.Minute),
+			wait.ForExec([]string{"pg_isready", "-U", "postgres", "-d", "security"}).
+				WithStartupTimeout(2*time.Minute),
+		),
+	)
+	requireNoErr(t, err)
+
+	endpoint, err := container.Endpoint(ctx, "")
+	requireNoErr(t, err)
+	host, portStr, err := net.SplitHostPort(endpoint)
+	requireNoErr(t, err)
+	port, err := strconv.Atoi(portStr)
+	requireNoErr(t, err)
+
+	return container, host, port
+}
+
+func TestSharedRedisDataSource(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	container, host, port := startRedis(t, ctx)
+	defer func() { _ = container.Terminate(context.Background()) }()
+
+	client := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:%d", host, port)})
+	t.Cleanup(func() { _ = client.Close() })
+	requireNoErr(t, client.Set(ctx, "apikey:k-123", "acme", 0).Err())
+	requireNoErr(t, client.Set(ctx, "tenant:globex", "suspended", 0).Err())
+
+	logger := zaptest.NewLogger(t)
+	dataSources, err := controller.BuildDataSources(ctx, logger.Named("datasource"), []config.DataSourceConfig{{
+		Name: "cache",
+		Type: "redis",
+		Settings: map[string]any{
+			"host":     host,
+			"port":     port,
+			"poolSize": 4,
+		},
+	}})
+	requireNoErr(t, err)
+
+	controllersCtx := controller.WithDataSources(ctx, dataSources)
+	apiKeys := buildController(t, controllersCtx, logger, config.ControllerConfig{
+		Name: "api-keys",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"key":      map[string]any{"source": "header", "name": "x-api-key"},
+			"database": map[string]any{"type": "redis", "datasource": "cache", "redis": map[string]any{"keyPrefix": "apikey:"}},
+		},
+	})
+	tenants := buildController(t, controllersCtx, logger, config.ControllerConfig{
+		Name: "suspended-tenants",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"key":      map[string]any{"source": "header", "name": "x-tenant"},
+			"database": map[string]any{"type": "redis", "datasource": "cache", "redis": map[string]any{"keyPrefix": "tenant:"}},
+		},
+	})
+
+	request := runtime.NewRequestContext(headerCheckRequest("/", map[string]string{"x-api-key": "k-123", "x-tenant": "globex"}))
+	for _, ctrl := range []controller.MatchController{apiKeys, tenants} {
+		verdict, err := ctrl.Match(ctx, request, nil)
+		requireNoErr(t, err)
+		if !verdict.IsMatch {
+			t.Fatalf("expected %s to match through the shared datasource, got: %s", ctrl.Name(), verdict.Description)
+		}
+		requireNoErr(t, ctrl.HealthCheck(ctx))
+	}
+
+	// The client is closed once the set is closed and both controllers released it
+	shared := dataSources.List()[0].(*sharedRedisClient)
+	requireNoErr(t, dataSources.Close())
+	requireNoErr(t, shared.client.Ping(context.Background()).Err())
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for shared.client.Ping(context.Background()).Err() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the shared client to be closed after the controllers stopped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func headerCheckRequest(path string, headers map[string]string) *authv3.CheckRequest {
+	return &authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{
+			Request: &authv3.AttributeContext_Request{
+				Http: &authv3.AttributeContext_HttpRequest{
+					Path:    path,
+					Headers: headers,
+				},
+			},
+		},
+	}
+}
+
+func buildController(t *testing.T, ctx context.Context, logger *zap.Logger, cfg config.ControllerConfig) controller.MatchController {
+	t.Helper()
+
+	controllers, err := controller.BuildMatchControllers(ctx, logger.Named("controller"), []config.ControllerConfig{cfg})
+	requireNoErr(t, err)
+	if len(controllers) != 1 {
+		t.Fatalf("expected 1 controller, got %d", len(controllers))
+	}
+	return controllers[0]
+}
+
+func requireNoErr(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// setEnvForTest sets an environment variable for the duration of the test and
+// restores its prior value on cleanup. Unlike t.Setenv, it is safe to use with
+// t.Parallel, provided the env var name is unique per test.
+func setEnvForTest(t *testing.T, key, value string) {
+	t.Helper()
+	prev, hadPrev := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatalf("os.Setenv(%q) failed: %v", key, err)
+	}
+	t.Cleanup(func() {
+		if hadPrev {
+			_ = os.Setenv(key, prev)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
+
+// sanitizeEnvName maps a test name into a fragment that is valid in an env var.
+func sanitizeEnvName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - ('a' - 'A')
+		default:
+			return '_'
+		}
+	}, name)
+}
@@ -0,0 +1,725 @@
+package attribute_match_database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"go.uber.org/zap"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/metrics"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+// stubDataSource is an in-memory DataSource whose answers and failures can be changed by tests
+type stubDataSource struct {
+	mu      sync.Mutex
+	keys    map[string]bool
+	err     error
+	queries int
+	release chan struct{} // when set, queries block until it is closed
+}
+
+func (f *stubDataSource) Contains(ctx context.Context, key string) (bool, error) {
+	f.mu.Lock()
+	f.queries++
+	release := f.release
+	f.mu.Unlock()
+
+	if release != nil {
+		<-release
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return false, f.err
+	}
+	return f.keys[key], nil
+}
+
+func (f *stubDataSource) Close() error                          { return nil }
+func (f *stubDataSource) HealthCheck(ctx context.Context) error { return nil }
+
+func (f *stubDataSource) set(key string, present bool, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys[key] = present
+	f.err = err
+}
+
+func (f *stubDataSource) queryCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.queries
+}
+
+func newTestController(dataSource DataSource, cache *Cache) *attributeMatchDatabaseController {
+	return &attributeMatchDatabaseController{
+		name:        "test",
+		kind:        ControllerKind,
+		key:         newKeyExtractor(KeyConfig{Source: KeySourceIP}),
+		dataSources: []namedDataSource{{DataSource: dataSource, name: "redis", dbType: metrics.REDIS, retry: RetryOptions{MaxAttempts: 1}}},
+		cache:       cache,
+		dbType:      metrics.REDIS,
+		logger:      zap.NewNop(),
+	}
+}
+
+func matchIP(t *testing.T, ctrl controller.MatchController, ip string) *controller.MatchVerdict {
+	t.Helper()
+	verdict, err := ctrl.Match(context.Background(), &runtime.RequestContext{
+		IpAddress:  netip.MustParseAddr(ip),
+		ReceivedAt: time.Now(),
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return verdict
+}
+
+func TestMatch_StaleIfError(t *testing.T) {
+	dataSource := &stubDataSource{keys: map[string]bool{"203.0.113.10": true}}
+	ctrl := newTestController(dataSource, NewCacheWithOptions(CacheOptions{
+		TTL:          10 * time.Millisecond,
+		StaleIfError: time.Hour,
+	}))
+
+	if verdict := matchIP(t, ctrl, "203.0.113.10"); !verdict.IsMatch {
+		t.Fatalf("expected match, got %s", verdict.Description)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	dataSource.set("203.0.113.10", false, errors.New("connection refused"))
+
+	verdict := matchIP(t, ctrl, "203.0.113.10")
+	if !verdict.IsMatch {
+		t.Fatalf("expected stale match to be served on error, got %s", verdict.Description)
+	}
+
+	// Without a cached answer the failure policy still applies
+	if verdict := matchIP(t, ctrl, "198.51.100.1"); verdict.IsMatch || verdict.Description != "database unavailable: connection refused" {
+		t.Fatalf("expected matchesOnFailure verdict, got %+v", verdict)
+	}
+}
+
+func TestMatch_StaleWhileRevalidate(t *testing.T) {
+	dataSource := &stubDataSource{keys: map[string]bool{"203.0.113.10": true}}
+	ctrl := newTestController(dataSource, NewCacheWithOptions(CacheOptions{
+		TTL:                  10 * time.Millisecond,
+		StaleWhileRevalidate: time.Hour,
+	}))
+
+	matchIP(t, ctrl, "203.0.113.10")
+	time.Sleep(20 * time.Millisecond)
+	dataSource.set("203.0.113.10", false, nil)
+
+	if verdict := matchIP(t, ctrl, "203.0.113.10"); !verdict.IsMatch {
+		t.Fatalf("expected stale value to be served while revalidating, got %s", verdict.Description)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if verdict := matchIP(t, ctrl, "203.0.113.10"); !verdict.IsMatch {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected background revalidation to refresh the cached value")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if queries := dataSource.queryCount(); queries != 2 {
+		t.Fatalf("expected one initial and one background query, got %d", queries)
+	}
+}
+
+func TestMatch_CoalescesConcurrentLookups(t *testing.T) {
+	release := make(chan struct{})
+	dataSource := &stubDataSource{keys: map[string]bool{"203.0.113.10": true}, release: release}
+	ctrl := newTestController(dataSource, NewCache(time.Minute))
+
+	const lookups = 10
+	var wg sync.WaitGroup
+	verdicts := make([]*controller.MatchVerdict, lookups)
+	for i := range lookups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			verdicts[i], _ = ctrl.Match(context.Background(), &runtime.RequestContext{
+				IpAddress:  netip.MustParseAddr("203.0.113.10"),
+				ReceivedAt: time.Now(),
+			}, nil)
+		}()
+	}
+
+	// Give every lookup the time to join the in-flight query
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if queries := dataSource.queryCount(); queries != 1 {
+		t.Fatalf("expected a single coalesced query, got %d", queries)
+	}
+	for i, verdict := range verdicts {
+		if verdict == nil || !verdict.IsMatch {
+			t.Fatalf("expected lookup %d to share the match, got %+v", i, verdict)
+		}
+	}
+}
+
+// stubInvalidationSource is a stubDataSource pushing the values sent on events
+type stubInvalidationSource struct {
+	*stubDataSource
+	events chan string
+}
+
+func (s *stubInvalidationSource) WatchInvalidations(ctx context.Context, ready func(), invalidate func(key string)) error {
+	ready()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case value := <-s.events:
+			invalidate(value)
+		}
+	}
+}
+
+func TestMatch_PushInvalidation(t *testing.T) {
+	dataSource := &stubInvalidationSource{
+		stubDataSource: &stubDataSource{keys: map[string]bool{"203.0.113.10": true, "198.51.100.1": true}},
+		events:         make(chan string),
+	}
+	ctrl := newTestController(dataSource, NewCache(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ctrl.watchInvalidations(ctx, dataSource)
+
+	// Once the watcher received an event it is subscribed and will not flush again
+	dataSource.events <- ""
+
+	matchIP(t, ctrl, "203.0.113.10")
+	matchIP(t, ctrl, "198.51.100.1")
+	if ctrl.cache.Size() != 2 {
+		t.Fatalf("expected 2 cached entries, got %d", ctrl.cache.Size())
+	}
+
+	// Unbuffered sends complete once the watcher received them, the next
+	// send (or cancel) happens after the previous invalidation ran
+	dataSource.set("203.0.113.10", false, nil)
+	dataSource.events <- "203.0.113.10/32"
+	dataSource.events <- "198.51.100.250"
+	if verdict := matchIP(t, ctrl, "203.0.113.10"); verdict.IsMatch {
+		t.Fatal("expected invalidated entry to be looked up again")
+	}
+	if verdict := matchIP(t, ctrl, "198.51.100.1"); !verdict.IsMatch {
+		t.Fatal("expected unrelated entry to stay cached")
+	}
+	if queries := dataSource.queryCount(); queries != 3 {
+		t.Fatalf("expected only the invalidated entry to be queried again, got %d queries", queries)
+	}
+
+	dataSource.events <- "10.0.0.0/8"
+	dataSource.events <- ""
+	if ctrl.cache.Size() != 0 {
+		t.Fatalf("expected network notification to flush the cache, got %d entries", ctrl.cache.Size())
+	}
+}
+
+// stubSnapshotSource is a stubDataSource whose full content and sync failures can be changed by tests
+type stubSnapshotSource struct {
+	*stubDataSource
+	entries []SnapshotEntry
+	err     error
+}
+
+func (s *stubSnapshotSource) LoadSnapshot(ctx context.Context) ([]SnapshotEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.entries, nil
+}
+
+func TestMatch_FullSync(t *testing.T) {
+	office, _ := ParseSnapshotEntry("10.1.0.0/16", "office")
+	single, _ := ParseSnapshotEntry("203.0.113.10", "")
+	source := &stubSnapshotSource{
+		stubDataSource: &stubDataSource{keys: map[string]bool{}},
+		entries:        []SnapshotEntry{office, single},
+	}
+	ctrl := newTestController(source, nil)
+	ctrl.fullSync = true
+	ctrl.syncTimeout = time.Second
+	ctrl.syncMaxAge = time.Hour
+
+	if err := ctrl.syncSnapshot(context.Background()); err != nil {
+		t.Fatalf("unexpected sync error: %v", err)
+	}
+
+	verdict := matchIP(t, ctrl, "10.1.2.3")
+	if !verdict.IsMatch || !strings.Contains(verdict.Description, "10.1.0.0/16 [office]") {
+		t.Fatalf("expected range match, got %+v", verdict)
+	}
+	if verdict := matchIP(t, ctrl, "203.0.113.10"); !verdict.IsMatch {
+		t.Fatal("expected single IP match")
+	}
+	if verdict := matchIP(t, ctrl, "198.51.100.1"); verdict.IsMatch {
+		t.Fatal("expected no match")
+	}
+	if queries := source.queryCount(); queries != 0 {
+		t.Fatalf("expected lookups to be answered from memory, got %d queries", queries)
+	}
+
+	// A failed sync keeps serving the last snapshot
+	source.mu.Lock()
+	source.err = errors.New("connection refused")
+	source.mu.Unlock()
+	if err := ctrl.syncSnapshot(context.Background()); err == nil {
+		t.Fatal("expected sync error")
+	}
+	if verdict := matchIP(t, ctrl, "10.1.2.3"); !verdict.IsMatch {
+		t.Fatal("expected last snapshot to be served after a failed sync")
+	}
+
+	if err := ctrl.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("unexpected health check error: %v", err)
+	}
+	ctrl.snapshot.Store(NewSnapshot(nil, time.Now().Add(-2*time.Hour)))
+	if err := ctrl.HealthCheck(context.Background()); err == nil || !strings.Contains(err.Error(), "snapshot is stale") {
+		t.Fatalf("expected stale snapshot error, got %v", err)
+	}
+}
+
+// stubMetadataSource is a stubDataSource returning a payload with every match
+type stubMetadataSource struct {
+	*stubDataSource
+	metadata map[string]Metadata
+}
+
+func (s *stubMetadataSource) LookupMetadata(ctx context.Context, key string) (bool, Metadata, error) {
+	matched, err := s.Contains(ctx, key)
+	if !matched || err != nil {
+		return false, nil, err
+	}
+	return true, s.metadata[key], nil
+}
+
+func TestMatch_Metadata(t *testing.T) {
+	dataSource := &stubMetadataSource{
+		stubDataSource: &stubDataSource{keys: map[string]bool{"203.0.113.10": true, "203.0.113.11": true}},
+		metadata: map[string]Metadata{
+			"203.0.113.10": {"reason": "scanner", "owner": "secops"},
+			"203.0.113.11": {"reason": "lifted", "expires_at": "2000-01-01T00:00:00Z"},
+		},
+	}
+	ctrl := newTestController(dataSource, NewCache(time.Hour))
+	ctrl.metadata = &MetadataConfig{
+		Description:       "{reason}",
+		UpstreamHeaders:   map[string]string{"X-Block-Owner": "{owner}"},
+		DownstreamHeaders: map[string]string{"X-Block-Reason": "{reason}"},
+		LogFields:         []string{"owner"},
+		ExpiresAtField:    "expires_at",
+	}
+
+	// The second lookup is answered from the cache, payload included
+	for range 2 {
+		verdict := matchIP(t, ctrl, "203.0.113.10")
+		if !verdict.IsMatch || verdict.Description != "IP 203.0.113.10 found in 'REDIS': scanner" {
+			t.Fatalf("unexpected verdict %+v", verdict)
+		}
+		if verdict.AllowUpstreamHeaders["X-Block-Owner"] != "secops" || verdict.DenyDownstreamHeaders["X-Block-Reason"] != "scanner" {
+			t.Fatalf("unexpected headers %v %v", verdict.AllowUpstreamHeaders, verdict.DenyDownstreamHeaders)
+		}
+		if len(verdict.LogFields) != 1 || verdict.LogFields[0].String != "secops" {
+			t.Fatalf("unexpected log fields %v", verdict.LogFields)
+		}
+	}
+	if queries := dataSource.queryCount(); queries != 1 {
+		t.Fatalf("expected 1 query, got %d", queries)
+	}
+
+	verdict := matchIP(t, ctrl, "203.0.113.11")
+	if verdict.IsMatch || !strings.Contains(verdict.Description, "expired at 2000-01-01T00:00:00Z") {
+		t.Fatalf("expected expired entry not to match, got %+v", verdict)
+	}
+	if verdict.DenyDownstreamHeaders != nil {
+		t.Fatalf("expected no headers for expired entry, got %v", verdict.DenyDownstreamHeaders)
+	}
+
+	// Upstream headers are overwritten even when nothing matches, so that
+	// clients cannot supply them
+	for _, ip := range []string{"203.0.113.11", "203.0.113.12"} {
+		verdict := matchIP(t, ctrl, ip)
+		if value, ok := verdict.AllowUpstreamHeaders["X-Block-Owner"]; verdict.IsMatch || !ok || value != "" {
+			t.Fatalf("expected an empty upstream header for %s, got %+v", ip, verdict)
+		}
+	}
+}
+
+func TestMatch_ASNKey(t *testing.T) {
+	dataSource := &stubInvalidationSource{
+		stubDataSource: &stubDataSource{keys: map[string]bool{"64500": true, "64501": true}},
+		events:         make(chan string),
+	}
+	ctrl := newTestController(dataSource, NewCache(time.Hour))
+	ctrl.key = newKeyExtractor(KeyConfig{Source: KeySourceASN})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ctrl.watchInvalidations(ctx, dataSource)
+	dataSource.events <- ""
+
+	matchASN := func(asn uint) *controller.MatchVerdict {
+		t.Helper()
+		verdict, err := ctrl.Match(context.Background(), runtime.NewRequestContext(nil), asnReports(asn))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return verdict
+	}
+
+	if verdict := matchASN(64500); !verdict.IsMatch || verdict.Description != "ASN 64500 found in 'REDIS'" {
+		t.Fatalf("unexpected verdict %+v", verdict)
+	}
+	matchASN(64501)
+
+	// Stored keys in the "AS" form invalidate the numeric cache key
+	dataSource.set("64500", false, nil)
+	dataSource.events <- "AS64500"
+	dataSource.events <- "not-an-asn"
+	if ctrl.cache.Size() != 0 {
+		t.Fatalf("expected unparsable notification to flush the cache, got %d entries", ctrl.cache.Size())
+	}
+	if verdict := matchASN(64500); verdict.IsMatch {
+		t.Fatal("expected invalidated entry to be looked up again")
+	}
+
+	// Without an ASN report the failure policy applies
+	ctrl.matchesOnFailure = true
+	verdict, err := ctrl.Match(context.Background(), runtime.NewRequestContext(nil), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !verdict.IsMatch || verdict.Description != "no ASN information available" {
+		t.Fatalf("expected matchesOnFailure verdict, got %+v", verdict)
+	}
+}
+
+func TestMatch_FullSyncNormalizesKeys(t *testing.T) {
+	source := &stubSnapshotSource{
+		stubDataSource: &stubDataSource{keys: map[string]bool{}},
+		entries:        []SnapshotEntry{{Key: "AS64500"}, {Key: " 64501"}, {Key: "not-an-asn"}},
+	}
+	ctrl := newTestController(source, nil)
+	ctrl.key = newKeyExtractor(KeyConfig{Source: KeySourceASN})
+	ctrl.fullSync = true
+	ctrl.syncTimeout = time.Second
+
+	if err := ctrl.syncSnapshot(context.Background()); err != nil {
+		t.Fatalf("unexpected sync error: %v", err)
+	}
+	if size := ctrl.snapshot.Load().Size(); size != 2 {
+		t.Fatalf("expected 2 entries, got %d", size)
+	}
+
+	for asn, want := range map[uint]bool{64500: true, 64501: true, 64502: false} {
+		verdict, err := ctrl.Match(context.Background(), runtime.NewRequestContext(nil), asnReports(asn))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if verdict.IsMatch != want {
+			t.Fatalf("expected match %v for AS%d, got %v", want, asn, verdict.IsMatch)
+		}
+	}
+}
+
+func TestMatch_HeaderKey(t *testing.T) {
+	dataSource := &stubDataSource{keys: map[string]bool{"k-123": true}}
+	ctrl := newTestController(dataSource, nil)
+	ctrl.kind = "api-key-database"
+	ctrl.key = newKeyExtractor(KeyConfig{Source: KeySourceHeader, Name: "x-api-key"})
+
+	matchHeader := func(headers map[string]string) *controller.MatchVerdict {
+		t.Helper()
+		verdict, err := ctrl.Match(context.Background(), runtime.NewRequestContext(&authv3.CheckRequest{
+			Attributes: &authv3.AttributeContext{
+				Request: &authv3.AttributeContext_Request{
+					Http: &authv3.AttributeContext_HttpRequest{Headers: headers},
+				},
+			},
+		}), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return verdict
+	}
+
+	verdict := matchHeader(map[string]string{"x-api-key": "k-123"})
+	if !verdict.IsMatch || verdict.Description != "key 'k-123' found in 'REDIS'" || verdict.ControllerType != "api-key-database" {
+		t.Fatalf("unexpected verdict %+v", verdict)
+	}
+	if verdict := matchHeader(map[string]string{"x-api-key": "k-999"}); verdict.IsMatch {
+		t.Fatalf("expected no match, got %+v", verdict)
+	}
+	if verdict := matchHeader(nil); verdict.IsMatch || verdict.Description != "no lookup key in header 'x-api-key'" {
+		t.Fatalf("expected missing key verdict, got %+v", verdict)
+	}
+	if queries := dataSource.queryCount(); queries != 2 {
+		t.Fatalf("expected requests without a key not to be looked up, got %d queries", queries)
+	}
+}
+
+func TestNewPresetFactory(t *testing.T) {
+	factory := NewPresetFactory("ip-match-database", KeyConfig{Source: KeySourceIP})
+
+	_, err := factory(context.Background(), zap.NewNop(), config.ControllerConfig{
+		Name: "preset",
+		Type: "ip-match-database",
+		Settings: map[string]any{
+			"key":      map[string]any{"source": "header", "name": "x-api-key"},
+			"database": map[string]any{"type": "redis"},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "key is not configurable for ip-match-database controllers") {
+		t.Fatalf("expected preset key error, got %v", err)
+	}
+
+	// The preset key is validated together with the remaining settings
+	_, err = factory(context.Background(), zap.NewNop(), config.ControllerConfig{
+		Name: "preset",
+		Type: "ip-match-database",
+		Settings: map[string]any{
+			"database": map[string]any{"type": "redis", "redis": map[string]any{"lookup": "range", "host": "localhost"}},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "database.redis.rangeKey is required") {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+}
+
+func TestNewController_HTTPDataSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/keys/k-123" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = io.WriteString(w, `{"tenant":"acme"}`)
+	}))
+	defer server.Close()
+
+	ctrl, err := newAttributeMatchDatabaseController(context.Background(), zap.NewNop(), config.ControllerConfig{
+		Name: "api-keys",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"key":      map[string]any{"source": "header", "name": "x-api-key"},
+			"metadata": map[string]any{"upstreamHeaders": map[string]any{"x-tenant": "{tenant}"}},
+			"database": map[string]any{"type": "http", "http": map[string]any{"url": server.URL + "/keys/{key}"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	verdict, err := ctrl.Match(context.Background(), runtime.NewRequestContext(&authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{
+			Request: &authv3.AttributeContext_Request{
+				Http: &authv3.AttributeContext_HttpRequest{Headers: map[string]string{"x-api-key": "k-123"}},
+			},
+		},
+	}), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !verdict.IsMatch || verdict.Description != "key 'k-123' found in 'HTTP'" || verdict.AllowUpstreamHeaders["x-tenant"] != "acme" {
+		t.Fatalf("unexpected verdict %+v", verdict)
+	}
+}
+
+// blockingDataSource is a stubDataSource whose queries only return once their context is done
+type blockingDataSource struct {
+	*stubDataSource
+}
+
+func (b *blockingDataSource) Contains(ctx context.Context, key string) (bool, error) {
+	<-ctx.Done()
+	return false, ctx.Err()
+}
+
+func TestMatch_Failover(t *testing.T) {
+	primary := &stubDataSource{keys: map[string]bool{}, err: errors.New("connection refused")}
+	fallback := &stubDataSource{keys: map[string]bool{"203.0.113.10": true}}
+	ctrl := newTestController(primary, nil)
+	ctrl.dataSources = append(ctrl.dataSources, namedDataSource{DataSource: fallback, name: "source-of-truth", dbType: metrics.POSTGRES})
+
+	verdict := matchIP(t, ctrl, "203.0.113.10")
+	if !verdict.IsMatch || verdict.Description != "IP 203.0.113.10 found in 'POSTGRES'" {
+		t.Fatalf("expected match from the fallback, got %+v", verdict)
+	}
+	if primary.queryCount() != 1 || fallback.queryCount() != 1 {
+		t.Fatalf("expected one query per data source, got %d and %d", primary.queryCount(), fallback.queryCount())
+	}
+
+	fallback.set("203.0.113.10", false, errors.New("too many connections"))
+	verdict = matchIP(t, ctrl, "203.0.113.10")
+	if verdict.IsMatch || !strings.Contains(verdict.Description, "all data sources failed: redis: connection refused; source-of-truth: too many connections") {
+		t.Fatalf("expected failure verdict, got %+v", verdict)
+	}
+
+	// A healthy primary is the only one queried
+	primary.set("198.51.100.1", true, nil)
+	if verdict := matchIP(t, ctrl, "198.51.100.1"); !verdict.IsMatch || verdict.Description != "IP 198.51.100.1 found in 'REDIS'" {
+		t.Fatalf("expected match from the primary, got %+v", verdict)
+	}
+	if queries := fallback.queryCount(); queries != 2 {
+		t.Fatalf("expected the fallback not to be queried, got %d queries", queries)
+	}
+}
+
+func TestMatch_FallbackAfter(t *testing.T) {
+	primary := &blockingDataSource{stubDataSource: &stubDataSource{}}
+	fallback := &stubDataSource{keys: map[string]bool{"203.0.113.10": true}}
+	ctrl := newTestController(primary, nil)
+	ctrl.dataSources = append(ctrl.dataSources, namedDataSource{DataSource: fallback, name: "postgres", dbType: metrics.POSTGRES})
+	ctrl.fallbackAfter = 10 * time.Millisecond
+
+	if verdict := matchIP(t, ctrl, "203.0.113.10"); !verdict.IsMatch {
+		t.Fatalf("expected match from the fallback after the primary timed out, got %+v", verdict)
+	}
+}
+
+func TestHealthCheck_Failover(t *testing.T) {
+	primary := &unhealthyDataSource{stubDataSource: &stubDataSource{}}
+	fallback := &unhealthyDataSource{stubDataSource: &stubDataSource{}}
+	ctrl := newTestController(primary, nil)
+	ctrl.dataSources = append(ctrl.dataSources, namedDataSource{DataSource: fallback, name: "postgres", dbType: metrics.POSTGRES})
+
+	primary.err = errors.New("connection refused")
+	if err := ctrl.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("expected healthy controller while the fallback is healthy, got %v", err)
+	}
+
+	fallback.err = errors.New("connection refused")
+	if err := ctrl.HealthCheck(context.Background()); err == nil || !strings.Contains(err.Error(), "all data sources are unhealthy") {
+		t.Fatalf("expected health check error, got %v", err)
+	}
+}
+
+// unhealthyDataSource is a stubDataSource whose health check fails with its error
+type unhealthyDataSource struct {
+	*stubDataSource
+}
+
+func (u *unhealthyDataSource) HealthCheck(ctx context.Context) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.err
+}
+
+func TestMatch_FullSyncFailover(t *testing.T) {
+	primary := &stubSnapshotSource{stubDataSource: &stubDataSource{}, err: errors.New("connection refused")}
+	single, _ := ParseSnapshotEntry("203.0.113.10", "")
+	fallback := &stubSnapshotSource{stubDataSource: &stubDataSource{}, entries: []SnapshotEntry{single}}
+	ctrl := newTestController(primary, nil)
+	ctrl.dataSources = append(ctrl.dataSources, namedDataSource{DataSource: fallback, name: "file", dbType: metrics.FILE})
+	ctrl.fullSync = true
+	ctrl.syncTimeout = time.Second
+
+	if err := ctrl.syncSnapshot(context.Background()); err != nil {
+		t.Fatalf("unexpected sync error: %v", err)
+	}
+	if verdict := matchIP(t, ctrl, "203.0.113.10"); !verdict.IsMatch || verdict.Description != "IP 203.0.113.10 found in 'FILE'" {
+		t.Fatalf("expected match from the fallback snapshot, got %+v", verdict)
+	}
+}
+
+// flakyDataSource is a stubDataSource failing its first queries with failure
+type flakyDataSource struct {
+	*stubDataSource
+	failures int
+	failure  error
+}
+
+func (f *flakyDataSource) Contains(ctx context.Context, key string) (bool, error) {
+	f.mu.Lock()
+	if f.failures > 0 {
+		f.failures--
+		f.queries++
+		f.mu.Unlock()
+		return false, f.failure
+	}
+	f.mu.Unlock()
+	return f.stubDataSource.Contains(ctx, key)
+}
+
+func TestMatch_Retry(t *testing.T) {
+	retry := RetryOptions{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+	t.Run("transient errors are retried", func(t *testing.T) {
+		dataSource := &flakyDataSource{stubDataSource: &stubDataSource{keys: map[string]bool{"203.0.113.10": true}}, failures: 2, failure: syscall.ECONNRESET}
+		ctrl := newTestController(dataSource, nil)
+		ctrl.dataSources[0].retry = retry
+
+		if verdict := matchIP(t, ctrl, "203.0.113.10"); !verdict.IsMatch {
+			t.Fatalf("expected match after retries, got %+v", verdict)
+		}
+		if got := dataSource.queryCount(); got != 3 {
+			t.Fatalf("expected 3 attempts, got %d", got)
+		}
+	})
+
+	t.Run("attempts are bounded", func(t *testing.T) {
+		dataSource := &flakyDataSource{stubDataSource: &stubDataSource{}, failures: 5, failure: syscall.ECONNRESET}
+		ctrl := newTestController(dataSource, nil)
+		ctrl.dataSources[0].retry = retry
+
+		if _, err := ctrl.queryDatabase(context.Background(), "", "203.0.113.10"); !errors.Is(err, syscall.ECONNRESET) {
+			t.Fatalf("expected the last error after exhausting attempts, got %v", err)
+		}
+		if got := dataSource.queryCount(); got != 3 {
+			t.Fatalf("expected 3 attempts, got %d", got)
+		}
+	})
+
+	t.Run("permanent errors are not retried", func(t *testing.T) {
+		dataSource := &flakyDataSource{stubDataSource: &stubDataSource{}, failures: 5, failure: errors.New("invalid query")}
+		ctrl := newTestController(dataSource, nil)
+		ctrl.dataSources[0].retry = retry
+
+		if _, err := ctrl.queryDatabase(context.Background(), "", "203.0.113.10"); err == nil {
+			t.Fatal("expected query error")
+		}
+		if got := dataSource.queryCount(); got != 1 {
+			t.Fatalf("expected a single attempt, got %d", got)
+		}
+	})
+}
+
+func TestMatch_QueryTimeout(t *testing.T) {
+	dataSource := &blockingDataSource{stubDataSource: &stubDataSource{}}
+	ctrl := newTestController(dataSource, nil)
+	ctrl.dataSources[0].queryTimeout = 10 * time.Millisecond
+	ctrl.dataSources[0].retry = RetryOptions{MaxAttempts: 2, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	start := time.Now()
+	if _, err := ctrl.queryDatabase(context.Background(), "", "203.0.113.10"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("expected two attempts bounded by the query timeout, took %s", elapsed)
+	}
+}
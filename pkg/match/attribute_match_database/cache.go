@@ -0,0 +1,180 @@
+package attribute_match_database
+
+import (
+	"sync"
+	"time"
+)
+
+// CacheState describes how fresh a cached lookup result is
+type CacheState int
+
+const (
+	// CacheMiss means no usable entry exists
+	CacheMiss CacheState = iota
+	// CacheFresh means the entry is within its TTL
+	CacheFresh
+	// CacheStale means the entry expired but is within the stale-while-revalidate
+	// window: it can be served while it is refreshed in the background
+	CacheStale
+	// CacheExpired means the entry expired but is within the stale-if-error
+	// window: it can only be served when querying the database fails
+	CacheExpired
+)
+
+// CacheOptions configures entry lifetimes of the cache
+type CacheOptions struct {
+	TTL                  time.Duration // Lifetime of positive results
+	NegativeTTL          time.Duration // Lifetime of negative results, defaults to TTL
+	StaleWhileRevalidate time.Duration // How long after expiration an entry is served while refreshing
+	StaleIfError         time.Duration // How long after expiration an entry is served when the database fails
+}
+
+// cacheEntry represents a single cached lookup result
+type cacheEntry struct {
+	result    LookupResult // Whether the key exists in the database, with the matched range and payload
+	expiresAt time.Time    // When this entry expires
+}
+
+// Cache provides TTL-based caching for key lookups
+type Cache struct {
+	mu           sync.RWMutex
+	entries      map[string]cacheEntry
+	revalidating map[string]struct{}
+	options      CacheOptions
+}
+
+// NewCache creates a new cache with the specified TTL
+func NewCache(ttl time.Duration) *Cache {
+	return NewCacheWithOptions(CacheOptions{TTL: ttl})
+}
+
+// NewCacheWithOptions creates a new cache with separate positive and negative
+// TTLs and optional stale serving windows
+func NewCacheWithOptions(options CacheOptions) *Cache {
+	if options.NegativeTTL <= 0 {
+		options.NegativeTTL = options.TTL
+	}
+	return &Cache{
+		entries:      make(map[string]cacheEntry),
+		revalidating: make(map[string]struct{}),
+		options:      options,
+	}
+}
+
+// Get retrieves a cached result for the key
+// Returns (matches, found) where:
+// - matches: whether the key exists in the database (only valid if found is true)
+// - found: whether a valid (non-expired) cache entry exists
+func (c *Cache) Get(key string) (matches bool, found bool) {
+	matches, _, found = c.GetRange(key)
+	return matches, found
+}
+
+// GetRange retrieves a cached result for the key together with the
+// range that contained it (nil when the data source does not report ranges)
+func (c *Cache) GetRange(key string) (matches bool, matchedRange *MatchedRange, found bool) {
+	result, state := c.Lookup(key)
+	if state != CacheFresh {
+		return false, nil, false
+	}
+	return result.Matched, result.Range, true
+}
+
+// Lookup retrieves a cached result for the key together with its
+// freshness. Results are only meaningful when the state is not CacheMiss.
+func (c *Cache) Lookup(key string) (result LookupResult, state CacheState) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, exists := c.entries[key]
+	if !exists {
+		return LookupResult{}, CacheMiss
+	}
+
+	now := time.Now()
+	switch {
+	case now.Before(entry.expiresAt):
+		state = CacheFresh
+	case now.Before(entry.expiresAt.Add(c.options.StaleWhileRevalidate)):
+		state = CacheStale
+	case now.Before(entry.expiresAt.Add(c.options.StaleIfError)):
+		state = CacheExpired
+	default:
+		return LookupResult{}, CacheMiss
+	}
+
+	return entry.result, state
+}
+
+// Set stores a lookup result for the key with TTL expiration
+func (c *Cache) Set(key string, matches bool) {
+	c.SetRange(key, matches, nil)
+}
+
+// SetRange stores a lookup result for the key together with the range
+// that contained it, with TTL expiration
+func (c *Cache) SetRange(key string, matches bool, matchedRange *MatchedRange) {
+	c.Store(key, LookupResult{Matched: matches, Range: matchedRange})
+}
+
+// Store stores a lookup result for the key, including its matched
+// range and payload, with TTL expiration
+func (c *Cache) Store(key string, result LookupResult) {
+	ttl := c.options.TTL
+	if !result.Matched {
+		ttl = c.options.NegativeTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = cacheEntry{
+		result:    result,
+		expiresAt: time.Now().Add(ttl),
+	}
+}
+
+// BeginRevalidation marks a background refresh of the key as started.
+// It returns false when a refresh is already in progress.
+func (c *Cache) BeginRevalidation(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, inProgress := c.revalidating[key]; inProgress {
+		return false
+	}
+	c.revalidating[key] = struct{}{}
+	return true
+}
+
+// EndRevalidation marks a background refresh of the key as finished
+func (c *Cache) EndRevalidation(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.revalidating, key)
+}
+
+// Invalidate removes the entry of the key, including stale copies
+func (c *Cache) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+// Clear removes all entries from the cache
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]cacheEntry)
+}
+
+// Size returns the current number of entries in the cache (including expired ones)
+func (c *Cache) Size() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.entries)
+}
@@ -0,0 +1,179 @@
+package attribute_match_database
+
+import (
+	"testing"
+	"time"
+)
+
+// TestCache tests the TTL-based cache implementation
+func TestCache(t *testing.T) {
+	t.Run("cache hit returns stored value", func(t *testing.T) {
+		cache := NewCache(1 * time.Hour)
+		cache.Set("1.2.3.4", true)
+
+		matches, found := cache.Get("1.2.3.4")
+		if !found {
+			t.Fatal("expected cache hit")
+		}
+		if !matches {
+			t.Fatal("expected matches to be true")
+		}
+	})
+
+	t.Run("cache miss returns not found", func(t *testing.T) {
+		cache := NewCache(1 * time.Hour)
+
+		_, found := cache.Get("1.2.3.4")
+		if found {
+			t.Fatal("expected cache miss")
+		}
+	})
+
+	t.Run("cache stores both match and no-match", func(t *testing.T) {
+		cache := NewCache(1 * time.Hour)
+		cache.Set("1.2.3.4", true)
+		cache.Set("5.6.7.8", false)
+
+		matches1, found1 := cache.Get("1.2.3.4")
+		if !found1 || !matches1 {
+			t.Fatal("expected match result for 1.2.3.4")
+		}
+
+		matches2, found2 := cache.Get("5.6.7.8")
+		if !found2 || matches2 {
+			t.Fatal("expected no-match result for 5.6.7.8")
+		}
+	})
+
+	t.Run("cache stores matched range", func(t *testing.T) {
+		cache := NewCache(1 * time.Hour)
+		cache.SetRange("192.0.2.7", true, &MatchedRange{Range: "192.0.2.0/24", Comment: "Bad subnet"})
+
+		matches, matchedRange, found := cache.GetRange("192.0.2.7")
+		if !found || !matches {
+			t.Fatal("expected match result for 192.0.2.7")
+		}
+		if matchedRange == nil || matchedRange.Range != "192.0.2.0/24" || matchedRange.Comment != "Bad subnet" {
+			t.Fatalf("unexpected matched range: %+v", matchedRange)
+		}
+
+		if matches, found := cache.Get("192.0.2.7"); !found || !matches {
+			t.Fatal("expected Get to report the range match")
+		}
+	})
+
+	t.Run("cache stores metadata", func(t *testing.T) {
+		cache := NewCache(1 * time.Hour)
+		cache.Store("192.0.2.7", LookupResult{Matched: true, Metadata: Metadata{"reason": "scanner"}})
+
+		result, state := cache.Lookup("192.0.2.7")
+		if state != CacheFresh || !result.Matched || result.Metadata["reason"] != "scanner" {
+			t.Fatalf("unexpected cached result %+v in state %d", result, state)
+		}
+	})
+
+	t.Run("expired entries return not found", func(t *testing.T) {
+		cache := NewCache(10 * time.Millisecond)
+		cache.Set("1.2.3.4", true)
+
+		// Wait for expiration
+		time.Sleep(20 * time.Millisecond)
+
+		_, found := cache.Get("1.2.3.4")
+		if found {
+			t.Fatal("expected cache miss for expired entry")
+		}
+	})
+
+	t.Run("clear removes all entries", func(t *testing.T) {
+		cache := NewCache(1 * time.Hour)
+		cache.Set("1.2.3.4", true)
+		cache.Set("5.6.7.8", false)
+
+		cache.Clear()
+
+		if cache.Size() != 0 {
+			t.Fatalf("expected size 0, got %d", cache.Size())
+		}
+	})
+
+	t.Run("size returns entry count", func(t *testing.T) {
+		cache := NewCache(1 * time.Hour)
+		if cache.Size() != 0 {
+			t.Fatal("expected initial size 0")
+		}
+
+		cache.Set("1.2.3.4", true)
+		if cache.Size() != 1 {
+			t.Fatal("expected size 1")
+		}
+
+		cache.Set("5.6.7.8", false)
+		if cache.Size() != 2 {
+			t.Fatal("expected size 2")
+		}
+	})
+
+	t.Run("negative results use negative TTL", func(t *testing.T) {
+		cache := NewCacheWithOptions(CacheOptions{TTL: time.Hour, NegativeTTL: 10 * time.Millisecond})
+		cache.Set("1.2.3.4", true)
+		cache.Set("5.6.7.8", false)
+
+		time.Sleep(20 * time.Millisecond)
+
+		if _, found := cache.Get("1.2.3.4"); !found {
+			t.Fatal("expected positive entry to outlive the negative TTL")
+		}
+		if _, found := cache.Get("5.6.7.8"); found {
+			t.Fatal("expected negative entry to expire")
+		}
+	})
+
+	t.Run("lookup reports stale windows", func(t *testing.T) {
+		cache := NewCacheWithOptions(CacheOptions{
+			TTL:                  10 * time.Millisecond,
+			StaleWhileRevalidate: 40 * time.Millisecond,
+			StaleIfError:         80 * time.Millisecond,
+		})
+		cache.Set("1.2.3.4", true)
+
+		expectState := func(want CacheState) {
+			t.Helper()
+			result, state := cache.Lookup("1.2.3.4")
+			if state != want {
+				t.Fatalf("expected state %d, got %d", want, state)
+			}
+			if state != CacheMiss && !result.Matched {
+				t.Fatal("expected stored result to be returned")
+			}
+		}
+
+		expectState(CacheFresh)
+		time.Sleep(20 * time.Millisecond)
+		expectState(CacheStale)
+		if _, found := cache.Get("1.2.3.4"); found {
+			t.Fatal("expected Get to ignore stale entries")
+		}
+		time.Sleep(40 * time.Millisecond)
+		expectState(CacheExpired)
+		time.Sleep(40 * time.Millisecond)
+		expectState(CacheMiss)
+	})
+
+	t.Run("revalidation is exclusive per key", func(t *testing.T) {
+		cache := NewCache(time.Hour)
+		if !cache.BeginRevalidation("1.2.3.4") {
+			t.Fatal("expected first revalidation to start")
+		}
+		if cache.BeginRevalidation("1.2.3.4") {
+			t.Fatal("expected concurrent revalidation to be refused")
+		}
+		if !cache.BeginRevalidation("5.6.7.8") {
+			t.Fatal("expected revalidation of another key to start")
+		}
+		cache.EndRevalidation("1.2.3.4")
+		if !cache.BeginRevalidation("1.2.3.4") {
+			t.Fatal("expected revalidation to start again once finished")
+		}
+	})
+}
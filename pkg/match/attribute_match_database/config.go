@@ -0,0 +1,354 @@
+package attribute_match_database
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const (
+	DefaultDatabaseConnectionTimeout = 500 * time.Millisecond
+	defaultPostgresPort              = 5432
+	defaultRedisPort                 = 6379
+)
+
+// AttributeMatchDatabaseConfig represents the complete configuration for the attribute-match-database controller
+type AttributeMatchDatabaseConfig struct {
+	Key              KeyConfig        `yaml:"key"`
+	MatchesOnFailure bool             `yaml:"matchesOnFailure"`
+	SyncMode         string           `yaml:"syncMode"`
+	Sync             *SyncConfig      `yaml:"sync"`
+	Metadata         *MetadataConfig  `yaml:"metadata"`
+	Cache            *CacheConfig     `yaml:"cache"`
+	Database         DatabaseConfig   `yaml:"database"`
+	Fallbacks        []DatabaseConfig `yaml:"fallbacks"`
+	FallbackAfter    string           `yaml:"fallbackAfter"`
+}
+
+// CacheConfig represents the caching configuration
+type CacheConfig struct {
+	TTL                  string `yaml:"ttl"`
+	NegativeTTL          string `yaml:"negativeTTL"`
+	StaleWhileRevalidate string `yaml:"staleWhileRevalidate"`
+	StaleIfError         string `yaml:"staleIfError"`
+}
+
+// DatabaseConfig represents the database configuration
+type DatabaseConfig struct {
+	Name              string          `yaml:"name"`
+	Type              string          `yaml:"type"`
+	DataSource        string          `yaml:"datasource"`
+	ConnectionTimeout string          `yaml:"connectionTimeout"`
+	QueryTimeout      string          `yaml:"queryTimeout"`
+	Retry             *RetryConfig    `yaml:"retry"`
+	Redis             *RedisConfig    `yaml:"redis"`
+	Postgres          *PostgresConfig `yaml:"postgres"`
+	HTTP              *HTTPConfig     `yaml:"http"`
+	File              *FileConfig     `yaml:"file"`
+}
+
+// GetName returns the name identifying the database in metrics and logs,
+// which defaults to the shared datasource it uses, or else to its type
+func (c *DatabaseConfig) GetName() string {
+	switch {
+	case c.Name != "":
+		return c.Name
+	case c.DataSource != "":
+		return c.DataSource
+	default:
+		return c.Type
+	}
+}
+
+// ApplyDefaults sets default values for the configuration
+func (c *AttributeMatchDatabaseConfig) ApplyDefaults() {
+	c.Database.applyDefaults()
+	for i := range c.Fallbacks {
+		c.Fallbacks[i].applyDefaults()
+	}
+}
+
+// applyDefaults sets default values for the database. The connection
+// settings of a shared datasource are defaulted with the datasource.
+func (c *DatabaseConfig) applyDefaults() {
+	if c.DataSource == "" {
+		c.Redis.ApplyDefaults()
+		c.Postgres.ApplyDefaults()
+	} else {
+		c.Redis.applyLookupDefaults()
+	}
+	c.HTTP.ApplyDefaults()
+}
+
+// Validate checks the configuration for completeness and correctness
+func (c *AttributeMatchDatabaseConfig) Validate() error {
+	if err := c.Key.validate(); err != nil {
+		return err
+	}
+
+	// Validate cache configuration if present
+	if c.Cache != nil {
+		if c.Cache.TTL == "" {
+			return fmt.Errorf("cache.ttl is required when cache is configured")
+		}
+		cacheTTL, err := time.ParseDuration(c.Cache.TTL)
+		if err != nil {
+			return fmt.Errorf("invalid cache.ttl: %w", err)
+		}
+		if cacheTTL <= 0 {
+			return fmt.Errorf("cache.ttl must be positive")
+		}
+		for _, setting := range []struct{ field, value string }{
+			{"cache.negativeTTL", c.Cache.NegativeTTL},
+			{"cache.staleWhileRevalidate", c.Cache.StaleWhileRevalidate},
+			{"cache.staleIfError", c.Cache.StaleIfError},
+		} {
+			if setting.value == "" {
+				continue
+			}
+			duration, err := time.ParseDuration(setting.value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", setting.field, err)
+			}
+			if duration <= 0 {
+				return fmt.Errorf("%s must be positive", setting.field)
+			}
+		}
+	}
+
+	// Validate database connection timeout if present
+	if c.Database.ConnectionTimeout != "" {
+		databaseTimeout, err := time.ParseDuration(c.Database.ConnectionTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid database.connectionTimeout: %w", err)
+		}
+		if databaseTimeout <= 0 {
+			return fmt.Errorf("database.connectionTimeout must be positive")
+		}
+	}
+
+	if err := c.validateQueryConfig(); err != nil {
+		return err
+	}
+
+	// Validate the shared datasource reference
+	if c.Database.DataSource != "" && c.Database.Type != "redis" && c.Database.Type != "postgres" {
+		return fmt.Errorf("database.datasource is only supported when database.type is 'redis' or 'postgres'")
+	}
+
+	// Validate type-specific configuration
+	switch c.Database.Type {
+	case "redis":
+		if err := c.validateRedisConfig(); err != nil {
+			return err
+		}
+	case "postgres":
+		if err := c.validatePostgresConfig(); err != nil {
+			return err
+		}
+	case "http":
+		if err := c.validateHTTPConfig(); err != nil {
+			return err
+		}
+	case "file":
+		if err := c.validateFileConfig(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("database.type must be 'redis', 'postgres', 'http' or 'file', got '%s'", c.Database.Type)
+	}
+
+	if err := c.validateSyncConfig(); err != nil {
+		return err
+	}
+
+	if err := c.validateMetadataConfig(); err != nil {
+		return err
+	}
+
+	// Push invalidation only affects the cache
+	if setting := c.invalidationSetting(); setting != "" && c.Cache == nil {
+		return fmt.Errorf("%s requires cache to be configured", setting)
+	}
+
+	return c.validateFallbacks()
+}
+
+// validateFallbacks checks the fallback databases, which are validated like
+// the primary one
+func (c *AttributeMatchDatabaseConfig) validateFallbacks() error {
+	if c.FallbackAfter != "" {
+		if len(c.Fallbacks) == 0 {
+			return fmt.Errorf("fallbackAfter requires fallbacks to be configured")
+		}
+		fallbackAfter, err := time.ParseDuration(c.FallbackAfter)
+		if err != nil {
+			return fmt.Errorf("invalid fallbackAfter: %w", err)
+		}
+		if fallbackAfter <= 0 {
+			return fmt.Errorf("fallbackAfter must be positive")
+		}
+		if c.FullSync() {
+			return fmt.Errorf("fallbackAfter is not supported when syncMode is '%s'", SyncModeFull)
+		}
+	}
+
+	names := map[string]bool{c.Database.GetName(): true}
+	for i, fallback := range c.Fallbacks {
+		fallbackConfig := *c
+		fallbackConfig.Database = fallback
+		fallbackConfig.Fallbacks = nil
+		fallbackConfig.FallbackAfter = ""
+		if err := fallbackConfig.Validate(); err != nil {
+			return fmt.Errorf("fallbacks[%d]: %w", i, err)
+		}
+
+		name := fallback.GetName()
+		if names[name] {
+			return fmt.Errorf("fallbacks[%d]: database name '%s' is already used, set a unique database name", i, name)
+		}
+		names[name] = true
+	}
+
+	return nil
+}
+
+// Databases returns the primary database followed by the fallbacks, in the
+// order they are queried
+func (c *AttributeMatchDatabaseConfig) Databases() []DatabaseConfig {
+	return append([]DatabaseConfig{c.Database}, c.Fallbacks...)
+}
+
+// GetFallbackAfter returns the parsed fallbackAfter duration, or 0 if fallbacks
+// are only queried on errors
+func (c *AttributeMatchDatabaseConfig) GetFallbackAfter() time.Duration {
+	fallbackAfter, _ := time.ParseDuration(c.FallbackAfter)
+	return max(fallbackAfter, 0)
+}
+
+// InvalidationEnabled reports whether cached lookups are invalidated by change
+// notifications pushed by the database
+func (c *AttributeMatchDatabaseConfig) InvalidationEnabled() bool {
+	return c.Cache != nil && c.invalidationSetting() != ""
+}
+
+// invalidationSetting returns the name of the setting enabling push
+// invalidation for the configured database type, if any
+func (c *AttributeMatchDatabaseConfig) invalidationSetting() string {
+	switch {
+	case c.Database.Type == "redis" && c.Database.Redis != nil && c.Database.Redis.KeyspaceNotifications:
+		return "database.redis.keyspaceNotifications"
+	case c.Database.Type == "postgres" && c.Database.Postgres != nil && c.Database.Postgres.NotifyChannel != "":
+		return "database.postgres.notifyChannel"
+	}
+	return ""
+}
+
+// GetCacheTTL returns the parsed cache TTL duration, or 0 if caching is disabled
+func (c *AttributeMatchDatabaseConfig) GetCacheTTL() time.Duration {
+	if c.Cache == nil || c.Cache.TTL == "" {
+		return 0
+	}
+	ttl, _ := time.ParseDuration(c.Cache.TTL)
+	return ttl
+}
+
+// GetCacheOptions returns the parsed cache lifetimes; a zero TTL means caching is disabled
+func (c *AttributeMatchDatabaseConfig) GetCacheOptions() CacheOptions {
+	if c.Cache == nil {
+		return CacheOptions{}
+	}
+	negativeTTL, _ := time.ParseDuration(c.Cache.NegativeTTL)
+	staleWhileRevalidate, _ := time.ParseDuration(c.Cache.StaleWhileRevalidate)
+	staleIfError, _ := time.ParseDuration(c.Cache.StaleIfError)
+	ttl := c.GetCacheTTL()
+	if negativeTTL <= 0 {
+		negativeTTL = ttl
+	}
+	return CacheOptions{
+		TTL:                  ttl,
+		NegativeTTL:          negativeTTL,
+		StaleWhileRevalidate: staleWhileRevalidate,
+		StaleIfError:         staleIfError,
+	}
+}
+
+// GetDatabaseConnectionTimeout returns the parsed database connection timeout duration, or default if not specified
+func (c *AttributeMatchDatabaseConfig) GetDatabaseConnectionTimeout() time.Duration {
+	return c.Database.GetConnectionTimeout()
+}
+
+// GetConnectionTimeout returns the parsed connection timeout duration, or default if not specified
+func (c *DatabaseConfig) GetConnectionTimeout() time.Duration {
+	if c.ConnectionTimeout == "" {
+		return DefaultDatabaseConnectionTimeout
+	}
+	timeout, _ := time.ParseDuration(c.ConnectionTimeout)
+
+	if timeout <= 0 {
+		return DefaultDatabaseConnectionTimeout
+	}
+
+	return timeout
+}
+
+// validateCertificateFile checks if a certificate file exists, is readable, and contains valid PEM data
+func validateCertificateFile(path string, description string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("%s path is not valid: %w", description, err)
+	}
+
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return fmt.Errorf("could not read %s file: %w", description, err)
+	}
+
+	if len(data) == 0 {
+		return fmt.Errorf("%s file is empty", description)
+	}
+
+	// Validate it's a valid certificate by attempting to parse it
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(data) {
+		return fmt.Errorf("%s file does not contain valid PEM-encoded certificate(s)", description)
+	}
+
+	return nil
+}
+
+// validateKeyFile checks if a private key file exists, is readable, and contains valid PEM data
+func validateKeyFile(path string, description string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("%s path is not valid: %w", description, err)
+	}
+
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return fmt.Errorf("could not read %s file: %w", description, err)
+	}
+
+	if len(data) == 0 {
+		return fmt.Errorf("%s file is empty", description)
+	}
+
+	// Validate it contains PEM data
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("%s file does not contain valid PEM-encoded data", description)
+	}
+
+	// Check if it's a private key type
+	keyTypes := []string{"RSA PRIVATE KEY", "EC PRIVATE KEY", "PRIVATE KEY", "ENCRYPTED PRIVATE KEY"}
+	validKeyType := slices.Contains(keyTypes, block.Type)
+	if !validKeyType {
+		return fmt.Errorf("%s file does not contain a valid private key (found PEM type: %s)", description, block.Type)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package attribute_match_database
+
+import (
+	"fmt"
+	"time"
+)
+
+// SharedPostgresConfig represents the settings of a PostgreSQL datasource
+// shared by database match controllers: the connection settings of
+// database.postgres, the queries being configured on each controller.
+type SharedPostgresConfig struct {
+	ConnectionTimeout string `yaml:"connectionTimeout"`
+	PostgresConfig    `yaml:",inline"`
+}
+
+// SharedRedisConfig represents the settings of a Redis datasource shared by
+// database match controllers: the connection settings of database.redis, the
+// lookup settings being configured on each controller.
+type SharedRedisConfig struct {
+	ConnectionTimeout string `yaml:"connectionTimeout"`
+	RedisConfig       `yaml:",inline"`
+}
+
+// ApplyDefaults sets default values for the shared postgres configuration
+func (c *SharedPostgresConfig) ApplyDefaults() {
+	c.PostgresConfig.ApplyDefaults()
+}
+
+// Validate checks the shared postgres configuration for completeness and correctness
+func (c *SharedPostgresConfig) Validate() error {
+	if c.hasQuerySettings() {
+		return fmt.Errorf("query, syncQuery and notifyChannel are configured on the controllers using the datasource")
+	}
+	if err := validateSharedConnectionTimeout(c.ConnectionTimeout); err != nil {
+		return err
+	}
+	return validatePostgresConnection(&c.PostgresConfig, "settings")
+}
+
+// GetConnectionTimeout returns the parsed connection timeout, or the default if not specified
+func (c *SharedPostgresConfig) GetConnectionTimeout() time.Duration {
+	return parseSharedConnectionTimeout(c.ConnectionTimeout)
+}
+
+// ApplyDefaults sets default values for the shared redis configuration
+func (c *SharedRedisConfig) ApplyDefaults() {
+	c.RedisConfig.applyConnectionDefaults()
+}
+
+// Validate checks the shared redis configuration for completeness and correctness
+func (c *SharedRedisConfig) Validate() error {
+	if c.hasLookupSettings() {
+		return fmt.Errorf("lookup, keyPrefix, rangeKey, valueType and keyspaceNotifications are configured on the controllers using the datasource")
+	}
+	if err := validateSharedConnectionTimeout(c.ConnectionTimeout); err != nil {
+		return err
+	}
+	return validateRedisConnection(&c.RedisConfig, "settings")
+}
+
+// GetConnectionTimeout returns the parsed connection timeout, or the default if not specified
+func (c *SharedRedisConfig) GetConnectionTimeout() time.Duration {
+	return parseSharedConnectionTimeout(c.ConnectionTimeout)
+}
+
+// validateSharedConnectionTimeout checks the connection timeout of a shared datasource
+func validateSharedConnectionTimeout(value string) error {
+	if value == "" {
+		return nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid settings.connectionTimeout: %w", err)
+	}
+	if timeout <= 0 {
+		return fmt.Errorf("settings.connectionTimeout must be positive")
+	}
+	return nil
+}
+
+// parseSharedConnectionTimeout returns the parsed connection timeout, or the default if not specified
+func parseSharedConnectionTimeout(value string) time.Duration {
+	timeout, _ := time.ParseDuration(value)
+	if timeout <= 0 {
+		return DefaultDatabaseConnectionTimeout
+	}
+	return timeout
+}
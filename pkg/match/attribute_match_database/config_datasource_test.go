@@ -0,0 +1,111 @@
+package attribute_match_database
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValidateSharedDataSourceReference(t *testing.T) {
+	tests := []struct {
+		name     string
+		database DatabaseConfig
+		wantErr  string
+	}{
+		{name: "postgres queries only", database: DatabaseConfig{Type: "postgres", DataSource: "security-db", Postgres: &PostgresConfig{Query: "SELECT 1 FROM blocked WHERE ip = $1"}}},
+		{name: "redis lookup only", database: DatabaseConfig{Type: "redis", DataSource: "cache", Redis: &RedisConfig{Lookup: RedisLookupRange, RangeKey: "blocked-ranges"}}},
+		{name: "postgres connection settings", database: DatabaseConfig{Type: "postgres", DataSource: "security-db", Postgres: &PostgresConfig{Query: "SELECT 1 FROM blocked WHERE ip = $1", Host: "localhost"}}, wantErr: "database.postgres connection settings are not allowed with database.datasource"},
+		{name: "redis connection settings", database: DatabaseConfig{Type: "redis", DataSource: "cache", Redis: &RedisConfig{KeyPrefix: "blocked:", PoolSize: 5}}, wantErr: "database.redis connection settings are not allowed with database.datasource"},
+		{name: "missing query", database: DatabaseConfig{Type: "postgres", DataSource: "security-db", Postgres: &PostgresConfig{}}, wantErr: "database.postgres.query is required"},
+		{name: "http type", database: DatabaseConfig{Type: "http", DataSource: "lookup", HTTP: &HTTPConfig{URL: "https://lookup.internal/{key}"}}, wantErr: "database.datasource is only supported when database.type is 'redis' or 'postgres'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := AttributeMatchDatabaseConfig{Key: ipKey, Database: tt.database}
+			config.ApplyDefaults()
+
+			err := config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDatabaseGetName(t *testing.T) {
+	tests := []struct {
+		database DatabaseConfig
+		want     string
+	}{
+		{DatabaseConfig{Type: "redis"}, "redis"},
+		{DatabaseConfig{Type: "redis", DataSource: "cache"}, "cache"},
+		{DatabaseConfig{Name: "blocked", Type: "redis", DataSource: "cache"}, "blocked"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.database.GetName(); got != tt.want {
+			t.Fatalf("expected name %q, got %q", tt.want, got)
+		}
+	}
+}
+
+func TestValidateSharedDataSourceConfig(t *testing.T) {
+	setEnv(t, "PG_USER", "user")
+	setEnv(t, "PG_PASS", "pass")
+
+	postgres := PostgresConfig{Host: "localhost", DatabaseName: "security", UsernameEnv: "PG_USER", PasswordEnv: "PG_PASS", Pool: &PostgresPoolConfig{MaxConnections: 20}}
+	withQuery := postgres
+	withQuery.Query = "SELECT 1 FROM blocked WHERE ip = $1"
+	withoutHost := postgres
+	withoutHost.Host = ""
+
+	tests := []struct {
+		name    string
+		config  interface{ Validate() error }
+		wantErr string
+	}{
+		{name: "postgres connection", config: &SharedPostgresConfig{ConnectionTimeout: "1s", PostgresConfig: postgres}},
+		{name: "postgres query", config: &SharedPostgresConfig{PostgresConfig: withQuery}, wantErr: "query, syncQuery and notifyChannel are configured on the controllers"},
+		{name: "postgres without host", config: &SharedPostgresConfig{PostgresConfig: withoutHost}, wantErr: "settings.host is required"},
+		{name: "invalid connection timeout", config: &SharedPostgresConfig{ConnectionTimeout: "0s", PostgresConfig: postgres}, wantErr: "settings.connectionTimeout must be positive"},
+		{name: "redis connection", config: &SharedRedisConfig{RedisConfig: RedisConfig{Host: "localhost", PoolSize: 20}}},
+		{name: "redis key prefix", config: &SharedRedisConfig{RedisConfig: RedisConfig{Host: "localhost", KeyPrefix: "blocked:"}}, wantErr: "keyPrefix, rangeKey, valueType and keyspaceNotifications are configured on the controllers"},
+		{name: "redis cluster without addresses", config: &SharedRedisConfig{RedisConfig: RedisConfig{Mode: RedisModeCluster}}, wantErr: "settings.cluster.addresses is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			switch config := tt.config.(type) {
+			case *SharedPostgresConfig:
+				config.ApplyDefaults()
+			case *SharedRedisConfig:
+				config.ApplyDefaults()
+			}
+
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewSharedDataSource_Undefined(t *testing.T) {
+	database := DatabaseConfig{Type: "redis", DataSource: "cache", Redis: &RedisConfig{KeyPrefix: "blocked:"}}
+	if _, err := newSharedDataSource(context.Background(), database); err == nil || !strings.Contains(err.Error(), "datasource 'cache' is not defined") {
+		t.Fatalf("expected not defined error, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package attribute_match_database
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateFallbacks(t *testing.T) {
+	snapshotFile := filepath.Join(t.TempDir(), "blocked.txt")
+	if err := os.WriteFile(snapshotFile, []byte("10.0.0.0/8\n"), 0o600); err != nil {
+		t.Fatalf("failed to write snapshot file: %v", err)
+	}
+	redis := DatabaseConfig{Type: "redis", Redis: &RedisConfig{KeyPrefix: "blocked:", Host: "localhost", Port: 6379}}
+	file := DatabaseConfig{Type: "file", File: &FileConfig{Path: snapshotFile}}
+
+	tests := []struct {
+		name    string
+		config  AttributeMatchDatabaseConfig
+		wantErr string
+	}{
+		{name: "file fallback", config: AttributeMatchDatabaseConfig{Database: redis, Fallbacks: []DatabaseConfig{file}, FallbackAfter: "50ms"}},
+		{name: "named fallback of the same type", config: AttributeMatchDatabaseConfig{Database: redis, Fallbacks: []DatabaseConfig{{Name: "replica", Type: "redis", Redis: redis.Redis}}}},
+		{name: "duplicate name", config: AttributeMatchDatabaseConfig{Database: redis, Fallbacks: []DatabaseConfig{redis}}, wantErr: "fallbacks[0]: database name 'redis' is already used"},
+		{name: "invalid fallback", config: AttributeMatchDatabaseConfig{Database: redis, Fallbacks: []DatabaseConfig{{Type: "file", File: &FileConfig{}}}}, wantErr: "fallbacks[0]: database.file.path is required"},
+		{name: "fallbackAfter without fallbacks", config: AttributeMatchDatabaseConfig{Database: redis, FallbackAfter: "50ms"}, wantErr: "fallbackAfter requires fallbacks"},
+		{name: "invalid fallbackAfter", config: AttributeMatchDatabaseConfig{Database: redis, Fallbacks: []DatabaseConfig{file}, FallbackAfter: "-1s"}, wantErr: "fallbackAfter must be positive"},
+		{name: "fallbackAfter with full sync", config: AttributeMatchDatabaseConfig{SyncMode: SyncModeFull, Database: redis, Fallbacks: []DatabaseConfig{file}, FallbackAfter: "1s"}, wantErr: "fallbackAfter is not supported"},
+		{name: "http fallback with full sync", config: AttributeMatchDatabaseConfig{SyncMode: SyncModeFull, Database: file, Fallbacks: []DatabaseConfig{{Type: "http", HTTP: &HTTPConfig{URL: "https://lookup.internal/{key}"}}}}, wantErr: "fallbacks[0]: syncMode 'full' is not supported when database.type is 'http'"},
+		{name: "missing file", config: AttributeMatchDatabaseConfig{Database: DatabaseConfig{Type: "file", File: &FileConfig{Path: snapshotFile + ".missing"}}}, wantErr: "database.file.path is not readable"},
+		{name: "invalid reload interval", config: AttributeMatchDatabaseConfig{Database: DatabaseConfig{Type: "file", File: &FileConfig{Path: snapshotFile, ReloadInterval: "0s"}}}, wantErr: "database.file.reloadInterval must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.Key = ipKey
+			config.ApplyDefaults()
+
+			err := config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
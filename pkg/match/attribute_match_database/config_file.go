@@ -0,0 +1,58 @@
+package attribute_match_database
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const defaultFileReloadInterval = 30 * time.Second
+
+// FileConfig represents the configuration of a local snapshot file. Each line
+// holds a key, optionally followed by whitespace and a comment; blank lines
+// and lines starting with # are skipped.
+type FileConfig struct {
+	Path           string `yaml:"path"`
+	ReloadInterval string `yaml:"reloadInterval"`
+}
+
+// GetReloadInterval returns the parsed reload interval, or the default if not specified
+func (c *FileConfig) GetReloadInterval() time.Duration {
+	interval, _ := time.ParseDuration(c.ReloadInterval)
+	if interval <= 0 {
+		return defaultFileReloadInterval
+	}
+	return interval
+}
+
+// validateFileConfig checks the file-specific configuration
+func (c *AttributeMatchDatabaseConfig) validateFileConfig() error {
+	if c.Database.File == nil {
+		return fmt.Errorf("database.file configuration is required when database.type is 'file'")
+	}
+
+	config := c.Database.File
+
+	if config.Path == "" {
+		return fmt.Errorf("database.file.path is required")
+	}
+	info, err := os.Stat(config.Path)
+	if err != nil {
+		return fmt.Errorf("database.file.path is not readable: %w", err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("database.file.path '%s' is a directory", config.Path)
+	}
+
+	if config.ReloadInterval != "" {
+		interval, err := time.ParseDuration(config.ReloadInterval)
+		if err != nil {
+			return fmt.Errorf("invalid database.file.reloadInterval: %w", err)
+		}
+		if interval <= 0 {
+			return fmt.Errorf("database.file.reloadInterval must be positive")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package attribute_match_database
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHTTPTimeout = 2 * time.Second
+	// httpKeyPlaceholder is replaced by the lookup key in the URL and body
+	httpKeyPlaceholder = "{key}"
+)
+
+// HTTPConfig represents HTTP-specific configuration. The lookup key is
+// templated into the URL and body as {key}.
+type HTTPConfig struct {
+	URL                string            `yaml:"url"`
+	Method             string            `yaml:"method"`
+	Body               string            `yaml:"body"`
+	Headers            map[string]string `yaml:"headers"`
+	BearerTokenEnv     string            `yaml:"bearerTokenEnv"`
+	MatchStatusCodes   []int             `yaml:"matchStatusCodes"`
+	NoMatchStatusCodes []int             `yaml:"noMatchStatusCodes"`
+	MatchPointer       string            `yaml:"matchPointer"`
+	HealthURL          string            `yaml:"healthUrl"`
+	Timeout            string            `yaml:"timeout"`
+	TLS                *HTTPTLSConfig    `yaml:"tls"`
+}
+
+// HTTPTLSConfig represents TLS configuration for HTTP
+type HTTPTLSConfig struct {
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+	CACert             string `yaml:"caCert"`
+	ClientCert         string `yaml:"clientCert"`
+	ClientKey          string `yaml:"clientKey"`
+}
+
+// ApplyDefaults sets default values for the http configuration
+func (c *HTTPConfig) ApplyDefaults() {
+	if c != nil {
+		if c.Method == "" {
+			c.Method = http.MethodGet
+		}
+		c.Method = strings.ToUpper(c.Method)
+		if len(c.MatchStatusCodes) == 0 {
+			c.MatchStatusCodes = []int{http.StatusOK}
+		}
+		if len(c.NoMatchStatusCodes) == 0 {
+			c.NoMatchStatusCodes = []int{http.StatusNotFound}
+		}
+	}
+}
+
+// GetTimeout returns the parsed request timeout, or the default if not specified
+func (c *HTTPConfig) GetTimeout() time.Duration {
+	timeout, _ := time.ParseDuration(c.Timeout)
+	if timeout <= 0 {
+		return defaultHTTPTimeout
+	}
+	return timeout
+}
+
+// validateHTTPConfig checks the HTTP-specific configuration
+func (c *AttributeMatchDatabaseConfig) validateHTTPConfig() error {
+	if c.Database.HTTP == nil {
+		return fmt.Errorf("database.http configuration is required when database.type is 'http'")
+	}
+
+	config := c.Database.HTTP
+
+	if err := validateHTTPURL(config.URL, "database.http.url"); err != nil {
+		return err
+	}
+	if !strings.Contains(config.URL, httpKeyPlaceholder) && !strings.Contains(config.Body, httpKeyPlaceholder) {
+		return fmt.Errorf("database.http.url or database.http.body must contain the %s placeholder", httpKeyPlaceholder)
+	}
+	if config.HealthURL != "" {
+		if err := validateHTTPURL(config.HealthURL, "database.http.healthUrl"); err != nil {
+			return err
+		}
+	}
+
+	switch config.Method {
+	case "", http.MethodGet:
+		if config.Body != "" {
+			return fmt.Errorf("database.http.body requires database.http.method 'POST'")
+		}
+	case http.MethodPost:
+	default:
+		return fmt.Errorf("database.http.method must be 'GET' or 'POST', got '%s'", config.Method)
+	}
+
+	for name := range config.Headers {
+		if name == "" || strings.ContainsAny(name, " :\t\r\n") {
+			return fmt.Errorf("database.http.headers: invalid header name '%s'", name)
+		}
+	}
+
+	if config.BearerTokenEnv != "" {
+		if _, exists := os.LookupEnv(config.BearerTokenEnv); !exists {
+			return fmt.Errorf("environment variable '%s' not found", config.BearerTokenEnv)
+		}
+	}
+
+	for _, code := range append(slices.Clone(config.MatchStatusCodes), config.NoMatchStatusCodes...) {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("database.http status codes must be between 100 and 599, got %d", code)
+		}
+	}
+	for _, code := range config.MatchStatusCodes {
+		if slices.Contains(config.NoMatchStatusCodes, code) {
+			return fmt.Errorf("database.http status code %d is both a match and a no-match status code", code)
+		}
+	}
+
+	if config.MatchPointer != "" && !strings.HasPrefix(config.MatchPointer, "/") {
+		return fmt.Errorf("database.http.matchPointer must be a JSON pointer starting with '/', got '%s'", config.MatchPointer)
+	}
+
+	if config.Timeout != "" {
+		timeout, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid database.http.timeout: %w", err)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("database.http.timeout must be positive")
+		}
+	}
+
+	// Validate TLS configuration
+	if config.TLS != nil {
+		if err := validateHTTPTLS(config.TLS); err != nil {
+			return fmt.Errorf("invalid http TLS configuration: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateHTTPURL checks that a URL template is an absolute http(s) URL
+func validateHTTPURL(value, field string) error {
+	if value == "" {
+		return fmt.Errorf("%s is required", field)
+	}
+	parsed, err := url.Parse(strings.ReplaceAll(value, httpKeyPlaceholder, "key"))
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", field, err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%s must be an absolute http or https URL, got '%s'", field, value)
+	}
+	return nil
+}
+
+// validateHTTPTLS ensures optional HTTP TLS settings point to valid certificates/keys and are consistent.
+func validateHTTPTLS(tls *HTTPTLSConfig) error {
+	if tls.CACert != "" {
+		if err := validateCertificateFile(tls.CACert, "CA certificate"); err != nil {
+			return err
+		}
+	}
+
+	if tls.ClientCert != "" {
+		if err := validateCertificateFile(tls.ClientCert, "client certificate"); err != nil {
+			return err
+		}
+	}
+
+	if tls.ClientKey != "" {
+		if err := validateKeyFile(tls.ClientKey, "client key"); err != nil {
+			return err
+		}
+	}
+
+	// Both client cert and key must be provided together
+	if (tls.ClientCert != "" && tls.ClientKey == "") || (tls.ClientCert == "" && tls.ClientKey != "") {
+		return fmt.Errorf("both clientCert and clientKey must be provided for mutual TLS")
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package attribute_match_database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateHTTPConfig(t *testing.T) {
+	fixtures := createTLSFixtures(t)
+	setEnv(t, "HTTP_LOOKUP_TOKEN", "token")
+
+	tests := []struct {
+		name    string
+		config  HTTPConfig
+		wantErr string
+	}{
+		{name: "get", config: HTTPConfig{URL: "https://lookup.internal/keys/{key}"}},
+		{name: "post", config: HTTPConfig{
+			URL:            "https://lookup.internal/lookup",
+			Method:         "post",
+			Body:           `{"key":"{key}"}`,
+			Headers:        map[string]string{"X-Client": "authz"},
+			BearerTokenEnv: "HTTP_LOOKUP_TOKEN",
+			MatchPointer:   "/blocked",
+			HealthURL:      "https://lookup.internal/healthz",
+			Timeout:        "500ms",
+			TLS:            &HTTPTLSConfig{CACert: fixtures.caCertPath, ClientCert: fixtures.clientCertPath, ClientKey: fixtures.clientKeyPath},
+		}},
+		{name: "missing url", config: HTTPConfig{}, wantErr: "database.http.url is required"},
+		{name: "relative url", config: HTTPConfig{URL: "/keys/{key}"}, wantErr: "must be an absolute http or https URL"},
+		{name: "missing placeholder", config: HTTPConfig{URL: "https://lookup.internal/keys"}, wantErr: "must contain the {key} placeholder"},
+		{name: "invalid health url", config: HTTPConfig{URL: "https://lookup.internal/{key}", HealthURL: "ftp://lookup.internal"}, wantErr: "database.http.healthUrl must be"},
+		{name: "get with body", config: HTTPConfig{URL: "https://lookup.internal/lookup", Body: `{"key":"{key}"}`}, wantErr: "requires database.http.method 'POST'"},
+		{name: "unsupported method", config: HTTPConfig{URL: "https://lookup.internal/{key}", Method: "delete"}, wantErr: "must be 'GET' or 'POST'"},
+		{name: "invalid header", config: HTTPConfig{URL: "https://lookup.internal/{key}", Headers: map[string]string{"X Client": "a"}}, wantErr: "invalid header name"},
+		{name: "missing token env", config: HTTPConfig{URL: "https://lookup.internal/{key}", BearerTokenEnv: "HTTP_LOOKUP_MISSING"}, wantErr: "environment variable 'HTTP_LOOKUP_MISSING' not found"},
+		{name: "invalid status code", config: HTTPConfig{URL: "https://lookup.internal/{key}", MatchStatusCodes: []int{600}}, wantErr: "between 100 and 599"},
+		{name: "overlapping status codes", config: HTTPConfig{URL: "https://lookup.internal/{key}", MatchStatusCodes: []int{200, 404}}, wantErr: "both a match and a no-match"},
+		{name: "invalid pointer", config: HTTPConfig{URL: "https://lookup.internal/{key}", MatchPointer: "blocked"}, wantErr: "must be a JSON pointer"},
+		{name: "invalid timeout", config: HTTPConfig{URL: "https://lookup.internal/{key}", Timeout: "soon"}, wantErr: "invalid database.http.timeout"},
+		{name: "negative timeout", config: HTTPConfig{URL: "https://lookup.internal/{key}", Timeout: "-1s"}, wantErr: "must be positive"},
+		{name: "client cert without key", config: HTTPConfig{URL: "https://lookup.internal/{key}", TLS: &HTTPTLSConfig{ClientCert: fixtures.clientCertPath}}, wantErr: "invalid http TLS configuration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpConfig := tt.config
+			config := &AttributeMatchDatabaseConfig{
+				Key:      ipKey,
+				Database: DatabaseConfig{Type: "http", HTTP: &httpConfig},
+			}
+			config.ApplyDefaults()
+
+			err := config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("requires http config when type is http", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{Key: ipKey, Database: DatabaseConfig{Type: "http"}}
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "database.http configuration is required") {
+			t.Fatalf("expected missing http config error, got %v", err)
+		}
+	})
+
+	t.Run("full sync is not supported", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{
+			Key:      ipKey,
+			SyncMode: SyncModeFull,
+			Database: DatabaseConfig{Type: "http", HTTP: &HTTPConfig{URL: "https://lookup.internal/{key}"}},
+		}
+		config.ApplyDefaults()
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "not supported when database.type is 'http'") {
+			t.Fatalf("expected full sync error, got %v", err)
+		}
+	})
+}
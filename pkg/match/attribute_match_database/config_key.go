@@ -0,0 +1,87 @@
+package attribute_match_database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// KeySourceIP keys lookups on the downstream client IP address
+	KeySourceIP = "ip"
+	// KeySourceASN keys lookups on the ASN reported by a maxmind-asn analysis controller
+	KeySourceASN = "asn"
+	// KeySourceHeader keys lookups on a request header
+	KeySourceHeader = "header"
+	// KeySourcePath keys lookups on the request path
+	KeySourcePath = "path"
+	// KeySourceContextExtension keys lookups on a context extension set by Envoy
+	KeySourceContextExtension = "contextExtension"
+)
+
+// KeyConfig represents where the lookup key is extracted from. An optional
+// pattern narrows the extracted value down to one of its capture groups.
+type KeyConfig struct {
+	Source  string `yaml:"source"`
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+	Group   *int   `yaml:"group"`
+}
+
+// validate checks the key extraction settings
+func (k *KeyConfig) validate() error {
+	switch k.Source {
+	case KeySourceIP, KeySourceASN:
+		if k.Name != "" {
+			return fmt.Errorf("key.name is not supported when key.source is '%s'", k.Source)
+		}
+		if k.Pattern != "" {
+			return fmt.Errorf("key.pattern is not supported when key.source is '%s'", k.Source)
+		}
+	case KeySourceHeader, KeySourceContextExtension:
+		if k.Name == "" {
+			return fmt.Errorf("key.name is required when key.source is '%s'", k.Source)
+		}
+		if k.Source == KeySourceHeader && strings.ContainsAny(k.Name, " :\t\r\n") {
+			return fmt.Errorf("key.name: invalid header name '%s'", k.Name)
+		}
+	case KeySourcePath:
+		if k.Name != "" {
+			return fmt.Errorf("key.name is not supported when key.source is '%s'", k.Source)
+		}
+		if k.Pattern == "" {
+			return fmt.Errorf("key.pattern is required when key.source is '%s'", k.Source)
+		}
+	case "":
+		return fmt.Errorf("key.source is required")
+	default:
+		return fmt.Errorf("key.source must be one of '%s', '%s', '%s', '%s' or '%s', got '%s'",
+			KeySourceIP, KeySourceASN, KeySourceHeader, KeySourcePath, KeySourceContextExtension, k.Source)
+	}
+
+	if k.Pattern == "" {
+		if k.Group != nil {
+			return fmt.Errorf("key.group requires key.pattern to be configured")
+		}
+		return nil
+	}
+
+	pattern, err := regexp.Compile(k.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid key.pattern: %w", err)
+	}
+	if group := k.group(pattern); group < 0 || group > pattern.NumSubexp() {
+		return fmt.Errorf("key.group must be between 0 and %d, got %d", pattern.NumSubexp(), group)
+	}
+
+	return nil
+}
+
+// group returns the capture group holding the key, by default the first one
+// or the whole match when the pattern has no capture groups
+func (k *KeyConfig) group(pattern *regexp.Regexp) int {
+	if k.Group != nil {
+		return *k.Group
+	}
+	return min(1, pattern.NumSubexp())
+}
@@ -0,0 +1,60 @@
+package attribute_match_database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeyConfig(t *testing.T) {
+	group := func(group int) *int { return &group }
+
+	tests := []struct {
+		name    string
+		config  KeyConfig
+		wantErr string
+	}{
+		{name: "ip", config: KeyConfig{Source: KeySourceIP}},
+		{name: "asn", config: KeyConfig{Source: KeySourceASN}},
+		{name: "header", config: KeyConfig{Source: KeySourceHeader, Name: "x-api-key"}},
+		{name: "path", config: KeyConfig{Source: KeySourcePath, Pattern: `^/tenants/([^/]+)`}},
+		{name: "context extension with pattern", config: KeyConfig{Source: KeySourceContextExtension, Name: "ja3", Pattern: `^[0-9a-f]{32}$`, Group: group(0)}},
+		{name: "missing source", config: KeyConfig{}, wantErr: "key.source is required"},
+		{name: "unknown source", config: KeyConfig{Source: "cookie"}, wantErr: "key.source must be one of"},
+		{name: "ip with name", config: KeyConfig{Source: KeySourceIP, Name: "x"}, wantErr: "key.name is not supported"},
+		{name: "asn with pattern", config: KeyConfig{Source: KeySourceASN, Pattern: `\d+`}, wantErr: "key.pattern is not supported"},
+		{name: "header without name", config: KeyConfig{Source: KeySourceHeader}, wantErr: "key.name is required"},
+		{name: "invalid header name", config: KeyConfig{Source: KeySourceHeader, Name: "x api key"}, wantErr: "invalid header name"},
+		{name: "path without pattern", config: KeyConfig{Source: KeySourcePath}, wantErr: "key.pattern is required"},
+		{name: "invalid pattern", config: KeyConfig{Source: KeySourcePath, Pattern: `^/(`}, wantErr: "invalid key.pattern"},
+		{name: "group out of range", config: KeyConfig{Source: KeySourcePath, Pattern: `^/([^/]+)`, Group: group(2)}, wantErr: "key.group must be between 0 and 1"},
+		{name: "group without pattern", config: KeyConfig{Source: KeySourceHeader, Name: "x-api-key", Group: group(1)}, wantErr: "key.group requires key.pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("range lookup requires ip keys", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{
+			Key: KeyConfig{Source: KeySourceASN},
+			Database: DatabaseConfig{
+				Type:  "redis",
+				Redis: &RedisConfig{Lookup: RedisLookupRange, RangeKey: "ranges", Host: "localhost", Port: 6379},
+			},
+		}
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "requires key.source 'ip'") {
+			t.Fatalf("expected range lookup error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package attribute_match_database
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// metadataPlaceholder matches the {field} placeholders of metadata templates
+var metadataPlaceholder = regexp.MustCompile(`\{([A-Za-z0-9_.-]+)\}`)
+
+// MetadataConfig represents how the payload stored with matched entries is
+// surfaced. Templates reference payload fields as {field}.
+type MetadataConfig struct {
+	Description       string            `yaml:"description"`
+	UpstreamHeaders   map[string]string `yaml:"upstreamHeaders"`
+	DownstreamHeaders map[string]string `yaml:"downstreamHeaders"`
+	LogFields         []string          `yaml:"logFields"`
+	ExpiresAtField    string            `yaml:"expiresAtField"`
+}
+
+// validateMetadataConfig checks the metadata settings against the data source
+func (c *AttributeMatchDatabaseConfig) validateMetadataConfig() error {
+	if c.Metadata == nil {
+		if c.Database.Redis != nil && c.Database.Redis.ValueType != "" {
+			return fmt.Errorf("database.redis.valueType requires metadata to be configured")
+		}
+		return nil
+	}
+
+	if c.FullSync() {
+		return fmt.Errorf("metadata is not supported when syncMode is '%s'", SyncModeFull)
+	}
+	if c.Database.Type == "redis" && c.Database.Redis != nil && c.Database.Redis.Lookup == RedisLookupRange {
+		return fmt.Errorf("metadata is not supported with database.redis.lookup '%s', ranges carry a comment instead", RedisLookupRange)
+	}
+
+	for field, headers := range map[string]map[string]string{
+		"metadata.upstreamHeaders":   c.Metadata.UpstreamHeaders,
+		"metadata.downstreamHeaders": c.Metadata.DownstreamHeaders,
+	} {
+		for name := range headers {
+			if name == "" || strings.ContainsAny(name, " :\t\r\n") {
+				return fmt.Errorf("%s: invalid header name '%s'", field, name)
+			}
+		}
+	}
+	if slices.Contains(c.Metadata.LogFields, "") {
+		return fmt.Errorf("metadata.logFields must not contain empty field names")
+	}
+
+	return nil
+}
+
+// describe renders the description template, or returns an empty string when
+// no template is configured
+func (m *MetadataConfig) describe(metadata Metadata) string {
+	if m == nil || m.Description == "" {
+		return ""
+	}
+	return expandMetadata(m.Description, metadata)
+}
+
+// upstreamHeaders renders the headers added to allowed requests. Every header
+// is set, empty without a payload, so that the headers sent by clients never
+// reach the upstream.
+func (m *MetadataConfig) upstreamHeaders(metadata Metadata) map[string]string {
+	if m == nil || len(m.UpstreamHeaders) == 0 {
+		return nil
+	}
+
+	headers := make(map[string]string, len(m.UpstreamHeaders))
+	for name, template := range m.UpstreamHeaders {
+		headers[name] = expandMetadata(template, metadata)
+	}
+	return headers
+}
+
+// downstreamHeaders renders the headers added to denial responses
+func (m *MetadataConfig) downstreamHeaders(metadata Metadata) map[string]string {
+	if m == nil {
+		return nil
+	}
+	return expandHeaders(m.DownstreamHeaders, metadata)
+}
+
+// logFields returns the configured payload fields as log fields
+func (m *MetadataConfig) logFields(metadata Metadata) []zap.Field {
+	if m == nil || len(metadata) == 0 {
+		return nil
+	}
+
+	var fields []zap.Field
+	for _, name := range m.LogFields {
+		if value, ok := metadata[name]; ok {
+			fields = append(fields, zap.String("metadata."+name, value))
+		}
+	}
+	return fields
+}
+
+// expiresAt returns the expiry stored in the payload, either an RFC 3339
+// timestamp or Unix seconds
+func (m *MetadataConfig) expiresAt(metadata Metadata) (time.Time, bool) {
+	if m == nil || m.ExpiresAtField == "" {
+		return time.Time{}, false
+	}
+
+	value := strings.TrimSpace(metadata[m.ExpiresAtField])
+	if value == "" {
+		return time.Time{}, false
+	}
+	if expiresAt, err := time.Parse(time.RFC3339, value); err == nil {
+		return expiresAt, true
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), true
+	}
+	return time.Time{}, false
+}
+
+// expandHeaders renders header templates, omitting headers rendered empty
+func expandHeaders(templates map[string]string, metadata Metadata) map[string]string {
+	if len(templates) == 0 || len(metadata) == 0 {
+		return nil
+	}
+
+	headers := make(map[string]string, len(templates))
+	for name, template := range templates {
+		if value := expandMetadata(template, metadata); value != "" {
+			headers[name] = value
+		}
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+// expandMetadata replaces the {field} placeholders of a template with payload
+// values; unknown fields expand to an empty string
+func expandMetadata(template string, metadata Metadata) string {
+	return metadataPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		return metadata[placeholder[1:len(placeholder)-1]]
+	})
+}
@@ -0,0 +1,84 @@
+package attribute_match_database
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateMetadataConfig(t *testing.T) {
+	redis := func(valueType string) DatabaseConfig {
+		return DatabaseConfig{
+			Type:  "redis",
+			Redis: &RedisConfig{KeyPrefix: "test:", Host: "localhost", Port: 6379, ValueType: valueType},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		config  *AttributeMatchDatabaseConfig
+		wantErr string
+	}{
+		{name: "metadata from string values", config: &AttributeMatchDatabaseConfig{Key: ipKey, Metadata: &MetadataConfig{Description: "{value}"}, Database: redis("")}},
+		{name: "metadata from hash values", config: &AttributeMatchDatabaseConfig{Key: ipKey, Metadata: &MetadataConfig{UpstreamHeaders: map[string]string{"X-Reason": "{reason}"}}, Database: redis("hash")}},
+		{name: "unknown value type", config: &AttributeMatchDatabaseConfig{Key: ipKey, Metadata: &MetadataConfig{}, Database: redis("json")}, wantErr: "database.redis.valueType must be 'string' or 'hash'"},
+		{name: "value type without metadata", config: &AttributeMatchDatabaseConfig{Key: ipKey, Database: redis("hash")}, wantErr: "database.redis.valueType requires metadata"},
+		{name: "full sync", config: &AttributeMatchDatabaseConfig{Key: ipKey, SyncMode: SyncModeFull, Metadata: &MetadataConfig{}, Database: redis("")}, wantErr: "metadata is not supported when syncMode is 'full'"},
+		{name: "range lookup", config: &AttributeMatchDatabaseConfig{Key: ipKey, Metadata: &MetadataConfig{}, Database: DatabaseConfig{Type: "redis", Redis: &RedisConfig{Lookup: RedisLookupRange, RangeKey: "ranges", Host: "localhost", Port: 6379}}}, wantErr: "metadata is not supported with database.redis.lookup 'range'"},
+		{name: "invalid header name", config: &AttributeMatchDatabaseConfig{Key: ipKey, Metadata: &MetadataConfig{DownstreamHeaders: map[string]string{"X Reason": "{reason}"}}, Database: redis("hash")}, wantErr: "metadata.downstreamHeaders: invalid header name"},
+		{name: "empty log field", config: &AttributeMatchDatabaseConfig{Key: ipKey, Metadata: &MetadataConfig{LogFields: []string{"reason", ""}}, Database: redis("hash")}, wantErr: "metadata.logFields must not contain empty field names"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMetadataRendering(t *testing.T) {
+	config := &MetadataConfig{
+		Description:       "{reason} (owner {owner})",
+		UpstreamHeaders:   map[string]string{"X-Block-Reason": "{reason}", "X-Block-Ticket": "{ticket}"},
+		DownstreamHeaders: map[string]string{"X-Block-Category": "{category}"},
+		LogFields:         []string{"owner", "ticket"},
+		ExpiresAtField:    "expires_at",
+	}
+	metadata := Metadata{"reason": "scanner", "owner": "secops", "category": "abuse", "expires_at": "2030-01-02T03:04:05Z"}
+
+	if got := config.describe(metadata); got != "scanner (owner secops)" {
+		t.Fatalf("unexpected description %q", got)
+	}
+	if got := config.upstreamHeaders(metadata); len(got) != 2 || got["X-Block-Reason"] != "scanner" || got["X-Block-Ticket"] != "" {
+		t.Fatalf("expected upstream headers rendered empty to be kept, got %v", got)
+	}
+	if got := config.downstreamHeaders(metadata); got["X-Block-Category"] != "abuse" {
+		t.Fatalf("unexpected downstream headers %v", got)
+	}
+	if fields := config.logFields(metadata); len(fields) != 1 || fields[0].Key != "metadata.owner" || fields[0].String != "secops" {
+		t.Fatalf("unexpected log fields %v", fields)
+	}
+	if expiresAt, ok := config.expiresAt(metadata); !ok || !expiresAt.Equal(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Fatalf("unexpected expiry %v (%v)", expiresAt, ok)
+	}
+	if expiresAt, ok := config.expiresAt(Metadata{"expires_at": "1700000000"}); !ok || expiresAt.Unix() != 1700000000 {
+		t.Fatalf("expected Unix seconds expiry, got %v (%v)", expiresAt, ok)
+	}
+	if _, ok := config.expiresAt(Metadata{"expires_at": "never"}); ok {
+		t.Fatal("expected unparsable expiry to be ignored")
+	}
+
+	var disabled *MetadataConfig
+	if disabled.describe(metadata) != "" || disabled.upstreamHeaders(metadata) != nil || disabled.logFields(metadata) != nil {
+		t.Fatal("expected nil metadata config to render nothing")
+	}
+}
@@ -1,4 +1,4 @@
-package ip_match_database
+package attribute_match_database
 
 import (
 	"fmt"
@@ -10,22 +10,38 @@ import (
 
 // PostgresConfig represents PostgreSQL-specific configuration
 type PostgresConfig struct {
-	Query        string              `yaml:"query"`
-	Host         string              `yaml:"host"`
-	Port         int                 `yaml:"port"`
-	DatabaseName string              `yaml:"databaseName"`
-	UsernameEnv  string              `yaml:"usernameEnv"`
-	PasswordEnv  string              `yaml:"passwordEnv"`
-	Pool         *PostgresPoolConfig `yaml:"pool"`
-	TLS          *PostgresTLSConfig  `yaml:"tls"`
+	Query            string              `yaml:"query"`
+	Host             string              `yaml:"host"`
+	Port             int                 `yaml:"port"`
+	DatabaseName     string              `yaml:"databaseName"`
+	UsernameEnv      string              `yaml:"usernameEnv"`
+	PasswordEnv      string              `yaml:"passwordEnv"`
+	Pool             *PostgresPoolConfig `yaml:"pool"`
+	TLS              *PostgresTLSConfig  `yaml:"tls"`
+	SyncQuery        string              `yaml:"syncQuery"`
+	NotifyChannel    string              `yaml:"notifyChannel"`
+	StatementTimeout string              `yaml:"statementTimeout"`
+	QueryExecMode    string              `yaml:"queryExecMode"`
 }
 
+// Query execution modes, see pgx.QueryExecMode. Modes other than the default
+// avoid named prepared statements, which transaction-pooling proxies such as
+// PgBouncer do not support.
+const (
+	PostgresExecModeCacheStatement = "cacheStatement"
+	PostgresExecModeCacheDescribe  = "cacheDescribe"
+	PostgresExecModeDescribeExec   = "describeExec"
+	PostgresExecModeExec           = "exec"
+	PostgresExecModeSimpleProtocol = "simpleProtocol"
+)
+
 // PostgresPoolConfig represents connection pool configuration
 type PostgresPoolConfig struct {
 	MaxConnections    int    `yaml:"maxConnections"`
 	MinConnections    int    `yaml:"minConnections"`
 	MaxIdleTime       string `yaml:"maxIdleTime"`
 	ConnectionTimeout string `yaml:"connectionTimeout"`
+	HealthCheckPeriod string `yaml:"healthCheckPeriod"`
 }
 
 // PostgresTLSConfig represents TLS configuration for PostgreSQL
@@ -36,6 +52,17 @@ type PostgresTLSConfig struct {
 	ClientKey  string `yaml:"clientKey"`
 }
 
+// hasConnectionSettings reports whether any connection setting is configured
+func (c *PostgresConfig) hasConnectionSettings() bool {
+	return c.Host != "" || c.Port != 0 || c.DatabaseName != "" || c.UsernameEnv != "" || c.PasswordEnv != "" ||
+		c.Pool != nil || c.TLS != nil || c.StatementTimeout != "" || c.QueryExecMode != ""
+}
+
+// hasQuerySettings reports whether any query setting is configured
+func (c *PostgresConfig) hasQuerySettings() bool {
+	return c.Query != "" || c.SyncQuery != "" || c.NotifyChannel != ""
+}
+
 // ApplyDefaults sets default values for the postgres configuration
 func (c *PostgresConfig) ApplyDefaults() {
 	if c != nil {
@@ -46,48 +73,64 @@ func (c *PostgresConfig) ApplyDefaults() {
 }
 
 // validatePostgresConfig checks the PostgreSQL-specific configuration
-func (c *IpMatchDatabaseConfig) validatePostgresConfig() error {
+func (c *AttributeMatchDatabaseConfig) validatePostgresConfig() error {
 	if c.Database.Postgres == nil {
 		return fmt.Errorf("database.postgres configuration is required when database.type is 'postgres'")
 	}
 
 	pg := c.Database.Postgres
 
-	if pg.Query == "" {
-		return fmt.Errorf("database.postgres.query is required")
-	}
+	// The lookup query is not used when the whole table is mirrored
+	if !c.FullSync() {
+		if pg.Query == "" {
+			return fmt.Errorf("database.postgres.query is required")
+		}
 
-	// Validate query contains exactly one parameter placeholder
-	placeholderRegex := regexp.MustCompile(`\$\d+`)
-	matches := placeholderRegex.FindAllString(pg.Query, -1)
-	if len(matches) != 1 {
-		return fmt.Errorf("database.postgres.query must contain exactly one parameter placeholder ($1), found %d", len(matches))
+		// Validate query contains exactly one parameter placeholder
+		placeholderRegex := regexp.MustCompile(`\$\d+`)
+		matches := placeholderRegex.FindAllString(pg.Query, -1)
+		if len(matches) != 1 {
+			return fmt.Errorf("database.postgres.query must contain exactly one parameter placeholder ($1), found %d", len(matches))
+		}
+		if matches[0] != "$1" {
+			return fmt.Errorf("database.postgres.query must use $1 as the parameter placeholder, found %s", matches[0])
+		}
 	}
-	if matches[0] != "$1" {
-		return fmt.Errorf("database.postgres.query must use $1 as the parameter placeholder, found %s", matches[0])
+
+	// The connection belongs to the shared datasource
+	if c.Database.DataSource != "" {
+		if pg.hasConnectionSettings() {
+			return fmt.Errorf("database.postgres connection settings are not allowed with database.datasource, configure them on the datasource")
+		}
+		return nil
 	}
 
+	return validatePostgresConnection(pg, "database.postgres")
+}
+
+// validatePostgresConnection checks the PostgreSQL connection settings, reported under field
+func validatePostgresConnection(pg *PostgresConfig, field string) error {
 	// Validate host
 	if pg.Host == "" {
-		return fmt.Errorf("database.postgres.host is required")
+		return fmt.Errorf("%s.host is required", field)
 	}
 
 	// Validate port range
 	if pg.Port < 1 || pg.Port > 65535 {
-		return fmt.Errorf("database.postgres.port must be between 1 and 65535")
+		return fmt.Errorf("%s.port must be between 1 and 65535", field)
 	}
 
 	// Validate database name
 	if pg.DatabaseName == "" {
-		return fmt.Errorf("database.postgres.databaseName is required")
+		return fmt.Errorf("%s.databaseName is required", field)
 	}
 
 	if pg.UsernameEnv == "" {
-		return fmt.Errorf("database.postgres.usernameEnv is required")
+		return fmt.Errorf("%s.usernameEnv is required", field)
 	}
 
 	if pg.PasswordEnv == "" {
-		return fmt.Errorf("database.postgres.passwordEnv is required")
+		return fmt.Errorf("%s.passwordEnv is required", field)
 	}
 
 	// Validate username env var exists
@@ -100,6 +143,23 @@ func (c *IpMatchDatabaseConfig) validatePostgresConfig() error {
 		return fmt.Errorf("environment variable '%s' not found", pg.PasswordEnv)
 	}
 
+	if pg.StatementTimeout != "" {
+		statementTimeout, err := time.ParseDuration(pg.StatementTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid %s.statementTimeout: %w", field, err)
+		}
+		if statementTimeout < time.Millisecond {
+			return fmt.Errorf("%s.statementTimeout must be at least 1ms", field)
+		}
+	}
+
+	switch pg.QueryExecMode {
+	case "", PostgresExecModeCacheStatement, PostgresExecModeCacheDescribe, PostgresExecModeDescribeExec, PostgresExecModeExec, PostgresExecModeSimpleProtocol:
+	default:
+		return fmt.Errorf("%s.queryExecMode must be one of '%s', '%s', '%s', '%s' or '%s', got '%s'", field,
+			PostgresExecModeCacheStatement, PostgresExecModeCacheDescribe, PostgresExecModeDescribeExec, PostgresExecModeExec, PostgresExecModeSimpleProtocol, pg.QueryExecMode)
+	}
+
 	// Validate pool configuration if present
 	if pg.Pool != nil {
 		if err := validatePostgresPoolConfig(pg.Pool); err != nil {
@@ -151,6 +211,16 @@ func validatePostgresPoolConfig(pool *PostgresPoolConfig) error {
 		}
 	}
 
+	if pool.HealthCheckPeriod != "" {
+		period, err := time.ParseDuration(pool.HealthCheckPeriod)
+		if err != nil {
+			return fmt.Errorf("invalid pool.healthCheckPeriod: %w", err)
+		}
+		if period <= 0 {
+			return fmt.Errorf("pool.healthCheckPeriod must be positive")
+		}
+	}
+
 	return nil
 }
 
@@ -1,4 +1,4 @@
-package asn_match_database
+package attribute_match_database
 
 import (
 	"os"
@@ -8,7 +8,8 @@ import (
 
 func TestValidatePostgresConfig(t *testing.T) {
 	t.Run("requires postgres config when type is postgres", func(t *testing.T) {
-		config := &ASNMatchDatabaseConfig{
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
 			Database: DatabaseConfig{
 				Type: "postgres",
 			},
@@ -24,7 +25,8 @@ func TestValidatePostgresConfig(t *testing.T) {
 		setEnv(t, "PG_USER", "testuser")
 		setEnv(t, "PG_PASS", "testpass")
 
-		config := &ASNMatchDatabaseConfig{
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
 			Database: DatabaseConfig{
 				Type: "postgres",
 				Postgres: &PostgresConfig{
@@ -59,7 +61,8 @@ func TestValidatePostgresConfig(t *testing.T) {
 		setEnv(t, "PG_USER", "user")
 		setEnv(t, "PG_PASS", "pass")
 
-		config := &ASNMatchDatabaseConfig{
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
 			Database: DatabaseConfig{
 				Type: "postgres",
 				Postgres: &PostgresConfig{
@@ -82,7 +85,8 @@ func TestValidatePostgresConfig(t *testing.T) {
 		setEnv(t, "PG_USER", "user")
 		setEnv(t, "PG_PASS", "pass")
 
-		config := &ASNMatchDatabaseConfig{
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
 			Database: DatabaseConfig{
 				Type: "postgres",
 				Postgres: &PostgresConfig{
@@ -105,7 +109,8 @@ func TestValidatePostgresConfig(t *testing.T) {
 		setEnv(t, "PG_USER", "user")
 		setEnv(t, "PG_PASS", "pass")
 
-		config := &ASNMatchDatabaseConfig{
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
 			Database: DatabaseConfig{
 				Type: "postgres",
 				Postgres: &PostgresConfig{
@@ -128,7 +133,8 @@ func TestValidatePostgresConfig(t *testing.T) {
 		_ = os.Unsetenv("PG_USER_MISSING")
 		setEnv(t, "PG_PASS_PRESENT", "secret")
 
-		config := &ASNMatchDatabaseConfig{
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
 			Database: DatabaseConfig{
 				Type: "postgres",
 				Postgres: &PostgresConfig{
@@ -151,7 +157,8 @@ func TestValidatePostgresConfig(t *testing.T) {
 		setEnv(t, "PG_USER_PRESENT", "user")
 		_ = os.Unsetenv("PG_PASS_MISSING")
 
-		config := &ASNMatchDatabaseConfig{
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
 			Database: DatabaseConfig{
 				Type: "postgres",
 				Postgres: &PostgresConfig{
@@ -174,7 +181,8 @@ func TestValidatePostgresConfig(t *testing.T) {
 		setEnv(t, "PG_USER", "user")
 		setEnv(t, "PG_PASS", "pass")
 
-		config := &ASNMatchDatabaseConfig{
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
 			Database: DatabaseConfig{
 				Type: "postgres",
 				Postgres: &PostgresConfig{
@@ -201,7 +209,8 @@ func TestValidatePostgresConfig(t *testing.T) {
 		setEnv(t, "PG_USER", "user")
 		setEnv(t, "PG_PASS", "pass")
 
-		config := &ASNMatchDatabaseConfig{
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
 			Database: DatabaseConfig{
 				Type: "postgres",
 				Postgres: &PostgresConfig{
@@ -228,7 +237,8 @@ func TestValidatePostgresConfig(t *testing.T) {
 		setEnv(t, "PG_USER", "user")
 		setEnv(t, "PG_PASS", "pass")
 
-		config := &ASNMatchDatabaseConfig{
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
 			Database: DatabaseConfig{
 				Type: "postgres",
 				Postgres: &PostgresConfig{
@@ -257,7 +267,8 @@ func TestValidatePostgresConfig(t *testing.T) {
 		setEnv(t, "PG_USER", "user")
 		setEnv(t, "PG_PASS", "pass")
 
-		config := &ASNMatchDatabaseConfig{
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
 			Database: DatabaseConfig{
 				Type: "postgres",
 				Postgres: &PostgresConfig{
@@ -284,7 +295,8 @@ func TestValidatePostgresConfig(t *testing.T) {
 		setEnv(t, "PG_USER", "user")
 		setEnv(t, "PG_PASS", "pass")
 
-		config := &ASNMatchDatabaseConfig{
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
 			Database: DatabaseConfig{
 				Type: "postgres",
 				Postgres: &PostgresConfig{
@@ -312,7 +324,8 @@ func TestValidatePostgresConfig(t *testing.T) {
 		setEnv(t, "PG_USER", "user")
 		setEnv(t, "PG_PASS", "pass")
 
-		config := &ASNMatchDatabaseConfig{
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
 			Database: DatabaseConfig{
 				Type: "postgres",
 				Postgres: &PostgresConfig{
@@ -0,0 +1,359 @@
+package attribute_match_database
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// RedisLookupKey looks up one Redis key per lookup key
+	RedisLookupKey = "key"
+	// RedisLookupRange looks up the IP address in a sorted set of ranges,
+	// only available with IP lookup keys
+	RedisLookupRange = "range"
+)
+
+const (
+	// RedisValueString reads the value of the matched key
+	RedisValueString = "string"
+	// RedisValueHash reads the fields of the matched hash key
+	RedisValueHash = "hash"
+)
+
+const (
+	// RedisModeStandalone connects to a single Redis node
+	RedisModeStandalone = "standalone"
+	// RedisModeSentinel discovers the master (and replicas) through Redis Sentinel
+	RedisModeSentinel = "sentinel"
+	// RedisModeCluster connects to a Redis Cluster through seed nodes
+	RedisModeCluster = "cluster"
+)
+
+// RedisConfig represents Redis-specific configuration
+type RedisConfig struct {
+	Mode                  string               `yaml:"mode"`
+	Lookup                string               `yaml:"lookup"`
+	KeyPrefix             string               `yaml:"keyPrefix"`
+	RangeKey              string               `yaml:"rangeKey"`
+	Host                  string               `yaml:"host"`
+	Port                  int                  `yaml:"port"`
+	Sentinel              *RedisSentinelConfig `yaml:"sentinel"`
+	Cluster               *RedisClusterConfig  `yaml:"cluster"`
+	ReadFromReplica       bool                 `yaml:"readFromReplica"`
+	KeyspaceNotifications bool                 `yaml:"keyspaceNotifications"`
+	ValueType             string               `yaml:"valueType"`
+	UsernameEnv           string               `yaml:"usernameEnv"`
+	PasswordEnv           string               `yaml:"passwordEnv"`
+	DB                    int                  `yaml:"db"`
+	ReadTimeout           string               `yaml:"readTimeout"`
+	WriteTimeout          string               `yaml:"writeTimeout"`
+	PoolSize              int                  `yaml:"poolSize"`
+	TLS                   *RedisTLSConfig      `yaml:"tls"`
+}
+
+// RedisSentinelConfig represents the Sentinel deployment used to discover the master
+type RedisSentinelConfig struct {
+	MasterName  string   `yaml:"masterName"`
+	Addresses   []string `yaml:"addresses"`
+	UsernameEnv string   `yaml:"usernameEnv"`
+	PasswordEnv string   `yaml:"passwordEnv"`
+}
+
+// RedisClusterConfig represents the seed nodes of a Redis Cluster
+type RedisClusterConfig struct {
+	Addresses []string `yaml:"addresses"`
+}
+
+// RedisTLSConfig represents TLS configuration for Redis
+type RedisTLSConfig struct {
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+	CACert             string `yaml:"caCert"`
+	ClientCert         string `yaml:"clientCert"`
+	ClientKey          string `yaml:"clientKey"`
+}
+
+// ApplyDefaults sets default values for the redis configuration
+func (c *RedisConfig) ApplyDefaults() {
+	c.applyConnectionDefaults()
+	c.applyLookupDefaults()
+}
+
+// applyConnectionDefaults sets default values for the connection settings
+func (c *RedisConfig) applyConnectionDefaults() {
+	if c != nil {
+		if c.Mode == "" {
+			c.Mode = RedisModeStandalone
+		}
+		if c.Port == 0 && c.Mode == RedisModeStandalone {
+			c.Port = defaultRedisPort
+		}
+	}
+}
+
+// applyLookupDefaults sets default values for the lookup settings
+func (c *RedisConfig) applyLookupDefaults() {
+	if c != nil && c.Lookup == "" {
+		c.Lookup = RedisLookupKey
+	}
+}
+
+// hasConnectionSettings reports whether any connection setting is configured
+func (c *RedisConfig) hasConnectionSettings() bool {
+	return c.Mode != "" || c.Host != "" || c.Port != 0 || c.Sentinel != nil || c.Cluster != nil ||
+		c.ReadFromReplica || c.UsernameEnv != "" || c.PasswordEnv != "" || c.DB != 0 ||
+		c.ReadTimeout != "" || c.WriteTimeout != "" || c.PoolSize != 0 || c.TLS != nil
+}
+
+// hasLookupSettings reports whether any lookup setting is configured
+func (c *RedisConfig) hasLookupSettings() bool {
+	return c.Lookup != "" || c.KeyPrefix != "" || c.RangeKey != "" || c.ValueType != "" || c.KeyspaceNotifications
+}
+
+// GetReadTimeout returns the parsed socket read timeout, or 0 for the client default
+func (c *RedisConfig) GetReadTimeout() time.Duration {
+	timeout, _ := time.ParseDuration(c.ReadTimeout)
+	return max(timeout, 0)
+}
+
+// GetWriteTimeout returns the parsed socket write timeout, or 0 for the client default
+func (c *RedisConfig) GetWriteTimeout() time.Duration {
+	timeout, _ := time.ParseDuration(c.WriteTimeout)
+	return max(timeout, 0)
+}
+
+// Addresses returns the endpoints the client initially connects to for the configured mode
+func (c *RedisConfig) Addresses() []string {
+	switch c.Mode {
+	case RedisModeSentinel:
+		if c.Sentinel != nil {
+			return c.Sentinel.Addresses
+		}
+		return nil
+	case RedisModeCluster:
+		if c.Cluster != nil {
+			return c.Cluster.Addresses
+		}
+		return nil
+	default:
+		return []string{net.JoinHostPort(c.Host, strconv.Itoa(c.Port))}
+	}
+}
+
+// validateRedisConfig checks the Redis-specific configuration
+func (c *AttributeMatchDatabaseConfig) validateRedisConfig() error {
+	if c.Database.Redis == nil {
+		return fmt.Errorf("database.redis configuration is required when database.type is 'redis'")
+	}
+
+	redis := c.Database.Redis
+
+	// Validate lookup strategy and its key settings
+	switch redis.Lookup {
+	case "", RedisLookupKey:
+		if redis.KeyPrefix == "" {
+			return fmt.Errorf("database.redis.keyPrefix is required")
+		}
+	case RedisLookupRange:
+		if redis.RangeKey == "" {
+			return fmt.Errorf("database.redis.rangeKey is required when database.redis.lookup is 'range'")
+		}
+		if c.Key.Source != KeySourceIP {
+			return fmt.Errorf("database.redis.lookup 'range' requires key.source '%s'", KeySourceIP)
+		}
+	default:
+		return fmt.Errorf("database.redis.lookup must be 'key' or 'range', got '%s'", redis.Lookup)
+	}
+
+	// Validate how the metadata of matched keys is read
+	switch redis.ValueType {
+	case "", RedisValueString, RedisValueHash:
+	default:
+		return fmt.Errorf("database.redis.valueType must be 'string' or 'hash', got '%s'", redis.ValueType)
+	}
+
+	// The connection belongs to the shared datasource
+	if c.Database.DataSource != "" {
+		if redis.hasConnectionSettings() {
+			return fmt.Errorf("database.redis connection settings are not allowed with database.datasource, configure them on the datasource")
+		}
+		return nil
+	}
+
+	if err := validateRedisConnection(redis, "database.redis"); err != nil {
+		return err
+	}
+
+	return validateRedisKeyspaceNotifications(redis, redis.Mode)
+}
+
+// validateRedisKeyspaceNotifications checks that keyspace notifications are
+// available in the Redis mode of the connection
+func validateRedisKeyspaceNotifications(redis *RedisConfig, mode string) error {
+	if redis.KeyspaceNotifications && mode == RedisModeCluster {
+		// Keyspace events are only published by the node owning the key
+		return fmt.Errorf("database.redis.keyspaceNotifications is not supported in Redis mode 'cluster'")
+	}
+	return nil
+}
+
+// validateRedisConnection checks the Redis connection settings, reported under field
+func validateRedisConnection(redis *RedisConfig, field string) error {
+	// Validate topology-specific settings
+	switch redis.Mode {
+	case "", RedisModeStandalone:
+		if redis.Host == "" {
+			return fmt.Errorf("%s.host is required", field)
+		}
+		if redis.Port < 1 || redis.Port > 65535 {
+			return fmt.Errorf("%s.port must be between 1 and 65535", field)
+		}
+		if redis.ReadFromReplica {
+			return fmt.Errorf("%s.readFromReplica requires %s.mode 'sentinel' or 'cluster'", field, field)
+		}
+	case RedisModeSentinel:
+		if err := validateRedisSentinel(redis.Sentinel, field); err != nil {
+			return err
+		}
+	case RedisModeCluster:
+		if redis.Cluster == nil || len(redis.Cluster.Addresses) == 0 {
+			return fmt.Errorf("%s.cluster.addresses is required when %s.mode is 'cluster'", field, field)
+		}
+		if err := validateRedisAddresses(redis.Cluster.Addresses, field+".cluster.addresses"); err != nil {
+			return err
+		}
+		if redis.DB != 0 {
+			return fmt.Errorf("%s.db must be 0 when %s.mode is 'cluster'", field, field)
+		}
+	default:
+		return fmt.Errorf("%s.mode must be 'standalone', 'sentinel' or 'cluster', got '%s'", field, redis.Mode)
+	}
+
+	// Validate DB number
+	if redis.DB < 0 {
+		return fmt.Errorf("%s.db must be non-negative", field)
+	}
+
+	// Validate client tuning
+	for _, setting := range []struct{ field, value string }{
+		{field + ".readTimeout", redis.ReadTimeout},
+		{field + ".writeTimeout", redis.WriteTimeout},
+	} {
+		if setting.value == "" {
+			continue
+		}
+		timeout, err := time.ParseDuration(setting.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", setting.field, err)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("%s must be positive", setting.field)
+		}
+	}
+	if redis.PoolSize < 0 {
+		return fmt.Errorf("%s.poolSize must be non-negative", field)
+	}
+
+	// Validate username env var exists if specified
+	if redis.UsernameEnv != "" {
+		if _, exists := os.LookupEnv(redis.UsernameEnv); !exists {
+			return fmt.Errorf("environment variable '%s' not found", redis.UsernameEnv)
+		}
+	}
+
+	// Validate password env var exists if specified
+	if redis.PasswordEnv != "" {
+		if _, exists := os.LookupEnv(redis.PasswordEnv); !exists {
+			return fmt.Errorf("environment variable '%s' not found", redis.PasswordEnv)
+		}
+	}
+
+	// Validate TLS configuration
+	if redis.TLS != nil {
+		if err := validateRedisTLS(redis.TLS); err != nil {
+			return fmt.Errorf("invalid redis TLS configuration: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateRedisSentinel checks the Sentinel discovery settings, reported under field
+func validateRedisSentinel(sentinel *RedisSentinelConfig, field string) error {
+	if sentinel == nil {
+		return fmt.Errorf("%s.sentinel configuration is required when %s.mode is 'sentinel'", field, field)
+	}
+
+	if sentinel.MasterName == "" {
+		return fmt.Errorf("%s.sentinel.masterName is required", field)
+	}
+
+	if len(sentinel.Addresses) == 0 {
+		return fmt.Errorf("%s.sentinel.addresses is required", field)
+	}
+
+	if err := validateRedisAddresses(sentinel.Addresses, field+".sentinel.addresses"); err != nil {
+		return err
+	}
+
+	// Validate sentinel credentials env vars exist if specified
+	if sentinel.UsernameEnv != "" {
+		if _, exists := os.LookupEnv(sentinel.UsernameEnv); !exists {
+			return fmt.Errorf("environment variable '%s' not found", sentinel.UsernameEnv)
+		}
+	}
+
+	if sentinel.PasswordEnv != "" {
+		if _, exists := os.LookupEnv(sentinel.PasswordEnv); !exists {
+			return fmt.Errorf("environment variable '%s' not found", sentinel.PasswordEnv)
+		}
+	}
+
+	return nil
+}
+
+// validateRedisAddresses ensures every address is a host:port pair with a valid port
+func validateRedisAddresses(addresses []string, field string) error {
+	for _, address := range addresses {
+		host, portStr, err := net.SplitHostPort(address)
+		if err != nil || host == "" {
+			return fmt.Errorf("%s entry '%s' must be in host:port form", field, address)
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("%s entry '%s' has an invalid port", field, address)
+		}
+	}
+	return nil
+}
+
+// validateRedisTLS ensures optional Redis TLS settings point to valid certificates/keys and are consistent.
+func validateRedisTLS(tls *RedisTLSConfig) error {
+	// Validate certificate files exist and are readable if specified
+	if tls.CACert != "" {
+		if err := validateCertificateFile(tls.CACert, "CA certificate"); err != nil {
+			return err
+		}
+	}
+
+	if tls.ClientCert != "" {
+		if err := validateCertificateFile(tls.ClientCert, "client certificate"); err != nil {
+			return err
+		}
+	}
+
+	if tls.ClientKey != "" {
+		if err := validateKeyFile(tls.ClientKey, "client key"); err != nil {
+			return err
+		}
+	}
+
+	// Both client cert and key must be provided together
+	if (tls.ClientCert != "" && tls.ClientKey == "") || (tls.ClientCert == "" && tls.ClientKey != "") {
+		return fmt.Errorf("both clientCert and clientKey must be provided for mutual TLS")
+	}
+
+	return nil
+}
@@ -0,0 +1,363 @@
+package attribute_match_database
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestValidateRedisConfig(t *testing.T) {
+	t.Run("requires redis config when type is redis", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
+			Database: DatabaseConfig{
+				Type: "redis",
+			},
+		}
+
+		if err := config.Validate(); err == nil {
+			t.Fatal("expected validation error when redis config is missing")
+		}
+	})
+
+	t.Run("valid redis config passes", func(t *testing.T) {
+		fixtures := createTLSFixtures(t)
+
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
+			Database: DatabaseConfig{
+				Type: "redis",
+				Redis: &RedisConfig{
+					KeyPrefix: "test:",
+					Host:      "localhost",
+					Port:      6379,
+					DB:        0,
+					TLS: &RedisTLSConfig{
+						CACert: fixtures.caCertPath,
+					},
+				},
+			},
+		}
+
+		if err := config.Validate(); err != nil {
+			t.Fatalf("expected valid config, got error: %v", err)
+		}
+	})
+
+	t.Run("missing keyPrefix fails", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
+			Database: DatabaseConfig{
+				Type: "redis",
+				Redis: &RedisConfig{
+					Host: "localhost",
+					Port: 6379,
+				},
+			},
+		}
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "database.redis.keyPrefix is required") {
+			t.Fatalf("expected keyPrefix validation error, got: %v", err)
+		}
+	})
+
+	t.Run("range lookup requires rangeKey", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
+			Database: DatabaseConfig{
+				Type: "redis",
+				Redis: &RedisConfig{
+					Lookup: RedisLookupRange,
+					Host:   "localhost",
+					Port:   6379,
+				},
+			},
+		}
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "database.redis.rangeKey is required") {
+			t.Fatalf("expected rangeKey validation error, got: %v", err)
+		}
+
+		config.Database.Redis.RangeKey = "blocked-ranges"
+		if err := config.Validate(); err != nil {
+			t.Fatalf("expected valid range config without keyPrefix, got error: %v", err)
+		}
+	})
+
+	t.Run("unknown lookup fails", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
+			Database: DatabaseConfig{
+				Type: "redis",
+				Redis: &RedisConfig{
+					Lookup:    "hash",
+					KeyPrefix: "test:",
+					Host:      "localhost",
+					Port:      6379,
+				},
+			},
+		}
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "database.redis.lookup must be 'key' or 'range'") {
+			t.Fatalf("expected lookup validation error, got: %v", err)
+		}
+	})
+
+	t.Run("host is required", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
+			Database: DatabaseConfig{
+				Type: "redis",
+				Redis: &RedisConfig{
+					KeyPrefix: "test:",
+					Port:      6379,
+				},
+			},
+		}
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "database.redis.host is required") {
+			t.Fatalf("expected host validation error, got: %v", err)
+		}
+	})
+
+	t.Run("port must be in range", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
+			Database: DatabaseConfig{
+				Type: "redis",
+				Redis: &RedisConfig{
+					KeyPrefix: "test:",
+					Host:      "localhost",
+					Port:      70000,
+				},
+			},
+		}
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "database.redis.port must be between 1 and 65535") {
+			t.Fatalf("expected port validation error, got: %v", err)
+		}
+	})
+
+	t.Run("db number must be non-negative", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
+			Database: DatabaseConfig{
+				Type: "redis",
+				Redis: &RedisConfig{
+					KeyPrefix: "test:",
+					Host:      "localhost",
+					Port:      6379,
+					DB:        -1,
+				},
+			},
+		}
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "database.redis.db must be non-negative") {
+			t.Fatalf("expected db validation error, got: %v", err)
+		}
+	})
+
+	t.Run("specified username env must exist", func(t *testing.T) {
+		_ = os.Unsetenv("REDIS_MISSING_USER")
+
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
+			Database: DatabaseConfig{
+				Type: "redis",
+				Redis: &RedisConfig{
+					KeyPrefix:   "test:",
+					Host:        "localhost",
+					Port:        6379,
+					UsernameEnv: "REDIS_MISSING_USER",
+				},
+			},
+		}
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "environment variable 'REDIS_MISSING_USER' not found") {
+			t.Fatalf("expected username env validation error, got: %v", err)
+		}
+	})
+
+	t.Run("specified password env must exist", func(t *testing.T) {
+		setEnv(t, "REDIS_USER_PRESENT", "user")
+		_ = os.Unsetenv("REDIS_MISSING_PASS")
+
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
+			Database: DatabaseConfig{
+				Type: "redis",
+				Redis: &RedisConfig{
+					KeyPrefix:   "test:",
+					Host:        "localhost",
+					Port:        6379,
+					UsernameEnv: "REDIS_USER_PRESENT",
+					PasswordEnv: "REDIS_MISSING_PASS",
+				},
+			},
+		}
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "environment variable 'REDIS_MISSING_PASS' not found") {
+			t.Fatalf("expected password env validation error, got: %v", err)
+		}
+	})
+
+	t.Run("client certificate without key fails", func(t *testing.T) {
+		fixtures := createTLSFixtures(t)
+
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
+			Database: DatabaseConfig{
+				Type: "redis",
+				Redis: &RedisConfig{
+					KeyPrefix: "test:",
+					Host:      "localhost",
+					Port:      6379,
+					TLS: &RedisTLSConfig{
+						ClientCert: fixtures.clientCertPath,
+					},
+				},
+			},
+		}
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "both clientCert and clientKey must be provided") {
+			t.Fatalf("expected mutual TLS pairing error, got: %v", err)
+		}
+	})
+
+	t.Run("invalid PEM ca cert fails", func(t *testing.T) {
+		fixtures := createTLSFixtures(t)
+
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
+			Database: DatabaseConfig{
+				Type: "redis",
+				Redis: &RedisConfig{
+					KeyPrefix: "test:",
+					Host:      "localhost",
+					Port:      6379,
+					TLS: &RedisTLSConfig{
+						CACert: fixtures.invalidPEMPath,
+					},
+				},
+			},
+		}
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "valid PEM-encoded certificate") {
+			t.Fatalf("expected invalid PEM validation error, got: %v", err)
+		}
+	})
+}
+
+func TestValidateRedisTopology(t *testing.T) {
+	tests := []struct {
+		name    string
+		redis   RedisConfig
+		wantErr string
+	}{
+		{
+			name:  "sentinel",
+			redis: RedisConfig{Mode: RedisModeSentinel, KeyPrefix: "test:", ReadFromReplica: true, Sentinel: &RedisSentinelConfig{MasterName: "mymaster", Addresses: []string{"sentinel-0:26379", "sentinel-1:26379"}}},
+		},
+		{
+			name:    "sentinel requires configuration",
+			redis:   RedisConfig{Mode: RedisModeSentinel, KeyPrefix: "test:"},
+			wantErr: "database.redis.sentinel configuration is required",
+		},
+		{
+			name:    "sentinel requires master name",
+			redis:   RedisConfig{Mode: RedisModeSentinel, KeyPrefix: "test:", Sentinel: &RedisSentinelConfig{Addresses: []string{"sentinel-0:26379"}}},
+			wantErr: "database.redis.sentinel.masterName is required",
+		},
+		{
+			name:    "sentinel requires addresses",
+			redis:   RedisConfig{Mode: RedisModeSentinel, KeyPrefix: "test:", Sentinel: &RedisSentinelConfig{MasterName: "mymaster"}},
+			wantErr: "database.redis.sentinel.addresses is required",
+		},
+		{
+			name:    "sentinel address needs port",
+			redis:   RedisConfig{Mode: RedisModeSentinel, KeyPrefix: "test:", Sentinel: &RedisSentinelConfig{MasterName: "mymaster", Addresses: []string{"sentinel-0"}}},
+			wantErr: "must be in host:port form",
+		},
+		{
+			name:    "sentinel password env must exist",
+			redis:   RedisConfig{Mode: RedisModeSentinel, KeyPrefix: "test:", Sentinel: &RedisSentinelConfig{MasterName: "mymaster", Addresses: []string{"sentinel-0:26379"}, PasswordEnv: "NON_EXISTENT_SENTINEL_PASSWORD"}},
+			wantErr: "environment variable 'NON_EXISTENT_SENTINEL_PASSWORD' not found",
+		},
+		{
+			name:  "cluster",
+			redis: RedisConfig{Mode: RedisModeCluster, KeyPrefix: "test:", ReadFromReplica: true, Cluster: &RedisClusterConfig{Addresses: []string{"redis-0:6379", "redis-1:6379"}}},
+		},
+		{
+			name:    "cluster requires addresses",
+			redis:   RedisConfig{Mode: RedisModeCluster, KeyPrefix: "test:"},
+			wantErr: "database.redis.cluster.addresses is required",
+		},
+		{
+			name:    "cluster address port range",
+			redis:   RedisConfig{Mode: RedisModeCluster, KeyPrefix: "test:", Cluster: &RedisClusterConfig{Addresses: []string{"redis-0:70000"}}},
+			wantErr: "has an invalid port",
+		},
+		{
+			name:    "cluster only supports db 0",
+			redis:   RedisConfig{Mode: RedisModeCluster, KeyPrefix: "test:", DB: 2, Cluster: &RedisClusterConfig{Addresses: []string{"redis-0:6379"}}},
+			wantErr: "database.redis.db must be 0",
+		},
+		{
+			name:    "cluster does not support keyspace notifications",
+			redis:   RedisConfig{Mode: RedisModeCluster, KeyPrefix: "test:", KeyspaceNotifications: true, Cluster: &RedisClusterConfig{Addresses: []string{"redis-0:6379"}}},
+			wantErr: "database.redis.keyspaceNotifications is not supported",
+		},
+		{
+			name:    "standalone cannot read from replica",
+			redis:   RedisConfig{Mode: RedisModeStandalone, KeyPrefix: "test:", Host: "localhost", Port: 6379, ReadFromReplica: true},
+			wantErr: "database.redis.readFromReplica requires",
+		},
+		{
+			name:    "unknown mode",
+			redis:   RedisConfig{Mode: "replicated", KeyPrefix: "test:", Host: "localhost", Port: 6379},
+			wantErr: "database.redis.mode must be 'standalone', 'sentinel' or 'cluster'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redis := tt.redis
+			config := &AttributeMatchDatabaseConfig{
+				Key: ipKey,
+				Database: DatabaseConfig{
+					Type:  "redis",
+					Redis: &redis,
+				},
+			}
+			config.ApplyDefaults()
+
+			err := config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected valid config, got error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRedisConfigAddresses(t *testing.T) {
+	standalone := &RedisConfig{Host: "redis.example.com"}
+	standalone.ApplyDefaults()
+	if got := standalone.Addresses(); len(got) != 1 || got[0] != "redis.example.com:6379" {
+		t.Fatalf("unexpected standalone addresses: %v", got)
+	}
+
+	sentinel := &RedisConfig{Mode: RedisModeSentinel, Sentinel: &RedisSentinelConfig{Addresses: []string{"s-0:26379", "s-1:26379"}}}
+	sentinel.ApplyDefaults()
+	if sentinel.Port != 0 {
+		t.Fatalf("expected no default port outside standalone mode, got %d", sentinel.Port)
+	}
+	if got := sentinel.Addresses(); len(got) != 2 || got[1] != "s-1:26379" {
+		t.Fatalf("unexpected sentinel addresses: %v", got)
+	}
+}
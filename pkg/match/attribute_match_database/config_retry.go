@@ -0,0 +1,94 @@
+package attribute_match_database
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultRetryBackoff    = 10 * time.Millisecond
+	defaultRetryMaxBackoff = 100 * time.Millisecond
+)
+
+// RetryConfig represents the retries of queries failing with transient errors
+type RetryConfig struct {
+	MaxAttempts int    `yaml:"maxAttempts"`
+	Backoff     string `yaml:"backoff"`
+	MaxBackoff  string `yaml:"maxBackoff"`
+}
+
+// RetryOptions holds the parsed retry settings
+type RetryOptions struct {
+	MaxAttempts int           // Total attempts, including the first one
+	Backoff     time.Duration // Delay before the first retry, doubled on every retry
+	MaxBackoff  time.Duration // Upper bound of the delay between retries
+}
+
+// validateQueryConfig checks the per-query timeout and retry settings of the database
+func (c *AttributeMatchDatabaseConfig) validateQueryConfig() error {
+	if c.Database.QueryTimeout != "" {
+		queryTimeout, err := time.ParseDuration(c.Database.QueryTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid database.queryTimeout: %w", err)
+		}
+		if queryTimeout <= 0 {
+			return fmt.Errorf("database.queryTimeout must be positive")
+		}
+	}
+
+	retry := c.Database.Retry
+	if retry == nil {
+		return nil
+	}
+	if retry.MaxAttempts < 1 || retry.MaxAttempts > 10 {
+		return fmt.Errorf("database.retry.maxAttempts must be between 1 and 10, got %d", retry.MaxAttempts)
+	}
+	for _, setting := range []struct{ field, value string }{
+		{"database.retry.backoff", retry.Backoff},
+		{"database.retry.maxBackoff", retry.MaxBackoff},
+	} {
+		if setting.value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(setting.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", setting.field, err)
+		}
+		if duration <= 0 {
+			return fmt.Errorf("%s must be positive", setting.field)
+		}
+	}
+	if options := c.Database.GetRetryOptions(); options.Backoff > options.MaxBackoff {
+		return fmt.Errorf("database.retry.backoff (%s) must not exceed database.retry.maxBackoff (%s)", options.Backoff, options.MaxBackoff)
+	}
+
+	return nil
+}
+
+// GetQueryTimeout returns the parsed per-query timeout, or 0 if queries are
+// only bounded by the request deadline
+func (c *DatabaseConfig) GetQueryTimeout() time.Duration {
+	timeout, _ := time.ParseDuration(c.QueryTimeout)
+	return max(timeout, 0)
+}
+
+// GetRetryOptions returns the parsed retry settings; without retry
+// configuration queries are attempted once
+func (c *DatabaseConfig) GetRetryOptions() RetryOptions {
+	if c.Retry == nil {
+		return RetryOptions{MaxAttempts: 1}
+	}
+	options := RetryOptions{
+		MaxAttempts: c.Retry.MaxAttempts,
+		Backoff:     defaultRetryBackoff,
+		MaxBackoff:  defaultRetryMaxBackoff,
+	}
+	if backoff, _ := time.ParseDuration(c.Retry.Backoff); backoff > 0 {
+		options.Backoff = backoff
+	}
+	if maxBackoff, _ := time.ParseDuration(c.Retry.MaxBackoff); maxBackoff > 0 {
+		options.MaxBackoff = maxBackoff
+	}
+	options.MaxAttempts = max(options.MaxAttempts, 1)
+	return options
+}
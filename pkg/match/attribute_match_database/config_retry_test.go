@@ -0,0 +1,90 @@
+package attribute_match_database
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateQueryConfig(t *testing.T) {
+	setEnv(t, "PG_USER", "user")
+	setEnv(t, "PG_PASS", "pass")
+
+	redis := func(tune func(*RedisConfig)) DatabaseConfig {
+		config := &RedisConfig{KeyPrefix: "blocked:", Host: "localhost", Port: 6379}
+		tune(config)
+		return DatabaseConfig{Type: "redis", Redis: config}
+	}
+	postgres := func(tune func(*PostgresConfig)) DatabaseConfig {
+		config := &PostgresConfig{Query: "SELECT 1 FROM blocked WHERE ip = $1", Host: "localhost", Port: 5432, DatabaseName: "authz", UsernameEnv: "PG_USER", PasswordEnv: "PG_PASS"}
+		tune(config)
+		return DatabaseConfig{Type: "postgres", Postgres: config}
+	}
+	withQuery := func(database DatabaseConfig, queryTimeout string, retry *RetryConfig) DatabaseConfig {
+		database.QueryTimeout, database.Retry = queryTimeout, retry
+		return database
+	}
+	untuned := redis(func(*RedisConfig) {})
+
+	tests := []struct {
+		name     string
+		database DatabaseConfig
+		wantErr  string
+	}{
+		{name: "query timeout and retries", database: withQuery(untuned, "50ms", &RetryConfig{MaxAttempts: 3, Backoff: "5ms", MaxBackoff: "20ms"})},
+		{name: "retries with default backoff", database: withQuery(untuned, "", &RetryConfig{MaxAttempts: 2})},
+		{name: "invalid query timeout", database: withQuery(untuned, "soon", nil), wantErr: "invalid database.queryTimeout"},
+		{name: "negative query timeout", database: withQuery(untuned, "-1s", nil), wantErr: "database.queryTimeout must be positive"},
+		{name: "zero attempts", database: withQuery(untuned, "", &RetryConfig{}), wantErr: "database.retry.maxAttempts must be between 1 and 10"},
+		{name: "too many attempts", database: withQuery(untuned, "", &RetryConfig{MaxAttempts: 11}), wantErr: "database.retry.maxAttempts must be between 1 and 10"},
+		{name: "invalid backoff", database: withQuery(untuned, "", &RetryConfig{MaxAttempts: 2, Backoff: "0s"}), wantErr: "database.retry.backoff must be positive"},
+		{name: "backoff above max backoff", database: withQuery(untuned, "", &RetryConfig{MaxAttempts: 2, Backoff: "1s"}), wantErr: "must not exceed database.retry.maxBackoff"},
+		{name: "redis tuning", database: redis(func(c *RedisConfig) { c.ReadTimeout, c.WriteTimeout, c.PoolSize = "200ms", "200ms", 50 })},
+		{name: "invalid redis read timeout", database: redis(func(c *RedisConfig) { c.ReadTimeout = "fast" }), wantErr: "invalid database.redis.readTimeout"},
+		{name: "negative redis write timeout", database: redis(func(c *RedisConfig) { c.WriteTimeout = "-1s" }), wantErr: "database.redis.writeTimeout must be positive"},
+		{name: "negative redis pool size", database: redis(func(c *RedisConfig) { c.PoolSize = -1 }), wantErr: "database.redis.poolSize must be non-negative"},
+		{name: "postgres tuning", database: postgres(func(c *PostgresConfig) {
+			c.StatementTimeout, c.QueryExecMode = "250ms", PostgresExecModeExec
+			c.Pool = &PostgresPoolConfig{MaxConnections: 10, HealthCheckPeriod: "30s"}
+		})},
+		{name: "sub-millisecond statement timeout", database: postgres(func(c *PostgresConfig) { c.StatementTimeout = "100us" }), wantErr: "database.postgres.statementTimeout must be at least 1ms"},
+		{name: "unknown query exec mode", database: postgres(func(c *PostgresConfig) { c.QueryExecMode = "prepared" }), wantErr: "database.postgres.queryExecMode must be one of"},
+		{name: "invalid health check period", database: postgres(func(c *PostgresConfig) { c.Pool = &PostgresPoolConfig{MaxConnections: 10, HealthCheckPeriod: "0s"} }), wantErr: "pool.healthCheckPeriod must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := AttributeMatchDatabaseConfig{Key: ipKey, Database: tt.database}
+			config.ApplyDefaults()
+
+			err := config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGetRetryOptions(t *testing.T) {
+	if got := (&DatabaseConfig{}).GetRetryOptions(); got != (RetryOptions{MaxAttempts: 1}) {
+		t.Fatalf("expected a single attempt without retry configuration, got %+v", got)
+	}
+
+	got := (&DatabaseConfig{Retry: &RetryConfig{MaxAttempts: 3}}).GetRetryOptions()
+	want := RetryOptions{MaxAttempts: 3, Backoff: defaultRetryBackoff, MaxBackoff: defaultRetryMaxBackoff}
+	if got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+
+	got = (&DatabaseConfig{Retry: &RetryConfig{MaxAttempts: 2, Backoff: "1ms", MaxBackoff: "8ms"}}).GetRetryOptions()
+	want = RetryOptions{MaxAttempts: 2, Backoff: time.Millisecond, MaxBackoff: 8 * time.Millisecond}
+	if got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
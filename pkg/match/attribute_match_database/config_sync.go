@@ -0,0 +1,110 @@
+package attribute_match_database
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+const (
+	// SyncModeLookup queries the database on every cache miss
+	SyncModeLookup = "lookup"
+	// SyncModeFull periodically mirrors the whole database into memory
+	SyncModeFull = "full"
+
+	defaultSyncInterval = time.Minute
+	defaultSyncTimeout  = 30 * time.Second
+)
+
+// SyncConfig represents the full sync configuration
+type SyncConfig struct {
+	Interval string `yaml:"interval"`
+	MaxAge   string `yaml:"maxAge"`
+	Timeout  string `yaml:"timeout"`
+}
+
+// FullSync reports whether the controller mirrors the database into memory
+func (c *AttributeMatchDatabaseConfig) FullSync() bool {
+	return c.SyncMode == SyncModeFull
+}
+
+// validateSyncConfig checks the sync mode and its settings
+func (c *AttributeMatchDatabaseConfig) validateSyncConfig() error {
+	switch c.SyncMode {
+	case "", SyncModeLookup:
+		if c.Sync != nil {
+			return fmt.Errorf("sync requires syncMode '%s'", SyncModeFull)
+		}
+		return nil
+	case SyncModeFull:
+	default:
+		return fmt.Errorf("syncMode must be '%s' or '%s', got '%s'", SyncModeLookup, SyncModeFull, c.SyncMode)
+	}
+
+	if c.Cache != nil {
+		return fmt.Errorf("cache is not supported when syncMode is '%s', lookups are answered from memory", SyncModeFull)
+	}
+	if c.Database.Type == "http" {
+		return fmt.Errorf("syncMode '%s' is not supported when database.type is 'http'", SyncModeFull)
+	}
+	if c.Database.Type == "postgres" && c.Database.Postgres != nil {
+		if c.Database.Postgres.SyncQuery == "" {
+			return fmt.Errorf("database.postgres.syncQuery is required when syncMode is '%s'", SyncModeFull)
+		}
+		if regexp.MustCompile(`\$\d+`).MatchString(c.Database.Postgres.SyncQuery) {
+			return fmt.Errorf("database.postgres.syncQuery must not contain parameter placeholders")
+		}
+	}
+
+	if c.Sync == nil {
+		return nil
+	}
+	for _, setting := range []struct{ field, value string }{
+		{"sync.interval", c.Sync.Interval},
+		{"sync.maxAge", c.Sync.MaxAge},
+		{"sync.timeout", c.Sync.Timeout},
+	} {
+		if setting.value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(setting.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", setting.field, err)
+		}
+		if duration <= 0 {
+			return fmt.Errorf("%s must be positive", setting.field)
+		}
+	}
+	if maxAge := c.GetSyncMaxAge(); maxAge > 0 && maxAge < c.GetSyncInterval() {
+		return fmt.Errorf("sync.maxAge must not be shorter than sync.interval")
+	}
+
+	return nil
+}
+
+// GetSyncInterval returns the delay between full syncs
+func (c *AttributeMatchDatabaseConfig) GetSyncInterval() time.Duration {
+	if c.Sync == nil || c.Sync.Interval == "" {
+		return defaultSyncInterval
+	}
+	interval, _ := time.ParseDuration(c.Sync.Interval)
+	return interval
+}
+
+// GetSyncMaxAge returns the snapshot age past which HealthCheck fails, or 0 if disabled
+func (c *AttributeMatchDatabaseConfig) GetSyncMaxAge() time.Duration {
+	if c.Sync == nil || c.Sync.MaxAge == "" {
+		return 0
+	}
+	maxAge, _ := time.ParseDuration(c.Sync.MaxAge)
+	return maxAge
+}
+
+// GetSyncTimeout returns the timeout of a single full sync
+func (c *AttributeMatchDatabaseConfig) GetSyncTimeout() time.Duration {
+	if c.Sync == nil || c.Sync.Timeout == "" {
+		return defaultSyncTimeout
+	}
+	timeout, _ := time.ParseDuration(c.Sync.Timeout)
+	return timeout
+}
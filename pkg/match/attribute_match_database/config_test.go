@@ -0,0 +1,301 @@
+package attribute_match_database
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// ipKey keys lookups on the client IP address, as ip-match-database does
+var ipKey = KeyConfig{Source: KeySourceIP}
+
+// TestConfigValidation tests configuration validation
+func TestConfigValidation(t *testing.T) {
+	t.Run("invalid database type fails", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
+			Database: DatabaseConfig{
+				Type: "invalid",
+			},
+		}
+
+		if err := config.Validate(); err == nil {
+			t.Fatal("expected validation error for invalid database type")
+		}
+	})
+
+	t.Run("invalid stale window fails", func(t *testing.T) {
+		for _, cache := range []*CacheConfig{
+			{TTL: "1m", NegativeTTL: "soon"},
+			{TTL: "1m", StaleWhileRevalidate: "-1s"},
+			{TTL: "1m", StaleIfError: "0s"},
+		} {
+			config := &AttributeMatchDatabaseConfig{
+				Key:   ipKey,
+				Cache: cache,
+				Database: DatabaseConfig{
+					Type: "redis",
+					Redis: &RedisConfig{
+						KeyPrefix: "test:",
+						Host:      "localhost",
+						Port:      6379,
+					},
+				},
+			}
+
+			if err := config.Validate(); err == nil {
+				t.Fatalf("expected validation error for cache config %+v", cache)
+			}
+		}
+	})
+
+	t.Run("invalid cache TTL fails", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
+			Cache: &CacheConfig{
+				TTL: "invalid",
+			},
+			Database: DatabaseConfig{
+				Type: "redis",
+				Redis: &RedisConfig{
+					KeyPrefix: "test:",
+					Host:      "localhost",
+					Port:      6379,
+				},
+			},
+		}
+
+		if err := config.Validate(); err == nil {
+			t.Fatal("expected validation error for invalid cache TTL")
+		}
+	})
+
+	t.Run("missing cache TTL fails when cache is set", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{
+			Key:   ipKey,
+			Cache: &CacheConfig{},
+			Database: DatabaseConfig{
+				Type: "redis",
+				Redis: &RedisConfig{
+					KeyPrefix: "test:",
+					Host:      "localhost",
+					Port:      6379,
+				},
+			},
+		}
+
+		if err := config.Validate(); err == nil {
+			t.Fatal("expected validation error for missing cache TTL")
+		}
+	})
+
+	t.Run("valid cache config passes", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
+			Cache: &CacheConfig{
+				TTL: "10m",
+			},
+			Database: DatabaseConfig{
+				Type: "redis",
+				Redis: &RedisConfig{
+					KeyPrefix: "test:",
+					Host:      "localhost",
+					Port:      6379,
+				},
+			},
+		}
+
+		if err := config.Validate(); err != nil {
+			t.Fatalf("expected valid config, got error: %v", err)
+		}
+	})
+}
+
+// TestGetCacheTTL tests the GetCacheTTL helper
+func TestGetCacheTTL(t *testing.T) {
+	t.Run("returns zero when cache is nil", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{}
+		if ttl := config.GetCacheTTL(); ttl != 0 {
+			t.Fatalf("expected 0, got %v", ttl)
+		}
+	})
+
+	t.Run("returns parsed TTL when cache is configured", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
+			Cache: &CacheConfig{
+				TTL: "15m",
+			},
+		}
+		expected := 15 * time.Minute
+		if ttl := config.GetCacheTTL(); ttl != expected {
+			t.Fatalf("expected %v, got %v", expected, ttl)
+		}
+	})
+}
+
+// TestGetCacheOptions tests the GetCacheOptions helper
+func TestGetCacheOptions(t *testing.T) {
+	config := &AttributeMatchDatabaseConfig{
+		Key: ipKey,
+		Cache: &CacheConfig{
+			TTL:                  "10m",
+			NegativeTTL:          "1m",
+			StaleWhileRevalidate: "30s",
+			StaleIfError:         "1h",
+		},
+	}
+
+	options := config.GetCacheOptions()
+	if options.TTL != 10*time.Minute || options.NegativeTTL != time.Minute || options.StaleWhileRevalidate != 30*time.Second || options.StaleIfError != time.Hour {
+		t.Fatalf("unexpected cache options: %+v", options)
+	}
+
+	config.Cache.NegativeTTL = ""
+	if options := config.GetCacheOptions(); options.NegativeTTL != 10*time.Minute {
+		t.Fatalf("expected negative TTL to default to the TTL, got %v", options.NegativeTTL)
+	}
+
+	if options := (&AttributeMatchDatabaseConfig{}).GetCacheOptions(); options.TTL != 0 {
+		t.Fatalf("expected caching to be disabled, got %+v", options)
+	}
+}
+
+// TestInvalidationEnabled tests the push invalidation settings
+func TestInvalidationEnabled(t *testing.T) {
+	redis := func(keyspaceNotifications bool) DatabaseConfig {
+		return DatabaseConfig{
+			Type:  "redis",
+			Redis: &RedisConfig{KeyPrefix: "test:", Host: "localhost", Port: 6379, KeyspaceNotifications: keyspaceNotifications},
+		}
+	}
+
+	t.Run("keyspace notifications require cache", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{Key: ipKey, Database: redis(true)}
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "database.redis.keyspaceNotifications requires cache") {
+			t.Fatalf("expected cache requirement error, got %v", err)
+		}
+	})
+
+	t.Run("keyspace notifications with cache", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{Key: ipKey, Cache: &CacheConfig{TTL: "5m"}, Database: redis(true)}
+		if err := config.Validate(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !config.InvalidationEnabled() {
+			t.Fatal("expected push invalidation to be enabled")
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{Key: ipKey, Cache: &CacheConfig{TTL: "5m"}, Database: redis(false)}
+		if config.InvalidationEnabled() {
+			t.Fatal("expected push invalidation to be disabled")
+		}
+	})
+
+	t.Run("postgres notify channel", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{
+			Key:   ipKey,
+			Cache: &CacheConfig{TTL: "5m"},
+			Database: DatabaseConfig{
+				Type:     "postgres",
+				Postgres: &PostgresConfig{NotifyChannel: "changes"},
+			},
+		}
+		if !config.InvalidationEnabled() {
+			t.Fatal("expected push invalidation to be enabled")
+		}
+	})
+}
+
+// TestSyncConfig tests the full sync settings
+func TestSyncConfig(t *testing.T) {
+	t.Setenv("PG_USER", "user")
+	t.Setenv("PG_PASS", "pass")
+
+	redis := DatabaseConfig{
+		Type:  "redis",
+		Redis: &RedisConfig{KeyPrefix: "test:", Host: "localhost", Port: 6379},
+	}
+	postgres := func(syncQuery string) DatabaseConfig {
+		return DatabaseConfig{
+			Type:     "postgres",
+			Postgres: &PostgresConfig{SyncQuery: syncQuery, Host: "localhost", Port: 5432, DatabaseName: "db", UsernameEnv: "PG_USER", PasswordEnv: "PG_PASS"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		config  *AttributeMatchDatabaseConfig
+		wantErr string
+	}{
+		{name: "full sync with redis", config: &AttributeMatchDatabaseConfig{Key: ipKey, SyncMode: SyncModeFull, Database: redis}},
+		{name: "full sync with postgres sync query", config: &AttributeMatchDatabaseConfig{Key: ipKey, SyncMode: SyncModeFull, Database: postgres("SELECT value FROM entries")}},
+		{name: "explicit lookup mode", config: &AttributeMatchDatabaseConfig{Key: ipKey, SyncMode: SyncModeLookup, Database: redis}},
+		{name: "unknown sync mode", config: &AttributeMatchDatabaseConfig{Key: ipKey, SyncMode: "eager", Database: redis}, wantErr: "syncMode must be"},
+		{name: "sync settings without full mode", config: &AttributeMatchDatabaseConfig{Key: ipKey, Sync: &SyncConfig{Interval: "1m"}, Database: redis}, wantErr: "sync requires syncMode 'full'"},
+		{name: "cache with full sync", config: &AttributeMatchDatabaseConfig{Key: ipKey, SyncMode: SyncModeFull, Cache: &CacheConfig{TTL: "5m"}, Database: redis}, wantErr: "cache is not supported"},
+		{name: "postgres without sync query", config: &AttributeMatchDatabaseConfig{Key: ipKey, SyncMode: SyncModeFull, Database: postgres("")}, wantErr: "database.postgres.syncQuery is required"},
+		{name: "postgres sync query with placeholder", config: &AttributeMatchDatabaseConfig{Key: ipKey, SyncMode: SyncModeFull, Database: postgres("SELECT value FROM entries WHERE value = $1")}, wantErr: "must not contain parameter placeholders"},
+		{name: "invalid interval", config: &AttributeMatchDatabaseConfig{Key: ipKey, SyncMode: SyncModeFull, Sync: &SyncConfig{Interval: "soon"}, Database: redis}, wantErr: "invalid sync.interval"},
+		{name: "negative timeout", config: &AttributeMatchDatabaseConfig{Key: ipKey, SyncMode: SyncModeFull, Sync: &SyncConfig{Timeout: "-1s"}, Database: redis}, wantErr: "sync.timeout must be positive"},
+		{name: "max age shorter than interval", config: &AttributeMatchDatabaseConfig{Key: ipKey, SyncMode: SyncModeFull, Sync: &SyncConfig{Interval: "5m", MaxAge: "1m"}, Database: redis}, wantErr: "sync.maxAge must not be shorter than sync.interval"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{Key: ipKey, SyncMode: SyncModeFull}
+		if config.GetSyncInterval() != defaultSyncInterval || config.GetSyncTimeout() != defaultSyncTimeout || config.GetSyncMaxAge() != 0 {
+			t.Fatalf("unexpected defaults: interval %v, timeout %v, maxAge %v", config.GetSyncInterval(), config.GetSyncTimeout(), config.GetSyncMaxAge())
+		}
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{Key: ipKey, SyncMode: SyncModeFull, Sync: &SyncConfig{Interval: "30s", MaxAge: "5m", Timeout: "10s"}}
+		if config.GetSyncInterval() != 30*time.Second || config.GetSyncTimeout() != 10*time.Second || config.GetSyncMaxAge() != 5*time.Minute {
+			t.Fatalf("unexpected settings: interval %v, timeout %v, maxAge %v", config.GetSyncInterval(), config.GetSyncTimeout(), config.GetSyncMaxAge())
+		}
+	})
+}
+
+// TestGetDatabaseConnectionTimeout tests the GetDatabaseConnectionTimeout helper
+func TestGetDatabaseConnectionTimeout(t *testing.T) {
+	t.Run("returns default when connectionTimeout is empty", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{
+			Key:      ipKey,
+			Database: DatabaseConfig{},
+		}
+		expected := DefaultDatabaseConnectionTimeout
+		if timeout := config.GetDatabaseConnectionTimeout(); timeout != expected {
+			t.Fatalf("expected %v, got %v", expected, timeout)
+		}
+	})
+
+	t.Run("returns parsed connectionTimeout when configured", func(t *testing.T) {
+		config := &AttributeMatchDatabaseConfig{
+			Key: ipKey,
+			Database: DatabaseConfig{
+				ConnectionTimeout: "500ms",
+			},
+		}
+		expected := 500 * time.Millisecond
+		if timeout := config.GetDatabaseConnectionTimeout(); timeout != expected {
+			t.Fatalf("expected %v, got %v", expected, timeout)
+		}
+	})
+}
@@ -1,4 +1,4 @@
-package asn_match_database
+package attribute_match_database
 
 import (
 	"os"
@@ -0,0 +1,84 @@
+package attribute_match_database
+
+import (
+	"context"
+)
+
+// DataSource abstracts the external data store for key lookups
+type DataSource interface {
+	// Contains checks if the key exists in the data source
+	// Returns true if the key is found, false otherwise
+	// Returns an error if the query fails or times out
+	Contains(ctx context.Context, key string) (bool, error)
+
+	// Close releases resources held by the data source
+	Close() error
+
+	// HealthCheck verifies connectivity to the data source
+	// Returns an error if the data source is unreachable
+	HealthCheck(ctx context.Context) error
+}
+
+// RangeDataSource is implemented by data sources that store networks rather
+// than individual IP addresses and can report which range matched. Only used
+// with IP lookup keys.
+type RangeDataSource interface {
+	DataSource
+
+	// FindRange returns the range containing the IP address, or nil if none does
+	FindRange(ctx context.Context, ipAddress string) (*MatchedRange, error)
+}
+
+// MetadataDataSource is implemented by data sources able to return the payload
+// stored with a matched entry, such as a reason, an owner or an expiry
+type MetadataDataSource interface {
+	DataSource
+
+	// LookupMetadata checks if the key exists in the data source and returns
+	// the payload stored with it (nil when not found)
+	LookupMetadata(ctx context.Context, key string) (bool, Metadata, error)
+}
+
+// SnapshotDataSource is implemented by data sources answering lookups from an
+// in-memory snapshot, such as a local file
+type SnapshotDataSource interface {
+	DataSource
+
+	// Snapshot returns the snapshot lookups are answered from
+	Snapshot() *Snapshot
+}
+
+// Metadata is the payload stored with a matched entry, indexed by field name
+type Metadata map[string]string
+
+// LookupResult is the outcome of resolving a lookup key
+type LookupResult struct {
+	Matched  bool
+	Range    *MatchedRange // The range that contained the IP, if reported by the data source
+	Metadata Metadata      // The payload stored with the entry, if reported by the data source
+	Source   string        // The type of the data source that answered, as shown in verdict descriptions
+}
+
+// MatchedRange describes the stored range that contained an IP address
+type MatchedRange struct {
+	Range   string
+	Comment string
+}
+
+// SnapshotSource is implemented by data sources able to read every stored
+// entry at once, which the full sync mode mirrors into memory
+type SnapshotSource interface {
+	// LoadSnapshot reads every stored key, or IP address range
+	LoadSnapshot(ctx context.Context) ([]SnapshotEntry, error)
+}
+
+// InvalidationSource is implemented by data sources able to push notifications
+// when stored entries change, so that cached lookups can be dropped early
+type InvalidationSource interface {
+	// WatchInvalidations subscribes to change notifications and calls
+	// invalidate with the key of every changed entry, or with an empty string
+	// when the change cannot be attributed to a single key.
+	// ready is called once the subscription is established. It blocks until
+	// ctx is done (returning nil) or the subscription fails.
+	WatchInvalidations(ctx context.Context, ready func(), invalidate func(key string)) error
+}
@@ -0,0 +1,164 @@
+package attribute_match_database
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FileDataSource implements DataSource for a local snapshot file, answering
+// lookups from memory. The file is reloaded when it changes.
+type FileDataSource struct {
+	path      string
+	filter    func(SnapshotEntry) (SnapshotEntry, bool)
+	snapshot  atomic.Pointer[Snapshot]
+	modTime   time.Time
+	size      int64
+	mu        sync.Mutex // guards modTime, size and reloadErr
+	reloadErr error
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewFileDataSource creates a new file data source from configuration. filter
+// normalizes the entries read from the file, dropping those it rejects; with a
+// nil filter entries are indexed as read.
+func NewFileDataSource(config *FileConfig, filter func(SnapshotEntry) (SnapshotEntry, bool)) (*FileDataSource, error) {
+	if config == nil {
+		return nil, fmt.Errorf("file configuration is required")
+	}
+
+	f := &FileDataSource{
+		path:   config.Path,
+		filter: filter,
+		done:   make(chan struct{}),
+	}
+	if err := f.reload(true); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+	go f.watch(ctx, config.GetReloadInterval())
+
+	return f, nil
+}
+
+// Contains checks if the lookup key is in the loaded file
+func (f *FileDataSource) Contains(ctx context.Context, key string) (bool, error) {
+	matched, _ := f.snapshot.Load().Find(key)
+	return matched, nil
+}
+
+// Snapshot implements SnapshotDataSource
+func (f *FileDataSource) Snapshot() *Snapshot {
+	return f.snapshot.Load()
+}
+
+// LoadSnapshot implements SnapshotSource by reading the file
+func (f *FileDataSource) LoadSnapshot(ctx context.Context) ([]SnapshotEntry, error) {
+	return readSnapshotFile(f.path)
+}
+
+// Close stops watching the file
+func (f *FileDataSource) Close() error {
+	if f.cancel != nil {
+		f.cancel()
+		<-f.done
+	}
+	return nil
+}
+
+// HealthCheck fails when the file can no longer be read
+func (f *FileDataSource) HealthCheck(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reloadErr
+}
+
+// watch reloads the file every interval until ctx is done
+func (f *FileDataSource) watch(ctx context.Context, interval time.Duration) {
+	defer close(f.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = f.reload(false)
+		}
+	}
+}
+
+// reload reads the file again if its modification time or size changed, or
+// if force is set. On failure the previous snapshot stays in use.
+func (f *FileDataSource) reload(force bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		f.reloadErr = fmt.Errorf("failed to read snapshot file: %w", err)
+		return f.reloadErr
+	}
+	if !force && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		f.reloadErr = nil
+		return nil
+	}
+
+	entries, err := readSnapshotFile(f.path)
+	if err != nil {
+		f.reloadErr = err
+		return err
+	}
+	if f.filter != nil {
+		validEntries := make([]SnapshotEntry, 0, len(entries))
+		for _, entry := range entries {
+			if entry, ok := f.filter(entry); ok {
+				validEntries = append(validEntries, entry)
+			}
+		}
+		entries = validEntries
+	}
+
+	f.snapshot.Store(NewSnapshot(entries, time.Now()))
+	f.modTime, f.size, f.reloadErr = info.ModTime(), info.Size(), nil
+	return nil
+}
+
+// readSnapshotFile reads the entries of a snapshot file: one key per line,
+// optionally followed by whitespace and a comment
+func readSnapshotFile(path string) ([]SnapshotEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var entries []SnapshotEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, comment := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			key, comment = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		entries = append(entries, SnapshotEntry{Key: key, Comment: comment})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read snapshot file: %w", err)
+	}
+
+	return entries, nil
+}
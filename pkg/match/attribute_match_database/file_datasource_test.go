@@ -0,0 +1,65 @@
+package attribute_match_database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileDataSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocked.txt")
+	writeFile := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("failed to set modification time: %v", err)
+		}
+	}
+	writeFile("# blocked networks\n10.0.0.0/8 internal scanners\n\n203.0.113.10\nnot-an-ip\n", time.Now().Add(-time.Hour))
+
+	dataSource, err := NewFileDataSource(&FileConfig{Path: path}, newKeyExtractor(KeyConfig{Source: KeySourceIP}).snapshotEntry)
+	if err != nil {
+		t.Fatalf("failed to create data source: %v", err)
+	}
+	t.Cleanup(func() { _ = dataSource.Close() })
+
+	if size := dataSource.Snapshot().Size(); size != 2 {
+		t.Fatalf("expected 2 entries, got %d", size)
+	}
+	matched, matchedRange := dataSource.Snapshot().Find("10.1.2.3")
+	if !matched || matchedRange == nil || matchedRange.Comment != "internal scanners" {
+		t.Fatalf("expected range match with comment, got %v, %+v", matched, matchedRange)
+	}
+	if found, err := dataSource.Contains(context.Background(), "203.0.113.10"); err != nil || !found {
+		t.Fatalf("expected match, got %v, %v", found, err)
+	}
+
+	writeFile("198.51.100.1\n", time.Now())
+	if err := dataSource.reload(false); err != nil {
+		t.Fatalf("unexpected reload error: %v", err)
+	}
+	if found, _ := dataSource.Contains(context.Background(), "203.0.113.10"); found {
+		t.Fatal("expected removed entry not to match after reload")
+	}
+	if found, _ := dataSource.Contains(context.Background(), "198.51.100.1"); !found {
+		t.Fatal("expected added entry to match after reload")
+	}
+
+	// A missing file fails the health check and keeps the last snapshot
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("failed to remove file: %v", err)
+	}
+	if err := dataSource.reload(false); err == nil {
+		t.Fatal("expected reload error")
+	}
+	if err := dataSource.HealthCheck(context.Background()); err == nil {
+		t.Fatal("expected health check error")
+	}
+	if found, _ := dataSource.Contains(context.Background(), "198.51.100.1"); !found {
+		t.Fatal("expected last snapshot to be served")
+	}
+}
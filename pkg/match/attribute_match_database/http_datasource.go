@@ -0,0 +1,328 @@
+package attribute_match_database
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxHTTPResponseBytes bounds the response body read from the lookup service
+const maxHTTPResponseBytes = 1 << 20
+
+// HTTPDataSource implements DataSource for an HTTP lookup service
+type HTTPDataSource struct {
+	client             *http.Client
+	url                string
+	method             string
+	body               string
+	headers            map[string]string
+	bearerToken        string
+	matchStatusCodes   []int
+	noMatchStatusCodes []int
+	matchPointer       string
+	healthURL          string
+}
+
+// NewHTTPDataSource creates a new HTTP data source from configuration
+func NewHTTPDataSource(ctx context.Context, config *HTTPConfig) (*HTTPDataSource, error) {
+	if config == nil {
+		return nil, fmt.Errorf("http configuration is required")
+	}
+
+	// Get bearer token from environment
+	var bearerToken string
+	if config.BearerTokenEnv != "" {
+		bearerToken = os.Getenv(config.BearerTokenEnv)
+		if bearerToken == "" {
+			return nil, fmt.Errorf("bearer token is empty in environment variable '%s'", config.BearerTokenEnv)
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	// Configure TLS if provided
+	if config.TLS != nil {
+		tlsConfig, err := buildHTTPTLSConfig(config.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build TLS configuration: %w", err)
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	method := config.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	dataSource := &HTTPDataSource{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   config.GetTimeout(),
+		},
+		url:                config.URL,
+		method:             method,
+		body:               config.Body,
+		headers:            config.Headers,
+		bearerToken:        bearerToken,
+		matchStatusCodes:   config.MatchStatusCodes,
+		noMatchStatusCodes: config.NoMatchStatusCodes,
+		matchPointer:       config.MatchPointer,
+		healthURL:          config.HealthURL,
+	}
+
+	// Test connection
+	if err := dataSource.HealthCheck(ctx); err != nil {
+		dataSource.client.CloseIdleConnections()
+		return nil, fmt.Errorf("failed to connect to HTTP service: %w", err)
+	}
+
+	return dataSource, nil
+}
+
+// Contains checks if the lookup key is known to the HTTP service
+func (h *HTTPDataSource) Contains(ctx context.Context, key string) (bool, error) {
+	found, _, err := h.lookup(ctx, key)
+	return found, err
+}
+
+// LookupMetadata implements MetadataDataSource. The payload holds the
+// top-level string, number and boolean fields of the JSON object returned with
+// a match.
+func (h *HTTPDataSource) LookupMetadata(ctx context.Context, key string) (bool, Metadata, error) {
+	found, document, err := h.lookup(ctx, key)
+	if err != nil || !found {
+		return false, nil, err
+	}
+
+	object, ok := document.(map[string]any)
+	if !ok {
+		return true, nil, nil
+	}
+	metadata := make(Metadata, len(object))
+	for name, value := range object {
+		if field, ok := formatJSONScalar(value); ok {
+			metadata[name] = field
+		}
+	}
+	return true, metadata, nil
+}
+
+// lookup sends the request for key and interprets the response. A status code
+// listed in matchStatusCodes is a match, unless a matchPointer is configured,
+// in which case the boolean it points to decides; a status code listed in
+// noMatchStatusCodes is a miss; any other status code is an error. The decoded
+// JSON response body is returned with matches, when there is one.
+func (h *HTTPDataSource) lookup(ctx context.Context, key string) (bool, any, error) {
+	req, err := h.newLookupRequest(ctx, key)
+	if err != nil {
+		return false, nil, err
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return false, nil, fmt.Errorf("http request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	payload, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseBytes))
+	if err != nil {
+		return false, nil, fmt.Errorf("http response read failed: %w", err)
+	}
+
+	switch {
+	case slices.Contains(h.noMatchStatusCodes, resp.StatusCode):
+		return false, nil, nil
+	case !slices.Contains(h.matchStatusCodes, resp.StatusCode):
+		return false, nil, &httpStatusError{code: resp.StatusCode}
+	}
+
+	var document any
+	if len(bytes.TrimSpace(payload)) > 0 {
+		if err := json.Unmarshal(payload, &document); err != nil && h.matchPointer != "" {
+			return false, nil, fmt.Errorf("http response is not valid JSON: %w", err)
+		}
+	}
+
+	if h.matchPointer == "" {
+		return true, document, nil
+	}
+
+	value, ok := resolveJSONPointer(document, h.matchPointer)
+	if !ok {
+		return false, nil, fmt.Errorf("http response has no value at '%s'", h.matchPointer)
+	}
+	matched, ok := value.(bool)
+	if !ok {
+		return false, nil, fmt.Errorf("http response value at '%s' is not a boolean", h.matchPointer)
+	}
+	if !matched {
+		return false, nil, nil
+	}
+	return true, document, nil
+}
+
+// newLookupRequest builds the request for key, templating it into the URL
+// (query-escaped) and the body (JSON-string-escaped)
+func (h *HTTPDataSource) newLookupRequest(ctx context.Context, key string) (*http.Request, error) {
+	target := strings.ReplaceAll(h.url, httpKeyPlaceholder, strings.ReplaceAll(url.QueryEscape(key), "+", "%20"))
+
+	var body io.Reader
+	if h.body != "" {
+		quoted, _ := json.Marshal(key)
+		body = strings.NewReader(strings.ReplaceAll(h.body, httpKeyPlaceholder, string(quoted[1:len(quoted)-1])))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, h.method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build http request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	for name, value := range h.headers {
+		req.Header.Set(name, value)
+	}
+	if h.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+h.bearerToken)
+	}
+	return req, nil
+}
+
+// Close releases idle HTTP connections
+func (h *HTTPDataSource) Close() error {
+	h.client.CloseIdleConnections()
+	return nil
+}
+
+// HealthCheck verifies that the health URL answers with a 2xx status code.
+// Without a health URL the service is assumed to be healthy.
+func (h *HTTPDataSource) HealthCheck(ctx context.Context) error {
+	if h.healthURL == "" {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.healthURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build http request: %w", err)
+	}
+	for name, value := range h.headers {
+		req.Header.Set(name, value)
+	}
+	if h.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+h.bearerToken)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("http health check failed: %w", err)
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxHTTPResponseBytes))
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("http health check returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// httpStatusError reports a lookup answered with a status code that is neither
+// a match nor a no-match status code
+type httpStatusError struct {
+	code int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("http lookup returned unexpected status %d", e.code)
+}
+
+// Transient reports whether a retry may get another answer: server errors and
+// rate limiting
+func (e *httpStatusError) Transient() bool {
+	return e.code >= http.StatusInternalServerError || e.code == http.StatusTooManyRequests
+}
+
+// resolveJSONPointer returns the value at pointer (RFC 6901) in document
+func resolveJSONPointer(document any, pointer string) (any, bool) {
+	if pointer == "" {
+		return document, true
+	}
+
+	current := document
+	for _, token := range strings.Split(pointer, "/")[1:] {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []any:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// formatJSONScalar converts a decoded JSON scalar into a metadata field
+func formatJSONScalar(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
+
+// buildHTTPTLSConfig creates a TLS configuration from the provided settings
+func buildHTTPTLSConfig(config *HTTPTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	// Load CA certificate if provided
+	if config.CACert != "" {
+		caCertData, err := os.ReadFile(config.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate file '%s': %w", config.CACert, err)
+		}
+
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCertData) {
+			return nil, fmt.Errorf("failed to parse CA certificate from file '%s'", config.CACert)
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	// Load client certificate and key if provided
+	if config.ClientCert != "" && config.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(config.ClientCert, config.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate pair: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,181 @@
+package attribute_match_database
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestHTTPDataSource(t *testing.T, config *HTTPConfig) *HTTPDataSource {
+	t.Helper()
+
+	config.ApplyDefaults()
+	dataSource, err := NewHTTPDataSource(context.Background(), config)
+	if err != nil {
+		t.Fatalf("failed to create data source: %v", err)
+	}
+	t.Cleanup(func() { _ = dataSource.Close() })
+	return dataSource
+}
+
+func TestHTTPDataSourceStatusCodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("key") {
+		case "k 1/2":
+			w.WriteHeader(http.StatusNoContent)
+		case "unknown":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	dataSource := newTestHTTPDataSource(t, &HTTPConfig{
+		URL:              server.URL + "/lookup?key={key}",
+		MatchStatusCodes: []int{http.StatusOK, http.StatusNoContent},
+	})
+
+	found, err := dataSource.Contains(context.Background(), "k 1/2")
+	if err != nil || !found {
+		t.Fatalf("expected match, got %v, %v", found, err)
+	}
+
+	found, err = dataSource.Contains(context.Background(), "unknown")
+	if err != nil || found {
+		t.Fatalf("expected miss, got %v, %v", found, err)
+	}
+
+	if _, err := dataSource.Contains(context.Background(), "broken"); err == nil || !strings.Contains(err.Error(), "unexpected status 500") {
+		t.Fatalf("expected unexpected status error, got %v", err)
+	}
+}
+
+func TestHTTPDataSourceMatchPointer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch strings.TrimPrefix(r.URL.Path, "/keys/") {
+		case "blocked":
+			_, _ = io.WriteString(w, `{"result":{"blocked":true},"reason":"abuse","score":0.9,"tags":["a"]}`)
+		case "allowed":
+			_, _ = io.WriteString(w, `{"result":{"blocked":false}}`)
+		case "string":
+			_, _ = io.WriteString(w, `{"result":{"blocked":"yes"}}`)
+		default:
+			_, _ = io.WriteString(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	dataSource := newTestHTTPDataSource(t, &HTTPConfig{
+		URL:          server.URL + "/keys/{key}",
+		MatchPointer: "/result/blocked",
+	})
+
+	found, metadata, err := dataSource.LookupMetadata(context.Background(), "blocked")
+	if err != nil || !found {
+		t.Fatalf("expected match, got %v, %v", found, err)
+	}
+	if len(metadata) != 2 || metadata["reason"] != "abuse" || metadata["score"] != "0.9" {
+		t.Fatalf("unexpected metadata: %v", metadata)
+	}
+
+	found, metadata, err = dataSource.LookupMetadata(context.Background(), "allowed")
+	if err != nil || found || metadata != nil {
+		t.Fatalf("expected miss, got %v, %v, %v", found, metadata, err)
+	}
+
+	if _, err := dataSource.Contains(context.Background(), "string"); err == nil || !strings.Contains(err.Error(), "is not a boolean") {
+		t.Fatalf("expected boolean error, got %v", err)
+	}
+
+	if _, err := dataSource.Contains(context.Background(), "missing"); err == nil || !strings.Contains(err.Error(), "no value at") {
+		t.Fatalf("expected missing value error, got %v", err)
+	}
+}
+
+func TestHTTPDataSourcePostBody(t *testing.T) {
+	setEnv(t, "HTTP_LOOKUP_TOKEN", "s3cr3t")
+
+	var gotBody, gotAuth, gotHeader, gotContentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		gotBody = string(payload)
+		gotAuth = r.Header.Get("Authorization")
+		gotHeader = r.Header.Get("X-Client")
+		gotContentType = r.Header.Get("Content-Type")
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	dataSource := newTestHTTPDataSource(t, &HTTPConfig{
+		URL:            server.URL + "/lookup",
+		Method:         "post",
+		Body:           `{"key":"{key}"}`,
+		Headers:        map[string]string{"X-Client": "authz"},
+		BearerTokenEnv: "HTTP_LOOKUP_TOKEN",
+	})
+
+	found, err := dataSource.Contains(context.Background(), `a"b`)
+	if err != nil || !found {
+		t.Fatalf("expected match, got %v, %v", found, err)
+	}
+	if gotBody != `{"key":"a\"b"}` {
+		t.Fatalf("unexpected body: %s", gotBody)
+	}
+	if gotAuth != "Bearer s3cr3t" || gotHeader != "authz" || gotContentType != "application/json" {
+		t.Fatalf("unexpected headers: %q, %q, %q", gotAuth, gotHeader, gotContentType)
+	}
+}
+
+func TestHTTPDataSourceHealthCheck(t *testing.T) {
+	healthy := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	dataSource := newTestHTTPDataSource(t, &HTTPConfig{
+		URL:       server.URL + "/lookup/{key}",
+		HealthURL: server.URL + "/healthz",
+	})
+
+	if err := dataSource.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("expected healthy service, got %v", err)
+	}
+
+	healthy = false
+	if err := dataSource.HealthCheck(context.Background()); err == nil || !strings.Contains(err.Error(), "status 503") {
+		t.Fatalf("expected health check error, got %v", err)
+	}
+
+	config := &HTTPConfig{URL: server.URL + "/lookup/{key}", HealthURL: server.URL + "/healthz"}
+	config.ApplyDefaults()
+	if _, err := NewHTTPDataSource(context.Background(), config); err == nil {
+		t.Fatal("expected unhealthy service to fail data source creation")
+	}
+}
+
+func TestResolveJSONPointer(t *testing.T) {
+	document := map[string]any{
+		"a/b": map[string]any{"c~d": []any{false, true}},
+	}
+
+	value, ok := resolveJSONPointer(document, "/a~1b/c~0d/1")
+	if !ok || value != true {
+		t.Fatalf("expected true, got %v, %v", value, ok)
+	}
+
+	for _, pointer := range []string{"/missing", "/a~1b/c~0d/2", "/a~1b/c~0d/x"} {
+		if value, ok := resolveJSONPointer(document, pointer); ok {
+			t.Fatalf("expected no value at %s, got %v", pointer, value)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package attribute_match_database
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/analysis/maxmind_asn"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+// keyExtractor derives the lookup key from a request and normalizes the keys
+// read back from the database, so that both compare equal
+type keyExtractor struct {
+	source  string
+	name    string
+	pattern *regexp.Regexp
+	group   int
+}
+
+// newKeyExtractor builds the extractor of a validated key configuration
+func newKeyExtractor(cfg KeyConfig) *keyExtractor {
+	extractor := &keyExtractor{
+		source: cfg.Source,
+		name:   strings.ToLower(cfg.Name),
+	}
+	if cfg.Source == KeySourceContextExtension {
+		extractor.name = cfg.Name
+	}
+	if cfg.Pattern != "" {
+		extractor.pattern = regexp.MustCompile(cfg.Pattern)
+		extractor.group = cfg.group(extractor.pattern)
+	}
+	return extractor
+}
+
+// extract returns the lookup key of the request, or false when the request
+// does not carry one
+func (k *keyExtractor) extract(req *runtime.RequestContext, reports controller.AnalysisReports) (string, bool) {
+	var value string
+
+	switch k.source {
+	case KeySourceIP:
+		if !req.IpAddress.IsValid() {
+			return "", false
+		}
+		return req.IpAddress.String(), true
+	case KeySourceASN:
+		for _, report := range reports {
+			if report == nil || report.ControllerKind != maxmind_asn.ControllerKind {
+				continue
+			}
+			if lookupResult := maxmind_asn.GetIpLookupResultFromReport(report); lookupResult != nil {
+				return strconv.FormatUint(uint64(lookupResult.AutonomousSystemNumber), 10), true
+			}
+		}
+		return "", false
+	case KeySourceHeader:
+		for name, headerValue := range req.Request.GetAttributes().GetRequest().GetHttp().GetHeaders() {
+			if strings.ToLower(name) == k.name {
+				value = headerValue
+				break
+			}
+		}
+	case KeySourcePath:
+		value, _, _ = strings.Cut(req.Request.GetAttributes().GetRequest().GetHttp().GetPath(), "?")
+	case KeySourceContextExtension:
+		value = req.Request.GetAttributes().GetContextExtensions()[k.name]
+	}
+
+	if k.pattern != nil {
+		match := k.pattern.FindStringSubmatch(value)
+		if match == nil {
+			return "", false
+		}
+		value = match[k.group]
+	}
+
+	return value, value != ""
+}
+
+// normalize converts a key read from the database, by a full sync or in a
+// change notification, into the form produced by extract
+func (k *keyExtractor) normalize(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+
+	switch k.source {
+	case KeySourceIP:
+		if prefix, err := netip.ParsePrefix(value); err == nil && prefix.IsSingleIP() {
+			value = prefix.Addr().String()
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return "", false
+		}
+		return addr.String(), true
+	case KeySourceASN:
+		asn, ok := ParseASN(value)
+		if !ok {
+			return "", false
+		}
+		return strconv.FormatUint(uint64(asn), 10), true
+	}
+
+	return value, value != ""
+}
+
+// snapshotEntry normalizes an entry read by a full sync. With IP keys stored
+// values may be networks, which are indexed as ranges.
+func (k *keyExtractor) snapshotEntry(entry SnapshotEntry) (SnapshotEntry, bool) {
+	if entry.Key == "" {
+		// Ranges are only read for IP keys
+		return entry, k.source == KeySourceIP
+	}
+	if k.source == KeySourceIP {
+		return ParseSnapshotEntry(entry.Key, entry.Comment)
+	}
+
+	key, ok := k.normalize(entry.Key)
+	return SnapshotEntry{Key: key, Comment: entry.Comment}, ok
+}
+
+// describe renders a lookup key for verdict descriptions
+func (k *keyExtractor) describe(key string) string {
+	switch k.source {
+	case KeySourceIP:
+		return "IP " + key
+	case KeySourceASN:
+		return "ASN " + key
+	}
+	return fmt.Sprintf("key '%s'", key)
+}
+
+// missingDescription explains why a request carries no lookup key
+func (k *keyExtractor) missingDescription() string {
+	switch k.source {
+	case KeySourceIP:
+		return "unable to determine source IP address"
+	case KeySourceASN:
+		return "no ASN information available"
+	case KeySourceHeader:
+		return fmt.Sprintf("no lookup key in header '%s'", k.name)
+	case KeySourceContextExtension:
+		return fmt.Sprintf("no lookup key in context extension '%s'", k.name)
+	}
+	return "no lookup key in path"
+}
+
+// logField renders a lookup key for logging
+func (k *keyExtractor) logField(key string) zap.Field {
+	switch k.source {
+	case KeySourceIP, KeySourceASN:
+		return zap.String(k.source, key)
+	}
+	return zap.String("key", key)
+}
+
+// ParseASN parses an AS number as stored in the database ("15169" or "AS15169")
+func ParseASN(value string) (uint, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) > 2 && strings.EqualFold(value[:2], "AS") {
+		value = strings.TrimSpace(value[2:])
+	}
+
+	asn, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(asn), true
+}
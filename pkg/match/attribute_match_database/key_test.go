@@ -0,0 +1,134 @@
+package attribute_match_database
+
+import (
+	"net/netip"
+	"testing"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/analysis/maxmind_asn"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+func TestKeyExtractor(t *testing.T) {
+	request := &runtime.RequestContext{
+		IpAddress: netip.MustParseAddr("203.0.113.10"),
+		Request: &authv3.CheckRequest{
+			Attributes: &authv3.AttributeContext{
+				Request: &authv3.AttributeContext_Request{
+					Http: &authv3.AttributeContext_HttpRequest{
+						Path: "/tenants/acme/orders?page=2",
+						Headers: map[string]string{
+							"x-api-key":     "k-123",
+							"authorization": "Bearer token-456",
+						},
+					},
+				},
+				ContextExtensions: map[string]string{"ja3": "e7d705a3286e19ea42f587b344ee6865"},
+			},
+		},
+	}
+	reports := asnReports(64500)
+	group := func(group int) *int { return &group }
+
+	tests := []struct {
+		name   string
+		config KeyConfig
+		want   string
+	}{
+		{name: "ip", config: KeyConfig{Source: KeySourceIP}, want: "203.0.113.10"},
+		{name: "asn", config: KeyConfig{Source: KeySourceASN}, want: "64500"},
+		{name: "header", config: KeyConfig{Source: KeySourceHeader, Name: "X-API-Key"}, want: "k-123"},
+		{name: "header pattern", config: KeyConfig{Source: KeySourceHeader, Name: "authorization", Pattern: `^Bearer (.+)$`}, want: "token-456"},
+		{name: "missing header", config: KeyConfig{Source: KeySourceHeader, Name: "x-tenant"}},
+		{name: "header pattern mismatch", config: KeyConfig{Source: KeySourceHeader, Name: "x-api-key", Pattern: `^Bearer (.+)$`}},
+		{name: "path", config: KeyConfig{Source: KeySourcePath, Pattern: `^/tenants/([^/]+)/`}, want: "acme"},
+		{name: "path mismatch", config: KeyConfig{Source: KeySourcePath, Pattern: `^/(tenants)/([^/]+)$`}},
+		{name: "path without query", config: KeyConfig{Source: KeySourcePath, Pattern: `/orders$`}, want: "/orders"},
+		{name: "path explicit group", config: KeyConfig{Source: KeySourcePath, Pattern: `^/(tenants)/([^/]+)/`, Group: group(2)}, want: "acme"},
+		{name: "context extension", config: KeyConfig{Source: KeySourceContextExtension, Name: "ja3"}, want: "e7d705a3286e19ea42f587b344ee6865"},
+		{name: "missing context extension", config: KeyConfig{Source: KeySourceContextExtension, Name: "JA3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.validate(); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			key, ok := newKeyExtractor(tt.config).extract(request, reports)
+			if ok != (tt.want != "") || key != tt.want {
+				t.Fatalf("extract() = %q, %v, want %q", key, ok, tt.want)
+			}
+		})
+	}
+
+	t.Run("missing request data", func(t *testing.T) {
+		for _, source := range []string{KeySourceIP, KeySourceASN} {
+			if key, ok := newKeyExtractor(KeyConfig{Source: source}).extract(runtime.NewRequestContext(nil), nil); ok {
+				t.Fatalf("expected no %s key, got %q", source, key)
+			}
+		}
+	})
+}
+
+func TestKeyNormalize(t *testing.T) {
+	tests := []struct {
+		source string
+		value  string
+		want   string
+	}{
+		{source: KeySourceIP, value: " 203.0.113.10 ", want: "203.0.113.10"},
+		{source: KeySourceIP, value: "203.0.113.10/32", want: "203.0.113.10"},
+		{source: KeySourceIP, value: "10.0.0.0/8"},
+		{source: KeySourceASN, value: "AS64500", want: "64500"},
+		{source: KeySourceASN, value: "not-an-asn"},
+		{source: KeySourceHeader, value: " k-123 ", want: "k-123"},
+		{source: KeySourceHeader, value: ""},
+	}
+
+	for _, tt := range tests {
+		key, ok := newKeyExtractor(KeyConfig{Source: tt.source, Name: "x"}).normalize(tt.value)
+		if ok != (tt.want != "") || key != tt.want {
+			t.Fatalf("normalize(%s, %q) = %q, %v, want %q", tt.source, tt.value, key, ok, tt.want)
+		}
+	}
+}
+
+func TestParseASN(t *testing.T) {
+	tests := []struct {
+		value string
+		want  uint
+		ok    bool
+	}{
+		{value: "15169", want: 15169, ok: true},
+		{value: "AS15169", want: 15169, ok: true},
+		{value: " as 64500 ", want: 64500, ok: true},
+		{value: "4294967295", want: 4294967295, ok: true},
+		{value: "4294967296"},
+		{value: "AS"},
+		{value: "google"},
+		{value: ""},
+	}
+
+	for _, tt := range tests {
+		asn, ok := ParseASN(tt.value)
+		if ok != tt.ok || asn != tt.want {
+			t.Fatalf("ParseASN(%q) = %d, %v, want %d, %v", tt.value, asn, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func asnReports(asn uint) controller.AnalysisReports {
+	return controller.AnalysisReports{
+		"asn": {
+			ControllerKind: maxmind_asn.ControllerKind,
+			Data: map[string]any{
+				"result": &maxmind_asn.IpLookupResult{
+					AutonomousSystemNumber:       asn,
+					AutonomousSystemOrganization: "test",
+				},
+			},
+		},
+	}
+}
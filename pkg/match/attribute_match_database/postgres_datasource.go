@@ -0,0 +1,369 @@
+package attribute_match_database
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+)
+
+// postgresQueryExecModes maps the queryExecMode setting to pgx modes
+var postgresQueryExecModes = map[string]pgx.QueryExecMode{
+	PostgresExecModeCacheStatement: pgx.QueryExecModeCacheStatement,
+	PostgresExecModeCacheDescribe:  pgx.QueryExecModeCacheDescribe,
+	PostgresExecModeDescribeExec:   pgx.QueryExecModeDescribeExec,
+	PostgresExecModeExec:           pgx.QueryExecModeExec,
+	PostgresExecModeSimpleProtocol: pgx.QueryExecModeSimpleProtocol,
+}
+
+// PostgresDataSource implements DataSource for PostgreSQL
+type PostgresDataSource struct {
+	pool          *pgxpool.Pool
+	shared        *controller.DataSourceHandle // set when the pool belongs to a shared datasource
+	query         string
+	syncQuery     string
+	notifyChannel string
+}
+
+// NewPostgresDataSource creates a new PostgreSQL data source from configuration
+func NewPostgresDataSource(ctx context.Context, config *PostgresConfig) (*PostgresDataSource, error) {
+	if config == nil {
+		return nil, fmt.Errorf("postgres configuration is required")
+	}
+
+	pool, err := newPostgresPool(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PostgresDataSource{
+		pool:          pool,
+		query:         config.Query,
+		syncQuery:     config.SyncQuery,
+		notifyChannel: config.NotifyChannel,
+	}, nil
+}
+
+// newSharedPostgresDataSource creates a PostgreSQL data source running the
+// queries of config on the pool of a shared datasource
+func newSharedPostgresDataSource(shared *controller.DataSourceHandle, pool *pgxpool.Pool, config *PostgresConfig) *PostgresDataSource {
+	return &PostgresDataSource{
+		pool:          pool,
+		shared:        shared,
+		query:         config.Query,
+		syncQuery:     config.SyncQuery,
+		notifyChannel: config.NotifyChannel,
+	}
+}
+
+// newPostgresPool builds a connection pool from the connection settings and verifies connectivity
+func newPostgresPool(ctx context.Context, config *PostgresConfig) (*pgxpool.Pool, error) {
+	// Get credentials from environment
+	username := os.Getenv(config.UsernameEnv)
+	if username == "" {
+		return nil, fmt.Errorf("username is empty in environment variable '%s'", config.UsernameEnv)
+	}
+
+	password := os.Getenv(config.PasswordEnv)
+	if password == "" {
+		return nil, fmt.Errorf("password is empty in environment variable '%s'", config.PasswordEnv)
+	}
+
+	// Build connection string
+	connString := fmt.Sprintf(
+		"postgres://%s:%s@%s:%d/%s",
+		username,
+		password,
+		config.Host,
+		config.Port,
+		config.DatabaseName,
+	)
+
+	// Parse pool config
+	poolConfig, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection string: %w", err)
+	}
+
+	// Apply defaults
+	poolConfig.MaxConns = 10
+	poolConfig.MinConns = 2
+	poolConfig.MaxConnIdleTime = 5 * time.Minute
+	poolConfig.ConnConfig.ConnectTimeout = 5 * time.Second
+
+	// Apply pool settings
+	if config.Pool != nil {
+		if config.Pool.MaxConnections > 0 {
+			poolConfig.MaxConns = int32(config.Pool.MaxConnections)
+		}
+
+		if config.Pool.MinConnections >= 0 {
+			poolConfig.MinConns = int32(config.Pool.MinConnections)
+		}
+
+		if config.Pool.MaxIdleTime != "" {
+			maxIdleTime, err := time.ParseDuration(config.Pool.MaxIdleTime)
+			if err == nil && maxIdleTime > 0 {
+				poolConfig.MaxConnIdleTime = maxIdleTime
+			}
+		}
+
+		if config.Pool.ConnectionTimeout != "" {
+			connTimeout, err := time.ParseDuration(config.Pool.ConnectionTimeout)
+			if err == nil && connTimeout > 0 {
+				poolConfig.ConnConfig.ConnectTimeout = connTimeout
+			}
+		}
+
+		if config.Pool.HealthCheckPeriod != "" {
+			period, err := time.ParseDuration(config.Pool.HealthCheckPeriod)
+			if err == nil && period > 0 {
+				poolConfig.HealthCheckPeriod = period
+			}
+		}
+	}
+
+	// Apply query settings
+	if config.StatementTimeout != "" {
+		statementTimeout, err := time.ParseDuration(config.StatementTimeout)
+		if err == nil && statementTimeout > 0 {
+			poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(statementTimeout.Milliseconds(), 10)
+		}
+	}
+	if execMode, ok := postgresQueryExecModes[config.QueryExecMode]; ok {
+		poolConfig.ConnConfig.DefaultQueryExecMode = execMode
+	}
+
+	// Configure TLS if enabled
+	if config.TLS != nil {
+		tlsConfig, sslMode, err := buildPostgresTLSConfig(config.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build TLS configuration: %w", err)
+		}
+
+		if tlsConfig != nil {
+			poolConfig.ConnConfig.TLSConfig = tlsConfig
+		}
+
+		// Set SSL mode in connection string
+		if sslMode != "" {
+			poolConfig.ConnConfig.RuntimeParams["sslmode"] = sslMode
+		}
+	}
+
+	// Create pool
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection pool: %w", err)
+	}
+
+	// Test connection
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+	}
+
+	return pool, nil
+}
+
+// Contains checks if the lookup key exists in PostgreSQL. The key is sent as
+// text, which PostgreSQL converts to the type of the query parameter.
+func (p *PostgresDataSource) Contains(ctx context.Context, key string) (bool, error) {
+	rows, err := p.pool.Query(ctx, p.query, key)
+	if err != nil {
+		return false, fmt.Errorf("postgres query failed: %w", err)
+	}
+	defer rows.Close()
+
+	// Any rows returned = match, zero rows = no match
+	return rows.Next(), nil
+}
+
+// LookupMetadata implements MetadataDataSource. The payload holds the columns
+// of the first row returned by the query, indexed by column name; NULL columns
+// are omitted.
+func (p *PostgresDataSource) LookupMetadata(ctx context.Context, key string) (bool, Metadata, error) {
+	rows, err := p.pool.Query(ctx, p.query, key)
+	if err != nil {
+		return false, nil, fmt.Errorf("postgres query failed: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return false, nil, fmt.Errorf("postgres query failed: %w", err)
+		}
+		return false, nil, nil
+	}
+
+	values, err := rows.Values()
+	if err != nil {
+		return false, nil, fmt.Errorf("postgres query failed: %w", err)
+	}
+
+	metadata := make(Metadata, len(values))
+	for i, field := range rows.FieldDescriptions() {
+		if values[i] != nil {
+			metadata[field.Name] = formatColumnValue(values[i])
+		}
+	}
+	return true, metadata, nil
+}
+
+// formatColumnValue converts a column value into a metadata field
+func formatColumnValue(value any) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// LoadSnapshot implements SnapshotSource by running the sync query. Its first
+// column is the stored key (with IP keys, an IP address or network as text,
+// inet or cidr), the optional second column a comment.
+func (p *PostgresDataSource) LoadSnapshot(ctx context.Context) ([]SnapshotEntry, error) {
+	if p.syncQuery == "" {
+		return nil, fmt.Errorf("postgres sync query is not configured")
+	}
+
+	rows, err := p.pool.Query(ctx, p.syncQuery)
+	if err != nil {
+		return nil, fmt.Errorf("postgres query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []SnapshotEntry
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, fmt.Errorf("postgres query failed: %w", err)
+		}
+		if len(values) == 0 || values[0] == nil {
+			continue
+		}
+		var comment string
+		if len(values) > 1 && values[1] != nil {
+			comment = fmt.Sprint(values[1])
+		}
+		entries = append(entries, SnapshotEntry{Key: fmt.Sprint(values[0]), Comment: comment})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("postgres query failed: %w", err)
+	}
+
+	return entries, nil
+}
+
+// WatchInvalidations implements InvalidationSource by LISTENing to the
+// configured notification channel. The payload of each notification is the
+// changed key; an empty payload invalidates every key.
+func (p *PostgresDataSource) WatchInvalidations(ctx context.Context, ready func(), invalidate func(key string)) error {
+	if p.notifyChannel == "" {
+		return fmt.Errorf("postgres notification channel is not configured")
+	}
+
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("postgres listen failed: %w", err)
+	}
+
+	// The listening connection is taken out of the pool for good
+	listenConn := conn.Hijack()
+	defer listenConn.Close(context.WithoutCancel(ctx))
+
+	if _, err := listenConn.Exec(ctx, "LISTEN "+pgx.Identifier{p.notifyChannel}.Sanitize()); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("postgres listen failed: %w", err)
+	}
+	ready()
+
+	for {
+		notification, err := listenConn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("postgres notification channel dropped: %w", err)
+		}
+		invalidate(notification.Payload)
+	}
+}
+
+// Close releases PostgreSQL pool resources, or the reference to the shared pool
+func (p *PostgresDataSource) Close() error {
+	if p.shared != nil {
+		p.shared.Release()
+		return nil
+	}
+	if p.pool != nil {
+		p.pool.Close()
+	}
+	return nil
+}
+
+// HealthCheck verifies connectivity to PostgreSQL
+func (p *PostgresDataSource) HealthCheck(ctx context.Context) error {
+	if p.shared != nil {
+		return p.shared.HealthCheck(ctx)
+	}
+	return p.pool.Ping(ctx)
+}
+
+// buildPostgresTLSConfig creates a TLS configuration from the provided settings
+// Returns (tlsConfig, sslMode, error)
+func buildPostgresTLSConfig(config *PostgresTLSConfig) (*tls.Config, string, error) {
+	// Determine SSL mode
+	sslMode := "prefer" // Default
+	if config.Mode != "" {
+		sslMode = config.Mode
+	}
+
+	// If mode is "disable", no TLS config needed
+	if sslMode == "disable" {
+		return nil, sslMode, nil
+	}
+
+	tlsConfig := &tls.Config{}
+
+	// Load CA certificate if provided
+	if config.CACert != "" {
+		caCertData, err := os.ReadFile(config.CACert)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read CA certificate file '%s': %w", config.CACert, err)
+		}
+
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCertData) {
+			return nil, "", fmt.Errorf("failed to parse CA certificate from file '%s'", config.CACert)
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	// Load client certificate and key if provided
+	if config.ClientCert != "" && config.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(config.ClientCert, config.ClientKey)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to load client certificate pair: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, sslMode, nil
+}
@@ -0,0 +1,335 @@
+package attribute_match_database
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+)
+
+// scanBatchSize is the COUNT hint of the SCAN commands issued by full syncs
+const scanBatchSize = 1000
+
+// RedisDataSource implements DataSource for Redis
+type RedisDataSource struct {
+	client    redis.UniversalClient
+	shared    *controller.DataSourceHandle // set when the client belongs to a shared datasource
+	keyPrefix string
+	valueType string
+	db        int
+}
+
+// NewRedisDataSource creates a new Redis data source from configuration
+func NewRedisDataSource(ctx context.Context, config *RedisConfig) (*RedisDataSource, error) {
+	client, err := newRedisClient(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisDataSource{
+		client:    client,
+		keyPrefix: config.KeyPrefix,
+		valueType: config.ValueType,
+		db:        config.DB,
+	}, nil
+}
+
+// Contains checks if the lookup key exists in Redis
+func (r *RedisDataSource) Contains(ctx context.Context, lookupKey string) (bool, error) {
+	key := r.keyPrefix + lookupKey
+
+	result, err := r.client.Exists(ctx, key).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis query failed: %w", err)
+	}
+
+	return result > 0, nil
+}
+
+// LookupMetadata implements MetadataDataSource. The payload is the value of
+// the key, exposed as the "value" field, or the fields of a hash key when
+// valueType is 'hash'.
+func (r *RedisDataSource) LookupMetadata(ctx context.Context, lookupKey string) (bool, Metadata, error) {
+	key := r.keyPrefix + lookupKey
+
+	if r.valueType == RedisValueHash {
+		fields, err := r.client.HGetAll(ctx, key).Result()
+		if err != nil {
+			return false, nil, fmt.Errorf("redis query failed: %w", err)
+		}
+		if len(fields) == 0 {
+			return false, nil, nil
+		}
+		return true, Metadata(fields), nil
+	}
+
+	value, err := r.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, fmt.Errorf("redis query failed: %w", err)
+	}
+	return true, Metadata{"value": value}, nil
+}
+
+// Close releases Redis client resources, or the reference to the shared client
+func (r *RedisDataSource) Close() error {
+	if r.shared != nil {
+		r.shared.Release()
+		return nil
+	}
+	if r.client != nil {
+		return r.client.Close()
+	}
+	return nil
+}
+
+// HealthCheck verifies connectivity to Redis
+func (r *RedisDataSource) HealthCheck(ctx context.Context) error {
+	if r.shared != nil {
+		return r.shared.HealthCheck(ctx)
+	}
+	return r.client.Ping(ctx).Err()
+}
+
+// LoadSnapshot implements SnapshotSource by scanning the keys under
+// keyPrefix. The key suffixes are the stored lookup keys (with IP keys, they
+// may also be CIDRs).
+func (r *RedisDataSource) LoadSnapshot(ctx context.Context) ([]SnapshotEntry, error) {
+	var entries []SnapshotEntry
+	err := scanKeys(ctx, r.client, escapeRedisPattern(r.keyPrefix)+"*", func(key string) {
+		entries = append(entries, SnapshotEntry{Key: strings.TrimPrefix(key, r.keyPrefix)})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// scanKeys iterates over the keys matching pattern, on every master node when
+// connected to a Redis Cluster
+func scanKeys(ctx context.Context, client redis.UniversalClient, pattern string, onKey func(key string)) error {
+	scanNode := func(ctx context.Context, node redis.Cmdable) ([]string, error) {
+		var keys []string
+		iter := node.Scan(ctx, 0, pattern, scanBatchSize).Iterator()
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			return nil, fmt.Errorf("redis scan failed: %w", err)
+		}
+		return keys, nil
+	}
+
+	cluster, ok := client.(*redis.ClusterClient)
+	if !ok {
+		keys, err := scanNode(ctx, client)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			onKey(key)
+		}
+		return nil
+	}
+
+	var mu sync.Mutex
+	return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		keys, err := scanNode(ctx, node)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, key := range keys {
+			onKey(key)
+		}
+		return nil
+	})
+}
+
+// WatchInvalidations implements InvalidationSource through keyspace
+// notifications for the keys under keyPrefix
+func (r *RedisDataSource) WatchInvalidations(ctx context.Context, ready func(), invalidate func(key string)) error {
+	pattern := keyspaceChannel(r.db, escapeRedisPattern(r.keyPrefix)+"*")
+	return watchKeyspace(ctx, r.client, pattern, ready, func(key string) {
+		invalidate(strings.TrimPrefix(key, r.keyPrefix))
+	})
+}
+
+// keyspaceChannel returns the keyspace notification channel of a key (or key pattern)
+func keyspaceChannel(db int, key string) string {
+	return fmt.Sprintf("__keyspace@%d__:%s", db, key)
+}
+
+// watchKeyspace subscribes to the keyspace notification channels matching
+// pattern and calls onEvent with the key of every event. The Redis server must
+// publish keyspace events (notify-keyspace-events), otherwise none arrive.
+func watchKeyspace(ctx context.Context, client redis.UniversalClient, pattern string, ready func(), onEvent func(key string)) error {
+	pubsub := client.PSubscribe(ctx, pattern)
+	defer pubsub.Close()
+
+	// Blocking reads do not observe ctx, closing the subscription interrupts them
+	stop := context.AfterFunc(ctx, func() { _ = pubsub.Close() })
+	defer stop()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("redis keyspace subscription failed: %w", err)
+	}
+	ready()
+
+	for {
+		msg, err := pubsub.ReceiveMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("redis keyspace subscription dropped: %w", err)
+		}
+		_, key, _ := strings.Cut(msg.Channel, "__:")
+		onEvent(key)
+	}
+}
+
+// escapeRedisPattern escapes the glob characters of a literal key prefix
+func escapeRedisPattern(value string) string {
+	var builder strings.Builder
+	for _, r := range value {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			builder.WriteRune('\\')
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// newRedisClient builds a Redis client for the configured topology and verifies connectivity
+func newRedisClient(ctx context.Context, config *RedisConfig) (redis.UniversalClient, error) {
+	if config == nil {
+		return nil, fmt.Errorf("redis configuration is required")
+	}
+
+	// Resolve credentials if configured
+	var username, password string
+	if config.UsernameEnv != "" {
+		username = os.Getenv(config.UsernameEnv)
+	}
+	if config.PasswordEnv != "" {
+		password = os.Getenv(config.PasswordEnv)
+	}
+
+	// Configure TLS if enabled
+	var tlsConfig *tls.Config
+	if config.TLS != nil {
+		var err error
+		tlsConfig, err = buildRedisTLSConfig(config.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build TLS configuration: %w", err)
+		}
+	}
+
+	// Create client
+	var client redis.UniversalClient
+	switch config.Mode {
+	case RedisModeSentinel:
+		opts := &redis.FailoverOptions{
+			MasterName:    config.Sentinel.MasterName,
+			SentinelAddrs: config.Sentinel.Addresses,
+			Username:      username,
+			Password:      password,
+			DB:            config.DB,
+			TLSConfig:     tlsConfig,
+			ReadTimeout:   config.GetReadTimeout(),
+			WriteTimeout:  config.GetWriteTimeout(),
+			PoolSize:      config.PoolSize,
+		}
+		if config.Sentinel.UsernameEnv != "" {
+			opts.SentinelUsername = os.Getenv(config.Sentinel.UsernameEnv)
+		}
+		if config.Sentinel.PasswordEnv != "" {
+			opts.SentinelPassword = os.Getenv(config.Sentinel.PasswordEnv)
+		}
+		if config.ReadFromReplica {
+			// Lookups are read-only: route them to a replica, falling back to the master
+			opts.ReplicaOnly = true
+			client = redis.NewFailoverClusterClient(opts)
+		} else {
+			client = redis.NewFailoverClient(opts)
+		}
+	case RedisModeCluster:
+		client = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        config.Cluster.Addresses,
+			Username:     username,
+			Password:     password,
+			TLSConfig:    tlsConfig,
+			ReadOnly:     config.ReadFromReplica,
+			ReadTimeout:  config.GetReadTimeout(),
+			WriteTimeout: config.GetWriteTimeout(),
+			PoolSize:     config.PoolSize,
+		})
+	default:
+		client = redis.NewClient(&redis.Options{
+			Addr:         fmt.Sprintf("%s:%d", config.Host, config.Port),
+			Username:     username,
+			Password:     password,
+			DB:           config.DB,
+			TLSConfig:    tlsConfig,
+			ReadTimeout:  config.GetReadTimeout(),
+			WriteTimeout: config.GetWriteTimeout(),
+			PoolSize:     config.PoolSize,
+		})
+	}
+
+	// Test connection
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	return client, nil
+}
+
+// buildRedisTLSConfig creates a TLS configuration from the provided settings
+func buildRedisTLSConfig(config *RedisTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	// Load CA certificate if provided
+	if config.CACert != "" {
+		caCertData, err := os.ReadFile(config.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate file '%s': %w", config.CACert, err)
+		}
+
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCertData) {
+			return nil, fmt.Errorf("failed to parse CA certificate from file '%s'", config.CACert)
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	// Load client certificate and key if provided
+	if config.ClientCert != "" && config.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(config.ClientCert, config.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate pair: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,23 @@
+package attribute_match_database
+
+import "testing"
+
+func TestKeyspaceChannel(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"scraper:", "__keyspace@0__:scraper:*"},
+		{"bad*keys?[1]\\", "__keyspace@0__:bad\\*keys\\?\\[1\\]\\\\*"},
+	}
+
+	for _, tt := range tests {
+		if got := keyspaceChannel(0, escapeRedisPattern(tt.prefix)+"*"); got != tt.want {
+			t.Fatalf("expected %q, got %q", tt.want, got)
+		}
+	}
+
+	if got := keyspaceChannel(3, "ranges"); got != "__keyspace@3__:ranges" {
+		t.Fatalf("unexpected channel for db 3: %q", got)
+	}
+}
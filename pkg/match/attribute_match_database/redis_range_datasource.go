@@ -0,0 +1,213 @@
+package attribute_match_database
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+)
+
+// RedisRangeDataSource implements RangeDataSource for Redis.
+//
+// Ranges are stored as members of a sorted set, all with score 0, so that
+// Redis orders them lexicographically. Each member has the form
+// "<end>|<start>|<comment>" where start and end are the first and last
+// address of the range as 32 lowercase hex digits (IPv4 addresses are encoded
+// in their IPv4-mapped IPv6 form). Scores are not used because a float64
+// cannot represent IPv6 addresses exactly. A lookup fetches the first member
+// whose end is not lower than the IP address and checks its start, so ranges
+// in the set must not overlap.
+type RedisRangeDataSource struct {
+	client   redis.UniversalClient
+	shared   *controller.DataSourceHandle // set when the client belongs to a shared datasource
+	rangeKey string
+	db       int
+}
+
+// NewRedisRangeDataSource creates a new Redis range data source from configuration
+func NewRedisRangeDataSource(ctx context.Context, config *RedisConfig) (*RedisRangeDataSource, error) {
+	client, err := newRedisClient(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisRangeDataSource{
+		client:   client,
+		rangeKey: config.RangeKey,
+		db:       config.DB,
+	}, nil
+}
+
+// Contains checks if the IP address falls within a range stored in Redis
+func (r *RedisRangeDataSource) Contains(ctx context.Context, ipAddress string) (bool, error) {
+	matchedRange, err := r.FindRange(ctx, ipAddress)
+	if err != nil {
+		return false, err
+	}
+	return matchedRange != nil, nil
+}
+
+// FindRange returns the stored range containing the IP address, or nil if none does
+func (r *RedisRangeDataSource) FindRange(ctx context.Context, ipAddress string) (*MatchedRange, error) {
+	addr, err := netip.ParseAddr(ipAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP address '%s': %w", ipAddress, err)
+	}
+	encoded := encodeRangeAddr(addr)
+
+	members, err := r.client.ZRangeByLex(ctx, r.rangeKey, &redis.ZRangeBy{
+		Min:   "[" + encoded,
+		Max:   "+",
+		Count: 1,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis query failed: %w", err)
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	start, end, comment, err := decodeRangeMember(members[0])
+	if err != nil {
+		return nil, err
+	}
+
+	// The first range ending at or after the IP does not contain it
+	if encodeRangeAddr(start) > encoded {
+		return nil, nil
+	}
+
+	return &MatchedRange{
+		Range:   formatRange(start, end),
+		Comment: comment,
+	}, nil
+}
+
+// LoadSnapshot implements SnapshotSource by reading the whole range set
+func (r *RedisRangeDataSource) LoadSnapshot(ctx context.Context) ([]SnapshotEntry, error) {
+	members, err := r.client.ZRange(ctx, r.rangeKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis query failed: %w", err)
+	}
+
+	entries := make([]SnapshotEntry, 0, len(members))
+	for _, member := range members {
+		start, end, comment, err := decodeRangeMember(member)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, SnapshotEntry{Start: start, End: end, Comment: comment})
+	}
+	return entries, nil
+}
+
+// WatchInvalidations implements InvalidationSource through keyspace
+// notifications for the range set. Any change may affect any IP address.
+func (r *RedisRangeDataSource) WatchInvalidations(ctx context.Context, ready func(), invalidate func(key string)) error {
+	pattern := keyspaceChannel(r.db, escapeRedisPattern(r.rangeKey))
+	return watchKeyspace(ctx, r.client, pattern, ready, func(string) {
+		invalidate("")
+	})
+}
+
+// Close releases Redis client resources, or the reference to the shared client
+func (r *RedisRangeDataSource) Close() error {
+	if r.shared != nil {
+		r.shared.Release()
+		return nil
+	}
+	if r.client != nil {
+		return r.client.Close()
+	}
+	return nil
+}
+
+// HealthCheck verifies connectivity to Redis
+func (r *RedisRangeDataSource) HealthCheck(ctx context.Context) error {
+	if r.shared != nil {
+		return r.shared.HealthCheck(ctx)
+	}
+	return r.client.Ping(ctx).Err()
+}
+
+// RedisRangeMember encodes a network and its comment as a member of the
+// sorted set read by RedisRangeDataSource
+func RedisRangeMember(prefix netip.Prefix, comment string) string {
+	prefix = prefix.Masked()
+	return encodeRangeAddr(lastAddress(prefix)) + "|" + encodeRangeAddr(prefix.Addr()) + "|" + comment
+}
+
+// encodeRangeAddr renders an address as 32 lowercase hex digits so that
+// lexicographic order matches numeric order across IPv4 and IPv6
+func encodeRangeAddr(addr netip.Addr) string {
+	bytes := addr.As16()
+	return hex.EncodeToString(bytes[:])
+}
+
+// decodeRangeMember parses a "<end>|<start>|<comment>" sorted set member
+func decodeRangeMember(member string) (start, end netip.Addr, comment string, err error) {
+	parts := strings.SplitN(member, "|", 3)
+	if len(parts) < 2 {
+		return netip.Addr{}, netip.Addr{}, "", fmt.Errorf("malformed range member '%s'", member)
+	}
+
+	if end, err = decodeRangeAddr(parts[0]); err != nil {
+		return netip.Addr{}, netip.Addr{}, "", fmt.Errorf("malformed range member '%s': %w", member, err)
+	}
+	if start, err = decodeRangeAddr(parts[1]); err != nil {
+		return netip.Addr{}, netip.Addr{}, "", fmt.Errorf("malformed range member '%s': %w", member, err)
+	}
+	if len(parts) == 3 {
+		comment = parts[2]
+	}
+
+	return start, end, comment, nil
+}
+
+// decodeRangeAddr parses 32 hex digits back into an address, unmapping IPv4
+func decodeRangeAddr(encoded string) (netip.Addr, error) {
+	decoded, err := hex.DecodeString(encoded)
+	if err != nil || len(decoded) != 16 {
+		return netip.Addr{}, fmt.Errorf("address '%s' is not 32 hex digits", encoded)
+	}
+	return netip.AddrFrom16([16]byte(decoded)).Unmap(), nil
+}
+
+// lastAddress returns the highest address within the prefix
+func lastAddress(prefix netip.Prefix) netip.Addr {
+	prefix = prefix.Masked()
+	bytes := prefix.Addr().As16()
+
+	hostBitsFrom := prefix.Bits()
+	if prefix.Addr().Is4() {
+		hostBitsFrom += 96
+	}
+	for i := hostBitsFrom; i < 128; i++ {
+		bytes[i/8] |= 1 << (7 - i%8)
+	}
+
+	addr := netip.AddrFrom16(bytes)
+	if prefix.Addr().Is4() {
+		return addr.Unmap()
+	}
+	return addr
+}
+
+// formatRange renders a range in CIDR notation when it is aligned to a
+// prefix, otherwise as "<start>-<end>"
+func formatRange(start, end netip.Addr) string {
+	if start.Is4() == end.Is4() {
+		for bits := 0; bits <= start.BitLen(); bits++ {
+			prefix := netip.PrefixFrom(start, bits)
+			if prefix.Masked().Addr() == start && lastAddress(prefix) == end {
+				return prefix.String()
+			}
+		}
+	}
+	return start.String() + "-" + end.String()
+}
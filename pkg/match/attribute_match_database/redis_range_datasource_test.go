@@ -0,0 +1,91 @@
+package attribute_match_database
+
+import (
+	"net/netip"
+	"sort"
+	"testing"
+)
+
+func TestRedisRangeMember(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		comment string
+		want    string
+	}{
+		{"192.0.2.0/24", "Bad subnet", "00000000000000000000ffffc00002ff|00000000000000000000ffffc0000200|Bad subnet"},
+		{"192.0.2.77/24", "", "00000000000000000000ffffc00002ff|00000000000000000000ffffc0000200|"},
+		{"203.0.113.10/32", "Single", "00000000000000000000ffffcb00710a|00000000000000000000ffffcb00710a|Single"},
+		{"2001:db8::/32", "Docs | v6", "20010db8ffffffffffffffffffffffff|20010db8000000000000000000000000|Docs | v6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			got := RedisRangeMember(netip.MustParsePrefix(tt.prefix), tt.comment)
+			if got != tt.want {
+				t.Fatalf("unexpected member:\ngot  %s\nwant %s", got, tt.want)
+			}
+
+			start, end, comment, err := decodeRangeMember(got)
+			if err != nil {
+				t.Fatalf("unexpected decode error: %v", err)
+			}
+			if formatRange(start, end) != netip.MustParsePrefix(tt.prefix).Masked().String() {
+				t.Fatalf("expected range %s to round-trip, got %s", tt.prefix, formatRange(start, end))
+			}
+			if comment != tt.comment {
+				t.Fatalf("expected comment %q, got %q", tt.comment, comment)
+			}
+		})
+	}
+}
+
+func TestRedisRangeMember_LexicographicOrder(t *testing.T) {
+	// Members sorted as Redis would sort them must follow numeric order of the range ends
+	members := []string{
+		RedisRangeMember(netip.MustParsePrefix("2001:db8::/32"), ""),
+		RedisRangeMember(netip.MustParsePrefix("10.0.0.0/8"), ""),
+		RedisRangeMember(netip.MustParsePrefix("9.255.255.0/24"), ""),
+		RedisRangeMember(netip.MustParsePrefix("192.0.2.0/24"), ""),
+	}
+	sort.Strings(members)
+
+	var ends []string
+	for _, member := range members {
+		_, end, _, _ := decodeRangeMember(member)
+		ends = append(ends, end.String())
+	}
+
+	want := []string{"9.255.255.255", "10.255.255.255", "192.0.2.255", "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff"}
+	for i := range want {
+		if ends[i] != want[i] {
+			t.Fatalf("unexpected order: got %v want %v", ends, want)
+		}
+	}
+}
+
+func TestDecodeRangeMember_Malformed(t *testing.T) {
+	for _, member := range []string{"", "not-a-range", "zz|00", "00000000000000000000ffffc00002ff|short"} {
+		if _, _, _, err := decodeRangeMember(member); err == nil {
+			t.Fatalf("expected error for %q", member)
+		}
+	}
+}
+
+func TestFormatRange(t *testing.T) {
+	tests := []struct {
+		start, end string
+		want       string
+	}{
+		{"10.0.0.0", "10.255.255.255", "10.0.0.0/8"},
+		{"10.0.0.1", "10.0.0.1", "10.0.0.1/32"},
+		{"10.0.0.1", "10.0.0.5", "10.0.0.1-10.0.0.5"},
+		{"0.0.0.0", "255.255.255.255", "0.0.0.0/0"},
+		{"2001:db8::", "2001:db8::ff", "2001:db8::/120"},
+	}
+
+	for _, tt := range tests {
+		if got := formatRange(netip.MustParseAddr(tt.start), netip.MustParseAddr(tt.end)); got != tt.want {
+			t.Fatalf("formatRange(%s, %s) = %s, want %s", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
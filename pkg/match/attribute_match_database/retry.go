@@ -0,0 +1,99 @@
+package attribute_match_database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/redis/go-redis/v9"
+)
+
+// transientPostgresCodes are the SQLSTATE codes of failures a retry may not hit again
+var transientPostgresCodes = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"53300": true, // too_many_connections
+	"57014": true, // query_canceled, raised by statement_timeout
+	"57P01": true, // admin_shutdown
+	"57P03": true, // cannot_connect_now
+}
+
+// transientRedisPrefixes are the prefixes of Redis error replies a retry may not hit again
+var transientRedisPrefixes = []string{"LOADING ", "READONLY ", "MASTERDOWN ", "TRYAGAIN ", "CLUSTERDOWN ", "BUSY "}
+
+// isTransient reports whether a query error is worth retrying: timeouts,
+// dropped connections and overloaded servers, as opposed to errors a retry
+// would hit again such as a malformed query
+func isTransient(err error) bool {
+	switch {
+	case err == nil, errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, redis.ErrPoolTimeout):
+		return true
+	}
+
+	var transientErr interface{ Transient() bool }
+	if errors.As(err, &transientErr) {
+		return transientErr.Transient()
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.Code, "08") || transientPostgresCodes[pgErr.Code]
+	}
+	if pgconn.SafeToRetry(err) || pgconn.Timeout(err) {
+		return true
+	}
+
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) {
+		for _, prefix := range transientRedisPrefixes {
+			if strings.HasPrefix(redisErr.Error(), prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryBackoff returns the delay before the given retry (1 for the first
+// one): the backoff doubled on every retry and capped at maxBackoff, of which
+// a random half is waited so that concurrent retries spread out
+func (o RetryOptions) retryBackoff(retry int) time.Duration {
+	backoff := o.Backoff
+	for i := 1; i < retry && backoff < o.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, o.MaxBackoff)
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// sleep waits for d, returning false if ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,67 @@
+package attribute_match_database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisReply is an error reply from the Redis server
+type redisReply string
+
+func (e redisReply) Error() string { return string(e) }
+func (redisReply) RedisError()     {}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "deadline exceeded", err: fmt.Errorf("query: %w", context.DeadlineExceeded), want: true},
+		{name: "connection reset", err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, want: true},
+		{name: "unexpected EOF", err: io.ErrUnexpectedEOF, want: true},
+		{name: "redis pool timeout", err: redis.ErrPoolTimeout, want: true},
+		{name: "redis loading", err: redisReply("LOADING Redis is loading the dataset in memory"), want: true},
+		{name: "redis wrong type", err: redisReply("WRONGTYPE Operation against a key holding the wrong kind of value"), want: false},
+		{name: "postgres connection failure", err: &pgconn.PgError{Code: "08006"}, want: true},
+		{name: "postgres statement timeout", err: fmt.Errorf("query: %w", &pgconn.PgError{Code: "57014"}), want: true},
+		{name: "postgres syntax error", err: &pgconn.PgError{Code: "42601"}, want: false},
+		{name: "http server error", err: &httpStatusError{code: http.StatusServiceUnavailable}, want: true},
+		{name: "http rate limited", err: &httpStatusError{code: http.StatusTooManyRequests}, want: true},
+		{name: "http client error", err: &httpStatusError{code: http.StatusForbidden}, want: false},
+		{name: "other error", err: errors.New("invalid key"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransient(tt.err); got != tt.want {
+				t.Fatalf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	options := RetryOptions{MaxAttempts: 5, Backoff: 10 * time.Millisecond, MaxBackoff: 30 * time.Millisecond}
+
+	for retry, ceiling := range map[int]time.Duration{1: 10 * time.Millisecond, 2: 20 * time.Millisecond, 3: 30 * time.Millisecond, 8: 30 * time.Millisecond} {
+		for range 50 {
+			backoff := options.retryBackoff(retry)
+			if backoff < ceiling/2 || backoff > ceiling {
+				t.Fatalf("retry %d: backoff %s outside [%s, %s]", retry, backoff, ceiling/2, ceiling)
+			}
+		}
+	}
+}
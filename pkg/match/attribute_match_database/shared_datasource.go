@@ -0,0 +1,183 @@
+package attribute_match_database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/metrics"
+)
+
+// Shared datasource types, matching database.type
+const (
+	SharedDataSourcePostgres = "postgres"
+	SharedDataSourceRedis    = "redis"
+)
+
+// init registers the shared datasources with the controller registry.
+func init() {
+	controller.RegisterDataSourceFactory(SharedDataSourcePostgres, newSharedPostgresPool)
+	controller.RegisterDataSourceFactory(SharedDataSourceRedis, newSharedRedisClient)
+}
+
+// sharedPostgresPool is a PostgreSQL connection pool declared under datasources
+type sharedPostgresPool struct {
+	name string
+	pool *pgxpool.Pool
+}
+
+// newSharedPostgresPool constructs a shared PostgreSQL pool from configuration
+func newSharedPostgresPool(ctx context.Context, logger *zap.Logger, cfg config.DataSourceConfig) (controller.DataSource, error) {
+	var poolConfig SharedPostgresConfig
+	if err := controller.DecodeControllerSettings(cfg.Settings, &poolConfig); err != nil {
+		return nil, fmt.Errorf("failed to decode settings: %w", err)
+	}
+	poolConfig.ApplyDefaults()
+	if err := poolConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	initCtx, cancel := context.WithTimeout(ctx, poolConfig.GetConnectionTimeout())
+	defer cancel()
+
+	pool, err := newPostgresPool(initCtx, &poolConfig.PostgresConfig)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("connected to PostgreSQL",
+		zap.String("host", poolConfig.Host),
+		zap.Int("port", poolConfig.Port),
+		zap.String("database", poolConfig.DatabaseName),
+		zap.Int32("maxConnections", pool.Config().MaxConns),
+	)
+
+	return &sharedPostgresPool{name: cfg.Name, pool: pool}, nil
+}
+
+// Name returns the datasource name
+func (p *sharedPostgresPool) Name() string {
+	return p.name
+}
+
+// Kind returns the datasource type
+func (p *sharedPostgresPool) Kind() string {
+	return SharedDataSourcePostgres
+}
+
+// HealthCheck verifies connectivity to PostgreSQL
+func (p *sharedPostgresPool) HealthCheck(ctx context.Context) error {
+	return p.pool.Ping(ctx)
+}
+
+// Close releases the pool
+func (p *sharedPostgresPool) Close() error {
+	p.pool.Close()
+	return nil
+}
+
+// SetInstrumentation exports the connections of the pool.
+func (p *sharedPostgresPool) SetInstrumentation(inst *metrics.Instrumentation) {
+	inst.RegisterDataSourcePool(p.name, metrics.POSTGRES, func() metrics.PoolStats {
+		stat := p.pool.Stat()
+		return metrics.PoolStats{InUse: int(stat.AcquiredConns()), Idle: int(stat.IdleConns())}
+	})
+}
+
+// sharedRedisClient is a Redis client declared under datasources
+type sharedRedisClient struct {
+	name   string
+	client redis.UniversalClient
+	mode   string
+	db     int
+}
+
+// newSharedRedisClient constructs a shared Redis client from configuration
+func newSharedRedisClient(ctx context.Context, logger *zap.Logger, cfg config.DataSourceConfig) (controller.DataSource, error) {
+	var clientConfig SharedRedisConfig
+	if err := controller.DecodeControllerSettings(cfg.Settings, &clientConfig); err != nil {
+		return nil, fmt.Errorf("failed to decode settings: %w", err)
+	}
+	clientConfig.ApplyDefaults()
+	if err := clientConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	initCtx, cancel := context.WithTimeout(ctx, clientConfig.GetConnectionTimeout())
+	defer cancel()
+
+	client, err := newRedisClient(initCtx, &clientConfig.RedisConfig)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("connected to Redis",
+		zap.String("mode", clientConfig.Mode),
+		zap.Strings("addresses", clientConfig.Addresses()),
+		zap.Int("db", clientConfig.DB),
+	)
+
+	return &sharedRedisClient{name: cfg.Name, client: client, mode: clientConfig.Mode, db: clientConfig.DB}, nil
+}
+
+// Name returns the datasource name
+func (r *sharedRedisClient) Name() string {
+	return r.name
+}
+
+// Kind returns the datasource type
+func (r *sharedRedisClient) Kind() string {
+	return SharedDataSourceRedis
+}
+
+// Client returns the Redis client, for the controllers issuing their own commands
+func (r *sharedRedisClient) Client() redis.UniversalClient {
+	return r.client
+}
+
+// HealthCheck verifies connectivity to Redis
+func (r *sharedRedisClient) HealthCheck(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// Close releases the client
+func (r *sharedRedisClient) Close() error {
+	return r.client.Close()
+}
+
+// SetInstrumentation exports the connections of the client pool.
+func (r *sharedRedisClient) SetInstrumentation(inst *metrics.Instrumentation) {
+	inst.RegisterDataSourcePool(r.name, metrics.REDIS, func() metrics.PoolStats {
+		stats := r.client.PoolStats()
+		return metrics.PoolStats{InUse: int(stats.TotalConns - stats.IdleConns), Idle: int(stats.IdleConns)}
+	})
+}
+
+// newSharedDataSource creates the data source of a database using a shared
+// datasource, holding a reference to it until closed
+func newSharedDataSource(ctx context.Context, database DatabaseConfig) (DataSource, error) {
+	handle, err := controller.DataSourcesFromContext(ctx).Acquire(database.DataSource, database.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	switch shared := handle.DataSource().(type) {
+	case *sharedPostgresPool:
+		return newSharedPostgresDataSource(handle, shared.pool, database.Postgres), nil
+	case *sharedRedisClient:
+		if err := validateRedisKeyspaceNotifications(database.Redis, shared.mode); err != nil {
+			handle.Release()
+			return nil, err
+		}
+		if database.Redis.Lookup == RedisLookupRange {
+			return &RedisRangeDataSource{client: shared.client, shared: handle, rangeKey: database.Redis.RangeKey, db: shared.db}, nil
+		}
+		return &RedisDataSource{client: shared.client, shared: handle, keyPrefix: database.Redis.KeyPrefix, valueType: database.Redis.ValueType, db: shared.db}, nil
+	default:
+		handle.Release()
+		return nil, fmt.Errorf("datasource '%s' is not a %s connection", database.DataSource, database.Type)
+	}
+}
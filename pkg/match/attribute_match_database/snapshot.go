@@ -0,0 +1,169 @@
+package attribute_match_database
+
+import (
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SnapshotEntry is a key, or an IP address range, read from the database by a
+// full sync
+type SnapshotEntry struct {
+	Key     string // The stored key, empty for IP address ranges
+	Start   netip.Addr
+	End     netip.Addr
+	Comment string
+}
+
+// ParseSnapshotEntry parses an IP address or CIDR as stored in the database
+func ParseSnapshotEntry(value, comment string) (SnapshotEntry, bool) {
+	value = strings.TrimSpace(value)
+	if prefix, err := netip.ParsePrefix(value); err == nil {
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefixBits(prefix)).Masked()
+		return SnapshotEntry{Start: prefix.Addr(), End: lastAddress(prefix), Comment: comment}, true
+	}
+	if addr, err := netip.ParseAddr(value); err == nil {
+		addr = addr.Unmap()
+		return SnapshotEntry{Start: addr, End: addr, Comment: comment}, true
+	}
+	return SnapshotEntry{}, false
+}
+
+// Snapshot is an immutable in-memory copy of the keys stored in the database.
+// Keys are indexed as is, except IP addresses and ranges: ranges are split into the prefixes covering them
+// and indexed by prefix length (IPv4 and IPv6 prefixes share an index but
+// never the same key), so a lookup costs one map access per distinct prefix
+// length; overlapping entries resolve to the most specific one.
+type Snapshot struct {
+	keys     map[string]struct{}
+	prefixes map[int]map[netip.Prefix]*MatchedRange // nil values for single IP addresses without comment
+	lengths  []int                                  // distinct prefix lengths, longest first
+	size     int
+	loadedAt time.Time
+	source   string // type of the data source the snapshot was read from, set by full syncs
+}
+
+// NewSnapshot indexes the entries read by a full sync
+func NewSnapshot(entries []SnapshotEntry, loadedAt time.Time) *Snapshot {
+	snapshot := &Snapshot{
+		keys:     make(map[string]struct{}),
+		prefixes: make(map[int]map[netip.Prefix]*MatchedRange),
+		loadedAt: loadedAt,
+	}
+
+	for _, entry := range entries {
+		if entry.Key != "" {
+			snapshot.keys[entry.Key] = struct{}{}
+			snapshot.size++
+			continue
+		}
+
+		start, end := entry.Start.Unmap(), entry.End.Unmap()
+		if !start.IsValid() || !end.IsValid() || start.Is4() != end.Is4() || end.Less(start) {
+			continue
+		}
+
+		var matchedRange *MatchedRange
+		if start != end || entry.Comment != "" {
+			matchedRange = &MatchedRange{Range: formatRange(start, end), Comment: entry.Comment}
+		}
+
+		for _, prefix := range rangePrefixes(start, end) {
+			byPrefix, ok := snapshot.prefixes[prefix.Bits()]
+			if !ok {
+				byPrefix = make(map[netip.Prefix]*MatchedRange)
+				snapshot.prefixes[prefix.Bits()] = byPrefix
+			}
+			byPrefix[prefix] = matchedRange
+		}
+		snapshot.size++
+	}
+
+	for bits := range snapshot.prefixes {
+		snapshot.lengths = append(snapshot.lengths, bits)
+	}
+	slices.Sort(snapshot.lengths)
+	slices.Reverse(snapshot.lengths)
+
+	return snapshot
+}
+
+// Find reports whether the key is contained in the snapshot, together with
+// the matched range when the key is an IP address within a stored range (nil
+// when the match is a single IP address)
+func (s *Snapshot) Find(key string) (bool, *MatchedRange) {
+	if s == nil {
+		return false, nil
+	}
+	if _, ok := s.keys[key]; ok {
+		return true, nil
+	}
+	if len(s.prefixes) == 0 {
+		return false, nil
+	}
+
+	addr, err := netip.ParseAddr(key)
+	if err != nil {
+		return false, nil
+	}
+	addr = addr.Unmap()
+
+	for _, bits := range s.lengths {
+		if bits > addr.BitLen() {
+			continue
+		}
+		prefix, _ := addr.Prefix(bits)
+		if matchedRange, ok := s.prefixes[bits][prefix]; ok {
+			return true, matchedRange
+		}
+	}
+	return false, nil
+}
+
+// Size returns the number of entries in the snapshot
+func (s *Snapshot) Size() int {
+	if s == nil {
+		return 0
+	}
+	return s.size
+}
+
+// LoadedAt returns when the snapshot was read from the database
+func (s *Snapshot) LoadedAt() time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	return s.loadedAt
+}
+
+// prefixBits returns the length of a prefix once its address is unmapped
+func prefixBits(prefix netip.Prefix) int {
+	if prefix.Addr().Is4In6() {
+		return max(prefix.Bits()-96, 0)
+	}
+	return prefix.Bits()
+}
+
+// rangePrefixes returns the smallest set of prefixes covering start to end
+func rangePrefixes(start, end netip.Addr) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for {
+		// Take the largest prefix starting at start that does not exceed end
+		var prefix netip.Prefix
+		for bits := 0; bits <= start.BitLen(); bits++ {
+			candidate := netip.PrefixFrom(start, bits)
+			if candidate.Masked().Addr() == start && !end.Less(lastAddress(candidate)) {
+				prefix = candidate
+				break
+			}
+		}
+		prefixes = append(prefixes, prefix)
+
+		last := lastAddress(prefix)
+		if last == end {
+			return prefixes
+		}
+		start = last.Next()
+	}
+}
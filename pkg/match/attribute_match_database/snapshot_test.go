@@ -0,0 +1,147 @@
+package attribute_match_database
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestParseSnapshotEntry(t *testing.T) {
+	tests := []struct {
+		value      string
+		start, end string
+		ok         bool
+	}{
+		{value: "203.0.113.10", start: "203.0.113.10", end: "203.0.113.10", ok: true},
+		{value: " 10.0.0.0/8 ", start: "10.0.0.0", end: "10.255.255.255", ok: true},
+		{value: "10.1.2.3/24", start: "10.1.2.0", end: "10.1.2.255", ok: true},
+		{value: "::ffff:192.0.2.1", start: "192.0.2.1", end: "192.0.2.1", ok: true},
+		{value: "::ffff:192.0.2.0/120", start: "192.0.2.0", end: "192.0.2.255", ok: true},
+		{value: "2001:db8::/32", start: "2001:db8::", end: "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff", ok: true},
+		{value: "not-an-ip"},
+		{value: ""},
+	}
+
+	for _, tt := range tests {
+		entry, ok := ParseSnapshotEntry(tt.value, "")
+		if ok != tt.ok {
+			t.Fatalf("ParseSnapshotEntry(%q) ok = %v, want %v", tt.value, ok, tt.ok)
+		}
+		if !ok {
+			continue
+		}
+		if entry.Start.String() != tt.start || entry.End.String() != tt.end {
+			t.Fatalf("ParseSnapshotEntry(%q) = %s-%s, want %s-%s", tt.value, entry.Start, entry.End, tt.start, tt.end)
+		}
+	}
+}
+
+func TestSnapshotFind(t *testing.T) {
+	entry := func(value, comment string) SnapshotEntry {
+		e, ok := ParseSnapshotEntry(value, comment)
+		if !ok {
+			t.Fatalf("invalid entry %q", value)
+		}
+		return e
+	}
+	loadedAt := time.Unix(1700000000, 0)
+	snapshot := NewSnapshot([]SnapshotEntry{
+		entry("203.0.113.10", ""),
+		entry("10.0.0.0/8", "private"),
+		entry("10.1.0.0/16", "office"),
+		entry("198.51.100.7", "scanner"),
+		entry("2001:db8::/32", "documentation"),
+		{Start: netip.MustParseAddr("192.0.2.10"), End: netip.MustParseAddr("192.0.2.20")},
+		{Start: netip.MustParseAddr("192.0.2.30"), End: netip.MustParseAddr("192.0.2.1")}, // inverted, skipped
+	}, loadedAt)
+
+	if snapshot.Size() != 6 {
+		t.Fatalf("expected 6 entries, got %d", snapshot.Size())
+	}
+	if !snapshot.LoadedAt().Equal(loadedAt) {
+		t.Fatalf("unexpected load time %v", snapshot.LoadedAt())
+	}
+
+	tests := []struct {
+		ip      string
+		matched bool
+		rng     string
+		comment string
+	}{
+		{ip: "203.0.113.10", matched: true},
+		{ip: "203.0.113.11"},
+		{ip: "10.200.0.1", matched: true, rng: "10.0.0.0/8", comment: "private"},
+		{ip: "10.1.2.3", matched: true, rng: "10.1.0.0/16", comment: "office"},
+		{ip: "198.51.100.7", matched: true, rng: "198.51.100.7/32", comment: "scanner"},
+		{ip: "::ffff:10.1.2.3", matched: true, rng: "10.1.0.0/16", comment: "office"},
+		{ip: "2001:db8::1", matched: true, rng: "2001:db8::/32", comment: "documentation"},
+		{ip: "2001:db9::1"},
+		{ip: "192.0.2.10", matched: true, rng: "192.0.2.10-192.0.2.20"},
+		{ip: "192.0.2.17", matched: true, rng: "192.0.2.10-192.0.2.20"},
+		{ip: "192.0.2.21"},
+		{ip: "192.0.2.5"},
+	}
+
+	for _, tt := range tests {
+		matched, matchedRange := snapshot.Find(tt.ip)
+		if matched != tt.matched {
+			t.Fatalf("Find(%s) matched = %v, want %v", tt.ip, matched, tt.matched)
+		}
+		if tt.rng == "" {
+			if matchedRange != nil {
+				t.Fatalf("Find(%s) returned unexpected range %+v", tt.ip, matchedRange)
+			}
+			continue
+		}
+		if matchedRange == nil || matchedRange.Range != tt.rng || matchedRange.Comment != tt.comment {
+			t.Fatalf("Find(%s) range = %+v, want %s [%s]", tt.ip, matchedRange, tt.rng, tt.comment)
+		}
+	}
+
+	var empty *Snapshot
+	if matched, _ := empty.Find("10.0.0.1"); matched || empty.Size() != 0 {
+		t.Fatal("expected nil snapshot to match nothing")
+	}
+}
+
+func TestRangePrefixes(t *testing.T) {
+	tests := []struct {
+		start, end string
+		want       []string
+	}{
+		{"10.0.0.0", "10.255.255.255", []string{"10.0.0.0/8"}},
+		{"10.0.0.1", "10.0.0.1", []string{"10.0.0.1/32"}},
+		{"10.0.0.1", "10.0.0.6", []string{"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/31", "10.0.0.6/32"}},
+		{"0.0.0.0", "255.255.255.255", []string{"0.0.0.0/0"}},
+		{"2001:db8::", "2001:db8::1ff", []string{"2001:db8::/119"}},
+	}
+
+	for _, tt := range tests {
+		prefixes := rangePrefixes(netip.MustParseAddr(tt.start), netip.MustParseAddr(tt.end))
+		got := make([]string, len(prefixes))
+		for i, prefix := range prefixes {
+			got[i] = prefix.String()
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("rangePrefixes(%s, %s) = %v, want %v", tt.start, tt.end, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Fatalf("rangePrefixes(%s, %s) = %v, want %v", tt.start, tt.end, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestSnapshotFindKeys(t *testing.T) {
+	snapshot := NewSnapshot([]SnapshotEntry{{Key: "k-123"}, {Key: "64500"}}, time.Now())
+
+	if snapshot.Size() != 2 {
+		t.Fatalf("expected 2 entries, got %d", snapshot.Size())
+	}
+	for key, want := range map[string]bool{"k-123": true, "64500": true, "k-124": false, "10.0.0.1": false} {
+		if matched, matchedRange := snapshot.Find(key); matched != want || matchedRange != nil {
+			t.Fatalf("Find(%s) = %v, %+v, want %v", key, matched, matchedRange, want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package auto_ban
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/analysis/maxmind_asn"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+const (
+	ControllerKind = "auto-ban"
+)
+
+// init registers the auto-ban match controller so it can be constructed
+// from configuration at runtime.
+func init() {
+	controller.RegisterMatchControllerFactory(ControllerKind, newAutoBanController)
+}
+
+type autoBanController struct {
+	name             string
+	key              KeyConfig
+	denials          bool
+	trapPaths        []string
+	store            Store
+	matchesOnFailure bool
+	logger           *zap.Logger
+}
+
+// Match implements controller.MatchController. The request matches when its
+// key is banned. Requests to trap paths count as offenses.
+func (c *autoBanController) Match(ctx context.Context, req *runtime.RequestContext, reports controller.AnalysisReports) (*controller.MatchVerdict, error) {
+	key, ok := c.extractKey(req, reports)
+	if !ok {
+		return c.createVerdict(false, c.missingDescription()), nil
+	}
+
+	var remaining time.Duration
+	var err error
+	trapPath, trapped := c.trapPath(req)
+	if trapped {
+		var banned bool
+		remaining, banned, err = c.store.Offend(ctx, key)
+		if banned {
+			c.logger.Info("key banned", zap.String("key", key), zap.String("trap_path", trapPath), zap.Duration("duration", remaining))
+		}
+	} else {
+		remaining, err = c.store.Banned(ctx, key)
+	}
+	if err != nil {
+		c.logger.Warn("auto-ban store failed", zap.String("key", key), zap.Error(err))
+		return c.createVerdict(c.matchesOnFailure, fmt.Sprintf("auto-ban store unavailable: %v", err)), nil
+	}
+
+	if remaining <= 0 {
+		if trapped {
+			return c.createVerdict(false, fmt.Sprintf("%s hit trap path %s", c.describe(key), trapPath)), nil
+		}
+		return c.createVerdict(false, fmt.Sprintf("%s not banned", c.describe(key))), nil
+	}
+
+	verdict := c.createVerdict(true, fmt.Sprintf("%s banned for %s", c.describe(key), remaining.Round(time.Second)))
+	verdict.DenyDownstreamHeaders = map[string]string{
+		"Retry-After": strconv.Itoa(max(int(math.Ceil(remaining.Seconds())), 1)),
+	}
+	return verdict, nil
+}
+
+// ObserveDecision implements controller.DecisionObserver, counting the
+// requests denied by the policy as offenses of their key. Denials caused by
+// the controller itself, challenges and requests to trap paths, already
+// counted, are not.
+func (c *autoBanController) ObserveDecision(ctx context.Context, req *runtime.RequestContext, reports controller.AnalysisReports, decision controller.Decision) {
+	if !c.denials || decision.Allowed || decision.Culprit == nil || decision.Culprit.Controller == c.name || decision.Culprit.DenyChallenge {
+		return
+	}
+	if _, trapped := c.trapPath(req); trapped {
+		return
+	}
+	key, ok := c.extractKey(req, reports)
+	if !ok {
+		return
+	}
+
+	remaining, banned, err := c.store.Offend(ctx, key)
+	if err != nil {
+		c.logger.Warn("auto-ban store failed", zap.String("key", key), zap.Error(err))
+		return
+	}
+	if banned {
+		c.logger.Info("key banned", zap.String("key", key), zap.String("denied_by", decision.Culprit.Controller), zap.Duration("duration", remaining))
+	}
+}
+
+// Name implements controller.MatchController.
+func (c *autoBanController) Name() string {
+	return c.name
+}
+
+// Kind implements controller.MatchController.
+func (c *autoBanController) Kind() string {
+	return ControllerKind
+}
+
+// HealthCheck implements controller.MatchController.
+func (c *autoBanController) HealthCheck(ctx context.Context) error {
+	return c.store.HealthCheck(ctx)
+}
+
+// createVerdict builds a verdict of the controller
+func (c *autoBanController) createVerdict(isMatch bool, description string) *controller.MatchVerdict {
+	return &controller.MatchVerdict{
+		Controller:     c.name,
+		ControllerType: ControllerKind,
+		DenyCode:       codes.PermissionDenied,
+		DenyMessage:    "client banned",
+		Description:    description,
+		IsMatch:        isMatch,
+	}
+}
+
+// trapPath returns the trap path hit by the request. Trap paths match the
+// normalized path equal to them or, when ending with '/', every path under
+// them.
+func (c *autoBanController) trapPath(req *runtime.RequestContext) (string, bool) {
+	path := req.Path()
+	for _, trapPath := range c.trapPaths {
+		if path == trapPath || (strings.HasSuffix(trapPath, "/") && strings.HasPrefix(path, trapPath)) {
+			return trapPath, true
+		}
+	}
+	return "", false
+}
+
+// extractKey returns the key bans apply to, or false when the request does
+// not carry one
+func (c *autoBanController) extractKey(req *runtime.RequestContext, reports controller.AnalysisReports) (string, bool) {
+	switch c.key.Source {
+	case KeySourceIP:
+		if !req.IpAddress.IsValid() {
+			return "", false
+		}
+		address := req.IpAddress.Unmap()
+		if address.Is6() && c.key.IPv6PrefixLength < 128 {
+			return netip.PrefixFrom(address, c.key.IPv6PrefixLength).Masked().String(), true
+		}
+		return address.String(), true
+	case KeySourceASN:
+		for _, report := range reports {
+			if report == nil || report.ControllerKind != maxmind_asn.ControllerKind {
+				continue
+			}
+			if lookupResult := maxmind_asn.GetIpLookupResultFromReport(report); lookupResult != nil {
+				return strconv.FormatUint(uint64(lookupResult.AutonomousSystemNumber), 10), true
+			}
+		}
+	}
+	return "", false
+}
+
+// describe renders a key for verdict descriptions
+func (c *autoBanController) describe(key string) string {
+	if c.key.Source == KeySourceASN {
+		return "ASN " + key
+	}
+	if strings.Contains(key, "/") {
+		return "network " + key
+	}
+	return "IP " + key
+}
+
+// missingDescription explains why a request carries no key
+func (c *autoBanController) missingDescription() string {
+	if c.key.Source == KeySourceASN {
+		return "no ASN information available"
+	}
+	return "unable to determine source IP address"
+}
+
+// decodeConfig decodes, completes and validates the settings of the controller configured by cfg
+func decodeConfig(cfg config.ControllerConfig) (AutoBanConfig, error) {
+	var autoBanConfig AutoBanConfig
+	if err := controller.DecodeControllerSettings(cfg.Settings, &autoBanConfig); err != nil {
+		return autoBanConfig, err
+	}
+	autoBanConfig.ApplyDefaults(cfg.Name)
+	if err := autoBanConfig.Validate(); err != nil {
+		return autoBanConfig, fmt.Errorf("configuration validation failed: %w", err)
+	}
+	return autoBanConfig, nil
+}
+
+// OpenBans opens the Redis store of the auto-ban controller configured by
+// cfg, to manage its bans out of the service. ctx must carry the shared
+// datasources; bans of memory stores only live in the service process and
+// are managed with NewBansHandler.
+func OpenBans(ctx context.Context, cfg config.ControllerConfig) (Store, error) {
+	if cfg.Type != ControllerKind {
+		return nil, fmt.Errorf("controller '%s' is of type '%s', not '%s'", cfg.Name, cfg.Type, ControllerKind)
+	}
+	autoBanConfig, err := decodeConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if autoBanConfig.Store.Type != StoreRedis {
+		return nil, fmt.Errorf("controller '%s' keeps its bans in the memory of the service, manage them with the bans endpoints of the admin server (admin.address)", cfg.Name)
+	}
+	return NewStore(ctx, autoBanConfig)
+}
+
+// newAutoBanController constructs an auto-ban controller from configuration
+func newAutoBanController(ctx context.Context, logger *zap.Logger, cfg config.ControllerConfig) (controller.MatchController, error) {
+	autoBanConfig, err := decodeConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := NewStore(ctx, autoBanConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("controller initialized",
+		zap.String("key_source", autoBanConfig.Key.Source),
+		zap.Bool("denials", autoBanConfig.Offenses.Denials),
+		zap.Strings("trapPaths", autoBanConfig.Offenses.TrapPaths),
+		zap.Int("threshold", autoBanConfig.Threshold),
+		zap.Duration("window", autoBanConfig.GetWindow()),
+		zap.Duration("banDuration", autoBanConfig.GetBanDuration()),
+		zap.String("store", autoBanConfig.Store.Type),
+		zap.Bool("matchesOnFailure", autoBanConfig.MatchesOnFailure),
+	)
+
+	trapPaths := make([]string, 0, len(autoBanConfig.Offenses.TrapPaths))
+	for _, trapPath := range autoBanConfig.Offenses.TrapPaths {
+		trapPaths = append(trapPaths, runtime.NormalizePath(trapPath))
+	}
+
+	return &autoBanController{
+		name:             cfg.Name,
+		key:              autoBanConfig.Key,
+		denials:          autoBanConfig.Offenses.Denials,
+		trapPaths:        trapPaths,
+		store:            store,
+		matchesOnFailure: autoBanConfig.MatchesOnFailure,
+		logger:           logger,
+	}, nil
+}
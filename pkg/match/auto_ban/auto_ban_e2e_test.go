@@ -0,0 +1,108 @@
+//go:build e2e
+
+package auto_ban
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	tcredis "github.com/testcontainers/testcontainers-go/modules/redis"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	_ "github.com/gtriggiano/envoy-authorization-service/pkg/match/attribute_match_database"
+)
+
+func TestRedisStore(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	container, err := tcredis.Run(ctx, "redis:7-alpine")
+	if err != nil {
+		t.Fatalf("failed to start redis: %v", err)
+	}
+	defer func() { _ = container.Terminate(context.Background()) }()
+
+	endpoint, err := container.Endpoint(ctx, "")
+	if err != nil {
+		t.Fatalf("failed to get redis endpoint: %v", err)
+	}
+	host, portStr, _ := net.SplitHostPort(endpoint)
+	port, _ := strconv.Atoi(portStr)
+
+	logger := zaptest.NewLogger(t)
+	dataSources, err := controller.BuildDataSources(ctx, logger, []config.DataSourceConfig{{
+		Name:     "cache",
+		Type:     "redis",
+		Settings: map[string]any{"host": host, "port": port},
+	}})
+	if err != nil {
+		t.Fatalf("failed to build datasources: %v", err)
+	}
+	defer func() { _ = dataSources.Close() }()
+	controllersCtx := controller.WithDataSources(ctx, dataSources)
+
+	controllerConfig := config.ControllerConfig{
+		Name: "repeat-offenders",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"key":         map[string]any{"source": "ip"},
+			"offenses":    map[string]any{"trapPaths": []string{"/.env"}},
+			"threshold":   2,
+			"window":      "10m",
+			"banDuration": "1h",
+			"store":       map[string]any{"type": "redis", "datasource": "cache"},
+		},
+	}
+
+	// Two instances of the service share the bans
+	var instances []controller.MatchController
+	for range 2 {
+		ctrl, err := newAutoBanController(controllersCtx, logger, controllerConfig)
+		if err != nil {
+			t.Fatalf("failed to build controller: %v", err)
+		}
+		if err := ctrl.HealthCheck(ctx); err != nil {
+			t.Fatalf("unexpected health check error: %v", err)
+		}
+		instances = append(instances, ctrl)
+	}
+
+	if verdict := match(t, instances[0], checkRequest("192.0.2.1", "/.env"), nil); verdict.IsMatch {
+		t.Fatalf("expected the first offense not to ban, got: %s", verdict.Description)
+	}
+	if verdict := match(t, instances[1], checkRequest("192.0.2.1", "/.env"), nil); !verdict.IsMatch {
+		t.Fatalf("expected the offenses to be shared by the instances, got: %s", verdict.Description)
+	}
+	if verdict := match(t, instances[0], checkRequest("192.0.2.1", "/"), nil); !verdict.IsMatch || verdict.DenyDownstreamHeaders["Retry-After"] == "" {
+		t.Fatalf("expected the ban to be shared by the instances, got: %s", verdict.Description)
+	}
+
+	// Bans are managed out of the service
+	bans, err := OpenBans(controllersCtx, controllerConfig)
+	if err != nil {
+		t.Fatalf("failed to open bans: %v", err)
+	}
+	list, err := bans.List(ctx)
+	if err != nil || len(list) != 1 || list[0].Key != "192.0.2.1" {
+		t.Fatalf("unexpected bans %+v: %v", list, err)
+	}
+	if remaining := time.Until(list[0].Until); remaining <= 59*time.Minute || remaining > time.Hour {
+		t.Fatalf("expected the ban to be listed with the time left on the Redis clock, got %s", remaining)
+	}
+	if lifted, err := bans.Lift(ctx, "192.0.2.1"); err != nil || !lifted {
+		t.Fatalf("expected the ban to be lifted: %v", err)
+	}
+	if verdict := match(t, instances[1], checkRequest("192.0.2.1", "/"), nil); verdict.IsMatch {
+		t.Fatalf("expected the lifted ban not to match, got: %s", verdict.Description)
+	}
+	if list, err := bans.List(ctx); err != nil || len(list) != 0 {
+		t.Fatalf("expected no bans left, got %+v: %v", list, err)
+	}
+}
@@ -0,0 +1,282 @@
+package auto_ban
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/analysis/maxmind_asn"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/match/challenge_pass"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+type failingStore struct{}
+
+func (failingStore) Offend(ctx context.Context, key string) (time.Duration, bool, error) {
+	return 0, false, errors.New("connection refused")
+}
+
+func (failingStore) Banned(ctx context.Context, key string) (time.Duration, error) {
+	return 0, errors.New("connection refused")
+}
+
+func (failingStore) List(ctx context.Context) ([]Ban, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (failingStore) Lift(ctx context.Context, key string) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func (failingStore) HealthCheck(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestMatch_TrapPaths(t *testing.T) {
+	ctrl := buildController(t, map[string]any{
+		"key":         map[string]any{"source": "ip"},
+		"offenses":    map[string]any{"trapPaths": []string{"/.env", "/wp-admin/"}},
+		"threshold":   2,
+		"window":      "10m",
+		"banDuration": "1h",
+	})
+
+	if verdict := match(t, ctrl, checkRequest("192.0.2.1", "/wp-admin"), nil); verdict.IsMatch || verdict.Description != "IP 192.0.2.1 not banned" {
+		t.Fatalf("expected paths outside the trap not to count, got: %s", verdict.Description)
+	}
+	if verdict := match(t, ctrl, checkRequest("192.0.2.1", "/.env?debug=1"), nil); verdict.IsMatch || verdict.Description != "IP 192.0.2.1 hit trap path /.env" {
+		t.Fatalf("expected the first trap hit not to match, got: %s", verdict.Description)
+	}
+
+	verdict := match(t, ctrl, checkRequest("192.0.2.1", "/wp-admin/install.php"), nil)
+	if !verdict.IsMatch || verdict.Description != "IP 192.0.2.1 banned for 1h0m0s" {
+		t.Fatalf("expected the threshold to ban the client, got: %s", verdict.Description)
+	}
+	if verdict.DenyCode != codes.PermissionDenied || verdict.DenyDownstreamHeaders["Retry-After"] != "3600" {
+		t.Fatalf("unexpected deny code %v and headers %v", verdict.DenyCode, verdict.DenyDownstreamHeaders)
+	}
+
+	if verdict := match(t, ctrl, checkRequest("192.0.2.1", "/"), nil); !verdict.IsMatch {
+		t.Fatalf("expected every request of a banned client to match, got: %s", verdict.Description)
+	}
+	if verdict := match(t, ctrl, checkRequest("192.0.2.2", "/"), nil); verdict.IsMatch {
+		t.Fatalf("expected other clients not to be banned, got: %s", verdict.Description)
+	}
+}
+
+func TestMatch_TrapPathsNormalized(t *testing.T) {
+	ctrl := buildController(t, map[string]any{
+		"key":         map[string]any{"source": "ip"},
+		"offenses":    map[string]any{"trapPaths": []string{"/.env", "/wp-admin/"}},
+		"threshold":   10,
+		"window":      "10m",
+		"banDuration": "1h",
+	})
+
+	tests := []struct {
+		path     string
+		trapPath string
+	}{
+		{path: "//.env", trapPath: "/.env"},
+		{path: "/a/../.env", trapPath: "/.env"},
+		{path: "/%2e%65nv", trapPath: "/.env"},
+		{path: "/./wp-admin//install.php", trapPath: "/wp-admin/"},
+		{path: "/static/../wp-admin/", trapPath: "/wp-admin/"},
+	}
+	for _, tt := range tests {
+		want := "IP 192.0.2.1 hit trap path " + tt.trapPath
+		if verdict := match(t, ctrl, checkRequest("192.0.2.1", tt.path), nil); verdict.IsMatch || verdict.Description != want {
+			t.Errorf("%s: expected %q, got: %s", tt.path, want, verdict.Description)
+		}
+	}
+}
+
+func TestObserveDecision_Denials(t *testing.T) {
+	ctrl := buildController(t, map[string]any{
+		"key":         map[string]any{"source": "ip", "ipv6PrefixLength": 64},
+		"offenses":    map[string]any{"denials": true, "trapPaths": []string{"/.env"}},
+		"threshold":   2,
+		"window":      "10m",
+		"banDuration": "1h",
+	})
+	observer := ctrl.(controller.DecisionObserver)
+	denied := controller.Decision{Culprit: &controller.MatchVerdict{Controller: "blocked-countries"}}
+
+	observe := func(ip, path string, decision controller.Decision) {
+		observer.ObserveDecision(context.Background(), runtime.NewRequestContext(checkRequest(ip, path)), nil, decision)
+	}
+
+	observe("2001:db8::1", "/", controller.Decision{Allowed: true})
+	observe("2001:db8::1", "/.env", denied)
+	observe("2001:db8::1", "/", controller.Decision{Culprit: &controller.MatchVerdict{Controller: "repeat-offenders"}})
+	observe("2001:db8::1", "/", denied)
+	if verdict := match(t, ctrl, checkRequest("2001:db8::2", "/"), nil); verdict.IsMatch {
+		t.Fatalf("expected allowed requests, trap hits and own denials not to count as denials, got: %s", verdict.Description)
+	}
+
+	observe("2001:db8::3", "/", denied)
+	verdict := match(t, ctrl, checkRequest("2001:db8::4", "/"), nil)
+	if !verdict.IsMatch || !strings.HasPrefix(verdict.Description, "network 2001:db8::/64 banned for") {
+		t.Fatalf("expected the denied network to be banned, got: %s", verdict.Description)
+	}
+}
+
+func TestObserveDecision_Challenges(t *testing.T) {
+	t.Setenv("CHALLENGE_SECRET", strings.Repeat("s", 32))
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	controllers, err := controller.BuildMatchControllers(ctx, zap.NewNop(), []config.ControllerConfig{
+		{Name: "challenge", Type: challenge_pass.ControllerKind, Settings: map[string]any{"secrets": []map[string]any{{"env": "CHALLENGE_SECRET"}}}},
+		{Name: "repeat-offenders", Type: ControllerKind, Settings: map[string]any{
+			"key":         map[string]any{"source": "ip"},
+			"offenses":    map[string]any{"denials": true},
+			"threshold":   2,
+			"window":      "10m",
+			"banDuration": "1h",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to build controllers: %v", err)
+	}
+	challenge, autoBan := controllers[0], controllers[1]
+
+	// Policy "!suspicious || challenge": every denial is caused by the challenge
+	pass := func(method, path string) *controller.MatchVerdict {
+		req := checkRequest("192.0.2.1", path)
+		req.Attributes.Request.Http.Method = method
+		verdict := match(t, challenge, req, nil)
+		if !verdict.IsMatch {
+			autoBan.(controller.DecisionObserver).ObserveDecision(context.Background(), runtime.NewRequestContext(req), nil, controller.Decision{Culprit: verdict})
+		}
+		return verdict
+	}
+
+	// Browsers passing challenges are never banned
+	for range 3 {
+		location := pass("GET", "/account").DenyDownstreamHeaders["Location"]
+		if verdict := pass("GET", location); !strings.Contains(verdict.DenyDownstreamHeaders["Set-Cookie"], "envoy_authz_challenge=") {
+			t.Fatalf("expected the challenge endpoint to issue a cookie, got: %s", verdict.Description)
+		}
+	}
+	if verdict := match(t, autoBan, checkRequest("192.0.2.1", "/"), nil); verdict.IsMatch {
+		t.Fatalf("expected challenges not to count as offenses, got: %s", verdict.Description)
+	}
+
+	// Requests that cannot be challenged are offenses
+	pass("POST", "/api/orders")
+	pass("GET", "/.well-known/envoy-authz/challenge?return=%2F&token=forged")
+	if verdict := match(t, autoBan, checkRequest("192.0.2.1", "/"), nil); !verdict.IsMatch {
+		t.Fatalf("expected rejected requests to count as offenses, got: %s", verdict.Description)
+	}
+}
+
+func TestMatch_ASNKey(t *testing.T) {
+	ctrl := buildController(t, map[string]any{
+		"key":         map[string]any{"source": "asn"},
+		"offenses":    map[string]any{"trapPaths": []string{"/.env"}},
+		"threshold":   1,
+		"window":      "10m",
+		"banDuration": "1h",
+	})
+	reports := controller.AnalysisReports{
+		"asn": {
+			ControllerKind: maxmind_asn.ControllerKind,
+			Data:           map[string]any{"result": &maxmind_asn.IpLookupResult{AutonomousSystemNumber: 64500}},
+		},
+	}
+
+	if verdict := match(t, ctrl, checkRequest("192.0.2.1", "/.env"), nil); verdict.IsMatch || verdict.Description != "no ASN information available" {
+		t.Fatalf("expected requests without ASN not to match, got: %s", verdict.Description)
+	}
+	if verdict := match(t, ctrl, checkRequest("192.0.2.1", "/.env"), reports); !verdict.IsMatch || !strings.HasPrefix(verdict.Description, "ASN 64500 banned") {
+		t.Fatalf("expected the ASN to be banned, got: %s", verdict.Description)
+	}
+}
+
+func TestMatch_StoreFailure(t *testing.T) {
+	for _, matchesOnFailure := range []bool{false, true} {
+		ctrl := &autoBanController{
+			name:             "repeat-offenders",
+			key:              KeyConfig{Source: KeySourceIP, IPv6PrefixLength: 128},
+			store:            failingStore{},
+			matchesOnFailure: matchesOnFailure,
+			logger:           zap.NewNop(),
+		}
+
+		verdict := match(t, ctrl, checkRequest("192.0.2.1", "/"), nil)
+		if verdict.IsMatch != matchesOnFailure || !strings.Contains(verdict.Description, "auto-ban store unavailable") {
+			t.Fatalf("expected IsMatch=%v on store failure, got %v: %s", matchesOnFailure, verdict.IsMatch, verdict.Description)
+		}
+	}
+}
+
+func TestOpenBans(t *testing.T) {
+	settings := map[string]any{
+		"key":         map[string]any{"source": "ip"},
+		"offenses":    map[string]any{"denials": true},
+		"threshold":   5,
+		"window":      "10m",
+		"banDuration": "1h",
+	}
+
+	_, err := OpenBans(context.Background(), config.ControllerConfig{Name: "repeat-offenders", Type: ControllerKind, Settings: settings})
+	if err == nil || !strings.Contains(err.Error(), "keeps its bans in the memory of the service") {
+		t.Fatalf("expected memory stores to be refused, got %v", err)
+	}
+
+	_, err = OpenBans(context.Background(), config.ControllerConfig{Name: "throttle", Type: "rate-limit", Settings: settings})
+	if err == nil || !strings.Contains(err.Error(), "is of type 'rate-limit'") {
+		t.Fatalf("expected other controller types to be refused, got %v", err)
+	}
+}
+
+func buildController(t *testing.T, settings map[string]any) controller.MatchController {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	ctrl, err := newAutoBanController(ctx, zap.NewNop(), config.ControllerConfig{
+		Name:     "repeat-offenders",
+		Type:     ControllerKind,
+		Settings: settings,
+	})
+	if err != nil {
+		t.Fatalf("failed to build controller: %v", err)
+	}
+	return ctrl
+}
+
+func match(t *testing.T, ctrl controller.MatchController, req *authv3.CheckRequest, reports controller.AnalysisReports) *controller.MatchVerdict {
+	t.Helper()
+	verdict, err := ctrl.Match(context.Background(), runtime.NewRequestContext(req), reports)
+	if err != nil {
+		t.Fatalf("match returned error: %v", err)
+	}
+	return verdict
+}
+
+func checkRequest(ip, path string) *authv3.CheckRequest {
+	return &authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{
+			Source: &authv3.AttributeContext_Peer{
+				Address: &corev3.Address{
+					Address: &corev3.Address_SocketAddress{
+						SocketAddress: &corev3.SocketAddress{Address: ip},
+					},
+				},
+			},
+			Request: &authv3.AttributeContext_Request{
+				Http: &authv3.AttributeContext_HttpRequest{Path: path},
+			},
+		},
+	}
+}
@@ -0,0 +1,84 @@
+package auto_ban
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+)
+
+// banResponse is a ban as listed by the bans endpoints
+type banResponse struct {
+	Key   string    `json:"key"`
+	Until time.Time `json:"until"`
+}
+
+// liftResponse is the outcome of lifting a ban
+type liftResponse struct {
+	Key    string `json:"key"`
+	Lifted bool   `json:"lifted"`
+}
+
+// NewBansHandler returns the HTTP endpoints managing the bans of the auto-ban
+// controllers among controllers, under path:
+//   - GET <path>/<controller> lists the active bans of the controller
+//   - DELETE <path>/<controller>/<key> lifts the ban of key, clearing its offenses
+//
+// The endpoints reach the bans of memory stores, which live in the service
+// process and cannot be managed with the bans command.
+func NewBansHandler(path string, controllers []controller.MatchController) http.Handler {
+	stores := make(map[string]Store)
+	for _, matchController := range controllers {
+		if autoBan, ok := matchController.(*autoBanController); ok {
+			stores[autoBan.name] = autoBan.store
+		}
+	}
+
+	storeOf := func(w http.ResponseWriter, r *http.Request) (Store, bool) {
+		store, ok := stores[r.PathValue("controller")]
+		if !ok {
+			http.Error(w, fmt.Sprintf("%s controller '%s' not found", ControllerKind, r.PathValue("controller")), http.StatusNotFound)
+		}
+		return store, ok
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+path+"/{controller}", func(w http.ResponseWriter, r *http.Request) {
+		store, ok := storeOf(w, r)
+		if !ok {
+			return
+		}
+		bans, err := store.List(r.Context())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("could not list bans: %v", err), http.StatusBadGateway)
+			return
+		}
+		response := make([]banResponse, 0, len(bans))
+		for _, ban := range bans {
+			response = append(response, banResponse{Key: ban.Key, Until: ban.Until.UTC()})
+		}
+		writeJSON(w, response)
+	})
+	mux.HandleFunc("DELETE "+path+"/{controller}/{key...}", func(w http.ResponseWriter, r *http.Request) {
+		store, ok := storeOf(w, r)
+		if !ok {
+			return
+		}
+		key := r.PathValue("key")
+		lifted, err := store.Lift(r.Context(), key)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("could not lift ban: %v", err), http.StatusBadGateway)
+			return
+		}
+		writeJSON(w, liftResponse{Key: key, Lifted: lifted})
+	})
+	return mux
+}
+
+// writeJSON writes value as the JSON body of the response
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
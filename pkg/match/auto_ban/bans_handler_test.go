@@ -0,0 +1,67 @@
+package auto_ban
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+)
+
+func TestBansHandler(t *testing.T) {
+	ctrl := buildController(t, map[string]any{
+		"key":         map[string]any{"source": "ip", "ipv6PrefixLength": 64},
+		"offenses":    map[string]any{"trapPaths": []string{"/.env"}},
+		"threshold":   1,
+		"window":      "10m",
+		"banDuration": "1h",
+	})
+	match(t, ctrl, checkRequest("192.0.2.1", "/.env"), nil)
+	match(t, ctrl, checkRequest("2001:db8::1", "/.env"), nil)
+
+	handler := NewBansHandler("/bans", []controller.MatchController{ctrl})
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+		return recorder
+	}
+
+	response := serve(http.MethodGet, "/bans/repeat-offenders")
+	var bans []banResponse
+	if err := json.Unmarshal(response.Body.Bytes(), &bans); err != nil || response.Code != http.StatusOK {
+		t.Fatalf("unexpected list response %d %q: %v", response.Code, response.Body.String(), err)
+	}
+	if len(bans) != 2 || bans[0].Key != "192.0.2.1" || bans[1].Key != "2001:db8::/64" {
+		t.Fatalf("unexpected bans %+v", bans)
+	}
+
+	tests := []struct {
+		target string
+		want   liftResponse
+	}{
+		{target: "/bans/repeat-offenders/2001:db8::/64", want: liftResponse{Key: "2001:db8::/64", Lifted: true}},
+		{target: "/bans/repeat-offenders/192.0.2.2", want: liftResponse{Key: "192.0.2.2", Lifted: false}},
+	}
+	for _, tt := range tests {
+		response := serve(http.MethodDelete, tt.target)
+		var lifted liftResponse
+		if err := json.Unmarshal(response.Body.Bytes(), &lifted); err != nil || lifted != tt.want {
+			t.Fatalf("DELETE %s: expected %+v, got %d %q", tt.target, tt.want, response.Code, response.Body.String())
+		}
+	}
+
+	if verdict := match(t, ctrl, checkRequest("2001:db8::2", "/"), nil); verdict.IsMatch {
+		t.Fatalf("expected the lifted network not to be banned, got: %s", verdict.Description)
+	}
+	if verdict := match(t, ctrl, checkRequest("192.0.2.1", "/"), nil); !verdict.IsMatch {
+		t.Fatalf("expected the other ban to stay, got: %s", verdict.Description)
+	}
+
+	if response := serve(http.MethodGet, "/bans/missing"); response.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown controllers not to be found, got %d", response.Code)
+	}
+	if response := serve(http.MethodPost, "/bans/repeat-offenders"); response.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected other methods to be refused, got %d", response.Code)
+	}
+}
@@ -0,0 +1,171 @@
+package auto_ban
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// KeySourceIP bans downstream client IP addresses
+	KeySourceIP = "ip"
+	// KeySourceASN bans the ASNs reported by a maxmind-asn analysis controller
+	KeySourceASN = "asn"
+)
+
+const (
+	// StoreMemory keeps offenses and bans in process
+	StoreMemory = "memory"
+	// StoreRedis keeps offenses and bans in a shared Redis datasource, sharing
+	// the bans across every instance of the service
+	StoreRedis = "redis"
+)
+
+const (
+	defaultStoreTimeout = 100 * time.Millisecond
+)
+
+// AutoBanConfig represents the configuration of an auto-ban controller
+type AutoBanConfig struct {
+	Key              KeyConfig      `yaml:"key"`
+	Offenses         OffensesConfig `yaml:"offenses"`
+	Threshold        int            `yaml:"threshold"`
+	Window           string         `yaml:"window"`
+	BanDuration      string         `yaml:"banDuration"`
+	Store            StoreConfig    `yaml:"store"`
+	MatchesOnFailure bool           `yaml:"matchesOnFailure"`
+}
+
+// KeyConfig represents what is banned
+type KeyConfig struct {
+	Source           string `yaml:"source"`
+	IPv6PrefixLength int    `yaml:"ipv6PrefixLength"`
+}
+
+// OffensesConfig represents what counts as an offense
+type OffensesConfig struct {
+	Denials   bool     `yaml:"denials"`
+	TrapPaths []string `yaml:"trapPaths"`
+}
+
+// StoreConfig represents where offenses and bans are kept
+type StoreConfig struct {
+	Type       string `yaml:"type"`
+	DataSource string `yaml:"datasource"`
+	KeyPrefix  string `yaml:"keyPrefix"`
+	Timeout    string `yaml:"timeout"`
+}
+
+// ApplyDefaults sets default values for the configuration of the controller named name
+func (c *AutoBanConfig) ApplyDefaults(name string) {
+	if c.Key.Source == KeySourceIP && c.Key.IPv6PrefixLength == 0 {
+		c.Key.IPv6PrefixLength = 128
+	}
+	if c.Store.Type == "" {
+		c.Store.Type = StoreMemory
+	}
+	if c.Store.Type == StoreRedis {
+		if c.Store.KeyPrefix == "" {
+			c.Store.KeyPrefix = "autoban:" + name + ":"
+		}
+		if c.Store.Timeout == "" {
+			c.Store.Timeout = defaultStoreTimeout.String()
+		}
+	}
+}
+
+// Validate checks the configuration for completeness
+func (c *AutoBanConfig) Validate() error {
+	switch c.Key.Source {
+	case KeySourceIP:
+		if c.Key.IPv6PrefixLength < 1 || c.Key.IPv6PrefixLength > 128 {
+			return fmt.Errorf("key.ipv6PrefixLength must be between 1 and 128, got %d", c.Key.IPv6PrefixLength)
+		}
+	case KeySourceASN:
+		if c.Key.IPv6PrefixLength != 0 {
+			return fmt.Errorf("key.ipv6PrefixLength is not supported when key.source is '%s'", c.Key.Source)
+		}
+	case "":
+		return fmt.Errorf("key.source is required")
+	default:
+		return fmt.Errorf("key.source must be one of '%s' or '%s', got '%s'", KeySourceIP, KeySourceASN, c.Key.Source)
+	}
+
+	if !c.Offenses.Denials && len(c.Offenses.TrapPaths) == 0 {
+		return fmt.Errorf("offenses requires denials or at least one trap path")
+	}
+	for _, path := range c.Offenses.TrapPaths {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("offenses.trapPaths: path '%s' must start with '/'", path)
+		}
+	}
+
+	if c.Threshold <= 0 {
+		return fmt.Errorf("threshold must be greater than 0")
+	}
+	if err := validateDuration("window", c.Window); err != nil {
+		return err
+	}
+	if err := validateDuration("banDuration", c.BanDuration); err != nil {
+		return err
+	}
+
+	return c.Store.validate()
+}
+
+// GetWindow returns the parsed window offenses are counted in
+func (c *AutoBanConfig) GetWindow() time.Duration {
+	window, _ := time.ParseDuration(c.Window)
+	return window
+}
+
+// GetBanDuration returns the parsed duration of the bans
+func (c *AutoBanConfig) GetBanDuration() time.Duration {
+	banDuration, _ := time.ParseDuration(c.BanDuration)
+	return banDuration
+}
+
+// validateDuration checks a required duration setting of at least one second
+func validateDuration(name, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s is required", name)
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if duration < time.Second {
+		return fmt.Errorf("%s must be at least 1s", name)
+	}
+	return nil
+}
+
+// validate checks the store settings
+func (s *StoreConfig) validate() error {
+	switch s.Type {
+	case StoreMemory:
+		if s.DataSource != "" || s.KeyPrefix != "" || s.Timeout != "" {
+			return fmt.Errorf("store.datasource, store.keyPrefix and store.timeout are only supported when store.type is '%s'", StoreRedis)
+		}
+	case StoreRedis:
+		if s.DataSource == "" {
+			return fmt.Errorf("store.datasource is required when store.type is '%s'", StoreRedis)
+		}
+		timeout, err := time.ParseDuration(s.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid store.timeout: %w", err)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("store.timeout must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("store.type must be one of '%s' or '%s', got '%s'", StoreMemory, StoreRedis, s.Type)
+	}
+	return nil
+}
+
+// GetTimeout returns the parsed timeout of the store commands
+func (s *StoreConfig) GetTimeout() time.Duration {
+	timeout, _ := time.ParseDuration(s.Timeout)
+	return timeout
+}
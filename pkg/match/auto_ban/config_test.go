@@ -0,0 +1,116 @@
+package auto_ban
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAutoBanConfigValidate(t *testing.T) {
+	denials := OffensesConfig{Denials: true}
+
+	tests := []struct {
+		name    string
+		config  AutoBanConfig
+		wantErr string
+	}{
+		{
+			name:   "ip key with denials",
+			config: AutoBanConfig{Key: KeyConfig{Source: KeySourceIP}, Offenses: denials, Threshold: 5, Window: "10m", BanDuration: "1h"},
+		},
+		{
+			name:   "asn key with trap paths and redis store",
+			config: AutoBanConfig{Key: KeyConfig{Source: KeySourceASN}, Offenses: OffensesConfig{TrapPaths: []string{"/.env", "/wp-admin/"}}, Threshold: 1, Window: "1m", BanDuration: "24h", Store: StoreConfig{Type: StoreRedis, DataSource: "cache"}},
+		},
+		{
+			name:    "missing key source",
+			config:  AutoBanConfig{Offenses: denials, Threshold: 5, Window: "10m", BanDuration: "1h"},
+			wantErr: "key.source is required",
+		},
+		{
+			name:    "unknown key source",
+			config:  AutoBanConfig{Key: KeyConfig{Source: "header"}, Offenses: denials, Threshold: 5, Window: "10m", BanDuration: "1h"},
+			wantErr: "key.source must be one of",
+		},
+		{
+			name:    "ipv6 prefix length with asn key",
+			config:  AutoBanConfig{Key: KeyConfig{Source: KeySourceASN, IPv6PrefixLength: 64}, Offenses: denials, Threshold: 5, Window: "10m", BanDuration: "1h"},
+			wantErr: "key.ipv6PrefixLength is not supported",
+		},
+		{
+			name:    "ipv6 prefix length out of range",
+			config:  AutoBanConfig{Key: KeyConfig{Source: KeySourceIP, IPv6PrefixLength: 129}, Offenses: denials, Threshold: 5, Window: "10m", BanDuration: "1h"},
+			wantErr: "key.ipv6PrefixLength must be between 1 and 128",
+		},
+		{
+			name:    "no offenses",
+			config:  AutoBanConfig{Key: KeyConfig{Source: KeySourceIP}, Threshold: 5, Window: "10m", BanDuration: "1h"},
+			wantErr: "offenses requires denials or at least one trap path",
+		},
+		{
+			name:    "relative trap path",
+			config:  AutoBanConfig{Key: KeyConfig{Source: KeySourceIP}, Offenses: OffensesConfig{TrapPaths: []string{"admin"}}, Threshold: 5, Window: "10m", BanDuration: "1h"},
+			wantErr: "path 'admin' must start with '/'",
+		},
+		{
+			name:    "missing threshold",
+			config:  AutoBanConfig{Key: KeyConfig{Source: KeySourceIP}, Offenses: denials, Window: "10m", BanDuration: "1h"},
+			wantErr: "threshold must be greater than 0",
+		},
+		{
+			name:    "missing window",
+			config:  AutoBanConfig{Key: KeyConfig{Source: KeySourceIP}, Offenses: denials, Threshold: 5, BanDuration: "1h"},
+			wantErr: "window is required",
+		},
+		{
+			name:    "invalid ban duration",
+			config:  AutoBanConfig{Key: KeyConfig{Source: KeySourceIP}, Offenses: denials, Threshold: 5, Window: "10m", BanDuration: "forever"},
+			wantErr: "invalid banDuration",
+		},
+		{
+			name:    "sub-second window",
+			config:  AutoBanConfig{Key: KeyConfig{Source: KeySourceIP}, Offenses: denials, Threshold: 5, Window: "100ms", BanDuration: "1h"},
+			wantErr: "window must be at least 1s",
+		},
+		{
+			name:    "redis store without datasource",
+			config:  AutoBanConfig{Key: KeyConfig{Source: KeySourceIP}, Offenses: denials, Threshold: 5, Window: "10m", BanDuration: "1h", Store: StoreConfig{Type: StoreRedis}},
+			wantErr: "store.datasource is required when store.type is 'redis'",
+		},
+		{
+			name:    "redis settings with memory store",
+			config:  AutoBanConfig{Key: KeyConfig{Source: KeySourceIP}, Offenses: denials, Threshold: 5, Window: "10m", BanDuration: "1h", Store: StoreConfig{KeyPrefix: "bans:"}},
+			wantErr: "only supported when store.type is 'redis'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.ApplyDefaults("repeat-offenders")
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAutoBanConfigApplyDefaults(t *testing.T) {
+	config := AutoBanConfig{Key: KeyConfig{Source: KeySourceIP}, Store: StoreConfig{Type: StoreRedis}}
+	config.ApplyDefaults("repeat-offenders")
+
+	if config.Key.IPv6PrefixLength != 128 {
+		t.Fatalf("expected IPv6 addresses to be banned individually, got /%d", config.Key.IPv6PrefixLength)
+	}
+	if config.Store.KeyPrefix != "autoban:repeat-offenders:" {
+		t.Fatalf("expected key prefix of the controller, got %q", config.Store.KeyPrefix)
+	}
+	if config.Store.GetTimeout() != defaultStoreTimeout {
+		t.Fatalf("expected default store timeout, got %s", config.Store.GetTimeout())
+	}
+}
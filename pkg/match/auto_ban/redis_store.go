@@ -0,0 +1,208 @@
+package auto_ban
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+)
+
+// redisDataSourceType is the type of the shared datasources the redis store uses
+const redisDataSourceType = "redis"
+
+// bansIndexKey is the key, after the store prefix, of the sorted set indexing
+// the bans by expiry, so that they can be listed without scanning the keyspace
+const bansIndexKey = "bans"
+
+// The state of a key is one hash holding the offenses counted in the current
+// window, when the window ends and when the ban of the key expires. Scripts
+// read the clock of the Redis server, so that every instance of the service
+// counts on the same time, and update the state and the index of the bans
+// together. The store prefix is a hash tag, so that the keys of a controller
+// share a Redis Cluster slot and the scripts can touch both.
+var (
+	offendScript = redis.NewScript(`
+redis.replicate_commands()
+local threshold = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local ban = tonumber(ARGV[3])
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'offenses', 'windowEnd', 'bannedUntil')
+local bannedUntil = tonumber(state[3])
+if bannedUntil ~= nil and bannedUntil > now then
+  return {bannedUntil - now, bannedUntil, 0}
+end
+
+local offenses = tonumber(state[1]) or 0
+local windowEnd = tonumber(state[2]) or 0
+if windowEnd <= now then
+  offenses, windowEnd = 0, now + window
+end
+offenses = offenses + 1
+
+redis.call('DEL', KEYS[1])
+if offenses >= threshold then
+  redis.call('HSET', KEYS[1], 'bannedUntil', now + ban)
+  redis.call('PEXPIRE', KEYS[1], ban)
+  redis.call('ZADD', KEYS[2], now + ban, ARGV[4])
+  return {ban, now + ban, 1}
+end
+redis.call('HSET', KEYS[1], 'offenses', offenses, 'windowEnd', windowEnd)
+redis.call('PEXPIRE', KEYS[1], windowEnd - now)
+return {0, 0, 0}
+`)
+
+	bannedScript = redis.NewScript(`
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+local bannedUntil = tonumber(redis.call('HGET', KEYS[1], 'bannedUntil'))
+if bannedUntil ~= nil and bannedUntil > now then
+  return bannedUntil - now
+end
+return 0
+`)
+
+	listScript = redis.NewScript(`
+redis.replicate_commands()
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+local members = redis.call('ZRANGEBYSCORE', KEYS[1], '(' .. now, '+inf', 'WITHSCORES')
+local bans = {}
+for i = 1, #members, 2 do
+  table.insert(bans, members[i])
+  table.insert(bans, tonumber(members[i + 1]) - now)
+end
+return bans
+`)
+
+	liftScript = redis.NewScript(`
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+local bannedUntil = tonumber(redis.call('HGET', KEYS[1], 'bannedUntil'))
+redis.call('DEL', KEYS[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+if bannedUntil ~= nil and bannedUntil > now then
+  return 1
+end
+return 0
+`)
+)
+
+// redisStore keeps offenses and bans in a shared Redis datasource
+type redisStore struct {
+	client      redis.UniversalClient
+	shared      *controller.DataSourceHandle
+	keyPrefix   string
+	threshold   string
+	window      string
+	banDuration string
+	timeout     time.Duration
+}
+
+// newRedisStore builds a store on the shared Redis datasource of the
+// configuration, holding a reference to it until ctx is canceled
+func newRedisStore(ctx context.Context, cfg AutoBanConfig) (*redisStore, error) {
+	handle, err := controller.DataSourcesFromContext(ctx).Acquire(cfg.Store.DataSource, redisDataSourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, ok := handle.DataSource().(interface{ Client() redis.UniversalClient })
+	if !ok {
+		handle.Release()
+		return nil, fmt.Errorf("datasource '%s' is not a redis connection", cfg.Store.DataSource)
+	}
+
+	go func() {
+		<-ctx.Done()
+		handle.Release()
+	}()
+
+	return &redisStore{
+		client:      shared.Client(),
+		shared:      handle,
+		keyPrefix:   "{" + cfg.Store.KeyPrefix + "}",
+		threshold:   strconv.Itoa(cfg.Threshold),
+		window:      strconv.FormatInt(cfg.GetWindow().Milliseconds(), 10),
+		banDuration: strconv.FormatInt(cfg.GetBanDuration().Milliseconds(), 10),
+		timeout:     cfg.Store.GetTimeout(),
+	}, nil
+}
+
+// Offend counts an offense of key, indexing the ban it may cause
+func (s *redisStore) Offend(ctx context.Context, key string) (time.Duration, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	keys := []string{s.keyPrefix + key, s.keyPrefix + bansIndexKey}
+	reply, err := offendScript.Run(ctx, s.client, keys, s.threshold, s.window, s.banDuration, key).Int64Slice()
+	if err != nil {
+		return 0, false, err
+	}
+	if len(reply) != 3 {
+		return 0, false, fmt.Errorf("unexpected script reply %v", reply)
+	}
+	return time.Duration(reply[0]) * time.Millisecond, reply[2] == 1, nil
+}
+
+// Banned returns the remaining ban of key
+func (s *redisStore) Banned(ctx context.Context, key string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	remaining, err := bannedScript.Run(ctx, s.client, []string{s.keyPrefix + key}).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(remaining) * time.Millisecond, nil
+}
+
+// List returns the active bans of the index, dropping the expired ones
+func (s *redisStore) List(ctx context.Context) ([]Ban, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	// The script returns the time left of each ban on the clock of Redis,
+	// converted to the local clock so that listings agree with enforcement
+	now := time.Now()
+	reply, err := listScript.Run(ctx, s.client, []string{s.keyPrefix + bansIndexKey}).Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	bans := make([]Ban, 0, len(reply)/2)
+	for i := 0; i+1 < len(reply); i += 2 {
+		key, ok := reply[i].(string)
+		remaining, isInt := reply[i+1].(int64)
+		if !ok || !isInt {
+			continue
+		}
+		bans = append(bans, Ban{Key: key, Until: now.Add(time.Duration(remaining) * time.Millisecond)})
+	}
+	sortBans(bans)
+	return bans, nil
+}
+
+// Lift removes the ban and the offenses of key
+func (s *redisStore) Lift(ctx context.Context, key string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	lifted, err := liftScript.Run(ctx, s.client, []string{s.keyPrefix + key, s.keyPrefix + bansIndexKey}, key).Int64()
+	if err != nil {
+		return false, err
+	}
+	return lifted == 1, nil
+}
+
+// HealthCheck verifies connectivity to Redis
+func (s *redisStore) HealthCheck(ctx context.Context) error {
+	return s.shared.HealthCheck(ctx)
+}
@@ -0,0 +1,197 @@
+package auto_ban
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Ban is a banned key and when its ban expires
+type Ban struct {
+	Key   string
+	Until time.Time
+}
+
+// Store counts the offenses of keys and bans them
+type Store interface {
+	// Offend counts an offense of key, banning it when the threshold is
+	// reached. It returns the remaining ban of key, zero when not banned, and
+	// whether the offense caused the ban.
+	Offend(ctx context.Context, key string) (time.Duration, bool, error)
+	// Banned returns the remaining ban of key, zero when not banned
+	Banned(ctx context.Context, key string) (time.Duration, error)
+	// List returns the active bans, sorted by key
+	List(ctx context.Context) ([]Ban, error)
+	// Lift removes the ban and the offenses of key, reporting whether it was banned
+	Lift(ctx context.Context, key string) (bool, error)
+	HealthCheck(ctx context.Context) error
+}
+
+// sortBans sorts bans by key
+func sortBans(bans []Ban) {
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Key < bans[j].Key })
+}
+
+// policy is how offenses turn into bans
+type policy struct {
+	threshold   int
+	window      time.Duration
+	banDuration time.Duration
+}
+
+// newPolicy builds the policy of a validated configuration
+func newPolicy(cfg AutoBanConfig) policy {
+	return policy{
+		threshold:   cfg.Threshold,
+		window:      cfg.GetWindow(),
+		banDuration: cfg.GetBanDuration(),
+	}
+}
+
+// NewStore builds the store of a validated configuration, keeping bans until ctx is canceled
+func NewStore(ctx context.Context, cfg AutoBanConfig) (Store, error) {
+	if cfg.Store.Type == StoreRedis {
+		store, err := newRedisStore(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("could not create redis store: %w", err)
+		}
+		return store, nil
+	}
+	return newMemoryStore(ctx, newPolicy(cfg)), nil
+}
+
+// memoryStore keeps offenses and bans in process
+type memoryStore struct {
+	policy  policy
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+// memoryEntry holds the offenses counted in the window ending at windowEnd
+// and the ban of a key
+type memoryEntry struct {
+	offenses    int
+	windowEnd   time.Time
+	bannedUntil time.Time
+}
+
+// newMemoryStore builds an in-process store, forgetting expired keys until ctx is canceled
+func newMemoryStore(ctx context.Context, policy policy) *memoryStore {
+	store := &memoryStore{
+		policy:  policy,
+		now:     time.Now,
+		entries: make(map[string]*memoryEntry),
+	}
+	go store.runSweep(ctx, policy.window)
+	return store
+}
+
+// Offend counts an offense of key
+func (s *memoryStore) Offend(ctx context.Context, key string) (time.Duration, bool, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &memoryEntry{}
+		s.entries[key] = entry
+	}
+	if entry.bannedUntil.After(now) {
+		return entry.bannedUntil.Sub(now), false, nil
+	}
+
+	if !entry.windowEnd.After(now) {
+		entry.offenses, entry.windowEnd = 0, now.Add(s.policy.window)
+	}
+	entry.offenses++
+	if entry.offenses < s.policy.threshold {
+		return 0, false, nil
+	}
+
+	*entry = memoryEntry{bannedUntil: now.Add(s.policy.banDuration)}
+	return s.policy.banDuration, true, nil
+}
+
+// Banned returns the remaining ban of key
+func (s *memoryStore) Banned(ctx context.Context, key string) (time.Duration, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok && entry.bannedUntil.After(now) {
+		return entry.bannedUntil.Sub(now), nil
+	}
+	return 0, nil
+}
+
+// List returns the active bans
+func (s *memoryStore) List(ctx context.Context) ([]Ban, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bans := []Ban{}
+	for key, entry := range s.entries {
+		if entry.bannedUntil.After(now) {
+			bans = append(bans, Ban{Key: key, Until: entry.bannedUntil})
+		}
+	}
+	sortBans(bans)
+	return bans, nil
+}
+
+// Lift removes the ban and the offenses of key
+func (s *memoryStore) Lift(ctx context.Context, key string) (bool, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return false, nil
+	}
+	delete(s.entries, key)
+	return entry.bannedUntil.After(now), nil
+}
+
+// HealthCheck always succeeds, the store has no external dependencies
+func (s *memoryStore) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+// sweep forgets the keys neither banned nor offending
+func (s *memoryStore) sweep() {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, entry := range s.entries {
+		if !entry.bannedUntil.After(now) && !entry.windowEnd.After(now) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// runSweep periodically forgets the expired keys until ctx is canceled
+func (s *memoryStore) runSweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep()
+		}
+	}
+}
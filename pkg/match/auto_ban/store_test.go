@@ -0,0 +1,61 @@
+package auto_ban
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := newMemoryStore(ctx, policy{threshold: 2, window: time.Minute, banDuration: time.Hour})
+	now := time.Unix(1_700_000_000, 0)
+	store.now = func() time.Time { return now }
+
+	if remaining, banned, _ := store.Offend(ctx, "192.0.2.1"); remaining != 0 || banned {
+		t.Fatalf("expected the first offense not to ban, got %s", remaining)
+	}
+
+	// Offenses of an expired window are forgotten
+	now = now.Add(time.Minute)
+	if remaining, banned, _ := store.Offend(ctx, "192.0.2.1"); remaining != 0 || banned {
+		t.Fatalf("expected the offense of a new window not to ban, got %s", remaining)
+	}
+	if remaining, banned, _ := store.Offend(ctx, "192.0.2.1"); remaining != time.Hour || !banned {
+		t.Fatalf("expected the threshold to ban for an hour, got %s", remaining)
+	}
+
+	now = now.Add(10 * time.Minute)
+	if remaining, banned, _ := store.Offend(ctx, "192.0.2.1"); remaining != 50*time.Minute || banned {
+		t.Fatalf("expected offenses of banned keys to leave the ban unchanged, got %s", remaining)
+	}
+	if remaining, _ := store.Banned(ctx, "192.0.2.1"); remaining != 50*time.Minute {
+		t.Fatalf("expected 50 minutes of ban left, got %s", remaining)
+	}
+	if remaining, _ := store.Banned(ctx, "192.0.2.2"); remaining != 0 {
+		t.Fatalf("expected keys to be banned separately, got %s", remaining)
+	}
+
+	store.Offend(ctx, "192.0.2.2")
+	store.Offend(ctx, "192.0.2.3")
+	store.Offend(ctx, "192.0.2.3")
+	bans, _ := store.List(ctx)
+	if len(bans) != 2 || bans[0].Key != "192.0.2.1" || bans[1].Key != "192.0.2.3" || !bans[1].Until.Equal(now.Add(time.Hour)) {
+		t.Fatalf("unexpected bans %+v", bans)
+	}
+
+	if lifted, _ := store.Lift(ctx, "192.0.2.3"); !lifted {
+		t.Fatal("expected the ban to be lifted")
+	}
+	if lifted, _ := store.Lift(ctx, "192.0.2.3"); lifted {
+		t.Fatal("expected lifting a key not banned to report false")
+	}
+
+	now = now.Add(time.Hour)
+	store.sweep()
+	if len(store.entries) != 0 {
+		t.Fatalf("expected expired bans and offenses to be forgotten, got %d entries", len(store.entries))
+	}
+}
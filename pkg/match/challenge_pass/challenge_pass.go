@@ -0,0 +1,269 @@
+package challenge_pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/secrets"
+)
+
+const (
+	ControllerKind = "challenge-pass"
+)
+
+const (
+	// ResultPassed is reported for requests carrying a valid challenge cookie
+	ResultPassed = "passed"
+	// ResultChallenged is reported for requests challenged to obtain a cookie
+	ResultChallenged = "challenged"
+	// ResultIssued is reported for challenge endpoint requests issuing a cookie
+	ResultIssued = "issued"
+	// ResultRejected is reported for requests which cannot obtain a cookie
+	ResultRejected = "rejected"
+)
+
+// cookiePurpose and tokenPurpose keep cookies and challenge tokens, signed
+// with the same secrets, from being used in place of each other
+const (
+	cookiePurpose = "cookie"
+	tokenPurpose  = "token"
+)
+
+// init registers the challenge-pass match controller so it can be
+// constructed from configuration at runtime.
+func init() {
+	controller.RegisterMatchControllerFactory(ControllerKind, newChallengePassController)
+}
+
+type challengePassController struct {
+	name       string
+	mode       string
+	path       string
+	cookie     CookieConfig
+	cookieTTL  time.Duration
+	tokenTTL   time.Duration
+	ipv4Prefix int
+	ipv6Prefix int
+	signer     signer
+	now        func() time.Time
+}
+
+// Match implements controller.MatchController. The request matches when it
+// carries a valid challenge cookie. The verdicts of the other requests carry
+// the challenge, returned to the client when the policy denies the request
+// because of this controller.
+func (c *challengePassController) Match(ctx context.Context, req *runtime.RequestContext, reports controller.AnalysisReports) (*controller.MatchVerdict, error) {
+	httpRequest := req.Request.GetAttributes().GetRequest().GetHttp()
+	requestPath := httpRequest.GetPath()
+	client := c.clientBinding(req)
+
+	if endpoint, err := url.Parse(requestPath); err == nil && endpoint.Path == c.path {
+		return c.handleChallengeEndpoint(endpoint.Query(), client), nil
+	}
+
+	cookie := req.Cookie(c.cookie.Name)
+	if cookie == "" {
+		return c.createChallengeVerdict(httpRequest.GetMethod(), requestPath, client, "challenge cookie missing")
+	}
+	expiry, err := c.signer.verify(cookie, c.now(), append([]string{cookiePurpose}, client...)...)
+	if err != nil {
+		return c.createChallengeVerdict(httpRequest.GetMethod(), requestPath, client, fmt.Sprintf("challenge cookie %s", err))
+	}
+
+	return c.createVerdict(true, ResultPassed, fmt.Sprintf("challenge cookie valid until %s", expiry.UTC().Format(time.RFC3339))), nil
+}
+
+// Name implements controller.MatchController.
+func (c *challengePassController) Name() string {
+	return c.name
+}
+
+// Kind implements controller.MatchController.
+func (c *challengePassController) Kind() string {
+	return ControllerKind
+}
+
+// HealthCheck implements controller.MatchController.
+func (c *challengePassController) HealthCheck(ctx context.Context) error {
+	// No external dependencies to check
+	return nil
+}
+
+// handleChallengeEndpoint verifies the challenge token of a request to the
+// challenge endpoint and, when valid, redirects the client back to the
+// challenged path setting the challenge cookie
+func (c *challengePassController) handleChallengeEndpoint(query url.Values, client []string) *controller.MatchVerdict {
+	returnPath := query.Get("return")
+	if !isLocalPath(returnPath) {
+		return c.createRejectedVerdict("challenge token rejected: return path is not valid")
+	}
+	if _, err := c.signer.verify(query.Get("token"), c.now(), append([]string{tokenPurpose, returnPath}, client...)...); err != nil {
+		if errors.Is(err, errTokenExpired) {
+			// Challenge again, the client was too slow or came back to a stale page
+			return c.createRedirectVerdict(ResultChallenged, c.challengeURL(returnPath, client), nil, "challenge token expired")
+		}
+		return c.createRejectedVerdict(fmt.Sprintf("challenge token rejected: %s", err))
+	}
+
+	expiry := c.now().Add(c.cookieTTL)
+	cookie := &http.Cookie{
+		Name:     c.cookie.Name,
+		Value:    c.signer.sign(expiry, append([]string{cookiePurpose}, client...)...),
+		Path:     "/",
+		Domain:   c.cookie.Domain,
+		MaxAge:   int(c.cookieTTL.Seconds()),
+		Secure:   !c.cookie.Insecure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	return c.createRedirectVerdict(ResultIssued, returnPath, cookie, fmt.Sprintf("challenge passed, cookie issued until %s", expiry.UTC().Format(time.RFC3339)))
+}
+
+// createChallengeVerdict builds the verdict challenging a request without a
+// valid cookie. Only GET and HEAD requests are challenged, as the others
+// could not be replayed after the redirects.
+func (c *challengePassController) createChallengeVerdict(method, requestPath string, client []string, description string) (*controller.MatchVerdict, error) {
+	if method != http.MethodGet && method != http.MethodHead {
+		return c.createRejectedVerdict(fmt.Sprintf("%s, %s requests are not challenged", description, method)), nil
+	}
+	if !isLocalPath(requestPath) {
+		requestPath = "/"
+	}
+
+	challengeURL := c.challengeURL(requestPath, client)
+	if c.mode == ModeRedirect {
+		return c.createRedirectVerdict(ResultChallenged, challengeURL, nil, description), nil
+	}
+
+	page, err := renderInterstitialPage(challengeURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not render interstitial page: %w", err)
+	}
+	verdict := c.createVerdict(false, ResultChallenged, description)
+	verdict.DenyBody = page
+	verdict.DenyDownstreamHeaders = map[string]string{
+		"Content-Type":  "text/html; charset=utf-8",
+		"Cache-Control": "no-store",
+	}
+	return verdict, nil
+}
+
+// createRedirectVerdict builds the verdict redirecting the client, optionally
+// setting a cookie
+func (c *challengePassController) createRedirectVerdict(result, location string, cookie *http.Cookie, description string) *controller.MatchVerdict {
+	verdict := c.createVerdict(false, result, description)
+	verdict.DenyHTTPStatus = http.StatusFound
+	verdict.DenyDownstreamHeaders = map[string]string{
+		"Location":      location,
+		"Cache-Control": "no-store",
+	}
+	if cookie != nil {
+		verdict.DenyDownstreamHeaders["Set-Cookie"] = cookie.String()
+	}
+	return verdict
+}
+
+// createRejectedVerdict builds the verdict of requests which cannot obtain a
+// challenge cookie
+func (c *challengePassController) createRejectedVerdict(description string) *controller.MatchVerdict {
+	return c.createVerdict(false, ResultRejected, description)
+}
+
+// createVerdict builds the verdict reported for the request. Challenges and
+// issued cookies are steps of the challenge flow, not offenses.
+func (c *challengePassController) createVerdict(isMatch bool, result, description string) *controller.MatchVerdict {
+	return &controller.MatchVerdict{
+		Controller:     c.name,
+		ControllerType: ControllerKind,
+		DenyCode:       codes.PermissionDenied,
+		DenyMessage:    "challenge required",
+		DenyChallenge:  result == ResultChallenged || result == ResultIssued,
+		Description:    description,
+		IsMatch:        isMatch,
+		LogFields: []zap.Field{
+			zap.String("challenge.result", result),
+		},
+	}
+}
+
+// challengeURL returns the URL of the challenge endpoint with a token bound
+// to the client and to the challenged path
+func (c *challengePassController) challengeURL(returnPath string, client []string) string {
+	token := c.signer.sign(c.now().Add(c.tokenTTL), append([]string{tokenPurpose, returnPath}, client...)...)
+	query := url.Values{"return": {returnPath}, "token": {token}}
+	return c.path + "?" + query.Encode()
+}
+
+// clientBinding returns the client attributes challenge cookies are bound to:
+// the network prefix of its IP and its user agent
+func (c *challengePassController) clientBinding(req *runtime.RequestContext) []string {
+	network := ""
+	if ip := req.IpAddress; ip.IsValid() {
+		bits := c.ipv6Prefix
+		if ip.Unmap().Is4() {
+			ip, bits = ip.Unmap(), c.ipv4Prefix
+		}
+		if prefix, err := ip.Prefix(bits); err == nil {
+			network = prefix.String()
+		}
+	}
+	return []string{network, req.Header("user-agent")}
+}
+
+// isLocalPath reports whether a path is a local absolute path, preventing
+// redirects to other hosts
+func isLocalPath(value string) bool {
+	return strings.HasPrefix(value, "/") && !strings.HasPrefix(value, "//") && !strings.HasPrefix(value, "/\\")
+}
+
+// newChallengePassController validates the settings and reads the secrets of
+// a controller challenging clients without a valid challenge cookie
+func newChallengePassController(ctx context.Context, logger *zap.Logger, cfg config.ControllerConfig) (controller.MatchController, error) {
+	var challengePassConfig ChallengePassConfig
+	if err := controller.DecodeControllerSettings(cfg.Settings, &challengePassConfig); err != nil {
+		return nil, err
+	}
+	challengePassConfig.ApplyDefaults()
+	if err := challengePassConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	signingSecrets, err := secrets.Load(challengePassConfig.Secrets, minSecretLength)
+	if err != nil {
+		return nil, err
+	}
+
+	ctrl := &challengePassController{
+		name:       cfg.Name,
+		mode:       challengePassConfig.Mode,
+		path:       challengePassConfig.Path,
+		cookie:     challengePassConfig.Cookie,
+		cookieTTL:  challengePassConfig.GetCookieTTL(),
+		tokenTTL:   challengePassConfig.GetTokenTTL(),
+		ipv4Prefix: challengePassConfig.IPv4Prefix,
+		ipv6Prefix: challengePassConfig.IPv6Prefix,
+		signer:     signer{secrets: signingSecrets},
+		now:        time.Now,
+	}
+
+	logger.Info("controller initialized",
+		zap.String("mode", ctrl.mode),
+		zap.String("path", ctrl.path),
+		zap.String("cookie", ctrl.cookie.Name),
+		zap.Duration("cookie_ttl", ctrl.cookieTTL),
+		zap.Int("secrets", len(signingSecrets)),
+	)
+
+	return ctrl, nil
+}
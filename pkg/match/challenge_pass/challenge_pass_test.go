@@ -0,0 +1,203 @@
+package challenge_pass
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"go.uber.org/zap"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+const (
+	browser = "Mozilla/5.0 (X11; Linux x86_64)"
+)
+
+func TestMatch_ChallengeFlow(t *testing.T) {
+	ctrl := buildController(t, map[string]any{})
+
+	// A client without cookie is redirected to the challenge endpoint
+	verdict := match(t, ctrl, request{path: "/account?tab=orders", ip: "192.0.2.10", userAgent: browser})
+	if verdict.IsMatch || verdict.Description != "challenge cookie missing" || verdict.DenyHTTPStatus != http.StatusFound {
+		t.Fatalf("expected a challenge redirect, got %v %d %q", verdict.IsMatch, verdict.DenyHTTPStatus, verdict.Description)
+	}
+	challengeURL := verdict.DenyDownstreamHeaders["Location"]
+	if !strings.HasPrefix(challengeURL, "/.well-known/envoy-authz/challenge?") {
+		t.Fatalf("unexpected challenge location %q", challengeURL)
+	}
+
+	// The challenge endpoint sets the cookie and redirects back
+	verdict = match(t, ctrl, request{path: challengeURL, ip: "192.0.2.10", userAgent: browser})
+	if verdict.IsMatch || verdict.DenyHTTPStatus != http.StatusFound || verdict.DenyDownstreamHeaders["Location"] != "/account?tab=orders" {
+		t.Fatalf("expected a redirect to the challenged path, got %v %q", verdict.DenyDownstreamHeaders, verdict.Description)
+	}
+	cookie, err := http.ParseSetCookie(verdict.DenyDownstreamHeaders["Set-Cookie"])
+	if err != nil {
+		t.Fatalf("invalid cookie: %v", err)
+	}
+	if cookie.Name != "envoy_authz_challenge" || !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode || cookie.MaxAge != 3600 {
+		t.Fatalf("unexpected cookie attributes %q", verdict.DenyDownstreamHeaders["Set-Cookie"])
+	}
+
+	requestCookie := cookie.Name + "=" + cookie.Value
+	tests := []struct {
+		name        string
+		request     request
+		match       bool
+		description string
+	}{
+		{name: "cookie", request: request{path: "/account", ip: "192.0.2.10", userAgent: browser, cookie: requestCookie}, match: true, description: "challenge cookie valid until"},
+		{name: "same network", request: request{path: "/account", ip: "192.0.2.200", userAgent: browser, cookie: requestCookie}, match: true, description: "challenge cookie valid until"},
+		{name: "other network", request: request{path: "/account", ip: "198.51.100.10", userAgent: browser, cookie: requestCookie}, description: "challenge cookie not valid for this client"},
+		{name: "other user agent", request: request{path: "/account", ip: "192.0.2.10", userAgent: "python-requests/2.31", cookie: requestCookie}, description: "challenge cookie not valid for this client"},
+		{name: "tampered cookie", request: request{path: "/account", ip: "192.0.2.10", userAgent: browser, cookie: cookie.Name + "=99999999999.abc"}, description: "challenge cookie not valid for this client"},
+		{name: "token used as cookie", request: request{path: "/account", ip: "192.0.2.10", userAgent: browser, cookie: cookie.Name + "=" + query(t, challengeURL).Get("token")}, description: "challenge cookie not valid for this client"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict := match(t, ctrl, tt.request)
+			if verdict.IsMatch != tt.match || !strings.HasPrefix(verdict.Description, tt.description) {
+				t.Fatalf("expected IsMatch=%v %q, got %v %q", tt.match, tt.description, verdict.IsMatch, verdict.Description)
+			}
+		})
+	}
+}
+
+func TestMatch_ChallengeEndpoint(t *testing.T) {
+	ctrl := buildController(t, map[string]any{"path": "/_challenge", "tokenTtl": "30s"})
+	challengeURL := match(t, ctrl, request{path: "/", ip: "192.0.2.10", userAgent: browser}).DenyDownstreamHeaders["Location"]
+	token := query(t, challengeURL).Get("token")
+
+	tests := []struct {
+		name        string
+		request     request
+		description string
+	}{
+		{name: "other client", request: request{path: challengeURL, ip: "198.51.100.10", userAgent: browser}, description: "challenge token rejected: not valid for this client"},
+		{name: "other return path", request: request{path: "/_challenge?" + url.Values{"return": {"/admin"}, "token": {token}}.Encode(), ip: "192.0.2.10", userAgent: browser}, description: "challenge token rejected: not valid for this client"},
+		{name: "open redirect", request: request{path: "/_challenge?" + url.Values{"return": {"//evil.example"}, "token": {token}}.Encode(), ip: "192.0.2.10", userAgent: browser}, description: "challenge token rejected: return path is not valid"},
+		{name: "missing token", request: request{path: "/_challenge?return=%2F", ip: "192.0.2.10", userAgent: browser}, description: "challenge token rejected: malformed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict := match(t, ctrl, tt.request)
+			if verdict.IsMatch || verdict.DenyHTTPStatus != 0 || verdict.Description != tt.description {
+				t.Fatalf("expected rejection %q, got %d %q", tt.description, verdict.DenyHTTPStatus, verdict.Description)
+			}
+		})
+	}
+
+	ctrl.now = func() time.Time { return time.Now().Add(time.Minute) }
+	verdict := match(t, ctrl, request{path: challengeURL, ip: "192.0.2.10", userAgent: browser})
+	if verdict.Description != "challenge token expired" || !strings.HasPrefix(verdict.DenyDownstreamHeaders["Location"], "/_challenge?") {
+		t.Fatalf("expected an expired token to be challenged again, got %v %q", verdict.DenyDownstreamHeaders, verdict.Description)
+	}
+}
+
+func TestMatch_Interstitial(t *testing.T) {
+	ctrl := buildController(t, map[string]any{"mode": "interstitial", "cookie": map[string]any{"name": "pass", "insecure": true}})
+
+	verdict := match(t, ctrl, request{path: "/pricing", ip: "2001:db8::1", userAgent: browser})
+	if verdict.IsMatch || verdict.DenyHTTPStatus != 0 || verdict.DenyDownstreamHeaders["Content-Type"] != "text/html; charset=utf-8" {
+		t.Fatalf("expected an interstitial page, got %d %v", verdict.DenyHTTPStatus, verdict.DenyDownstreamHeaders)
+	}
+	if !strings.Contains(verdict.DenyBody, `window.location.replace("/.well-known/envoy-authz/challenge?return=%2Fpricing\u0026token=`) || strings.Contains(verdict.DenyBody, "href=") || strings.Contains(verdict.DenyBody, "refresh") {
+		t.Fatalf("expected the challenge URL to be followed by script only, got %s", verdict.DenyBody)
+	}
+
+	challengeURL := ctrl.challengeURL("/pricing", ctrl.clientBinding(runtime.NewRequestContext(checkRequest(request{path: "/pricing", ip: "2001:db8::1", userAgent: browser}))))
+	verdict = match(t, ctrl, request{path: challengeURL, ip: "2001:db8::ffff", userAgent: browser})
+	if verdict.DenyDownstreamHeaders["Location"] != "/pricing" || strings.Contains(verdict.DenyDownstreamHeaders["Set-Cookie"], "Secure") {
+		t.Fatalf("expected an insecure cookie for the same /64, got %v %q", verdict.DenyDownstreamHeaders, verdict.Description)
+	}
+}
+
+func TestMatch_NotChallengedMethods(t *testing.T) {
+	ctrl := buildController(t, map[string]any{})
+	verdict := match(t, ctrl, request{method: http.MethodPost, path: "/api/orders", ip: "192.0.2.10", userAgent: browser})
+	if verdict.IsMatch || verdict.DenyHTTPStatus != 0 || verdict.Description != "challenge cookie missing, POST requests are not challenged" {
+		t.Fatalf("expected POST to be rejected without challenge, got %d %q", verdict.DenyHTTPStatus, verdict.Description)
+	}
+}
+
+func TestNewChallengePassController_Errors(t *testing.T) {
+	t.Setenv("SHORT_CHALLENGE_SECRET", "short")
+	_, err := newChallengePassController(context.Background(), zap.NewNop(), config.ControllerConfig{
+		Name:     "challenge",
+		Type:     ControllerKind,
+		Settings: map[string]any{"secrets": []map[string]any{{"env": "SHORT_CHALLENGE_SECRET"}}},
+	})
+	if err == nil || err.Error() != "secrets[0] must be at least 32 bytes long" {
+		t.Fatalf("expected short secret error, got %v", err)
+	}
+
+	_, err = newChallengePassController(context.Background(), zap.NewNop(), config.ControllerConfig{Name: "challenge", Type: ControllerKind, Settings: map[string]any{}})
+	if err == nil || !strings.Contains(err.Error(), "configuration validation failed: at least one secret is required") {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+}
+
+type request struct {
+	method    string
+	path      string
+	ip        string
+	userAgent string
+	cookie    string
+}
+
+func checkRequest(r request) *authv3.CheckRequest {
+	if r.method == "" {
+		r.method = http.MethodGet
+	}
+	headers := map[string]string{"user-agent": r.userAgent, "x-forwarded-for": r.ip}
+	if r.cookie != "" {
+		headers["cookie"] = r.cookie
+	}
+	return &authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{
+			Request: &authv3.AttributeContext_Request{
+				Http: &authv3.AttributeContext_HttpRequest{Method: r.method, Path: r.path, Headers: headers},
+			},
+		},
+	}
+}
+
+func query(t *testing.T, location string) url.Values {
+	t.Helper()
+	parsed, err := url.Parse(location)
+	if err != nil {
+		t.Fatalf("invalid location %q: %v", location, err)
+	}
+	return parsed.Query()
+}
+
+func buildController(t *testing.T, settings map[string]any) *challengePassController {
+	t.Helper()
+	t.Setenv("CHALLENGE_SECRET", strings.Repeat("s", 32))
+	settings["secrets"] = []map[string]any{{"env": "CHALLENGE_SECRET"}}
+	ctrl, err := newChallengePassController(context.Background(), zap.NewNop(), config.ControllerConfig{
+		Name:     "challenge",
+		Type:     ControllerKind,
+		Settings: settings,
+	})
+	if err != nil {
+		t.Fatalf("failed to build controller: %v", err)
+	}
+	return ctrl.(*challengePassController)
+}
+
+func match(t *testing.T, ctrl controller.MatchController, r request) *controller.MatchVerdict {
+	t.Helper()
+	verdict, err := ctrl.Match(context.Background(), runtime.NewRequestContext(checkRequest(r)), nil)
+	if err != nil {
+		t.Fatalf("match returned error: %v", err)
+	}
+	return verdict
+}
@@ -0,0 +1,131 @@
+package challenge_pass
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/secrets"
+)
+
+const (
+	ModeRedirect     = "redirect"
+	ModeInterstitial = "interstitial"
+)
+
+const (
+	defaultMode       = ModeRedirect
+	defaultPath       = "/.well-known/envoy-authz/challenge"
+	defaultCookieName = "envoy_authz_challenge"
+	defaultCookieTTL  = "1h"
+	defaultTokenTTL   = "30s"
+	defaultIPv4Prefix = 24
+	defaultIPv6Prefix = 64
+)
+
+// ChallengePassConfig represents the configuration of a challenge-pass
+// controller. Requests match when they carry a valid challenge cookie; the
+// others are challenged to obtain one.
+type ChallengePassConfig struct {
+	Mode       string           `yaml:"mode"`
+	Secrets    []secrets.Config `yaml:"secrets"`
+	Path       string           `yaml:"path"`
+	Cookie     CookieConfig     `yaml:"cookie"`
+	TokenTTL   string           `yaml:"tokenTtl"`
+	IPv4Prefix int              `yaml:"ipv4Prefix"`
+	IPv6Prefix int              `yaml:"ipv6Prefix"`
+}
+
+// CookieConfig represents the attributes of the challenge cookie
+type CookieConfig struct {
+	Name     string `yaml:"name"`
+	TTL      string `yaml:"ttl"`
+	Domain   string `yaml:"domain"`
+	Insecure bool   `yaml:"insecure"`
+}
+
+// ApplyDefaults sets default values for the configuration
+func (c *ChallengePassConfig) ApplyDefaults() {
+	if c.Mode == "" {
+		c.Mode = defaultMode
+	}
+	if c.Path == "" {
+		c.Path = defaultPath
+	}
+	if c.Cookie.Name == "" {
+		c.Cookie.Name = defaultCookieName
+	}
+	if c.Cookie.TTL == "" {
+		c.Cookie.TTL = defaultCookieTTL
+	}
+	if c.TokenTTL == "" {
+		c.TokenTTL = defaultTokenTTL
+	}
+	if c.IPv4Prefix == 0 {
+		c.IPv4Prefix = defaultIPv4Prefix
+	}
+	if c.IPv6Prefix == 0 {
+		c.IPv6Prefix = defaultIPv6Prefix
+	}
+}
+
+// Validate checks the configuration for completeness. The secrets are read
+// when the controller is created.
+func (c *ChallengePassConfig) Validate() error {
+	if c.Mode != ModeRedirect && c.Mode != ModeInterstitial {
+		return fmt.Errorf("unsupported mode '%s', expected redirect or interstitial", c.Mode)
+	}
+
+	// The first secret signs, every secret verifies
+	if err := secrets.Validate(c.Secrets); err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(c.Path, "/") || strings.ContainsAny(c.Path, "?# ") {
+		return fmt.Errorf("path must be an absolute path without query, got '%s'", c.Path)
+	}
+
+	if c.Cookie.Name == "" || strings.ContainsAny(c.Cookie.Name, "=;, \t\r\n") {
+		return fmt.Errorf("cookie.name: invalid cookie name '%s'", c.Cookie.Name)
+	}
+	if strings.ContainsAny(c.Cookie.Domain, ";, \t\r\n") {
+		return fmt.Errorf("cookie.domain: invalid domain '%s'", c.Cookie.Domain)
+	}
+
+	for _, setting := range []struct {
+		field string
+		value string
+	}{
+		{field: "cookie.ttl", value: c.Cookie.TTL},
+		{field: "tokenTtl", value: c.TokenTTL},
+	} {
+		duration, err := time.ParseDuration(setting.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", setting.field, err)
+		}
+		if duration < time.Second {
+			return fmt.Errorf("%s must be at least 1s", setting.field)
+		}
+	}
+
+	if c.IPv4Prefix < 1 || c.IPv4Prefix > 32 {
+		return fmt.Errorf("ipv4Prefix must be between 1 and 32")
+	}
+	if c.IPv6Prefix < 1 || c.IPv6Prefix > 128 {
+		return fmt.Errorf("ipv6Prefix must be between 1 and 128")
+	}
+
+	return nil
+}
+
+// GetCookieTTL returns the parsed lifetime of challenge cookies
+func (c *ChallengePassConfig) GetCookieTTL() time.Duration {
+	ttl, _ := time.ParseDuration(c.Cookie.TTL)
+	return ttl
+}
+
+// GetTokenTTL returns the parsed lifetime of challenge tokens
+func (c *ChallengePassConfig) GetTokenTTL() time.Duration {
+	ttl, _ := time.ParseDuration(c.TokenTTL)
+	return ttl
+}
@@ -0,0 +1,104 @@
+package challenge_pass
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/secrets"
+)
+
+func TestChallengePassConfigValidate(t *testing.T) {
+	t.Setenv("CHALLENGE_SECRET", strings.Repeat("s", 32))
+	signingSecrets := []secrets.Config{{Env: "CHALLENGE_SECRET"}}
+
+	tests := []struct {
+		name    string
+		config  ChallengePassConfig
+		wantErr string
+	}{
+		{
+			name:   "defaults",
+			config: ChallengePassConfig{Secrets: signingSecrets},
+		},
+		{
+			name:   "interstitial",
+			config: ChallengePassConfig{Mode: ModeInterstitial, Secrets: []secrets.Config{{Env: "CHALLENGE_SECRET"}, {File: "/run/secrets/previous"}}, Path: "/_challenge", Cookie: CookieConfig{Name: "pass", TTL: "12h", Domain: "example.com"}, TokenTTL: "1m", IPv4Prefix: 32, IPv6Prefix: 48},
+		},
+		{
+			name:    "unsupported mode",
+			config:  ChallengePassConfig{Mode: "captcha", Secrets: signingSecrets},
+			wantErr: "unsupported mode 'captcha', expected redirect or interstitial",
+		},
+		{
+			name:    "no secrets",
+			config:  ChallengePassConfig{},
+			wantErr: "at least one secret is required",
+		},
+		{
+			name:    "secret without source",
+			config:  ChallengePassConfig{Secrets: []secrets.Config{{}}},
+			wantErr: "secrets[0]: exactly one of env or file is required",
+		},
+		{
+			name:    "missing environment variable",
+			config:  ChallengePassConfig{Secrets: []secrets.Config{{Env: "UNDEFINED_CHALLENGE_SECRET"}}},
+			wantErr: "secrets[0]: environment variable 'UNDEFINED_CHALLENGE_SECRET' not found",
+		},
+		{
+			name:    "relative path",
+			config:  ChallengePassConfig{Secrets: signingSecrets, Path: "challenge"},
+			wantErr: "path must be an absolute path without query, got 'challenge'",
+		},
+		{
+			name:    "path with query",
+			config:  ChallengePassConfig{Secrets: signingSecrets, Path: "/challenge?x=1"},
+			wantErr: "path must be an absolute path without query",
+		},
+		{
+			name:    "invalid cookie name",
+			config:  ChallengePassConfig{Secrets: signingSecrets, Cookie: CookieConfig{Name: "a=b"}},
+			wantErr: "cookie.name: invalid cookie name 'a=b'",
+		},
+		{
+			name:    "invalid cookie domain",
+			config:  ChallengePassConfig{Secrets: signingSecrets, Cookie: CookieConfig{Domain: "example.com; Secure"}},
+			wantErr: "cookie.domain: invalid domain",
+		},
+		{
+			name:    "invalid cookie ttl",
+			config:  ChallengePassConfig{Secrets: signingSecrets, Cookie: CookieConfig{TTL: "1 hour"}},
+			wantErr: "invalid cookie.ttl",
+		},
+		{
+			name:    "short token ttl",
+			config:  ChallengePassConfig{Secrets: signingSecrets, TokenTTL: "10ms"},
+			wantErr: "tokenTtl must be at least 1s",
+		},
+		{
+			name:    "ipv4 prefix",
+			config:  ChallengePassConfig{Secrets: signingSecrets, IPv4Prefix: 33},
+			wantErr: "ipv4Prefix must be between 1 and 32",
+		},
+		{
+			name:    "ipv6 prefix",
+			config:  ChallengePassConfig{Secrets: signingSecrets, IPv6Prefix: -1},
+			wantErr: "ipv6Prefix must be between 1 and 128",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.ApplyDefaults()
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
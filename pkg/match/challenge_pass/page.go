@@ -0,0 +1,36 @@
+package challenge_pass
+
+import (
+	"html/template"
+	"strings"
+)
+
+// interstitialPage is served to the challenged clients in interstitial mode.
+// The challenge URL is only followed by clients running JavaScript: the page
+// has neither links nor refresh.
+var interstitialPage = template.Must(template.New("interstitial").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex, nofollow">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Checking your browser</title>
+<style>body{font-family:system-ui,sans-serif;max-width:32rem;margin:20vh auto;padding:0 1rem;color:#333;text-align:center}</style>
+</head>
+<body>
+<h1>Checking your browser</h1>
+<p>You will be redirected in a moment.</p>
+<noscript><p>Please enable JavaScript and cookies to continue.</p></noscript>
+<script>
+window.location.replace({{.URL}});
+</script>
+</body>
+</html>
+`))
+
+// renderInterstitialPage returns the page following the challenge URL
+func renderInterstitialPage(challengeURL string) (string, error) {
+	var page strings.Builder
+	err := interstitialPage.Execute(&page, struct{ URL string }{URL: challengeURL})
+	return page.String(), err
+}
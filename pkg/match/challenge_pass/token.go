@@ -0,0 +1,71 @@
+package challenge_pass
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minSecretLength is the minimum length of signing secrets, the size of the
+// HMAC-SHA256 key
+const minSecretLength = 32
+
+var (
+	errTokenMalformed = errors.New("malformed")
+	errTokenExpired   = errors.New("expired")
+	errTokenInvalid   = errors.New("not valid for this client")
+)
+
+// signer signs expiring values bound to a list of fields, such as the client
+// IP prefix and user agent. The first secret signs, every secret verifies.
+type signer struct {
+	secrets [][]byte
+}
+
+// sign returns '<unix expiry>.<base64url HMAC>' of the fields
+func (s signer) sign(expiry time.Time, fields ...string) string {
+	expiryValue := strconv.FormatInt(expiry.Unix(), 10)
+	return expiryValue + "." + base64.RawURLEncoding.EncodeToString(s.mac(s.secrets[0], expiryValue, fields))
+}
+
+// verify checks a signed value against the fields, returning its expiry
+func (s signer) verify(value string, now time.Time, fields ...string) (time.Time, error) {
+	expiryValue, encodedMAC, ok := strings.Cut(value, ".")
+	if !ok {
+		return time.Time{}, errTokenMalformed
+	}
+	seconds, err := strconv.ParseInt(expiryValue, 10, 64)
+	if err != nil {
+		return time.Time{}, errTokenMalformed
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil {
+		return time.Time{}, errTokenMalformed
+	}
+
+	expiry := time.Unix(seconds, 0)
+	if !now.Before(expiry) {
+		return expiry, errTokenExpired
+	}
+	for _, secret := range s.secrets {
+		if hmac.Equal(signature, s.mac(secret, expiryValue, fields)) {
+			return expiry, nil
+		}
+	}
+	return expiry, errTokenInvalid
+}
+
+// mac computes the HMAC-SHA256 of the expiry and the fields, separated by NUL
+func (s signer) mac(secret []byte, expiry string, fields []string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(expiry))
+	for _, field := range fields {
+		mac.Write([]byte{0})
+		mac.Write([]byte(field))
+	}
+	return mac.Sum(nil)
+}
@@ -0,0 +1,45 @@
+package challenge_pass
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigner(t *testing.T) {
+	now := time.Now()
+	current := signer{secrets: [][]byte{[]byte(strings.Repeat("a", 32)), []byte(strings.Repeat("b", 32))}}
+	previous := signer{secrets: [][]byte{[]byte(strings.Repeat("b", 32))}}
+	other := signer{secrets: [][]byte{[]byte(strings.Repeat("c", 32))}}
+
+	valid := current.sign(now.Add(time.Hour), "cookie", "192.0.2.0/24", "Mozilla/5.0")
+
+	tests := []struct {
+		name    string
+		value   string
+		fields  []string
+		wantErr error
+	}{
+		{name: "valid", value: valid, fields: []string{"cookie", "192.0.2.0/24", "Mozilla/5.0"}},
+		{name: "signed with previous secret", value: previous.sign(now.Add(time.Hour), "cookie"), fields: []string{"cookie"}},
+		{name: "other network", value: valid, fields: []string{"cookie", "198.51.100.0/24", "Mozilla/5.0"}, wantErr: errTokenInvalid},
+		{name: "other user agent", value: valid, fields: []string{"cookie", "192.0.2.0/24", "curl/8.0"}, wantErr: errTokenInvalid},
+		{name: "other purpose", value: valid, fields: []string{"token", "192.0.2.0/24", "Mozilla/5.0"}, wantErr: errTokenInvalid},
+		{name: "fields not concatenated", value: current.sign(now.Add(time.Hour), "ab", "c"), fields: []string{"a", "bc"}, wantErr: errTokenInvalid},
+		{name: "other secret", value: other.sign(now.Add(time.Hour), "cookie"), fields: []string{"cookie"}, wantErr: errTokenInvalid},
+		{name: "expired", value: current.sign(now.Add(-time.Second), "cookie"), fields: []string{"cookie"}, wantErr: errTokenExpired},
+		{name: "extended expiry", value: strings.Replace(valid, strings.Split(valid, ".")[0], "99999999999", 1), fields: []string{"cookie", "192.0.2.0/24", "Mozilla/5.0"}, wantErr: errTokenInvalid},
+		{name: "no signature", value: "1700000000", wantErr: errTokenMalformed},
+		{name: "invalid expiry", value: "soon.abc", wantErr: errTokenMalformed},
+		{name: "invalid encoding", value: "1700000000.!!!", wantErr: errTokenMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := current.verify(tt.value, now, tt.fields...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
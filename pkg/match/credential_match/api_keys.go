@@ -0,0 +1,173 @@
+package credential_match
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const sha256Prefix = "sha256:"
+
+const (
+	// maxArgon2Memory is the highest argon2 memory cost accepted, in KiB
+	maxArgon2Memory = 64 * 1024
+	// maxArgon2Time is the highest argon2 number of passes accepted
+	maxArgon2Time = 10
+	// maxArgon2Threads is the highest argon2 parallelism accepted
+	maxArgon2Threads = 16
+	// maxArgon2HashLength is the longest argon2 hash accepted, in bytes
+	maxArgon2HashLength = 64
+)
+
+// apiKeys is the content of an API keys file: one hashed key per line,
+// followed by whitespace and the label of the key
+type apiKeys struct {
+	sha256 map[[sha256.Size]byte]string // digest of the key -> label
+	argon2 []*argon2Key
+	// verified caches the argon2 verifications, as argon2 is too slow to run
+	// on every request
+	verified *verificationCache
+}
+
+// argon2Key is an API key hashed with argon2i or argon2id
+type argon2Key struct {
+	variant string
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	hash    []byte
+	label   string
+}
+
+// parseAPIKeys parses an API keys file. Keys are hashed as
+// sha256:<hex digest> or in the PHC format of argon2
+// ($argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>).
+func parseAPIKeys(content []byte) (*apiKeys, error) {
+	keys := &apiKeys{
+		sha256:   make(map[[sha256.Size]byte]string),
+		verified: newVerificationCache(),
+	}
+
+	for i, rawLine := range strings.Split(string(content), "\n") {
+		line := strings.TrimSpace(rawLine)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: a key hash followed by a label is required", i+1)
+		}
+		hash, label := fields[0], strings.Join(fields[1:], " ")
+
+		switch {
+		case strings.HasPrefix(hash, sha256Prefix):
+			digest, err := hex.DecodeString(strings.TrimPrefix(hash, sha256Prefix))
+			if err != nil || len(digest) != sha256.Size {
+				return nil, fmt.Errorf("line %d: invalid sha256 digest", i+1)
+			}
+			keys.sha256[[sha256.Size]byte(digest)] = label
+		case strings.HasPrefix(hash, "$argon2"):
+			key, err := parseArgon2Hash(hash)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			key.label = label
+			keys.argon2 = append(keys.argon2, key)
+		default:
+			return nil, fmt.Errorf("line %d: unsupported key hash, expected '%s<hex digest>' or an argon2 hash", i+1, sha256Prefix)
+		}
+	}
+
+	if len(keys.sha256) == 0 && len(keys.argon2) == 0 {
+		return nil, fmt.Errorf("file contains no keys")
+	}
+	return keys, nil
+}
+
+// verify returns the label of the key. Argon2 keys are verified through the
+// gate, at most once per key and request; it fails when ctx is done first.
+func (k *apiKeys) verify(ctx context.Context, gate slowHashGate, key string) (string, bool, error) {
+	digest := sha256.Sum256([]byte(key))
+	if label, ok := k.sha256[digest]; ok {
+		return label, true, nil
+	}
+	if len(k.argon2) == 0 {
+		return "", false, nil
+	}
+	if label, accepted, found := k.verified.lookup(digest); found {
+		return label, accepted, nil
+	}
+
+	var label string
+	var accepted bool
+	err := gate.run(ctx, func() {
+		for _, argon2Key := range k.argon2 {
+			if argon2Key.matches(key) {
+				label, accepted = argon2Key.label, true
+				return
+			}
+		}
+	})
+	if err != nil {
+		return "", false, err
+	}
+	k.verified.store(digest, label, accepted)
+	return label, accepted, nil
+}
+
+// matches reports whether the key hashes to the argon2 hash
+func (k *argon2Key) matches(key string) bool {
+	var hash []byte
+	if k.variant == "argon2id" {
+		hash = argon2.IDKey([]byte(key), k.salt, k.time, k.memory, k.threads, uint32(len(k.hash)))
+	} else {
+		hash = argon2.Key([]byte(key), k.salt, k.time, k.memory, k.threads, uint32(len(k.hash)))
+	}
+	return subtle.ConstantTimeCompare(hash, k.hash) == 1
+}
+
+// parseArgon2Hash parses an argon2i or argon2id hash in the PHC format
+func parseArgon2Hash(hash string) (*argon2Key, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" {
+		return nil, fmt.Errorf("invalid argon2 hash")
+	}
+
+	key := &argon2Key{variant: parts[1]}
+	if key.variant != "argon2id" && key.variant != "argon2i" {
+		return nil, fmt.Errorf("unsupported argon2 variant '%s', expected argon2id or argon2i", key.variant)
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, fmt.Errorf("unsupported argon2 version '%s', expected v=%d", parts[2], argon2.Version)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &key.memory, &key.time, &key.threads); err != nil {
+		return nil, fmt.Errorf("invalid argon2 parameters '%s'", parts[3])
+	}
+	if key.memory == 0 || key.time == 0 || key.threads == 0 {
+		return nil, fmt.Errorf("invalid argon2 parameters '%s'", parts[3])
+	}
+	if key.memory > maxArgon2Memory || key.time > maxArgon2Time || key.threads > maxArgon2Threads {
+		return nil, fmt.Errorf("argon2 parameters '%s' exceed the limits m=%d,t=%d,p=%d", parts[3], maxArgon2Memory, maxArgon2Time, maxArgon2Threads)
+	}
+
+	var err error
+	if key.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, fmt.Errorf("invalid argon2 salt")
+	}
+	if key.hash, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key.hash) == 0 {
+		return nil, fmt.Errorf("invalid argon2 hash")
+	}
+	if len(key.hash) > maxArgon2HashLength {
+		return nil, fmt.Errorf("argon2 hash longer than %d bytes", maxArgon2HashLength)
+	}
+	return key, nil
+}
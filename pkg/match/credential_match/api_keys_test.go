@@ -0,0 +1,118 @@
+package credential_match
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+func TestParseAPIKeys(t *testing.T) {
+	content := strings.Join([]string{
+		"# CI and partner keys",
+		sha256Hash("ci-secret") + " ci-deploy",
+		"",
+		argon2Hash("argon2id", "partner-secret") + " partner acme",
+		argon2Hash("argon2i", "legacy-secret") + " legacy",
+	}, "\n")
+
+	keys, err := parseAPIKeys([]byte(content))
+	if err != nil {
+		t.Fatalf("failed to parse keys: %v", err)
+	}
+
+	tests := []struct {
+		key   string
+		label string
+	}{
+		{key: "ci-secret", label: "ci-deploy"},
+		{key: "partner-secret", label: "partner acme"},
+		{key: "legacy-secret", label: "legacy"},
+		{key: "partner-secret", label: "partner acme"}, // cached
+		{key: "wrong-secret"},
+		{key: "wrong-secret"}, // cached
+		{key: ""},
+	}
+	for _, tt := range tests {
+		label, ok, err := keys.verify(context.Background(), newSlowHashGate(), tt.key)
+		if err != nil {
+			t.Fatalf("key %q: unexpected error: %v", tt.key, err)
+		}
+		if ok != (tt.label != "") || label != tt.label {
+			t.Fatalf("key %q: expected label %q, got %q (%v)", tt.key, tt.label, label, ok)
+		}
+	}
+}
+
+func TestParseAPIKeys_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "empty", content: "# no keys yet\n", wantErr: "file contains no keys"},
+		{name: "missing label", content: sha256Hash("secret"), wantErr: "line 1: a key hash followed by a label is required"},
+		{name: "plain text key", content: "secret ci", wantErr: "line 1: unsupported key hash"},
+		{name: "short digest", content: "sha256:abcd ci", wantErr: "line 1: invalid sha256 digest"},
+		{name: "argon2d", content: "$argon2d$v=19$m=64,t=1,p=1$c2FsdA$aGFzaA ci", wantErr: "unsupported argon2 variant 'argon2d'"},
+		{name: "argon2 version", content: "$argon2id$v=16$m=64,t=1,p=1$c2FsdA$aGFzaA ci", wantErr: "unsupported argon2 version 'v=16'"},
+		{name: "argon2 parameters", content: "$argon2id$v=19$m=0,t=1,p=1$c2FsdA$aGFzaA ci", wantErr: "invalid argon2 parameters 'm=0,t=1,p=1'"},
+		{name: "argon2 memory limit", content: "$argon2id$v=19$m=1048576,t=1,p=1$c2FsdA$aGFzaA ci", wantErr: "argon2 parameters 'm=1048576,t=1,p=1' exceed the limits m=65536,t=10,p=16"},
+		{name: "argon2 time limit", content: "$argon2id$v=19$m=64,t=100,p=1$c2FsdA$aGFzaA ci", wantErr: "exceed the limits"},
+		{name: "argon2 hash length limit", content: "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$" + base64.RawStdEncoding.EncodeToString(make([]byte, 128)) + " ci", wantErr: "argon2 hash longer than 64 bytes"},
+		{name: "argon2 salt", content: "$argon2id$v=19$m=64,t=1,p=1$!!$aGFzaA ci", wantErr: "invalid argon2 salt"},
+		{name: "argon2 format", content: "\n$argon2id$v=19 ci", wantErr: "line 2: invalid argon2 hash"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseAPIKeys([]byte(tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAPIKeysVerify_Gate(t *testing.T) {
+	keys, err := parseAPIKeys([]byte(sha256Hash("ci-secret") + " ci-deploy\n" + argon2Hash("argon2id", "partner-secret") + " partner acme"))
+	if err != nil {
+		t.Fatalf("failed to parse keys: %v", err)
+	}
+
+	// All slots taken: argon2 keys are not verified, sha256 keys are
+	gate := newSlowHashGate()
+	for range maxSlowHashes {
+		gate <- struct{}{}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, _, err := keys.verify(ctx, gate, "partner-secret"); err != context.Canceled {
+		t.Fatalf("expected the argon2 verification to wait for a slot, got %v", err)
+	}
+	if label, ok, err := keys.verify(ctx, gate, "ci-secret"); err != nil || !ok || label != "ci-deploy" {
+		t.Fatalf("expected sha256 keys to bypass the gate, got %q %v %v", label, ok, err)
+	}
+}
+
+func sha256Hash(key string) string {
+	digest := sha256.Sum256([]byte(key))
+	return sha256Prefix + hex.EncodeToString(digest[:])
+}
+
+func argon2Hash(variant, key string) string {
+	salt := []byte("0123456789abcdef")
+	var hash []byte
+	if variant == "argon2id" {
+		hash = argon2.IDKey([]byte(key), salt, 1, 64, 1, 32)
+	} else {
+		hash = argon2.Key([]byte(key), salt, 1, 64, 1, 32)
+	}
+	return fmt.Sprintf("$%s$v=%d$m=64,t=1,p=1$%s$%s", variant, argon2.Version,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hash))
+}
@@ -0,0 +1,119 @@
+package credential_match
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAPIKeyHeader    = "x-api-key"
+	defaultPrincipalHeader = "X-Authenticated-Principal"
+	defaultRealm           = "restricted"
+	defaultReloadInterval  = "30s"
+)
+
+// CredentialMatchConfig represents the configuration of a credential-match
+// controller. At least one of apiKey or basicAuth is configured.
+type CredentialMatchConfig struct {
+	APIKey          *APIKeyConfig    `yaml:"apiKey"`
+	BasicAuth       *BasicAuthConfig `yaml:"basicAuth"`
+	PrincipalHeader string           `yaml:"principalHeader"`
+	ReloadInterval  string           `yaml:"reloadInterval"`
+}
+
+// APIKeyConfig represents API keys read from a header and checked against a
+// file of hashed keys
+type APIKeyConfig struct {
+	Header string `yaml:"header"`
+	File   string `yaml:"file"`
+}
+
+// BasicAuthConfig represents Basic authentication checked against an
+// htpasswd file
+type BasicAuthConfig struct {
+	HtpasswdFile string `yaml:"htpasswdFile"`
+	Realm        string `yaml:"realm"`
+}
+
+// ApplyDefaults sets default values for the configuration
+func (c *CredentialMatchConfig) ApplyDefaults() {
+	if c.APIKey != nil && c.APIKey.Header == "" {
+		c.APIKey.Header = defaultAPIKeyHeader
+	}
+	if c.BasicAuth != nil && c.BasicAuth.Realm == "" {
+		c.BasicAuth.Realm = defaultRealm
+	}
+	if c.PrincipalHeader == "" {
+		c.PrincipalHeader = defaultPrincipalHeader
+	}
+	if c.ReloadInterval == "" {
+		c.ReloadInterval = defaultReloadInterval
+	}
+}
+
+// Validate checks the configuration for completeness. The content of the
+// credential files is checked when loaded.
+func (c *CredentialMatchConfig) Validate() error {
+	if c.APIKey == nil && c.BasicAuth == nil {
+		return fmt.Errorf("at least one of apiKey or basicAuth is required")
+	}
+
+	if c.APIKey != nil {
+		if strings.ContainsAny(c.APIKey.Header, " :\t\r\n") {
+			return fmt.Errorf("apiKey.header: invalid header name '%s'", c.APIKey.Header)
+		}
+		if strings.EqualFold(c.APIKey.Header, "authorization") {
+			return fmt.Errorf("apiKey.header must not be 'authorization', use basicAuth for Basic credentials")
+		}
+		if err := validateFile("apiKey.file", c.APIKey.File); err != nil {
+			return err
+		}
+	}
+
+	if c.BasicAuth != nil {
+		if err := validateFile("basicAuth.htpasswdFile", c.BasicAuth.HtpasswdFile); err != nil {
+			return err
+		}
+		if strings.ContainsAny(c.BasicAuth.Realm, "\"\r\n") {
+			return fmt.Errorf("basicAuth.realm must not contain quotes or line breaks")
+		}
+	}
+
+	if strings.ContainsAny(c.PrincipalHeader, " :\t\r\n") {
+		return fmt.Errorf("principalHeader: invalid header name '%s'", c.PrincipalHeader)
+	}
+
+	interval, err := time.ParseDuration(c.ReloadInterval)
+	if err != nil {
+		return fmt.Errorf("invalid reloadInterval: %w", err)
+	}
+	if interval <= 0 {
+		return fmt.Errorf("reloadInterval must be positive")
+	}
+
+	return nil
+}
+
+// GetReloadInterval returns the parsed delay between checks of the
+// credential files for changes
+func (c *CredentialMatchConfig) GetReloadInterval() time.Duration {
+	interval, _ := time.ParseDuration(c.ReloadInterval)
+	return interval
+}
+
+// validateFile checks that a credential file setting points to a readable file
+func validateFile(setting, path string) error {
+	if path == "" {
+		return fmt.Errorf("%s is required", setting)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("%s is not readable: %w", setting, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s '%s' is a directory", setting, path)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package credential_match
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCredentialMatchConfigValidate(t *testing.T) {
+	dir := t.TempDir()
+	keysFile := filepath.Join(dir, "api-keys.txt")
+	if err := os.WriteFile(keysFile, []byte("sha256:00 ci\n"), 0o600); err != nil {
+		t.Fatalf("failed to write keys file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		config  CredentialMatchConfig
+		wantErr string
+	}{
+		{
+			name:   "api key",
+			config: CredentialMatchConfig{APIKey: &APIKeyConfig{File: keysFile}},
+		},
+		{
+			name:   "api key and basic auth",
+			config: CredentialMatchConfig{APIKey: &APIKeyConfig{Header: "X-Admin-Key", File: keysFile}, BasicAuth: &BasicAuthConfig{HtpasswdFile: keysFile, Realm: "Admin console"}, ReloadInterval: "5s"},
+		},
+		{
+			name:    "no credentials",
+			config:  CredentialMatchConfig{},
+			wantErr: "at least one of apiKey or basicAuth is required",
+		},
+		{
+			name:    "invalid api key header",
+			config:  CredentialMatchConfig{APIKey: &APIKeyConfig{Header: "x api key", File: keysFile}},
+			wantErr: "apiKey.header: invalid header name 'x api key'",
+		},
+		{
+			name:    "api key in authorization header",
+			config:  CredentialMatchConfig{APIKey: &APIKeyConfig{Header: "Authorization", File: keysFile}},
+			wantErr: "apiKey.header must not be 'authorization'",
+		},
+		{
+			name:    "missing api keys file",
+			config:  CredentialMatchConfig{APIKey: &APIKeyConfig{}},
+			wantErr: "apiKey.file is required",
+		},
+		{
+			name:    "unreadable api keys file",
+			config:  CredentialMatchConfig{APIKey: &APIKeyConfig{File: filepath.Join(dir, "missing.txt")}},
+			wantErr: "apiKey.file is not readable",
+		},
+		{
+			name:    "htpasswd directory",
+			config:  CredentialMatchConfig{BasicAuth: &BasicAuthConfig{HtpasswdFile: dir}},
+			wantErr: "is a directory",
+		},
+		{
+			name:    "realm with quotes",
+			config:  CredentialMatchConfig{BasicAuth: &BasicAuthConfig{HtpasswdFile: keysFile, Realm: `say "hi"`}},
+			wantErr: "basicAuth.realm must not contain quotes or line breaks",
+		},
+		{
+			name:    "invalid principal header",
+			config:  CredentialMatchConfig{APIKey: &APIKeyConfig{File: keysFile}, PrincipalHeader: "X-Principal:"},
+			wantErr: "principalHeader: invalid header name",
+		},
+		{
+			name:    "invalid reload interval",
+			config:  CredentialMatchConfig{APIKey: &APIKeyConfig{File: keysFile}, ReloadInterval: "often"},
+			wantErr: "invalid reloadInterval",
+		},
+		{
+			name:    "non-positive reload interval",
+			config:  CredentialMatchConfig{APIKey: &APIKeyConfig{File: keysFile}, ReloadInterval: "0s"},
+			wantErr: "reloadInterval must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.ApplyDefaults()
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package credential_match
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+const (
+	ControllerKind = "credential-match"
+)
+
+const (
+	// CredentialTypeAPIKey is reported for requests authenticated by API key
+	CredentialTypeAPIKey = "api-key"
+	// CredentialTypeBasic is reported for requests authenticated by Basic auth
+	CredentialTypeBasic = "basic"
+)
+
+// init registers the credential-match match controller so it can be
+// constructed from configuration at runtime.
+func init() {
+	controller.RegisterMatchControllerFactory(ControllerKind, newCredentialMatchController)
+}
+
+type credentialMatchController struct {
+	name            string
+	apiKeyHeader    string
+	apiKeys         *credentialFile[*apiKeys]  // nil when apiKey is not configured
+	htpasswd        *credentialFile[*htpasswd] // nil when basicAuth is not configured
+	realm           string
+	principalHeader string
+	gate            slowHashGate
+}
+
+// Match implements controller.MatchController. The request matches when it
+// carries a valid API key or valid Basic credentials. Secrets are never
+// reported.
+func (c *credentialMatchController) Match(ctx context.Context, req *runtime.RequestContext, reports controller.AnalysisReports) (*controller.MatchVerdict, error) {
+	if c.apiKeys != nil {
+		if key := req.Header(c.apiKeyHeader); key != "" {
+			label, ok, err := c.apiKeys.Content().verify(ctx, c.gate, key)
+			if err != nil {
+				return nil, fmt.Errorf("could not verify API key: %w", err)
+			}
+			if ok {
+				return c.createMatchVerdict(CredentialTypeAPIKey, label), nil
+			}
+			return c.createUnauthenticatedVerdict("API key rejected"), nil
+		}
+	}
+
+	if c.htpasswd != nil {
+		if user, password, ok := basicCredentials(req.Header("authorization")); ok {
+			ok, err := c.htpasswd.Content().verify(ctx, c.gate, user, password)
+			if err != nil {
+				return nil, fmt.Errorf("could not verify basic credentials of user '%s': %w", user, err)
+			}
+			if ok {
+				return c.createMatchVerdict(CredentialTypeBasic, user), nil
+			}
+			return c.createUnauthenticatedVerdict(fmt.Sprintf("basic credentials of user '%s' rejected", user)), nil
+		}
+	}
+
+	// Requests without credentials are challenged for them, not offending
+	verdict := c.createUnauthenticatedVerdict("credentials missing")
+	verdict.DenyChallenge = true
+	return verdict, nil
+}
+
+// Name implements controller.MatchController.
+func (c *credentialMatchController) Name() string {
+	return c.name
+}
+
+// Kind implements controller.MatchController.
+func (c *credentialMatchController) Kind() string {
+	return ControllerKind
+}
+
+// HealthCheck implements controller.MatchController. It fails while a
+// credential file cannot be reloaded.
+func (c *credentialMatchController) HealthCheck(ctx context.Context) error {
+	var errs []error
+	if c.apiKeys != nil {
+		errs = append(errs, c.apiKeys.HealthCheck())
+	}
+	if c.htpasswd != nil {
+		errs = append(errs, c.htpasswd.HealthCheck())
+	}
+	return errors.Join(errs...)
+}
+
+// createMatchVerdict builds the verdict of an authenticated request,
+// forwarding the principal upstream
+func (c *credentialMatchController) createMatchVerdict(credentialType, principal string) *controller.MatchVerdict {
+	return &controller.MatchVerdict{
+		Controller:           c.name,
+		ControllerType:       ControllerKind,
+		DenyCode:             codes.Unauthenticated,
+		Description:          fmt.Sprintf("%s credentials of '%s' accepted", credentialType, principal),
+		IsMatch:              true,
+		AllowUpstreamHeaders: c.makeUpstreamHeaders(principal),
+		LogFields: []zap.Field{
+			zap.String("credential.type", credentialType),
+			zap.String("credential.principal", principal),
+		},
+	}
+}
+
+// createUnauthenticatedVerdict builds the verdict of requests without valid
+// credentials, challenging clients for Basic credentials when accepted
+func (c *credentialMatchController) createUnauthenticatedVerdict(description string) *controller.MatchVerdict {
+	verdict := &controller.MatchVerdict{
+		Controller:           c.name,
+		ControllerType:       ControllerKind,
+		DenyCode:             codes.Unauthenticated,
+		DenyMessage:          "unauthenticated",
+		Description:          description,
+		IsMatch:              false,
+		AllowUpstreamHeaders: c.makeUpstreamHeaders(""),
+	}
+	if c.htpasswd != nil {
+		verdict.DenyDownstreamHeaders = map[string]string{
+			"WWW-Authenticate": fmt.Sprintf(`Basic realm="%s", charset="UTF-8"`, c.realm),
+		}
+	}
+	return verdict
+}
+
+// makeUpstreamHeaders sets the principal header forwarded upstream. The
+// header is set on every verdict, empty for requests without accepted
+// credentials, so that the header sent by clients never reaches the upstream.
+func (c *credentialMatchController) makeUpstreamHeaders(principal string) map[string]string {
+	return map[string]string{c.principalHeader: principal}
+}
+
+// basicCredentials decodes the user and password of a Basic Authorization
+// header value
+func basicCredentials(authorization string) (string, string, bool) {
+	scheme, encoded, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "basic") {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", "", false
+	}
+	user, password, ok := strings.Cut(string(decoded), ":")
+	if !ok || user == "" {
+		return "", "", false
+	}
+	return user, password, true
+}
+
+// newCredentialMatchController loads the credential files and prepares a
+// controller. The files are reloaded in the background when they change.
+func newCredentialMatchController(ctx context.Context, logger *zap.Logger, cfg config.ControllerConfig) (controller.MatchController, error) {
+	var credentialMatchConfig CredentialMatchConfig
+	if err := controller.DecodeControllerSettings(cfg.Settings, &credentialMatchConfig); err != nil {
+		return nil, err
+	}
+	credentialMatchConfig.ApplyDefaults()
+	if err := credentialMatchConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	ctrl := &credentialMatchController{
+		name:            cfg.Name,
+		principalHeader: credentialMatchConfig.PrincipalHeader,
+		gate:            newSlowHashGate(),
+	}
+	reloadInterval := credentialMatchConfig.GetReloadInterval()
+
+	if apiKeyConfig := credentialMatchConfig.APIKey; apiKeyConfig != nil {
+		file, err := loadCredentialFile(ctx, apiKeyConfig.File, reloadInterval, parseAPIKeys, logger)
+		if err != nil {
+			return nil, fmt.Errorf("apiKey.file: %w", err)
+		}
+		ctrl.apiKeyHeader = strings.ToLower(apiKeyConfig.Header)
+		ctrl.apiKeys = file
+	}
+
+	if basicAuthConfig := credentialMatchConfig.BasicAuth; basicAuthConfig != nil {
+		file, err := loadCredentialFile(ctx, basicAuthConfig.HtpasswdFile, reloadInterval, parseHtpasswd, logger)
+		if err != nil {
+			return nil, fmt.Errorf("basicAuth.htpasswdFile: %w", err)
+		}
+		ctrl.htpasswd = file
+		ctrl.realm = basicAuthConfig.Realm
+	}
+
+	logger.Info("controller initialized",
+		zap.Bool("api_key", ctrl.apiKeys != nil),
+		zap.Bool("basic_auth", ctrl.htpasswd != nil),
+	)
+
+	return ctrl, nil
+}
@@ -0,0 +1,214 @@
+package credential_match
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+func TestMatch_Credentials(t *testing.T) {
+	dir := t.TempDir()
+	keysFile := writeFile(t, dir, "api-keys.txt", sha256Hash("ci-secret")+" ci-deploy\n")
+	htpasswdFile := writeFile(t, dir, "admins.htpasswd", htpasswdLine(t, "alice", "wonderland")+"\n")
+
+	ctrl := buildController(t, map[string]any{
+		"apiKey":    map[string]any{"file": keysFile},
+		"basicAuth": map[string]any{"htpasswdFile": htpasswdFile, "realm": "Admin console"},
+	})
+
+	tests := []struct {
+		name        string
+		headers     map[string]string
+		match       bool
+		principal   string
+		description string
+	}{
+		{name: "api key", headers: map[string]string{"X-API-Key": "ci-secret"}, match: true, principal: "ci-deploy", description: "api-key credentials of 'ci-deploy' accepted"},
+		{name: "basic auth", headers: map[string]string{"authorization": basicAuth("alice", "wonderland")}, match: true, principal: "alice", description: "basic credentials of 'alice' accepted"},
+		{name: "invalid api key", headers: map[string]string{"x-api-key": "guess", "authorization": basicAuth("alice", "wonderland")}, description: "API key rejected"},
+		{name: "invalid password", headers: map[string]string{"authorization": basicAuth("alice", "guess")}, description: "basic credentials of user 'alice' rejected"},
+		{name: "bearer token", headers: map[string]string{"authorization": "Bearer abc"}, description: "credentials missing"},
+		{name: "no credentials", headers: map[string]string{"accept": "*/*"}, description: "credentials missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict := match(t, ctrl, tt.headers)
+			if verdict.IsMatch != tt.match || verdict.Description != tt.description {
+				t.Fatalf("expected IsMatch=%v %q, got %v %q", tt.match, tt.description, verdict.IsMatch, verdict.Description)
+			}
+			// Every verdict overwrites the principal header sent by clients
+			if principal, ok := verdict.AllowUpstreamHeaders["X-Authenticated-Principal"]; !ok || principal != tt.principal {
+				t.Fatalf("expected principal header %q, got %v", tt.principal, verdict.AllowUpstreamHeaders)
+			}
+			if tt.match {
+				return
+			}
+			if verdict.DenyCode != codes.Unauthenticated {
+				t.Fatalf("expected Unauthenticated, got %v", verdict.DenyCode)
+			}
+			if verdict.DenyChallenge != (tt.description == "credentials missing") {
+				t.Fatalf("expected only missing credentials to be challenged, got %v", verdict.DenyChallenge)
+			}
+			if challenge := verdict.DenyDownstreamHeaders["WWW-Authenticate"]; challenge != `Basic realm="Admin console", charset="UTF-8"` {
+				t.Fatalf("unexpected challenge %q", challenge)
+			}
+		})
+	}
+
+	for _, secret := range []string{"ci-secret", "wonderland", "guess"} {
+		for _, verdict := range []*controller.MatchVerdict{
+			match(t, ctrl, map[string]string{"x-api-key": secret}),
+			match(t, ctrl, map[string]string{"authorization": basicAuth("alice", secret)}),
+		} {
+			for _, field := range verdict.LogFields {
+				if strings.Contains(field.String, secret) {
+					t.Fatalf("secret %q logged in field %s", secret, field.Key)
+				}
+			}
+			if strings.Contains(verdict.Description, secret) {
+				t.Fatalf("secret %q reported in description %q", secret, verdict.Description)
+			}
+		}
+	}
+}
+
+func TestMatch_APIKeyOnly(t *testing.T) {
+	keysFile := writeFile(t, t.TempDir(), "api-keys.txt", sha256Hash("ci-secret")+" ci-deploy\n")
+	ctrl := buildController(t, map[string]any{
+		"apiKey":          map[string]any{"header": "X-Admin-Key", "file": keysFile},
+		"principalHeader": "X-Admin",
+	})
+
+	verdict := match(t, ctrl, map[string]string{"x-admin-key": "ci-secret"})
+	if !verdict.IsMatch || verdict.AllowUpstreamHeaders["X-Admin"] != "ci-deploy" {
+		t.Fatalf("expected api key to match with principal header, got %v: %s", verdict.AllowUpstreamHeaders, verdict.Description)
+	}
+
+	verdict = match(t, ctrl, map[string]string{"authorization": basicAuth("alice", "wonderland")})
+	if verdict.IsMatch || len(verdict.DenyDownstreamHeaders) != 0 {
+		t.Fatalf("expected basic credentials to be ignored without challenge, got %v: %s", verdict.DenyDownstreamHeaders, verdict.Description)
+	}
+	if principal, ok := verdict.AllowUpstreamHeaders["X-Admin"]; !ok || principal != "" {
+		t.Fatalf("expected an empty principal header, got %v", verdict.AllowUpstreamHeaders)
+	}
+}
+
+func TestMatch_ReloadsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	keysFile := writeFile(t, dir, "api-keys.txt", sha256Hash("old-secret")+" ci-deploy\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctrl, err := newCredentialMatchController(ctx, zap.NewNop(), config.ControllerConfig{
+		Name:     "admin-credentials",
+		Type:     ControllerKind,
+		Settings: map[string]any{"apiKey": map[string]any{"file": keysFile}, "reloadInterval": "10ms"},
+	})
+	if err != nil {
+		t.Fatalf("failed to build controller: %v", err)
+	}
+
+	writeFile(t, dir, "api-keys.txt", sha256Hash("new-secret")+" ci-deploy rotated\n")
+	waitFor(t, func() bool { return match(t, ctrl, map[string]string{"x-api-key": "new-secret"}).IsMatch })
+	if match(t, ctrl, map[string]string{"x-api-key": "old-secret"}).IsMatch {
+		t.Fatalf("expected the rotated key to be revoked")
+	}
+
+	writeFile(t, dir, "api-keys.txt", "not a valid line\n")
+	waitFor(t, func() bool { return ctrl.HealthCheck(context.Background()) != nil })
+	if !match(t, ctrl, map[string]string{"x-api-key": "new-secret"}).IsMatch {
+		t.Fatalf("expected previous keys to stay in use when the file is invalid")
+	}
+
+	writeFile(t, dir, "api-keys.txt", sha256Hash("new-secret")+" ci-deploy\n")
+	waitFor(t, func() bool { return ctrl.HealthCheck(context.Background()) == nil })
+}
+
+func TestNewCredentialMatchController_Errors(t *testing.T) {
+	dir := t.TempDir()
+	invalidKeys := writeFile(t, dir, "api-keys.txt", "plain-key ci\n")
+	invalidHtpasswd := writeFile(t, dir, "admins.htpasswd", "alice:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n")
+
+	tests := []struct {
+		name     string
+		settings map[string]any
+		wantErr  string
+	}{
+		{name: "invalid settings", settings: map[string]any{}, wantErr: "configuration validation failed: at least one of apiKey or basicAuth is required"},
+		{name: "invalid keys file", settings: map[string]any{"apiKey": map[string]any{"file": invalidKeys}}, wantErr: "apiKey.file: " + invalidKeys + " is not valid: line 1: unsupported key hash"},
+		{name: "invalid htpasswd file", settings: map[string]any{"basicAuth": map[string]any{"htpasswdFile": invalidHtpasswd}}, wantErr: "basicAuth.htpasswdFile: " + invalidHtpasswd + " is not valid: line 1: user 'alice' does not have a bcrypt password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newCredentialMatchController(context.Background(), zap.NewNop(), config.ControllerConfig{Name: "admin-credentials", Type: ControllerKind, Settings: tt.settings})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	location := filepath.Join(dir, name)
+	if err := os.WriteFile(location, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return location
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met before deadline")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func basicAuth(user, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+}
+
+func buildController(t *testing.T, settings map[string]any) *credentialMatchController {
+	t.Helper()
+	ctrl, err := newCredentialMatchController(context.Background(), zap.NewNop(), config.ControllerConfig{
+		Name:     "admin-credentials",
+		Type:     ControllerKind,
+		Settings: settings,
+	})
+	if err != nil {
+		t.Fatalf("failed to build controller: %v", err)
+	}
+	return ctrl.(*credentialMatchController)
+}
+
+func match(t *testing.T, ctrl controller.MatchController, headers map[string]string) *controller.MatchVerdict {
+	t.Helper()
+	req := &authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{
+			Request: &authv3.AttributeContext_Request{
+				Http: &authv3.AttributeContext_HttpRequest{Headers: headers},
+			},
+		},
+	}
+	verdict, err := ctrl.Match(context.Background(), runtime.NewRequestContext(req), nil)
+	if err != nil {
+		t.Fatalf("match returned error: %v", err)
+	}
+	return verdict
+}
@@ -0,0 +1,114 @@
+package credential_match
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// credentialFile is a credential file parsed into T and reloaded when it
+// changes. On failure the previous content stays in use.
+type credentialFile[T any] struct {
+	path   string
+	parse  func(content []byte) (T, error)
+	logger *zap.Logger
+
+	mu        sync.RWMutex
+	content   T
+	modTime   time.Time
+	size      int64
+	reloadErr error
+}
+
+// loadCredentialFile reads and parses the file, then checks it for changes
+// every interval until ctx is done
+func loadCredentialFile[T any](ctx context.Context, path string, interval time.Duration, parse func([]byte) (T, error), logger *zap.Logger) (*credentialFile[T], error) {
+	f := &credentialFile[T]{
+		path:   path,
+		parse:  parse,
+		logger: logger.With(zap.String("file", path)),
+	}
+	if err := f.reload(true); err != nil {
+		return nil, err
+	}
+
+	go f.watch(ctx, interval)
+	return f, nil
+}
+
+// Content returns the last successfully parsed content
+func (f *credentialFile[T]) Content() T {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.content
+}
+
+// HealthCheck fails when the last reload failed
+func (f *credentialFile[T]) HealthCheck() error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.reloadErr
+}
+
+// watch reloads the file every interval until ctx is done
+func (f *credentialFile[T]) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := f.reload(false); err != nil {
+				f.logger.Warn("could not reload credential file, keeping previous credentials", zap.Error(err))
+			}
+		}
+	}
+}
+
+// reload reads the file again if its modification time or size changed, or
+// if force is set
+func (f *credentialFile[T]) reload(force bool) error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return f.setReloadErr(fmt.Errorf("could not read %s: %w", f.path, err))
+	}
+
+	f.mu.RLock()
+	unchanged := info.ModTime().Equal(f.modTime) && info.Size() == f.size
+	f.mu.RUnlock()
+	if !force && unchanged {
+		return f.setReloadErr(nil)
+	}
+
+	raw, err := os.ReadFile(f.path)
+	if err != nil {
+		return f.setReloadErr(fmt.Errorf("could not read %s: %w", f.path, err))
+	}
+	content, err := f.parse(raw)
+	if err != nil {
+		return f.setReloadErr(fmt.Errorf("%s is not valid: %w", f.path, err))
+	}
+
+	f.mu.Lock()
+	f.content, f.modTime, f.size, f.reloadErr = content, info.ModTime(), info.Size(), nil
+	f.mu.Unlock()
+
+	if !force {
+		f.logger.Info("credential file reloaded")
+	}
+	return nil
+}
+
+// setReloadErr records the outcome of the last reload
+func (f *credentialFile[T]) setReloadErr(err error) error {
+	f.mu.Lock()
+	f.reloadErr = err
+	f.mu.Unlock()
+	return err
+}
@@ -0,0 +1,89 @@
+package credential_match
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// htpasswd is the content of an htpasswd file: one user:hash line per user,
+// with bcrypt hashes as created by 'htpasswd -B'
+type htpasswd struct {
+	users map[string][]byte
+	// unknownUser is the hash of a random password, with the highest cost of
+	// the file, verified for unknown users so that the response time does not
+	// reveal which users exist
+	unknownUser []byte
+	// verified caches the bcrypt verifications, as bcrypt is too slow to run
+	// on every request
+	verified *verificationCache
+}
+
+// parseHtpasswd parses an htpasswd file, rejecting hashes other than bcrypt
+func parseHtpasswd(content []byte) (*htpasswd, error) {
+	file := &htpasswd{
+		users:    make(map[string][]byte),
+		verified: newVerificationCache(),
+	}
+	maxCost := bcrypt.MinCost
+
+	for i, rawLine := range strings.Split(string(content), "\n") {
+		line := strings.TrimSpace(rawLine)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user, hash, ok := strings.Cut(line, ":")
+		if !ok || user == "" {
+			return nil, fmt.Errorf("line %d: a user:hash entry is required", i+1)
+		}
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: user '%s' does not have a bcrypt password, create it with 'htpasswd -B'", i+1, user)
+		}
+		maxCost = max(maxCost, cost)
+		if _, exists := file.users[user]; exists {
+			return nil, fmt.Errorf("line %d: user '%s' is listed more than once", i+1, user)
+		}
+		file.users[user] = []byte(hash)
+	}
+
+	if len(file.users) == 0 {
+		return nil, fmt.Errorf("file contains no users")
+	}
+
+	unknownUser, err := bcrypt.GenerateFromPassword([]byte(rand.Text()), maxCost)
+	if err != nil {
+		return nil, fmt.Errorf("could not hash the password of unknown users: %w", err)
+	}
+	file.unknownUser = unknownUser
+	return file, nil
+}
+
+// verify reports whether the password is the one of the user. The password
+// is verified through the gate, against a dummy hash for unknown users so
+// that both cost the same; it fails when ctx is done first.
+func (h *htpasswd) verify(ctx context.Context, gate slowHashGate, user, password string) (bool, error) {
+	hash, known := h.users[user]
+	if !known {
+		hash = h.unknownUser
+	}
+
+	digest := sha256.Sum256([]byte(user + ":" + password))
+	if _, accepted, found := h.verified.lookup(digest); found {
+		return accepted, nil
+	}
+
+	var accepted bool
+	err := gate.run(ctx, func() {
+		accepted = bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil && known
+	})
+	if err != nil {
+		return false, err
+	}
+	h.verified.store(digest, user, accepted)
+	return accepted, nil
+}
@@ -0,0 +1,97 @@
+package credential_match
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestParseHtpasswd(t *testing.T) {
+	file, err := parseHtpasswd([]byte("# Admin console\n" + htpasswdLine(t, "alice", "wonderland") + "\n" + htpasswdLine(t, "bob", "builder") + "\n"))
+	if err != nil {
+		t.Fatalf("failed to parse htpasswd: %v", err)
+	}
+
+	tests := []struct {
+		user     string
+		password string
+		valid    bool
+	}{
+		{user: "alice", password: "wonderland", valid: true},
+		{user: "alice", password: "wonderland", valid: true}, // cached
+		{user: "bob", password: "builder", valid: true},
+		{user: "alice", password: "builder"},
+		{user: "alice", password: "builder"}, // cached
+		{user: "carol", password: "wonderland"},
+	}
+	for _, tt := range tests {
+		got, err := file.verify(context.Background(), newSlowHashGate(), tt.user, tt.password)
+		if err != nil {
+			t.Fatalf("%s:%s unexpected error: %v", tt.user, tt.password, err)
+		}
+		if got != tt.valid {
+			t.Fatalf("%s:%s expected %v, got %v", tt.user, tt.password, tt.valid, got)
+		}
+	}
+}
+
+func TestHtpasswdVerify_UnknownUser(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("wonderland"), bcrypt.MinCost+1)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	file, err := parseHtpasswd([]byte(htpasswdLine(t, "bob", "builder") + "\nalice:" + string(hash)))
+	if err != nil {
+		t.Fatalf("failed to parse htpasswd: %v", err)
+	}
+	if cost, err := bcrypt.Cost(file.unknownUser); err != nil || cost != bcrypt.MinCost+1 {
+		t.Fatalf("expected unknown users to be verified with the highest cost, got %d (%v)", cost, err)
+	}
+
+	// Unknown users take a verification slot like known users
+	gate := newSlowHashGate()
+	for range maxSlowHashes {
+		gate <- struct{}{}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, user := range []string{"alice", "carol"} {
+		if _, err := file.verify(ctx, gate, user, "guess"); err != context.Canceled {
+			t.Fatalf("expected %s to wait for a verification slot, got %v", user, err)
+		}
+	}
+}
+
+func TestParseHtpasswd_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "empty", content: "\n", wantErr: "file contains no users"},
+		{name: "missing hash", content: "alice", wantErr: "line 1: a user:hash entry is required"},
+		{name: "md5 hash", content: "alice:$apr1$r31.....$HqJZimcKQFAMYayBlzkrA/", wantErr: "line 1: user 'alice' does not have a bcrypt password"},
+		{name: "sha1 hash", content: "alice:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=", wantErr: "does not have a bcrypt password"},
+		{name: "duplicate user", content: htpasswdLine(t, "alice", "a") + "\n" + htpasswdLine(t, "alice", "b"), wantErr: "line 2: user 'alice' is listed more than once"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseHtpasswd([]byte(tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func htpasswdLine(t *testing.T, user, password string) string {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	// htpasswd -B writes the $2y$ prefix
+	return user + ":" + strings.Replace(string(hash), "$2a$", "$2y$", 1)
+}
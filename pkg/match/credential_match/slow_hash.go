@@ -0,0 +1,99 @@
+package credential_match
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+const (
+	// maxSlowHashes bounds the argon2 and bcrypt verifications a controller
+	// runs at once
+	maxSlowHashes = 4
+	// rejectionTTL is how long rejected credentials are remembered
+	rejectionTTL = time.Minute
+	// maxRejections bounds the number of rejected credentials remembered
+	maxRejections = 10000
+)
+
+// slowHashGate bounds the concurrent argon2 and bcrypt verifications of a
+// controller, as each takes tens of milliseconds and up to 64 MiB of memory
+type slowHashGate chan struct{}
+
+// newSlowHashGate returns a gate running up to maxSlowHashes verifications
+func newSlowHashGate() slowHashGate {
+	return make(slowHashGate, maxSlowHashes)
+}
+
+// run waits for a free slot, then runs verify. It fails when ctx is done
+// before a slot is free.
+func (g slowHashGate) run(ctx context.Context, verify func()) error {
+	select {
+	case g <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-g }()
+
+	verify()
+	return nil
+}
+
+// verificationCache remembers the outcome of slow verifications by digest of
+// the credentials: accepted ones until the file is reloaded, rejected ones for
+// rejectionTTL, so that repeated credentials are never verified again
+type verificationCache struct {
+	mu       sync.Mutex
+	accepted map[[sha256.Size]byte]string // digest -> label
+	rejected map[[sha256.Size]byte]time.Time
+}
+
+// newVerificationCache returns an empty cache
+func newVerificationCache() *verificationCache {
+	return &verificationCache{
+		accepted: make(map[[sha256.Size]byte]string),
+		rejected: make(map[[sha256.Size]byte]time.Time),
+	}
+}
+
+// lookup returns the label and outcome of credentials verified before
+func (c *verificationCache) lookup(digest [sha256.Size]byte) (label string, accepted bool, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if label, ok := c.accepted[digest]; ok {
+		return label, true, true
+	}
+	if expiry, ok := c.rejected[digest]; ok {
+		if time.Now().Before(expiry) {
+			return "", false, true
+		}
+		delete(c.rejected, digest)
+	}
+	return "", false, false
+}
+
+// store records the outcome of a verification
+func (c *verificationCache) store(digest [sha256.Size]byte, label string, accepted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if accepted {
+		c.accepted[digest] = label
+		return
+	}
+
+	now := time.Now()
+	if len(c.rejected) >= maxRejections {
+		for rejectedDigest, expiry := range c.rejected {
+			if now.After(expiry) {
+				delete(c.rejected, rejectedDigest)
+			}
+		}
+		if len(c.rejected) >= maxRejections {
+			clear(c.rejected)
+		}
+	}
+	c.rejected[digest] = now.Add(rejectionTTL)
+}
@@ -0,0 +1,41 @@
+package credential_match
+
+import (
+	"crypto/sha256"
+	"testing"
+	"time"
+)
+
+func TestVerificationCache(t *testing.T) {
+	cache := newVerificationCache()
+	accepted := sha256.Sum256([]byte("accepted"))
+	rejected := sha256.Sum256([]byte("rejected"))
+
+	if _, _, found := cache.lookup(accepted); found {
+		t.Fatalf("expected an empty cache")
+	}
+
+	cache.store(accepted, "ci-deploy", true)
+	cache.store(rejected, "", false)
+	if label, ok, found := cache.lookup(accepted); !found || !ok || label != "ci-deploy" {
+		t.Fatalf("expected accepted credentials, got %q %v %v", label, ok, found)
+	}
+	if _, ok, found := cache.lookup(rejected); !found || ok {
+		t.Fatalf("expected rejected credentials, got %v %v", ok, found)
+	}
+
+	cache.rejected[rejected] = time.Now().Add(-time.Second)
+	if _, _, found := cache.lookup(rejected); found {
+		t.Fatalf("expected rejected credentials to expire")
+	}
+}
+
+func TestVerificationCache_MaxRejections(t *testing.T) {
+	cache := newVerificationCache()
+	for i := range maxRejections + 10 {
+		cache.store(sha256.Sum256([]byte{byte(i), byte(i >> 8), byte(i >> 16)}), "", false)
+	}
+	if len(cache.rejected) > maxRejections {
+		t.Fatalf("expected at most %d rejections, got %d", maxRejections, len(cache.rejected))
+	}
+}
@@ -0,0 +1,161 @@
+package geo_match
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	countryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+	regionCodePattern  = regexp.MustCompile(`^[A-Z]{2}-[A-Z0-9]{1,3}$`)
+
+	// continentNames maps the continent codes of the GeoIP database to names
+	continentNames = map[string]string{
+		"AF": "Africa",
+		"AN": "Antarctica",
+		"AS": "Asia",
+		"EU": "Europe",
+		"NA": "North America",
+		"OC": "Oceania",
+		"SA": "South America",
+	}
+)
+
+// GeoMatchConfig represents the configuration of a geo-match controller.
+// Requests match when their location matches at least one configured value.
+type GeoMatchConfig struct {
+	Source               string   `yaml:"source"`
+	Countries            []string `yaml:"countries"`
+	CountriesFile        string   `yaml:"countriesFile"`
+	Presets              []string `yaml:"presets"`
+	Continents           []string `yaml:"continents"`
+	ContinentsFile       string   `yaml:"continentsFile"`
+	Regions              []string `yaml:"regions"`
+	RegionsFile          string   `yaml:"regionsFile"`
+	Cities               []string `yaml:"cities"`
+	CitiesFile           string   `yaml:"citiesFile"`
+	PostalCodes          []string `yaml:"postalCodes"`
+	PostalCodesFile      string   `yaml:"postalCodesFile"`
+	MatchesOnMissingData bool     `yaml:"matchesOnMissingData"`
+}
+
+// Validate checks the configuration for completeness. List files are checked
+// when loaded.
+func (c *GeoMatchConfig) Validate() error {
+	if len(c.Countries) == 0 && c.CountriesFile == "" && len(c.Presets) == 0 &&
+		len(c.Continents) == 0 && c.ContinentsFile == "" &&
+		len(c.Regions) == 0 && c.RegionsFile == "" &&
+		len(c.Cities) == 0 && c.CitiesFile == "" &&
+		len(c.PostalCodes) == 0 && c.PostalCodesFile == "" {
+		return fmt.Errorf("at least one of countries, presets, continents, regions, cities or postalCodes is required")
+	}
+
+	for i, name := range c.Presets {
+		if _, ok := presets[name]; !ok {
+			return fmt.Errorf("presets[%d]: unknown preset '%s', expected one of '%s'", i, name, strings.Join(presetNames(), "', '"))
+		}
+	}
+
+	lists := []struct {
+		setting string
+		values  []string
+		parse   func(string) error
+	}{
+		{setting: "countries", values: c.Countries, parse: func(value string) error { _, err := parseCountry(value); return err }},
+		{setting: "continents", values: c.Continents, parse: func(value string) error { _, err := parseContinent(value); return err }},
+		{setting: "regions", values: c.Regions, parse: func(value string) error { _, err := parseRegion(value); return err }},
+		{setting: "cities", values: c.Cities, parse: func(value string) error { _, err := parseCity(value); return err }},
+		{setting: "postalCodes", values: c.PostalCodes, parse: func(value string) error { _, err := parsePostalCode(value); return err }},
+	}
+	for _, list := range lists {
+		for i, value := range list.values {
+			if err := list.parse(value); err != nil {
+				return fmt.Errorf("%s[%d]: %w", list.setting, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// parseCountry normalizes an ISO 3166-1 alpha-2 country code
+func parseCountry(value string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(value))
+	if !countryCodePattern.MatchString(code) {
+		return "", fmt.Errorf("invalid country code '%s', expected an ISO 3166-1 alpha-2 code like 'DE'", value)
+	}
+	return code, nil
+}
+
+// parseContinent normalizes a continent code of the GeoIP database
+func parseContinent(value string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(value))
+	if _, ok := continentNames[code]; !ok {
+		codes := make([]string, 0, len(continentNames))
+		for continentCode := range continentNames {
+			codes = append(codes, continentCode)
+		}
+		slices.Sort(codes)
+		return "", fmt.Errorf("invalid continent code '%s', expected one of '%s'", value, strings.Join(codes, "', '"))
+	}
+	return code, nil
+}
+
+// parseRegion normalizes an ISO 3166-2 subdivision code
+func parseRegion(value string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(value))
+	if !regionCodePattern.MatchString(code) {
+		return "", fmt.Errorf("invalid region code '%s', expected an ISO 3166-2 code like 'US-CA'", value)
+	}
+	return code, nil
+}
+
+// qualifiedValue is a city name or postal code pattern, optionally restricted
+// to a country with a 'CC:' prefix
+type qualifiedValue struct {
+	country string
+	value   string
+}
+
+// parseQualified splits the optional country prefix of a value
+func parseQualified(value string) (qualifiedValue, error) {
+	value = strings.TrimSpace(value)
+	qualified := qualifiedValue{value: value}
+	if country, rest, found := strings.Cut(value, ":"); found {
+		code, err := parseCountry(country)
+		if err != nil {
+			return qualifiedValue{}, err
+		}
+		qualified = qualifiedValue{country: code, value: strings.TrimSpace(rest)}
+	}
+	if qualified.value == "" {
+		return qualifiedValue{}, fmt.Errorf("empty value '%s'", value)
+	}
+	return qualified, nil
+}
+
+// parseCity normalizes a city name, compared case-insensitively
+func parseCity(value string) (qualifiedValue, error) {
+	city, err := parseQualified(value)
+	if err != nil {
+		return qualifiedValue{}, err
+	}
+	city.value = strings.ToLower(city.value)
+	return city, nil
+}
+
+// parsePostalCode normalizes a postal code glob pattern, compared
+// case-insensitively
+func parsePostalCode(value string) (qualifiedValue, error) {
+	postalCode, err := parseQualified(value)
+	if err != nil {
+		return qualifiedValue{}, err
+	}
+	postalCode.value = strings.ToUpper(postalCode.value)
+	if _, err := path.Match(postalCode.value, ""); err != nil {
+		return qualifiedValue{}, fmt.Errorf("invalid postal code pattern '%s': %w", value, err)
+	}
+	return postalCode, nil
+}
@@ -0,0 +1,105 @@
+package geo_match
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGeoMatchConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  GeoMatchConfig
+		wantErr string
+	}{
+		{
+			name:   "countries",
+			config: GeoMatchConfig{Countries: []string{"de", " FR "}},
+		},
+		{
+			name:   "every criterion",
+			config: GeoMatchConfig{Presets: []string{"eu", "ofac-sanctioned"}, Continents: []string{"eu"}, Regions: []string{"US-CA", "gb-eng"}, Cities: []string{"Berlin", "US:Portland"}, PostalCodes: []string{"GB:SW1*", "940??"}},
+		},
+		{
+			name:   "files only",
+			config: GeoMatchConfig{CountriesFile: "/etc/geo/countries.txt"},
+		},
+		{
+			name:    "no criteria",
+			config:  GeoMatchConfig{MatchesOnMissingData: true},
+			wantErr: "at least one of countries, presets, continents, regions, cities or postalCodes is required",
+		},
+		{
+			name:    "unknown preset",
+			config:  GeoMatchConfig{Presets: []string{"schengen"}},
+			wantErr: "presets[0]: unknown preset 'schengen', expected one of 'eea', 'eu', 'ofac-sanctioned'",
+		},
+		{
+			name:    "country name",
+			config:  GeoMatchConfig{Countries: []string{"DE", "Germany"}},
+			wantErr: "countries[1]: invalid country code 'Germany'",
+		},
+		{
+			name:    "alpha-3 country code",
+			config:  GeoMatchConfig{Countries: []string{"DEU"}},
+			wantErr: "invalid country code 'DEU'",
+		},
+		{
+			name:    "continent name",
+			config:  GeoMatchConfig{Continents: []string{"Europe"}},
+			wantErr: "continents[0]: invalid continent code 'Europe', expected one of 'AF', 'AN', 'AS', 'EU', 'NA', 'OC', 'SA'",
+		},
+		{
+			name:    "region without country",
+			config:  GeoMatchConfig{Regions: []string{"CA"}},
+			wantErr: "regions[0]: invalid region code 'CA', expected an ISO 3166-2 code like 'US-CA'",
+		},
+		{
+			name:    "city with invalid country",
+			config:  GeoMatchConfig{Cities: []string{"USA:Portland"}},
+			wantErr: "cities[0]: invalid country code 'USA'",
+		},
+		{
+			name:    "empty city",
+			config:  GeoMatchConfig{Cities: []string{"US:"}},
+			wantErr: "cities[0]: empty value 'US:'",
+		},
+		{
+			name:    "invalid postal code pattern",
+			config:  GeoMatchConfig{PostalCodes: []string{"GB:SW[1"}},
+			wantErr: "postalCodes[0]: invalid postal code pattern 'GB:SW[1'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPresets(t *testing.T) {
+	if len(presets["eu"].countries) != 27 || len(presets["eea"].countries) != 30 {
+		t.Fatalf("expected 27 EU and 30 EEA countries, got %d and %d", len(presets["eu"].countries), len(presets["eea"].countries))
+	}
+	for name, preset := range presets {
+		for _, country := range preset.countries {
+			if _, err := parseCountry(country); err != nil {
+				t.Errorf("preset %s: %v", name, err)
+			}
+		}
+		for _, region := range preset.regions {
+			if _, err := parseRegion(region); err != nil {
+				t.Errorf("preset %s: %v", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package geo_match
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/analysis/maxmind_geoip"
+)
+
+// criteria holds the normalized values a location is matched against
+type criteria struct {
+	countries   map[string]string // country code -> preset name, empty when configured
+	continents  map[string]struct{}
+	regions     map[string]string // region code -> preset name, empty when configured
+	cities      map[qualifiedValue]struct{}
+	postalCodes []qualifiedValue
+}
+
+// newCriteria loads the list files and builds the criteria of a validated
+// configuration
+func newCriteria(cfg *GeoMatchConfig) (*criteria, error) {
+	c := &criteria{
+		countries:  make(map[string]string),
+		continents: make(map[string]struct{}),
+		regions:    make(map[string]string),
+		cities:     make(map[qualifiedValue]struct{}),
+	}
+
+	countries, err := listValues("countriesFile", cfg.Countries, cfg.CountriesFile, parseCountry)
+	if err != nil {
+		return nil, err
+	}
+	for _, country := range countries {
+		c.countries[country] = ""
+	}
+
+	continents, err := listValues("continentsFile", cfg.Continents, cfg.ContinentsFile, parseContinent)
+	if err != nil {
+		return nil, err
+	}
+	for _, continent := range continents {
+		c.continents[continent] = struct{}{}
+	}
+
+	regions, err := listValues("regionsFile", cfg.Regions, cfg.RegionsFile, parseRegion)
+	if err != nil {
+		return nil, err
+	}
+	for _, region := range regions {
+		c.regions[region] = ""
+	}
+
+	cities, err := listValues("citiesFile", cfg.Cities, cfg.CitiesFile, parseCity)
+	if err != nil {
+		return nil, err
+	}
+	for _, city := range cities {
+		c.cities[city] = struct{}{}
+	}
+
+	c.postalCodes, err = listValues("postalCodesFile", cfg.PostalCodes, cfg.PostalCodesFile, parsePostalCode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Configured values take precedence over presets in verdict descriptions
+	for _, name := range cfg.Presets {
+		for _, country := range presets[name].countries {
+			if _, ok := c.countries[country]; !ok {
+				c.countries[country] = name
+			}
+		}
+		for _, region := range presets[name].regions {
+			if _, ok := c.regions[region]; !ok {
+				c.regions[region] = name
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// match reports whether the location matches at least one value, with a
+// description of the first matched value or of the location
+func (c *criteria) match(location *maxmind_geoip.IpLookupResult) (bool, string) {
+	country := location.CountryISO
+
+	if preset, ok := c.countries[country]; ok && country != "" {
+		return true, fmt.Sprintf("country %s matched%s", describeCode(country, location.CountryName), presetSuffix(preset))
+	}
+
+	if _, ok := c.continents[location.ContinentCode]; ok && location.ContinentCode != "" {
+		return true, fmt.Sprintf("continent %s matched", describeCode(location.ContinentCode, location.ContinentName))
+	}
+
+	region := regionCode(location)
+	if preset, ok := c.regions[region]; ok && region != "" {
+		return true, fmt.Sprintf("region %s matched%s", describeCode(region, location.Region), presetSuffix(preset))
+	}
+
+	if location.City != "" {
+		city := strings.ToLower(location.City)
+		_, anyCountry := c.cities[qualifiedValue{value: city}]
+		_, sameCountry := c.cities[qualifiedValue{country: country, value: city}]
+		if anyCountry || (sameCountry && country != "") {
+			return true, fmt.Sprintf("city %s matched", withCountry(location.City, country))
+		}
+	}
+
+	if location.PostalCode != "" {
+		postalCode := strings.ToUpper(location.PostalCode)
+		for _, pattern := range c.postalCodes {
+			if pattern.country != "" && pattern.country != country {
+				continue
+			}
+			if matched, _ := path.Match(pattern.value, postalCode); matched {
+				return true, fmt.Sprintf("postal code %s matched pattern '%s'", withCountry(location.PostalCode, country), pattern.value)
+			}
+		}
+	}
+
+	return false, fmt.Sprintf("location %s did not match", describeLocation(location))
+}
+
+// regionCode returns the ISO 3166-2 code of the location subdivision
+func regionCode(location *maxmind_geoip.IpLookupResult) string {
+	if location.CountryISO == "" || location.RegionISO == "" {
+		return ""
+	}
+	return location.CountryISO + "-" + location.RegionISO
+}
+
+// describeCode formats a code with its name, when known
+func describeCode(code, name string) string {
+	if name == "" {
+		return code
+	}
+	return fmt.Sprintf("%s (%s)", code, name)
+}
+
+// withCountry formats a city name or postal code with its country, when known
+func withCountry(value, country string) string {
+	if country == "" {
+		return value
+	}
+	return value + ", " + country
+}
+
+// presetSuffix names the preset a value comes from
+func presetSuffix(preset string) string {
+	if preset == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (preset %s)", preset)
+}
+
+// describeLocation formats the known parts of a location, from the city to
+// the continent
+func describeLocation(location *maxmind_geoip.IpLookupResult) string {
+	var parts []string
+	for _, part := range []string{location.City, regionCode(location), location.CountryISO, location.ContinentCode} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return "unknown"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// listValues parses the configured values of a list followed by the values of
+// its file
+func listValues[T any](setting string, values []string, file string, parse func(string) (T, error)) ([]T, error) {
+	if file != "" {
+		fileValues, err := readListFile(setting, file)
+		if err != nil {
+			return nil, err
+		}
+		values = append(append([]string{}, values...), fileValues...)
+	}
+
+	parsed := make([]T, 0, len(values))
+	for _, value := range values {
+		item, err := parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", setting, err)
+		}
+		parsed = append(parsed, item)
+	}
+	return parsed, nil
+}
+
+// readListFile reads a list file: one value per line, skipping blank lines
+// and lines starting with '#'
+func readListFile(setting, location string) ([]string, error) {
+	listFilePath, err := filepath.Abs(location)
+	if err != nil {
+		return nil, fmt.Errorf("%s path is not valid: %w", setting, err)
+	}
+	content, err := os.ReadFile(listFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", setting, err)
+	}
+
+	var values []string
+	for rawLine := range strings.SplitSeq(string(content), "\n") {
+		line := strings.TrimSpace(rawLine)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		values = append(values, line)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%s %s contains no values", setting, location)
+	}
+	return values, nil
+}
@@ -0,0 +1,105 @@
+package geo_match
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/analysis/maxmind_geoip"
+)
+
+var (
+	berlin   = &maxmind_geoip.IpLookupResult{City: "Berlin", PostalCode: "10115", Region: "Land Berlin", RegionISO: "BE", CountryName: "Germany", CountryISO: "DE", ContinentName: "Europe", ContinentCode: "EU"}
+	london   = &maxmind_geoip.IpLookupResult{City: "London", PostalCode: "SW1A", Region: "England", RegionISO: "ENG", CountryName: "United Kingdom", CountryISO: "GB", ContinentName: "Europe", ContinentCode: "EU"}
+	portland = &maxmind_geoip.IpLookupResult{City: "Portland", PostalCode: "97201", Region: "Oregon", RegionISO: "OR", CountryName: "United States", CountryISO: "US", ContinentName: "North America", ContinentCode: "NA"}
+	tehran   = &maxmind_geoip.IpLookupResult{City: "Tehran", CountryName: "Iran", CountryISO: "IR", ContinentName: "Asia", ContinentCode: "AS"}
+	crimea   = &maxmind_geoip.IpLookupResult{City: "Simferopol", Region: "Crimea", RegionISO: "43", CountryName: "Ukraine", CountryISO: "UA", ContinentName: "Europe", ContinentCode: "EU"}
+	anycast  = &maxmind_geoip.IpLookupResult{ContinentName: "Europe", ContinentCode: "EU"}
+)
+
+func TestCriteriaMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      GeoMatchConfig
+		location    *maxmind_geoip.IpLookupResult
+		match       bool
+		description string
+	}{
+		{name: "country", config: GeoMatchConfig{Countries: []string{"fr", "de"}}, location: berlin, match: true, description: "country DE (Germany) matched"},
+		{name: "other country", config: GeoMatchConfig{Countries: []string{"FR"}}, location: berlin, description: "location Berlin, DE-BE, DE, EU did not match"},
+		{name: "eu preset", config: GeoMatchConfig{Presets: []string{"eu"}}, location: berlin, match: true, description: "country DE (Germany) matched (preset eu)"},
+		{name: "eu preset outside the union", config: GeoMatchConfig{Presets: []string{"eu"}}, location: london, description: "location London, GB-ENG, GB, EU did not match"},
+		{name: "configured country over preset", config: GeoMatchConfig{Countries: []string{"DE"}, Presets: []string{"eea"}}, location: berlin, match: true, description: "country DE (Germany) matched"},
+		{name: "ofac preset country", config: GeoMatchConfig{Presets: []string{"ofac-sanctioned"}}, location: tehran, match: true, description: "country IR (Iran) matched (preset ofac-sanctioned)"},
+		{name: "ofac preset region", config: GeoMatchConfig{Presets: []string{"ofac-sanctioned"}}, location: crimea, match: true, description: "region UA-43 (Crimea) matched (preset ofac-sanctioned)"},
+		{name: "continent", config: GeoMatchConfig{Continents: []string{"eu"}}, location: london, match: true, description: "continent EU (Europe) matched"},
+		{name: "continent only location", config: GeoMatchConfig{Continents: []string{"EU"}}, location: anycast, match: true, description: "continent EU (Europe) matched"},
+		{name: "region", config: GeoMatchConfig{Regions: []string{"us-or"}}, location: portland, match: true, description: "region US-OR (Oregon) matched"},
+		{name: "city", config: GeoMatchConfig{Cities: []string{"portland"}}, location: portland, match: true, description: "city Portland, US matched"},
+		{name: "city in country", config: GeoMatchConfig{Cities: []string{"US:Portland"}}, location: portland, match: true, description: "city Portland, US matched"},
+		{name: "city in other country", config: GeoMatchConfig{Cities: []string{"GB:Portland"}}, location: portland, description: "location Portland, US-OR, US, NA did not match"},
+		{name: "postal code", config: GeoMatchConfig{PostalCodes: []string{"101??"}}, location: berlin, match: true, description: "postal code 10115, DE matched pattern '101??'"},
+		{name: "postal code in country", config: GeoMatchConfig{PostalCodes: []string{"gb:sw1*"}}, location: london, match: true, description: "postal code SW1A, GB matched pattern 'SW1*'"},
+		{name: "postal code in other country", config: GeoMatchConfig{PostalCodes: []string{"US:101*"}}, location: berlin, description: "location Berlin, DE-BE, DE, EU did not match"},
+		{name: "empty location", config: GeoMatchConfig{Cities: []string{"Berlin"}}, location: &maxmind_geoip.IpLookupResult{}, description: "location unknown did not match"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			criteria, err := newCriteria(&tt.config)
+			if err != nil {
+				t.Fatalf("failed to build criteria: %v", err)
+			}
+			match, description := criteria.match(tt.location)
+			if match != tt.match || description != tt.description {
+				t.Fatalf("expected %v %q, got %v %q", tt.match, tt.description, match, description)
+			}
+		})
+	}
+}
+
+func TestNewCriteria_Files(t *testing.T) {
+	dir := t.TempDir()
+	countriesFile := filepath.Join(dir, "countries.txt")
+	if err := os.WriteFile(countriesFile, []byte("# Alpine countries\nAT\n\nch\nLI\n"), 0o600); err != nil {
+		t.Fatalf("failed to write countries file: %v", err)
+	}
+	invalidFile := filepath.Join(dir, "invalid.txt")
+	if err := os.WriteFile(invalidFile, []byte("US-CA\nCalifornia\n"), 0o600); err != nil {
+		t.Fatalf("failed to write regions file: %v", err)
+	}
+	emptyFile := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(emptyFile, []byte("# nothing yet\n"), 0o600); err != nil {
+		t.Fatalf("failed to write empty file: %v", err)
+	}
+
+	criteria, err := newCriteria(&GeoMatchConfig{Countries: []string{"DE"}, CountriesFile: countriesFile})
+	if err != nil {
+		t.Fatalf("failed to build criteria: %v", err)
+	}
+	if len(criteria.countries) != 4 {
+		t.Fatalf("expected configured and file countries, got %v", criteria.countries)
+	}
+	if _, ok := criteria.countries["CH"]; !ok {
+		t.Fatalf("expected file countries to be normalized, got %v", criteria.countries)
+	}
+
+	tests := []struct {
+		name    string
+		config  GeoMatchConfig
+		wantErr string
+	}{
+		{name: "invalid value", config: GeoMatchConfig{RegionsFile: invalidFile}, wantErr: "regionsFile: invalid region code 'California'"},
+		{name: "no values", config: GeoMatchConfig{CitiesFile: emptyFile}, wantErr: "citiesFile " + emptyFile + " contains no values"},
+		{name: "missing file", config: GeoMatchConfig{PostalCodesFile: filepath.Join(dir, "missing.txt")}, wantErr: "could not read postalCodesFile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newCriteria(&tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
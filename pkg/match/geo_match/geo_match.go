@@ -0,0 +1,148 @@
+package geo_match
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/analysis/maxmind_geoip"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+const (
+	ControllerKind = "geo-match"
+)
+
+// init registers the geo-match match controller so it can be constructed
+// from configuration at runtime.
+func init() {
+	controller.RegisterMatchControllerFactory(ControllerKind, newGeoMatchController)
+}
+
+type geoMatchController struct {
+	name                 string
+	source               string
+	criteria             *criteria
+	matchesOnMissingData bool
+	logger               *zap.Logger
+}
+
+// Match implements controller.MatchController.
+func (c *geoMatchController) Match(ctx context.Context, req *runtime.RequestContext, reports controller.AnalysisReports) (*controller.MatchVerdict, error) {
+	isMatch, description, err := c.deriveMatch(reports)
+	if err != nil {
+		return nil, err
+	}
+
+	return &controller.MatchVerdict{
+		Controller:     c.name,
+		ControllerType: ControllerKind,
+		DenyCode:       codes.PermissionDenied,
+		Description:    description,
+		IsMatch:        isMatch,
+	}, nil
+}
+
+// Name implements controller.MatchController.
+func (c *geoMatchController) Name() string {
+	return c.name
+}
+
+// Kind implements controller.MatchController.
+func (c *geoMatchController) Kind() string {
+	return ControllerKind
+}
+
+// HealthCheck implements controller.MatchController.
+func (c *geoMatchController) HealthCheck(ctx context.Context) error {
+	// No external dependencies to check
+	return nil
+}
+
+// deriveMatch inspects analyzer reports and determines whether the request
+// location matches the configured values.
+func (c *geoMatchController) deriveMatch(reports controller.AnalysisReports) (bool, string, error) {
+	report, err := c.geoipReport(reports)
+	if err != nil {
+		return false, "", err
+	}
+
+	geoipResult := maxmind_geoip.GetIpLookupResultFromReport(report)
+	if geoipResult == nil {
+		return c.matchesOnMissingData, "no GeoIP information available", nil
+	}
+
+	isMatch, description := c.criteria.match(geoipResult)
+	return isMatch, description, nil
+}
+
+// geoipReport returns the report of the source analysis controller or, when
+// no source is configured, the only maxmind-geoip report. Several reports
+// without a source are refused rather than picked at random.
+func (c *geoMatchController) geoipReport(reports controller.AnalysisReports) (*controller.AnalysisReport, error) {
+	if c.source != "" {
+		report := reports[c.source]
+		if report != nil && report.ControllerKind != maxmind_geoip.ControllerKind {
+			return nil, fmt.Errorf("source '%s' is a %s controller, expected %s", c.source, report.ControllerKind, maxmind_geoip.ControllerKind)
+		}
+		return report, nil
+	}
+
+	var sources []string
+	for name, report := range reports {
+		if report != nil && report.ControllerKind == maxmind_geoip.ControllerKind {
+			sources = append(sources, name)
+		}
+	}
+	switch len(sources) {
+	case 0:
+		return nil, nil
+	case 1:
+		return reports[sources[0]], nil
+	default:
+		slices.Sort(sources)
+		return nil, fmt.Errorf("several %s reports available ('%s'), set source to choose one", maxmind_geoip.ControllerKind, strings.Join(sources, "', '"))
+	}
+}
+
+// newGeoMatchController constructs a match controller from configuration,
+// loading the list files and expanding the presets.
+func newGeoMatchController(_ context.Context, logger *zap.Logger, cfg config.ControllerConfig) (controller.MatchController, error) {
+	var matchConfig GeoMatchConfig
+	if err := controller.DecodeControllerSettings(cfg.Settings, &matchConfig); err != nil {
+		return nil, err
+	}
+
+	if err := matchConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	criteria, err := newCriteria(&matchConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("controller initialized",
+		zap.String("source", matchConfig.Source),
+		zap.Int("countries", len(criteria.countries)),
+		zap.Int("continents", len(criteria.continents)),
+		zap.Int("regions", len(criteria.regions)),
+		zap.Int("cities", len(criteria.cities)),
+		zap.Int("postal_codes", len(criteria.postalCodes)),
+		zap.Bool("matches_on_missing_data", matchConfig.MatchesOnMissingData),
+	)
+
+	return &geoMatchController{
+		name:                 cfg.Name,
+		source:               matchConfig.Source,
+		criteria:             criteria,
+		matchesOnMissingData: matchConfig.MatchesOnMissingData,
+		logger:               logger,
+	}, nil
+}
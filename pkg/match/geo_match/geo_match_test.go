@@ -0,0 +1,127 @@
+package geo_match
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/analysis/maxmind_geoip"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+)
+
+func TestMatch(t *testing.T) {
+	geoipReport := func(result *maxmind_geoip.IpLookupResult) controller.AnalysisReports {
+		return controller.AnalysisReports{
+			"geoip": {Controller: "geoip", ControllerKind: maxmind_geoip.ControllerKind, Data: map[string]any{"result": result}},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		settings    map[string]any
+		reports     controller.AnalysisReports
+		match       bool
+		description string
+	}{
+		{name: "match", settings: map[string]any{"presets": []string{"eea"}}, reports: geoipReport(berlin), match: true, description: "country DE (Germany) matched (preset eea)"},
+		{name: "no match", settings: map[string]any{"countries": []string{"US"}}, reports: geoipReport(berlin), description: "location Berlin, DE-BE, DE, EU did not match"},
+		{name: "no GeoIP data", settings: map[string]any{"countries": []string{"US"}}, reports: geoipReport(nil), description: "no GeoIP information available"},
+		{name: "no GeoIP report", settings: map[string]any{"countries": []string{"US"}}, reports: controller.AnalysisReports{}, description: "no GeoIP information available"},
+		{name: "matches on missing data", settings: map[string]any{"countries": []string{"US"}, "matchesOnMissingData": true}, reports: geoipReport(nil), match: true, description: "no GeoIP information available"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := buildController(t, tt.settings)
+			verdict, err := ctrl.Match(context.Background(), nil, tt.reports)
+			if err != nil {
+				t.Fatalf("match returned error: %v", err)
+			}
+			if verdict.IsMatch != tt.match || verdict.Description != tt.description {
+				t.Fatalf("expected %v %q, got %v %q", tt.match, tt.description, verdict.IsMatch, verdict.Description)
+			}
+			if verdict.Controller != "geo" || verdict.ControllerType != ControllerKind || verdict.DenyCode != codes.PermissionDenied {
+				t.Fatalf("unexpected verdict %+v", verdict)
+			}
+		})
+	}
+}
+
+func TestMatch_Source(t *testing.T) {
+	reports := controller.AnalysisReports{
+		"geoip-city":    {Controller: "geoip-city", ControllerKind: maxmind_geoip.ControllerKind, Data: map[string]any{"result": berlin}},
+		"geoip-country": {Controller: "geoip-country", ControllerKind: maxmind_geoip.ControllerKind, Data: map[string]any{"result": portland}},
+		"asn":           {Controller: "asn", ControllerKind: "maxmind-asn"},
+	}
+
+	tests := []struct {
+		name        string
+		source      string
+		match       bool
+		description string
+		wantErr     string
+	}{
+		{name: "source", source: "geoip-country", match: true, description: "country US (United States) matched"},
+		{name: "other source", source: "geoip-city", description: "location Berlin, DE-BE, DE, EU did not match"},
+		{name: "missing source", source: "geoip-backup", description: "no GeoIP information available"},
+		{name: "source of another kind", source: "asn", wantErr: "source 'asn' is a maxmind-asn controller, expected maxmind-geoip"},
+		{name: "ambiguous reports", wantErr: "several maxmind-geoip reports available ('geoip-city', 'geoip-country'), set source to choose one"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := buildController(t, map[string]any{"countries": []string{"US"}, "source": tt.source})
+			verdict, err := ctrl.Match(context.Background(), nil, reports)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("match returned error: %v", err)
+			}
+			if verdict.IsMatch != tt.match || verdict.Description != tt.description {
+				t.Fatalf("expected %v %q, got %v %q", tt.match, tt.description, verdict.IsMatch, verdict.Description)
+			}
+		})
+	}
+}
+
+func TestNewGeoMatchController_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]any
+		wantErr  string
+	}{
+		{name: "no criteria", settings: map[string]any{}, wantErr: "configuration validation failed: at least one of countries"},
+		{name: "invalid country", settings: map[string]any{"countries": []string{"Germany"}}, wantErr: "configuration validation failed: countries[0]: invalid country code 'Germany'"},
+		{name: "missing file", settings: map[string]any{"countriesFile": "/nonexistent/countries.txt"}, wantErr: "could not read countriesFile"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newGeoMatchController(context.Background(), zap.NewNop(), config.ControllerConfig{Name: "geo", Type: ControllerKind, Settings: tt.settings})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func buildController(t *testing.T, settings map[string]any) controller.MatchController {
+	t.Helper()
+	ctrl, err := newGeoMatchController(context.Background(), zap.NewNop(), config.ControllerConfig{
+		Name:     "geo",
+		Type:     ControllerKind,
+		Settings: settings,
+	})
+	if err != nil {
+		t.Fatalf("failed to build controller: %v", err)
+	}
+	return ctrl
+}
@@ -0,0 +1,40 @@
+package geo_match
+
+import (
+	"slices"
+)
+
+// preset is a named list of countries and regions
+type preset struct {
+	countries []string
+	regions   []string
+}
+
+var euCountries = []string{
+	"AT", "BE", "BG", "CY", "CZ", "DE", "DK", "EE", "ES", "FI", "FR", "GR", "HR", "HU",
+	"IE", "IT", "LT", "LU", "LV", "MT", "NL", "PL", "PT", "RO", "SE", "SI", "SK",
+}
+
+// presets are the lists available through the presets setting
+var presets = map[string]preset{
+	// Member states of the European Union
+	"eu": {countries: euCountries},
+	// European Economic Area: the European Union, Iceland, Liechtenstein and Norway
+	"eea": {countries: append(slices.Clone(euCountries), "IS", "LI", "NO")},
+	// Jurisdictions under comprehensive OFAC sanctions: Cuba, Iran, North
+	// Korea, and the Crimea, Sevastopol, Donetsk and Luhansk regions of Ukraine
+	"ofac-sanctioned": {
+		countries: []string{"CU", "IR", "KP"},
+		regions:   []string{"UA-43", "UA-40", "UA-14", "UA-09"},
+	},
+}
+
+// presetNames returns the sorted names of the presets
+func presetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 
 	"go.uber.org/zap"
@@ -13,6 +15,8 @@ import (
 	"github.com/gtriggiano/envoy-authorization-service/pkg/cidrlist"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/listsource"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/metrics"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
 )
 
@@ -27,15 +31,56 @@ func init() {
 }
 
 type IpMatchConfig struct {
-	CIDRList string `yaml:"cidrList"`
+	CIDRList     string              `yaml:"cidrList"`
+	ProviderList *ProviderListConfig `yaml:"providerList"`
+	Remote       *listsource.Config  `yaml:"remote"`
+}
+
+// ProviderListConfig builds the CIDR list from a cloud provider IP range feed.
+type ProviderListConfig struct {
+	Provider string   `yaml:"provider"`
+	Location string   `yaml:"location"`
+	Services []string `yaml:"services"`
+	Regions  []string `yaml:"regions"`
+}
+
+// GetLocation returns the configured feed location, or the provider's published feed if not specified.
+func (c *ProviderListConfig) GetLocation() string {
+	if c.Location == "" {
+		return cidrlist.ProviderDefaultURL(c.Provider)
+	}
+	return c.Location
+}
+
+// Validate checks the provider list configuration for completeness.
+func (c *ProviderListConfig) Validate() error {
+	if c.Provider == "" {
+		return fmt.Errorf("providerList.provider is required")
+	}
+	if !slices.Contains(cidrlist.Providers(), c.Provider) {
+		return fmt.Errorf("providerList.provider must be one of %s", strings.Join(cidrlist.Providers(), ", "))
+	}
+	if c.GetLocation() == "" {
+		return fmt.Errorf("providerList.location is required for provider %s", c.Provider)
+	}
+	return nil
 }
 
 type ipMatchController struct {
-	name     string
-	cidrList []cidrlist.CIDR
-	cache    map[string]*cidrlist.CIDR // nil if IP didn't match any CIDR
-	cacheMu  sync.RWMutex
-	logger   *zap.Logger
+	name         string
+	cidrList     []cidrlist.CIDR
+	cache        map[string]*cidrlist.CIDR // nil if IP didn't match any CIDR
+	cacheMu      sync.RWMutex
+	source       *listsource.Source  // nil when the list is read from a local file
+	providerList *ProviderListConfig // nil when the list is in the CIDR list format
+	logger       *zap.Logger
+}
+
+// SetInstrumentation injects the shared metrics instrumentation.
+func (c *ipMatchController) SetInstrumentation(inst *metrics.Instrumentation) {
+	if c.source != nil {
+		c.source.SetInstrumentation(inst)
+	}
 }
 
 // Match implements controller.MatchController.
@@ -78,6 +123,9 @@ func (c *ipMatchController) Kind() string {
 
 // HealthCheck implements controller.MatchController.
 func (c *ipMatchController) HealthCheck(ctx context.Context) error {
+	if c.source != nil {
+		return c.source.HealthCheck()
+	}
 	// No external dependencies to check
 	return nil
 }
@@ -92,15 +140,17 @@ func (c *ipMatchController) getOrComputeMatch(ipAddress string) *cidrlist.CIDR {
 		c.logger.Debug("cache hit for IP", zap.String("ip", ipAddress))
 		return matchedCIDR
 	}
+	// Capture list and cache together so a concurrent refresh cannot mix them
+	cidrList, cache := c.cidrList, c.cache
 	c.cacheMu.RUnlock()
 
 	// Cache miss - compute match
 	c.logger.Debug("cache miss for IP", zap.String("ip", ipAddress))
-	matchedCIDR, _ := cidrlist.FindContaining(c.cidrList, ipAddress)
+	matchedCIDR, _ := cidrlist.FindContaining(cidrList, ipAddress)
 
 	// Store in cache with write lock
 	c.cacheMu.Lock()
-	c.cache[ipAddress] = matchedCIDR
+	cache[ipAddress] = matchedCIDR
 	c.cacheMu.Unlock()
 
 	c.logger.Debug("cached match result", zap.String("ip", ipAddress), zap.Bool("matched", matchedCIDR != nil))
@@ -119,32 +169,104 @@ func (c *ipMatchController) deriveMatch(ipAddress string, matchedCIDR *cidrlist.
 	}
 }
 
+// parseCIDRList converts list content into CIDR entries, importing it from a
+// provider feed when providerList is configured.
+func (c *ipMatchController) parseCIDRList(content []byte) ([]cidrlist.CIDR, error) {
+	if c.providerList == nil {
+		return cidrlist.Parse(string(content)), nil
+	}
+	return cidrlist.ImportProvider(c.providerList.Provider, content, cidrlist.ProviderFilter{
+		Services: c.providerList.Services,
+		Regions:  c.providerList.Regions,
+	})
+}
+
+// applyCIDRList parses list content and replaces the active list,
+// dropping every cached match computed against the previous one.
+func (c *ipMatchController) applyCIDRList(content []byte) error {
+	cidrList, err := c.parseCIDRList(content)
+	if err != nil {
+		return err
+	}
+	if len(cidrList) == 0 {
+		return fmt.Errorf("list contains no valid CIDR entries")
+	}
+
+	c.cacheMu.Lock()
+	c.cidrList = cidrList
+	c.cache = make(map[string]*cidrlist.CIDR)
+	c.cacheMu.Unlock()
+
+	c.logger.Debug("CIDR list applied", zap.Int("entries", len(cidrList)))
+	return nil
+}
+
 // newIpMatchController constructs a match controller from
 // configuration by loading the CIDR list file and preparing the evaluation cache.
-func newIpMatchController(_ context.Context, logger *zap.Logger, cfg config.ControllerConfig) (controller.MatchController, error) {
+// When the list location is an http(s) URL the list is fetched and refreshed in the background.
+func newIpMatchController(ctx context.Context, logger *zap.Logger, cfg config.ControllerConfig) (controller.MatchController, error) {
 	var matchConfig IpMatchConfig
 	if err := controller.DecodeControllerSettings(cfg.Settings, &matchConfig); err != nil {
 		return nil, err
 	}
 
-	if matchConfig.CIDRList == "" {
+	if matchConfig.CIDRList == "" && matchConfig.ProviderList == nil {
 		return nil, fmt.Errorf("cidrList is required, check your configuration")
 	}
 
-	cidrListFilePath, err := filepath.Abs(matchConfig.CIDRList)
+	if matchConfig.CIDRList != "" && matchConfig.ProviderList != nil {
+		return nil, fmt.Errorf("cidrList and providerList are mutually exclusive")
+	}
+
+	location := matchConfig.CIDRList
+	if matchConfig.ProviderList != nil {
+		if err := matchConfig.ProviderList.Validate(); err != nil {
+			return nil, err
+		}
+		location = matchConfig.ProviderList.GetLocation()
+	}
+
+	ctrl := &ipMatchController{
+		name:         cfg.Name,
+		cache:        make(map[string]*cidrlist.CIDR),
+		providerList: matchConfig.ProviderList,
+		logger:       logger,
+	}
+
+	if listsource.IsRemote(location) {
+		source, err := listsource.New(location, matchConfig.Remote, cfg.Name, ControllerKind, logger)
+		if err != nil {
+			return nil, fmt.Errorf("list source is not valid: %w", err)
+		}
+		if err := source.Start(ctx, ctrl.applyCIDRList); err != nil {
+			return nil, fmt.Errorf("could not load list: %w", err)
+		}
+		ctrl.source = source
+
+		return ctrl, nil
+	}
+
+	if matchConfig.Remote != nil {
+		return nil, fmt.Errorf("remote settings require the list location to be an http(s) URL")
+	}
+
+	listFilePath, err := filepath.Abs(location)
 	if err != nil {
-		return nil, fmt.Errorf("cidrList path is not valid: %w", err)
+		return nil, fmt.Errorf("list path is not valid: %w", err)
 	}
 
-	cidrListFileContent, err := os.ReadFile(cidrListFilePath)
+	listFileContent, err := os.ReadFile(listFilePath)
 	if err != nil {
-		return nil, fmt.Errorf("could not read cidrList file: %w", err)
+		return nil, fmt.Errorf("could not read list file: %w", err)
 	}
 
-	return &ipMatchController{
-		name:     cfg.Name,
-		cidrList: cidrlist.Parse(string(cidrListFileContent)),
-		cache:    make(map[string]*cidrlist.CIDR),
-		logger:   logger,
-	}, nil
+	if matchConfig.ProviderList != nil {
+		if err := ctrl.applyCIDRList(listFileContent); err != nil {
+			return nil, fmt.Errorf("could not load providerList: %w", err)
+		}
+		return ctrl, nil
+	}
+
+	ctrl.cidrList = cidrlist.Parse(string(listFileContent))
+	return ctrl, nil
 }
@@ -2,8 +2,11 @@ package ip_match
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
@@ -117,6 +120,164 @@ func TestNewMatchController_InvalidPath(t *testing.T) {
 	}
 }
 
+func TestNewMatchController_RemoteCIDRList(t *testing.T) {
+	content := "# Scrapers\n192.168.1.0/24"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl, err := newIpMatchController(ctx, zap.NewNop(), config.ControllerConfig{
+		Name: "remote",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"cidrList": server.URL + "/cidrs.txt",
+			"remote": map[string]any{
+				"refreshInterval": "1h",
+				"maxAge":          "2h",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create controller: %v", err)
+	}
+
+	if verdict := matchIP(t, ctrl, "192.168.1.10"); !verdict.IsMatch {
+		t.Fatalf("expected match from remote list, got %s", verdict.Description)
+	}
+	if err := ctrl.HealthCheck(ctx); err != nil {
+		t.Fatalf("expected healthy controller, got %v", err)
+	}
+
+	c := ctrl.(*ipMatchController)
+	if err := c.applyCIDRList([]byte("10.0.0.0/8")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if verdict := matchIP(t, ctrl, "192.168.1.10"); verdict.IsMatch {
+		t.Fatal("expected cached match to be dropped after list update")
+	}
+	if err := c.applyCIDRList([]byte("<html>oops</html>")); err == nil {
+		t.Fatal("expected list without valid CIDRs to be rejected")
+	}
+	if verdict := matchIP(t, ctrl, "10.1.2.3"); !verdict.IsMatch {
+		t.Fatal("expected previous list to remain active after rejection")
+	}
+}
+
+func TestNewMatchController_RemoteSettingsRequireURL(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "cidrs.txt")
+	if err := os.WriteFile(path, []byte("10.0.0.0/8"), 0o644); err != nil {
+		t.Fatalf("failed to write cidr list: %v", err)
+	}
+
+	_, err := newIpMatchController(context.Background(), zap.NewNop(), config.ControllerConfig{
+		Name: "test",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"cidrList": path,
+			"remote":   map[string]any{"refreshInterval": "1h"},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected error when remote settings are used with a local file")
+	}
+}
+
+func TestNewMatchController_ProviderList(t *testing.T) {
+	feed := `{"prefixes": [
+		{"ip_prefix": "52.95.245.0/24", "region": "eu-west-1", "service": "EC2"},
+		{"ip_prefix": "52.94.76.0/22", "region": "us-west-2", "service": "EC2"}
+	]}`
+
+	t.Run("local file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ip-ranges.json")
+		if err := os.WriteFile(path, []byte(feed), 0o644); err != nil {
+			t.Fatalf("failed to write feed: %v", err)
+		}
+
+		ctrl, err := newIpMatchController(context.Background(), zap.NewNop(), config.ControllerConfig{
+			Name: "aws-eu",
+			Type: ControllerKind,
+			Settings: map[string]any{
+				"providerList": map[string]any{
+					"provider": "aws",
+					"location": path,
+					"services": []string{"EC2"},
+					"regions":  []string{"eu-west-1"},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to create controller: %v", err)
+		}
+
+		verdict := matchIP(t, ctrl, "52.95.245.7")
+		if !verdict.IsMatch || verdict.Description != "IP 52.95.245.7 matched CIDR 52.95.245.0/24 [AWS EC2 eu-west-1]" {
+			t.Fatalf("unexpected verdict: %+v", verdict)
+		}
+		if verdict := matchIP(t, ctrl, "52.94.76.1"); verdict.IsMatch {
+			t.Fatal("expected range outside the region filter not to match")
+		}
+	})
+
+	t.Run("remote feed", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(feed))
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ctrl, err := newIpMatchController(ctx, zap.NewNop(), config.ControllerConfig{
+			Name: "aws",
+			Type: ControllerKind,
+			Settings: map[string]any{
+				"providerList": map[string]any{
+					"provider": "aws",
+					"location": server.URL + "/ip-ranges.json",
+				},
+				"remote": map[string]any{"refreshInterval": "6h"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to create controller: %v", err)
+		}
+
+		if verdict := matchIP(t, ctrl, "52.94.76.1"); !verdict.IsMatch {
+			t.Fatalf("expected match from remote feed, got %s", verdict.Description)
+		}
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			settings map[string]any
+			wantErr  string
+		}{
+			{"both sources", map[string]any{"cidrList": "cidrs.txt", "providerList": map[string]any{"provider": "aws"}}, "mutually exclusive"},
+			{"missing provider", map[string]any{"providerList": map[string]any{"location": "feed.json"}}, "providerList.provider is required"},
+			{"unknown provider", map[string]any{"providerList": map[string]any{"provider": "oracle"}}, "must be one of"},
+			{"azure without location", map[string]any{"providerList": map[string]any{"provider": "azure"}}, "providerList.location is required"},
+		}
+
+		for _, tt := range tests {
+			_, err := newIpMatchController(context.Background(), zap.NewNop(), config.ControllerConfig{
+				Name:     "test",
+				Type:     ControllerKind,
+				Settings: tt.settings,
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("%s: expected error containing %q, got %v", tt.name, tt.wantErr, err)
+			}
+		}
+	})
+}
+
 // helpers
 func createTestController(t *testing.T, cidrList string) controller.MatchController {
 	t.Helper()
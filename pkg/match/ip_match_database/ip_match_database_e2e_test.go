@@ -16,6 +16,7 @@ import (
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
 	"github.com/jackc/pgx/v5"
+	dockercontainer "github.com/moby/moby/api/types/container"
 	"github.com/redis/go-redis/v9"
 	"github.com/testcontainers/testcontainers-go"
 	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
@@ -26,6 +27,7 @@ import (
 
 	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/match/attribute_match_database"
 	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
 )
 
@@ -84,6 +86,161 @@ func TestRedisIpMatchDatabase(t *testing.T) {
 	}
 }
 
+func TestRedisRangeIpMatchDatabase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, host, port := startRedis(t, ctx)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	client := redis.NewClient(&redis.Options{
+		Addr: fmt.Sprintf("%s:%d", host, port),
+		DB:   0,
+	})
+	for prefix, comment := range map[string]string{
+		"203.0.113.0/24":  "Scraper network",
+		"198.51.100.8/29": "",
+		"2001:db8::/48":   "IPv6 scraper",
+	} {
+		member := attribute_match_database.RedisRangeMember(netip.MustParsePrefix(prefix), comment)
+		requireNoErr(t, client.ZAdd(ctx, "blocked-ranges", redis.Z{Score: 0, Member: member}).Err())
+	}
+
+	logger := zaptest.NewLogger(t)
+	ctrl := buildController(t, ctx, logger, config.ControllerConfig{
+		Name: "ip-db-redis-range",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"cache": map[string]any{
+				"ttl": "1m",
+			},
+			"database": map[string]any{
+				"type": "redis",
+				"redis": map[string]any{
+					"lookup":   "range",
+					"rangeKey": "blocked-ranges",
+					"host":     host,
+					"port":     port,
+				},
+			},
+		},
+	})
+
+	tests := []struct {
+		ip          string
+		match       bool
+		description string
+	}{
+		{"203.0.113.10", true, "IP 203.0.113.10 matched range 203.0.113.0/24 [Scraper network] in 'redis'"},
+		{"198.51.100.15", true, "IP 198.51.100.15 matched range 198.51.100.8/29 in 'redis'"},
+		{"198.51.100.16", false, ""},
+		{"198.51.100.7", false, ""},
+		{"2001:db8::1", true, "IP 2001:db8::1 matched range 2001:db8::/48 [IPv6 scraper] in 'redis'"},
+		{"2001:db9::1", false, ""},
+	}
+
+	for _, tt := range tests {
+		// Run twice so the second lookup is served from the cache
+		for range 2 {
+			verdict, err := ctrl.Match(ctx, &runtime.RequestContext{
+				Request:    minimalCheckRequest(tt.ip),
+				ReceivedAt: time.Now(),
+				IpAddress:  netip.MustParseAddr(tt.ip),
+			}, nil)
+			requireNoErr(t, err)
+			if verdict.IsMatch != tt.match {
+				t.Fatalf("%s: expected match=%v, got: %s", tt.ip, tt.match, verdict.Description)
+			}
+			if tt.match && verdict.Description != tt.description {
+				t.Fatalf("%s: unexpected description: %s", tt.ip, verdict.Description)
+			}
+		}
+	}
+}
+
+func TestRedisSentinelIpMatchDatabase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, sentinelAddr := startRedisSentinel(t, ctx)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	client := redis.NewFailoverClient(&redis.FailoverOptions{
+		MasterName:    "mymaster",
+		SentinelAddrs: []string{sentinelAddr},
+	})
+	defer client.Close()
+	requireNoErr(t, client.Set(ctx, "block:203.0.113.10", "1", 0).Err())
+	// Make sure the replica has the key before reading from it
+	requireNoErr(t, client.Do(ctx, "WAIT", 1, 5000).Err())
+
+	for _, readFromReplica := range []bool{false, true} {
+		t.Run(fmt.Sprintf("readFromReplica=%v", readFromReplica), func(t *testing.T) {
+			ctrl := buildController(t, ctx, zaptest.NewLogger(t), config.ControllerConfig{
+				Name: "ip-db-redis-sentinel",
+				Type: ControllerKind,
+				Settings: map[string]any{
+					"database": map[string]any{
+						"type":              "redis",
+						"connectionTimeout": "5s",
+						"redis": map[string]any{
+							"mode":            "sentinel",
+							"keyPrefix":       "block:",
+							"readFromReplica": readFromReplica,
+							"sentinel": map[string]any{
+								"masterName": "mymaster",
+								"addresses":  []string{sentinelAddr},
+							},
+						},
+					},
+				},
+			})
+
+			requireMatch(t, ctx, ctrl, "203.0.113.10", true)
+			requireMatch(t, ctx, ctrl, "198.51.100.42", false)
+		})
+	}
+}
+
+func TestRedisClusterIpMatchDatabase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, seedAddrs := startRedisCluster(t, ctx)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: seedAddrs})
+	defer client.Close()
+	requireNoErr(t, client.Set(ctx, "block:203.0.113.10", "1", 0).Err())
+
+	for _, readFromReplica := range []bool{false, true} {
+		t.Run(fmt.Sprintf("readFromReplica=%v", readFromReplica), func(t *testing.T) {
+			ctrl := buildController(t, ctx, zaptest.NewLogger(t), config.ControllerConfig{
+				Name: "ip-db-redis-cluster",
+				Type: ControllerKind,
+				Settings: map[string]any{
+					"database": map[string]any{
+						"type":              "redis",
+						"connectionTimeout": "5s",
+						"redis": map[string]any{
+							"mode":            "cluster",
+							"keyPrefix":       "block:",
+							"readFromReplica": readFromReplica,
+							"cluster": map[string]any{
+								"addresses": seedAddrs,
+							},
+						},
+					},
+				},
+			})
+
+			// Replicas catch up asynchronously, so allow the first lookups to miss
+			requireEventualMatch(t, ctx, ctrl, "203.0.113.10")
+			requireMatch(t, ctx, ctrl, "198.51.100.42", false)
+		})
+	}
+}
+
 func TestPostgresIpMatchDatabase(t *testing.T) {
 	t.Parallel()
 
@@ -159,8 +316,266 @@ func TestPostgresIpMatchDatabase(t *testing.T) {
 	}
 }
 
+func TestRedisKeyspaceInvalidationIpMatchDatabase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, host, port := startRedis(t, ctx)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	client := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:%d", host, port)})
+	requireNoErr(t, client.ConfigSet(ctx, "notify-keyspace-events", "Kgx$").Err())
+	requireNoErr(t, client.Set(ctx, "block:203.0.113.10", "1", 0).Err())
+
+	ctrlCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctrl := buildController(t, ctrlCtx, zaptest.NewLogger(t), config.ControllerConfig{
+		Name: "ip-db-redis-keyspace",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"cache": map[string]any{
+				"ttl": "1h",
+			},
+			"database": map[string]any{
+				"type": "redis",
+				"redis": map[string]any{
+					"keyPrefix":             "block:",
+					"host":                  host,
+					"port":                  port,
+					"keyspaceNotifications": true,
+				},
+			},
+		},
+	})
+
+	requireEventualMatch(t, ctx, ctrl, "203.0.113.10")
+
+	// Despite the 1h TTL the deletion is picked up through the keyspace event
+	requireNoErr(t, client.Del(ctx, "block:203.0.113.10").Err())
+	requireEventualMiss(t, ctx, ctrl, "203.0.113.10")
+}
+
+func TestPostgresNotifyInvalidationIpMatchDatabase(t *testing.T) {
+	t.Parallel()
+
+	userEnv := "IP_PG_USER_" + sanitizeEnvName(t.Name())
+	passEnv := "IP_PG_PASS_" + sanitizeEnvName(t.Name())
+	setEnvForTest(t, userEnv, "postgres")
+	setEnvForTest(t, passEnv, "postgres")
+
+	ctx := context.Background()
+	container, host, port := startPostgres(t, ctx)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	dsn := fmt.Sprintf("postgres://postgres:postgres@%s:%d/security?sslmode=disable", host, port)
+	conn, err := pgx.Connect(ctx, dsn)
+	requireNoErr(t, err)
+	t.Cleanup(func() { _ = conn.Close(ctx) })
+
+	_, err = conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS trusted_ips (ip inet PRIMARY KEY);
+		INSERT INTO trusted_ips (ip) VALUES ('203.0.113.10') ON CONFLICT DO NOTHING;
+
+		CREATE OR REPLACE FUNCTION notify_trusted_ips() RETURNS trigger AS $$
+		BEGIN
+			PERFORM pg_notify('trusted_ips_changed', host(COALESCE(NEW.ip, OLD.ip)));
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE TRIGGER trusted_ips_changed AFTER INSERT OR UPDATE OR DELETE ON trusted_ips
+			FOR EACH ROW EXECUTE FUNCTION notify_trusted_ips();
+	`)
+	requireNoErr(t, err)
+
+	ctrlCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctrl := buildController(t, ctrlCtx, zaptest.NewLogger(t), config.ControllerConfig{
+		Name: "ip-db-postgres-notify",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"cache": map[string]any{
+				"ttl": "1h",
+			},
+			"database": map[string]any{
+				"type":              "postgres",
+				"connectionTimeout": "1s",
+				"postgres": map[string]any{
+					"query":         "SELECT 1 FROM trusted_ips WHERE ip = $1 LIMIT 1",
+					"host":          host,
+					"port":          port,
+					"databaseName":  "security",
+					"usernameEnv":   userEnv,
+					"passwordEnv":   passEnv,
+					"notifyChannel": "trusted_ips_changed",
+				},
+			},
+		},
+	})
+
+	requireEventualMatch(t, ctx, ctrl, "203.0.113.10")
+
+	// Despite the 1h TTL the deletion is picked up through the notification
+	_, err = conn.Exec(ctx, `DELETE FROM trusted_ips WHERE ip = '203.0.113.10'`)
+	requireNoErr(t, err)
+	requireEventualMiss(t, ctx, ctrl, "203.0.113.10")
+}
+
 // --- helpers ---
 
+func TestRedisFullSyncIpMatchDatabase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, host, port := startRedis(t, ctx)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	client := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:%d", host, port)})
+	requireNoErr(t, client.Set(ctx, "block:203.0.113.10", "1", 0).Err())
+	requireNoErr(t, client.Set(ctx, "block:10.0.0.0/8", "1", 0).Err())
+
+	ctrlCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctrl := buildController(t, ctrlCtx, zaptest.NewLogger(t), config.ControllerConfig{
+		Name: "ip-db-redis-full-sync",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"syncMode": "full",
+			"sync": map[string]any{
+				"interval": "1s",
+			},
+			"database": map[string]any{
+				"type": "redis",
+				"redis": map[string]any{
+					"keyPrefix": "block:",
+					"host":      host,
+					"port":      port,
+				},
+			},
+		},
+	})
+
+	requireMatch(t, ctx, ctrl, "203.0.113.10", true)
+	requireMatch(t, ctx, ctrl, "10.20.30.40", true)
+	requireMatch(t, ctx, ctrl, "198.51.100.42", false)
+
+	// Changes are picked up by the next sync
+	requireNoErr(t, client.Set(ctx, "block:198.51.100.42", "1", 0).Err())
+	requireEventualMatch(t, ctx, ctrl, "198.51.100.42")
+}
+
+func TestPostgresFullSyncIpMatchDatabase(t *testing.T) {
+	t.Parallel()
+
+	userEnv := "IP_PG_USER_" + sanitizeEnvName(t.Name())
+	passEnv := "IP_PG_PASS_" + sanitizeEnvName(t.Name())
+	setEnvForTest(t, userEnv, "postgres")
+	setEnvForTest(t, passEnv, "postgres")
+
+	ctx := context.Background()
+	container, host, port := startPostgres(t, ctx)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	dsn := fmt.Sprintf("postgres://postgres:postgres@%s:%d/security?sslmode=disable", host, port)
+	conn, err := pgx.Connect(ctx, dsn)
+	requireNoErr(t, err)
+	t.Cleanup(func() { _ = conn.Close(ctx) })
+
+	_, err = conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS blocked_networks (network cidr PRIMARY KEY, reason text);
+		INSERT INTO blocked_networks (network, reason) VALUES ('203.0.113.0/24', 'abuse'), ('2001:db8::/32', NULL) ON CONFLICT DO NOTHING;
+	`)
+	requireNoErr(t, err)
+
+	ctrlCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctrl := buildController(t, ctrlCtx, zaptest.NewLogger(t), config.ControllerConfig{
+		Name: "ip-db-postgres-full-sync",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"syncMode": "full",
+			"sync": map[string]any{
+				"interval": "1s",
+			},
+			"database": map[string]any{
+				"type": "postgres",
+				"postgres": map[string]any{
+					"syncQuery":    "SELECT network, reason FROM blocked_networks",
+					"host":         host,
+					"port":         port,
+					"databaseName": "security",
+					"usernameEnv":  userEnv,
+					"passwordEnv":  passEnv,
+				},
+			},
+		},
+	})
+
+	verdict, err := ctrl.Match(ctx, &runtime.RequestContext{
+		Request:    minimalCheckRequest("203.0.113.10"),
+		ReceivedAt: time.Now(),
+		IpAddress:  netip.MustParseAddr("203.0.113.10"),
+	}, nil)
+	requireNoErr(t, err)
+	if !verdict.IsMatch || !strings.Contains(verdict.Description, "203.0.113.0/24 [abuse]") {
+		t.Fatalf("expected range match with comment, got: %s", verdict.Description)
+	}
+	requireMatch(t, ctx, ctrl, "2001:db8::1", true)
+	requireMatch(t, ctx, ctrl, "198.51.100.42", false)
+
+	// Changes are picked up by the next sync
+	_, err = conn.Exec(ctx, `DELETE FROM blocked_networks WHERE network = '203.0.113.0/24'`)
+	requireNoErr(t, err)
+	requireEventualMiss(t, ctx, ctrl, "203.0.113.10")
+}
+
+func TestRedisMetadataIpMatchDatabase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, host, port := startRedis(t, ctx)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	client := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:%d", host, port)})
+	requireNoErr(t, client.HSet(ctx, "block:203.0.113.10", "reason", "credential stuffing", "owner", "secops").Err())
+
+	ctrl := buildController(t, ctx, zaptest.NewLogger(t), config.ControllerConfig{
+		Name: "ip-db-redis-metadata",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"metadata": map[string]any{
+				"description": "{reason}",
+				"downstreamHeaders": map[string]any{
+					"X-Block-Reason": "{reason}",
+				},
+			},
+			"database": map[string]any{
+				"type": "redis",
+				"redis": map[string]any{
+					"keyPrefix": "block:",
+					"valueType": "hash",
+					"host":      host,
+					"port":      port,
+				},
+			},
+		},
+	})
+
+	verdict, err := ctrl.Match(ctx, &runtime.RequestContext{
+		Request:    minimalCheckRequest("203.0.113.10"),
+		ReceivedAt: time.Now(),
+		IpAddress:  netip.MustParseAddr("203.0.113.10"),
+	}, nil)
+	requireNoErr(t, err)
+	if !verdict.IsMatch || !strings.HasSuffix(verdict.Description, ": credential stuffing") {
+		t.Fatalf("expected match with metadata, got: %s", verdict.Description)
+	}
+	if verdict.DenyDownstreamHeaders["X-Block-Reason"] != "credential stuffing" {
+		t.Fatalf("unexpected downstream headers: %v", verdict.DenyDownstreamHeaders)
+	}
+	requireMatch(t, ctx, ctrl, "198.51.100.42", false)
+}
+
 func startRedis(t *testing.T, ctx context.Context) (testcontainers.Container, string, int) {
 	t.Helper()
 
@@ -177,6 +592,135 @@ func startRedis(t *testing.T, ctx context.Context) (testcontainers.Container, st
 	return container, host, port
 }
 
+// startRedisSentinel runs a master, a replica and a sentinel monitoring them
+// in a single container on the host network, so the addresses the sentinel
+// announces are reachable from the test process. It returns the sentinel address.
+func startRedisSentinel(t *testing.T, ctx context.Context) (testcontainers.Container, string) {
+	t.Helper()
+
+	masterPort, replicaPort, sentinelPort := freePort(t), freePort(t), freePort(t)
+	script := fmt.Sprintf(`redis-server --port %[1]d --daemonize yes &&
+redis-server --port %[2]d --replicaof 127.0.0.1 %[1]d --daemonize yes &&
+printf 'port %[3]d\nsentinel monitor mymaster 127.0.0.1 %[1]d 1\n' > /tmp/sentinel.conf &&
+exec redis-sentinel /tmp/sentinel.conf`, masterPort, replicaPort, sentinelPort)
+
+	container, err := testcontainers.Run(ctx, "redis:7-alpine",
+		testcontainers.WithEntrypoint("sh", "-c", script),
+		testcontainers.WithHostConfigModifier(func(hostConfig *dockercontainer.HostConfig) {
+			hostConfig.NetworkMode = "host"
+		}),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("+slave slave").WithStartupTimeout(time.Minute),
+		),
+	)
+	requireNoErr(t, err)
+
+	return container, fmt.Sprintf("127.0.0.1:%d", sentinelPort)
+}
+
+// startRedisCluster runs a three-master, three-replica Redis Cluster in a
+// single container on the host network and returns the seed addresses.
+func startRedisCluster(t *testing.T, ctx context.Context) (testcontainers.Container, []string) {
+	t.Helper()
+
+	var ports []string
+	var addrs []string
+	for range 6 {
+		port := freePort(t)
+		ports = append(ports, strconv.Itoa(port))
+		addrs = append(addrs, fmt.Sprintf("127.0.0.1:%d", port))
+	}
+	script := fmt.Sprintf(`for port in %s; do
+  redis-server --port $port --cluster-enabled yes --cluster-config-file nodes-$port.conf --daemonize yes || exit 1
+done &&
+redis-cli --cluster create %s --cluster-replicas 1 --cluster-yes &&
+echo cluster-ready &&
+exec tail -f /dev/null`, strings.Join(ports, " "), strings.Join(addrs, " "))
+
+	container, err := testcontainers.Run(ctx, "redis:7-alpine",
+		testcontainers.WithEntrypoint("sh", "-c", script),
+		testcontainers.WithHostConfigModifier(func(hostConfig *dockercontainer.HostConfig) {
+			hostConfig.NetworkMode = "host"
+		}),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("cluster-ready").WithStartupTimeout(2*time.Minute),
+		),
+	)
+	requireNoErr(t, err)
+
+	return container, addrs[:3]
+}
+
+// freePort returns a TCP port that is currently free on the loopback interface.
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	requireNoErr(t, err)
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// requireMatch runs a single lookup and checks its outcome.
+func requireMatch(t *testing.T, ctx context.Context, ctrl controller.MatchController, ip string, match bool) {
+	t.Helper()
+
+	verdict, err := ctrl.Match(ctx, &runtime.RequestContext{
+		Request:    minimalCheckRequest(ip),
+		ReceivedAt: time.Now(),
+		IpAddress:  netip.MustParseAddr(ip),
+	}, nil)
+	requireNoErr(t, err)
+	if verdict.IsMatch != match {
+		t.Fatalf("%s: expected match=%v, got: %s", ip, match, verdict.Description)
+	}
+}
+
+// requireEventualMatch retries an uncached lookup until it matches.
+func requireEventualMatch(t *testing.T, ctx context.Context, ctrl controller.MatchController, ip string) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		verdict, err := ctrl.Match(ctx, &runtime.RequestContext{
+			Request:    minimalCheckRequest(ip),
+			ReceivedAt: time.Now(),
+			IpAddress:  netip.MustParseAddr(ip),
+		}, nil)
+		requireNoErr(t, err)
+		if verdict.IsMatch {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: expected match, got: %s", ip, verdict.Description)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// requireEventualMiss retries a lookup until it no longer matches.
+func requireEventualMiss(t *testing.T, ctx context.Context, ctrl controller.MatchController, ip string) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		verdict, err := ctrl.Match(ctx, &runtime.RequestContext{
+			Request:    minimalCheckRequest(ip),
+			ReceivedAt: time.Now(),
+			IpAddress:  netip.MustParseAddr(ip),
+		}, nil)
+		requireNoErr(t, err)
+		if !verdict.IsMatch {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: expected miss, got: %s", ip, verdict.Description)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
 func startPostgres(t *testing.T, ctx context.Context) (testcontainers.Container, string, int) {
 	t.Helper()
 
@@ -0,0 +1,125 @@
+package ip_match_database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/match/attribute_match_database"
+)
+
+func TestRegisteredPreset(t *testing.T) {
+	_, err := controller.BuildMatchControllers(context.Background(), zap.NewNop(), []config.ControllerConfig{{
+		Name:     "blocked-ips",
+		Type:     ControllerKind,
+		Settings: map[string]any{"key": map[string]any{"source": "header", "name": "x-api-key"}},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "key is not configurable for ip-match-database controllers") {
+		t.Fatalf("expected the registered factory to fix the key, got %v", err)
+	}
+	if presetKey.Source != attribute_match_database.KeySourceIP {
+		t.Fatalf("expected the client IP to be the key, got %q", presetKey.Source)
+	}
+}
+
+// The examples of the ip-match-database documentation before the controller
+// became an attribute-match-database preset
+func TestDecodeBaselineSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		check    func(t *testing.T, cfg attribute_match_database.AttributeMatchDatabaseConfig)
+	}{
+		{
+			name: "redis",
+			settings: `
+matchesOnFailure: false
+cache:
+  ttl: 10m
+database:
+  type: redis
+  redis:
+    keyPrefix: "suspect-scraper:"
+    host: redis.example.com
+    port: 6379
+    tls:
+      insecureSkipVerify: false
+      caCert: /path/to/ca.crt
+      clientCert: /path/to/client.crt
+      clientKey: /path/to/client.key
+`,
+			check: func(t *testing.T, cfg attribute_match_database.AttributeMatchDatabaseConfig) {
+				redis := cfg.Database.Redis
+				if cfg.Database.Type != "redis" || cfg.Cache == nil || cfg.Cache.TTL != "10m" {
+					t.Fatalf("unexpected database or cache %+v %+v", cfg.Database, cfg.Cache)
+				}
+				if redis.KeyPrefix != "suspect-scraper:" || redis.Host != "redis.example.com" || redis.Port != 6379 {
+					t.Fatalf("unexpected redis settings %+v", redis)
+				}
+				if redis.TLS == nil || redis.TLS.CACert != "/path/to/ca.crt" || redis.TLS.ClientKey != "/path/to/client.key" {
+					t.Fatalf("unexpected redis TLS %+v", redis.TLS)
+				}
+				if redis.Mode != attribute_match_database.RedisModeStandalone || redis.Lookup != attribute_match_database.RedisLookupKey {
+					t.Fatalf("expected a standalone key lookup, got mode %q lookup %q", redis.Mode, redis.Lookup)
+				}
+			},
+		},
+		{
+			name: "postgres",
+			settings: `
+matchesOnFailure: false
+database:
+  type: postgres
+  connectionTimeout: 500ms
+  postgres:
+    query: "SELECT 1 FROM customer_whitelisted_ips WHERE ip = $1 LIMIT 1"
+    host: postgres.example.com
+    port: 5432
+    databaseName: security
+    usernameEnv: POSTGRES_USER
+    passwordEnv: POSTGRES_PASSWORD
+    tls:
+      mode: verify-full
+      caCert: /path/to/ca.crt
+`,
+			check: func(t *testing.T, cfg attribute_match_database.AttributeMatchDatabaseConfig) {
+				postgres := cfg.Database.Postgres
+				if cfg.Database.Type != "postgres" || cfg.Database.ConnectionTimeout != "500ms" {
+					t.Fatalf("unexpected database %+v", cfg.Database)
+				}
+				if postgres.Query != "SELECT 1 FROM customer_whitelisted_ips WHERE ip = $1 LIMIT 1" || postgres.DatabaseName != "security" {
+					t.Fatalf("unexpected postgres settings %+v", postgres)
+				}
+				if postgres.UsernameEnv != "POSTGRES_USER" || postgres.PasswordEnv != "POSTGRES_PASSWORD" {
+					t.Fatalf("unexpected postgres credentials %+v", postgres)
+				}
+				if postgres.TLS == nil || postgres.TLS.Mode != "verify-full" {
+					t.Fatalf("unexpected postgres TLS %+v", postgres.TLS)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var settings map[string]any
+			if err := yaml.Unmarshal([]byte(tt.settings), &settings); err != nil {
+				t.Fatalf("invalid settings: %v", err)
+			}
+			cfg, err := attribute_match_database.DecodePresetSettings(ControllerKind, presetKey, settings)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			cfg.ApplyDefaults()
+			if cfg.Key.Source != attribute_match_database.KeySourceIP || cfg.MatchesOnFailure {
+				t.Fatalf("unexpected key or matchesOnFailure %+v", cfg)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package jwt_match
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/listsource"
+)
+
+const (
+	defaultTokenHeader = "authorization"
+	defaultTokenPrefix = "Bearer "
+	defaultLeeway      = "30s"
+)
+
+const (
+	// ClaimOperatorExact matches claims equal to one of the values
+	ClaimOperatorExact = "exact"
+	// ClaimOperatorPrefix matches claims starting with one of the values
+	ClaimOperatorPrefix = "prefix"
+	// ClaimOperatorRegex matches claims matched by one of the regular expressions
+	ClaimOperatorRegex = "regex"
+	// ClaimOperatorPresent matches tokens carrying the claim
+	ClaimOperatorPresent = "present"
+)
+
+var (
+	claimOperators = []string{ClaimOperatorExact, ClaimOperatorPrefix, ClaimOperatorRegex, ClaimOperatorPresent}
+
+	// supportedAlgorithms lists the signature algorithms tokens may use
+	supportedAlgorithms = []string{
+		"RS256", "RS384", "RS512",
+		"PS256", "PS384", "PS512",
+		"ES256", "ES384", "ES512",
+		"EdDSA",
+		"HS256", "HS384", "HS512",
+	}
+)
+
+// JWTMatchConfig represents the configuration of a jwt-match controller
+type JWTMatchConfig struct {
+	Token           TokenConfig        `yaml:"token"`
+	JWKS            string             `yaml:"jwks"`
+	Remote          *listsource.Config `yaml:"remote"`
+	Algorithms      []string           `yaml:"algorithms"`
+	Issuers         []string           `yaml:"issuers"`
+	Audiences       []string           `yaml:"audiences"`
+	Leeway          string             `yaml:"leeway"`
+	Claims          []ClaimConfig      `yaml:"claims"`
+	UpstreamHeaders map[string]string  `yaml:"upstreamHeaders"`
+}
+
+// TokenConfig represents where the token is read from. One of header, query
+// or cookie is used.
+type TokenConfig struct {
+	Header string `yaml:"header"`
+	Prefix string `yaml:"prefix"`
+	Query  string `yaml:"query"`
+	Cookie string `yaml:"cookie"`
+}
+
+// ClaimConfig represents a condition on a claim of the token
+type ClaimConfig struct {
+	Name     string   `yaml:"name"`
+	Operator string   `yaml:"operator"`
+	Values   []string `yaml:"values"`
+}
+
+// ApplyDefaults sets default values for the configuration
+func (c *JWTMatchConfig) ApplyDefaults() {
+	if c.Token.Header == "" && c.Token.Query == "" && c.Token.Cookie == "" {
+		c.Token.Header = defaultTokenHeader
+		if c.Token.Prefix == "" {
+			c.Token.Prefix = defaultTokenPrefix
+		}
+	}
+	if len(c.Algorithms) == 0 {
+		c.Algorithms = supportedAlgorithms
+	}
+	if c.Leeway == "" {
+		c.Leeway = defaultLeeway
+	}
+	for i := range c.Claims {
+		if c.Claims[i].Operator == "" {
+			c.Claims[i].Operator = ClaimOperatorExact
+		}
+	}
+}
+
+// Validate checks the configuration for completeness
+func (c *JWTMatchConfig) Validate() error {
+	sources := 0
+	for _, source := range []string{c.Token.Header, c.Token.Query, c.Token.Cookie} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("token requires exactly one of header, query or cookie")
+	}
+	if c.Token.Prefix != "" && c.Token.Header == "" {
+		return fmt.Errorf("token.prefix is only supported with token.header")
+	}
+
+	if c.JWKS == "" {
+		return fmt.Errorf("jwks is required")
+	}
+	if c.Remote != nil && !listsource.IsRemote(c.JWKS) {
+		return fmt.Errorf("remote settings require jwks to be an http(s) URL")
+	}
+	if err := c.Remote.Validate(); err != nil {
+		return err
+	}
+
+	for _, algorithm := range c.Algorithms {
+		if !slices.Contains(supportedAlgorithms, algorithm) {
+			return fmt.Errorf("algorithms must contain only '%s', got '%s'", strings.Join(supportedAlgorithms, "', '"), algorithm)
+		}
+	}
+	if slices.Contains(c.Issuers, "") {
+		return fmt.Errorf("issuers must not contain empty values")
+	}
+	if slices.Contains(c.Audiences, "") {
+		return fmt.Errorf("audiences must not contain empty values")
+	}
+	leeway, err := time.ParseDuration(c.Leeway)
+	if err != nil {
+		return fmt.Errorf("invalid leeway: %w", err)
+	}
+	if leeway < 0 {
+		return fmt.Errorf("leeway must not be negative")
+	}
+
+	for i := range c.Claims {
+		if err := c.Claims[i].validate(); err != nil {
+			return fmt.Errorf("claims[%d]: %w", i, err)
+		}
+	}
+
+	for name, claim := range c.UpstreamHeaders {
+		if name == "" || strings.ContainsAny(name, " :\t\r\n") {
+			return fmt.Errorf("upstreamHeaders: invalid header name '%s'", name)
+		}
+		if claim == "" {
+			return fmt.Errorf("upstreamHeaders: header '%s' requires a claim name", name)
+		}
+	}
+
+	return nil
+}
+
+// GetLeeway returns the parsed clock skew tolerated on exp and nbf
+func (c *JWTMatchConfig) GetLeeway() time.Duration {
+	leeway, _ := time.ParseDuration(c.Leeway)
+	return leeway
+}
+
+// validate checks the claim condition settings
+func (c *ClaimConfig) validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !slices.Contains(claimOperators, c.Operator) {
+		return fmt.Errorf("operator must be one of '%s', got '%s'", strings.Join(claimOperators, "', '"), c.Operator)
+	}
+
+	if c.Operator == ClaimOperatorPresent {
+		if len(c.Values) > 0 {
+			return fmt.Errorf("values are not supported with operator '%s'", ClaimOperatorPresent)
+		}
+		return nil
+	}
+	if len(c.Values) == 0 {
+		return fmt.Errorf("values is required with operator '%s'", c.Operator)
+	}
+	if c.Operator == ClaimOperatorRegex {
+		for _, value := range c.Values {
+			if _, err := regexp.Compile(value); err != nil {
+				return fmt.Errorf("invalid regex '%s': %w", value, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package jwt_match
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/listsource"
+)
+
+func TestJWTMatchConfigDefaults(t *testing.T) {
+	config := JWTMatchConfig{JWKS: "jwks.json", Claims: []ClaimConfig{{Name: "scope", Values: []string{"orders:read"}}}}
+	config.ApplyDefaults()
+
+	if config.Token.Header != "authorization" || config.Token.Prefix != "Bearer " {
+		t.Fatalf("expected the bearer token of the Authorization header, got %+v", config.Token)
+	}
+	if len(config.Algorithms) != len(supportedAlgorithms) {
+		t.Fatalf("expected every algorithm to be accepted, got %v", config.Algorithms)
+	}
+	if config.GetLeeway().String() != "30s" {
+		t.Fatalf("expected leeway 30s, got %s", config.GetLeeway())
+	}
+	if config.Claims[0].Operator != ClaimOperatorExact {
+		t.Fatalf("expected claim operator exact, got %s", config.Claims[0].Operator)
+	}
+
+	cookieConfig := JWTMatchConfig{JWKS: "jwks.json", Token: TokenConfig{Cookie: "session"}}
+	cookieConfig.ApplyDefaults()
+	if cookieConfig.Token.Header != "" || cookieConfig.Token.Prefix != "" {
+		t.Fatalf("expected no header defaults with token.cookie, got %+v", cookieConfig.Token)
+	}
+}
+
+func TestJWTMatchConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  JWTMatchConfig
+		wantErr string
+	}{
+		{
+			name:   "defaults",
+			config: JWTMatchConfig{JWKS: "jwks.json"},
+		},
+		{
+			name:   "remote JWKS with claims and headers",
+			config: JWTMatchConfig{JWKS: "https://issuer.example.com/.well-known/jwks.json", Remote: &listsource.Config{RefreshInterval: "5m"}, Algorithms: []string{"RS256"}, Issuers: []string{"https://issuer.example.com"}, Audiences: []string{"orders-api"}, Claims: []ClaimConfig{{Name: "email_verified", Operator: ClaimOperatorPresent}}, UpstreamHeaders: map[string]string{"X-User-Id": "sub"}},
+		},
+		{
+			name:    "two token sources",
+			config:  JWTMatchConfig{JWKS: "jwks.json", Token: TokenConfig{Header: "x-token", Query: "token"}},
+			wantErr: "token requires exactly one of header, query or cookie",
+		},
+		{
+			name:    "prefix without header",
+			config:  JWTMatchConfig{JWKS: "jwks.json", Token: TokenConfig{Query: "token", Prefix: "Bearer "}},
+			wantErr: "token.prefix is only supported with token.header",
+		},
+		{
+			name:    "missing JWKS",
+			config:  JWTMatchConfig{},
+			wantErr: "jwks is required",
+		},
+		{
+			name:    "remote settings with JWKS file",
+			config:  JWTMatchConfig{JWKS: "jwks.json", Remote: &listsource.Config{}},
+			wantErr: "remote settings require jwks to be an http(s) URL",
+		},
+		{
+			name:    "invalid remote settings",
+			config:  JWTMatchConfig{JWKS: "https://issuer.example.com/jwks.json", Remote: &listsource.Config{RefreshInterval: "soon"}},
+			wantErr: "invalid remote.refreshInterval",
+		},
+		{
+			name:    "unsupported algorithm",
+			config:  JWTMatchConfig{JWKS: "jwks.json", Algorithms: []string{"none"}},
+			wantErr: "algorithms must contain only",
+		},
+		{
+			name:    "empty issuer",
+			config:  JWTMatchConfig{JWKS: "jwks.json", Issuers: []string{""}},
+			wantErr: "issuers must not contain empty values",
+		},
+		{
+			name:    "empty audience",
+			config:  JWTMatchConfig{JWKS: "jwks.json", Audiences: []string{""}},
+			wantErr: "audiences must not contain empty values",
+		},
+		{
+			name:    "invalid leeway",
+			config:  JWTMatchConfig{JWKS: "jwks.json", Leeway: "a while"},
+			wantErr: "invalid leeway",
+		},
+		{
+			name:    "negative leeway",
+			config:  JWTMatchConfig{JWKS: "jwks.json", Leeway: "-1s"},
+			wantErr: "leeway must not be negative",
+		},
+		{
+			name:    "claim without name",
+			config:  JWTMatchConfig{JWKS: "jwks.json", Claims: []ClaimConfig{{Values: []string{"admin"}}}},
+			wantErr: "claims[0]: name is required",
+		},
+		{
+			name:    "unknown claim operator",
+			config:  JWTMatchConfig{JWKS: "jwks.json", Claims: []ClaimConfig{{Name: "roles", Operator: "contains", Values: []string{"admin"}}}},
+			wantErr: "claims[0]: operator must be one of",
+		},
+		{
+			name:    "present with values",
+			config:  JWTMatchConfig{JWKS: "jwks.json", Claims: []ClaimConfig{{Name: "roles", Operator: ClaimOperatorPresent, Values: []string{"admin"}}}},
+			wantErr: "values are not supported with operator 'present'",
+		},
+		{
+			name:    "missing claim values",
+			config:  JWTMatchConfig{JWKS: "jwks.json", Claims: []ClaimConfig{{Name: "roles", Operator: ClaimOperatorPrefix}}},
+			wantErr: "values is required with operator 'prefix'",
+		},
+		{
+			name:    "invalid claim regex",
+			config:  JWTMatchConfig{JWKS: "jwks.json", Claims: []ClaimConfig{{Name: "email", Operator: ClaimOperatorRegex, Values: []string{"(@example.com"}}}},
+			wantErr: "invalid regex '(@example.com'",
+		},
+		{
+			name:    "invalid upstream header name",
+			config:  JWTMatchConfig{JWKS: "jwks.json", UpstreamHeaders: map[string]string{"X User": "sub"}},
+			wantErr: "upstreamHeaders: invalid header name 'X User'",
+		},
+		{
+			name:    "upstream header without claim",
+			config:  JWTMatchConfig{JWKS: "jwks.json", UpstreamHeaders: map[string]string{"X-User-Id": ""}},
+			wantErr: "upstreamHeaders: header 'X-User-Id' requires a claim name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.ApplyDefaults()
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package jwt_match
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// errUnsupportedKey reports a well-formed key the controller cannot use, such
+// as a key of an unsupported curve
+var errUnsupportedKey = errors.New("unsupported key")
+
+// jwk is a JSON Web Key as found in a JWKS document
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC and OKP
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// Symmetric
+	K string `json:"k"`
+}
+
+// verificationKey is a parsed key able to verify token signatures
+type verificationKey struct {
+	id        string
+	algorithm string // restricts the key to one algorithm when set
+	public    any    // *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey or []byte
+}
+
+// keySet is the set of keys of a JWKS document
+type keySet []*verificationKey
+
+// parseJWKS parses a JWKS document. Keys not meant for signatures and
+// unsupported keys are skipped, so that providers can publish keys for other
+// consumers; a malformed key or a document without usable keys is rejected.
+func parseJWKS(content []byte, logger *zap.Logger) (keySet, error) {
+	var document struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("invalid JWKS document: %w", err)
+	}
+
+	var keys keySet
+	for i, key := range document.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			logger.Debug("JWKS key skipped", zap.Int("index", i), zap.String("kid", key.Kid), zap.String("reason", fmt.Sprintf("use '%s'", key.Use)))
+			continue
+		}
+		public, err := key.publicKey()
+		if errors.Is(err, errUnsupportedKey) {
+			logger.Debug("JWKS key skipped", zap.Int("index", i), zap.String("kid", key.Kid), zap.String("reason", err.Error()))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("keys[%d]: %w", i, err)
+		}
+		keys = append(keys, &verificationKey{id: key.Kid, algorithm: key.Alg, public: public})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS document contains no signature keys")
+	}
+	return keys, nil
+}
+
+// publicKey decodes the key material, failing with errUnsupportedKey for the
+// keys the controller cannot use
+func (k *jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		if n.BitLen() < 2048 {
+			return nil, fmt.Errorf("%w: RSA keys must be at least 2048 bits long", errUnsupportedKey)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("%w: EC curve '%s'", errUnsupportedKey, k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC x coordinate: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC y coordinate: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("EC point is not on curve %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("%w: OKP curve '%s'", errUnsupportedKey, k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), nil
+
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil || len(secret) == 0 {
+			return nil, fmt.Errorf("invalid symmetric key")
+		}
+		return secret, nil
+	}
+	return nil, fmt.Errorf("%w: key type '%s'", errUnsupportedKey, k.Kty)
+}
+
+// supports reports whether the key can verify signatures of the algorithm
+func (k *verificationKey) supports(algorithm string) bool {
+	if k.algorithm != "" && k.algorithm != algorithm {
+		return false
+	}
+
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(algorithm, "RS") || strings.HasPrefix(algorithm, "PS")
+	case *ecdsa.PublicKey:
+		switch algorithm {
+		case "ES256":
+			return public.Curve == elliptic.P256()
+		case "ES384":
+			return public.Curve == elliptic.P384()
+		case "ES512":
+			return public.Curve == elliptic.P521()
+		}
+	case ed25519.PublicKey:
+		return algorithm == "EdDSA"
+	case []byte:
+		return strings.HasPrefix(algorithm, "HS")
+	}
+	return false
+}
+
+// candidates returns the keys able to verify a token signed with the
+// algorithm by the key id, every compatible key when the token has no key id
+func (s keySet) candidates(keyID, algorithm string) []*verificationKey {
+	var keys []*verificationKey
+	for _, key := range s {
+		if keyID != "" && key.id != keyID {
+			continue
+		}
+		if key.supports(algorithm) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// decodeBigInt decodes a base64url big-endian unsigned integer
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
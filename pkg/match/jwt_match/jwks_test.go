@@ -0,0 +1,212 @@
+package jwt_match
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey := newRSAKey(t, "sig")
+	encryptionKey := newRSAKey(t, "enc")
+	encryptionKey.jwk["use"] = "enc"
+
+	keys, err := parseJWKS(jwksDocument(t, rsaKey, encryptionKey), zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to parse JWKS: %v", err)
+	}
+	if len(keys) != 1 || keys[0].id != "sig" {
+		t.Fatalf("expected only the signature key, got %d keys", len(keys))
+	}
+
+	rsaKey.jwk["alg"] = "PS256"
+	keys, _ = parseJWKS(jwksDocument(t, rsaKey), zap.NewNop())
+	if keys[0].supports("RS256") || !keys[0].supports("PS256") {
+		t.Fatalf("expected the key to be restricted to its alg")
+	}
+
+	if _, err := parseJWKS(jwksDocument(t, encryptionKey), zap.NewNop()); err == nil || !strings.Contains(err.Error(), "contains no signature keys") {
+		t.Fatalf("expected no signature keys error, got %v", err)
+	}
+	if _, err := parseJWKS([]byte("{"), zap.NewNop()); err == nil || !strings.Contains(err.Error(), "invalid JWKS document") {
+		t.Fatalf("expected invalid document error, got %v", err)
+	}
+	invalidCurve := newECKey(t, "p256", elliptic.P256(), "P-256")
+	invalidCurve.jwk["y"] = invalidCurve.jwk["x"]
+	if _, err := parseJWKS(jwksDocument(t, invalidCurve), zap.NewNop()); err == nil || !strings.Contains(err.Error(), "keys[0]: EC point is not on curve") {
+		t.Fatalf("expected invalid EC point error, got %v", err)
+	}
+}
+
+func TestParseJWKS_UnsupportedKeys(t *testing.T) {
+	rsaKey := newRSAKey(t, "sig")
+
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	weakKey := &testKey{private: weak, jwk: map[string]any{
+		"kty": "RSA",
+		"kid": "weak",
+		"n":   encodeBytes(weak.N.Bytes()),
+		"e":   encodeBytes(big.NewInt(int64(weak.E)).Bytes()),
+	}}
+	secp256k1Key := &testKey{jwk: map[string]any{"kty": "EC", "kid": "secp256k1", "crv": "secp256k1", "x": "AA", "y": "AA"}}
+	ed448Key := &testKey{jwk: map[string]any{"kty": "OKP", "kid": "ed448", "crv": "Ed448", "x": "AA"}}
+	unknownKey := &testKey{jwk: map[string]any{"kty": "AKP", "kid": "ml-dsa"}}
+
+	keys, err := parseJWKS(jwksDocument(t, weakKey, secp256k1Key, ed448Key, unknownKey, rsaKey), zap.NewNop())
+	if err != nil {
+		t.Fatalf("expected unsupported keys to be skipped, got %v", err)
+	}
+	if len(keys) != 1 || keys[0].id != "sig" {
+		t.Fatalf("expected only the supported key, got %d keys", len(keys))
+	}
+
+	if _, err := parseJWKS(jwksDocument(t, weakKey, ed448Key), zap.NewNop()); err == nil || !strings.Contains(err.Error(), "contains no signature keys") {
+		t.Fatalf("expected no signature keys error, got %v", err)
+	}
+}
+
+// testKey is a signing key with its JWK representation
+type testKey struct {
+	private any
+	jwk     map[string]any
+}
+
+func newRSAKey(t *testing.T, kid string) *testKey {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	return &testKey{private: private, jwk: map[string]any{
+		"kty": "RSA",
+		"kid": kid,
+		"n":   encodeBytes(private.N.Bytes()),
+		"e":   encodeBytes(big.NewInt(int64(private.E)).Bytes()),
+	}}
+}
+
+func newECKey(t *testing.T, kid string, curve elliptic.Curve, crv string) *testKey {
+	t.Helper()
+	private, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+	size := (curve.Params().BitSize + 7) / 8
+	return &testKey{private: private, jwk: map[string]any{
+		"kty": "EC",
+		"kid": kid,
+		"crv": crv,
+		"x":   encodeBytes(private.X.FillBytes(make([]byte, size))),
+		"y":   encodeBytes(private.Y.FillBytes(make([]byte, size))),
+	}}
+}
+
+func newEd25519Key(t *testing.T, kid string) *testKey {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %v", err)
+	}
+	return &testKey{private: private, jwk: map[string]any{
+		"kty": "OKP",
+		"kid": kid,
+		"crv": "Ed25519",
+		"x":   encodeBytes(public),
+	}}
+}
+
+func newHMACKey(kid string, secret string) *testKey {
+	return &testKey{private: []byte(secret), jwk: map[string]any{
+		"kty": "oct",
+		"kid": kid,
+		"k":   encodeBytes([]byte(secret)),
+	}}
+}
+
+// jwksDocument renders the public keys as a JWKS document
+func jwksDocument(t *testing.T, keys ...*testKey) []byte {
+	t.Helper()
+	document := map[string]any{"keys": []map[string]any{}}
+	for _, key := range keys {
+		document["keys"] = append(document["keys"].([]map[string]any), key.jwk)
+	}
+	content, err := json.Marshal(document)
+	if err != nil {
+		t.Fatalf("failed to encode JWKS: %v", err)
+	}
+	return content
+}
+
+// signToken signs the claims with the key, using the key id of its JWK
+func signToken(t *testing.T, key *testKey, algorithm string, tokenClaims map[string]any) string {
+	t.Helper()
+	header := map[string]any{"alg": algorithm, "typ": "JWT"}
+	if kid, _ := key.jwk["kid"].(string); kid != "" {
+		header["kid"] = kid
+	}
+	signingInput := encodeJSON(t, header) + "." + encodeJSON(t, tokenClaims)
+
+	hash := crypto.SHA256
+	if strings.HasSuffix(algorithm, "384") {
+		hash = crypto.SHA384
+	} else if strings.HasSuffix(algorithm, "512") {
+		hash = crypto.SHA512
+	}
+	hasher := hash.New()
+	hasher.Write([]byte(signingInput))
+	digest := hasher.Sum(nil)
+
+	var signature []byte
+	var err error
+	switch private := key.private.(type) {
+	case *rsa.PrivateKey:
+		if strings.HasPrefix(algorithm, "PS") {
+			signature, err = rsa.SignPSS(rand.Reader, private, hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, private, hash, digest)
+		}
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, private, digest)
+		size := (private.Curve.Params().BitSize + 7) / 8
+		signature = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(private, []byte(signingInput))
+	case []byte:
+		mac := hmac.New(hash.New, private)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	}
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	return signingInput + "." + encodeBytes(signature)
+}
+
+func encodeJSON(t *testing.T, value any) string {
+	t.Helper()
+	content, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("failed to encode token segment: %v", err)
+	}
+	return encodeBytes(content)
+}
+
+func encodeBytes(value []byte) string {
+	return base64.RawURLEncoding.EncodeToString(value)
+}
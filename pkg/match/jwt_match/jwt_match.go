@@ -0,0 +1,320 @@
+package jwt_match
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/listsource"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/metrics"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+const (
+	ControllerKind = "jwt-match"
+)
+
+// init registers the jwt-match match controller so it can be constructed
+// from configuration at runtime.
+func init() {
+	controller.RegisterMatchControllerFactory(ControllerKind, newJWTMatchController)
+}
+
+type jwtMatchController struct {
+	name            string
+	token           TokenConfig
+	verifier        *verifier
+	claims          []*claimCondition
+	upstreamHeaders map[string]string
+	keys            keySet
+	keysMu          sync.RWMutex
+	source          *listsource.Source // nil when the JWKS is read from a local file
+	logger          *zap.Logger
+}
+
+// claimCondition is a compiled condition on a claim
+type claimCondition struct {
+	name     string
+	operator string
+	values   []string
+	patterns []*regexp.Regexp
+}
+
+// SetInstrumentation injects the shared metrics instrumentation.
+func (c *jwtMatchController) SetInstrumentation(inst *metrics.Instrumentation) {
+	if c.source != nil {
+		c.source.SetInstrumentation(inst)
+	}
+}
+
+// Match implements controller.MatchController. The request matches when it
+// carries a valid token satisfying every claim condition.
+func (c *jwtMatchController) Match(ctx context.Context, req *runtime.RequestContext, reports controller.AnalysisReports) (*controller.MatchVerdict, error) {
+	raw, ok := c.extractToken(req)
+	if !ok {
+		// Requests without token are challenged for one, not offending
+		verdict := c.createUnauthenticatedVerdict("token missing", "")
+		verdict.DenyChallenge = true
+		return verdict, nil
+	}
+
+	c.keysMu.RLock()
+	keys := c.keys
+	c.keysMu.RUnlock()
+
+	tokenClaims, err := c.verifier.verify(raw, keys, time.Now())
+	if err != nil {
+		return c.createUnauthenticatedVerdict(fmt.Sprintf("token rejected: %v", err), "invalid_token"), nil
+	}
+
+	subject := tokenClaims.text("sub")
+	issuer := tokenClaims.text("iss")
+	logFields := []zap.Field{zap.String("jwt.sub", subject), zap.String("jwt.iss", issuer)}
+
+	for _, condition := range c.claims {
+		if !condition.match(tokenClaims) {
+			return &controller.MatchVerdict{
+				Controller:           c.name,
+				ControllerType:       ControllerKind,
+				DenyCode:             codes.PermissionDenied,
+				Description:          fmt.Sprintf("token of subject '%s' %s", subject, condition.describeMismatch()),
+				IsMatch:              false,
+				AllowUpstreamHeaders: c.makeUpstreamHeaders(nil),
+				LogFields:            logFields,
+			}, nil
+		}
+	}
+
+	return &controller.MatchVerdict{
+		Controller:           c.name,
+		ControllerType:       ControllerKind,
+		DenyCode:             codes.PermissionDenied,
+		Description:          fmt.Sprintf("token of subject '%s' issued by '%s' accepted", subject, issuer),
+		IsMatch:              true,
+		AllowUpstreamHeaders: c.makeUpstreamHeaders(tokenClaims),
+		LogFields:            logFields,
+	}, nil
+}
+
+// Name implements controller.MatchController.
+func (c *jwtMatchController) Name() string {
+	return c.name
+}
+
+// Kind implements controller.MatchController.
+func (c *jwtMatchController) Kind() string {
+	return ControllerKind
+}
+
+// HealthCheck implements controller.MatchController.
+func (c *jwtMatchController) HealthCheck(ctx context.Context) error {
+	if c.source != nil {
+		return c.source.HealthCheck()
+	}
+	// No external dependencies to check
+	return nil
+}
+
+// createUnauthenticatedVerdict builds the verdict of requests without a
+// valid token, challenging clients sending tokens in the Authorization header
+func (c *jwtMatchController) createUnauthenticatedVerdict(description, challengeError string) *controller.MatchVerdict {
+	verdict := &controller.MatchVerdict{
+		Controller:           c.name,
+		ControllerType:       ControllerKind,
+		DenyCode:             codes.Unauthenticated,
+		DenyMessage:          "unauthenticated",
+		Description:          description,
+		IsMatch:              false,
+		AllowUpstreamHeaders: c.makeUpstreamHeaders(nil),
+	}
+	if c.token.Header == defaultTokenHeader {
+		challenge := "Bearer"
+		if challengeError != "" {
+			challenge = fmt.Sprintf(`Bearer error="%s"`, challengeError)
+		}
+		verdict.DenyDownstreamHeaders = map[string]string{"WWW-Authenticate": challenge}
+	}
+	return verdict
+}
+
+// extractToken reads the token from the configured header, query parameter
+// or cookie
+func (c *jwtMatchController) extractToken(req *runtime.RequestContext) (string, bool) {
+	httpRequest := req.Request.GetAttributes().GetRequest().GetHttp()
+
+	var value string
+	switch {
+	case c.token.Header != "":
+		value = req.Header(c.token.Header)
+		if c.token.Prefix != "" {
+			if len(value) < len(c.token.Prefix) || !strings.EqualFold(value[:len(c.token.Prefix)], c.token.Prefix) {
+				return "", false
+			}
+			value = strings.TrimSpace(value[len(c.token.Prefix):])
+		}
+	case c.token.Query != "":
+		_, rawQuery, _ := strings.Cut(httpRequest.GetPath(), "?")
+		query, _ := url.ParseQuery(rawQuery)
+		value = query.Get(c.token.Query)
+	case c.token.Cookie != "":
+		value = req.Cookie(c.token.Cookie)
+	}
+
+	return value, value != ""
+}
+
+// makeUpstreamHeaders renders the configured claims as headers forwarded
+// upstream. Every header is set, empty for the claims the token does not
+// carry and for requests without an accepted token, so that the headers sent
+// by clients never reach the upstream.
+func (c *jwtMatchController) makeUpstreamHeaders(tokenClaims claims) map[string]string {
+	if len(c.upstreamHeaders) == 0 {
+		return nil
+	}
+
+	headers := make(map[string]string, len(c.upstreamHeaders))
+	for name, claim := range c.upstreamHeaders {
+		headers[name] = tokenClaims.text(claim)
+	}
+	return headers
+}
+
+// applyJWKS parses JWKS content and replaces the active keys.
+func (c *jwtMatchController) applyJWKS(content []byte) error {
+	keys, err := parseJWKS(content, c.logger)
+	if err != nil {
+		return err
+	}
+
+	c.keysMu.Lock()
+	c.keys = keys
+	c.keysMu.Unlock()
+
+	c.logger.Debug("JWKS applied", zap.Int("keys", len(keys)))
+	return nil
+}
+
+// match reports whether the token satisfies the condition
+func (c *claimCondition) match(tokenClaims claims) bool {
+	if c.operator == ClaimOperatorPresent {
+		_, ok := tokenClaims.lookup(c.name)
+		return ok
+	}
+
+	for _, value := range tokenClaims.values(c.name) {
+		switch c.operator {
+		case ClaimOperatorExact:
+			if slices.Contains(c.values, value) {
+				return true
+			}
+		case ClaimOperatorPrefix:
+			for _, prefix := range c.values {
+				if strings.HasPrefix(value, prefix) {
+					return true
+				}
+			}
+		case ClaimOperatorRegex:
+			for _, pattern := range c.patterns {
+				if pattern.MatchString(value) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// describeMismatch explains why the token does not satisfy the condition
+func (c *claimCondition) describeMismatch() string {
+	if c.operator == ClaimOperatorPresent {
+		return fmt.Sprintf("has no claim '%s'", c.name)
+	}
+	return fmt.Sprintf("claim '%s' did not match %s '%s'", c.name, c.operator, strings.Join(c.values, "', '"))
+}
+
+// newJWTMatchController loads the JWKS and prepares a controller.
+// When jwks is an http(s) URL the key set is fetched and refreshed in the background.
+func newJWTMatchController(ctx context.Context, logger *zap.Logger, cfg config.ControllerConfig) (controller.MatchController, error) {
+	var jwtMatchConfig JWTMatchConfig
+	if err := controller.DecodeControllerSettings(cfg.Settings, &jwtMatchConfig); err != nil {
+		return nil, err
+	}
+	jwtMatchConfig.ApplyDefaults()
+	if err := jwtMatchConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	ctrl := &jwtMatchController{
+		name:  cfg.Name,
+		token: jwtMatchConfig.Token,
+		verifier: &verifier{
+			algorithms: jwtMatchConfig.Algorithms,
+			issuers:    jwtMatchConfig.Issuers,
+			audiences:  jwtMatchConfig.Audiences,
+			leeway:     jwtMatchConfig.GetLeeway(),
+		},
+		upstreamHeaders: jwtMatchConfig.UpstreamHeaders,
+		logger:          logger,
+	}
+	ctrl.token.Header = strings.ToLower(ctrl.token.Header)
+	for _, claimConfig := range jwtMatchConfig.Claims {
+		condition := &claimCondition{
+			name:     claimConfig.Name,
+			operator: claimConfig.Operator,
+			values:   claimConfig.Values,
+		}
+		if condition.operator == ClaimOperatorRegex {
+			for _, value := range claimConfig.Values {
+				condition.patterns = append(condition.patterns, regexp.MustCompile(value))
+			}
+		}
+		ctrl.claims = append(ctrl.claims, condition)
+	}
+
+	if listsource.IsRemote(jwtMatchConfig.JWKS) {
+		source, err := listsource.New(jwtMatchConfig.JWKS, jwtMatchConfig.Remote, cfg.Name, ControllerKind, logger)
+		if err != nil {
+			return nil, fmt.Errorf("jwks source is not valid: %w", err)
+		}
+		if err := source.Start(ctx, ctrl.applyJWKS); err != nil {
+			return nil, fmt.Errorf("could not load jwks: %w", err)
+		}
+		ctrl.source = source
+	} else {
+		jwksFilePath, err := filepath.Abs(jwtMatchConfig.JWKS)
+		if err != nil {
+			return nil, fmt.Errorf("jwks path is not valid: %w", err)
+		}
+		content, err := os.ReadFile(jwksFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read jwks file: %w", err)
+		}
+		if err := ctrl.applyJWKS(content); err != nil {
+			return nil, fmt.Errorf("jwks file is not valid: %w", err)
+		}
+	}
+
+	ctrl.keysMu.RLock()
+	keysCount := len(ctrl.keys)
+	ctrl.keysMu.RUnlock()
+
+	logger.Info("controller initialized",
+		zap.Int("keys", keysCount),
+		zap.Strings("algorithms", jwtMatchConfig.Algorithms),
+		zap.Int("claims", len(ctrl.claims)),
+	)
+
+	return ctrl, nil
+}
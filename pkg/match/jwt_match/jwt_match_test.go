@@ -0,0 +1,281 @@
+package jwt_match
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+func TestMatch_Token(t *testing.T) {
+	key := newRSAKey(t, "primary")
+	ctrl := buildController(t, map[string]any{
+		"jwks":      writeJWKS(t, key),
+		"issuers":   []string{"https://issuer.example.com"},
+		"audiences": []string{"orders-api"},
+		"upstreamHeaders": map[string]string{
+			"X-User-Id": "sub",
+			"X-Roles":   "roles",
+			"X-Tenant":  "tenant",
+		},
+	})
+
+	validClaims := map[string]any{
+		"sub":   "alice",
+		"iss":   "https://issuer.example.com",
+		"aud":   "orders-api",
+		"roles": []string{"admin", "ops"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+
+	verdict := match(t, ctrl, map[string]string{"Authorization": "Bearer " + signToken(t, key, "RS256", validClaims)}, "/orders")
+	if !verdict.IsMatch {
+		t.Fatalf("expected valid token to match, got: %s", verdict.Description)
+	}
+	if verdict.Description != "token of subject 'alice' issued by 'https://issuer.example.com' accepted" {
+		t.Fatalf("unexpected description: %s", verdict.Description)
+	}
+	if verdict.AllowUpstreamHeaders["X-User-Id"] != "alice" || verdict.AllowUpstreamHeaders["X-Roles"] != "admin,ops" {
+		t.Fatalf("unexpected upstream headers: %v", verdict.AllowUpstreamHeaders)
+	}
+	if tenant, ok := verdict.AllowUpstreamHeaders["X-Tenant"]; !ok || tenant != "" {
+		t.Fatalf("expected headers of missing claims to be cleared, got %v", verdict.AllowUpstreamHeaders)
+	}
+
+	verdict = match(t, ctrl, map[string]string{"accept": "*/*", "x-user-id": "mallory"}, "/orders")
+	if verdict.IsMatch || verdict.DenyCode != codes.Unauthenticated || verdict.Description != "token missing" {
+		t.Fatalf("expected missing token to be unauthenticated, got %v: %s", verdict.DenyCode, verdict.Description)
+	}
+	if len(verdict.AllowUpstreamHeaders) != 3 || verdict.AllowUpstreamHeaders["X-User-Id"] != "" {
+		t.Fatalf("expected headers to be cleared without token, got %v", verdict.AllowUpstreamHeaders)
+	}
+	if verdict.DenyDownstreamHeaders["WWW-Authenticate"] != "Bearer" || !verdict.DenyChallenge {
+		t.Fatalf("expected bearer challenge, got %v", verdict.DenyDownstreamHeaders)
+	}
+
+	verdict = match(t, ctrl, map[string]string{"authorization": "Basic YWxpY2U6c2VjcmV0"}, "/orders")
+	if verdict.IsMatch || verdict.Description != "token missing" {
+		t.Fatalf("expected other schemes to carry no token, got: %s", verdict.Description)
+	}
+
+	expiredClaims := map[string]any{"sub": "alice", "iss": "https://issuer.example.com", "aud": "orders-api", "exp": time.Now().Add(-time.Hour).Unix()}
+	verdict = match(t, ctrl, map[string]string{"authorization": "bearer " + signToken(t, key, "RS256", expiredClaims)}, "/orders")
+	if verdict.IsMatch || verdict.DenyCode != codes.Unauthenticated || verdict.Description != "token rejected: token is expired" {
+		t.Fatalf("expected expired token to be unauthenticated, got %v: %s", verdict.DenyCode, verdict.Description)
+	}
+	if verdict.DenyDownstreamHeaders["WWW-Authenticate"] != `Bearer error="invalid_token"` || verdict.DenyChallenge {
+		t.Fatalf("expected invalid_token challenge, got %v", verdict.DenyDownstreamHeaders)
+	}
+}
+
+func TestMatch_Claims(t *testing.T) {
+	key := newEd25519Key(t, "ed")
+	ctrl := buildController(t, map[string]any{
+		"jwks": writeJWKS(t, key),
+		"claims": []map[string]any{
+			{"name": "scope", "values": []string{"orders:write"}},
+			{"name": "email", "operator": "regex", "values": []string{"@example\\.com$"}},
+			{"name": "realm_access.roles", "operator": "prefix", "values": []string{"partner-"}},
+			{"name": "email_verified", "operator": "present"},
+		},
+	})
+
+	valid := func() map[string]any {
+		return map[string]any{
+			"sub":            "partner-42",
+			"scope":          "orders:read orders:write",
+			"email":          "ops@example.com",
+			"email_verified": true,
+			"realm_access":   map[string]any{"roles": []string{"partner-acme"}},
+			"exp":            time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	tests := []struct {
+		name        string
+		claim       string
+		value       any
+		description string
+	}{
+		{name: "all claims satisfied"},
+		{name: "scope", claim: "scope", value: "orders:read", description: "token of subject 'partner-42' claim 'scope' did not match exact 'orders:write'"},
+		{name: "email", claim: "email", value: "ops@example.org", description: "token of subject 'partner-42' claim 'email' did not match regex '@example\\.com$'"},
+		{name: "nested roles", claim: "realm_access", value: map[string]any{"roles": []string{"employee"}}, description: "token of subject 'partner-42' claim 'realm_access.roles' did not match prefix 'partner-'"},
+		{name: "missing claim", claim: "email_verified", description: "token of subject 'partner-42' has no claim 'email_verified'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenClaims := valid()
+			if tt.claim != "" {
+				if tt.value == nil {
+					delete(tokenClaims, tt.claim)
+				} else {
+					tokenClaims[tt.claim] = tt.value
+				}
+			}
+
+			verdict := match(t, ctrl, map[string]string{"authorization": "Bearer " + signToken(t, key, "EdDSA", tokenClaims)}, "/")
+			if tt.description == "" {
+				if !verdict.IsMatch {
+					t.Fatalf("expected token to match, got: %s", verdict.Description)
+				}
+				return
+			}
+			if verdict.IsMatch || verdict.DenyCode != codes.PermissionDenied || verdict.Description != tt.description {
+				t.Fatalf("expected %q denied with PermissionDenied, got %v: %s", tt.description, verdict.DenyCode, verdict.Description)
+			}
+		})
+	}
+}
+
+func TestMatch_TokenSources(t *testing.T) {
+	key := newHMACKey("", "a-shared-secret-of-32-bytes-long")
+	jwks := writeJWKS(t, key)
+	token := signToken(t, key, "HS256", map[string]any{"sub": "webhook", "exp": time.Now().Add(time.Hour).Unix()})
+
+	tests := []struct {
+		name    string
+		token   map[string]any
+		headers map[string]string
+		path    string
+	}{
+		{name: "header without prefix", token: map[string]any{"header": "X-Webhook-Token"}, headers: map[string]string{"x-webhook-token": token}, path: "/hooks"},
+		{name: "query parameter", token: map[string]any{"query": "access_token"}, path: "/hooks?access_token=" + token},
+		{name: "cookie", token: map[string]any{"cookie": "session"}, headers: map[string]string{"cookie": "theme=dark; session=" + token}, path: "/hooks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := buildController(t, map[string]any{"jwks": jwks, "token": tt.token})
+			if verdict := match(t, ctrl, tt.headers, tt.path); !verdict.IsMatch {
+				t.Fatalf("expected token to match, got: %s", verdict.Description)
+			}
+			verdict := match(t, ctrl, nil, "/hooks")
+			if verdict.IsMatch || verdict.DenyCode != codes.Unauthenticated || len(verdict.DenyDownstreamHeaders) != 0 {
+				t.Fatalf("expected missing token without challenge, got %v: %s", verdict.DenyDownstreamHeaders, verdict.Description)
+			}
+		})
+	}
+}
+
+func TestNewJWTMatchController_RemoteJWKS(t *testing.T) {
+	oldKey := newRSAKey(t, "2024")
+	newKey := newRSAKey(t, "2025")
+
+	var documentMu sync.Mutex
+	document := jwksDocument(t, oldKey)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		documentMu.Lock()
+		defer documentMu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(document)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctrl, err := newJWTMatchController(ctx, zap.NewNop(), config.ControllerConfig{
+		Name:     "partner-jwt",
+		Type:     ControllerKind,
+		Settings: map[string]any{"jwks": server.URL, "remote": map[string]any{"refreshInterval": "10ms"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to build controller: %v", err)
+	}
+
+	claims := map[string]any{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix()}
+	if verdict := match(t, ctrl, map[string]string{"authorization": "Bearer " + signToken(t, oldKey, "RS256", claims)}, "/"); !verdict.IsMatch {
+		t.Fatalf("expected token of the fetched key to match, got: %s", verdict.Description)
+	}
+
+	documentMu.Lock()
+	document = jwksDocument(t, newKey)
+	documentMu.Unlock()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		verdict := match(t, ctrl, map[string]string{"authorization": "Bearer " + signToken(t, newKey, "RS256", claims)}, "/")
+		if verdict.IsMatch {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected rotated key to be picked up, got: %s", verdict.Description)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNewJWTMatchController_Errors(t *testing.T) {
+	dir := t.TempDir()
+	invalidJWKS := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalidJWKS, []byte(`{"keys": []}`), 0o600); err != nil {
+		t.Fatalf("failed to write JWKS: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		settings map[string]any
+		wantErr  string
+	}{
+		{name: "invalid settings", settings: map[string]any{}, wantErr: "configuration validation failed: jwks is required"},
+		{name: "missing file", settings: map[string]any{"jwks": filepath.Join(dir, "missing.json")}, wantErr: "could not read jwks file"},
+		{name: "no keys", settings: map[string]any{"jwks": invalidJWKS}, wantErr: "jwks file is not valid: JWKS document contains no signature keys"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newJWTMatchController(context.Background(), zap.NewNop(), config.ControllerConfig{Name: "partner-jwt", Type: ControllerKind, Settings: tt.settings})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func writeJWKS(t *testing.T, keys ...*testKey) string {
+	t.Helper()
+	location := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(location, jwksDocument(t, keys...), 0o600); err != nil {
+		t.Fatalf("failed to write JWKS: %v", err)
+	}
+	return location
+}
+
+func buildController(t *testing.T, settings map[string]any) controller.MatchController {
+	t.Helper()
+	ctrl, err := newJWTMatchController(context.Background(), zap.NewNop(), config.ControllerConfig{
+		Name:     "partner-jwt",
+		Type:     ControllerKind,
+		Settings: settings,
+	})
+	if err != nil {
+		t.Fatalf("failed to build controller: %v", err)
+	}
+	return ctrl
+}
+
+func match(t *testing.T, ctrl controller.MatchController, headers map[string]string, path string) *controller.MatchVerdict {
+	t.Helper()
+	req := &authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{
+			Request: &authv3.AttributeContext_Request{
+				Http: &authv3.AttributeContext_HttpRequest{Headers: headers, Path: path},
+			},
+		},
+	}
+	verdict, err := ctrl.Match(context.Background(), runtime.NewRequestContext(req), nil)
+	if err != nil {
+		t.Fatalf("match returned error: %v", err)
+	}
+	return verdict
+}
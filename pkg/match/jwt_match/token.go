@@ -0,0 +1,299 @@
+package jwt_match
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errTokenMalformed       = errors.New("token is malformed")
+	errAlgorithmNotAccepted = errors.New("token algorithm is not accepted")
+	errKeyNotFound          = errors.New("no key can verify the token")
+	errSignatureInvalid     = errors.New("token signature is invalid")
+	errTokenExpired         = errors.New("token is expired")
+	errExpirationMissing    = errors.New("token has no expiration")
+	errTokenNotYetValid     = errors.New("token is not valid yet")
+	errIssuerNotAccepted    = errors.New("token issuer is not accepted")
+	errAudienceNotAccepted  = errors.New("token audience is not accepted")
+)
+
+// claims are the decoded claims of a token
+type claims map[string]any
+
+// tokenHeader is the JOSE header of a token
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// verifier checks the signature and the registered claims of tokens
+type verifier struct {
+	algorithms []string
+	issuers    []string
+	audiences  []string
+	leeway     time.Duration
+}
+
+// verify parses the compact serialization of a token, verifies its signature
+// with the keys and its registered claims at now, and returns its claims
+func (v *verifier) verify(raw string, keys keySet, now time.Time) (claims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errTokenMalformed
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, errTokenMalformed
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errTokenMalformed
+	}
+	if !slices.Contains(v.algorithms, header.Algorithm) {
+		return nil, errAlgorithmNotAccepted
+	}
+
+	candidates := keys.candidates(header.KeyID, header.Algorithm)
+	if len(candidates) == 0 {
+		return nil, errKeyNotFound
+	}
+	signingInput := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range candidates {
+		if verifySignature(header.Algorithm, key.public, signingInput, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errSignatureInvalid
+	}
+
+	var tokenClaims claims
+	if err := decodeSegment(parts[1], &tokenClaims); err != nil || tokenClaims == nil {
+		return nil, errTokenMalformed
+	}
+	if err := v.validateClaims(tokenClaims, now); err != nil {
+		return nil, err
+	}
+	return tokenClaims, nil
+}
+
+// validateClaims checks the exp, nbf, iss and aud claims
+func (v *verifier) validateClaims(tokenClaims claims, now time.Time) error {
+	expiresAt, ok, err := tokenClaims.numericDate("exp")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errExpirationMissing
+	}
+	if !now.Before(expiresAt.Add(v.leeway)) {
+		return errTokenExpired
+	}
+
+	notBefore, ok, err := tokenClaims.numericDate("nbf")
+	if err != nil {
+		return err
+	}
+	if ok && now.Add(v.leeway).Before(notBefore) {
+		return errTokenNotYetValid
+	}
+
+	if len(v.issuers) > 0 {
+		issuer, _ := tokenClaims["iss"].(string)
+		if !slices.Contains(v.issuers, issuer) {
+			return errIssuerNotAccepted
+		}
+	}
+
+	if len(v.audiences) > 0 {
+		accepted := false
+		for _, audience := range tokenClaims.values("aud") {
+			if slices.Contains(v.audiences, audience) {
+				accepted = true
+				break
+			}
+		}
+		if !accepted {
+			return errAudienceNotAccepted
+		}
+	}
+
+	return nil
+}
+
+// numericDate returns a NumericDate claim, reporting whether the token has it
+func (c claims) numericDate(name string) (time.Time, bool, error) {
+	value, ok := c[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	number, isNumber := value.(json.Number)
+	if !isNumber {
+		return time.Time{}, false, fmt.Errorf("token claim '%s' is not a numeric date", name)
+	}
+	seconds, err := strconv.ParseFloat(number.String(), 64)
+	if err != nil || math.IsInf(seconds, 0) {
+		return time.Time{}, false, fmt.Errorf("token claim '%s' is not a numeric date", name)
+	}
+	integer, fraction := math.Modf(seconds)
+	return time.Unix(int64(integer), int64(fraction*1e9)), true, nil
+}
+
+// lookup returns the claim at a dot-separated path, such as
+// 'realm_access.roles'
+func (c claims) lookup(path string) (any, bool) {
+	var current any = map[string]any(c)
+	for segment := range strings.SplitSeq(path, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[segment]; !ok {
+			return nil, false
+		}
+	}
+	return current, current != nil
+}
+
+// values returns the string values of a claim: the elements of arrays, the
+// space-delimited scopes of the 'scope' claim and scalars as text
+func (c claims) values(path string) []string {
+	value, ok := c.lookup(path)
+	if !ok {
+		return nil
+	}
+
+	switch value := value.(type) {
+	case string:
+		if path == "scope" {
+			return strings.Fields(value)
+		}
+		return []string{value}
+	case []any:
+		var values []string
+		for _, element := range value {
+			if text, ok := scalarText(element); ok {
+				values = append(values, text)
+			}
+		}
+		return values
+	}
+	if text, ok := scalarText(value); ok {
+		return []string{text}
+	}
+	return nil
+}
+
+// text renders a claim as a header value: scalars as text, arrays of
+// scalars comma-separated and objects as JSON
+func (c claims) text(path string) string {
+	value, ok := c.lookup(path)
+	if !ok {
+		return ""
+	}
+	if text, ok := scalarText(value); ok {
+		return text
+	}
+	if array, ok := value.([]any); ok {
+		var values []string
+		for _, element := range array {
+			text, ok := scalarText(element)
+			if !ok {
+				values = nil
+				break
+			}
+			values = append(values, text)
+		}
+		if values != nil {
+			return strings.Join(values, ",")
+		}
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
+
+// scalarText renders strings, numbers and booleans as text
+func scalarText(value any) (string, bool) {
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case json.Number:
+		return value.String(), true
+	case bool:
+		return strconv.FormatBool(value), true
+	}
+	return "", false
+}
+
+// decodeSegment decodes a base64url JSON segment of a token, keeping numbers
+// as json.Number
+func decodeSegment(segment string, target any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	return decoder.Decode(target)
+}
+
+// verifySignature verifies the signature of the signing input with the key,
+// which must support the algorithm
+func verifySignature(algorithm string, key any, signingInput, signature []byte) bool {
+	hash := crypto.SHA256
+	switch algorithm[len(algorithm)-3:] {
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	}
+
+	if algorithm == "EdDSA" {
+		public, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(public, signingInput, signature)
+	}
+
+	hasher := hash.New()
+	hasher.Write(signingInput)
+	digest := hasher.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if strings.HasPrefix(algorithm, "PS") {
+			return rsa.VerifyPSS(key, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+		return rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(key, digest, r, s)
+	case []byte:
+		mac := hmac.New(hash.New, key)
+		mac.Write(signingInput)
+		return hmac.Equal(mac.Sum(nil), signature)
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package jwt_match
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestVerify_Algorithms(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa")
+	p256Key := newECKey(t, "p256", elliptic.P256(), "P-256")
+	p384Key := newECKey(t, "p384", elliptic.P384(), "P-384")
+	p521Key := newECKey(t, "p521", elliptic.P521(), "P-521")
+	edKey := newEd25519Key(t, "ed")
+	hmacKey := newHMACKey("hmac", "a-shared-secret-of-32-bytes-long")
+
+	keys, err := parseJWKS(jwksDocument(t, rsaKey, p256Key, p384Key, p521Key, edKey, hmacKey), zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to parse JWKS: %v", err)
+	}
+
+	v := &verifier{algorithms: supportedAlgorithms}
+	now := time.Now()
+	tokenClaims := map[string]any{"sub": "alice", "exp": now.Add(time.Hour).Unix()}
+
+	tests := []struct {
+		algorithm string
+		key       *testKey
+	}{
+		{"RS256", rsaKey}, {"RS384", rsaKey}, {"RS512", rsaKey},
+		{"PS256", rsaKey}, {"PS384", rsaKey}, {"PS512", rsaKey},
+		{"ES256", p256Key}, {"ES384", p384Key}, {"ES512", p521Key},
+		{"EdDSA", edKey},
+		{"HS256", hmacKey}, {"HS384", hmacKey}, {"HS512", hmacKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			token := signToken(t, tt.key, tt.algorithm, tokenClaims)
+			verified, err := v.verify(token, keys, now)
+			if err != nil {
+				t.Fatalf("expected token to verify, got %v", err)
+			}
+			if verified["sub"] != "alice" {
+				t.Fatalf("expected subject alice, got %v", verified["sub"])
+			}
+
+			parts := strings.Split(token, ".")
+			tampered := parts[0] + "." + encodeJSON(t, map[string]any{"sub": "mallory", "exp": now.Add(time.Hour).Unix()}) + "." + parts[2]
+			if _, err := v.verify(tampered, keys, now); !errors.Is(err, errSignatureInvalid) {
+				t.Fatalf("expected tampered token to be rejected, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerify_Rejections(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa")
+	otherKey := newRSAKey(t, "rsa")
+	hmacKey := newHMACKey("", "a-shared-secret-of-32-bytes-long")
+	keys, err := parseJWKS(jwksDocument(t, rsaKey), zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to parse JWKS: %v", err)
+	}
+
+	now := time.Now()
+	v := &verifier{
+		algorithms: []string{"RS256", "HS256"},
+		issuers:    []string{"https://issuer.example.com"},
+		audiences:  []string{"orders-api"},
+		leeway:     30 * time.Second,
+	}
+	valid := func() map[string]any {
+		return map[string]any{
+			"iss": "https://issuer.example.com",
+			"aud": []string{"billing-api", "orders-api"},
+			"exp": now.Add(time.Minute).Unix(),
+		}
+	}
+	with := func(key string, value any) map[string]any {
+		tokenClaims := valid()
+		if value == nil {
+			delete(tokenClaims, key)
+		} else {
+			tokenClaims[key] = value
+		}
+		return tokenClaims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{name: "valid", token: signToken(t, rsaKey, "RS256", valid())},
+		{name: "expired within leeway", token: signToken(t, rsaKey, "RS256", with("exp", now.Add(-10*time.Second).Unix()))},
+		{name: "not before within leeway", token: signToken(t, rsaKey, "RS256", with("nbf", now.Add(10*time.Second).Unix()))},
+		{name: "audience string", token: signToken(t, rsaKey, "RS256", with("aud", "orders-api"))},
+		{name: "malformed", token: "not-a-token", want: errTokenMalformed},
+		{name: "algorithm none", token: encodeJSON(t, map[string]any{"alg": "none"}) + "." + encodeJSON(t, valid()) + ".", want: errAlgorithmNotAccepted},
+		{name: "algorithm not accepted", token: signToken(t, rsaKey, "RS512", valid()), want: errAlgorithmNotAccepted},
+		{name: "HMAC with the RSA public key", token: signToken(t, hmacKey, "HS256", valid()), want: errKeyNotFound},
+		{name: "unknown key", token: signToken(t, otherKey, "RS256", valid()), want: errSignatureInvalid},
+		{name: "expired", token: signToken(t, rsaKey, "RS256", with("exp", now.Add(-time.Minute).Unix())), want: errTokenExpired},
+		{name: "no expiration", token: signToken(t, rsaKey, "RS256", with("exp", nil)), want: errExpirationMissing},
+		{name: "not before", token: signToken(t, rsaKey, "RS256", with("nbf", now.Add(time.Minute).Unix())), want: errTokenNotYetValid},
+		{name: "issuer", token: signToken(t, rsaKey, "RS256", with("iss", "https://evil.example.com")), want: errIssuerNotAccepted},
+		{name: "audience", token: signToken(t, rsaKey, "RS256", with("aud", "billing-api")), want: errAudienceNotAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.verify(tt.token, keys, now)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("expected token to verify, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if _, err := v.verify(signToken(t, rsaKey, "RS256", with("exp", "tomorrow")), keys, now); err == nil || !strings.Contains(err.Error(), "token claim 'exp' is not a numeric date") {
+		t.Fatalf("expected numeric date error, got %v", err)
+	}
+}
+
+func TestClaimsValues(t *testing.T) {
+	tokenClaims := claims{}
+	if err := decodeSegment(encodeJSON(t, map[string]any{
+		"scope":        "orders:read orders:write",
+		"roles":        []any{"admin", "ops"},
+		"realm_access": map[string]any{"roles": []any{"auditor"}},
+		"tenant_id":    42,
+		"verified":     true,
+	}), &tokenClaims); err != nil {
+		t.Fatalf("failed to decode claims: %v", err)
+	}
+
+	tests := []struct {
+		path   string
+		values []string
+		text   string
+	}{
+		{path: "scope", values: []string{"orders:read", "orders:write"}, text: "orders:read orders:write"},
+		{path: "roles", values: []string{"admin", "ops"}, text: "admin,ops"},
+		{path: "realm_access.roles", values: []string{"auditor"}, text: "auditor"},
+		{path: "realm_access", text: `{"roles":["auditor"]}`},
+		{path: "tenant_id", values: []string{"42"}, text: "42"},
+		{path: "verified", values: []string{"true"}, text: "true"},
+		{path: "missing"},
+		{path: "scope.nested"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := tokenClaims.values(tt.path); strings.Join(got, "|") != strings.Join(tt.values, "|") {
+				t.Fatalf("expected values %v, got %v", tt.values, got)
+			}
+			if got := tokenClaims.text(tt.path); got != tt.text {
+				t.Fatalf("expected text %q, got %q", tt.text, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package peer_identity_match
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+const spiffeScheme = "spiffe://"
+
+// PeerIdentityMatchConfig represents the configuration of a
+// peer-identity-match controller. The peer matches when its identity matches
+// any of spiffeIds, trustDomains, subject, dnsSans or uriSans, and its
+// certificate passes the certificate checks.
+type PeerIdentityMatchConfig struct {
+	SpiffeIDs    []string           `yaml:"spiffeIds"`
+	TrustDomains []string           `yaml:"trustDomains"`
+	Subject      *NameConfig        `yaml:"subject"`
+	DNSSANs      []string           `yaml:"dnsSans"`
+	URISANs      []string           `yaml:"uriSans"`
+	Certificate  *CertificateConfig `yaml:"certificate"`
+}
+
+// NameConfig represents glob patterns on the fields of a distinguished name.
+// Every configured field must match.
+type NameConfig struct {
+	CommonName         []string `yaml:"commonName"`
+	Organization       []string `yaml:"organization"`
+	OrganizationalUnit []string `yaml:"organizationalUnit"`
+	Country            []string `yaml:"country"`
+}
+
+// CertificateConfig represents checks on the certificate forwarded by Envoy.
+// When configured, requests without a forwarded certificate never match.
+type CertificateConfig struct {
+	Issuer        *NameConfig `yaml:"issuer"`
+	CheckValidity bool        `yaml:"checkValidity"`
+}
+
+// Validate checks the configuration for completeness
+func (c *PeerIdentityMatchConfig) Validate() error {
+	if len(c.SpiffeIDs) == 0 && len(c.TrustDomains) == 0 && c.Subject == nil && len(c.DNSSANs) == 0 && len(c.URISANs) == 0 && c.Certificate == nil {
+		return fmt.Errorf("at least one of spiffeIds, trustDomains, subject, dnsSans, uriSans or certificate is required")
+	}
+
+	for _, pattern := range c.SpiffeIDs {
+		if !strings.HasPrefix(pattern, spiffeScheme) || len(pattern) == len(spiffeScheme) {
+			return fmt.Errorf("spiffeIds: '%s' must start with '%s' followed by the trust domain", pattern, spiffeScheme)
+		}
+		if err := validateGlob(pattern); err != nil {
+			return fmt.Errorf("spiffeIds: %w", err)
+		}
+	}
+	for _, trustDomain := range c.TrustDomains {
+		if trustDomain == "" || strings.ContainsAny(trustDomain, ":/") || trustDomain != strings.ToLower(trustDomain) {
+			return fmt.Errorf("trustDomains: '%s' must be a lowercase trust domain name, without scheme nor path", trustDomain)
+		}
+	}
+	if c.Subject != nil {
+		if err := c.Subject.validate(); err != nil {
+			return fmt.Errorf("subject.%w", err)
+		}
+	}
+	for _, pattern := range c.DNSSANs {
+		if err := validateGlob(pattern); err != nil {
+			return fmt.Errorf("dnsSans: %w", err)
+		}
+	}
+	for _, pattern := range c.URISANs {
+		if err := validateGlob(pattern); err != nil {
+			return fmt.Errorf("uriSans: %w", err)
+		}
+	}
+
+	if c.Certificate != nil {
+		if c.Certificate.Issuer == nil && !c.Certificate.CheckValidity {
+			return fmt.Errorf("certificate requires issuer or checkValidity")
+		}
+		if c.Certificate.Issuer != nil {
+			if err := c.Certificate.Issuer.validate(); err != nil {
+				return fmt.Errorf("certificate.issuer.%w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validate checks the distinguished name patterns
+func (n *NameConfig) validate() error {
+	fields := []struct {
+		name     string
+		patterns []string
+	}{
+		{name: "commonName", patterns: n.CommonName},
+		{name: "organization", patterns: n.Organization},
+		{name: "organizationalUnit", patterns: n.OrganizationalUnit},
+		{name: "country", patterns: n.Country},
+	}
+
+	configured := false
+	for _, field := range fields {
+		for _, pattern := range field.patterns {
+			configured = true
+			if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+				return fmt.Errorf("%s: invalid pattern '%s'", field.name, pattern)
+			}
+		}
+	}
+	if !configured {
+		return fmt.Errorf("commonName, organization, organizationalUnit or country is required")
+	}
+	return nil
+}
+
+// validateGlob checks a glob pattern of identifiers
+func validateGlob(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("patterns must not be empty")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern '%s'", pattern)
+	}
+	return nil
+}
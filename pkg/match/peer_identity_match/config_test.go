@@ -0,0 +1,102 @@
+package peer_identity_match
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPeerIdentityMatchConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  PeerIdentityMatchConfig
+		wantErr string
+	}{
+		{
+			name:   "spiffe ids",
+			config: PeerIdentityMatchConfig{SpiffeIDs: []string{"spiffe://prod.example.org/ns/payments/sa/*", "spiffe://*.example.org/**"}},
+		},
+		{
+			name:   "all identities",
+			config: PeerIdentityMatchConfig{TrustDomains: []string{"prod.example.org"}, Subject: &NameConfig{Organization: []string{"Example"}}, DNSSANs: []string{"*.svc.cluster.local"}, URISANs: []string{"https://example.org/*"}},
+		},
+		{
+			name:   "certificate checks only",
+			config: PeerIdentityMatchConfig{Certificate: &CertificateConfig{CheckValidity: true}},
+		},
+		{
+			name:    "no identity",
+			config:  PeerIdentityMatchConfig{},
+			wantErr: "at least one of spiffeIds, trustDomains, subject, dnsSans, uriSans or certificate is required",
+		},
+		{
+			name:    "spiffe id without scheme",
+			config:  PeerIdentityMatchConfig{SpiffeIDs: []string{"prod.example.org/ns/payments"}},
+			wantErr: "spiffeIds: 'prod.example.org/ns/payments' must start with 'spiffe://' followed by the trust domain",
+		},
+		{
+			name:    "spiffe id without trust domain",
+			config:  PeerIdentityMatchConfig{SpiffeIDs: []string{"spiffe://"}},
+			wantErr: "must start with 'spiffe://' followed by the trust domain",
+		},
+		{
+			name:    "invalid spiffe id pattern",
+			config:  PeerIdentityMatchConfig{SpiffeIDs: []string{"spiffe://prod.example.org/[ns"}},
+			wantErr: "spiffeIds: invalid pattern 'spiffe://prod.example.org/[ns'",
+		},
+		{
+			name:    "trust domain with scheme",
+			config:  PeerIdentityMatchConfig{TrustDomains: []string{"spiffe://prod.example.org"}},
+			wantErr: "trustDomains: 'spiffe://prod.example.org' must be a lowercase trust domain name",
+		},
+		{
+			name:    "uppercase trust domain",
+			config:  PeerIdentityMatchConfig{TrustDomains: []string{"Prod.example.org"}},
+			wantErr: "must be a lowercase trust domain name",
+		},
+		{
+			name:    "empty subject",
+			config:  PeerIdentityMatchConfig{Subject: &NameConfig{}},
+			wantErr: "subject.commonName, organization, organizationalUnit or country is required",
+		},
+		{
+			name:    "invalid subject pattern",
+			config:  PeerIdentityMatchConfig{Subject: &NameConfig{OrganizationalUnit: []string{"[payments"}}},
+			wantErr: "subject.organizationalUnit: invalid pattern '[payments'",
+		},
+		{
+			name:    "empty dns san",
+			config:  PeerIdentityMatchConfig{DNSSANs: []string{""}},
+			wantErr: "dnsSans: patterns must not be empty",
+		},
+		{
+			name:    "invalid uri san pattern",
+			config:  PeerIdentityMatchConfig{URISANs: []string{"https://[example.org"}},
+			wantErr: "uriSans: invalid pattern",
+		},
+		{
+			name:    "empty certificate checks",
+			config:  PeerIdentityMatchConfig{Certificate: &CertificateConfig{}},
+			wantErr: "certificate requires issuer or checkValidity",
+		},
+		{
+			name:    "invalid issuer",
+			config:  PeerIdentityMatchConfig{Certificate: &CertificateConfig{Issuer: &NameConfig{}}},
+			wantErr: "certificate.issuer.commonName, organization, organizationalUnit or country is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
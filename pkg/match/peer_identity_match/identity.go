@@ -0,0 +1,161 @@
+package peer_identity_match
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+)
+
+var (
+	errCertificateNotPEM = errors.New("certificate is not PEM encoded")
+)
+
+// peerIdentity represents the identity of the downstream peer of a mTLS
+// connection, as forwarded by Envoy
+type peerIdentity struct {
+	principal   string
+	spiffeIDs   []string
+	uriSANs     []string
+	dnsSANs     []string
+	certificate *x509.Certificate // nil when Envoy does not forward it
+}
+
+// newPeerIdentity extracts the identity of the peer from the source of a
+// request. The SANs are read from the forwarded certificate; without it, the
+// principal is the only SAN available.
+func newPeerIdentity(source *authv3.AttributeContext_Peer) (*peerIdentity, error) {
+	identity := &peerIdentity{principal: source.GetPrincipal()}
+
+	if encoded := source.GetCertificate(); encoded != "" {
+		certificate, err := parseCertificate(encoded)
+		if err != nil {
+			return identity, err
+		}
+		identity.certificate = certificate
+		for _, uri := range certificate.URIs {
+			identity.uriSANs = append(identity.uriSANs, uri.String())
+		}
+		identity.dnsSANs = certificate.DNSNames
+	} else if identity.principal != "" {
+		switch {
+		case strings.Contains(identity.principal, "://"):
+			identity.uriSANs = []string{identity.principal}
+		case !strings.ContainsAny(identity.principal, "=, "):
+			identity.dnsSANs = []string{identity.principal}
+		}
+	}
+
+	for _, uri := range identity.uriSANs {
+		if strings.HasPrefix(uri, spiffeScheme) {
+			identity.spiffeIDs = append(identity.spiffeIDs, uri)
+		}
+	}
+
+	return identity, nil
+}
+
+// String returns the identity reported for the peer: its principal or, when
+// Envoy does not forward it, the first identity of its certificate
+func (p *peerIdentity) String() string {
+	switch {
+	case p.principal != "":
+		return p.principal
+	case len(p.uriSANs) > 0:
+		return p.uriSANs[0]
+	case len(p.dnsSANs) > 0:
+		return p.dnsSANs[0]
+	case p.certificate != nil:
+		return p.certificate.Subject.String()
+	}
+	return ""
+}
+
+// parseCertificate decodes the URL-encoded PEM certificate forwarded by Envoy
+func parseCertificate(encoded string) (*x509.Certificate, error) {
+	decoded, err := url.PathUnescape(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("certificate is not URL encoded: %w", err)
+	}
+	block, _ := pem.Decode([]byte(decoded))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errCertificateNotPEM
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// spiffeTrustDomain returns the trust domain of a SPIFFE ID
+func spiffeTrustDomain(spiffeID string) string {
+	trustDomain, _, _ := strings.Cut(strings.TrimPrefix(spiffeID, spiffeScheme), "/")
+	return strings.ToLower(trustDomain)
+}
+
+// matchGlob reports whether value matches pattern, both split in segments by
+// separator. '*' matches within a segment and a '**' segment matches any
+// number of segments.
+func matchGlob(pattern, value, separator string) bool {
+	return matchSegments(strings.Split(pattern, separator), strings.Split(value, separator))
+}
+
+func matchSegments(patterns, values []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			for skipped := 0; skipped <= len(values); skipped++ {
+				if matchSegments(patterns[1:], values[skipped:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(values) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(patterns[0], values[0]); !ok {
+			return false
+		}
+		patterns, values = patterns[1:], values[1:]
+	}
+	return len(values) == 0
+}
+
+// matchAny returns the first pattern matching any of values, if any
+func matchAny(patterns, values []string, separator string) (string, bool) {
+	for _, pattern := range patterns {
+		for _, value := range values {
+			if matchGlob(pattern, value, separator) {
+				return pattern, true
+			}
+		}
+	}
+	return "", false
+}
+
+// matchName reports whether a distinguished name matches every configured
+// field of config, returning the first field that does not match otherwise
+func matchName(config *NameConfig, name pkix.Name) (string, bool) {
+	fields := []struct {
+		name     string
+		patterns []string
+		values   []string
+	}{
+		{name: "commonName", patterns: config.CommonName, values: []string{name.CommonName}},
+		{name: "organization", patterns: config.Organization, values: name.Organization},
+		{name: "organizationalUnit", patterns: config.OrganizationalUnit, values: name.OrganizationalUnit},
+		{name: "country", patterns: config.Country, values: name.Country},
+	}
+	for _, field := range fields {
+		if len(field.patterns) == 0 {
+			continue
+		}
+		if _, ok := matchAny(field.patterns, field.values, "\x00"); !ok {
+			return field.name, false
+		}
+	}
+	return "", true
+}
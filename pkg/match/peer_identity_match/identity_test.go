@@ -0,0 +1,188 @@
+package peer_identity_match
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		value     string
+		separator string
+		match     bool
+	}{
+		{pattern: "spiffe://prod.example.org/ns/payments/sa/api", value: "spiffe://prod.example.org/ns/payments/sa/api", separator: "/", match: true},
+		{pattern: "spiffe://prod.example.org/ns/*/sa/api", value: "spiffe://prod.example.org/ns/payments/sa/api", separator: "/", match: true},
+		{pattern: "spiffe://prod.example.org/ns/*", value: "spiffe://prod.example.org/ns/payments/sa/api", separator: "/"},
+		{pattern: "spiffe://prod.example.org/ns/**", value: "spiffe://prod.example.org/ns/payments/sa/api", separator: "/", match: true},
+		{pattern: "spiffe://prod.example.org/**/sa/api", value: "spiffe://prod.example.org/ns/payments/sa/api", separator: "/", match: true},
+		{pattern: "spiffe://*.example.org/**", value: "spiffe://prod.example.org/ns/payments", separator: "/", match: true},
+		{pattern: "spiffe://*.example.org/**", value: "spiffe://prod.example.com/ns/payments", separator: "/"},
+		{pattern: "*.svc.cluster.local", value: "api.payments.svc.cluster.local", separator: "."},
+		{pattern: "*.*.svc.cluster.local", value: "api.payments.svc.cluster.local", separator: ".", match: true},
+		{pattern: "**.svc.cluster.local", value: "api.payments.svc.cluster.local", separator: ".", match: true},
+		{pattern: "**.example.org", value: "example.org", separator: ".", match: true},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.value, tt.separator); got != tt.match {
+			t.Fatalf("%q against %q: expected %v, got %v", tt.pattern, tt.value, tt.match, got)
+		}
+	}
+}
+
+func TestNewPeerIdentity(t *testing.T) {
+	certificate := newCertificate(t, certificateTemplate{
+		subject:  pkix.Name{CommonName: "payments-api", Organization: []string{"Example"}},
+		uris:     []string{"spiffe://prod.example.org/ns/payments/sa/api", "https://payments.example.org/api"},
+		dnsNames: []string{"api.payments.svc.cluster.local"},
+	})
+
+	tests := []struct {
+		name      string
+		source    *authv3.AttributeContext_Peer
+		identity  string
+		spiffeIDs []string
+		uriSANs   []string
+		dnsSANs   []string
+		wantErr   string
+	}{
+		{
+			name:      "certificate",
+			source:    &authv3.AttributeContext_Peer{Principal: "spiffe://prod.example.org/ns/payments/sa/api", Certificate: certificate},
+			identity:  "spiffe://prod.example.org/ns/payments/sa/api",
+			spiffeIDs: []string{"spiffe://prod.example.org/ns/payments/sa/api"},
+			uriSANs:   []string{"spiffe://prod.example.org/ns/payments/sa/api", "https://payments.example.org/api"},
+			dnsSANs:   []string{"api.payments.svc.cluster.local"},
+		},
+		{
+			name:     "certificate without principal",
+			source:   &authv3.AttributeContext_Peer{Certificate: certificate},
+			identity: "spiffe://prod.example.org/ns/payments/sa/api",
+		},
+		{
+			name:      "spiffe principal",
+			source:    &authv3.AttributeContext_Peer{Principal: "spiffe://prod.example.org/ns/payments/sa/api"},
+			identity:  "spiffe://prod.example.org/ns/payments/sa/api",
+			spiffeIDs: []string{"spiffe://prod.example.org/ns/payments/sa/api"},
+			uriSANs:   []string{"spiffe://prod.example.org/ns/payments/sa/api"},
+		},
+		{
+			name:     "dns principal",
+			source:   &authv3.AttributeContext_Peer{Principal: "api.payments.svc.cluster.local"},
+			identity: "api.payments.svc.cluster.local",
+			dnsSANs:  []string{"api.payments.svc.cluster.local"},
+		},
+		{
+			name:     "subject principal",
+			source:   &authv3.AttributeContext_Peer{Principal: "CN=payments-api,O=Example"},
+			identity: "CN=payments-api,O=Example",
+		},
+		{
+			name:   "no peer",
+			source: nil,
+		},
+		{
+			name:    "invalid certificate",
+			source:  &authv3.AttributeContext_Peer{Certificate: url.PathEscape("not a certificate")},
+			wantErr: "certificate is not PEM encoded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := newPeerIdentity(tt.source)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if identity.String() != tt.identity {
+				t.Fatalf("expected identity %q, got %q", tt.identity, identity.String())
+			}
+			if tt.spiffeIDs != nil && !slices.Equal(identity.spiffeIDs, tt.spiffeIDs) {
+				t.Fatalf("expected spiffe ids %v, got %v", tt.spiffeIDs, identity.spiffeIDs)
+			}
+			if tt.uriSANs != nil && !slices.Equal(identity.uriSANs, tt.uriSANs) {
+				t.Fatalf("expected uri sans %v, got %v", tt.uriSANs, identity.uriSANs)
+			}
+			if tt.dnsSANs != nil && !slices.Equal(identity.dnsSANs, tt.dnsSANs) {
+				t.Fatalf("expected dns sans %v, got %v", tt.dnsSANs, identity.dnsSANs)
+			}
+		})
+	}
+}
+
+type certificateTemplate struct {
+	subject   pkix.Name
+	issuer    pkix.Name
+	uris      []string
+	dnsNames  []string
+	notBefore time.Time
+	notAfter  time.Time
+}
+
+// newCertificate returns a certificate signed by a throwaway CA, URL-encoded
+// as forwarded by Envoy
+func newCertificate(t *testing.T, template certificateTemplate) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	if template.issuer.CommonName == "" {
+		template.issuer = pkix.Name{CommonName: "Example Internal CA", Organization: []string{"Example"}}
+	}
+	if template.notBefore.IsZero() {
+		template.notBefore = time.Now().Add(-time.Hour)
+	}
+	if template.notAfter.IsZero() {
+		template.notAfter = time.Now().Add(time.Hour)
+	}
+
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               template.issuer,
+		NotBefore:             template.notBefore,
+		NotAfter:              template.notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	leaf := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      template.subject,
+		DNSNames:     template.dnsNames,
+		NotBefore:    template.notBefore,
+		NotAfter:     template.notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, uri := range template.uris {
+		parsed, err := url.Parse(uri)
+		if err != nil {
+			t.Fatalf("invalid uri %q: %v", uri, err)
+		}
+		leaf.URIs = append(leaf.URIs, parsed)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, leaf, ca, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return url.PathEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+}
@@ -0,0 +1,189 @@
+package peer_identity_match
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+const (
+	ControllerKind = "peer-identity-match"
+)
+
+// init registers the peer-identity-match match controller so it can be
+// constructed from configuration at runtime.
+func init() {
+	controller.RegisterMatchControllerFactory(ControllerKind, newPeerIdentityMatchController)
+}
+
+type peerIdentityMatchController struct {
+	name         string
+	spiffeIDs    []string
+	trustDomains []string
+	subject      *NameConfig
+	dnsSANs      []string
+	uriSANs      []string
+	certificate  *CertificateConfig
+	now          func() time.Time
+}
+
+// Match implements controller.MatchController. The request matches when the
+// peer identity matches any configured identity and its certificate passes
+// the certificate checks.
+func (c *peerIdentityMatchController) Match(ctx context.Context, req *runtime.RequestContext, reports controller.AnalysisReports) (*controller.MatchVerdict, error) {
+	identity, err := newPeerIdentity(req.Request.GetAttributes().GetSource())
+	if err != nil {
+		return c.createVerdict(identity, false, fmt.Sprintf("peer certificate is not valid: %v", err)), nil
+	}
+	if identity.String() == "" {
+		return c.createVerdict(identity, false, "peer identity missing"), nil
+	}
+
+	if c.certificate != nil {
+		if description, ok := c.checkCertificate(identity); !ok {
+			return c.createVerdict(identity, false, description), nil
+		}
+	}
+
+	if !c.hasIdentitySelectors() {
+		return c.createVerdict(identity, true, fmt.Sprintf("peer '%s' certificate accepted", identity)), nil
+	}
+
+	if pattern, ok := matchAny(c.spiffeIDs, identity.spiffeIDs, "/"); ok {
+		return c.createVerdict(identity, true, fmt.Sprintf("peer '%s' matched spiffe id '%s'", identity, pattern)), nil
+	}
+	for _, spiffeID := range identity.spiffeIDs {
+		for _, trustDomain := range c.trustDomains {
+			if trustDomain == spiffeTrustDomain(spiffeID) {
+				return c.createVerdict(identity, true, fmt.Sprintf("peer '%s' matched trust domain '%s'", identity, trustDomain)), nil
+			}
+		}
+	}
+	if pattern, ok := matchAny(c.uriSANs, identity.uriSANs, "/"); ok {
+		return c.createVerdict(identity, true, fmt.Sprintf("peer '%s' matched uri san '%s'", identity, pattern)), nil
+	}
+	if pattern, ok := matchAny(c.dnsSANs, lowercase(identity.dnsSANs), "."); ok {
+		return c.createVerdict(identity, true, fmt.Sprintf("peer '%s' matched dns san '%s'", identity, pattern)), nil
+	}
+	if c.subject != nil && identity.certificate != nil {
+		if _, ok := matchName(c.subject, identity.certificate.Subject); ok {
+			return c.createVerdict(identity, true, fmt.Sprintf("peer '%s' matched subject '%s'", identity, identity.certificate.Subject)), nil
+		}
+	}
+
+	return c.createVerdict(identity, false, fmt.Sprintf("peer '%s' did not match any identity", identity)), nil
+}
+
+// Name implements controller.MatchController.
+func (c *peerIdentityMatchController) Name() string {
+	return c.name
+}
+
+// Kind implements controller.MatchController.
+func (c *peerIdentityMatchController) Kind() string {
+	return ControllerKind
+}
+
+// HealthCheck implements controller.MatchController.
+func (c *peerIdentityMatchController) HealthCheck(ctx context.Context) error {
+	// No external dependencies to check
+	return nil
+}
+
+// hasIdentitySelectors reports whether any identity is configured, as
+// opposed to certificate checks only
+func (c *peerIdentityMatchController) hasIdentitySelectors() bool {
+	return len(c.spiffeIDs) > 0 || len(c.trustDomains) > 0 || c.subject != nil || len(c.dnsSANs) > 0 || len(c.uriSANs) > 0
+}
+
+// checkCertificate runs the certificate checks, returning the description of
+// the first failing one
+func (c *peerIdentityMatchController) checkCertificate(identity *peerIdentity) (string, bool) {
+	certificate := identity.certificate
+	if certificate == nil {
+		return fmt.Sprintf("peer '%s' certificate missing", identity), false
+	}
+
+	if c.certificate.CheckValidity {
+		now := c.now()
+		if now.Before(certificate.NotBefore) {
+			return fmt.Sprintf("peer '%s' certificate not valid before %s", identity, certificate.NotBefore.UTC().Format(time.RFC3339)), false
+		}
+		if now.After(certificate.NotAfter) {
+			return fmt.Sprintf("peer '%s' certificate expired at %s", identity, certificate.NotAfter.UTC().Format(time.RFC3339)), false
+		}
+	}
+
+	if c.certificate.Issuer != nil {
+		if field, ok := matchName(c.certificate.Issuer, certificate.Issuer); !ok {
+			return fmt.Sprintf("peer '%s' certificate issuer '%s' did not match %s", identity, certificate.Issuer, field), false
+		}
+	}
+
+	return "", true
+}
+
+// createVerdict builds the verdict reported for the peer
+func (c *peerIdentityMatchController) createVerdict(identity *peerIdentity, isMatch bool, description string) *controller.MatchVerdict {
+	return &controller.MatchVerdict{
+		Controller:     c.name,
+		ControllerType: ControllerKind,
+		DenyCode:       codes.PermissionDenied,
+		Description:    description,
+		IsMatch:        isMatch,
+		LogFields: []zap.Field{
+			zap.String("peer.identity", identity.String()),
+		},
+	}
+}
+
+// lowercase returns the lowercase copy of values
+func lowercase(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+	return lowered
+}
+
+// newPeerIdentityMatchController validates the settings and prepares a
+// controller matching the peer identity of requests
+func newPeerIdentityMatchController(ctx context.Context, logger *zap.Logger, cfg config.ControllerConfig) (controller.MatchController, error) {
+	var peerIdentityMatchConfig PeerIdentityMatchConfig
+	if err := controller.DecodeControllerSettings(cfg.Settings, &peerIdentityMatchConfig); err != nil {
+		return nil, err
+	}
+	if err := peerIdentityMatchConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	ctrl := &peerIdentityMatchController{
+		name:         cfg.Name,
+		spiffeIDs:    peerIdentityMatchConfig.SpiffeIDs,
+		trustDomains: peerIdentityMatchConfig.TrustDomains,
+		subject:      peerIdentityMatchConfig.Subject,
+		dnsSANs:      lowercase(peerIdentityMatchConfig.DNSSANs),
+		uriSANs:      peerIdentityMatchConfig.URISANs,
+		certificate:  peerIdentityMatchConfig.Certificate,
+		now:          time.Now,
+	}
+
+	logger.Info("controller initialized",
+		zap.Int("spiffe_ids", len(ctrl.spiffeIDs)),
+		zap.Int("trust_domains", len(ctrl.trustDomains)),
+		zap.Int("dns_sans", len(ctrl.dnsSANs)),
+		zap.Int("uri_sans", len(ctrl.uriSANs)),
+		zap.Bool("subject", ctrl.subject != nil),
+		zap.Bool("certificate", ctrl.certificate != nil),
+	)
+
+	return ctrl, nil
+}
@@ -0,0 +1,196 @@
+package peer_identity_match
+
+import (
+	"context"
+	"crypto/x509/pkix"
+	"strings"
+	"testing"
+	"time"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+func TestMatch_Identities(t *testing.T) {
+	paymentsCertificate := newCertificate(t, certificateTemplate{
+		subject:  pkix.Name{CommonName: "payments-api", Organization: []string{"Example"}, OrganizationalUnit: []string{"payments"}},
+		uris:     []string{"spiffe://prod.example.org/ns/payments/sa/api"},
+		dnsNames: []string{"API.payments.svc.cluster.local"},
+	})
+	legacyCertificate := newCertificate(t, certificateTemplate{
+		subject: pkix.Name{CommonName: "billing-batch", Organization: []string{"Example"}, OrganizationalUnit: []string{"billing"}},
+	})
+
+	tests := []struct {
+		name        string
+		settings    map[string]any
+		source      *authv3.AttributeContext_Peer
+		match       bool
+		description string
+	}{
+		{
+			name:        "spiffe id glob",
+			settings:    map[string]any{"spiffeIds": []string{"spiffe://prod.example.org/ns/*/sa/api"}},
+			source:      &authv3.AttributeContext_Peer{Principal: "spiffe://prod.example.org/ns/payments/sa/api"},
+			match:       true,
+			description: "peer 'spiffe://prod.example.org/ns/payments/sa/api' matched spiffe id 'spiffe://prod.example.org/ns/*/sa/api'",
+		},
+		{
+			name:        "spiffe id mismatch",
+			settings:    map[string]any{"spiffeIds": []string{"spiffe://prod.example.org/ns/orders/**"}},
+			source:      &authv3.AttributeContext_Peer{Principal: "spiffe://prod.example.org/ns/payments/sa/api"},
+			description: "peer 'spiffe://prod.example.org/ns/payments/sa/api' did not match any identity",
+		},
+		{
+			name:        "trust domain",
+			settings:    map[string]any{"trustDomains": []string{"staging.example.org", "prod.example.org"}},
+			source:      &authv3.AttributeContext_Peer{Certificate: paymentsCertificate},
+			match:       true,
+			description: "peer 'spiffe://prod.example.org/ns/payments/sa/api' matched trust domain 'prod.example.org'",
+		},
+		{
+			name:        "dns san",
+			settings:    map[string]any{"dnsSans": []string{"*.Payments.svc.cluster.local"}},
+			source:      &authv3.AttributeContext_Peer{Certificate: paymentsCertificate},
+			match:       true,
+			description: "peer 'spiffe://prod.example.org/ns/payments/sa/api' matched dns san '*.payments.svc.cluster.local'",
+		},
+		{
+			name:        "subject",
+			settings:    map[string]any{"subject": map[string]any{"organization": []string{"Example"}, "organizationalUnit": []string{"billing"}}},
+			source:      &authv3.AttributeContext_Peer{Principal: "CN=billing-batch,OU=billing,O=Example", Certificate: legacyCertificate},
+			match:       true,
+			description: "peer 'CN=billing-batch,OU=billing,O=Example' matched subject 'CN=billing-batch,OU=billing,O=Example'",
+		},
+		{
+			name:        "subject requires every field",
+			settings:    map[string]any{"subject": map[string]any{"organization": []string{"Example"}, "organizationalUnit": []string{"billing"}}},
+			source:      &authv3.AttributeContext_Peer{Certificate: paymentsCertificate},
+			description: "peer 'spiffe://prod.example.org/ns/payments/sa/api' did not match any identity",
+		},
+		{
+			name:        "subject without certificate",
+			settings:    map[string]any{"subject": map[string]any{"commonName": []string{"billing-*"}}},
+			source:      &authv3.AttributeContext_Peer{Principal: "CN=billing-batch,OU=billing,O=Example"},
+			description: "peer 'CN=billing-batch,OU=billing,O=Example' did not match any identity",
+		},
+		{
+			name:        "no peer identity",
+			settings:    map[string]any{"trustDomains": []string{"prod.example.org"}},
+			source:      &authv3.AttributeContext_Peer{},
+			description: "peer identity missing",
+		},
+		{
+			name:        "invalid certificate",
+			settings:    map[string]any{"trustDomains": []string{"prod.example.org"}},
+			source:      &authv3.AttributeContext_Peer{Principal: "spiffe://prod.example.org/ns/payments/sa/api", Certificate: "%zz"},
+			description: "peer certificate is not valid: certificate is not URL encoded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict := match(t, buildController(t, tt.settings), tt.source)
+			if verdict.IsMatch != tt.match || !strings.HasPrefix(verdict.Description, tt.description) {
+				t.Fatalf("expected IsMatch=%v %q, got %v %q", tt.match, tt.description, verdict.IsMatch, verdict.Description)
+			}
+			if verdict.DenyCode != codes.PermissionDenied {
+				t.Fatalf("expected PermissionDenied, got %v", verdict.DenyCode)
+			}
+		})
+	}
+}
+
+func TestMatch_CertificateChecks(t *testing.T) {
+	now := time.Now()
+	spiffeID := "spiffe://prod.example.org/ns/payments/sa/api"
+	validCertificate := newCertificate(t, certificateTemplate{uris: []string{spiffeID}})
+	expiredCertificate := newCertificate(t, certificateTemplate{uris: []string{spiffeID}, notBefore: now.Add(-2 * time.Hour), notAfter: now.Add(-time.Hour)})
+	futureCertificate := newCertificate(t, certificateTemplate{uris: []string{spiffeID}, notBefore: now.Add(time.Hour), notAfter: now.Add(2 * time.Hour)})
+	foreignCertificate := newCertificate(t, certificateTemplate{uris: []string{spiffeID}, issuer: pkix.Name{CommonName: "Partner CA", Organization: []string{"Partner"}}})
+
+	ctrl := buildController(t, map[string]any{
+		"spiffeIds": []string{"spiffe://prod.example.org/**"},
+		"certificate": map[string]any{
+			"issuer":        map[string]any{"commonName": []string{"Example Internal CA"}},
+			"checkValidity": true,
+		},
+	})
+
+	tests := []struct {
+		name        string
+		source      *authv3.AttributeContext_Peer
+		match       bool
+		description string
+	}{
+		{name: "valid", source: &authv3.AttributeContext_Peer{Principal: spiffeID, Certificate: validCertificate}, match: true, description: "peer '" + spiffeID + "' matched spiffe id"},
+		{name: "expired", source: &authv3.AttributeContext_Peer{Principal: spiffeID, Certificate: expiredCertificate}, description: "peer '" + spiffeID + "' certificate expired at"},
+		{name: "not yet valid", source: &authv3.AttributeContext_Peer{Principal: spiffeID, Certificate: futureCertificate}, description: "peer '" + spiffeID + "' certificate not valid before"},
+		{name: "foreign issuer", source: &authv3.AttributeContext_Peer{Principal: spiffeID, Certificate: foreignCertificate}, description: "peer '" + spiffeID + "' certificate issuer 'CN=Partner CA,O=Partner' did not match commonName"},
+		{name: "certificate not forwarded", source: &authv3.AttributeContext_Peer{Principal: spiffeID}, description: "peer '" + spiffeID + "' certificate missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict := match(t, ctrl, tt.source)
+			if verdict.IsMatch != tt.match || !strings.HasPrefix(verdict.Description, tt.description) {
+				t.Fatalf("expected IsMatch=%v %q, got %v %q", tt.match, tt.description, verdict.IsMatch, verdict.Description)
+			}
+		})
+	}
+
+	certificateOnly := buildController(t, map[string]any{"certificate": map[string]any{"checkValidity": true}})
+	if verdict := match(t, certificateOnly, &authv3.AttributeContext_Peer{Certificate: validCertificate}); !verdict.IsMatch {
+		t.Fatalf("expected a valid certificate to match without identities, got %q", verdict.Description)
+	}
+	if verdict := match(t, certificateOnly, &authv3.AttributeContext_Peer{Certificate: expiredCertificate}); verdict.IsMatch {
+		t.Fatalf("expected an expired certificate not to match, got %q", verdict.Description)
+	}
+}
+
+func TestMatch_LogFields(t *testing.T) {
+	ctrl := buildController(t, map[string]any{"trustDomains": []string{"prod.example.org"}})
+	verdict := match(t, ctrl, &authv3.AttributeContext_Peer{Principal: "spiffe://prod.example.org/ns/payments/sa/api"})
+	if len(verdict.LogFields) != 1 || verdict.LogFields[0].Key != "peer.identity" || verdict.LogFields[0].String != "spiffe://prod.example.org/ns/payments/sa/api" {
+		t.Fatalf("unexpected log fields %v", verdict.LogFields)
+	}
+}
+
+func TestNewPeerIdentityMatchController_InvalidSettings(t *testing.T) {
+	_, err := newPeerIdentityMatchController(context.Background(), zap.NewNop(), config.ControllerConfig{
+		Name:     "mesh-peers",
+		Type:     ControllerKind,
+		Settings: map[string]any{"spiffeIds": []string{"prod.example.org/ns/payments"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "configuration validation failed: spiffeIds") {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+}
+
+func buildController(t *testing.T, settings map[string]any) *peerIdentityMatchController {
+	t.Helper()
+	ctrl, err := newPeerIdentityMatchController(context.Background(), zap.NewNop(), config.ControllerConfig{
+		Name:     "mesh-peers",
+		Type:     ControllerKind,
+		Settings: settings,
+	})
+	if err != nil {
+		t.Fatalf("failed to build controller: %v", err)
+	}
+	return ctrl.(*peerIdentityMatchController)
+}
+
+func match(t *testing.T, ctrl controller.MatchController, source *authv3.AttributeContext_Peer) *controller.MatchVerdict {
+	t.Helper()
+	req := &authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{Source: source},
+	}
+	verdict, err := ctrl.Match(context.Background(), runtime.NewRequestContext(req), nil)
+	if err != nil {
+		t.Fatalf("match returned error: %v", err)
+	}
+	return verdict
+}
@@ -0,0 +1,196 @@
+package rate_limit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// KeySourceIP counts requests per downstream client IP address
+	KeySourceIP = "ip"
+	// KeySourceASN counts requests per ASN reported by a maxmind-asn analysis controller
+	KeySourceASN = "asn"
+	// KeySourceHeader counts requests per value of a request header
+	KeySourceHeader = "header"
+	// KeySourceAuthority counts requests per requested authority
+	KeySourceAuthority = "authority"
+)
+
+const (
+	// AlgorithmTokenBucket refills the allowance continuously, allowing bursts
+	// up to the limit
+	AlgorithmTokenBucket = "tokenBucket"
+	// AlgorithmSlidingWindow weighs the count of the previous window by its
+	// overlap with the sliding period
+	AlgorithmSlidingWindow = "slidingWindow"
+)
+
+const (
+	// StoreMemory keeps the counters in process
+	StoreMemory = "memory"
+	// StoreRedis keeps the counters in a shared Redis datasource, limiting
+	// requests across every instance of the service
+	StoreRedis = "redis"
+)
+
+const (
+	defaultStoreTimeout = 100 * time.Millisecond
+)
+
+// RateLimitConfig represents the configuration of a rate-limit controller
+type RateLimitConfig struct {
+	Key              KeyConfig `yaml:"key"`
+	LimitConfig      `yaml:",inline"`
+	MatchesOnFailure bool `yaml:"matchesOnFailure"`
+}
+
+// LimitConfig represents a limit and where its counters are kept
+type LimitConfig struct {
+	Algorithm string      `yaml:"algorithm"`
+	Limit     int         `yaml:"limit"`
+	Period    string      `yaml:"period"`
+	Store     StoreConfig `yaml:"store"`
+}
+
+// KeyConfig represents what requests are counted by
+type KeyConfig struct {
+	Source           string `yaml:"source"`
+	Name             string `yaml:"name"`
+	IPv6PrefixLength int    `yaml:"ipv6PrefixLength"`
+}
+
+// StoreConfig represents where the counters are kept
+type StoreConfig struct {
+	Type       string `yaml:"type"`
+	DataSource string `yaml:"datasource"`
+	KeyPrefix  string `yaml:"keyPrefix"`
+	Timeout    string `yaml:"timeout"`
+}
+
+// ApplyDefaults sets default values for the configuration of the controller named name
+func (c *RateLimitConfig) ApplyDefaults(name string) {
+	if c.Key.Source == KeySourceIP && c.Key.IPv6PrefixLength == 0 {
+		c.Key.IPv6PrefixLength = 128
+	}
+	c.LimitConfig.ApplyDefaults("ratelimit:" + name + ":")
+}
+
+// Validate checks the configuration for completeness
+func (c *RateLimitConfig) Validate() error {
+	if err := c.Key.validate(); err != nil {
+		return err
+	}
+	return c.LimitConfig.Validate()
+}
+
+// ApplyDefaults sets default values for the limit, keyPrefix being the
+// default prefix of the Redis keys
+func (c *LimitConfig) ApplyDefaults(keyPrefix string) {
+	if c.Algorithm == "" {
+		c.Algorithm = AlgorithmTokenBucket
+	}
+	if c.Store.Type == "" {
+		c.Store.Type = StoreMemory
+	}
+	if c.Store.Type == StoreRedis {
+		if c.Store.KeyPrefix == "" {
+			c.Store.KeyPrefix = keyPrefix
+		}
+		if c.Store.Timeout == "" {
+			c.Store.Timeout = defaultStoreTimeout.String()
+		}
+	}
+}
+
+// Validate checks the limit for completeness
+func (c *LimitConfig) Validate() error {
+	switch c.Algorithm {
+	case AlgorithmTokenBucket, AlgorithmSlidingWindow:
+	default:
+		return fmt.Errorf("algorithm must be one of '%s' or '%s', got '%s'", AlgorithmTokenBucket, AlgorithmSlidingWindow, c.Algorithm)
+	}
+
+	if c.Limit <= 0 {
+		return fmt.Errorf("limit must be greater than 0")
+	}
+	if c.Period == "" {
+		return fmt.Errorf("period is required")
+	}
+	period, err := time.ParseDuration(c.Period)
+	if err != nil {
+		return fmt.Errorf("invalid period: %w", err)
+	}
+	if period < time.Second {
+		return fmt.Errorf("period must be at least 1s")
+	}
+
+	return c.Store.validate()
+}
+
+// GetPeriod returns the parsed period
+func (c *LimitConfig) GetPeriod() time.Duration {
+	period, _ := time.ParseDuration(c.Period)
+	return period
+}
+
+// validate checks the key settings
+func (k *KeyConfig) validate() error {
+	switch k.Source {
+	case KeySourceIP:
+		if k.IPv6PrefixLength < 1 || k.IPv6PrefixLength > 128 {
+			return fmt.Errorf("key.ipv6PrefixLength must be between 1 and 128, got %d", k.IPv6PrefixLength)
+		}
+	case KeySourceASN, KeySourceAuthority:
+	case KeySourceHeader:
+		if k.Name == "" {
+			return fmt.Errorf("key.name is required when key.source is '%s'", k.Source)
+		}
+		if strings.ContainsAny(k.Name, " :\t\r\n") {
+			return fmt.Errorf("key.name: invalid header name '%s'", k.Name)
+		}
+	case "":
+		return fmt.Errorf("key.source is required")
+	default:
+		return fmt.Errorf("key.source must be one of '%s', '%s', '%s' or '%s', got '%s'",
+			KeySourceIP, KeySourceASN, KeySourceHeader, KeySourceAuthority, k.Source)
+	}
+
+	if k.Source != KeySourceHeader && k.Name != "" {
+		return fmt.Errorf("key.name is not supported when key.source is '%s'", k.Source)
+	}
+	if k.Source != KeySourceIP && k.IPv6PrefixLength != 0 {
+		return fmt.Errorf("key.ipv6PrefixLength is not supported when key.source is '%s'", k.Source)
+	}
+	return nil
+}
+
+// validate checks the store settings
+func (s *StoreConfig) validate() error {
+	switch s.Type {
+	case StoreMemory:
+		if s.DataSource != "" || s.KeyPrefix != "" || s.Timeout != "" {
+			return fmt.Errorf("store.datasource, store.keyPrefix and store.timeout are only supported when store.type is '%s'", StoreRedis)
+		}
+	case StoreRedis:
+		if s.DataSource == "" {
+			return fmt.Errorf("store.datasource is required when store.type is '%s'", StoreRedis)
+		}
+		timeout, err := time.ParseDuration(s.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid store.timeout: %w", err)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("store.timeout must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("store.type must be one of '%s' or '%s', got '%s'", StoreMemory, StoreRedis, s.Type)
+	}
+	return nil
+}
+
+// GetTimeout returns the parsed timeout of the store commands
+func (s *StoreConfig) GetTimeout() time.Duration {
+	timeout, _ := time.ParseDuration(s.Timeout)
+	return timeout
+}
@@ -0,0 +1,136 @@
+package rate_limit
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRateLimitConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  RateLimitConfig
+		wantErr string
+	}{
+		{
+			name:   "ip key with memory store",
+			config: RateLimitConfig{Key: KeyConfig{Source: KeySourceIP}, LimitConfig: LimitConfig{Limit: 100, Period: "1m"}},
+		},
+		{
+			name:   "ipv6 networks",
+			config: RateLimitConfig{Key: KeyConfig{Source: KeySourceIP, IPv6PrefixLength: 64}, LimitConfig: LimitConfig{Limit: 100, Period: "1m"}},
+		},
+		{
+			name:   "header key with redis store",
+			config: RateLimitConfig{Key: KeyConfig{Source: KeySourceHeader, Name: "x-api-key"}, LimitConfig: LimitConfig{Algorithm: AlgorithmSlidingWindow, Limit: 10, Period: "1s", Store: StoreConfig{Type: StoreRedis, DataSource: "cache"}}},
+		},
+		{
+			name:    "missing key source",
+			config:  RateLimitConfig{LimitConfig: LimitConfig{Limit: 100, Period: "1m"}},
+			wantErr: "key.source is required",
+		},
+		{
+			name:    "unknown key source",
+			config:  RateLimitConfig{Key: KeyConfig{Source: "path"}, LimitConfig: LimitConfig{Limit: 100, Period: "1m"}},
+			wantErr: "key.source must be one of",
+		},
+		{
+			name:    "header key without name",
+			config:  RateLimitConfig{Key: KeyConfig{Source: KeySourceHeader}, LimitConfig: LimitConfig{Limit: 100, Period: "1m"}},
+			wantErr: "key.name is required when key.source is 'header'",
+		},
+		{
+			name:    "name with asn key",
+			config:  RateLimitConfig{Key: KeyConfig{Source: KeySourceASN, Name: "x-asn"}, LimitConfig: LimitConfig{Limit: 100, Period: "1m"}},
+			wantErr: "key.name is not supported when key.source is 'asn'",
+		},
+		{
+			name:    "ipv6 prefix length out of range",
+			config:  RateLimitConfig{Key: KeyConfig{Source: KeySourceIP, IPv6PrefixLength: 129}, LimitConfig: LimitConfig{Limit: 100, Period: "1m"}},
+			wantErr: "key.ipv6PrefixLength must be between 1 and 128",
+		},
+		{
+			name:    "ipv6 prefix length with authority key",
+			config:  RateLimitConfig{Key: KeyConfig{Source: KeySourceAuthority, IPv6PrefixLength: 64}, LimitConfig: LimitConfig{Limit: 100, Period: "1m"}},
+			wantErr: "key.ipv6PrefixLength is not supported when key.source is 'authority'",
+		},
+		{
+			name:    "unknown algorithm",
+			config:  RateLimitConfig{Key: KeyConfig{Source: KeySourceAuthority}, LimitConfig: LimitConfig{Algorithm: "leakyBucket", Limit: 100, Period: "1m"}},
+			wantErr: "algorithm must be one of",
+		},
+		{
+			name:    "missing limit",
+			config:  RateLimitConfig{Key: KeyConfig{Source: KeySourceAuthority}, LimitConfig: LimitConfig{Period: "1m"}},
+			wantErr: "limit must be greater than 0",
+		},
+		{
+			name:    "missing period",
+			config:  RateLimitConfig{Key: KeyConfig{Source: KeySourceAuthority}, LimitConfig: LimitConfig{Limit: 100}},
+			wantErr: "period is required",
+		},
+		{
+			name:    "invalid period",
+			config:  RateLimitConfig{Key: KeyConfig{Source: KeySourceAuthority}, LimitConfig: LimitConfig{Limit: 100, Period: "soon"}},
+			wantErr: "invalid period",
+		},
+		{
+			name:    "sub-second period",
+			config:  RateLimitConfig{Key: KeyConfig{Source: KeySourceAuthority}, LimitConfig: LimitConfig{Limit: 100, Period: "500ms"}},
+			wantErr: "period must be at least 1s",
+		},
+		{
+			name:    "unknown store",
+			config:  RateLimitConfig{Key: KeyConfig{Source: KeySourceAuthority}, LimitConfig: LimitConfig{Limit: 100, Period: "1m", Store: StoreConfig{Type: "memcached"}}},
+			wantErr: "store.type must be one of",
+		},
+		{
+			name:    "redis store without datasource",
+			config:  RateLimitConfig{Key: KeyConfig{Source: KeySourceAuthority}, LimitConfig: LimitConfig{Limit: 100, Period: "1m", Store: StoreConfig{Type: StoreRedis}}},
+			wantErr: "store.datasource is required when store.type is 'redis'",
+		},
+		{
+			name:    "redis settings with memory store",
+			config:  RateLimitConfig{Key: KeyConfig{Source: KeySourceAuthority}, LimitConfig: LimitConfig{Limit: 100, Period: "1m", Store: StoreConfig{DataSource: "cache"}}},
+			wantErr: "only supported when store.type is 'redis'",
+		},
+		{
+			name:    "invalid redis timeout",
+			config:  RateLimitConfig{Key: KeyConfig{Source: KeySourceAuthority}, LimitConfig: LimitConfig{Limit: 100, Period: "1m", Store: StoreConfig{Type: StoreRedis, DataSource: "cache", Timeout: "fast"}}},
+			wantErr: "invalid store.timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.ApplyDefaults("throttle")
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRateLimitConfigApplyDefaults(t *testing.T) {
+	config := RateLimitConfig{Key: KeyConfig{Source: KeySourceIP}, LimitConfig: LimitConfig{Store: StoreConfig{Type: StoreRedis}}}
+	config.ApplyDefaults("throttle")
+
+	if config.Algorithm != AlgorithmTokenBucket {
+		t.Fatalf("expected token bucket algorithm, got %q", config.Algorithm)
+	}
+	if config.Key.IPv6PrefixLength != 128 {
+		t.Fatalf("expected IPv6 addresses to be counted individually, got /%d", config.Key.IPv6PrefixLength)
+	}
+	if config.Store.KeyPrefix != "ratelimit:throttle:" {
+		t.Fatalf("expected key prefix of the controller, got %q", config.Store.KeyPrefix)
+	}
+	if config.Store.GetTimeout() != defaultStoreTimeout {
+		t.Fatalf("expected default store timeout, got %s", config.Store.GetTimeout())
+	}
+}
@@ -0,0 +1,222 @@
+package rate_limit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Decision is the outcome of counting a request against a limit
+type Decision struct {
+	// Allowed reports whether the request is within the limit
+	Allowed bool
+	// Remaining is the number of requests still allowed right now
+	Remaining int
+	// RetryAfter is how long a denied client should wait before retrying
+	RetryAfter time.Duration
+	// Reset is how long until the full allowance is available again
+	Reset time.Duration
+}
+
+// Store counts requests per key
+type Store interface {
+	// Take counts a request of cost hits against the state of key. A denied
+	// request is not counted.
+	Take(ctx context.Context, key string, cost int) (Decision, error)
+	HealthCheck(ctx context.Context) error
+}
+
+// limiter applies an algorithm to the state of a key. Durations are handled
+// in milliseconds, as in the Redis scripts implementing the same algorithms.
+type limiter struct {
+	algorithm string
+	limit     float64
+	period    float64
+}
+
+// limiterState is the state of a key. Token buckets use tokens and at,
+// sliding windows window, current and previous.
+type limiterState struct {
+	tokens   float64
+	at       int64
+	window   int64
+	current  float64
+	previous float64
+}
+
+// newLimiter builds the limiter of a validated configuration
+func newLimiter(cfg LimitConfig) limiter {
+	return limiter{
+		algorithm: cfg.Algorithm,
+		limit:     float64(cfg.Limit),
+		period:    float64(cfg.GetPeriod().Milliseconds()),
+	}
+}
+
+// take counts a request of cost hits made at now (in Unix milliseconds)
+// against state, returning the decision and how long the state must be kept
+func (l limiter) take(state *limiterState, now int64, cost int) (Decision, time.Duration) {
+	if l.algorithm == AlgorithmSlidingWindow {
+		return l.takeSlidingWindow(state, now, float64(cost))
+	}
+	return l.takeTokenBucket(state, now, float64(cost))
+}
+
+// takeTokenBucket refills the bucket for the time elapsed since the last
+// request and takes cost tokens from it
+func (l limiter) takeTokenBucket(state *limiterState, now int64, cost float64) (Decision, time.Duration) {
+	rate := l.limit / l.period
+	if state.at == 0 {
+		state.tokens = l.limit
+	} else {
+		state.tokens = math.Min(l.limit, state.tokens+float64(now-state.at)*rate)
+	}
+	state.at = now
+
+	var decision Decision
+	if state.tokens >= cost {
+		state.tokens -= cost
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = milliseconds((cost - state.tokens) / rate)
+	}
+	decision.Remaining = int(state.tokens)
+	decision.Reset = milliseconds((l.limit - state.tokens) / rate)
+
+	return decision, max(decision.Reset, time.Millisecond)
+}
+
+// takeSlidingWindow estimates the hits of the last period from the counts of
+// the current and previous fixed windows and counts the cost of the request in
+// the current one
+func (l limiter) takeSlidingWindow(state *limiterState, now int64, cost float64) (Decision, time.Duration) {
+	window := now / int64(l.period)
+	switch state.window {
+	case window:
+	case window - 1:
+		state.previous, state.current = state.current, 0
+	default:
+		state.previous, state.current = 0, 0
+	}
+	state.window = window
+
+	elapsed := float64(now - window*int64(l.period))
+	estimate := state.previous*(l.period-elapsed)/l.period + state.current
+
+	decision := Decision{Reset: milliseconds(l.period - elapsed)}
+	switch {
+	case estimate+cost <= l.limit:
+		state.current += cost
+		estimate += cost
+		decision.Allowed = true
+	case cost > l.limit:
+		// The request is never allowed
+		decision.RetryAfter = milliseconds(l.period)
+	case state.current+cost > l.limit:
+		// The current window alone exceeds the limit, wait for it to slide
+		// far enough into the next one
+		decision.RetryAfter = milliseconds(l.period - elapsed + l.period*(1-(l.limit-cost)/state.current))
+	default:
+		// Wait for the previous window to weigh less
+		decision.RetryAfter = milliseconds(math.Max(l.period*(1-(l.limit-cost-state.current)/state.previous)-elapsed, 1))
+	}
+	decision.Remaining = max(int(l.limit-estimate), 0)
+
+	return decision, decision.Reset + milliseconds(l.period)
+}
+
+// milliseconds converts a number of milliseconds to a duration, rounding up
+func milliseconds(value float64) time.Duration {
+	return time.Duration(math.Ceil(value)) * time.Millisecond
+}
+
+// NewStore builds the store of a validated limit, counting requests until ctx is canceled
+func NewStore(ctx context.Context, cfg LimitConfig) (Store, error) {
+	if cfg.Store.Type == StoreRedis {
+		store, err := newRedisStore(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("could not create redis store: %w", err)
+		}
+		return store, nil
+	}
+	return newMemoryStore(ctx, newLimiter(cfg), cfg.GetPeriod()), nil
+}
+
+// memoryStore keeps the state of every key in process
+type memoryStore struct {
+	limiter limiter
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+// memoryEntry is the state of a key and when it can be forgotten
+type memoryEntry struct {
+	state     limiterState
+	expiresAt time.Time
+}
+
+// newMemoryStore builds an in-process store, evicting expired keys until ctx is canceled
+func newMemoryStore(ctx context.Context, limiter limiter, sweepInterval time.Duration) *memoryStore {
+	store := &memoryStore{
+		limiter: limiter,
+		now:     time.Now,
+		entries: make(map[string]*memoryEntry),
+	}
+	go store.runSweep(ctx, sweepInterval)
+	return store
+}
+
+// Take counts a request of cost hits against the state of key
+func (s *memoryStore) Take(ctx context.Context, key string, cost int) (Decision, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || !entry.expiresAt.After(now) {
+		entry = &memoryEntry{}
+		s.entries[key] = entry
+	}
+	decision, ttl := s.limiter.take(&entry.state, now.UnixMilli(), cost)
+	entry.expiresAt = now.Add(ttl)
+
+	return decision, nil
+}
+
+// HealthCheck always succeeds, the store has no external dependencies
+func (s *memoryStore) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+// sweep forgets the expired keys
+func (s *memoryStore) sweep() {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, entry := range s.entries {
+		if !entry.expiresAt.After(now) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// runSweep periodically forgets the expired keys until ctx is canceled
+func (s *memoryStore) runSweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep()
+		}
+	}
+}
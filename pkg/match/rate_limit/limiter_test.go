@@ -0,0 +1,134 @@
+package rate_limit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiterTokenBucket(t *testing.T) {
+	l := limiter{algorithm: AlgorithmTokenBucket, limit: 3, period: 3000}
+	var state limiterState
+	now := int64(1_000_000)
+
+	for i := range 3 {
+		decision, _ := l.take(&state, now, 1)
+		if !decision.Allowed || decision.Remaining != 2-i {
+			t.Fatalf("request %d: expected to be allowed with %d remaining, got %+v", i+1, 2-i, decision)
+		}
+	}
+
+	decision, ttl := l.take(&state, now, 1)
+	if decision.Allowed {
+		t.Fatal("expected the empty bucket to deny the request")
+	}
+	if decision.RetryAfter != time.Second || decision.Reset != 3*time.Second || ttl != 3*time.Second {
+		t.Fatalf("expected to retry after 1s and reset after 3s, got %+v (ttl %s)", decision, ttl)
+	}
+
+	// One token is refilled every second
+	if decision, _ := l.take(&state, now+1000, 1); !decision.Allowed || decision.Remaining != 0 {
+		t.Fatalf("expected the refilled token to allow the request, got %+v", decision)
+	}
+	if decision, _ := l.take(&state, now+10_000, 1); !decision.Allowed || decision.Remaining != 2 {
+		t.Fatalf("expected the bucket to refill up to the limit, got %+v", decision)
+	}
+}
+
+func TestLimiterSlidingWindow(t *testing.T) {
+	l := limiter{algorithm: AlgorithmSlidingWindow, limit: 4, period: 10_000}
+	var state limiterState
+	windowStart := int64(100 * 10_000)
+
+	for i := range 4 {
+		decision, _ := l.take(&state, windowStart, 1)
+		if !decision.Allowed || decision.Remaining != 3-i {
+			t.Fatalf("request %d: expected to be allowed with %d remaining, got %+v", i+1, 3-i, decision)
+		}
+	}
+
+	decision, ttl := l.take(&state, windowStart, 1)
+	if decision.Allowed {
+		t.Fatal("expected the full window to deny the request")
+	}
+	if decision.RetryAfter != 12_500*time.Millisecond || decision.Reset != 10*time.Second || ttl != 20*time.Second {
+		t.Fatalf("expected to retry after 12.5s and reset after 10s, got %+v (ttl %s)", decision, ttl)
+	}
+
+	// At the start of the next window the previous one still weighs in full
+	decision, _ = l.take(&state, windowStart+10_000, 1)
+	if decision.Allowed || decision.RetryAfter != 2500*time.Millisecond {
+		t.Fatalf("expected to retry after 2.5s, got %+v", decision)
+	}
+
+	// A quarter into the next window the previous one weighs 3 requests
+	decision, _ = l.take(&state, windowStart+12_500, 1)
+	if !decision.Allowed || decision.Remaining != 0 {
+		t.Fatalf("expected the sliding window to allow the request, got %+v", decision)
+	}
+
+	// Windows older than the previous one are forgotten
+	decision, _ = l.take(&state, windowStart+30_000, 1)
+	if !decision.Allowed || decision.Remaining != 3 {
+		t.Fatalf("expected a fresh window, got %+v", decision)
+	}
+}
+
+func TestLimiterCost(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		retry     time.Duration
+	}{
+		{algorithm: AlgorithmTokenBucket, retry: 2 * time.Second},
+		{algorithm: AlgorithmSlidingWindow, retry: 12_500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			l := limiter{algorithm: tt.algorithm, limit: 10, period: 10_000}
+			var state limiterState
+			now := int64(100 * 10_000)
+
+			if decision, _ := l.take(&state, now, 8); !decision.Allowed || decision.Remaining != 2 {
+				t.Fatalf("expected 8 hits to be allowed with 2 remaining, got %+v", decision)
+			}
+			decision, _ := l.take(&state, now, 4)
+			if decision.Allowed || decision.Remaining != 2 || decision.RetryAfter != tt.retry {
+				t.Fatalf("expected 4 hits to be denied and not counted, retrying after %s, got %+v", tt.retry, decision)
+			}
+			if decision, _ := l.take(&state, now, 2); !decision.Allowed || decision.Remaining != 0 {
+				t.Fatalf("expected 2 hits to be allowed, got %+v", decision)
+			}
+			if decision, _ := l.take(&state, now+100_000, 11); decision.Allowed {
+				t.Fatalf("expected hits above the limit to be denied, got %+v", decision)
+			}
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := newMemoryStore(ctx, limiter{algorithm: AlgorithmTokenBucket, limit: 1, period: 60_000}, time.Hour)
+	now := time.Unix(1_700_000_000, 0)
+	store.now = func() time.Time { return now }
+
+	if decision, _ := store.Take(ctx, "192.0.2.1", 1); !decision.Allowed {
+		t.Fatalf("expected the first request to be allowed, got %+v", decision)
+	}
+	if decision, _ := store.Take(ctx, "192.0.2.1", 1); decision.Allowed {
+		t.Fatalf("expected the second request to be denied, got %+v", decision)
+	}
+	if decision, _ := store.Take(ctx, "192.0.2.2", 1); !decision.Allowed {
+		t.Fatalf("expected keys to be counted separately, got %+v", decision)
+	}
+
+	now = now.Add(time.Minute)
+	store.sweep()
+	if len(store.entries) != 0 {
+		t.Fatalf("expected the refilled keys to be evicted, got %d entries", len(store.entries))
+	}
+	if decision, _ := store.Take(ctx, "192.0.2.1", 1); !decision.Allowed {
+		t.Fatalf("expected the evicted key to start over, got %+v", decision)
+	}
+}
@@ -0,0 +1,196 @@
+package rate_limit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/analysis/maxmind_asn"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+const (
+	ControllerKind = "rate-limit"
+)
+
+// init registers the rate-limit match controller so it can be constructed
+// from configuration at runtime.
+func init() {
+	controller.RegisterMatchControllerFactory(ControllerKind, newRateLimitController)
+}
+
+type rateLimitController struct {
+	name             string
+	key              KeyConfig
+	limit            int
+	period           time.Duration
+	store            Store
+	matchesOnFailure bool
+	logger           *zap.Logger
+}
+
+// Match implements controller.MatchController. The request matches when its
+// key exceeded the limit.
+func (c *rateLimitController) Match(ctx context.Context, req *runtime.RequestContext, reports controller.AnalysisReports) (*controller.MatchVerdict, error) {
+	key, ok := c.extractKey(req, reports)
+	if !ok {
+		return c.createVerdict(false, c.missingDescription()), nil
+	}
+
+	decision, err := c.store.Take(ctx, key, 1)
+	if err != nil {
+		c.logger.Warn("rate limit store failed", zap.String("key", key), zap.Error(err))
+		return c.createVerdict(c.matchesOnFailure, fmt.Sprintf("rate limit store unavailable: %v", err)), nil
+	}
+
+	if decision.Allowed {
+		return c.createVerdict(false, fmt.Sprintf("%s within %d requests per %s (%d remaining)", c.describe(key), c.limit, c.period, decision.Remaining)), nil
+	}
+
+	verdict := c.createVerdict(true, fmt.Sprintf("%s exceeded %d requests per %s", c.describe(key), c.limit, c.period))
+	verdict.DenyDownstreamHeaders = map[string]string{
+		"Retry-After":         strconv.Itoa(max(seconds(decision.RetryAfter), 1)),
+		"RateLimit-Limit":     strconv.Itoa(c.limit),
+		"RateLimit-Remaining": strconv.Itoa(decision.Remaining),
+		"RateLimit-Reset":     strconv.Itoa(seconds(decision.Reset)),
+	}
+	return verdict, nil
+}
+
+// Name implements controller.MatchController.
+func (c *rateLimitController) Name() string {
+	return c.name
+}
+
+// Kind implements controller.MatchController.
+func (c *rateLimitController) Kind() string {
+	return ControllerKind
+}
+
+// HealthCheck implements controller.MatchController.
+func (c *rateLimitController) HealthCheck(ctx context.Context) error {
+	return c.store.HealthCheck(ctx)
+}
+
+// createVerdict builds a verdict of the controller
+func (c *rateLimitController) createVerdict(isMatch bool, description string) *controller.MatchVerdict {
+	return &controller.MatchVerdict{
+		Controller:     c.name,
+		ControllerType: ControllerKind,
+		DenyCode:       codes.ResourceExhausted,
+		DenyMessage:    "rate limit exceeded",
+		Description:    description,
+		IsMatch:        isMatch,
+	}
+}
+
+// extractKey returns the key requests are counted by, or false when the
+// request does not carry one
+func (c *rateLimitController) extractKey(req *runtime.RequestContext, reports controller.AnalysisReports) (string, bool) {
+	switch c.key.Source {
+	case KeySourceIP:
+		if !req.IpAddress.IsValid() {
+			return "", false
+		}
+		address := req.IpAddress.Unmap()
+		if address.Is6() && c.key.IPv6PrefixLength < 128 {
+			return netip.PrefixFrom(address, c.key.IPv6PrefixLength).Masked().String(), true
+		}
+		return address.String(), true
+	case KeySourceASN:
+		for _, report := range reports {
+			if report == nil || report.ControllerKind != maxmind_asn.ControllerKind {
+				continue
+			}
+			if lookupResult := maxmind_asn.GetIpLookupResultFromReport(report); lookupResult != nil {
+				return strconv.FormatUint(uint64(lookupResult.AutonomousSystemNumber), 10), true
+			}
+		}
+		return "", false
+	case KeySourceHeader:
+		value := req.Header(c.key.Name)
+		return value, value != ""
+	case KeySourceAuthority:
+		return req.Authority, req.Authority != "" && req.Authority != "-"
+	}
+	return "", false
+}
+
+// describe renders a key for verdict descriptions
+func (c *rateLimitController) describe(key string) string {
+	switch c.key.Source {
+	case KeySourceIP:
+		if strings.Contains(key, "/") {
+			return "network " + key
+		}
+		return "IP " + key
+	case KeySourceASN:
+		return "ASN " + key
+	case KeySourceAuthority:
+		return "authority " + key
+	}
+	return fmt.Sprintf("header '%s' value '%s'", c.key.Name, key)
+}
+
+// missingDescription explains why a request carries no key
+func (c *rateLimitController) missingDescription() string {
+	switch c.key.Source {
+	case KeySourceIP:
+		return "unable to determine source IP address"
+	case KeySourceASN:
+		return "no ASN information available"
+	case KeySourceAuthority:
+		return "no authority in request"
+	}
+	return fmt.Sprintf("no rate limit key in header '%s'", c.key.Name)
+}
+
+// seconds converts a duration to whole seconds, rounding up
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// newRateLimitController constructs a rate-limit controller from configuration
+func newRateLimitController(ctx context.Context, logger *zap.Logger, cfg config.ControllerConfig) (controller.MatchController, error) {
+	var rateLimitConfig RateLimitConfig
+	if err := controller.DecodeControllerSettings(cfg.Settings, &rateLimitConfig); err != nil {
+		return nil, err
+	}
+	rateLimitConfig.ApplyDefaults(cfg.Name)
+	if err := rateLimitConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	store, err := NewStore(ctx, rateLimitConfig.LimitConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("controller initialized",
+		zap.String("key_source", rateLimitConfig.Key.Source),
+		zap.String("algorithm", rateLimitConfig.Algorithm),
+		zap.Int("limit", rateLimitConfig.Limit),
+		zap.Duration("period", rateLimitConfig.GetPeriod()),
+		zap.String("store", rateLimitConfig.Store.Type),
+		zap.Bool("matchesOnFailure", rateLimitConfig.MatchesOnFailure),
+	)
+
+	return &rateLimitController{
+		name:             cfg.Name,
+		key:              rateLimitConfig.Key,
+		limit:            rateLimitConfig.Limit,
+		period:           rateLimitConfig.GetPeriod(),
+		store:            store,
+		matchesOnFailure: rateLimitConfig.MatchesOnFailure,
+		logger:           logger,
+	}, nil
+}
@@ -0,0 +1,92 @@
+//go:build e2e
+
+package rate_limit
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+
+	tcredis "github.com/testcontainers/testcontainers-go/modules/redis"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	_ "github.com/gtriggiano/envoy-authorization-service/pkg/match/attribute_match_database"
+)
+
+func TestRedisStore(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	container, err := tcredis.Run(ctx, "redis:7-alpine")
+	if err != nil {
+		t.Fatalf("failed to start redis: %v", err)
+	}
+	defer func() { _ = container.Terminate(context.Background()) }()
+
+	endpoint, err := container.Endpoint(ctx, "")
+	if err != nil {
+		t.Fatalf("failed to get redis endpoint: %v", err)
+	}
+	host, portStr, _ := net.SplitHostPort(endpoint)
+	port, _ := strconv.Atoi(portStr)
+
+	logger := zaptest.NewLogger(t)
+	dataSources, err := controller.BuildDataSources(ctx, logger, []config.DataSourceConfig{{
+		Name:     "cache",
+		Type:     "redis",
+		Settings: map[string]any{"host": host, "port": port},
+	}})
+	if err != nil {
+		t.Fatalf("failed to build datasources: %v", err)
+	}
+	defer func() { _ = dataSources.Close() }()
+	controllersCtx := controller.WithDataSources(ctx, dataSources)
+
+	for _, algorithm := range []string{AlgorithmTokenBucket, AlgorithmSlidingWindow} {
+		t.Run(algorithm, func(t *testing.T) {
+			// Two instances of the service share the counters
+			var instances []controller.MatchController
+			for range 2 {
+				ctrl, err := newRateLimitController(controllersCtx, logger, config.ControllerConfig{
+					Name: "throttle-" + algorithm,
+					Type: ControllerKind,
+					Settings: map[string]any{
+						"key":       map[string]any{"source": "ip"},
+						"algorithm": algorithm,
+						"limit":     2,
+						"period":    "1m",
+						"store":     map[string]any{"type": "redis", "datasource": "cache"},
+					},
+				})
+				if err != nil {
+					t.Fatalf("failed to build controller: %v", err)
+				}
+				if err := ctrl.HealthCheck(ctx); err != nil {
+					t.Fatalf("unexpected health check error: %v", err)
+				}
+				instances = append(instances, ctrl)
+			}
+
+			for _, ctrl := range instances {
+				if verdict := match(t, ctrl, checkRequest("192.0.2.1", nil), nil); verdict.IsMatch {
+					t.Fatalf("expected request within the limit not to match, got: %s", verdict.Description)
+				}
+			}
+			verdict := match(t, instances[0], checkRequest("192.0.2.1", nil), nil)
+			if !verdict.IsMatch {
+				t.Fatalf("expected the limit to be shared by the instances, got: %s", verdict.Description)
+			}
+			if verdict.DenyDownstreamHeaders["RateLimit-Limit"] != "2" || verdict.DenyDownstreamHeaders["Retry-After"] == "" {
+				t.Fatalf("expected rate limit headers, got %v", verdict.DenyDownstreamHeaders)
+			}
+			if verdict := match(t, instances[1], checkRequest("192.0.2.2", nil), nil); verdict.IsMatch {
+				t.Fatalf("expected other IPs to be counted separately, got: %s", verdict.Description)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package rate_limit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/analysis/maxmind_asn"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, cost int) (Decision, error) {
+	return Decision{}, errors.New("connection refused")
+}
+
+func (failingStore) HealthCheck(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestMatch_ExceededLimit(t *testing.T) {
+	ctrl := buildController(t, map[string]any{
+		"key":    map[string]any{"source": "ip"},
+		"limit":  2,
+		"period": "1m",
+	})
+
+	for range 2 {
+		verdict := match(t, ctrl, checkRequest("192.0.2.1", nil), nil)
+		if verdict.IsMatch {
+			t.Fatalf("expected request within the limit not to match, got: %s", verdict.Description)
+		}
+	}
+
+	verdict := match(t, ctrl, checkRequest("192.0.2.1", nil), nil)
+	if !verdict.IsMatch || !strings.Contains(verdict.Description, "IP 192.0.2.1 exceeded 2 requests per 1m0s") {
+		t.Fatalf("expected request over the limit to match, got: %s", verdict.Description)
+	}
+	if verdict.DenyCode != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted deny code, got %v", verdict.DenyCode)
+	}
+	expectedHeaders := map[string]string{
+		"Retry-After":         "30",
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "60",
+	}
+	for name, value := range expectedHeaders {
+		if got := verdict.DenyDownstreamHeaders[name]; got != value {
+			t.Fatalf("expected header %s=%s, got %q", name, value, got)
+		}
+	}
+
+	if verdict := match(t, ctrl, checkRequest("192.0.2.2", nil), nil); verdict.IsMatch {
+		t.Fatalf("expected other IPs to be counted separately, got: %s", verdict.Description)
+	}
+}
+
+func TestMatch_Keys(t *testing.T) {
+	asnReports := controller.AnalysisReports{
+		"asn": {
+			ControllerKind: maxmind_asn.ControllerKind,
+			Data:           map[string]any{"result": &maxmind_asn.IpLookupResult{AutonomousSystemNumber: 64500}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		key         map[string]any
+		first       *authv3.CheckRequest
+		second      *authv3.CheckRequest
+		reports     controller.AnalysisReports
+		description string
+	}{
+		{
+			name:        "ipv6 network",
+			key:         map[string]any{"source": "ip", "ipv6PrefixLength": 64},
+			first:       checkRequest("2001:db8::1", nil),
+			second:      checkRequest("2001:db8::2", nil),
+			description: "network 2001:db8::/64",
+		},
+		{
+			name:        "ipv4 addresses ignore the ipv6 prefix length",
+			key:         map[string]any{"source": "ip", "ipv6PrefixLength": 64},
+			first:       checkRequest("192.0.2.1", nil),
+			second:      checkRequest("192.0.2.1", nil),
+			description: "IP 192.0.2.1",
+		},
+		{
+			name:        "asn",
+			key:         map[string]any{"source": "asn"},
+			first:       checkRequest("192.0.2.1", nil),
+			second:      checkRequest("198.51.100.1", nil),
+			reports:     asnReports,
+			description: "ASN 64500",
+		},
+		{
+			name:        "header",
+			key:         map[string]any{"source": "header", "name": "X-Api-Key"},
+			first:       checkRequest("192.0.2.1", map[string]string{"x-api-key": "k-123"}),
+			second:      checkRequest("198.51.100.1", map[string]string{"x-api-key": "k-123"}),
+			description: "header 'X-Api-Key' value 'k-123'",
+		},
+		{
+			name:        "authority",
+			key:         map[string]any{"source": "authority"},
+			first:       checkRequest("192.0.2.1", map[string]string{"host": "api.example.com"}),
+			second:      checkRequest("198.51.100.1", map[string]string{"host": "api.example.com"}),
+			description: "authority api.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := buildController(t, map[string]any{"key": tt.key, "limit": 1, "period": "1m"})
+
+			if verdict := match(t, ctrl, tt.first, tt.reports); verdict.IsMatch {
+				t.Fatalf("expected the first request not to match, got: %s", verdict.Description)
+			}
+			verdict := match(t, ctrl, tt.second, tt.reports)
+			if !verdict.IsMatch || !strings.Contains(verdict.Description, tt.description+" exceeded") {
+				t.Fatalf("expected the second request to exceed the limit of %s, got: %s", tt.description, verdict.Description)
+			}
+		})
+	}
+}
+
+func TestMatch_MissingKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		key         map[string]any
+		description string
+	}{
+		{"ip", map[string]any{"source": "ip"}, "unable to determine source IP address"},
+		{"asn", map[string]any{"source": "asn"}, "no ASN information available"},
+		{"header", map[string]any{"source": "header", "name": "x-api-key"}, "no rate limit key in header 'x-api-key'"},
+		{"authority", map[string]any{"source": "authority"}, "no authority in request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := buildController(t, map[string]any{"key": tt.key, "limit": 1, "period": "1m"})
+			for range 2 {
+				verdict := match(t, ctrl, &authv3.CheckRequest{}, nil)
+				if verdict.IsMatch || verdict.Description != tt.description {
+					t.Fatalf("expected no match with description %q, got %v %q", tt.description, verdict.IsMatch, verdict.Description)
+				}
+			}
+		})
+	}
+}
+
+func TestMatch_StoreFailure(t *testing.T) {
+	for _, matchesOnFailure := range []bool{false, true} {
+		ctrl := &rateLimitController{
+			name:             "throttle",
+			key:              KeyConfig{Source: KeySourceIP},
+			store:            failingStore{},
+			matchesOnFailure: matchesOnFailure,
+			logger:           zap.NewNop(),
+		}
+		verdict := match(t, ctrl, checkRequest("192.0.2.1", nil), nil)
+		if verdict.IsMatch != matchesOnFailure || !strings.Contains(verdict.Description, "rate limit store unavailable") {
+			t.Fatalf("expected IsMatch=%v on store failure, got %v %q", matchesOnFailure, verdict.IsMatch, verdict.Description)
+		}
+		if ctrl.HealthCheck(context.Background()) == nil {
+			t.Fatal("expected the health check to report the store failure")
+		}
+	}
+}
+
+func TestNewRateLimitController_RedisStoreRequiresDataSource(t *testing.T) {
+	_, err := newRateLimitController(context.Background(), zap.NewNop(), config.ControllerConfig{
+		Name: "throttle",
+		Type: ControllerKind,
+		Settings: map[string]any{
+			"key":    map[string]any{"source": "ip"},
+			"limit":  10,
+			"period": "1m",
+			"store":  map[string]any{"type": "redis", "datasource": "cache"},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "datasource 'cache' is not defined") {
+		t.Fatalf("expected undefined datasource error, got %v", err)
+	}
+}
+
+func buildController(t *testing.T, settings map[string]any) controller.MatchController {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	ctrl, err := newRateLimitController(ctx, zap.NewNop(), config.ControllerConfig{
+		Name:     "throttle",
+		Type:     ControllerKind,
+		Settings: settings,
+	})
+	if err != nil {
+		t.Fatalf("failed to build controller: %v", err)
+	}
+	return ctrl
+}
+
+func match(t *testing.T, ctrl controller.MatchController, req *authv3.CheckRequest, reports controller.AnalysisReports) *controller.MatchVerdict {
+	t.Helper()
+	verdict, err := ctrl.Match(context.Background(), runtime.NewRequestContext(req), reports)
+	if err != nil {
+		t.Fatalf("match returned error: %v", err)
+	}
+	return verdict
+}
+
+func checkRequest(ip string, headers map[string]string) *authv3.CheckRequest {
+	return &authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{
+			Source: &authv3.AttributeContext_Peer{
+				Address: &corev3.Address{
+					Address: &corev3.Address_SocketAddress{
+						SocketAddress: &corev3.SocketAddress{Address: ip},
+					},
+				},
+			},
+			Request: &authv3.AttributeContext_Request{
+				Http: &authv3.AttributeContext_HttpRequest{Headers: headers},
+			},
+		},
+	}
+}
@@ -0,0 +1,166 @@
+package rate_limit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+)
+
+// redisDataSourceType is the type of the shared datasources the redis store uses
+const redisDataSourceType = "redis"
+
+// Both scripts keep the state of a key in one hash, so that they run on a
+// single Redis Cluster slot, and read the clock of the Redis server so that
+// every instance of the service counts on the same time. They implement the
+// algorithms of limiter, counting ARGV[3] hits, and reply {allowed, remaining,
+// retryAfterMs, resetMs}.
+var (
+	tokenBucketScript = redis.NewScript(`
+redis.replicate_commands()
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+local rate = limit / period
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(state[1])
+if tokens == nil then
+  tokens = limit
+else
+  tokens = math.min(limit, tokens + (now - tonumber(state[2])) * rate)
+end
+
+local allowed, retry = 0, 0
+if tokens >= cost then
+  tokens = tokens - cost
+  allowed = 1
+else
+  retry = math.ceil((cost - tokens) / rate)
+end
+local reset = math.ceil((limit - tokens) / rate)
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', now)
+redis.call('PEXPIRE', KEYS[1], math.max(reset, 1))
+return {allowed, math.floor(tokens), retry, reset}
+`)
+
+	slidingWindowScript = redis.NewScript(`
+redis.replicate_commands()
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+local window = math.floor(now / period)
+
+local state = redis.call('HMGET', KEYS[1], 'window', 'current', 'previous')
+local current = tonumber(state[2]) or 0
+local previous = tonumber(state[3]) or 0
+local stored = tonumber(state[1])
+if stored == window - 1 then
+  previous, current = current, 0
+elseif stored ~= window then
+  previous, current = 0, 0
+end
+
+local elapsed = now - window * period
+local reset = period - elapsed
+local estimate = previous * (period - elapsed) / period + current
+
+local allowed, retry = 0, 0
+if estimate + cost <= limit then
+  current = current + cost
+  estimate = estimate + cost
+  allowed = 1
+elseif cost > limit then
+  retry = period
+elseif current + cost > limit then
+  retry = math.ceil(reset + period * (1 - (limit - cost) / current))
+else
+  retry = math.ceil(math.max(period * (1 - (limit - cost - current) / previous) - elapsed, 1))
+end
+
+redis.call('HSET', KEYS[1], 'window', window, 'current', current, 'previous', previous)
+redis.call('PEXPIRE', KEYS[1], reset + period)
+return {allowed, math.max(math.floor(limit - estimate), 0), retry, reset}
+`)
+)
+
+// redisStore keeps the state of every key in a shared Redis datasource
+type redisStore struct {
+	client    redis.UniversalClient
+	shared    *controller.DataSourceHandle
+	script    *redis.Script
+	keyPrefix string
+	limit     string
+	period    string
+	timeout   time.Duration
+}
+
+// newRedisStore builds a store on the shared Redis datasource configured for
+// the limit, holding a reference to it until ctx is canceled
+func newRedisStore(ctx context.Context, cfg LimitConfig) (*redisStore, error) {
+	handle, err := controller.DataSourcesFromContext(ctx).Acquire(cfg.Store.DataSource, redisDataSourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, ok := handle.DataSource().(interface{ Client() redis.UniversalClient })
+	if !ok {
+		handle.Release()
+		return nil, fmt.Errorf("datasource '%s' is not a redis connection", cfg.Store.DataSource)
+	}
+
+	store := &redisStore{
+		client:    shared.Client(),
+		shared:    handle,
+		script:    tokenBucketScript,
+		keyPrefix: cfg.Store.KeyPrefix,
+		limit:     strconv.Itoa(cfg.Limit),
+		period:    strconv.FormatInt(cfg.GetPeriod().Milliseconds(), 10),
+		timeout:   cfg.Store.GetTimeout(),
+	}
+	if cfg.Algorithm == AlgorithmSlidingWindow {
+		store.script = slidingWindowScript
+	}
+
+	go func() {
+		<-ctx.Done()
+		handle.Release()
+	}()
+
+	return store, nil
+}
+
+// Take counts a request of cost hits against the state of key
+func (s *redisStore) Take(ctx context.Context, key string, cost int) (Decision, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	reply, err := s.script.Run(ctx, s.client, []string{s.keyPrefix + key}, s.limit, s.period, cost).Int64Slice()
+	if err != nil {
+		return Decision{}, err
+	}
+	if len(reply) != 4 {
+		return Decision{}, fmt.Errorf("unexpected script reply %v", reply)
+	}
+
+	return Decision{
+		Allowed:    reply[0] == 1,
+		Remaining:  int(reply[1]),
+		RetryAfter: time.Duration(reply[2]) * time.Millisecond,
+		Reset:      time.Duration(reply[3]) * time.Millisecond,
+	}, nil
+}
+
+// HealthCheck verifies connectivity to Redis
+func (s *redisStore) HealthCheck(ctx context.Context) error {
+	return s.shared.HealthCheck(ctx)
+}
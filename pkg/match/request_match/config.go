@@ -0,0 +1,147 @@
+package request_match
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	// AttributeMethod is the HTTP method of the request
+	AttributeMethod = "method"
+	// AttributePath is the request path, without the query string
+	AttributePath = "path"
+	// AttributeQuery is the decoded value of the query parameter name
+	AttributeQuery = "query"
+	// AttributeHeader is the value of the request header name
+	AttributeHeader = "header"
+	// AttributeScheme is the request scheme (http or https)
+	AttributeScheme = "scheme"
+	// AttributeHost is the requested host, without the port
+	AttributeHost = "host"
+)
+
+const (
+	// OperatorExact matches values equal to one of the rule values
+	OperatorExact = "exact"
+	// OperatorPrefix matches values starting with one of the rule values
+	OperatorPrefix = "prefix"
+	// OperatorRegex matches values matched by one of the rule regular expressions
+	OperatorRegex = "regex"
+	// OperatorGlob matches values matched by one of the rule glob patterns
+	OperatorGlob = "glob"
+	// OperatorPresent matches requests carrying the header or query parameter
+	OperatorPresent = "present"
+)
+
+const (
+	// CombineAny matches requests matching at least one rule
+	CombineAny = "any"
+	// CombineAll matches requests matching every rule
+	CombineAll = "all"
+)
+
+var (
+	attributes = []string{AttributeMethod, AttributePath, AttributeQuery, AttributeHeader, AttributeScheme, AttributeHost}
+	operators  = []string{OperatorExact, OperatorPrefix, OperatorRegex, OperatorGlob, OperatorPresent}
+)
+
+// RequestMatchConfig represents the configuration of a request-match controller
+type RequestMatchConfig struct {
+	Combine string       `yaml:"combine"`
+	Rules   []RuleConfig `yaml:"rules"`
+}
+
+// RuleConfig represents a condition on a request attribute
+type RuleConfig struct {
+	Attribute  string   `yaml:"attribute"`
+	Name       string   `yaml:"name"`
+	Operator   string   `yaml:"operator"`
+	Values     []string `yaml:"values"`
+	ValuesFile string   `yaml:"valuesFile"`
+	IgnoreCase bool     `yaml:"ignoreCase"`
+}
+
+// ApplyDefaults sets default values for the configuration
+func (c *RequestMatchConfig) ApplyDefaults() {
+	if c.Combine == "" {
+		c.Combine = CombineAny
+	}
+}
+
+// Validate checks the configuration for completeness. Values files are
+// checked when loaded.
+func (c *RequestMatchConfig) Validate() error {
+	if c.Combine != CombineAny && c.Combine != CombineAll {
+		return fmt.Errorf("combine must be one of '%s' or '%s', got '%s'", CombineAny, CombineAll, c.Combine)
+	}
+	if len(c.Rules) == 0 {
+		return fmt.Errorf("rules requires at least one rule")
+	}
+	for i := range c.Rules {
+		if err := c.Rules[i].validate(); err != nil {
+			return fmt.Errorf("rules[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validate checks the rule settings
+func (r *RuleConfig) validate() error {
+	if r.Attribute == "" {
+		return fmt.Errorf("attribute is required")
+	}
+	if !slices.Contains(attributes, r.Attribute) {
+		return fmt.Errorf("attribute must be one of '%s', got '%s'", strings.Join(attributes, "', '"), r.Attribute)
+	}
+
+	named := r.Attribute == AttributeQuery || r.Attribute == AttributeHeader
+	if named && r.Name == "" {
+		return fmt.Errorf("name is required when attribute is '%s'", r.Attribute)
+	}
+	if !named && r.Name != "" {
+		return fmt.Errorf("name is not supported when attribute is '%s'", r.Attribute)
+	}
+
+	if r.Operator == "" {
+		return fmt.Errorf("operator is required")
+	}
+	if !slices.Contains(operators, r.Operator) {
+		return fmt.Errorf("operator must be one of '%s', got '%s'", strings.Join(operators, "', '"), r.Operator)
+	}
+
+	if r.Operator == OperatorPresent {
+		if !named {
+			return fmt.Errorf("operator '%s' is only supported when attribute is '%s' or '%s'", OperatorPresent, AttributeQuery, AttributeHeader)
+		}
+		if len(r.Values) > 0 || r.ValuesFile != "" || r.IgnoreCase {
+			return fmt.Errorf("values, valuesFile and ignoreCase are not supported with operator '%s'", OperatorPresent)
+		}
+		return nil
+	}
+
+	if len(r.Values) == 0 && r.ValuesFile == "" {
+		return fmt.Errorf("values or valuesFile is required with operator '%s'", r.Operator)
+	}
+	return validateValues(r.Operator, r.Values)
+}
+
+// validateValues checks the values of a rule, whether configured or loaded
+// from its values file
+func validateValues(operator string, values []string) error {
+	for _, value := range values {
+		switch operator {
+		case OperatorRegex:
+			if _, err := regexp.Compile(value); err != nil {
+				return fmt.Errorf("invalid regex '%s': %w", value, err)
+			}
+		case OperatorGlob:
+			if _, err := path.Match(value, ""); err != nil {
+				return fmt.Errorf("invalid glob '%s': %w", value, err)
+			}
+		}
+	}
+	return nil
+}
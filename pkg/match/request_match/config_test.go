@@ -0,0 +1,99 @@
+package request_match
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRequestMatchConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  RequestMatchConfig
+		wantErr string
+	}{
+		{
+			name:   "path prefix",
+			config: RequestMatchConfig{Rules: []RuleConfig{{Attribute: AttributePath, Operator: OperatorPrefix, Values: []string{"/admin/"}}}},
+		},
+		{
+			name:   "header present and query values file",
+			config: RequestMatchConfig{Combine: CombineAll, Rules: []RuleConfig{{Attribute: AttributeHeader, Name: "authorization", Operator: OperatorPresent}, {Attribute: AttributeQuery, Name: "tenant", Operator: OperatorExact, ValuesFile: "tenants.txt"}}},
+		},
+		{
+			name:    "unknown combine",
+			config:  RequestMatchConfig{Combine: "none", Rules: []RuleConfig{{Attribute: AttributeMethod, Operator: OperatorExact, Values: []string{"POST"}}}},
+			wantErr: "combine must be one of 'any' or 'all'",
+		},
+		{
+			name:    "no rules",
+			config:  RequestMatchConfig{},
+			wantErr: "rules requires at least one rule",
+		},
+		{
+			name:    "missing attribute",
+			config:  RequestMatchConfig{Rules: []RuleConfig{{Operator: OperatorExact, Values: []string{"POST"}}}},
+			wantErr: "rules[0]: attribute is required",
+		},
+		{
+			name:    "unknown attribute",
+			config:  RequestMatchConfig{Rules: []RuleConfig{{Attribute: "body", Operator: OperatorExact, Values: []string{"x"}}}},
+			wantErr: "attribute must be one of",
+		},
+		{
+			name:    "header without name",
+			config:  RequestMatchConfig{Rules: []RuleConfig{{Attribute: AttributeHeader, Operator: OperatorPresent}}},
+			wantErr: "name is required when attribute is 'header'",
+		},
+		{
+			name:    "name with path",
+			config:  RequestMatchConfig{Rules: []RuleConfig{{Attribute: AttributePath, Name: "x", Operator: OperatorPrefix, Values: []string{"/"}}}},
+			wantErr: "name is not supported when attribute is 'path'",
+		},
+		{
+			name:    "unknown operator",
+			config:  RequestMatchConfig{Rules: []RuleConfig{{Attribute: AttributePath, Operator: "suffix", Values: []string{".php"}}}},
+			wantErr: "operator must be one of",
+		},
+		{
+			name:    "present with method",
+			config:  RequestMatchConfig{Rules: []RuleConfig{{Attribute: AttributeMethod, Operator: OperatorPresent}}},
+			wantErr: "operator 'present' is only supported when attribute is 'query' or 'header'",
+		},
+		{
+			name:    "present with values",
+			config:  RequestMatchConfig{Rules: []RuleConfig{{Attribute: AttributeHeader, Name: "x-debug", Operator: OperatorPresent, Values: []string{"1"}}}},
+			wantErr: "not supported with operator 'present'",
+		},
+		{
+			name:    "missing values",
+			config:  RequestMatchConfig{Rules: []RuleConfig{{Attribute: AttributeHost, Operator: OperatorExact}}},
+			wantErr: "values or valuesFile is required with operator 'exact'",
+		},
+		{
+			name:    "invalid regex",
+			config:  RequestMatchConfig{Rules: []RuleConfig{{Attribute: AttributePath, Operator: OperatorRegex, Values: []string{"^/api/(v1"}}}},
+			wantErr: "invalid regex '^/api/(v1'",
+		},
+		{
+			name:    "invalid glob",
+			config:  RequestMatchConfig{Rules: []RuleConfig{{Attribute: AttributePath, Operator: OperatorGlob, Values: []string{"/api/[v1"}}}},
+			wantErr: "invalid glob '/api/[v1'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.ApplyDefaults()
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
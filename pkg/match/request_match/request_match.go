@@ -0,0 +1,114 @@
+package request_match
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+const (
+	ControllerKind = "request-match"
+)
+
+// init registers the request-match match controller so it can be constructed
+// from configuration at runtime.
+func init() {
+	controller.RegisterMatchControllerFactory(ControllerKind, newRequestMatchController)
+}
+
+type requestMatchController struct {
+	name    string
+	combine string
+	rules   []*rule
+}
+
+// Match implements controller.MatchController. The request matches when any
+// rule, or every rule with combine 'all', matches it.
+func (c *requestMatchController) Match(ctx context.Context, req *runtime.RequestContext, reports controller.AnalysisReports) (*controller.MatchVerdict, error) {
+	var matches []string
+	for _, rule := range c.rules {
+		matched, value, ruleValue := rule.match(req)
+		description := rule.describeMatch(matched, value, ruleValue)
+
+		if matched && c.combine == CombineAny {
+			return c.createVerdict(true, description), nil
+		}
+		if !matched && c.combine == CombineAll {
+			return c.createVerdict(false, description), nil
+		}
+		if matched {
+			matches = append(matches, description)
+		}
+	}
+
+	if c.combine == CombineAll {
+		return c.createVerdict(true, strings.Join(matches, ", ")), nil
+	}
+	return c.createVerdict(false, "request did not match any rule"), nil
+}
+
+// Name implements controller.MatchController.
+func (c *requestMatchController) Name() string {
+	return c.name
+}
+
+// Kind implements controller.MatchController.
+func (c *requestMatchController) Kind() string {
+	return ControllerKind
+}
+
+// HealthCheck implements controller.MatchController.
+func (c *requestMatchController) HealthCheck(ctx context.Context) error {
+	// No external dependencies to check
+	return nil
+}
+
+// createVerdict builds a verdict of the controller
+func (c *requestMatchController) createVerdict(isMatch bool, description string) *controller.MatchVerdict {
+	return &controller.MatchVerdict{
+		Controller:     c.name,
+		ControllerType: ControllerKind,
+		DenyCode:       codes.PermissionDenied,
+		Description:    description,
+		IsMatch:        isMatch,
+	}
+}
+
+// newRequestMatchController constructs a request-match controller from
+// configuration, loading the values files of its rules
+func newRequestMatchController(ctx context.Context, logger *zap.Logger, cfg config.ControllerConfig) (controller.MatchController, error) {
+	var requestMatchConfig RequestMatchConfig
+	if err := controller.DecodeControllerSettings(cfg.Settings, &requestMatchConfig); err != nil {
+		return nil, err
+	}
+	requestMatchConfig.ApplyDefaults()
+	if err := requestMatchConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	ctrl := &requestMatchController{
+		name:    cfg.Name,
+		combine: requestMatchConfig.Combine,
+	}
+	for i, ruleConfig := range requestMatchConfig.Rules {
+		rule, err := newRule(ruleConfig)
+		if err != nil {
+			return nil, fmt.Errorf("rules[%d]: %w", i, err)
+		}
+		ctrl.rules = append(ctrl.rules, rule)
+	}
+
+	logger.Info("controller initialized",
+		zap.String("combine", requestMatchConfig.Combine),
+		zap.Int("rules", len(ctrl.rules)),
+	)
+
+	return ctrl, nil
+}
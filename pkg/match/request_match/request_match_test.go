@@ -0,0 +1,220 @@
+package request_match
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"go.uber.org/zap"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+func TestMatch_Rules(t *testing.T) {
+	tests := []struct {
+		name        string
+		rule        map[string]any
+		request     *authv3.AttributeContext_HttpRequest
+		match       bool
+		description string
+	}{
+		{
+			name:        "method exact",
+			rule:        map[string]any{"attribute": "method", "operator": "exact", "values": []string{"POST", "PUT"}},
+			request:     &authv3.AttributeContext_HttpRequest{Method: "PUT"},
+			match:       true,
+			description: "method 'PUT' matched exact 'PUT'",
+		},
+		{
+			name:        "path prefix ignores query",
+			rule:        map[string]any{"attribute": "path", "operator": "prefix", "values": []string{"/admin/"}},
+			request:     &authv3.AttributeContext_HttpRequest{Path: "/api?next=/admin/"},
+			description: "path '/api' did not match",
+		},
+		{
+			name:        "path glob",
+			rule:        map[string]any{"attribute": "path", "operator": "glob", "values": []string{"/tenants/*/admin"}},
+			request:     &authv3.AttributeContext_HttpRequest{Path: "/tenants/acme/admin?page=2"},
+			match:       true,
+			description: "path '/tenants/acme/admin' matched glob '/tenants/*/admin'",
+		},
+		{
+			name:        "path normalized",
+			rule:        map[string]any{"attribute": "path", "operator": "prefix", "values": []string{"/admin/"}},
+			request:     &authv3.AttributeContext_HttpRequest{Path: "//x/../%61dmin/users?tab=1"},
+			match:       true,
+			description: "path '/admin/users' matched prefix '/admin/'",
+		},
+		{
+			name:    "glob does not cross segments",
+			rule:    map[string]any{"attribute": "path", "operator": "glob", "values": []string{"/tenants/*/admin"}},
+			request: &authv3.AttributeContext_HttpRequest{Path: "/tenants/acme/users/admin"},
+		},
+		{
+			name:        "path regex ignoring case",
+			rule:        map[string]any{"attribute": "path", "operator": "regex", "values": []string{`\.php$`}, "ignoreCase": true},
+			request:     &authv3.AttributeContext_HttpRequest{Path: "/INDEX.PHP"},
+			match:       true,
+			description: `path '/INDEX.PHP' matched regex '(?i)\.php$'`,
+		},
+		{
+			name:        "query parameter with several values",
+			rule:        map[string]any{"attribute": "query", "name": "tenant", "operator": "exact", "values": []string{"acme corp"}},
+			request:     &authv3.AttributeContext_HttpRequest{Path: "/?tenant=other&tenant=acme+corp"},
+			match:       true,
+			description: "query parameter 'tenant' 'acme corp' matched exact 'acme corp'",
+		},
+		{
+			name:        "query parameter present",
+			rule:        map[string]any{"attribute": "query", "name": "debug", "operator": "present"},
+			request:     &authv3.AttributeContext_HttpRequest{Path: "/?debug"},
+			match:       true,
+			description: "query parameter 'debug' present",
+		},
+		{
+			name:        "header present",
+			rule:        map[string]any{"attribute": "header", "name": "X-Debug", "operator": "present"},
+			request:     &authv3.AttributeContext_HttpRequest{Headers: map[string]string{"accept": "*/*"}},
+			description: "header 'x-debug' missing",
+		},
+		{
+			name:        "header value ignoring case",
+			rule:        map[string]any{"attribute": "header", "name": "user-agent", "operator": "prefix", "values": []string{"curl/"}, "ignoreCase": true},
+			request:     &authv3.AttributeContext_HttpRequest{Headers: map[string]string{"user-agent": "Curl/8.5.0"}},
+			match:       true,
+			description: "header 'user-agent' 'Curl/8.5.0' matched prefix 'curl/'",
+		},
+		{
+			name:        "scheme",
+			rule:        map[string]any{"attribute": "scheme", "operator": "exact", "values": []string{"http"}},
+			request:     &authv3.AttributeContext_HttpRequest{Scheme: "https"},
+			description: "scheme 'https' did not match",
+		},
+		{
+			name:        "host without port",
+			rule:        map[string]any{"attribute": "host", "operator": "glob", "values": []string{"*.internal.example.com"}},
+			request:     &authv3.AttributeContext_HttpRequest{Host: "admin.internal.example.com:8443"},
+			match:       true,
+			description: "host 'admin.internal.example.com' matched glob '*.internal.example.com'",
+		},
+		{
+			name:        "missing host",
+			rule:        map[string]any{"attribute": "host", "operator": "exact", "values": []string{"example.com"}},
+			request:     &authv3.AttributeContext_HttpRequest{},
+			description: "host missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := buildController(t, map[string]any{"combine": "all", "rules": []map[string]any{tt.rule}})
+			verdict := match(t, ctrl, tt.request)
+			if verdict.IsMatch != tt.match {
+				t.Fatalf("expected IsMatch=%v, got %v: %s", tt.match, verdict.IsMatch, verdict.Description)
+			}
+			if tt.description != "" && verdict.Description != tt.description {
+				t.Fatalf("expected description %q, got %q", tt.description, verdict.Description)
+			}
+		})
+	}
+}
+
+func TestMatch_Combine(t *testing.T) {
+	rules := []map[string]any{
+		{"attribute": "path", "operator": "prefix", "values": []string{"/admin/"}},
+		{"attribute": "method", "operator": "exact", "values": []string{"DELETE"}},
+	}
+	adminGet := &authv3.AttributeContext_HttpRequest{Method: "GET", Path: "/admin/users"}
+	adminDelete := &authv3.AttributeContext_HttpRequest{Method: "DELETE", Path: "/admin/users"}
+
+	anyController := buildController(t, map[string]any{"rules": rules})
+	if verdict := match(t, anyController, adminGet); !verdict.IsMatch {
+		t.Fatalf("expected any rule to match, got: %s", verdict.Description)
+	}
+	if verdict := match(t, anyController, &authv3.AttributeContext_HttpRequest{Method: "GET", Path: "/"}); verdict.IsMatch || verdict.Description != "request did not match any rule" {
+		t.Fatalf("expected no rule to match, got: %s", verdict.Description)
+	}
+
+	allController := buildController(t, map[string]any{"combine": "all", "rules": rules})
+	if verdict := match(t, allController, adminGet); verdict.IsMatch || verdict.Description != "method 'GET' did not match" {
+		t.Fatalf("expected all rules to be required, got: %s", verdict.Description)
+	}
+	verdict := match(t, allController, adminDelete)
+	if !verdict.IsMatch || verdict.Description != "path '/admin/users' matched prefix '/admin/', method 'DELETE' matched exact 'DELETE'" {
+		t.Fatalf("expected every rule to match, got: %s", verdict.Description)
+	}
+}
+
+func TestNewRequestMatchController_ValuesFile(t *testing.T) {
+	dir := t.TempDir()
+	valuesFile := filepath.Join(dir, "tenants.txt")
+	if err := os.WriteFile(valuesFile, []byte("# Enterprise tenants\nacme\n\nglobex\n"), 0o600); err != nil {
+		t.Fatalf("failed to write values file: %v", err)
+	}
+
+	ctrl := buildController(t, map[string]any{
+		"rules": []map[string]any{{"attribute": "header", "name": "x-tenant-id", "operator": "exact", "values": []string{"initech"}, "valuesFile": valuesFile}},
+	})
+	for _, tenant := range []string{"acme", "globex", "initech"} {
+		if verdict := match(t, ctrl, &authv3.AttributeContext_HttpRequest{Headers: map[string]string{"x-tenant-id": tenant}}); !verdict.IsMatch {
+			t.Fatalf("expected tenant %s to match, got: %s", tenant, verdict.Description)
+		}
+	}
+	if verdict := match(t, ctrl, &authv3.AttributeContext_HttpRequest{Headers: map[string]string{"x-tenant-id": "# Enterprise tenants"}}); verdict.IsMatch {
+		t.Fatalf("expected comments not to be values, got: %s", verdict.Description)
+	}
+
+	invalidFile := filepath.Join(dir, "patterns.txt")
+	if err := os.WriteFile(invalidFile, []byte("^/api/(v1\n"), 0o600); err != nil {
+		t.Fatalf("failed to write values file: %v", err)
+	}
+	_, err := newRequestMatchController(context.Background(), zap.NewNop(), config.ControllerConfig{
+		Name:     "api-paths",
+		Type:     ControllerKind,
+		Settings: map[string]any{"rules": []map[string]any{{"attribute": "path", "operator": "regex", "valuesFile": invalidFile}}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "rules[0]: valuesFile: invalid regex") {
+		t.Fatalf("expected invalid values file error, got %v", err)
+	}
+
+	_, err = newRequestMatchController(context.Background(), zap.NewNop(), config.ControllerConfig{
+		Name:     "tenants",
+		Type:     ControllerKind,
+		Settings: map[string]any{"rules": []map[string]any{{"attribute": "path", "operator": "exact", "valuesFile": filepath.Join(dir, "missing.txt")}}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "could not read valuesFile") {
+		t.Fatalf("expected missing values file error, got %v", err)
+	}
+}
+
+func buildController(t *testing.T, settings map[string]any) controller.MatchController {
+	t.Helper()
+	ctrl, err := newRequestMatchController(context.Background(), zap.NewNop(), config.ControllerConfig{
+		Name:     "admin-paths",
+		Type:     ControllerKind,
+		Settings: settings,
+	})
+	if err != nil {
+		t.Fatalf("failed to build controller: %v", err)
+	}
+	return ctrl
+}
+
+func match(t *testing.T, ctrl controller.MatchController, http *authv3.AttributeContext_HttpRequest) *controller.MatchVerdict {
+	t.Helper()
+	req := &authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{
+			Request: &authv3.AttributeContext_Request{Http: http},
+		},
+	}
+	verdict, err := ctrl.Match(context.Background(), runtime.NewRequestContext(req), nil)
+	if err != nil {
+		t.Fatalf("match returned error: %v", err)
+	}
+	return verdict
+}
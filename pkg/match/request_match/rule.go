@@ -0,0 +1,224 @@
+package request_match
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+// rule is a compiled condition on a request attribute
+type rule struct {
+	attribute  string
+	name       string
+	operator   string
+	ignoreCase bool
+	exact      map[string]struct{}
+	values     []string // prefixes and glob patterns
+	patterns   []*regexp.Regexp
+}
+
+// newRule compiles a validated rule configuration, loading its values file
+func newRule(cfg RuleConfig) (*rule, error) {
+	values := cfg.Values
+	if cfg.ValuesFile != "" {
+		fileValues, err := readValuesFile(cfg.ValuesFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateValues(cfg.Operator, fileValues); err != nil {
+			return nil, fmt.Errorf("valuesFile: %w", err)
+		}
+		values = append(append([]string{}, values...), fileValues...)
+	}
+
+	r := &rule{
+		attribute:  cfg.Attribute,
+		name:       cfg.Name,
+		operator:   cfg.Operator,
+		ignoreCase: cfg.IgnoreCase,
+	}
+	if r.attribute == AttributeHeader {
+		r.name = strings.ToLower(r.name)
+	}
+
+	switch r.operator {
+	case OperatorExact:
+		r.exact = make(map[string]struct{}, len(values))
+		for _, value := range values {
+			r.exact[r.fold(value)] = struct{}{}
+		}
+	case OperatorPrefix, OperatorGlob:
+		for _, value := range values {
+			r.values = append(r.values, r.fold(value))
+		}
+	case OperatorRegex:
+		for _, value := range values {
+			if r.ignoreCase {
+				value = "(?i)" + value
+			}
+			r.patterns = append(r.patterns, regexp.MustCompile(value))
+		}
+	}
+
+	return r, nil
+}
+
+// match reports whether the request matches the rule, with the value and
+// the rule value it matched
+func (r *rule) match(req *runtime.RequestContext) (bool, string, string) {
+	values, present := r.extract(req)
+	if r.operator == OperatorPresent {
+		return present, "", ""
+	}
+
+	for _, value := range values {
+		if matched, ruleValue := r.matchValue(value); matched {
+			return true, value, ruleValue
+		}
+	}
+	if len(values) > 0 {
+		return false, values[0], ""
+	}
+	return false, "", ""
+}
+
+// matchValue reports whether a value of the attribute matches one of the
+// rule values, returning it
+func (r *rule) matchValue(value string) (bool, string) {
+	folded := r.fold(value)
+
+	switch r.operator {
+	case OperatorExact:
+		if _, ok := r.exact[folded]; ok {
+			return true, value
+		}
+	case OperatorPrefix:
+		for _, prefix := range r.values {
+			if strings.HasPrefix(folded, prefix) {
+				return true, prefix
+			}
+		}
+	case OperatorGlob:
+		for _, pattern := range r.values {
+			if matched, _ := path.Match(pattern, folded); matched {
+				return true, pattern
+			}
+		}
+	case OperatorRegex:
+		for _, pattern := range r.patterns {
+			if pattern.MatchString(value) {
+				return true, pattern.String()
+			}
+		}
+	}
+	return false, ""
+}
+
+// extract returns the values of the attribute in the request and whether
+// the request carries it
+func (r *rule) extract(req *runtime.RequestContext) ([]string, bool) {
+	http := req.Request.GetAttributes().GetRequest().GetHttp()
+
+	var value string
+	switch r.attribute {
+	case AttributeMethod:
+		value = http.GetMethod()
+	case AttributePath:
+		value = req.Path()
+	case AttributeQuery:
+		_, rawQuery, _ := strings.Cut(http.GetPath(), "?")
+		query, _ := url.ParseQuery(rawQuery)
+		values, ok := query[r.name]
+		return values, ok
+	case AttributeHeader:
+		for name, headerValue := range http.GetHeaders() {
+			if strings.ToLower(name) == r.name {
+				return []string{headerValue}, true
+			}
+		}
+		return nil, false
+	case AttributeScheme:
+		value = http.GetScheme()
+	case AttributeHost:
+		if req.Authority != "-" {
+			value = req.Authority
+			if host, _, err := net.SplitHostPort(value); err == nil {
+				value = host
+			}
+		}
+	}
+
+	if value == "" {
+		return nil, false
+	}
+	return []string{value}, true
+}
+
+// fold lowercases value when the rule ignores case
+func (r *rule) fold(value string) string {
+	if r.ignoreCase {
+		return strings.ToLower(value)
+	}
+	return value
+}
+
+// describe renders the attribute of the rule for verdict descriptions
+func (r *rule) describe() string {
+	switch r.attribute {
+	case AttributeQuery:
+		return fmt.Sprintf("query parameter '%s'", r.name)
+	case AttributeHeader:
+		return fmt.Sprintf("header '%s'", r.name)
+	}
+	return r.attribute
+}
+
+// describeMatch explains the outcome of the rule on a request
+func (r *rule) describeMatch(matched bool, value, ruleValue string) string {
+	if r.operator == OperatorPresent {
+		if matched {
+			return r.describe() + " present"
+		}
+		return r.describe() + " missing"
+	}
+	if matched {
+		return fmt.Sprintf("%s '%s' matched %s '%s'", r.describe(), value, r.operator, ruleValue)
+	}
+	if value == "" {
+		return r.describe() + " missing"
+	}
+	return fmt.Sprintf("%s '%s' did not match", r.describe(), value)
+}
+
+// readValuesFile reads a values file: one value per line, skipping blank
+// lines and lines starting with '#'
+func readValuesFile(location string) ([]string, error) {
+	valuesFilePath, err := filepath.Abs(location)
+	if err != nil {
+		return nil, fmt.Errorf("valuesFile path is not valid: %w", err)
+	}
+	content, err := os.ReadFile(valuesFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read valuesFile: %w", err)
+	}
+
+	var values []string
+	for rawLine := range strings.SplitSeq(string(content), "\n") {
+		line := strings.TrimSpace(rawLine)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		values = append(values, line)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("valuesFile %s contains no values", location)
+	}
+	return values, nil
+}
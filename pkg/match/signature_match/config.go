@@ -0,0 +1,129 @@
+package signature_match
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/secrets"
+)
+
+const (
+	SchemeGitHub  = "github"
+	SchemeStripe  = "stripe"
+	SchemeSlack   = "slack"
+	SchemeGeneric = "generic"
+)
+
+const (
+	defaultTolerance = "5m"
+	defaultAlgorithm = "sha256"
+	defaultEncoding  = "hex"
+	defaultPayload   = "{body}"
+)
+
+// SignatureMatchConfig represents the configuration of a signature-match
+// controller. The request matches when its body is signed with any of the
+// secrets.
+type SignatureMatchConfig struct {
+	Scheme    string           `yaml:"scheme"`
+	Secrets   []secrets.Config `yaml:"secrets"`
+	Tolerance string           `yaml:"tolerance"`
+	Generic   *GenericConfig   `yaml:"generic"`
+}
+
+// GenericConfig represents a custom HMAC signature scheme
+type GenericConfig struct {
+	SignatureHeader string `yaml:"signatureHeader"`
+	Prefix          string `yaml:"prefix"`
+	Algorithm       string `yaml:"algorithm"`
+	Encoding        string `yaml:"encoding"`
+	TimestampHeader string `yaml:"timestampHeader"`
+	Payload         string `yaml:"payload"`
+}
+
+// ApplyDefaults sets default values for the configuration
+func (c *SignatureMatchConfig) ApplyDefaults() {
+	if c.Tolerance == "" {
+		c.Tolerance = defaultTolerance
+	}
+	if c.Generic != nil {
+		if c.Generic.Algorithm == "" {
+			c.Generic.Algorithm = defaultAlgorithm
+		}
+		if c.Generic.Encoding == "" {
+			c.Generic.Encoding = defaultEncoding
+		}
+		if c.Generic.Payload == "" {
+			c.Generic.Payload = defaultPayload
+		}
+	}
+}
+
+// Validate checks the configuration for completeness. The secrets are read
+// when the controller is created.
+func (c *SignatureMatchConfig) Validate() error {
+	switch c.Scheme {
+	case SchemeGitHub, SchemeStripe, SchemeSlack:
+		if c.Generic != nil {
+			return fmt.Errorf("generic is only supported with scheme '%s'", SchemeGeneric)
+		}
+	case SchemeGeneric:
+		if c.Generic == nil {
+			return fmt.Errorf("generic is required with scheme '%s'", SchemeGeneric)
+		}
+		if err := c.Generic.validate(); err != nil {
+			return fmt.Errorf("generic.%w", err)
+		}
+	case "":
+		return fmt.Errorf("scheme is required")
+	default:
+		return fmt.Errorf("unsupported scheme '%s', expected one of github, stripe, slack or generic", c.Scheme)
+	}
+
+	if err := secrets.Validate(c.Secrets); err != nil {
+		return err
+	}
+
+	tolerance, err := time.ParseDuration(c.Tolerance)
+	if err != nil {
+		return fmt.Errorf("invalid tolerance: %w", err)
+	}
+	if tolerance <= 0 {
+		return fmt.Errorf("tolerance must be positive")
+	}
+
+	return nil
+}
+
+// GetTolerance returns the parsed maximum age of signed timestamps
+func (c *SignatureMatchConfig) GetTolerance() time.Duration {
+	tolerance, _ := time.ParseDuration(c.Tolerance)
+	return tolerance
+}
+
+// validate checks the custom signature scheme
+func (g *GenericConfig) validate() error {
+	if g.SignatureHeader == "" {
+		return fmt.Errorf("signatureHeader is required")
+	}
+	if strings.ContainsAny(g.SignatureHeader, " :\t\r\n") {
+		return fmt.Errorf("signatureHeader: invalid header name '%s'", g.SignatureHeader)
+	}
+	if strings.ContainsAny(g.TimestampHeader, " :\t\r\n") {
+		return fmt.Errorf("timestampHeader: invalid header name '%s'", g.TimestampHeader)
+	}
+	if _, ok := hashes[g.Algorithm]; !ok {
+		return fmt.Errorf("unsupported algorithm '%s', expected one of sha1, sha256 or sha512", g.Algorithm)
+	}
+	if g.Encoding != "hex" && g.Encoding != "base64" {
+		return fmt.Errorf("unsupported encoding '%s', expected hex or base64", g.Encoding)
+	}
+	if !strings.Contains(g.Payload, "{body}") {
+		return fmt.Errorf("payload must contain {body}")
+	}
+	if strings.Contains(g.Payload, "{timestamp}") != (g.TimestampHeader != "") {
+		return fmt.Errorf("payload must contain {timestamp} when, and only when, timestampHeader is set")
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package signature_match
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/secrets"
+)
+
+func TestSignatureMatchConfigValidate(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "secret")
+
+	tests := []struct {
+		name    string
+		config  SignatureMatchConfig
+		wantErr string
+	}{
+		{
+			name:   "github",
+			config: SignatureMatchConfig{Scheme: SchemeGitHub, Secrets: []secrets.Config{{Env: "WEBHOOK_SECRET"}, {File: "/run/secrets/previous"}}},
+		},
+		{
+			name:   "generic",
+			config: SignatureMatchConfig{Scheme: SchemeGeneric, Secrets: []secrets.Config{{Env: "WEBHOOK_SECRET"}}, Generic: &GenericConfig{SignatureHeader: "X-Signature", TimestampHeader: "X-Timestamp", Payload: "{timestamp}:{body}"}},
+		},
+		{
+			name:    "missing scheme",
+			config:  SignatureMatchConfig{Secrets: []secrets.Config{{Env: "WEBHOOK_SECRET"}}},
+			wantErr: "scheme is required",
+		},
+		{
+			name:    "unsupported scheme",
+			config:  SignatureMatchConfig{Scheme: "shopify", Secrets: []secrets.Config{{Env: "WEBHOOK_SECRET"}}},
+			wantErr: "unsupported scheme 'shopify'",
+		},
+		{
+			name:    "generic settings with github",
+			config:  SignatureMatchConfig{Scheme: SchemeGitHub, Secrets: []secrets.Config{{Env: "WEBHOOK_SECRET"}}, Generic: &GenericConfig{SignatureHeader: "X-Signature"}},
+			wantErr: "generic is only supported with scheme 'generic'",
+		},
+		{
+			name:    "generic without settings",
+			config:  SignatureMatchConfig{Scheme: SchemeGeneric, Secrets: []secrets.Config{{Env: "WEBHOOK_SECRET"}}},
+			wantErr: "generic is required with scheme 'generic'",
+		},
+		{
+			name:    "no secrets",
+			config:  SignatureMatchConfig{Scheme: SchemeStripe},
+			wantErr: "at least one secret is required",
+		},
+		{
+			name:    "secret with env and file",
+			config:  SignatureMatchConfig{Scheme: SchemeSlack, Secrets: []secrets.Config{{Env: "WEBHOOK_SECRET", File: "/run/secrets/slack"}}},
+			wantErr: "secrets[0]: exactly one of env or file is required",
+		},
+		{
+			name:    "missing environment variable",
+			config:  SignatureMatchConfig{Scheme: SchemeSlack, Secrets: []secrets.Config{{Env: "WEBHOOK_SECRET"}, {Env: "UNDEFINED_WEBHOOK_SECRET"}}},
+			wantErr: "secrets[1]: environment variable 'UNDEFINED_WEBHOOK_SECRET' not found",
+		},
+		{
+			name:    "invalid tolerance",
+			config:  SignatureMatchConfig{Scheme: SchemeStripe, Secrets: []secrets.Config{{Env: "WEBHOOK_SECRET"}}, Tolerance: "-1m"},
+			wantErr: "tolerance must be positive",
+		},
+		{
+			name:    "generic without header",
+			config:  SignatureMatchConfig{Scheme: SchemeGeneric, Secrets: []secrets.Config{{Env: "WEBHOOK_SECRET"}}, Generic: &GenericConfig{}},
+			wantErr: "generic.signatureHeader is required",
+		},
+		{
+			name:    "generic algorithm",
+			config:  SignatureMatchConfig{Scheme: SchemeGeneric, Secrets: []secrets.Config{{Env: "WEBHOOK_SECRET"}}, Generic: &GenericConfig{SignatureHeader: "X-Signature", Algorithm: "md5"}},
+			wantErr: "generic.unsupported algorithm 'md5'",
+		},
+		{
+			name:    "generic encoding",
+			config:  SignatureMatchConfig{Scheme: SchemeGeneric, Secrets: []secrets.Config{{Env: "WEBHOOK_SECRET"}}, Generic: &GenericConfig{SignatureHeader: "X-Signature", Encoding: "base32"}},
+			wantErr: "generic.unsupported encoding 'base32'",
+		},
+		{
+			name:    "generic payload without body",
+			config:  SignatureMatchConfig{Scheme: SchemeGeneric, Secrets: []secrets.Config{{Env: "WEBHOOK_SECRET"}}, Generic: &GenericConfig{SignatureHeader: "X-Signature", Payload: "{timestamp}"}},
+			wantErr: "generic.payload must contain {body}",
+		},
+		{
+			name:    "generic timestamp not signed",
+			config:  SignatureMatchConfig{Scheme: SchemeGeneric, Secrets: []secrets.Config{{Env: "WEBHOOK_SECRET"}}, Generic: &GenericConfig{SignatureHeader: "X-Signature", TimestampHeader: "X-Timestamp"}},
+			wantErr: "generic.payload must contain {timestamp} when, and only when, timestampHeader is set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.ApplyDefaults()
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
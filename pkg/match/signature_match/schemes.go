@@ -0,0 +1,169 @@
+package signature_match
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+var hashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+var (
+	errSignatureMalformed = errors.New("signature is malformed")
+	errTimestampMalformed = errors.New("timestamp is malformed")
+)
+
+// signedRequest represents the payload of a request and the signatures it
+// carries
+type signedRequest struct {
+	payload    []byte
+	signatures [][]byte
+	timestamp  time.Time // zero when the scheme does not sign a timestamp
+}
+
+// scheme extracts the signed payload and the signatures of a request
+type scheme struct {
+	hash            func() hash.Hash
+	signatureHeader string
+	extract         func(headers map[string]string, body []byte) (*signedRequest, error)
+}
+
+// newScheme returns the scheme of the configuration
+func newScheme(cfg SignatureMatchConfig) scheme {
+	switch cfg.Scheme {
+	case SchemeGitHub:
+		return scheme{hash: sha256.New, signatureHeader: "x-hub-signature-256", extract: extractGitHub}
+	case SchemeStripe:
+		return scheme{hash: sha256.New, signatureHeader: "stripe-signature", extract: extractStripe}
+	case SchemeSlack:
+		return scheme{hash: sha256.New, signatureHeader: "x-slack-signature", extract: extractSlack}
+	default:
+		generic := cfg.Generic
+		return scheme{hash: hashes[generic.Algorithm], signatureHeader: strings.ToLower(generic.SignatureHeader), extract: generic.extract}
+	}
+}
+
+// extractGitHub reads 'X-Hub-Signature-256: sha256=<hex>'
+func extractGitHub(headers map[string]string, body []byte) (*signedRequest, error) {
+	value, ok := strings.CutPrefix(runtime.HeaderValue(headers, "x-hub-signature-256"), "sha256=")
+	if !ok {
+		return nil, errSignatureMalformed
+	}
+	signature, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, errSignatureMalformed
+	}
+	return &signedRequest{payload: body, signatures: [][]byte{signature}}, nil
+}
+
+// extractStripe reads 'Stripe-Signature: t=<unix>,v1=<hex>[,v1=<hex>]',
+// signing '<unix>.<body>'. Several v1 signatures are sent while Stripe
+// rotates secrets.
+func extractStripe(headers map[string]string, body []byte) (*signedRequest, error) {
+	var timestamp string
+	request := &signedRequest{}
+	for _, element := range strings.Split(runtime.HeaderValue(headers, "stripe-signature"), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(element), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature, err := hex.DecodeString(value)
+			if err != nil {
+				return nil, errSignatureMalformed
+			}
+			request.signatures = append(request.signatures, signature)
+		}
+	}
+	if len(request.signatures) == 0 {
+		return nil, errSignatureMalformed
+	}
+
+	var err error
+	if request.timestamp, err = parseUnixTimestamp(timestamp); err != nil {
+		return nil, err
+	}
+	request.payload = signedPayload(timestamp+".", body)
+	return request, nil
+}
+
+// extractSlack reads 'X-Slack-Signature: v0=<hex>' and
+// 'X-Slack-Request-Timestamp: <unix>', signing 'v0:<unix>:<body>'
+func extractSlack(headers map[string]string, body []byte) (*signedRequest, error) {
+	value, ok := strings.CutPrefix(runtime.HeaderValue(headers, "x-slack-signature"), "v0=")
+	if !ok {
+		return nil, errSignatureMalformed
+	}
+	signature, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, errSignatureMalformed
+	}
+
+	timestamp := runtime.HeaderValue(headers, "x-slack-request-timestamp")
+	request := &signedRequest{signatures: [][]byte{signature}}
+	if request.timestamp, err = parseUnixTimestamp(timestamp); err != nil {
+		return nil, err
+	}
+	request.payload = signedPayload("v0:"+timestamp+":", body)
+	return request, nil
+}
+
+// extract reads the signature and timestamp headers of the custom scheme,
+// building the signed payload from its template
+func (g *GenericConfig) extract(headers map[string]string, body []byte) (*signedRequest, error) {
+	value, ok := strings.CutPrefix(runtime.HeaderValue(headers, g.SignatureHeader), g.Prefix)
+	if !ok {
+		return nil, errSignatureMalformed
+	}
+	var signature []byte
+	var err error
+	if g.Encoding == "base64" {
+		signature, err = base64.StdEncoding.DecodeString(value)
+	} else {
+		signature, err = hex.DecodeString(value)
+	}
+	if err != nil {
+		return nil, errSignatureMalformed
+	}
+
+	request := &signedRequest{signatures: [][]byte{signature}}
+	prefix, suffix, _ := strings.Cut(g.Payload, "{body}")
+	if g.TimestampHeader != "" {
+		timestamp := runtime.HeaderValue(headers, g.TimestampHeader)
+		if request.timestamp, err = parseUnixTimestamp(timestamp); err != nil {
+			return nil, err
+		}
+		prefix = strings.ReplaceAll(prefix, "{timestamp}", timestamp)
+		suffix = strings.ReplaceAll(suffix, "{timestamp}", timestamp)
+	}
+	request.payload = append(signedPayload(prefix, body), suffix...)
+	return request, nil
+}
+
+// signedPayload returns prefix followed by body
+func signedPayload(prefix string, body []byte) []byte {
+	payload := make([]byte, 0, len(prefix)+len(body))
+	return append(append(payload, prefix...), body...)
+}
+
+// parseUnixTimestamp parses a timestamp in seconds since the epoch
+func parseUnixTimestamp(value string) (time.Time, error) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, errTimestampMalformed
+	}
+	return time.Unix(seconds, 0), nil
+}
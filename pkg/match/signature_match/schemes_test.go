@@ -0,0 +1,52 @@
+package signature_match
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSchemes_Extract(t *testing.T) {
+	generic := &GenericConfig{SignatureHeader: "X-Signature", Prefix: "sha256=", Algorithm: "sha256", Encoding: "hex", TimestampHeader: "X-Timestamp", Payload: "{timestamp}.{body}.{timestamp}"}
+
+	tests := []struct {
+		name       string
+		extract    func(map[string]string, []byte) (*signedRequest, error)
+		headers    map[string]string
+		payload    string
+		signatures int
+		timestamp  int64
+		wantErr    error
+	}{
+		{name: "github", extract: extractGitHub, headers: map[string]string{"X-Hub-Signature-256": "sha256=abcd"}, payload: "body", signatures: 1},
+		{name: "github sha1 header", extract: extractGitHub, headers: map[string]string{"X-Hub-Signature": "sha1=abcd"}, wantErr: errSignatureMalformed},
+		{name: "github not hex", extract: extractGitHub, headers: map[string]string{"x-hub-signature-256": "sha256=xyz"}, wantErr: errSignatureMalformed},
+		{name: "stripe", extract: extractStripe, headers: map[string]string{"stripe-signature": "t=1700000000, v1=abcd, v1=ef01, v0=ffff"}, payload: "1700000000.body", signatures: 2, timestamp: 1700000000},
+		{name: "stripe without v1", extract: extractStripe, headers: map[string]string{"stripe-signature": "t=1700000000,v0=abcd"}, wantErr: errSignatureMalformed},
+		{name: "stripe timestamp", extract: extractStripe, headers: map[string]string{"stripe-signature": "t=yesterday,v1=abcd"}, wantErr: errTimestampMalformed},
+		{name: "slack", extract: extractSlack, headers: map[string]string{"x-slack-signature": "v0=abcd", "x-slack-request-timestamp": "1700000000"}, payload: "v0:1700000000:body", signatures: 1, timestamp: 1700000000},
+		{name: "slack without timestamp", extract: extractSlack, headers: map[string]string{"x-slack-signature": "v0=abcd"}, wantErr: errTimestampMalformed},
+		{name: "generic", extract: generic.extract, headers: map[string]string{"x-signature": "sha256=abcd", "x-timestamp": "1700000000"}, payload: "1700000000.body.1700000000", signatures: 1, timestamp: 1700000000},
+		{name: "generic without prefix", extract: generic.extract, headers: map[string]string{"x-signature": "abcd", "x-timestamp": "1700000000"}, wantErr: errSignatureMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed, err := tt.extract(tt.headers, []byte("body"))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(signed.payload) != tt.payload || len(signed.signatures) != tt.signatures {
+				t.Fatalf("expected payload %q with %d signatures, got %q with %d", tt.payload, tt.signatures, signed.payload, len(signed.signatures))
+			}
+			if tt.timestamp != 0 && !signed.timestamp.Equal(time.Unix(tt.timestamp, 0)) {
+				t.Fatalf("expected timestamp %d, got %v", tt.timestamp, signed.timestamp)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package signature_match
+
+import (
+	"context"
+	"crypto/hmac"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/secrets"
+)
+
+const (
+	ControllerKind = "signature-match"
+)
+
+// partialBodyHeader is set by Envoy when the body forwarded to ext_authz was
+// truncated to max_request_bytes
+const partialBodyHeader = "x-envoy-auth-partial-body"
+
+// init registers the signature-match match controller so it can be
+// constructed from configuration at runtime.
+func init() {
+	controller.RegisterMatchControllerFactory(ControllerKind, newSignatureMatchController)
+}
+
+type signatureMatchController struct {
+	name      string
+	scheme    scheme
+	schemeKey string
+	secrets   [][]byte
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+// Match implements controller.MatchController. The request matches when its
+// body carries a signature of any secret, with a timestamp within tolerance
+// for the schemes signing one.
+func (c *signatureMatchController) Match(ctx context.Context, req *runtime.RequestContext, reports controller.AnalysisReports) (*controller.MatchVerdict, error) {
+	http := req.Request.GetAttributes().GetRequest().GetHttp()
+	headers := http.GetHeaders()
+
+	if req.Header(c.scheme.signatureHeader) == "" {
+		return c.createVerdict(false, fmt.Sprintf("signature header '%s' missing", c.scheme.signatureHeader), -1), nil
+	}
+	if req.Header(partialBodyHeader) == "true" {
+		return c.createVerdict(false, "request body truncated by Envoy, signature not verifiable", -1), nil
+	}
+
+	body := http.GetRawBody()
+	if len(body) == 0 {
+		body = []byte(http.GetBody())
+	}
+
+	signed, err := c.scheme.extract(headers, body)
+	if err != nil {
+		return c.createVerdict(false, fmt.Sprintf("%s in '%s'", err, c.scheme.signatureHeader), -1), nil
+	}
+
+	if !signed.timestamp.IsZero() {
+		age := c.now().Sub(signed.timestamp)
+		if age > c.tolerance || age < -c.tolerance {
+			return c.createVerdict(false, fmt.Sprintf("signature timestamp %s outside tolerance of %s", signed.timestamp.UTC().Format(time.RFC3339), c.tolerance), -1), nil
+		}
+	}
+
+	for i, secret := range c.secrets {
+		mac := hmac.New(c.scheme.hash, secret)
+		mac.Write(signed.payload)
+		expected := mac.Sum(nil)
+		for _, signature := range signed.signatures {
+			if hmac.Equal(expected, signature) {
+				return c.createVerdict(true, fmt.Sprintf("%s signature verified with secrets[%d]", c.schemeKey, i), i), nil
+			}
+		}
+	}
+
+	return c.createVerdict(false, fmt.Sprintf("%s signature does not match any secret", c.schemeKey), -1), nil
+}
+
+// Name implements controller.MatchController.
+func (c *signatureMatchController) Name() string {
+	return c.name
+}
+
+// Kind implements controller.MatchController.
+func (c *signatureMatchController) Kind() string {
+	return ControllerKind
+}
+
+// HealthCheck implements controller.MatchController.
+func (c *signatureMatchController) HealthCheck(ctx context.Context) error {
+	// No external dependencies to check
+	return nil
+}
+
+// createVerdict builds the verdict of a request, reporting the index of the
+// secret which verified the signature, or -1
+func (c *signatureMatchController) createVerdict(isMatch bool, description string, secret int) *controller.MatchVerdict {
+	verdict := &controller.MatchVerdict{
+		Controller:     c.name,
+		ControllerType: ControllerKind,
+		DenyCode:       codes.Unauthenticated,
+		DenyMessage:    "unauthenticated",
+		Description:    description,
+		IsMatch:        isMatch,
+		LogFields: []zap.Field{
+			zap.String("signature.scheme", c.schemeKey),
+		},
+	}
+	if secret >= 0 {
+		verdict.LogFields = append(verdict.LogFields, zap.Int("signature.secret", secret))
+	}
+	return verdict
+}
+
+// newSignatureMatchController validates the settings and reads the secrets of
+// a controller verifying webhook signatures
+func newSignatureMatchController(ctx context.Context, logger *zap.Logger, cfg config.ControllerConfig) (controller.MatchController, error) {
+	var signatureMatchConfig SignatureMatchConfig
+	if err := controller.DecodeControllerSettings(cfg.Settings, &signatureMatchConfig); err != nil {
+		return nil, err
+	}
+	signatureMatchConfig.ApplyDefaults()
+	if err := signatureMatchConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	signingSecrets, err := secrets.Load(signatureMatchConfig.Secrets, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	ctrl := &signatureMatchController{
+		name:      cfg.Name,
+		scheme:    newScheme(signatureMatchConfig),
+		schemeKey: signatureMatchConfig.Scheme,
+		secrets:   signingSecrets,
+		tolerance: signatureMatchConfig.GetTolerance(),
+		now:       time.Now,
+	}
+
+	logger.Info("controller initialized",
+		zap.String("scheme", ctrl.schemeKey),
+		zap.String("signature_header", ctrl.scheme.signatureHeader),
+		zap.Int("secrets", len(ctrl.secrets)),
+	)
+
+	return ctrl, nil
+}
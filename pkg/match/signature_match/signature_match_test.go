@@ -0,0 +1,259 @@
+package signature_match
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+const body = `{"action":"opened","number":42}`
+
+func TestMatch_Schemes(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "current-secret")
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	staleTimestamp := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name        string
+		settings    map[string]any
+		headers     map[string]string
+		match       bool
+		description string
+	}{
+		{
+			name:        "github",
+			settings:    map[string]any{"scheme": "github"},
+			headers:     map[string]string{"X-Hub-Signature-256": "sha256=" + sign(sha256.New, "current-secret", body)},
+			match:       true,
+			description: "github signature verified with secrets[0]",
+		},
+		{
+			name:        "github with wrong secret",
+			settings:    map[string]any{"scheme": "github"},
+			headers:     map[string]string{"x-hub-signature-256": "sha256=" + sign(sha256.New, "other-secret", body)},
+			description: "github signature does not match any secret",
+		},
+		{
+			name:        "github without prefix",
+			settings:    map[string]any{"scheme": "github"},
+			headers:     map[string]string{"x-hub-signature-256": sign(sha256.New, "current-secret", body)},
+			description: "signature is malformed in 'x-hub-signature-256'",
+		},
+		{
+			name:        "stripe",
+			settings:    map[string]any{"scheme": "stripe"},
+			headers:     map[string]string{"stripe-signature": "t=" + timestamp + ",v1=" + sign(sha256.New, "other-secret", timestamp+"."+body) + ",v1=" + sign(sha256.New, "current-secret", timestamp+"."+body) + ",v0=abc"},
+			match:       true,
+			description: "stripe signature verified with secrets[0]",
+		},
+		{
+			name:        "stripe outside tolerance",
+			settings:    map[string]any{"scheme": "stripe"},
+			headers:     map[string]string{"stripe-signature": "t=" + staleTimestamp + ",v1=" + sign(sha256.New, "current-secret", staleTimestamp+"."+body)},
+			description: "signature timestamp " + time.Unix(now.Add(-10*time.Minute).Unix(), 0).UTC().Format(time.RFC3339) + " outside tolerance of 5m0s",
+		},
+		{
+			name:        "stripe with larger tolerance",
+			settings:    map[string]any{"scheme": "stripe", "tolerance": "15m"},
+			headers:     map[string]string{"stripe-signature": "t=" + staleTimestamp + ",v1=" + sign(sha256.New, "current-secret", staleTimestamp+"."+body)},
+			match:       true,
+			description: "stripe signature verified with secrets[0]",
+		},
+		{
+			name:        "stripe without timestamp",
+			settings:    map[string]any{"scheme": "stripe"},
+			headers:     map[string]string{"stripe-signature": "v1=" + sign(sha256.New, "current-secret", "."+body)},
+			description: "timestamp is malformed in 'stripe-signature'",
+		},
+		{
+			name:     "slack",
+			settings: map[string]any{"scheme": "slack"},
+			headers: map[string]string{
+				"x-slack-signature":         "v0=" + sign(sha256.New, "current-secret", "v0:"+timestamp+":"+body),
+				"x-slack-request-timestamp": timestamp,
+			},
+			match:       true,
+			description: "slack signature verified with secrets[0]",
+		},
+		{
+			name:     "slack with replayed timestamp",
+			settings: map[string]any{"scheme": "slack"},
+			headers: map[string]string{
+				"x-slack-signature":         "v0=" + sign(sha256.New, "current-secret", "v0:"+timestamp+":"+body),
+				"x-slack-request-timestamp": staleTimestamp,
+			},
+			description: "signature timestamp",
+		},
+		{
+			name: "generic",
+			settings: map[string]any{"scheme": "generic", "generic": map[string]any{
+				"signatureHeader": "X-Signature",
+				"prefix":          "hmac ",
+				"algorithm":       "sha512",
+				"encoding":        "base64",
+				"timestampHeader": "X-Timestamp",
+				"payload":         "{timestamp}\n{body}",
+			}},
+			headers: map[string]string{
+				"x-signature": "hmac " + signBase64(sha512.New, "current-secret", timestamp+"\n"+body),
+				"x-timestamp": timestamp,
+			},
+			match:       true,
+			description: "generic signature verified with secrets[0]",
+		},
+		{
+			name:        "missing signature",
+			settings:    map[string]any{"scheme": "github"},
+			headers:     map[string]string{"content-type": "application/json"},
+			description: "signature header 'x-hub-signature-256' missing",
+		},
+		{
+			name:     "truncated body",
+			settings: map[string]any{"scheme": "github"},
+			headers: map[string]string{
+				"x-hub-signature-256":       "sha256=" + sign(sha256.New, "current-secret", body),
+				"x-envoy-auth-partial-body": "true",
+			},
+			description: "request body truncated by Envoy, signature not verifiable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.settings["secrets"] = []map[string]any{{"env": "WEBHOOK_SECRET"}}
+			verdict := match(t, buildController(t, tt.settings), tt.headers, body)
+			if verdict.IsMatch != tt.match || !strings.HasPrefix(verdict.Description, tt.description) {
+				t.Fatalf("expected IsMatch=%v %q, got %v %q", tt.match, tt.description, verdict.IsMatch, verdict.Description)
+			}
+			if verdict.DenyCode != codes.Unauthenticated {
+				t.Fatalf("expected Unauthenticated, got %v", verdict.DenyCode)
+			}
+		})
+	}
+}
+
+func TestMatch_SecretRotation(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "next-secret")
+	previousSecret := filepath.Join(t.TempDir(), "previous")
+	if err := os.WriteFile(previousSecret, []byte("previous-secret\n"), 0o600); err != nil {
+		t.Fatalf("failed to write secret: %v", err)
+	}
+
+	ctrl := buildController(t, map[string]any{
+		"scheme":  "github",
+		"secrets": []map[string]any{{"env": "WEBHOOK_SECRET"}, {"file": previousSecret}},
+	})
+
+	for i, secret := range []string{"next-secret", "previous-secret"} {
+		verdict := match(t, ctrl, map[string]string{"x-hub-signature-256": "sha256=" + sign(sha256.New, secret, body)}, body)
+		if !verdict.IsMatch {
+			t.Fatalf("expected %s to be accepted, got %q", secret, verdict.Description)
+		}
+		if field := verdict.LogFields[len(verdict.LogFields)-1]; field.Key != "signature.secret" || field.Integer != int64(i) {
+			t.Fatalf("expected secrets[%d] to be logged, got %v", i, verdict.LogFields)
+		}
+	}
+}
+
+func TestMatch_RawBody(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "current-secret")
+	ctrl := buildController(t, map[string]any{"scheme": "github", "secrets": []map[string]any{{"env": "WEBHOOK_SECRET"}}})
+
+	rawBody := []byte{0x00, 0xff, 0x10}
+	req := &authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{
+			Request: &authv3.AttributeContext_Request{
+				Http: &authv3.AttributeContext_HttpRequest{
+					Headers: map[string]string{"x-hub-signature-256": "sha256=" + sign(sha256.New, "current-secret", string(rawBody))},
+					RawBody: rawBody,
+				},
+			},
+		},
+	}
+	verdict, err := ctrl.Match(context.Background(), runtime.NewRequestContext(req), nil)
+	if err != nil || !verdict.IsMatch {
+		t.Fatalf("expected raw body signature to match, got %v %v", verdict, err)
+	}
+}
+
+func TestNewSignatureMatchController_Errors(t *testing.T) {
+	t.Setenv("EMPTY_WEBHOOK_SECRET", "")
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		settings map[string]any
+		wantErr  string
+	}{
+		{name: "invalid settings", settings: map[string]any{"scheme": "github"}, wantErr: "configuration validation failed: at least one secret is required"},
+		{name: "empty secret", settings: map[string]any{"scheme": "github", "secrets": []map[string]any{{"env": "EMPTY_WEBHOOK_SECRET"}}}, wantErr: "secrets[0] is empty"},
+		{name: "missing secret file", settings: map[string]any{"scheme": "github", "secrets": []map[string]any{{"file": filepath.Join(dir, "missing")}}}, wantErr: "secrets[0]: could not read file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newSignatureMatchController(context.Background(), zap.NewNop(), config.ControllerConfig{Name: "partner-signature", Type: ControllerKind, Settings: tt.settings})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func sign(newHash func() hash.Hash, secret, payload string) string {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func signBase64(newHash func() hash.Hash, secret, payload string) string {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write([]byte(payload))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func buildController(t *testing.T, settings map[string]any) *signatureMatchController {
+	t.Helper()
+	ctrl, err := newSignatureMatchController(context.Background(), zap.NewNop(), config.ControllerConfig{
+		Name:     "partner-signature",
+		Type:     ControllerKind,
+		Settings: settings,
+	})
+	if err != nil {
+		t.Fatalf("failed to build controller: %v", err)
+	}
+	return ctrl.(*signatureMatchController)
+}
+
+func match(t *testing.T, ctrl controller.MatchController, headers map[string]string, body string) *controller.MatchVerdict {
+	t.Helper()
+	req := &authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{
+			Request: &authv3.AttributeContext_Request{
+				Http: &authv3.AttributeContext_HttpRequest{Headers: headers, Body: body},
+			},
+		},
+	}
+	verdict, err := ctrl.Match(context.Background(), runtime.NewRequestContext(req), nil)
+	if err != nil {
+		t.Fatalf("match returned error: %v", err)
+	}
+	return verdict
+}
@@ -0,0 +1,279 @@
+// Package maxmind implements an updater that keeps MaxMind databases fresh by
+// speaking the MaxMind update HTTP protocol, so deployments do not need a sidecar
+// or cron job running scripts/fetch-maxmind.sh.
+package maxmind
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oschwald/geoip2-golang/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultEndpoint       = "https://updates.maxmind.com"
+	DefaultUpdateInterval = 24 * time.Hour
+	minimumUpdateInterval = time.Minute
+	defaultRequestTimeout = 5 * time.Minute
+	// zeroMD5 is sent when no local database exists yet, forcing a full download.
+	zeroMD5 = "00000000000000000000000000000000"
+)
+
+// UpdateConfig configures periodic downloads of a MaxMind database edition.
+type UpdateConfig struct {
+	AccountID     string `yaml:"accountId"`
+	LicenseKeyEnv string `yaml:"licenseKeyEnv"`
+	EditionID     string `yaml:"editionId"`
+	Interval      string `yaml:"interval"`
+	Endpoint      string `yaml:"endpoint"`
+}
+
+// Validate checks the update configuration for completeness and correctness.
+func (c *UpdateConfig) Validate() error {
+	if c.AccountID == "" {
+		return fmt.Errorf("autoUpdate.accountId is required")
+	}
+
+	if c.LicenseKeyEnv == "" {
+		return fmt.Errorf("autoUpdate.licenseKeyEnv is required")
+	}
+
+	if value, exists := os.LookupEnv(c.LicenseKeyEnv); !exists || value == "" {
+		return fmt.Errorf("environment variable '%s' not found or empty", c.LicenseKeyEnv)
+	}
+
+	if c.EditionID == "" {
+		return fmt.Errorf("autoUpdate.editionId is required")
+	}
+
+	if c.Interval != "" {
+		interval, err := time.ParseDuration(c.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid autoUpdate.interval: %w", err)
+		}
+		if interval < minimumUpdateInterval {
+			return fmt.Errorf("autoUpdate.interval must be at least %s", minimumUpdateInterval)
+		}
+	}
+
+	if c.Endpoint != "" {
+		endpoint, err := url.Parse(c.Endpoint)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return fmt.Errorf("autoUpdate.endpoint must be an absolute http(s) URL")
+		}
+	}
+
+	return nil
+}
+
+// GetInterval returns the parsed update interval, or the default if not specified.
+func (c *UpdateConfig) GetInterval() time.Duration {
+	if c.Interval == "" {
+		return DefaultUpdateInterval
+	}
+	interval, err := time.ParseDuration(c.Interval)
+	if err != nil || interval < minimumUpdateInterval {
+		return DefaultUpdateInterval
+	}
+	return interval
+}
+
+// GetEndpoint returns the update server base URL, or the MaxMind default if not specified.
+func (c *UpdateConfig) GetEndpoint() string {
+	if c.Endpoint == "" {
+		return DefaultEndpoint
+	}
+	return strings.TrimRight(c.Endpoint, "/")
+}
+
+// Updater downloads a MaxMind database edition to a local path whenever the
+// server reports a version different from the one on disk.
+type Updater struct {
+	databasePath string
+	accountID    string
+	licenseKey   string
+	editionID    string
+	endpoint     string
+	interval     time.Duration
+	client       *http.Client
+	logger       *zap.Logger
+}
+
+// NewUpdater creates an updater for the database stored at databasePath.
+func NewUpdater(config *UpdateConfig, databasePath string, logger *zap.Logger) (*Updater, error) {
+	if config == nil {
+		return nil, fmt.Errorf("autoUpdate configuration is required")
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Updater{
+		databasePath: databasePath,
+		accountID:    config.AccountID,
+		licenseKey:   os.Getenv(config.LicenseKeyEnv),
+		editionID:    config.EditionID,
+		endpoint:     config.GetEndpoint(),
+		interval:     config.GetInterval(),
+		client:       &http.Client{Timeout: defaultRequestTimeout},
+		logger:       logger,
+	}, nil
+}
+
+// Interval returns the delay between two update checks.
+func (u *Updater) Interval() time.Duration {
+	return u.interval
+}
+
+// Update checks the update server and replaces the local database when a newer
+// version is available. It reports whether the database on disk was replaced.
+func (u *Updater) Update(ctx context.Context) (bool, error) {
+	currentMD5, err := fileMD5(u.databasePath)
+	if err != nil {
+		return false, err
+	}
+
+	updateURL := fmt.Sprintf("%s/geoip/databases/%s/update?db_md5=%s", u.endpoint, url.PathEscape(u.editionID), currentMD5)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, updateURL, nil)
+	if err != nil {
+		return false, fmt.Errorf("could not build update request: %w", err)
+	}
+	req.SetBasicAuth(u.accountID, u.licenseKey)
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("update request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		u.logger.Debug("MaxMind database is up to date", zap.String("edition", u.editionID), zap.String("md5", currentMD5))
+		return false, nil
+	case http.StatusOK:
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return false, fmt.Errorf("update server responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	expectedMD5 := strings.ToLower(strings.TrimSpace(resp.Header.Get("X-Database-MD5")))
+	if expectedMD5 == "" {
+		return false, fmt.Errorf("update server response is missing the X-Database-MD5 header")
+	}
+
+	if err := u.install(resp.Body, expectedMD5); err != nil {
+		return false, err
+	}
+
+	u.logger.Info("MaxMind database updated", zap.String("edition", u.editionID), zap.String("md5", expectedMD5), zap.String("path", u.databasePath))
+	return true, nil
+}
+
+// Run periodically calls Update until the context is canceled, invoking
+// onUpdate every time a new database has been written to disk.
+func (u *Updater) Run(ctx context.Context, onUpdate func()) {
+	ticker := time.NewTicker(u.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			updated, err := u.Update(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				u.logger.Error("could not update MaxMind database", zap.String("edition", u.editionID), zap.Error(err))
+				continue
+			}
+			if updated && onUpdate != nil {
+				onUpdate()
+			}
+		}
+	}
+}
+
+// install decompresses the gzip payload to a temporary file next to the target,
+// verifies its checksum and format, and atomically moves it into place.
+func (u *Updater) install(body io.Reader, expectedMD5 string) error {
+	gzipReader, err := gzip.NewReader(body)
+	if err != nil {
+		return fmt.Errorf("could not decompress database archive: %w", err)
+	}
+	defer gzipReader.Close()
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(u.databasePath), "."+filepath.Base(u.databasePath)+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("could not create temporary database file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	hash := md5.New()
+	if _, err := io.Copy(io.MultiWriter(tmpFile, hash), gzipReader); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("could not write database archive: %w", err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("could not flush temporary database file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("could not close temporary database file: %w", err)
+	}
+
+	if actualMD5 := hex.EncodeToString(hash.Sum(nil)); actualMD5 != expectedMD5 {
+		return fmt.Errorf("database checksum mismatch: expected %s, got %s", expectedMD5, actualMD5)
+	}
+
+	reader, err := geoip2.Open(tmpPath)
+	if err != nil {
+		return fmt.Errorf("downloaded database is not valid: %w", err)
+	}
+	if err := reader.Close(); err != nil {
+		return fmt.Errorf("could not close downloaded database: %w", err)
+	}
+
+	if err := os.Chmod(tmpPath, 0o644); err != nil {
+		return fmt.Errorf("could not set database file permissions: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, u.databasePath); err != nil {
+		return fmt.Errorf("could not replace database file: %w", err)
+	}
+
+	return nil
+}
+
+// fileMD5 returns the hex MD5 digest of a file, or the all-zero digest when the file does not exist.
+func fileMD5(path string) (string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return zeroMD5, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not open database file: %w", err)
+	}
+	defer file.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("could not read database file: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,317 @@
+package maxmind
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// buildTestDatabase encodes an empty but structurally valid MaxMind DB file
+// whose metadata carries the provided database type and build epoch.
+func buildTestDatabase(t *testing.T, databaseType string, buildEpoch uint32) []byte {
+	t.Helper()
+
+	encodeString := func(value string) []byte {
+		if len(value) >= 29 {
+			t.Fatalf("test string %q is too long", value)
+		}
+		return append([]byte{0x40 | byte(len(value))}, value...)
+	}
+	encodeUint16 := func(value uint16) []byte {
+		return []byte{0xA2, byte(value >> 8), byte(value)}
+	}
+	encodeUint32 := func(value uint32) []byte {
+		buf := make([]byte, 4)
+		binary.BigEndian.PutUint32(buf, value)
+		return append([]byte{0xC4}, buf...)
+	}
+	encodeUint64 := func(value uint32) []byte {
+		buf := make([]byte, 4)
+		binary.BigEndian.PutUint32(buf, value)
+		return append([]byte{0x04, 0x02}, buf...)
+	}
+
+	var metadata bytes.Buffer
+	metadata.WriteByte(0xE0 | 6) // map with 6 entries
+	metadata.Write(encodeString("node_count"))
+	metadata.Write(encodeUint32(0))
+	metadata.Write(encodeString("record_size"))
+	metadata.Write(encodeUint16(24))
+	metadata.Write(encodeString("ip_version"))
+	metadata.Write(encodeUint16(4))
+	metadata.Write(encodeString("database_type"))
+	metadata.Write(encodeString(databaseType))
+	metadata.Write(encodeString("binary_format_major_version"))
+	metadata.Write(encodeUint16(2))
+	metadata.Write(encodeString("build_epoch"))
+	metadata.Write(encodeUint64(buildEpoch))
+
+	var database bytes.Buffer
+	database.Write(make([]byte, 16)) // data section separator
+	database.WriteString("\xab\xcd\xefMaxMind.com")
+	database.Write(metadata.Bytes())
+	return database.Bytes()
+}
+
+type testUpdateServer struct {
+	*httptest.Server
+	database atomic.Pointer[[]byte]
+	requests atomic.Int32
+	badMD5   atomic.Bool
+}
+
+// newTestUpdateServer starts a stand-in for the MaxMind update endpoint serving the given database.
+func newTestUpdateServer(t *testing.T, database []byte) *testUpdateServer {
+	t.Helper()
+
+	server := &testUpdateServer{}
+	server.database.Store(&database)
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.requests.Add(1)
+
+		accountID, licenseKey, ok := r.BasicAuth()
+		if !ok || accountID != "42" || licenseKey != "secret" {
+			http.Error(w, "invalid credentials", http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Path != "/geoip/databases/GeoLite2-ASN/update" {
+			http.Error(w, "unknown edition", http.StatusNotFound)
+			return
+		}
+
+		current := *server.database.Load()
+		sum := md5.Sum(current)
+		currentMD5 := hex.EncodeToString(sum[:])
+		if r.URL.Query().Get("db_md5") == currentMD5 {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		if server.badMD5.Load() {
+			currentMD5 = strings.Repeat("f", 32)
+		}
+		w.Header().Set("X-Database-MD5", currentMD5)
+		w.WriteHeader(http.StatusOK)
+		gzipWriter := gzip.NewWriter(w)
+		_, _ = gzipWriter.Write(current)
+		_ = gzipWriter.Close()
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// newTestUpdater builds an updater pointing at the stand-in server.
+func newTestUpdater(t *testing.T, endpoint string, databasePath string) *Updater {
+	t.Helper()
+	t.Setenv("TEST_MAXMIND_LICENSE_KEY", "secret")
+
+	updater, err := NewUpdater(&UpdateConfig{
+		AccountID:     "42",
+		LicenseKeyEnv: "TEST_MAXMIND_LICENSE_KEY",
+		EditionID:     "GeoLite2-ASN",
+		Endpoint:      endpoint,
+	}, databasePath, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create updater: %v", err)
+	}
+	return updater
+}
+
+func TestUpdater_DownloadsMissingDatabase(t *testing.T) {
+	database := buildTestDatabase(t, "GeoLite2-ASN", 1)
+	server := newTestUpdateServer(t, database)
+	databasePath := filepath.Join(t.TempDir(), "GeoLite2-ASN.mmdb")
+
+	updated, err := newTestUpdater(t, server.URL, databasePath).Update(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !updated {
+		t.Fatal("expected database to be downloaded")
+	}
+
+	written, err := os.ReadFile(databasePath)
+	if err != nil {
+		t.Fatalf("failed to read written database: %v", err)
+	}
+	if !bytes.Equal(written, database) {
+		t.Fatal("written database does not match served database")
+	}
+}
+
+func TestUpdater_SkipsUnchangedDatabase(t *testing.T) {
+	database := buildTestDatabase(t, "GeoLite2-ASN", 1)
+	server := newTestUpdateServer(t, database)
+	databasePath := filepath.Join(t.TempDir(), "GeoLite2-ASN.mmdb")
+	if err := os.WriteFile(databasePath, database, 0o644); err != nil {
+		t.Fatalf("failed to write database: %v", err)
+	}
+
+	updated, err := newTestUpdater(t, server.URL, databasePath).Update(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated {
+		t.Fatal("expected unchanged database not to be downloaded")
+	}
+}
+
+func TestUpdater_ReplacesChangedDatabase(t *testing.T) {
+	server := newTestUpdateServer(t, buildTestDatabase(t, "GeoLite2-ASN", 1))
+	databasePath := filepath.Join(t.TempDir(), "GeoLite2-ASN.mmdb")
+	updater := newTestUpdater(t, server.URL, databasePath)
+
+	if _, err := updater.Update(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	newer := buildTestDatabase(t, "GeoLite2-ASN", 2)
+	server.database.Store(&newer)
+
+	updated, err := updater.Update(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !updated {
+		t.Fatal("expected changed database to be downloaded")
+	}
+
+	written, _ := os.ReadFile(databasePath)
+	if !bytes.Equal(written, newer) {
+		t.Fatal("written database does not match the newer served database")
+	}
+}
+
+func TestUpdater_RejectsChecksumMismatch(t *testing.T) {
+	original := buildTestDatabase(t, "GeoLite2-ASN", 1)
+	server := newTestUpdateServer(t, buildTestDatabase(t, "GeoLite2-ASN", 2))
+	server.badMD5.Store(true)
+
+	dir := t.TempDir()
+	databasePath := filepath.Join(dir, "GeoLite2-ASN.mmdb")
+	if err := os.WriteFile(databasePath, original, 0o644); err != nil {
+		t.Fatalf("failed to write database: %v", err)
+	}
+
+	_, err := newTestUpdater(t, server.URL, databasePath).Update(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch error, got %v", err)
+	}
+
+	written, _ := os.ReadFile(databasePath)
+	if !bytes.Equal(written, original) {
+		t.Fatal("expected original database to be left untouched")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("expected temporary files to be removed, found %d entries", len(entries))
+	}
+}
+
+func TestUpdater_RejectsInvalidDatabase(t *testing.T) {
+	server := newTestUpdateServer(t, []byte("definitely not a MaxMind database"))
+	databasePath := filepath.Join(t.TempDir(), "GeoLite2-ASN.mmdb")
+
+	_, err := newTestUpdater(t, server.URL, databasePath).Update(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "not valid") {
+		t.Fatalf("expected invalid database error, got %v", err)
+	}
+	if _, statErr := os.Stat(databasePath); !os.IsNotExist(statErr) {
+		t.Fatal("expected no database file to be written")
+	}
+}
+
+func TestUpdater_ReportsServerErrors(t *testing.T) {
+	server := newTestUpdateServer(t, buildTestDatabase(t, "GeoLite2-ASN", 1))
+	databasePath := filepath.Join(t.TempDir(), "GeoLite2-ASN.mmdb")
+
+	t.Setenv("TEST_MAXMIND_LICENSE_KEY", "wrong")
+	updater, err := NewUpdater(&UpdateConfig{
+		AccountID:     "42",
+		LicenseKeyEnv: "TEST_MAXMIND_LICENSE_KEY",
+		EditionID:     "GeoLite2-ASN",
+		Endpoint:      server.URL,
+	}, databasePath, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create updater: %v", err)
+	}
+
+	_, err = updater.Update(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected 401 error, got %v", err)
+	}
+}
+
+func TestUpdateConfig_Validate(t *testing.T) {
+	t.Setenv("TEST_MAXMIND_LICENSE_KEY", "secret")
+
+	valid := func() *UpdateConfig {
+		return &UpdateConfig{
+			AccountID:     "42",
+			LicenseKeyEnv: "TEST_MAXMIND_LICENSE_KEY",
+			EditionID:     "GeoLite2-City",
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*UpdateConfig)
+		wantErr string
+	}{
+		{"valid", func(*UpdateConfig) {}, ""},
+		{"missing account", func(c *UpdateConfig) { c.AccountID = "" }, "accountId is required"},
+		{"missing license env", func(c *UpdateConfig) { c.LicenseKeyEnv = "" }, "licenseKeyEnv is required"},
+		{"unset license env", func(c *UpdateConfig) { c.LicenseKeyEnv = "TEST_MAXMIND_UNSET" }, "not found"},
+		{"missing edition", func(c *UpdateConfig) { c.EditionID = "" }, "editionId is required"},
+		{"invalid interval", func(c *UpdateConfig) { c.Interval = "soon" }, "invalid autoUpdate.interval"},
+		{"interval too short", func(c *UpdateConfig) { c.Interval = "10s" }, "at least"},
+		{"invalid endpoint", func(c *UpdateConfig) { c.Endpoint = "ftp://example.com" }, "endpoint"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.mutate(cfg)
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestUpdateConfig_Defaults(t *testing.T) {
+	cfg := &UpdateConfig{}
+	if cfg.GetInterval() != DefaultUpdateInterval {
+		t.Fatalf("expected default interval, got %s", cfg.GetInterval())
+	}
+	if cfg.GetEndpoint() != DefaultEndpoint {
+		t.Fatalf("expected default endpoint, got %s", cfg.GetEndpoint())
+	}
+
+	cfg.Endpoint = "http://localhost:8080/"
+	if cfg.GetEndpoint() != "http://localhost:8080" {
+		t.Fatalf("expected trailing slash to be trimmed, got %s", cfg.GetEndpoint())
+	}
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolStats is a snapshot of the connections of a shared data source pool.
+type PoolStats struct {
+	InUse int
+	Idle  int
+}
+
+// dataSourcePool identifies a pool registered with the collector
+type dataSourcePool struct {
+	name   string
+	dbType string
+}
+
+// dataSourcePoolCollector reads the connections of the registered pools at scrape time.
+type dataSourcePoolCollector struct {
+	mu          sync.Mutex
+	pools       map[dataSourcePool]func() PoolStats
+	connections *prometheus.Desc
+}
+
+// newDataSourcePoolCollector builds an empty pool collector.
+func newDataSourcePoolCollector() *dataSourcePoolCollector {
+	return &dataSourcePoolCollector{
+		pools: make(map[dataSourcePool]func() PoolStats),
+		connections: prometheus.NewDesc(
+			prometheus.BuildFQName("envoy_authz", "datasource", "connections"),
+			"Connections of each shared datasource pool by state (in_use, idle)",
+			[]string{"datasource", "db_type", "state"},
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *dataSourcePoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.connections
+}
+
+// Collect implements prometheus.Collector.
+func (c *dataSourcePoolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for pool, stats := range c.pools {
+		snapshot := stats()
+		ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(snapshot.InUse), pool.name, pool.dbType, "in_use")
+		ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(snapshot.Idle), pool.name, pool.dbType, "idle")
+	}
+}
+
+// RegisterDataSourcePool exports the connections of a shared data source pool,
+// read from stats at every scrape.
+func (i *Instrumentation) RegisterDataSourcePool(name, dbType string, stats func() PoolStats) {
+	if i == nil {
+		return
+	}
+	i.dataSourcePools.mu.Lock()
+	defer i.dataSourcePools.mu.Unlock()
+	i.dataSourcePools.pools[dataSourcePool{name: name, dbType: dbType}] = stats
+}
@@ -16,12 +16,19 @@ const (
 	NotAvailable     = "-"
 	POSTGRES         = "POSTGRES"
 	REDIS            = "REDIS"
+	HTTP             = "HTTP"
+	FILE             = "FILE"
 	FOUND            = "FOUND"
 	NOTFOUND         = "NOT_FOUND"
 	HIT              = "HIT"
 	MISS             = "MISS"
+	STALE            = "STALE"
+	STALE_IF_ERROR   = "STALE_IF_ERROR"
 	MATCH_VERDICT    = "MATCH"
 	NO_MATCH_VERDICT = "NO_MATCH"
+	UPDATED          = "UPDATED"
+	NOT_MODIFIED     = "NOT_MODIFIED"
+	OVER_LIMIT       = "OVER_LIMIT"
 )
 
 // Instrumentation publishes Prometheus metrics for the authorization flow.
@@ -37,8 +44,18 @@ type Instrumentation struct {
 	matchDbQueryDur     *prometheus.HistogramVec
 	matchDbCacheReq     *prometheus.CounterVec
 	matchDbCacheSize    *prometheus.GaugeVec
+	matchDbCoalesced    *prometheus.CounterVec
+	matchDbSyncs        *prometheus.CounterVec
+	matchDbSyncSuccess  *prometheus.GaugeVec
+	matchDbSnapshotSize *prometheus.GaugeVec
 	matchDbUnavailable  *prometheus.CounterVec
+	matchDbSourceUp     *prometheus.GaugeVec
 	geofenceMatchTotals *prometheus.CounterVec
+	listSourceRefreshes *prometheus.CounterVec
+	listSourceSuccess   *prometheus.GaugeVec
+	rateLimitDecisions  *prometheus.CounterVec
+	droppedDecisions    prometheus.Counter
+	dataSourcePools     *dataSourcePoolCollector
 
 	trackOptions TrackOptions
 }
@@ -96,14 +113,14 @@ func NewInstrumentation(reg prometheus.Registerer, opts TrackOptions) *Instrumen
 			Subsystem: "match_database",
 			Name:      "queries_total",
 			Help:      "Total database queries issued by match controllers",
-		}, []string{"authority", "controller_name", "controller_kind", "db_type", "verdict", "result"}),
+		}, []string{"authority", "controller_name", "controller_kind", "db_type", "datasource", "verdict", "result"}),
 		matchDbQueryDur: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 			Namespace: "envoy_authz",
 			Subsystem: "match_database",
 			Name:      "query_duration_seconds",
 			Help:      "Database query duration in seconds for match controllers",
 			Buckets:   []float64{.001, .002, .005, .01, .025, .05, .1, .25, .5, 1},
-		}, []string{"authority", "controller_name", "controller_kind", "db_type", "verdict", "result"}),
+		}, []string{"authority", "controller_name", "controller_kind", "db_type", "datasource", "verdict", "result"}),
 		matchDbCacheReq: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: "envoy_authz",
 			Subsystem: "match_database",
@@ -116,12 +133,66 @@ func NewInstrumentation(reg prometheus.Registerer, opts TrackOptions) *Instrumen
 			Name:      "cache_entries",
 			Help:      "Current cache entries for match controllers",
 		}, []string{"authority", "controller_name", "controller_kind", "db_type"}),
+		matchDbCoalesced: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "envoy_authz",
+			Subsystem: "match_database",
+			Name:      "coalesced_lookups_total",
+			Help:      "Lookups served by joining an identical in-flight database query",
+		}, []string{"authority", "controller_name", "controller_kind", "db_type"}),
+		matchDbSyncs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "envoy_authz",
+			Subsystem: "match_database",
+			Name:      "syncs_total",
+			Help:      "Full sync attempts of match controllers mirroring their database in memory",
+		}, []string{"controller_name", "controller_kind", "db_type", "result"}),
+		matchDbSyncSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "envoy_authz",
+			Subsystem: "match_database",
+			Name:      "last_sync_timestamp_seconds",
+			Help:      "Unix time of the last successful full sync",
+		}, []string{"controller_name", "controller_kind", "db_type"}),
+		matchDbSnapshotSize: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "envoy_authz",
+			Subsystem: "match_database",
+			Name:      "snapshot_entries",
+			Help:      "Entries in the in-memory snapshot of match controllers using full sync",
+		}, []string{"controller_name", "controller_kind", "db_type"}),
 		matchDbUnavailable: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: "envoy_authz",
 			Subsystem: "match_database",
 			Name:      "unavailable_total",
 			Help:      "Database unavailability events for match controllers",
 		}, []string{"authority", "controller_name", "controller_kind", "db_type"}),
+		matchDbSourceUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "envoy_authz",
+			Subsystem: "match_database",
+			Name:      "datasource_up",
+			Help:      "Outcome of the last health check of each match controller datasource (1 healthy, 0 unhealthy)",
+		}, []string{"controller_name", "controller_kind", "db_type", "datasource"}),
+		listSourceRefreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "envoy_authz",
+			Subsystem: "list_source",
+			Name:      "refreshes_total",
+			Help:      "Remote list refresh attempts by result",
+		}, []string{"controller_name", "controller_kind", "result"}),
+		listSourceSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "envoy_authz",
+			Subsystem: "list_source",
+			Name:      "last_success_timestamp_seconds",
+			Help:      "Unix time of the last successful remote list load",
+		}, []string{"controller_name", "controller_kind"}),
+		rateLimitDecisions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "envoy_authz",
+			Subsystem: "rate_limit",
+			Name:      "decisions_total",
+			Help:      "Descriptors counted by the rate limit service by limit and result",
+		}, []string{"domain", "limit", "result"}),
+		droppedDecisions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "envoy_authz",
+			Name:      "dropped_decisions_total",
+			Help:      "Policy decisions not notified to the observing controllers because their queue was full",
+		}),
+		dataSourcePools: newDataSourcePoolCollector(),
 	}
 
 	reg.MustRegister(
@@ -136,7 +207,17 @@ func NewInstrumentation(reg prometheus.Registerer, opts TrackOptions) *Instrumen
 		inst.matchDbQueryDur,
 		inst.matchDbCacheReq,
 		inst.matchDbCacheSize,
+		inst.matchDbCoalesced,
+		inst.matchDbSyncs,
+		inst.matchDbSyncSuccess,
+		inst.matchDbSnapshotSize,
 		inst.matchDbUnavailable,
+		inst.matchDbSourceUp,
+		inst.listSourceRefreshes,
+		inst.listSourceSuccess,
+		inst.rateLimitDecisions,
+		inst.droppedDecisions,
+		inst.dataSourcePools,
 	)
 
 	if opts.TrackGeofence {
@@ -263,8 +344,9 @@ func (i *Instrumentation) ObserveMatchDatabaseRequest(authority, controllerName,
 	i.matchDbRequests.WithLabelValues(authority, controllerName, controllerKind, dbType, verdict, result).Inc()
 }
 
-// ObserveMatchDatabaseQuery records database query outcome and duration.
-func (i *Instrumentation) ObserveMatchDatabaseQuery(authority, controllerName, controllerKind string, dbType string, matched bool, err error, duration time.Duration) {
+// ObserveMatchDatabaseQuery records the outcome and duration of a query to one
+// of the datasources of a controller.
+func (i *Instrumentation) ObserveMatchDatabaseQuery(authority, controllerName, controllerKind string, dbType, datasource string, matched bool, err error, duration time.Duration) {
 	if i == nil {
 		return
 	}
@@ -276,8 +358,8 @@ func (i *Instrumentation) ObserveMatchDatabaseQuery(authority, controllerName, c
 	if err != nil {
 		result = ERROR
 	}
-	i.matchDbQueries.WithLabelValues(authority, controllerName, controllerKind, dbType, verdict, result).Inc()
-	i.matchDbQueryDur.WithLabelValues(authority, controllerName, controllerKind, dbType, verdict, result).Observe(duration.Seconds())
+	i.matchDbQueries.WithLabelValues(authority, controllerName, controllerKind, dbType, datasource, verdict, result).Inc()
+	i.matchDbQueryDur.WithLabelValues(authority, controllerName, controllerKind, dbType, datasource, verdict, result).Observe(duration.Seconds())
 }
 
 // ObserveMatchDatabaseCacheHit records a cache lookup that returned an entry.
@@ -296,6 +378,19 @@ func (i *Instrumentation) ObserveMatchDatabaseCacheMiss(authority, controllerNam
 	i.matchDbCacheReq.WithLabelValues(authority, controllerName, controllerKind, dbType, MISS).Inc()
 }
 
+// ObserveMatchDatabaseCacheStale records an expired cache entry being served,
+// either while it is refreshed in the background or because the database failed.
+func (i *Instrumentation) ObserveMatchDatabaseCacheStale(authority, controllerName, controllerKind string, dbType string, onError bool) {
+	if i == nil {
+		return
+	}
+	result := STALE
+	if onError {
+		result = STALE_IF_ERROR
+	}
+	i.matchDbCacheReq.WithLabelValues(authority, controllerName, controllerKind, dbType, result).Inc()
+}
+
 // ObserveMatchDatabaseCacheSize sets the current cache size gauge.
 func (i *Instrumentation) ObserveMatchDatabaseCacheSize(authority, controllerName, controllerKind string, dbType string, size int) {
 	if i == nil {
@@ -304,6 +399,36 @@ func (i *Instrumentation) ObserveMatchDatabaseCacheSize(authority, controllerNam
 	i.matchDbCacheSize.WithLabelValues(authority, controllerName, controllerKind, dbType).Set(float64(size))
 }
 
+// ObserveMatchDatabaseCoalesced records a lookup that waited for an identical
+// in-flight database query instead of issuing its own.
+func (i *Instrumentation) ObserveMatchDatabaseCoalesced(authority, controllerName, controllerKind string, dbType string) {
+	if i == nil {
+		return
+	}
+	i.matchDbCoalesced.WithLabelValues(authority, controllerName, controllerKind, dbType).Inc()
+}
+
+// ObserveMatchDatabaseSync records the outcome of a full sync attempt.
+func (i *Instrumentation) ObserveMatchDatabaseSync(controllerName, controllerKind, dbType string, success bool) {
+	if i == nil {
+		return
+	}
+	result := OK
+	if !success {
+		result = ERROR
+	}
+	i.matchDbSyncs.WithLabelValues(controllerName, controllerKind, dbType, result).Inc()
+}
+
+// ObserveMatchDatabaseSnapshot records the size and load time of the in-memory snapshot.
+func (i *Instrumentation) ObserveMatchDatabaseSnapshot(controllerName, controllerKind, dbType string, entries int, loadedAt time.Time) {
+	if i == nil {
+		return
+	}
+	i.matchDbSyncSuccess.WithLabelValues(controllerName, controllerKind, dbType).Set(float64(loadedAt.Unix()))
+	i.matchDbSnapshotSize.WithLabelValues(controllerName, controllerKind, dbType).Set(float64(entries))
+}
+
 // ObserveMatchDatabaseUnavailable records database unavailability.
 func (i *Instrumentation) ObserveMatchDatabaseUnavailable(authority, controllerName, controllerKind string, dbType string) {
 	if i == nil {
@@ -312,6 +437,18 @@ func (i *Instrumentation) ObserveMatchDatabaseUnavailable(authority, controllerN
 	i.matchDbUnavailable.WithLabelValues(authority, controllerName, controllerKind, dbType).Inc()
 }
 
+// ObserveMatchDatabaseHealth records the outcome of a datasource health check.
+func (i *Instrumentation) ObserveMatchDatabaseHealth(controllerName, controllerKind, dbType, datasource string, healthy bool) {
+	if i == nil {
+		return
+	}
+	up := 0.0
+	if healthy {
+		up = 1
+	}
+	i.matchDbSourceUp.WithLabelValues(controllerName, controllerKind, dbType, datasource).Set(up)
+}
+
 // ObserveGeofenceMatch records a geofence feature match.
 func (i *Instrumentation) ObserveGeofenceMatch(authority, controllerName, feature string) {
 	if i == nil || i.geofenceMatchTotals == nil {
@@ -319,3 +456,42 @@ func (i *Instrumentation) ObserveGeofenceMatch(authority, controllerName, featur
 	}
 	i.geofenceMatchTotals.WithLabelValues(authority, controllerName, feature).Inc()
 }
+
+// ListSourceRefreshes exposes the listSourceRefreshes counter (primarily for testing).
+func (i *Instrumentation) ListSourceRefreshes() *prometheus.CounterVec {
+	return i.listSourceRefreshes
+}
+
+// ObserveListSourceRefresh records the outcome of a remote list refresh attempt.
+func (i *Instrumentation) ObserveListSourceRefresh(controllerName, controllerKind, result string) {
+	if i == nil {
+		return
+	}
+	i.listSourceRefreshes.WithLabelValues(controllerName, controllerKind, result).Inc()
+}
+
+// ObserveListSourceLastSuccess records when a remote list was last loaded successfully.
+func (i *Instrumentation) ObserveListSourceLastSuccess(controllerName, controllerKind string, at time.Time) {
+	if i == nil {
+		return
+	}
+	i.listSourceSuccess.WithLabelValues(controllerName, controllerKind).Set(float64(at.Unix()))
+}
+
+// ObserveRateLimitDecision records a descriptor counted against a limit of the
+// rate limit service, with result OK, OVER_LIMIT or ERROR.
+func (i *Instrumentation) ObserveRateLimitDecision(domain, limit, result string) {
+	if i == nil {
+		return
+	}
+	i.rateLimitDecisions.WithLabelValues(domain, limit, result).Inc()
+}
+
+// ObserveDroppedDecision records a policy decision dropped because the queue of
+// the decision observers was full.
+func (i *Instrumentation) ObserveDroppedDecision() {
+	if i == nil {
+		return
+	}
+	i.droppedDecisions.Inc()
+}
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -58,10 +59,15 @@ func TestObserveMatchDatabase(t *testing.T) {
 
 	inst.ObserveMatchDatabaseRequest("auth", "c1", "kind", POSTGRES, true, true)
 	inst.ObserveMatchDatabaseRequest("auth", "c1", "kind", POSTGRES, false, false)
-	inst.ObserveMatchDatabaseQuery("auth", "c1", "kind", POSTGRES, true, nil, 5*time.Millisecond)
+	inst.ObserveMatchDatabaseQuery("auth", "c1", "kind", POSTGRES, "primary", true, nil, 5*time.Millisecond)
+	inst.ObserveMatchDatabaseHealth("c1", "kind", POSTGRES, "primary", true)
+	inst.ObserveMatchDatabaseHealth("c1", "kind", REDIS, "replica", false)
 	inst.ObserveMatchDatabaseCacheHit("auth", "c1", "kind", POSTGRES)
 	inst.ObserveMatchDatabaseCacheMiss("auth", "c1", "kind", POSTGRES)
+	inst.ObserveMatchDatabaseCacheStale("auth", "c1", "kind", POSTGRES, false)
+	inst.ObserveMatchDatabaseCacheStale("auth", "c1", "kind", POSTGRES, true)
 	inst.ObserveMatchDatabaseCacheSize("auth", "c1", "kind", POSTGRES, 3)
+	inst.ObserveMatchDatabaseCoalesced("auth", "c1", "kind", POSTGRES)
 	inst.ObserveMatchDatabaseUnavailable("auth", "c1", "kind", POSTGRES)
 	inst.ObserveMatchVerdict("auth", "c1", "kind", true)
 	inst.ObserveMatchVerdict("auth", "c1", "kind", false)
@@ -72,15 +78,30 @@ func TestObserveMatchDatabase(t *testing.T) {
 	if v := testutil.ToFloat64(inst.matchDbRequests.WithLabelValues("auth", "c1", "kind", POSTGRES, NO_MATCH_VERDICT, ERROR)); v != 1 {
 		t.Fatalf("expected 1 error request, got %v", v)
 	}
-	if v := testutil.ToFloat64(inst.matchDbQueries.WithLabelValues("auth", "c1", "kind", POSTGRES, MATCH_VERDICT, OK)); v != 1 {
+	if v := testutil.ToFloat64(inst.matchDbQueries.WithLabelValues("auth", "c1", "kind", POSTGRES, "primary", MATCH_VERDICT, OK)); v != 1 {
 		t.Fatalf("expected 1 successful query, got %v", v)
 	}
+	if v := testutil.ToFloat64(inst.matchDbSourceUp.WithLabelValues("c1", "kind", POSTGRES, "primary")); v != 1 {
+		t.Fatalf("expected healthy datasource, got %v", v)
+	}
+	if v := testutil.ToFloat64(inst.matchDbSourceUp.WithLabelValues("c1", "kind", REDIS, "replica")); v != 0 {
+		t.Fatalf("expected unhealthy datasource, got %v", v)
+	}
 	if v := testutil.ToFloat64(inst.matchDbCacheReq.WithLabelValues("auth", "c1", "kind", POSTGRES, HIT)); v != 1 {
 		t.Fatalf("expected 1 cache hit, got %v", v)
 	}
 	if v := testutil.ToFloat64(inst.matchDbCacheReq.WithLabelValues("auth", "c1", "kind", POSTGRES, MISS)); v != 1 {
 		t.Fatalf("expected 1 cache miss, got %v", v)
 	}
+	if v := testutil.ToFloat64(inst.matchDbCacheReq.WithLabelValues("auth", "c1", "kind", POSTGRES, STALE)); v != 1 {
+		t.Fatalf("expected 1 stale cache serve, got %v", v)
+	}
+	if v := testutil.ToFloat64(inst.matchDbCacheReq.WithLabelValues("auth", "c1", "kind", POSTGRES, STALE_IF_ERROR)); v != 1 {
+		t.Fatalf("expected 1 stale-if-error cache serve, got %v", v)
+	}
+	if v := testutil.ToFloat64(inst.matchDbCoalesced.WithLabelValues("auth", "c1", "kind", POSTGRES)); v != 1 {
+		t.Fatalf("expected 1 coalesced lookup, got %v", v)
+	}
 	if v := testutil.ToFloat64(inst.matchDbUnavailable.WithLabelValues("auth", "c1", "kind", POSTGRES)); v != 1 {
 		t.Fatalf("expected 1 unavailable event, got %v", v)
 	}
@@ -91,3 +112,97 @@ func TestObserveMatchDatabase(t *testing.T) {
 		t.Fatalf("expected 1 no-match verdict, got %v", v)
 	}
 }
+
+func TestObserveMatchDatabaseSync(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	inst := NewInstrumentation(reg, TrackOptions{TrackCountry: false, TrackGeofence: true})
+
+	inst.ObserveMatchDatabaseSync("c1", "kind", REDIS, true)
+	inst.ObserveMatchDatabaseSync("c1", "kind", REDIS, false)
+	inst.ObserveMatchDatabaseSnapshot("c1", "kind", REDIS, 42, time.Unix(1700000000, 0))
+
+	if v := testutil.ToFloat64(inst.matchDbSyncs.WithLabelValues("c1", "kind", REDIS, OK)); v != 1 {
+		t.Fatalf("expected 1 successful sync, got %v", v)
+	}
+	if v := testutil.ToFloat64(inst.matchDbSyncs.WithLabelValues("c1", "kind", REDIS, ERROR)); v != 1 {
+		t.Fatalf("expected 1 failed sync, got %v", v)
+	}
+	if v := testutil.ToFloat64(inst.matchDbSyncSuccess.WithLabelValues("c1", "kind", REDIS)); v != 1700000000 {
+		t.Fatalf("expected last sync timestamp, got %v", v)
+	}
+	if v := testutil.ToFloat64(inst.matchDbSnapshotSize.WithLabelValues("c1", "kind", REDIS)); v != 42 {
+		t.Fatalf("expected 42 snapshot entries, got %v", v)
+	}
+
+	var nilInst *Instrumentation
+	nilInst.ObserveMatchDatabaseSync("c1", "kind", REDIS, true)
+	nilInst.ObserveMatchDatabaseSnapshot("c1", "kind", REDIS, 1, time.Now())
+}
+
+func TestObserveListSource(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	inst := NewInstrumentation(reg, TrackOptions{TrackCountry: false, TrackGeofence: true})
+
+	inst.ObserveListSourceRefresh("c1", "ip-match", UPDATED)
+	inst.ObserveListSourceRefresh("c1", "ip-match", ERROR)
+	inst.ObserveListSourceLastSuccess("c1", "ip-match", time.Unix(1700000000, 0))
+
+	if v := testutil.ToFloat64(inst.listSourceRefreshes.WithLabelValues("c1", "ip-match", UPDATED)); v != 1 {
+		t.Fatalf("expected 1 updated refresh, got %v", v)
+	}
+	if v := testutil.ToFloat64(inst.listSourceRefreshes.WithLabelValues("c1", "ip-match", ERROR)); v != 1 {
+		t.Fatalf("expected 1 failed refresh, got %v", v)
+	}
+	if v := testutil.ToFloat64(inst.listSourceSuccess.WithLabelValues("c1", "ip-match")); v != 1700000000 {
+		t.Fatalf("expected last success timestamp, got %v", v)
+	}
+
+	var nilInst *Instrumentation
+	nilInst.ObserveListSourceRefresh("c1", "ip-match", UPDATED)
+	nilInst.ObserveListSourceLastSuccess("c1", "ip-match", time.Now())
+}
+
+func TestObserveRateLimitDecision(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	inst := NewInstrumentation(reg, TrackOptions{})
+
+	inst.ObserveRateLimitDecision("edge", "per-ip", OK)
+	inst.ObserveRateLimitDecision("edge", "per-ip", OVER_LIMIT)
+	inst.ObserveRateLimitDecision("edge", "per-ip", OVER_LIMIT)
+
+	if v := testutil.ToFloat64(inst.rateLimitDecisions.WithLabelValues("edge", "per-ip", OVER_LIMIT)); v != 2 {
+		t.Fatalf("expected 2 over limit decisions, got %v", v)
+	}
+
+	var nilInst *Instrumentation
+	nilInst.ObserveRateLimitDecision("edge", "per-ip", OK)
+}
+
+func TestRegisterDataSourcePool(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	inst := NewInstrumentation(reg, TrackOptions{})
+
+	inUse := 3
+	inst.RegisterDataSourcePool("security-db", POSTGRES, func() PoolStats {
+		return PoolStats{InUse: inUse, Idle: 7}
+	})
+
+	expected := `
+# HELP envoy_authz_datasource_connections Connections of each shared datasource pool by state (in_use, idle)
+# TYPE envoy_authz_datasource_connections gauge
+envoy_authz_datasource_connections{datasource="security-db",db_type="POSTGRES",state="idle"} 7
+envoy_authz_datasource_connections{datasource="security-db",db_type="POSTGRES",state="in_use"} 3
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "envoy_authz_datasource_connections"); err != nil {
+		t.Fatalf("unexpected pool metrics: %v", err)
+	}
+
+	// Stats are read at scrape time
+	inUse = 5
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(strings.Replace(expected, "} 3", "} 5", 1)), "envoy_authz_datasource_connections"); err != nil {
+		t.Fatalf("unexpected pool metrics: %v", err)
+	}
+
+	var nilInst *Instrumentation
+	nilInst.RegisterDataSourcePool("security-db", POSTGRES, func() PoolStats { return PoolStats{} })
+}
@@ -47,9 +47,20 @@ func (p *Policy) Evaluate(values map[string]bool) (bool, string) {
 	return allowed, cause
 }
 
+// Blames reports whether Evaluate can report the controller as the culprit of
+// a false result. The right operand of '||' expressions is the only one
+// reported, so controllers on their left side are never blamed.
+func (p *Policy) Blames(name string) bool {
+	if p == nil || p.root == nil {
+		return false
+	}
+	return p.root.blames(name)
+}
+
 // node abstracts AST nodes so each implementation can perform evaluation independently.
 type node interface {
 	eval(values map[string]bool) (bool, string)
+	blames(name string) bool
 }
 
 // identifierNode represents a single controller name.
@@ -111,6 +122,30 @@ func (n *binaryNode) eval(values map[string]bool) (bool, string) {
 	}
 }
 
+// blames reports whether the node is the controller.
+func (n *identifierNode) blames(name string) bool {
+	return n.name == name
+}
+
+// blames reports whether the child can blame the controller, as eval
+// preserves its culprit.
+func (n *notNode) blames(name string) bool {
+	return n.child.blames(name)
+}
+
+// blames follows eval: '&&' reports the culprit of either operand, '||' the
+// culprit of its right operand only.
+func (n *binaryNode) blames(name string) bool {
+	switch n.op {
+	case "&&":
+		return n.left.blames(name) || n.right.blames(name)
+	case "||":
+		return n.right.blames(name)
+	default:
+		return false
+	}
+}
+
 // parser holds state for a single pass over the policy expression string.
 type parser struct {
 	input string
@@ -241,3 +241,35 @@ func TestPolicyShortCircuiting(t *testing.T) {
 		}
 	})
 }
+
+// TestPolicyBlames ensures Blames follows the culprits reported by Evaluate.
+func TestPolicyBlames(t *testing.T) {
+	tests := []struct {
+		expr   string
+		name   string
+		blames bool
+	}{
+		{expr: "challenge", name: "challenge", blames: true},
+		{expr: "!suspicious || challenge", name: "challenge", blames: true},
+		{expr: "challenge || !suspicious", name: "challenge", blames: false},
+		{expr: "a && (!suspicious || challenge)", name: "challenge", blames: true},
+		{expr: "(!suspicious || challenge) || a", name: "challenge", blames: false},
+		{expr: "challenge && a", name: "challenge", blames: true},
+		{expr: "!challenge", name: "challenge", blames: true},
+		{expr: "a && b", name: "challenge", blames: false},
+	}
+	for _, tt := range tests {
+		p, err := Parse(tt.expr, []string{"a", "b", "suspicious", "challenge"})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got := p.Blames(tt.name); got != tt.blames {
+			t.Errorf("%q: expected Blames(%q) = %v, got %v", tt.expr, tt.name, tt.blames, got)
+		}
+	}
+
+	var p *Policy
+	if p.Blames("challenge") {
+		t.Fatalf("expected a nil policy to blame no controller")
+	}
+}
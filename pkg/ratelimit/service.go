@@ -0,0 +1,336 @@
+// Package ratelimit implements the Envoy Rate Limit Service API. Descriptors
+// sent by Envoy's rate limit filter are counted against the limits configured
+// for their domain, optionally extended with entries derived by the analysis
+// controllers, such as the country of the client.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	ratelimitv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/analysis/maxmind_asn"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/analysis/maxmind_geoip"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/analysis/ua_detect"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/match/rate_limit"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/metrics"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+// Descriptor entries derived from the analysis reports
+const (
+	// EntryCountry is the ISO code of the client country, from maxmind-geoip
+	EntryCountry = "country"
+	// EntryASN is the client AS number, from maxmind-asn
+	EntryASN = "asn"
+	// EntryBot is "true" when the client User-Agent is a bot, from ua-detect
+	EntryBot = "bot"
+)
+
+// Analyzer runs the analysis controllers on a request.
+type Analyzer interface {
+	Analyze(ctx context.Context, req *runtime.RequestContext) controller.AnalysisReports
+}
+
+// Service serves the Envoy Rate Limit Service API.
+type Service struct {
+	rlsv3.UnimplementedRateLimitServiceServer
+	limits          map[string][]*limit // by domain
+	addressKey      string
+	userAgentKey    string
+	analyzer        Analyzer
+	instrumentation *metrics.Instrumentation
+	logger          *zap.Logger
+}
+
+// limit counts the descriptors matching its entries
+type limit struct {
+	name         string
+	domain       string
+	entries      []config.DescriptorEntryConfig
+	currentLimit *rlsv3.RateLimitResponse_RateLimit
+	store        rate_limit.Store
+}
+
+// NewService builds the limits of the configuration, counting requests until ctx is canceled.
+func NewService(ctx context.Context, cfg config.RateLimitServiceConfig, analyzer Analyzer, logger *zap.Logger) (*Service, error) {
+	service := &Service{
+		limits:       make(map[string][]*limit),
+		addressKey:   cfg.AddressKey,
+		userAgentKey: cfg.UserAgentKey,
+		analyzer:     analyzer,
+		logger:       logger,
+	}
+
+	for _, limitConfig := range cfg.Limits {
+		var settings rate_limit.LimitConfig
+		if err := controller.DecodeControllerSettings(limitConfig.Settings, &settings); err != nil {
+			return nil, fmt.Errorf("rate limit '%s': %w", limitConfig.Name, err)
+		}
+		settings.ApplyDefaults("ratelimit:" + limitConfig.Domain + ":" + limitConfig.Name + ":")
+		if err := settings.Validate(); err != nil {
+			return nil, fmt.Errorf("rate limit '%s': %w", limitConfig.Name, err)
+		}
+
+		store, err := rate_limit.NewStore(ctx, settings)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit '%s': %w", limitConfig.Name, err)
+		}
+
+		service.limits[limitConfig.Domain] = append(service.limits[limitConfig.Domain], &limit{
+			name:         limitConfig.Name,
+			domain:       limitConfig.Domain,
+			entries:      limitConfig.Descriptor,
+			currentLimit: currentLimit(limitConfig.Name, settings),
+			store:        store,
+		})
+		logger.Info("rate limit configured",
+			zap.String("name", limitConfig.Name),
+			zap.String("domain", limitConfig.Domain),
+			zap.String("algorithm", settings.Algorithm),
+			zap.Int("limit", settings.Limit),
+			zap.Duration("period", settings.GetPeriod()),
+			zap.String("store", settings.Store.Type),
+		)
+	}
+
+	return service, nil
+}
+
+// SetInstrumentation injects the shared metrics instrumentation.
+func (s *Service) SetInstrumentation(inst *metrics.Instrumentation) {
+	s.instrumentation = inst
+}
+
+// ShouldRateLimit counts every descriptor of the request against the limits
+// of its domain. The request is over limit when any descriptor is.
+func (s *Service) ShouldRateLimit(ctx context.Context, req *rlsv3.RateLimitRequest) (*rlsv3.RateLimitResponse, error) {
+	response := &rlsv3.RateLimitResponse{OverallCode: rlsv3.RateLimitResponse_OK}
+	limits := s.limits[req.GetDomain()]
+	analyses := make(map[string]map[string]string)
+
+	var retryAfter time.Duration
+	for _, descriptor := range req.GetDescriptors() {
+		status := &rlsv3.RateLimitResponse_DescriptorStatus{Code: rlsv3.RateLimitResponse_OK}
+		entries := descriptorEntries(descriptor)
+		extended := maps.Clone(entries)
+		cost := descriptorCost(req, descriptor)
+
+		var selected *rate_limit.Decision
+		for _, limit := range limits {
+			if !limit.applies(entries, s.addressKey, s.userAgentKey) {
+				continue
+			}
+			if limit.needsAnalysis(extended) {
+				s.addAnalysisEntries(ctx, extended, analyses)
+			}
+			key, ok := limit.key(extended)
+			if !ok {
+				continue
+			}
+
+			decision, err := limit.store.Take(ctx, key, cost)
+			if err != nil {
+				s.logger.Warn("rate limit store failed", zap.String("limit", limit.name), zap.String("key", key), zap.Error(err))
+				s.instrumentation.ObserveRateLimitDecision(limit.domain, limit.name, metrics.ERROR)
+				continue
+			}
+
+			if decision.Allowed {
+				s.instrumentation.ObserveRateLimitDecision(limit.domain, limit.name, metrics.OK)
+			} else {
+				s.instrumentation.ObserveRateLimitDecision(limit.domain, limit.name, metrics.OVER_LIMIT)
+				retryAfter = max(retryAfter, decision.RetryAfter)
+			}
+
+			// The status reports the most restrictive limit of the descriptor
+			if selected == nil || (selected.Allowed && !decision.Allowed) || (selected.Allowed == decision.Allowed && decision.Remaining < selected.Remaining) {
+				selected = &decision
+				status.CurrentLimit = limit.currentLimit
+				status.LimitRemaining = uint32(decision.Remaining)
+				status.DurationUntilReset = durationpb.New(decision.Reset)
+				if !decision.Allowed {
+					status.Code = rlsv3.RateLimitResponse_OVER_LIMIT
+				}
+			}
+		}
+
+		if status.Code == rlsv3.RateLimitResponse_OVER_LIMIT {
+			response.OverallCode = rlsv3.RateLimitResponse_OVER_LIMIT
+		}
+		response.Statuses = append(response.Statuses, status)
+	}
+
+	if response.OverallCode == rlsv3.RateLimitResponse_OVER_LIMIT {
+		response.ResponseHeadersToAdd = []*corev3.HeaderValue{{
+			Key:   "Retry-After",
+			Value: strconv.Itoa(max(int(math.Ceil(retryAfter.Seconds())), 1)),
+		}}
+	}
+
+	return response, nil
+}
+
+// addAnalysisEntries adds to entries those derived by the analysis controllers
+// from the client address and User-Agent of the descriptor. Entries sent by
+// Envoy take precedence. Analyses are shared by the descriptors of a request.
+func (s *Service) addAnalysisEntries(ctx context.Context, entries map[string]string, analyses map[string]map[string]string) {
+	address, ok := entries[s.addressKey]
+	if !ok {
+		return
+	}
+	userAgent := entries[s.userAgentKey]
+
+	cacheKey := address + "|" + userAgent
+	derived, ok := analyses[cacheKey]
+	if !ok {
+		derived = s.analyze(ctx, address, userAgent)
+		analyses[cacheKey] = derived
+	}
+
+	for key, value := range derived {
+		if _, exists := entries[key]; !exists {
+			entries[key] = value
+		}
+	}
+}
+
+// analyze runs the analysis controllers on a request from address with userAgent
+func (s *Service) analyze(ctx context.Context, address, userAgent string) map[string]string {
+	checkRequest := &authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{
+			Source: &authv3.AttributeContext_Peer{
+				Address: &corev3.Address{
+					Address: &corev3.Address_SocketAddress{
+						SocketAddress: &corev3.SocketAddress{Address: address},
+					},
+				},
+			},
+		},
+	}
+	if userAgent != "" {
+		checkRequest.Attributes.Request = &authv3.AttributeContext_Request{
+			Http: &authv3.AttributeContext_HttpRequest{Headers: map[string]string{"user-agent": userAgent}},
+		}
+	}
+
+	derived := make(map[string]string)
+	for _, report := range s.analyzer.Analyze(ctx, runtime.NewRequestContext(checkRequest)) {
+		if report == nil {
+			continue
+		}
+		switch report.ControllerKind {
+		case maxmind_geoip.ControllerKind:
+			if result := maxmind_geoip.GetIpLookupResultFromReport(report); result != nil && result.CountryISO != "" {
+				derived[EntryCountry] = result.CountryISO
+			}
+		case maxmind_asn.ControllerKind:
+			if result := maxmind_asn.GetIpLookupResultFromReport(report); result != nil {
+				derived[EntryASN] = strconv.FormatUint(uint64(result.AutonomousSystemNumber), 10)
+			}
+		case ua_detect.ControllerKind:
+			if result := ua_detect.GetUADetectionResultFromReport(report); result != nil && userAgent != "" {
+				derived[EntryBot] = strconv.FormatBool(result.Bot.Detected)
+			}
+		}
+	}
+	return derived
+}
+
+// applies reports whether the limit covers a descriptor: apart from the
+// analysis inputs, every entry sent by Envoy must be one of the limit entries,
+// so that a limit does not count twice the descriptors of one request
+// differing by entries it does not know about
+func (l *limit) applies(entries map[string]string, addressKey, userAgentKey string) bool {
+	for key := range entries {
+		if key == addressKey || key == userAgentKey {
+			continue
+		}
+		if !l.hasEntry(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// needsAnalysis reports whether some entry of the limit is missing from the descriptor
+func (l *limit) needsAnalysis(entries map[string]string) bool {
+	for _, entry := range l.entries {
+		if _, ok := entries[entry.Key]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// key returns the counter key of a descriptor, or false when the descriptor
+// lacks one of the limit entries or carries a value other than the configured one
+func (l *limit) key(entries map[string]string) (string, bool) {
+	parts := make([]string, 0, len(l.entries))
+	for _, entry := range l.entries {
+		value, ok := entries[entry.Key]
+		if !ok || (entry.Value != "" && entry.Value != value) {
+			return "", false
+		}
+		parts = append(parts, entry.Key+"="+value)
+	}
+	return strings.Join(parts, "|"), true
+}
+
+// hasEntry reports whether key is one of the limit entries
+func (l *limit) hasEntry(key string) bool {
+	for _, entry := range l.entries {
+		if entry.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// descriptorEntries indexes the entries of a descriptor by key
+func descriptorEntries(descriptor *ratelimitv3.RateLimitDescriptor) map[string]string {
+	entries := make(map[string]string, len(descriptor.GetEntries()))
+	for _, entry := range descriptor.GetEntries() {
+		entries[entry.GetKey()] = entry.GetValue()
+	}
+	return entries
+}
+
+// descriptorCost returns the hits a descriptor counts: its own hits_addend,
+// which is 0 to check the limits without counting, else the one of the
+// request, 1 when unset
+func descriptorCost(req *rlsv3.RateLimitRequest, descriptor *ratelimitv3.RateLimitDescriptor) int {
+	if addend := descriptor.GetHitsAddend(); addend != nil {
+		return int(min(addend.GetValue(), math.MaxInt32))
+	}
+	return int(min(max(uint64(req.GetHitsAddend()), 1), math.MaxInt32))
+}
+
+// currentLimit describes a limit to Envoy, expressing the period in one of
+// the units of the API when it is one
+func currentLimit(name string, settings rate_limit.LimitConfig) *rlsv3.RateLimitResponse_RateLimit {
+	units := map[time.Duration]rlsv3.RateLimitResponse_RateLimit_Unit{
+		time.Second:    rlsv3.RateLimitResponse_RateLimit_SECOND,
+		time.Minute:    rlsv3.RateLimitResponse_RateLimit_MINUTE,
+		time.Hour:      rlsv3.RateLimitResponse_RateLimit_HOUR,
+		24 * time.Hour: rlsv3.RateLimitResponse_RateLimit_DAY,
+	}
+	return &rlsv3.RateLimitResponse_RateLimit{
+		Name:            name,
+		RequestsPerUnit: uint32(settings.Limit),
+		Unit:            units[settings.GetPeriod()],
+	}
+}
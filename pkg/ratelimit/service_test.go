@@ -0,0 +1,260 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+
+	ratelimitv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/gtriggiano/envoy-authorization-service/pkg/analysis/maxmind_geoip"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/config"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/controller"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/match/rate_limit"
+	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
+)
+
+// stubAnalyzer reports a fixed country for every address
+type stubAnalyzer struct {
+	country string
+	calls   int
+}
+
+func (a *stubAnalyzer) Analyze(context.Context, *runtime.RequestContext) controller.AnalysisReports {
+	a.calls++
+	return controller.AnalysisReports{
+		"geo": {
+			Controller:     "geo",
+			ControllerKind: maxmind_geoip.ControllerKind,
+			Data:           map[string]any{"result": &maxmind_geoip.IpLookupResult{CountryISO: a.country}},
+		},
+	}
+}
+
+func buildService(t *testing.T, analyzer Analyzer, limits ...config.RateLimitConfig) *Service {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	service, err := NewService(ctx, config.RateLimitServiceConfig{
+		AddressKey:   "remote_address",
+		UserAgentKey: "user_agent",
+		Limits:       limits,
+	}, analyzer, zap.NewNop())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return service
+}
+
+func rateLimitRequest(domain string, descriptors ...map[string]string) *rlsv3.RateLimitRequest {
+	req := &rlsv3.RateLimitRequest{Domain: domain}
+	for _, entries := range descriptors {
+		descriptor := &ratelimitv3.RateLimitDescriptor{}
+		for key, value := range entries {
+			descriptor.Entries = append(descriptor.Entries, &ratelimitv3.RateLimitDescriptor_Entry{Key: key, Value: value})
+		}
+		req.Descriptors = append(req.Descriptors, descriptor)
+	}
+	return req
+}
+
+func shouldRateLimit(t *testing.T, service *Service, req *rlsv3.RateLimitRequest) *rlsv3.RateLimitResponse {
+	t.Helper()
+	response, err := service.ShouldRateLimit(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return response
+}
+
+func TestShouldRateLimitPerAddress(t *testing.T) {
+	service := buildService(t, &stubAnalyzer{}, config.RateLimitConfig{
+		Name:       "per-client",
+		Domain:     "edge",
+		Descriptor: []config.DescriptorEntryConfig{{Key: "remote_address"}},
+		Settings:   map[string]any{"limit": 2, "period": "1m"},
+	})
+	req := rateLimitRequest("edge", map[string]string{"remote_address": "203.0.113.10"})
+
+	for i := range 2 {
+		response := shouldRateLimit(t, service, req)
+		if response.OverallCode != rlsv3.RateLimitResponse_OK {
+			t.Fatalf("request %d: expected OK, got %s", i+1, response.OverallCode)
+		}
+		status := response.Statuses[0]
+		if status.CurrentLimit.GetName() != "per-client" || status.CurrentLimit.GetUnit() != rlsv3.RateLimitResponse_RateLimit_MINUTE {
+			t.Fatalf("unexpected current limit %v", status.CurrentLimit)
+		}
+		if status.LimitRemaining != uint32(1-i) {
+			t.Fatalf("request %d: expected %d remaining, got %d", i+1, 1-i, status.LimitRemaining)
+		}
+	}
+
+	response := shouldRateLimit(t, service, req)
+	if response.OverallCode != rlsv3.RateLimitResponse_OVER_LIMIT || response.Statuses[0].Code != rlsv3.RateLimitResponse_OVER_LIMIT {
+		t.Fatalf("expected OVER_LIMIT, got %v", response)
+	}
+	if len(response.ResponseHeadersToAdd) != 1 || response.ResponseHeadersToAdd[0].Key != "Retry-After" || response.ResponseHeadersToAdd[0].Value != "30" {
+		t.Fatalf("expected Retry-After of 30 seconds, got %v", response.ResponseHeadersToAdd)
+	}
+
+	other := shouldRateLimit(t, service, rateLimitRequest("edge", map[string]string{"remote_address": "203.0.113.11"}))
+	if other.OverallCode != rlsv3.RateLimitResponse_OK {
+		t.Fatalf("expected other clients to be counted separately, got %s", other.OverallCode)
+	}
+}
+
+func TestShouldRateLimitHitsAddend(t *testing.T) {
+	service := buildService(t, &stubAnalyzer{}, config.RateLimitConfig{
+		Name:       "per-client",
+		Domain:     "edge",
+		Descriptor: []config.DescriptorEntryConfig{{Key: "remote_address"}},
+		Settings:   map[string]any{"limit": 10, "period": "1m"},
+	})
+
+	req := rateLimitRequest("edge", map[string]string{"remote_address": "203.0.113.10"})
+	req.HitsAddend = 4
+	if response := shouldRateLimit(t, service, req); response.OverallCode != rlsv3.RateLimitResponse_OK || response.Statuses[0].LimitRemaining != 6 {
+		t.Fatalf("expected the request to count 4 hits, got %v", response)
+	}
+
+	// The hits of the descriptor take precedence over those of the request
+	req.Descriptors[0].HitsAddend = wrapperspb.UInt64(7)
+	if response := shouldRateLimit(t, service, req); response.OverallCode != rlsv3.RateLimitResponse_OVER_LIMIT {
+		t.Fatalf("expected 7 more hits to exceed the limit, got %v", response)
+	}
+	req.Descriptors[0].HitsAddend = nil
+	req.HitsAddend = 0
+	if response := shouldRateLimit(t, service, req); response.OverallCode != rlsv3.RateLimitResponse_OK || response.Statuses[0].LimitRemaining != 5 {
+		t.Fatalf("expected denied hits not to be counted and requests to count at least 1 hit, got %v", response)
+	}
+
+	// Descriptors of 0 hits check the limit without counting
+	req.Descriptors[0].HitsAddend = wrapperspb.UInt64(0)
+	for range 2 {
+		if response := shouldRateLimit(t, service, req); response.OverallCode != rlsv3.RateLimitResponse_OK || response.Statuses[0].LimitRemaining != 5 {
+			t.Fatalf("expected 0 hits not to be counted, got %v", response)
+		}
+	}
+}
+
+func TestShouldRateLimitDescriptorMatching(t *testing.T) {
+	service := buildService(t, &stubAnalyzer{}, config.RateLimitConfig{
+		Name:       "login",
+		Domain:     "edge",
+		Descriptor: []config.DescriptorEntryConfig{{Key: "path", Value: "/login"}, {Key: "remote_address"}},
+		Settings:   map[string]any{"limit": 1, "period": "1s"},
+	})
+
+	tests := []struct {
+		name    string
+		request *rlsv3.RateLimitRequest
+		limited bool
+	}{
+		{
+			name:    "configured value",
+			request: rateLimitRequest("edge", map[string]string{"path": "/login", "remote_address": "203.0.113.10"}),
+			limited: true,
+		},
+		{
+			name:    "other value",
+			request: rateLimitRequest("edge", map[string]string{"path": "/home", "remote_address": "203.0.113.10"}),
+		},
+		{
+			name:    "unknown entry",
+			request: rateLimitRequest("edge", map[string]string{"path": "/login", "remote_address": "203.0.113.10", "method": "POST"}),
+		},
+		{
+			name:    "missing entry",
+			request: rateLimitRequest("edge", map[string]string{"path": "/login"}),
+		},
+		{
+			name:    "other domain",
+			request: rateLimitRequest("internal", map[string]string{"path": "/login", "remote_address": "203.0.113.10"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shouldRateLimit(t, service, tt.request)
+			response := shouldRateLimit(t, service, tt.request)
+			if limited := response.OverallCode == rlsv3.RateLimitResponse_OVER_LIMIT; limited != tt.limited {
+				t.Fatalf("expected limited=%v, got %s", tt.limited, response.OverallCode)
+			}
+			if len(response.Statuses) != len(tt.request.Descriptors) {
+				t.Fatalf("expected one status per descriptor, got %d", len(response.Statuses))
+			}
+		})
+	}
+}
+
+func TestShouldRateLimitAnalysisEntries(t *testing.T) {
+	analyzer := &stubAnalyzer{country: "IT"}
+	service := buildService(t, analyzer,
+		config.RateLimitConfig{
+			Name:       "per-country",
+			Domain:     "edge",
+			Descriptor: []config.DescriptorEntryConfig{{Key: EntryCountry}},
+			Settings:   map[string]any{"limit": 1, "period": "1h"},
+		},
+		config.RateLimitConfig{
+			Name:       "foreign",
+			Domain:     "edge",
+			Descriptor: []config.DescriptorEntryConfig{{Key: EntryCountry, Value: "US"}},
+			Settings:   map[string]any{"limit": 1, "period": "1h"},
+		},
+	)
+
+	req := rateLimitRequest("edge",
+		map[string]string{"remote_address": "203.0.113.10"},
+		map[string]string{"remote_address": "203.0.113.10", "user_agent": ""},
+	)
+	response := shouldRateLimit(t, service, req)
+	if response.Statuses[0].Code != rlsv3.RateLimitResponse_OK || response.Statuses[1].Code != rlsv3.RateLimitResponse_OVER_LIMIT {
+		t.Fatalf("expected the second descriptor to exceed the country limit, got %v", response.Statuses)
+	}
+	if response.Statuses[1].CurrentLimit.GetUnit() != rlsv3.RateLimitResponse_RateLimit_HOUR {
+		t.Fatalf("expected hourly limit, got %v", response.Statuses[1].CurrentLimit)
+	}
+	if analyzer.calls != 1 {
+		t.Fatalf("expected one analysis per client, got %d", analyzer.calls)
+	}
+
+	// Entries sent by Envoy take precedence over the analysis
+	response = shouldRateLimit(t, service, rateLimitRequest("edge", map[string]string{"remote_address": "203.0.113.10", EntryCountry: "US"}))
+	if response.OverallCode != rlsv3.RateLimitResponse_OK || response.Statuses[0].CurrentLimit.GetName() != "per-country" {
+		t.Fatalf("expected the country sent by Envoy to be counted, got %v", response)
+	}
+}
+
+func TestNewServiceInvalidSettings(t *testing.T) {
+	_, err := NewService(context.Background(), config.RateLimitServiceConfig{
+		Limits: []config.RateLimitConfig{{
+			Name:       "broken",
+			Domain:     "edge",
+			Descriptor: []config.DescriptorEntryConfig{{Key: "remote_address"}},
+			Settings:   map[string]any{"limit": 0, "period": "1m"},
+		}},
+	}, &stubAnalyzer{}, zap.NewNop())
+	if err == nil || err.Error() != "rate limit 'broken': limit must be greater than 0" {
+		t.Fatalf("expected settings validation error, got %v", err)
+	}
+}
+
+func TestCurrentLimitUnits(t *testing.T) {
+	tests := map[string]rlsv3.RateLimitResponse_RateLimit_Unit{
+		"1s":  rlsv3.RateLimitResponse_RateLimit_SECOND,
+		"1m":  rlsv3.RateLimitResponse_RateLimit_MINUTE,
+		"1h":  rlsv3.RateLimitResponse_RateLimit_HOUR,
+		"24h": rlsv3.RateLimitResponse_RateLimit_DAY,
+		"10s": rlsv3.RateLimitResponse_RateLimit_UNKNOWN,
+	}
+	for period, unit := range tests {
+		if got := currentLimit("limit", rate_limit.LimitConfig{Limit: 1, Period: period}).Unit; got != unit {
+			t.Fatalf("period %s: expected unit %s, got %s", period, unit, got)
+		}
+	}
+}
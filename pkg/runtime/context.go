@@ -4,6 +4,7 @@
 package runtime
 
 import (
+	"net/http"
 	"net/netip"
 	"strings"
 	"sync"
@@ -82,6 +83,34 @@ func (r *RequestContext) LogFields() []zap.Field {
 	return out
 }
 
+// Header returns the value of a request header, with surrounding whitespace
+// trimmed. The name is matched case-insensitively.
+func (r *RequestContext) Header(name string) string {
+	return HeaderValue(r.Request.GetAttributes().GetRequest().GetHttp().GetHeaders(), name)
+}
+
+// Cookie returns the value of a request cookie, empty when missing.
+func (r *RequestContext) Cookie(name string) string {
+	cookies, _ := http.ParseCookie(r.Header("cookie"))
+	for _, cookie := range cookies {
+		if cookie.Name == name {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// HeaderValue returns the value of a header among headers, with surrounding
+// whitespace trimmed. The name is matched case-insensitively.
+func HeaderValue(headers map[string]string, name string) string {
+	for headerName, value := range headers {
+		if strings.EqualFold(headerName, name) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
 // Standard HTTP headers that may contain the client IP address.
 var ipAddressHeadersCandidates = []string{"x-client-ip", "x-forwarded-for", "cf-connecting-ip", "fastly-client-ip", "true-client-ip", "x-real-ip", "x-cluster-client-ip", "x-forwarded", "forwarded-for", "forwarded"}
 
@@ -209,3 +209,31 @@ func TestRequestAuthorityExtraction(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestHeaderAndCookie(t *testing.T) {
+	req := NewRequestContext(&authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{
+			Request: &authv3.AttributeContext_Request{
+				Http: &authv3.AttributeContext_HttpRequest{
+					Headers: map[string]string{
+						"user-agent": " curl/8.5.0 ",
+						"Cookie":     "session=abc; theme=dark",
+					},
+				},
+			},
+		},
+	})
+
+	if got := req.Header("User-Agent"); got != "curl/8.5.0" {
+		t.Fatalf("expected trimmed header matched case-insensitively, got %q", got)
+	}
+	if got := req.Header("x-api-key"); got != "" {
+		t.Fatalf("expected missing header to be empty, got %q", got)
+	}
+	if got := req.Cookie("theme"); got != "dark" {
+		t.Fatalf("expected cookie value, got %q", got)
+	}
+	if got := req.Cookie("missing"); got != "" {
+		t.Fatalf("expected missing cookie to be empty, got %q", got)
+	}
+}
@@ -0,0 +1,69 @@
+package runtime
+
+import (
+	"path"
+	"strings"
+)
+
+// Path returns the request path without the query string, normalized by
+// NormalizePath.
+func (r *RequestContext) Path() string {
+	requestPath, _, _ := strings.Cut(r.Request.GetAttributes().GetRequest().GetHttp().GetPath(), "?")
+	return NormalizePath(requestPath)
+}
+
+// NormalizePath decodes the percent-encoded unreserved characters, merges
+// slashes and resolves dot segments, so that '/%61dmin/', '//admin/' and
+// '/x/../admin/' all become '/admin/'. Other escapes, such as '%2F',
+// are kept with uppercase hex digits. Trailing slashes are kept.
+func NormalizePath(rawPath string) string {
+	if !strings.HasPrefix(rawPath, "/") {
+		return rawPath
+	}
+
+	var decoded strings.Builder
+	decoded.Grow(len(rawPath))
+	for i := 0; i < len(rawPath); i++ {
+		if rawPath[i] == '%' && i+2 < len(rawPath) && isHex(rawPath[i+1]) && isHex(rawPath[i+2]) {
+			char := unhex(rawPath[i+1])<<4 | unhex(rawPath[i+2])
+			if isUnreserved(char) {
+				decoded.WriteByte(char)
+			} else {
+				decoded.WriteString(strings.ToUpper(rawPath[i : i+3]))
+			}
+			i += 2
+			continue
+		}
+		decoded.WriteByte(rawPath[i])
+	}
+
+	decodedPath := decoded.String()
+	cleaned := path.Clean(decodedPath)
+	if cleaned != "/" && (strings.HasSuffix(decodedPath, "/") || strings.HasSuffix(decodedPath, "/.") || strings.HasSuffix(decodedPath, "/..")) {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// isUnreserved reports whether char is an unreserved character of RFC 3986
+func isUnreserved(char byte) bool {
+	return 'a' <= char && char <= 'z' || 'A' <= char && char <= 'Z' || '0' <= char && char <= '9' ||
+		char == '-' || char == '.' || char == '_' || char == '~'
+}
+
+// isHex reports whether char is a hexadecimal digit
+func isHex(char byte) bool {
+	return '0' <= char && char <= '9' || 'a' <= char && char <= 'f' || 'A' <= char && char <= 'F'
+}
+
+// unhex returns the value of a hexadecimal digit
+func unhex(char byte) byte {
+	switch {
+	case '0' <= char && char <= '9':
+		return char - '0'
+	case 'a' <= char && char <= 'f':
+		return char - 'a' + 10
+	default:
+		return char - 'A' + 10
+	}
+}
@@ -0,0 +1,31 @@
+package runtime
+
+import "testing"
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/admin/users", want: "/admin/users"},
+		{path: "/admin/", want: "/admin/"},
+		{path: "/%61dmin/", want: "/admin/"},
+		{path: "/%7Euser/%2d", want: "/~user/-"},
+		{path: "//admin//users", want: "/admin/users"},
+		{path: "/x/../admin/", want: "/admin/"},
+		{path: "/admin/./users/.", want: "/admin/users/"},
+		{path: "/admin/users/..", want: "/admin/"},
+		{path: "/../../admin", want: "/admin"},
+		{path: "/%2e%2e/admin", want: "/admin"},
+		{path: "/files/a%2fb", want: "/files/a%2Fb"},
+		{path: "/files/a%2Fb/../c", want: "/files/c"},
+		{path: "/search/%zz", want: "/search/%zz"},
+		{path: "/", want: "/"},
+		{path: "*", want: "*"},
+	}
+	for _, tt := range tests {
+		if got := NormalizePath(tt.path); got != tt.want {
+			t.Errorf("NormalizePath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Package secrets reads the signing secrets of the controllers from
+// environment variables and files, so that they never appear in the
+// configuration file. Several secrets are configured during rotations.
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// Config represents a secret read from an environment variable or from a file
+type Config struct {
+	Env  string `yaml:"env"`
+	File string `yaml:"file"`
+}
+
+// Validate checks that at least one secret is configured, each with either
+// env or file, and that the environment variables exist. Files are read by
+// Load.
+func Validate(secrets []Config) error {
+	if len(secrets) == 0 {
+		return fmt.Errorf("at least one secret is required")
+	}
+	for i, secret := range secrets {
+		if (secret.Env == "") == (secret.File == "") {
+			return fmt.Errorf("secrets[%d]: exactly one of env or file is required", i)
+		}
+		if secret.Env != "" {
+			if _, exists := os.LookupEnv(secret.Env); !exists {
+				return fmt.Errorf("secrets[%d]: environment variable '%s' not found", i, secret.Env)
+			}
+		}
+	}
+	return nil
+}
+
+// Load reads the secrets from the environment and from files, requiring them
+// to be at least minLength bytes long. Trailing line breaks of files are
+// ignored.
+func Load(secrets []Config, minLength int) ([][]byte, error) {
+	loaded := make([][]byte, 0, len(secrets))
+	for i, secret := range secrets {
+		var value []byte
+		if secret.Env != "" {
+			value = []byte(os.Getenv(secret.Env))
+		} else {
+			content, err := os.ReadFile(secret.File)
+			if err != nil {
+				return nil, fmt.Errorf("secrets[%d]: could not read file: %w", i, err)
+			}
+			value = bytes.TrimRight(content, "\r\n")
+		}
+		if len(value) == 0 {
+			return nil, fmt.Errorf("secrets[%d] is empty", i)
+		}
+		if len(value) < minLength {
+			return nil, fmt.Errorf("secrets[%d] must be at least %d bytes long", i, minLength)
+		}
+		loaded = append(loaded, value)
+	}
+	return loaded, nil
+}
@@ -0,0 +1,67 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	t.Setenv("SIGNING_SECRET", "secret")
+
+	tests := []struct {
+		name    string
+		secrets []Config
+		wantErr string
+	}{
+		{name: "env and file", secrets: []Config{{Env: "SIGNING_SECRET"}, {File: "/run/secrets/previous"}}},
+		{name: "no secrets", wantErr: "at least one secret is required"},
+		{name: "env with file", secrets: []Config{{Env: "SIGNING_SECRET", File: "/run/secrets/signing"}}, wantErr: "secrets[0]: exactly one of env or file is required"},
+		{name: "neither env nor file", secrets: []Config{{Env: "SIGNING_SECRET"}, {}}, wantErr: "secrets[1]: exactly one of env or file is required"},
+		{name: "undefined env", secrets: []Config{{Env: "UNDEFINED_SIGNING_SECRET"}}, wantErr: "secrets[0]: environment variable 'UNDEFINED_SIGNING_SECRET' not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.secrets)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected valid secrets, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "secret")
+	if err := os.WriteFile(secretFile, []byte(strings.Repeat("f", 32)+"\n"), 0o600); err != nil {
+		t.Fatalf("failed to write secret: %v", err)
+	}
+	t.Setenv("SIGNING_SECRET", strings.Repeat("e", 32))
+	t.Setenv("SHORT_SIGNING_SECRET", "short")
+	t.Setenv("EMPTY_SIGNING_SECRET", "")
+
+	secrets, err := Load([]Config{{Env: "SIGNING_SECRET"}, {File: secretFile}}, 32)
+	if err != nil {
+		t.Fatalf("failed to load secrets: %v", err)
+	}
+	if string(secrets[0]) != strings.Repeat("e", 32) || string(secrets[1]) != strings.Repeat("f", 32) {
+		t.Fatalf("expected trailing line break to be trimmed, got %q", secrets)
+	}
+
+	if _, err := Load([]Config{{Env: "SHORT_SIGNING_SECRET"}}, 32); err == nil || err.Error() != "secrets[0] must be at least 32 bytes long" {
+		t.Fatalf("expected short secret error, got %v", err)
+	}
+	if _, err := Load([]Config{{Env: "SHORT_SIGNING_SECRET"}, {Env: "EMPTY_SIGNING_SECRET"}}, 1); err == nil || err.Error() != "secrets[1] is empty" {
+		t.Fatalf("expected empty secret error, got %v", err)
+	}
+	if _, err := Load([]Config{{File: filepath.Join(dir, "missing")}}, 1); err == nil || !strings.Contains(err.Error(), "secrets[0]: could not read file") {
+		t.Fatalf("expected missing file error, got %v", err)
+	}
+}
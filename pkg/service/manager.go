@@ -23,10 +23,29 @@ import (
 	"github.com/gtriggiano/envoy-authorization-service/pkg/runtime"
 )
 
+const (
+	// decisionWorkers is the number of goroutines notifying the decision observers
+	decisionWorkers = 4
+	// decisionQueueSize bounds the decisions waiting for the observers, beyond
+	// which decisions are dropped rather than delaying the responses
+	decisionQueueSize = 4096
+)
+
+// observedDecision is a policy decision waiting to be notified to the observers
+type observedDecision struct {
+	ctx      context.Context
+	req      *runtime.RequestContext
+	reports  controller.AnalysisReports
+	decision controller.Decision
+}
+
 // Manager coordinates controllers through the Envoy authorization lifecycle.
 type Manager struct {
 	analysisControllers []controller.AnalysisController
 	matchControllers    []controller.MatchController
+	decisionObservers   []controller.DecisionObserver
+	decisions           chan observedDecision
+	observersDone       sync.WaitGroup
 	instrumentation     *metrics.Instrumentation
 	authorizationPolicy *policy.Policy
 	policyBypass        bool
@@ -42,22 +61,45 @@ func NewManager(
 	policyBypass bool,
 	logger *zap.Logger,
 ) *Manager {
+	var decisionObservers []controller.DecisionObserver
 	for _, matchController := range matchControllers {
 		if instrumented, ok := matchController.(interface {
 			SetInstrumentation(*metrics.Instrumentation)
 		}); ok {
 			instrumented.SetInstrumentation(instrumentation)
 		}
+		if observer, ok := matchController.(controller.DecisionObserver); ok {
+			decisionObservers = append(decisionObservers, observer)
+		}
 	}
 
-	return &Manager{
+	m := &Manager{
 		analysisControllers: analysisControllers,
 		matchControllers:    matchControllers,
+		decisionObservers:   decisionObservers,
 		instrumentation:     instrumentation,
 		authorizationPolicy: policy,
 		policyBypass:        policyBypass,
 		logger:              logger,
 	}
+	if len(decisionObservers) > 0 {
+		m.decisions = make(chan observedDecision, decisionQueueSize)
+		for range decisionWorkers {
+			m.observersDone.Add(1)
+			go m.observeDecisions()
+		}
+	}
+	return m
+}
+
+// Close notifies the queued decisions to the observers and stops the workers.
+// It must be called once no more requests are checked.
+func (m *Manager) Close() {
+	if m.decisions == nil {
+		return
+	}
+	close(m.decisions)
+	m.observersDone.Wait()
 }
 
 // Check executes analysis + match phases and evaluates the configured authorization policy.
@@ -83,6 +125,7 @@ func (m *Manager) Check(ctx context.Context, req *authv3.CheckRequest) (*authv3.
 			zap.String("controller_type", matchVerdict.ControllerType),
 			zap.String("controller_name", matchVerdict.Controller),
 		}
+		logFields = append(logFields, matchVerdict.LogFields...)
 		m.logger.Debug("match controller verdict", append(
 			logFields,
 			reqCtx.LogFields()...,
@@ -91,6 +134,11 @@ func (m *Manager) Check(ctx context.Context, req *authv3.CheckRequest) (*authv3.
 
 	// Evaluate policy
 	policyAllowed, denyVerdict := m.evaluatePolicy(matchVerdicts)
+	decision := controller.Decision{Allowed: policyAllowed}
+	if !policyAllowed {
+		decision.Culprit = denyVerdict
+	}
+	m.notifyDecision(ctx, reqCtx, analysisReports, decision)
 
 	logFields := append(
 		reqCtx.LogFields(),
@@ -108,6 +156,7 @@ func (m *Manager) Check(ctx context.Context, req *authv3.CheckRequest) (*authv3.
 			zap.String("culprit_controller_name", denyVerdict.Controller),
 			zap.String("culprit_description", denyVerdict.Description),
 		)
+		logFields = append(logFields, denyVerdict.LogFields...)
 		m.logger.Debug("POLICY DENY", logFields...)
 	} else {
 		// Log requests allowed by policy
@@ -130,11 +179,7 @@ func (m *Manager) Check(ctx context.Context, req *authv3.CheckRequest) (*authv3.
 	if !finalAllowed {
 		m.logger.Warn("DENY", logFields...)
 		m.instrumentation.ObserveDenyDecision(reqCtx.Authority, policyVerdict, countryISO, countryName, continent, culpritName, culpritKind, culpritVerdict, culpritResult, time.Since(start))
-		return m.denyResponse(
-			denyVerdict.DenyCode,
-			denyVerdict.DenyMessage,
-			sanitizedHeaders(denyVerdict.DenyDownstreamHeaders),
-		), nil
+		return m.denyResponse(denyVerdict), nil
 	} else {
 		if bypassed {
 			m.logger.Warn("BYPASS", logFields...)
@@ -156,6 +201,38 @@ func (m *Manager) Check(ctx context.Context, req *authv3.CheckRequest) (*authv3.
 	return m.okResponse(upstreamHeaders), nil
 }
 
+// Analyze runs the analysis phase alone, for the services deriving request
+// attributes from the analysis reports.
+func (m *Manager) Analyze(ctx context.Context, req *runtime.RequestContext) controller.AnalysisReports {
+	return m.runAnalysis(ctx, req)
+}
+
+// notifyDecision queues the policy decision for the controllers observing it,
+// so that observers writing to remote stores do not delay the response. The
+// decision is dropped when the queue is full.
+func (m *Manager) notifyDecision(ctx context.Context, req *runtime.RequestContext, reports controller.AnalysisReports, decision controller.Decision) {
+	if m.decisions == nil {
+		return
+	}
+	select {
+	case m.decisions <- observedDecision{ctx: context.WithoutCancel(ctx), req: req, reports: reports, decision: decision}:
+	default:
+		m.instrumentation.ObserveDroppedDecision()
+		m.logger.Warn("decision observers queue full, decision dropped", req.LogFields()...)
+	}
+}
+
+// observeDecisions notifies the queued decisions to the observers until the
+// queue is closed
+func (m *Manager) observeDecisions() {
+	defer m.observersDone.Done()
+	for observed := range m.decisions {
+		for _, observer := range m.decisionObservers {
+			observer.ObserveDecision(observed.ctx, observed.req, observed.reports, observed.decision)
+		}
+	}
+}
+
 // runAnalysis executes all analysis controllers concurrently and collects their
 // reports keyed by controller name.
 func (m *Manager) runAnalysis(ctx context.Context, req *runtime.RequestContext) controller.AnalysisReports {
@@ -343,19 +420,30 @@ func (m *Manager) okResponse(headers []*corev3.HeaderValueOption) *authv3.CheckR
 }
 
 // denyResponse wraps a denied authorization result with headers suitable for Envoy.
-func (m *Manager) denyResponse(code codes.Code, message string, headers []*corev3.HeaderValueOption) *authv3.CheckResponse {
-	sanitizedCode := code
+// The verdict can override the HTTP status and body of the response, such as
+// to redirect clients.
+func (m *Manager) denyResponse(verdict *controller.MatchVerdict) *authv3.CheckResponse {
+	sanitizedCode := verdict.DenyCode
 	if sanitizedCode == codes.OK {
 		sanitizedCode = codes.PermissionDenied
 	}
 
+	httpStatus := codeToHTTP(sanitizedCode)
+	if verdict.DenyHTTPStatus != 0 {
+		httpStatus = typev3.StatusCode(verdict.DenyHTTPStatus)
+	}
+	body := verdict.DenyMessage
+	if verdict.DenyBody != "" {
+		body = verdict.DenyBody
+	}
+
 	return &authv3.CheckResponse{
-		Status: status.New(sanitizedCode, message).Proto(),
+		Status: status.New(sanitizedCode, verdict.DenyMessage).Proto(),
 		HttpResponse: &authv3.CheckResponse_DeniedResponse{
 			DeniedResponse: &authv3.DeniedHttpResponse{
-				Status:  &typev3.HttpStatus{Code: codeToHTTP(sanitizedCode)},
-				Body:    message,
-				Headers: headers,
+				Status:  &typev3.HttpStatus{Code: httpStatus},
+				Body:    body,
+				Headers: sanitizedHeaders(verdict.DenyDownstreamHeaders),
 			},
 		},
 	}
@@ -368,6 +456,8 @@ func codeToHTTP(code codes.Code) typev3.StatusCode {
 		return typev3.StatusCode_OK
 	case codes.Unauthenticated:
 		return typev3.StatusCode_Unauthorized
+	case codes.ResourceExhausted:
+		return typev3.StatusCode_TooManyRequests
 	default:
 		return typev3.StatusCode_Forbidden
 	}
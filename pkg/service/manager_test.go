@@ -72,6 +72,16 @@ func (s stubMatchController) Match(ctx context.Context, _ *runtime.RequestContex
 }
 func (s stubMatchController) HealthCheck(context.Context) error { return nil }
 
+// observingMatchController records the decisions it observes.
+type observingMatchController struct {
+	stubMatchController
+	decisions *[]controller.Decision
+}
+
+func (o observingMatchController) ObserveDecision(_ context.Context, _ *runtime.RequestContext, _ controller.AnalysisReports, decision controller.Decision) {
+	*o.decisions = append(*o.decisions, decision)
+}
+
 func minimalCheckRequestUnit(ip string) *authv3.CheckRequest {
 	return &authv3.CheckRequest{
 		Attributes: &authv3.AttributeContext{
@@ -228,6 +238,9 @@ func TestCodeToHTTPMapping(t *testing.T) {
 	if got := codeToHTTP(codes.Unauthenticated); got != typev3.StatusCode_Unauthorized {
 		t.Fatalf("expected Unauthorized, got %v", got)
 	}
+	if got := codeToHTTP(codes.ResourceExhausted); got != typev3.StatusCode_TooManyRequests {
+		t.Fatalf("expected TooManyRequests, got %v", got)
+	}
 	if got := codeToHTTP(codes.PermissionDenied); got != typev3.StatusCode_Forbidden {
 		t.Fatalf("expected Forbidden fallback, got %v", got)
 	}
@@ -320,6 +333,50 @@ func TestManagerCheckDeniesViaPolicy(t *testing.T) {
 	}
 }
 
+func TestManagerCheckDenyResponseOverrides(t *testing.T) {
+	pol, err := policy.Parse("auth-one", []string{"auth-one"})
+	if err != nil {
+		t.Fatalf("policy parse failed: %v", err)
+	}
+
+	logger := zaptest.NewLogger(t)
+	inst := metrics.NewInstrumentation(prometheus.NewRegistry(), metrics.TrackOptions{TrackCountry: false, TrackGeofence: true})
+	mgr := &Manager{
+		matchControllers: []controller.MatchController{
+			stubMatchController{
+				name: "auth-one",
+				kind: "auth",
+				verdict: &controller.MatchVerdict{
+					DenyCode:              codes.PermissionDenied,
+					DenyMessage:           "challenge required",
+					DenyHTTPStatus:        302,
+					DenyBody:              "<html></html>",
+					DenyDownstreamHeaders: map[string]string{"Location": "/challenge"},
+					Description:           "challenge cookie missing",
+				},
+			},
+		},
+		instrumentation:     inst,
+		authorizationPolicy: pol,
+		logger:              logger,
+	}
+
+	resp, err := mgr.Check(context.Background(), minimalCheckRequestUnit("198.51.100.99"))
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	denied := resp.GetDeniedResponse()
+	if denied.GetStatus().GetCode() != typev3.StatusCode_Found {
+		t.Fatalf("unexpected HTTP status: %v", denied.GetStatus().GetCode())
+	}
+	if denied.GetBody() != "<html></html>" || len(denied.GetHeaders()) != 1 {
+		t.Fatalf("unexpected denied response: %v", denied)
+	}
+	if resp.GetStatus().GetCode() != int32(codes.PermissionDenied) || resp.GetStatus().GetMessage() != "challenge required" {
+		t.Fatalf("unexpected gRPC status: %v", resp.GetStatus())
+	}
+}
+
 func TestManagerCheckPolicyBypassReturnsOK(t *testing.T) {
 	pol, err := policy.Parse("auth-one", []string{"auth-one"})
 	if err != nil {
@@ -355,6 +412,48 @@ func TestManagerCheckPolicyBypassReturnsOK(t *testing.T) {
 	}
 }
 
+func TestManagerCheckNotifiesDecisionObservers(t *testing.T) {
+	pol, err := policy.Parse("auth-one", []string{"auth-one"})
+	if err != nil {
+		t.Fatalf("policy parse failed: %v", err)
+	}
+
+	var decisions []controller.Decision
+	matchControllers := []controller.MatchController{
+		observingMatchController{
+			stubMatchController: stubMatchController{
+				name:    "auth-one",
+				kind:    "auth",
+				verdict: &controller.MatchVerdict{Controller: "auth-one", Description: "blocked", IsMatch: false},
+			},
+			decisions: &decisions,
+		},
+	}
+	inst := metrics.NewInstrumentation(prometheus.NewRegistry(), metrics.TrackOptions{TrackCountry: false, TrackGeofence: true})
+
+	// Observers learn the policy decision, also when bypassed
+	mgr := NewManager(nil, matchControllers, inst, pol, true, zaptest.NewLogger(t))
+	if _, err := mgr.Check(context.Background(), minimalCheckRequestUnit("198.51.100.99")); err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	mgr.Close()
+	mgr = NewManager(nil, matchControllers, inst, nil, false, zaptest.NewLogger(t))
+	if _, err := mgr.Check(context.Background(), minimalCheckRequestUnit("198.51.100.99")); err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	mgr.Close()
+
+	if len(decisions) != 2 {
+		t.Fatalf("expected 2 observed decisions, got %d", len(decisions))
+	}
+	if decisions[0].Allowed || decisions[0].Culprit == nil || decisions[0].Culprit.Controller != "auth-one" {
+		t.Fatalf("expected a denial by auth-one, got %+v", decisions[0])
+	}
+	if !decisions[1].Allowed || decisions[1].Culprit != nil {
+		t.Fatalf("expected an allow without culprit, got %+v", decisions[1])
+	}
+}
+
 func TestRequestMetricsIncludeGeoAndPolicyVerdict(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	inst := metrics.NewInstrumentation(prometheus.NewRegistry(), metrics.TrackOptions{TrackCountry: false, TrackGeofence: true})
@@ -465,7 +564,7 @@ func TestServerStartFailsOnBadAddress(t *testing.T) {
 		logger:          logger,
 	}
 
-	srv, err := NewServer(config.ServerConfig{Address: "bad::addr"}, mgr, logger)
+	srv, err := NewServer(config.ServerConfig{Address: "bad::addr"}, mgr, nil, logger)
 	if err != nil {
 		t.Fatalf("unexpected error constructing server: %v", err)
 	}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -31,8 +32,9 @@ type Server struct {
 	logger     *zap.Logger
 }
 
-// NewServer constructs the gRPC server and registers handlers.
-func NewServer(cfg config.ServerConfig, manager *Manager, logger *zap.Logger) (*Server, error) {
+// NewServer constructs the gRPC server and registers handlers. The Envoy Rate
+// Limit Service API is served as well when rateLimitService is not nil.
+func NewServer(cfg config.ServerConfig, manager *Manager, rateLimitService rlsv3.RateLimitServiceServer, logger *zap.Logger) (*Server, error) {
 	opts := []grpc.ServerOption{}
 	if cfg.TLS != nil {
 		tlsConfig, err := buildTLSConfig(cfg)
@@ -46,6 +48,9 @@ func NewServer(cfg config.ServerConfig, manager *Manager, logger *zap.Logger) (*
 	reflection.Register(grpcServer)
 	authv3Server := &authorizationService{manager: manager, logger: logger}
 	registerService(grpcServer, authv3Server)
+	if rateLimitService != nil {
+		rlsv3.RegisterRateLimitServiceServer(grpcServer, rateLimitService)
+	}
 
 	return &Server{cfg: cfg, manager: manager, grpcServer: grpcServer, logger: logger}, nil
 }